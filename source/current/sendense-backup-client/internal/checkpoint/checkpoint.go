@@ -0,0 +1,358 @@
+// Package checkpoint persists the disk ranges a job has already copied so an
+// interrupted transfer can be retried without starting over.
+//
+// Checkpoints live in <dir>/<job_id>.json on the SNA, next to the VMware snapshot
+// reference and the change IDs the copy was taken against. A retried job reuses the
+// kept snapshot and skips ranges that were flushed to the target before the
+// interruption; a checkpoint whose snapshot, change ID or target no longer matches is
+// discarded and the disk is copied in full.
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultDir is where the backup client keeps transfer checkpoints
+	DefaultDir = "/var/lib/sendense/checkpoints"
+
+	// MaxAge is how long a checkpoint (and the snapshot it keeps) stays resumable
+	MaxAge = 24 * time.Hour
+
+	ModeFull        = "full"
+	ModeIncremental = "incremental"
+
+	// saveInterval limits how often completed ranges are flushed and written
+	saveInterval = 5 * time.Second
+)
+
+// State is the checkpoint of one job
+type State struct {
+	JobID                string          `json:"job_id"`
+	VMRef                string          `json:"vm_ref"`
+	SnapshotRef          string          `json:"snapshot_ref"`
+	SnapshotName         string          `json:"snapshot_name"`
+	ConsistencyRequested string          `json:"consistency_requested,omitempty"`
+	ConsistencyAchieved  string          `json:"consistency_achieved,omitempty"`
+	ConsistencyFallback  string          `json:"consistency_fallback,omitempty"`
+	Disks                map[int32]*Disk `json:"disks"`
+	CreatedAt            time.Time       `json:"created_at"`
+	UpdatedAt            time.Time       `json:"updated_at"`
+}
+
+// Disk is the checkpoint of one disk transfer
+type Disk struct {
+	Mode         string `json:"mode"`                     // "full" or "incremental"
+	TargetExport string `json:"target_export"`            // NBD export name the ranges were written to
+	ChangeID     string `json:"change_id"`                // Snapshot change ID being copied
+	BaseChangeID string `json:"base_change_id,omitempty"` // Change ID the incremental is taken against
+	Completed    Ranges `json:"completed"`
+}
+
+// Store holds the checkpoint of one job; a nil store records nothing
+type Store struct {
+	dir   string
+	jobID string
+
+	mu    sync.Mutex
+	state *State
+}
+
+// Open loads the checkpoint of a job, dropping it once it is older than MaxAge
+func Open(dir, jobID string) *Store {
+	s := &Store{dir: dir, jobID: jobID}
+
+	state, err := load(Path(dir, jobID))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		log.WithError(err).WithField("job_id", jobID).Warn("⚠️ Ignoring unreadable transfer checkpoint")
+	case time.Since(state.UpdatedAt) > MaxAge:
+		log.WithField("job_id", jobID).Info("Transfer checkpoint expired - starting over")
+	default:
+		s.state = state
+	}
+	return s
+}
+
+// Path is the checkpoint file of a job
+func Path(dir, jobID string) string {
+	return filepath.Join(dir, jobID+".json")
+}
+
+// Stale returns the checkpoints of other jobs for the same VM, and expired ones;
+// their snapshots will never be resumed and should be removed
+func Stale(dir, vmRef, exceptJobID string) []*State {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var stale []*State
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		state, err := load(filepath.Join(dir, entry.Name()))
+		if err != nil || state.JobID == exceptJobID {
+			continue
+		}
+		if state.VMRef == vmRef || time.Since(state.UpdatedAt) > MaxAge {
+			stale = append(stale, state)
+		}
+	}
+	return stale
+}
+
+// Remove deletes the checkpoint of a job
+func Remove(dir, jobID string) error {
+	err := os.Remove(Path(dir, jobID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Resumable returns the checkpoint if it belongs to the VM, or nil
+func (s *Store) Resumable(vmRef string) *State {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state == nil || s.state.VMRef != vmRef || s.state.SnapshotRef == "" {
+		return nil
+	}
+	return s.state
+}
+
+// Begin records the snapshot the job copies from; disk checkpoints taken against
+// another snapshot are dropped
+func (s *Store) Begin(state State) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state != nil && s.state.SnapshotRef == state.SnapshotRef && s.state.VMRef == state.VMRef {
+		state.Disks = s.state.Disks
+		state.CreatedAt = s.state.CreatedAt
+	}
+	if state.Disks == nil {
+		state.Disks = make(map[int32]*Disk)
+	}
+	if state.CreatedAt.IsZero() {
+		state.CreatedAt = time.Now().UTC()
+	}
+	state.JobID = s.jobID
+	s.state = &state
+	s.saveLocked()
+}
+
+// Disk returns the tracker of a disk transfer, keeping completed ranges only if
+// they were copied in the same mode, to the same export and from the same change IDs
+func (s *Store) Disk(key int32, mode, targetPath, changeID, baseChangeID string) *Tracker {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state == nil {
+		return nil
+	}
+
+	want := Disk{
+		Mode:         mode,
+		TargetExport: exportName(targetPath),
+		ChangeID:     changeID,
+		BaseChangeID: baseChangeID,
+	}
+	disk, exists := s.state.Disks[key]
+	if !exists || disk.Mode != want.Mode || disk.TargetExport != want.TargetExport ||
+		disk.ChangeID != want.ChangeID || disk.BaseChangeID != want.BaseChangeID {
+		if exists {
+			log.WithField("disk_key", key).Info("Disk checkpoint does not match this transfer - copying in full")
+		}
+		disk = &want
+		s.state.Disks[key] = disk
+		s.saveLocked()
+	}
+
+	return &Tracker{
+		store:     s,
+		disk:      disk,
+		key:       key,
+		completed: append(Ranges(nil), disk.Completed...),
+	}
+}
+
+// HasProgress reports whether any disk has completed ranges worth resuming
+func (s *Store) HasProgress() bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state == nil {
+		return false
+	}
+	for _, disk := range s.state.Disks {
+		if len(disk.Completed) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Discard removes the checkpoint once the job no longer needs it
+func (s *Store) Discard() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = nil
+	if err := Remove(s.dir, s.jobID); err != nil {
+		log.WithError(err).Warn("Failed to remove transfer checkpoint")
+	}
+}
+
+// saveLocked writes the checkpoint file (caller holds s.mu)
+func (s *Store) saveLocked() {
+	if s.state == nil {
+		return
+	}
+	s.state.UpdatedAt = time.Now().UTC()
+
+	data, err := json.Marshal(s.state)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		log.WithError(err).Warn("Failed to create checkpoint directory")
+		return
+	}
+	path := Path(s.dir, s.jobID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		log.WithError(err).Warn("Failed to write transfer checkpoint")
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		log.WithError(err).Warn("Failed to write transfer checkpoint")
+	}
+}
+
+// Tracker records the completed ranges of one disk transfer; a nil tracker records nothing
+type Tracker struct {
+	store *Store
+	disk  *Disk // Ranges known to be durable on the target (what the file holds)
+	key   int32
+
+	mu        sync.Mutex
+	completed Ranges // Ranges written to the target, durable or not
+
+	flush     func() error
+	saving    sync.Mutex
+	lastSaved time.Time
+}
+
+// SetFlush sets the target flush run before ranges are written to the checkpoint,
+// so a range is never recorded before the target has made it durable
+func (t *Tracker) SetFlush(flush func() error) {
+	if t == nil {
+		return
+	}
+	t.flush = flush
+}
+
+// Completed returns the ranges already copied
+func (t *Tracker) Completed() Ranges {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append(Ranges(nil), t.completed...)
+}
+
+// MarkCompleted records a copied range; the checkpoint is saved every few seconds
+func (t *Tracker) MarkCompleted(start, length int64) {
+	if t == nil || length <= 0 {
+		return
+	}
+
+	t.mu.Lock()
+	t.completed = t.completed.Add(Range{Start: start, Length: length})
+	due := time.Since(t.lastSaved) >= saveInterval
+	t.mu.Unlock()
+
+	if due {
+		t.Save()
+	}
+}
+
+// Save flushes the target and records the ranges completed before the flush
+func (t *Tracker) Save() {
+	if t == nil || !t.saving.TryLock() {
+		return // Another worker is saving
+	}
+	defer t.saving.Unlock()
+
+	t.mu.Lock()
+	completed := append(Ranges(nil), t.completed...)
+	t.lastSaved = time.Now()
+	t.mu.Unlock()
+
+	if t.flush != nil {
+		if err := t.flush(); err != nil {
+			log.WithError(err).WithField("disk_key", t.key).Warn("⚠️ Target flush failed - checkpoint not updated")
+			return
+		}
+	}
+
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+	t.disk.Completed = completed
+	t.store.saveLocked()
+}
+
+func load(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	if state.Disks == nil {
+		state.Disks = make(map[int32]*Disk)
+	}
+	return &state, nil
+}
+
+// exportName strips host and port from an NBD URL - the SHA may hand a retried
+// job the same export on a different port
+func exportName(targetPath string) string {
+	if u, err := url.Parse(targetPath); err == nil && u.Scheme == "nbd" {
+		return strings.TrimPrefix(u.Path, "/")
+	}
+	return targetPath
+}
@@ -0,0 +1,115 @@
+package checkpoint
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRangesMergeAndRemaining(t *testing.T) {
+	var rs Ranges
+	rs = rs.Add(Range{Start: 64, Length: 32})
+	rs = rs.Add(Range{Start: 0, Length: 32})
+	rs = rs.Add(Range{Start: 32, Length: 16}) // Adjacent to the first range
+	rs = rs.Add(Range{Start: 70, Length: 10}) // Inside an existing range
+
+	assert.Equal(t, Ranges{{Start: 0, Length: 48}, {Start: 64, Length: 32}}, rs)
+	assert.Equal(t, int64(80), rs.Total())
+
+	assert.True(t, rs.Covers(8, 16))
+	assert.False(t, rs.Covers(40, 16))
+
+	assert.Equal(t, []Range{{Start: 48, Length: 16}, {Start: 96, Length: 32}}, rs.Remaining(0, 128))
+	assert.Empty(t, rs.Remaining(64, 32))
+	assert.Equal(t, []Range{{Start: 200, Length: 50}}, rs.Remaining(200, 50))
+}
+
+func TestResumeKeepsOnlyDurableRangesForMatchingTransfer(t *testing.T) {
+	dir := t.TempDir()
+	store := Open(dir, "backup-test-1")
+	store.Begin(State{VMRef: "vm-42", SnapshotRef: "snapshot-7"})
+
+	flushes := 0
+	tracker := store.Disk(2000, ModeFull, "nbd://10.0.0.1:10105/disk0", "52 de/3", "")
+	tracker.SetFlush(func() error { flushes++; return nil })
+	tracker.MarkCompleted(0, 1024)
+	tracker.MarkCompleted(1024, 1024)
+	tracker.Save()
+	assert.Greater(t, flushes, 0)
+
+	// Written but never flushed - must not be resumed
+	tracker.SetFlush(func() error { return errors.New("target gone") })
+	tracker.MarkCompleted(4096, 1024)
+	tracker.Save()
+
+	// A retry may get the same export on another port
+	retry := Open(dir, "backup-test-1")
+	state := retry.Resumable("vm-42")
+	require.NotNil(t, state)
+	assert.Equal(t, "snapshot-7", state.SnapshotRef)
+	assert.Nil(t, retry.Resumable("vm-43"))
+
+	resumed := retry.Disk(2000, ModeFull, "nbd://10.0.0.1:10110/disk0", "52 de/3", "")
+	assert.Equal(t, Ranges{{Start: 0, Length: 2048}}, resumed.Completed())
+
+	// A different change ID means a different snapshot - start over
+	other := retry.Disk(2000, ModeFull, "nbd://10.0.0.1:10110/disk0", "52 de/4", "")
+	assert.Empty(t, other.Completed())
+}
+
+func TestBeginWithNewSnapshotDropsDisks(t *testing.T) {
+	dir := t.TempDir()
+	store := Open(dir, "backup-test-2")
+	store.Begin(State{VMRef: "vm-42", SnapshotRef: "snapshot-7"})
+	tracker := store.Disk(2000, ModeIncremental, "disk0", "52 de/5", "52 de/3")
+	tracker.MarkCompleted(0, 512)
+	tracker.Save()
+	assert.True(t, store.HasProgress())
+
+	store.Begin(State{VMRef: "vm-42", SnapshotRef: "snapshot-8"})
+	assert.False(t, store.HasProgress())
+
+	store.Discard()
+	_, err := os.Stat(Path(dir, "backup-test-2"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestStaleFindsOtherJobsOfTheSameVM(t *testing.T) {
+	dir := t.TempDir()
+	Open(dir, "backup-old").Begin(State{VMRef: "vm-42", SnapshotRef: "snapshot-1"})
+	Open(dir, "backup-other-vm").Begin(State{VMRef: "vm-99", SnapshotRef: "snapshot-2"})
+	Open(dir, "backup-new").Begin(State{VMRef: "vm-42", SnapshotRef: "snapshot-3"})
+
+	stale := Stale(dir, "vm-42", "backup-new")
+	require.Len(t, stale, 1)
+	assert.Equal(t, "backup-old", stale[0].JobID)
+	assert.WithinDuration(t, time.Now(), stale[0].UpdatedAt, time.Minute)
+}
+
+func TestNilStoreIsInert(t *testing.T) {
+	var store *Store
+	assert.Nil(t, store.Resumable("vm-42"))
+	assert.False(t, store.HasProgress())
+
+	tracker := store.Disk(2000, ModeFull, "disk0", "", "")
+	tracker.MarkCompleted(0, 512)
+	assert.Empty(t, tracker.Completed())
+}
+
+func TestRangesAddBridgesSeveralRanges(t *testing.T) {
+	rs := Ranges{{Start: 0, Length: 8}, {Start: 16, Length: 8}, {Start: 32, Length: 8}, {Start: 64, Length: 8}}
+
+	rs = rs.Add(Range{Start: 4, Length: 30})
+	assert.Equal(t, Ranges{{Start: 0, Length: 40}, {Start: 64, Length: 8}}, rs)
+
+	rs = rs.Add(Range{Start: 48, Length: 4})
+	assert.Equal(t, Ranges{{Start: 0, Length: 40}, {Start: 48, Length: 4}, {Start: 64, Length: 8}}, rs)
+
+	rs = rs.Add(Range{Start: 100, Length: 4})
+	rs = rs.Add(Range{Start: 72, Length: 28}) // Touches both neighbours
+	assert.Equal(t, Ranges{{Start: 0, Length: 40}, {Start: 48, Length: 4}, {Start: 64, Length: 40}}, rs)
+}
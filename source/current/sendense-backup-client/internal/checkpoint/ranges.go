@@ -0,0 +1,91 @@
+package checkpoint
+
+import "sort"
+
+// Range is a byte range of a disk
+type Range struct {
+	Start  int64 `json:"start"`
+	Length int64 `json:"length"`
+}
+
+// End returns the first offset after the range
+func (r Range) End() int64 {
+	return r.Start + r.Length
+}
+
+// Ranges is a sorted list of non-overlapping, non-adjacent ranges
+type Ranges []Range
+
+// Add returns the ranges with r merged in. The backing array is reused, so
+// callers must keep the result and drop the receiver.
+func (rs Ranges) Add(r Range) Ranges {
+	if r.Length <= 0 {
+		return rs
+	}
+
+	// Ranges [i, j) overlap or touch r and collapse into one
+	i := sort.Search(len(rs), func(k int) bool { return rs[k].End() >= r.Start })
+	j := i + sort.Search(len(rs)-i, func(k int) bool { return rs[i+k].Start > r.End() })
+
+	if i == j {
+		// Nothing to merge with; shift the tail up by one
+		rs = append(rs, Range{})
+		copy(rs[i+1:], rs[i:])
+		rs[i] = r
+		return rs
+	}
+
+	start, end := r.Start, r.End()
+	if rs[i].Start < start {
+		start = rs[i].Start
+	}
+	if last := rs[j-1].End(); last > end {
+		end = last
+	}
+	rs[i] = Range{Start: start, Length: end - start}
+	return append(rs[:i+1], rs[j:]...)
+}
+
+// Covers reports whether [start, start+length) was completed
+func (rs Ranges) Covers(start, length int64) bool {
+	for _, r := range rs {
+		if r.Start <= start && start+length <= r.End() {
+			return true
+		}
+	}
+	return false
+}
+
+// Remaining returns the parts of [start, start+length) not yet completed
+func (rs Ranges) Remaining(start, length int64) []Range {
+	var remaining []Range
+	cursor, end := start, start+length
+	for _, r := range rs {
+		if r.End() <= cursor {
+			continue
+		}
+		if r.Start >= end {
+			break
+		}
+		if r.Start > cursor {
+			remaining = append(remaining, Range{Start: cursor, Length: r.Start - cursor})
+		}
+		cursor = r.End()
+		if cursor >= end {
+			return remaining
+		}
+	}
+	if cursor < end {
+		remaining = append(remaining, Range{Start: cursor, Length: end - cursor})
+	}
+	return remaining
+}
+
+// Total returns the number of bytes completed
+func (rs Ranges) Total() int64 {
+	var total int64
+	for _, r := range rs {
+		total += r.Length
+	}
+	return total
+}
@@ -0,0 +1,215 @@
+// Package credentials resolves the vCenter password of the backup client without it
+// having to appear on the command line.
+//
+// The password can come from an inherited file descriptor (the SNA passes a pipe as
+// fd 3; fd 0 reads stdin), from a private JSON file that is removed after loading, or
+// from a credential reference: a SHA credential ID plus a single-use token that the
+// client redeems with the SHA over the tunnel. Every source has an environment
+// variable equivalent; flags take precedence over the environment. A plaintext
+// --vmware-password is still accepted for manual runs.
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Environment variables read when the matching flag is not set
+const (
+	EnvPassword        = "VMWARE_PASSWORD"
+	EnvPasswordFD      = "VMWARE_PASSWORD_FD"
+	EnvCredentialsFile = "VMWARE_CREDENTIALS_FILE"
+	EnvCredentialRef   = "VMWARE_CREDENTIAL_REF"
+	EnvToken           = "VMWARE_CREDENTIAL_TOKEN"
+	EnvTokenFD         = "VMWARE_CREDENTIAL_TOKEN_FD"
+)
+
+// maxSecretSize bounds what is read from a descriptor or credentials file
+const maxSecretSize = 64 * 1024
+
+// Source describes where the vCenter password comes from (FD fields use -1 for unset)
+type Source struct {
+	Password        string // Plaintext password (visible in ps - manual runs only)
+	PasswordFD      int    // Inherited descriptor holding the password
+	CredentialsFile string // JSON file with a password or a credential reference (removed after loading)
+	CredentialRef   int    // SHA credential ID redeemed with a one-time token
+	Token           string // One-time token for CredentialRef
+	TokenFD         int    // Inherited descriptor holding the token
+	SHAURL          string // SHA API base URL for credential references
+}
+
+// fileCredentials is the format of a credentials file
+type fileCredentials struct {
+	Password     string `json:"password,omitempty"`
+	CredentialID int    `json:"credential_id,omitempty"`
+	Token        string `json:"token,omitempty"`
+}
+
+// ApplyEnv fills fields not set by flags from the environment
+func (s *Source) ApplyEnv() error {
+	if s.Password == "" {
+		s.Password = os.Getenv(EnvPassword)
+	}
+	if s.CredentialsFile == "" {
+		s.CredentialsFile = os.Getenv(EnvCredentialsFile)
+	}
+	if s.Token == "" {
+		s.Token = os.Getenv(EnvToken)
+	}
+	for _, field := range []struct {
+		value *int
+		env   string
+		unset int
+	}{
+		{&s.PasswordFD, EnvPasswordFD, -1},
+		{&s.TokenFD, EnvTokenFD, -1},
+		{&s.CredentialRef, EnvCredentialRef, 0},
+	} {
+		raw := os.Getenv(field.env)
+		if *field.value != field.unset || raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", field.env, raw, err)
+		}
+		*field.value = value
+	}
+	return nil
+}
+
+// Resolve returns the vCenter password from the first configured source
+func (s *Source) Resolve(ctx context.Context) (string, error) {
+	switch {
+	case s.PasswordFD >= 0:
+		return readFD(s.PasswordFD, "password")
+	case s.CredentialsFile != "":
+		return s.resolveFile(ctx)
+	case s.CredentialRef > 0:
+		token := s.Token
+		if s.TokenFD >= 0 {
+			var err error
+			if token, err = readFD(s.TokenFD, "credential token"); err != nil {
+				return "", err
+			}
+		}
+		return s.redeem(ctx, s.CredentialRef, token)
+	case s.Password != "":
+		log.Warn("⚠️ vCenter password passed in plaintext - prefer --vmware-password-fd or --vmware-credential-ref")
+		return s.Password, nil
+	}
+	return "", fmt.Errorf("no vCenter password: set --vmware-password-fd, --vmware-credential-ref or --vmware-credentials-file")
+}
+
+// resolveFile loads a credentials file and removes it so the secret does not linger on disk
+func (s *Source) resolveFile(ctx context.Context) (string, error) {
+	info, err := os.Stat(s.CredentialsFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return "", fmt.Errorf("credentials file %s must not be readable by group or others (mode %o)", s.CredentialsFile, info.Mode().Perm())
+	}
+
+	data, err := os.ReadFile(s.CredentialsFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	if err := os.Remove(s.CredentialsFile); err != nil {
+		log.WithError(err).WithField("path", s.CredentialsFile).Warn("Failed to remove credentials file")
+	}
+	if len(data) > maxSecretSize {
+		return "", fmt.Errorf("credentials file %s is too large", s.CredentialsFile)
+	}
+
+	var creds fileCredentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return "", fmt.Errorf("invalid credentials file: %w", err)
+	}
+	switch {
+	case creds.Password != "":
+		return creds.Password, nil
+	case creds.CredentialID > 0:
+		return s.redeem(ctx, creds.CredentialID, creds.Token)
+	}
+	return "", fmt.Errorf("credentials file %s has neither a password nor a credential_id", s.CredentialsFile)
+}
+
+// redeem resolves a credential reference with its one-time token at the SHA
+func (s *Source) redeem(ctx context.Context, credentialID int, token string) (string, error) {
+	if token == "" {
+		return "", fmt.Errorf("credential reference %d has no token (set --vmware-credential-token-fd or %s)", credentialID, EnvToken)
+	}
+	shaURL := s.SHAURL
+	if shaURL == "" {
+		shaURL = "http://localhost:8082" // Default tunnel endpoint
+	}
+
+	body, err := json.Marshal(map[string]string{"token": token})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode credential request: %w", err)
+	}
+	url := fmt.Sprintf("%s/api/v1/vmware-credentials/%d/resolve", strings.TrimRight(shaURL, "/"), credentialID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create credential request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve credential %d from SHA: %w", credentialID, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return "", fmt.Errorf("SHA rejected the token for credential %d (expired or already used)", credentialID)
+	default:
+		return "", fmt.Errorf("SHA credential resolve failed with status %d", resp.StatusCode)
+	}
+
+	var resolved struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxSecretSize)).Decode(&resolved); err != nil {
+		return "", fmt.Errorf("invalid SHA credential response: %w", err)
+	}
+	if resolved.Password == "" {
+		return "", fmt.Errorf("SHA returned no password for credential %d", credentialID)
+	}
+
+	log.WithField("credential_id", credentialID).Info("🔐 vCenter credentials resolved from SHA")
+	return resolved.Password, nil
+}
+
+// readFD reads a secret from an inherited descriptor and closes it
+func readFD(fd int, what string) (string, error) {
+	file := os.NewFile(uintptr(fd), fmt.Sprintf("%s-fd-%d", strings.ReplaceAll(what, " ", "-"), fd))
+	if file == nil {
+		return "", fmt.Errorf("invalid %s descriptor %d", what, fd)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSecretSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s from descriptor %d: %w", what, fd, err)
+	}
+	secret := strings.TrimRight(string(data), "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("descriptor %d held no %s", fd, what)
+	}
+	return secret, nil
+}
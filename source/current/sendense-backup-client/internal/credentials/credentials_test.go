@@ -0,0 +1,113 @@
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func secretPipe(t *testing.T, secret string) int {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	_, err = w.WriteString(secret)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	// Hand over a descriptor r does not own, as an inherited one would be
+	fd, err := syscall.Dup(int(r.Fd()))
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	return fd
+}
+
+func shaServer(t *testing.T, credentialID, token, password string) *httptest.Server {
+	t.Helper()
+	used := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Token string `json:"token"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if r.URL.Path != "/api/v1/vmware-credentials/"+credentialID+"/resolve" || req.Token != token || used {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		used = true
+		json.NewEncoder(w).Encode(map[string]string{"password": password})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestResolvePasswordFromDescriptor(t *testing.T) {
+	s := &Source{PasswordFD: secretPipe(t, "s3cret pass\n"), TokenFD: -1}
+
+	password, err := s.Resolve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret pass", password)
+}
+
+func TestResolveCredentialReference(t *testing.T) {
+	server := shaServer(t, "7", "one-time", "from-sha")
+	s := &Source{PasswordFD: -1, CredentialRef: 7, TokenFD: secretPipe(t, "one-time"), SHAURL: server.URL}
+
+	password, err := s.Resolve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "from-sha", password)
+
+	// Tokens are single use
+	s = &Source{PasswordFD: -1, CredentialRef: 7, Token: "one-time", TokenFD: -1, SHAURL: server.URL}
+	_, err = s.Resolve(context.Background())
+	assert.ErrorContains(t, err, "rejected")
+}
+
+func TestResolveCredentialsFile(t *testing.T) {
+	server := shaServer(t, "3", "tok", "via-file")
+	path := filepath.Join(t.TempDir(), "creds.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"credential_id":3,"token":"tok"}`), 0600))
+
+	s := &Source{PasswordFD: -1, TokenFD: -1, CredentialsFile: path, SHAURL: server.URL}
+	password, err := s.Resolve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "via-file", password)
+
+	// The file is removed after loading
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	// Files others can read are refused
+	require.NoError(t, os.WriteFile(path, []byte(`{"password":"x"}`), 0644))
+	_, err = s.Resolve(context.Background())
+	assert.ErrorContains(t, err, "must not be readable")
+}
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv(EnvPassword, "env-pass")
+	t.Setenv(EnvCredentialRef, "9")
+	t.Setenv(EnvTokenFD, "5")
+
+	// Flags win over the environment
+	s := &Source{PasswordFD: -1, TokenFD: 4}
+	require.NoError(t, s.ApplyEnv())
+	assert.Equal(t, "env-pass", s.Password)
+	assert.Equal(t, 9, s.CredentialRef)
+	assert.Equal(t, 4, s.TokenFD)
+	assert.Equal(t, -1, s.PasswordFD)
+
+	t.Setenv(EnvPasswordFD, "not-a-number")
+	assert.Error(t, (&Source{PasswordFD: -1, TokenFD: -1}).ApplyEnv())
+}
+
+func TestResolveWithoutSource(t *testing.T) {
+	_, err := (&Source{PasswordFD: -1, TokenFD: -1}).Resolve(context.Background())
+	assert.ErrorContains(t, err, "no vCenter password")
+}
@@ -0,0 +1,393 @@
+// Package events emits the structured event stream the SNA consumes instead of
+// scraping backup client log lines.
+//
+// Events are written as JSON lines to a descriptor inherited from the SNA
+// (--event-fd). Every event carries the schema version, a per-job sequence number and
+// a timestamp; the type decides which other fields are set. Writes never fail the job.
+// When the descriptor breaks because the SNA restarted, the emitter reconnects to the
+// SNA's event socket in the job control directory, learns the last event the SNA
+// applied and replays the recent events after it; until then events are only kept
+// for the replay.
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Version is the event schema version; fields are only ever added within a version
+const Version = 1
+
+// SocketName is the SNA's event socket in the job control directory
+const SocketName = "events.sock"
+
+const (
+	// replayEvents is how many recent events are kept to replay after a reconnect;
+	// progress events carry totals, so older ones are not needed to catch up
+	replayEvents = 512
+
+	// redialInterval is how often a broken stream tries the SNA's event socket
+	redialInterval = 5 * time.Second
+
+	// socketTimeout bounds the reconnect handshake and each write to the socket
+	socketTimeout = 5 * time.Second
+)
+
+// Event types
+const (
+	TypeStatus   = "status"    // Job status or phase change (final statuses end the stream)
+	TypeProgress = "progress"  // Aggregate bytes transferred
+	TypeDisk     = "disk"      // Bytes transferred for one disk
+	TypeSnapshot = "snapshot"  // Snapshot created or removed
+	TypeChangeID = "change_id" // Change ID recorded for a disk after its transfer
+	TypeError    = "error"     // Error with a stable code
+)
+
+// Error codes reported with error events and failed statuses
+const (
+	CodeVMwareConnect = "VMWARE_CONNECT_FAILED"
+	CodeSnapshot      = "SNAPSHOT_FAILED"
+	CodeTarget        = "TARGET_FAILED"
+	CodeTransfer      = "TRANSFER_FAILED"
+	CodeChangeID      = "CHANGE_ID_FAILED"
+	CodeCancelled     = "CANCELLED"
+	CodeInternal      = "INTERNAL"
+)
+
+// Event is one line of the stream
+type Event struct {
+	Version int       `json:"v"`
+	Seq     uint64    `json:"seq"`
+	Time    time.Time `json:"time"`
+	JobID   string    `json:"job_id"`
+	Type    string    `json:"type"`
+
+	// status
+	Status   string `json:"status,omitempty"`
+	Phase    string `json:"phase,omitempty"`
+	SyncType string `json:"sync_type,omitempty"` // "full" or "incremental", with DiskKey
+
+	// progress and disk (progress covers the disk being copied; disk events carry its key)
+	DiskKey          int32  `json:"disk_key,omitempty"`
+	DiskStatus       string `json:"disk_status,omitempty"` // "transferring", "completed"
+	BytesTransferred int64  `json:"bytes_transferred,omitempty"`
+	TotalBytes       int64  `json:"total_bytes,omitempty"`
+	SpeedBps         int64  `json:"speed_bps,omitempty"`
+	ETASeconds       int    `json:"eta_seconds,omitempty"`
+
+	// snapshot
+	Action       string `json:"action,omitempty"` // "created", "removed"
+	VMRef        string `json:"vm_ref,omitempty"`
+	SnapshotRef  string `json:"snapshot_ref,omitempty"`
+	SnapshotName string `json:"snapshot_name,omitempty"`
+
+	// change_id
+	ChangeID string `json:"change_id,omitempty"`
+
+	// error and failed status
+	Error *ErrorDetail `json:"error,omitempty"`
+}
+
+// ErrorDetail describes an error with a stable code
+type ErrorDetail struct {
+	Code        string `json:"code"`
+	Message     string `json:"message"`
+	Recoverable bool   `json:"recoverable"`
+}
+
+// CodedError attaches an error code to an error
+type CodedError struct {
+	Code string
+	Err  error
+}
+
+func (e *CodedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+// WithCode attaches an error code to err (nil stays nil; an existing code is kept)
+func WithCode(code string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var coded *CodedError
+	if errors.As(err, &coded) {
+		return err
+	}
+	return &CodedError{Code: code, Err: err}
+}
+
+// CodeOf returns the code attached to err, or CodeInternal
+func CodeOf(err error) string {
+	var coded *CodedError
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+	if errors.Is(err, context.Canceled) {
+		return CodeCancelled
+	}
+	return CodeInternal
+}
+
+// Emitter writes the event stream of one job; a nil emitter drops every event
+type Emitter struct {
+	jobID      string
+	socketPath string // SNA event socket to reconnect to (empty = drop events once broken)
+
+	mu       sync.Mutex
+	w        io.Writer // nil while the stream is broken
+	conn     net.Conn  // Set while writing to the event socket
+	seq      uint64
+	history  []Event // Recent events, replayed after a reconnect
+	nextDial time.Time
+}
+
+// New creates an emitter writing to w
+func New(w io.Writer, jobID string) *Emitter {
+	return &Emitter{w: w, jobID: jobID}
+}
+
+// Open creates an emitter writing to an inherited descriptor that reconnects to the
+// event socket at socketPath once the descriptor breaks
+func Open(fd int, socketPath, jobID string) (*Emitter, error) {
+	file := os.NewFile(uintptr(fd), fmt.Sprintf("event-fd-%d", fd))
+	if file == nil {
+		return nil, fmt.Errorf("invalid event descriptor %d", fd)
+	}
+	e := New(file, jobID)
+	e.socketPath = socketPath
+	return e, nil
+}
+
+// FromContext returns the emitter stored in ctx, or nil
+func FromContext(ctx context.Context) *Emitter {
+	e, _ := ctx.Value("eventEmitter").(*Emitter)
+	return e
+}
+
+// Emit stamps and writes one event
+func (e *Emitter) Emit(event Event) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.w == nil && e.socketPath == "" {
+		return
+	}
+
+	e.seq++
+	event.Version = Version
+	event.Seq = e.seq
+	event.Time = time.Now().UTC()
+	event.JobID = e.jobID
+
+	if e.socketPath != "" {
+		e.history = append(e.history, event)
+		if len(e.history) > replayEvents {
+			e.history = e.history[len(e.history)-replayEvents:]
+		}
+	}
+
+	if e.w == nil {
+		// reconnect replays the history, this event included
+		e.reconnect()
+		return
+	}
+	if err := e.write(event); err != nil {
+		// The SNA went away - keep the job running, it still reports to the SHA
+		e.disconnect()
+		if e.socketPath == "" {
+			log.WithError(err).Warn("Event stream closed - dropping further events")
+			return
+		}
+		log.WithError(err).Warn("Event stream closed - reconnecting to the SNA event socket")
+		e.nextDial = time.Now().Add(redialInterval)
+	}
+}
+
+// write sends one event on the current stream
+func (e *Emitter) write(event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if e.conn != nil {
+		e.conn.SetWriteDeadline(time.Now().Add(socketTimeout))
+	}
+	_, err = e.w.Write(append(data, '\n'))
+	return err
+}
+
+// disconnect drops the current stream
+func (e *Emitter) disconnect() {
+	if closer, ok := e.w.(io.Closer); ok {
+		closer.Close()
+	}
+	e.w = nil
+	e.conn = nil
+}
+
+// reconnect reopens the stream on the SNA's event socket, at most once per
+// redialInterval, and replays the events the SNA has not applied yet
+func (e *Emitter) reconnect() {
+	if time.Now().Before(e.nextDial) {
+		return
+	}
+	e.nextDial = time.Now().Add(redialInterval)
+
+	conn, err := net.DialTimeout("unix", e.socketPath, socketTimeout)
+	if err != nil {
+		log.WithError(err).Debug("SNA event socket not reachable yet")
+		return
+	}
+	lastSeq, err := handshake(conn, e.jobID)
+	if err != nil {
+		conn.Close()
+		log.WithError(err).Warn("SNA refused the reconnected event stream")
+		return
+	}
+
+	e.w, e.conn = conn, conn
+	replayed := 0
+	for _, event := range e.history {
+		if event.Seq <= lastSeq {
+			continue
+		}
+		if err := e.write(event); err != nil {
+			e.disconnect()
+			log.WithError(err).Warn("Event stream broke during replay")
+			return
+		}
+		replayed++
+	}
+	log.WithFields(log.Fields{
+		"last_seq": lastSeq,
+		"replayed": replayed,
+	}).Info("Event stream reconnected to the SNA")
+}
+
+// handshake names the job on a new socket stream and returns the last event the SNA
+// applied for it
+func handshake(conn net.Conn, jobID string) (uint64, error) {
+	conn.SetDeadline(time.Now().Add(socketTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	hello, err := json.Marshal(struct {
+		Version int    `json:"v"`
+		JobID   string `json:"job_id"`
+	}{Version, jobID})
+	if err != nil {
+		return 0, err
+	}
+	if _, err := conn.Write(append(hello, '\n')); err != nil {
+		return 0, err
+	}
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return 0, err
+	}
+	var resume struct {
+		LastSeq uint64 `json:"last_seq"`
+		Error   string `json:"error"`
+	}
+	if err := json.Unmarshal(line, &resume); err != nil {
+		return 0, err
+	}
+	if resume.Error != "" {
+		return 0, errors.New(resume.Error)
+	}
+	return resume.LastSeq, nil
+}
+
+// Status reports a job status and phase
+func (e *Emitter) Status(status, phase string) {
+	e.Emit(Event{Type: TypeStatus, Status: status, Phase: phase})
+}
+
+// Transfer reports the start of a disk transfer and whether it copies the full disk
+func (e *Emitter) Transfer(diskKey int32, syncType string) {
+	e.Emit(Event{Type: TypeStatus, Status: "running", Phase: "transferring", DiskKey: diskKey, SyncType: syncType})
+}
+
+// Progress reports aggregate transfer progress
+func (e *Emitter) Progress(bytesTransferred, totalBytes, speedBps int64, etaSeconds int) {
+	e.Emit(Event{
+		Type:             TypeProgress,
+		BytesTransferred: bytesTransferred,
+		TotalBytes:       totalBytes,
+		SpeedBps:         speedBps,
+		ETASeconds:       etaSeconds,
+	})
+}
+
+// Disk reports the transfer progress of one disk
+func (e *Emitter) Disk(diskKey int32, status string, bytesTransferred, totalBytes, speedBps int64) {
+	e.Emit(Event{
+		Type:             TypeDisk,
+		DiskKey:          diskKey,
+		DiskStatus:       status,
+		BytesTransferred: bytesTransferred,
+		TotalBytes:       totalBytes,
+		SpeedBps:         speedBps,
+	})
+}
+
+// Snapshot reports a snapshot being created or removed
+func (e *Emitter) Snapshot(action, vmRef, snapshotRef, snapshotName string) {
+	e.Emit(Event{
+		Type:         TypeSnapshot,
+		Action:       action,
+		VMRef:        vmRef,
+		SnapshotRef:  snapshotRef,
+		SnapshotName: snapshotName,
+	})
+}
+
+// ChangeID reports the change ID recorded for a disk
+func (e *Emitter) ChangeID(diskKey int32, changeID string) {
+	e.Emit(Event{Type: TypeChangeID, DiskKey: diskKey, ChangeID: changeID})
+}
+
+// Error reports an error; recoverable errors do not end the job
+func (e *Emitter) Error(err error, recoverable bool) {
+	if err == nil {
+		return
+	}
+	e.Emit(Event{Type: TypeError, Error: detail(err, recoverable)})
+}
+
+// Finish reports the final status of the job from the error it ended with
+func (e *Emitter) Finish(err error, cancelled bool) {
+	switch {
+	case cancelled:
+		e.Emit(Event{Type: TypeStatus, Status: "cancelled", Error: detail(WithCode(CodeCancelled, err), false)})
+	case err != nil:
+		e.Emit(Event{Type: TypeStatus, Status: "failed", Error: detail(err, false)})
+	default:
+		e.Emit(Event{Type: TypeStatus, Status: "completed"})
+	}
+}
+
+func detail(err error, recoverable bool) *ErrorDetail {
+	if err == nil {
+		return nil
+	}
+	return &ErrorDetail{Code: CodeOf(err), Message: err.Error(), Recoverable: recoverable}
+}
@@ -0,0 +1,156 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, buf *bytes.Buffer) []Event {
+	t.Helper()
+	var events []Event
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var event Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestEmitterWritesVersionedJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	e := New(&buf, "backup-1")
+
+	e.Status("running", "snapshot")
+	e.Snapshot("created", "vm-42", "snapshot-7", "sbak-backup-1")
+	e.Transfer(2000, "incremental")
+	e.Disk(2000, "transferring", 512, 1024, 256)
+	e.ChangeID(2000, "52 3c/8")
+	e.Finish(nil, false)
+
+	events := decode(t, &buf)
+	require.Len(t, events, 6)
+	for i, event := range events {
+		assert.Equal(t, Version, event.Version)
+		assert.Equal(t, uint64(i+1), event.Seq)
+		assert.Equal(t, "backup-1", event.JobID)
+		assert.False(t, event.Time.IsZero())
+	}
+	assert.Equal(t, TypeSnapshot, events[1].Type)
+	assert.Equal(t, "snapshot-7", events[1].SnapshotRef)
+	assert.Equal(t, "incremental", events[2].SyncType)
+	assert.Equal(t, int32(2000), events[3].DiskKey)
+	assert.Equal(t, "52 3c/8", events[4].ChangeID)
+	assert.Equal(t, "completed", events[5].Status)
+	assert.Nil(t, events[5].Error)
+}
+
+func TestFinishReportsErrorCode(t *testing.T) {
+	var buf bytes.Buffer
+	e := New(&buf, "backup-2")
+
+	err := fmt.Errorf("sync disk 2000: %w", WithCode(CodeSnapshot, errors.New("quiesce timed out")))
+	e.Finish(err, false)
+	e.Finish(context.Canceled, true)
+
+	events := decode(t, &buf)
+	require.Len(t, events, 2)
+	assert.Equal(t, "failed", events[0].Status)
+	require.NotNil(t, events[0].Error)
+	assert.Equal(t, CodeSnapshot, events[0].Error.Code)
+	assert.Equal(t, "sync disk 2000: quiesce timed out", events[0].Error.Message)
+	assert.Equal(t, "cancelled", events[1].Status)
+	assert.Equal(t, CodeCancelled, events[1].Error.Code)
+}
+
+func TestWithCodeKeepsInnermostCode(t *testing.T) {
+	err := WithCode(CodeTransfer, WithCode(CodeTarget, errors.New("connection refused")))
+	assert.Equal(t, CodeTarget, CodeOf(err))
+	assert.Equal(t, CodeInternal, CodeOf(errors.New("plain")))
+	assert.Nil(t, WithCode(CodeTarget, nil))
+}
+
+type failingWriter struct{ writes int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("broken pipe")
+}
+
+func TestBrokenStreamDropsEvents(t *testing.T) {
+	w := &failingWriter{}
+	e := New(w, "backup-3")
+	e.Progress(1, 2, 3, 4)
+	e.Progress(2, 2, 3, 0)
+	assert.Equal(t, 1, w.writes)
+
+	// A nil emitter drops everything
+	var nilEmitter *Emitter
+	nilEmitter.Status("running", "transferring")
+	nilEmitter.Finish(errors.New("x"), false)
+}
+
+func TestBrokenStreamReconnectsAndReplays(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), SocketName)
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	// The SNA applied events up to 2 before it restarted
+	received := make(chan []Event, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		hello, _ := reader.ReadBytes('\n')
+		assert.Contains(t, string(hello), `"job_id":"backup-4"`)
+		conn.Write([]byte(`{"last_seq":2}` + "\n"))
+
+		var replayed []Event
+		for len(replayed) < 3 {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				break
+			}
+			var event Event
+			if json.Unmarshal(line, &event) == nil {
+				replayed = append(replayed, event)
+			}
+		}
+		received <- replayed
+	}()
+
+	e := New(&failingWriter{}, "backup-4")
+	e.socketPath = socketPath
+	e.Status("running", "transferring") // seq 1, breaks the pipe
+	e.Progress(1, 4, 1, 3)              // seq 2
+	e.nextDial = time.Time{}
+	e.Progress(2, 4, 1, 2) // seq 3, reconnects and replays 3
+	e.Progress(3, 4, 1, 1) // seq 4, live on the socket
+	e.Finish(nil, false)   // seq 5
+
+	select {
+	case replayed := <-received:
+		require.Len(t, replayed, 3)
+		assert.Equal(t, uint64(3), replayed[0].Seq)
+		assert.Equal(t, int64(2), replayed[0].BytesTransferred)
+		assert.Equal(t, uint64(4), replayed[1].Seq)
+		assert.Equal(t, "completed", replayed[2].Status)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no events received on the event socket")
+	}
+}
@@ -0,0 +1,305 @@
+// Package jobcontrol applies pause, resume and cancel requests from the SNA to a running job.
+//
+// The SNA writes the requested action to <dir>/<job_id>.request and signals the backup
+// client with SIGUSR1 (the file is also polled, so a lost signal only delays the action).
+// The client reports its state and the per-worker checkpoint offsets to
+// <dir>/<job_id>.state.json from the moment it starts watching, which the SNA reads to
+// tell controllable clients apart and to confirm a pause took effect.
+package jobcontrol
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultDir is where the SNA and the backup client exchange control files
+	DefaultDir = "/var/lib/sendense/control"
+
+	ActionPause  = "pause"
+	ActionResume = "resume"
+	ActionCancel = "cancel"
+
+	StateRunning   = "running"
+	StatePaused    = "paused"
+	StateCancelled = "cancelled"
+
+	// Final states written when the client exits
+	StateCompleted = "completed"
+	StateFailed    = "failed"
+
+	pollInterval = 2 * time.Second
+)
+
+// State is the control state reported back to the SNA
+type State struct {
+	JobID       string           `json:"job_id"`
+	State       string           `json:"state"`
+	Checkpoints map[string]int64 `json:"checkpoints,omitempty"` // "<disk_key>/<worker_id>" -> next offset to copy
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// Controller tracks the control state of one job; a nil controller never pauses or cancels
+type Controller struct {
+	jobID string
+	dir   string
+
+	mu          sync.Mutex
+	state       string
+	resumed     chan struct{} // closed while the job is not paused
+	cancelled   chan struct{} // closed once the job is cancelled
+	checkpoints map[string]int64
+}
+
+// New creates a controller for a job
+func New(dir, jobID string) *Controller {
+	c := &Controller{
+		jobID:       jobID,
+		dir:         dir,
+		state:       StateRunning,
+		resumed:     make(chan struct{}),
+		cancelled:   make(chan struct{}),
+		checkpoints: make(map[string]int64),
+	}
+	close(c.resumed)
+	return c
+}
+
+// FromContext returns the job controller stored in ctx, or nil
+func FromContext(ctx context.Context) *Controller {
+	c, _ := ctx.Value("jobControl").(*Controller)
+	return c
+}
+
+// RequestPath is the file the SNA writes the requested action to
+func RequestPath(dir, jobID string) string {
+	return filepath.Join(dir, jobID+".request")
+}
+
+// StatePath is the file the backup client reports its control state to
+func StatePath(dir, jobID string) string {
+	return filepath.Join(dir, jobID+".state.json")
+}
+
+// Watch applies control requests until ctx is cancelled; the initial state file
+// tells the SNA this client accepts pause and resume requests
+func (c *Controller) Watch(ctx context.Context) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	c.writeStateLocked()
+	c.mu.Unlock()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1)
+	defer signal.Stop(signals)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		c.poll()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll applies the action in the request file, if any
+func (c *Controller) poll() {
+	data, err := os.ReadFile(RequestPath(c.dir, c.jobID))
+	if err != nil {
+		return
+	}
+	c.Apply(strings.TrimSpace(string(data)))
+}
+
+// Apply moves the job to the state an action asks for; repeated actions are no-ops
+func (c *Controller) Apply(action string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous := c.state
+	switch action {
+	case ActionPause:
+		if c.state == StateRunning {
+			c.state = StatePaused
+			c.resumed = make(chan struct{})
+		}
+	case ActionResume:
+		if c.state == StatePaused {
+			c.state = StateRunning
+			close(c.resumed)
+		}
+	case ActionCancel:
+		if c.state != StateCancelled {
+			if c.state == StatePaused {
+				close(c.resumed)
+			}
+			c.state = StateCancelled
+			close(c.cancelled)
+		}
+	case "":
+		return
+	default:
+		log.WithFields(log.Fields{"job_id": c.jobID, "action": action}).Warn("⚠️ Ignoring unknown job control action")
+		return
+	}
+
+	if c.state == previous {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"job_id": c.jobID,
+		"action": action,
+		"state":  c.state,
+	}).Info("🎛️ Job control state changed")
+	c.writeStateLocked()
+}
+
+// Bind returns a context that is cancelled once the job is cancelled
+func (c *Controller) Bind(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	if c == nil {
+		return ctx, cancel
+	}
+
+	go func() {
+		select {
+		case <-c.cancelled:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// WaitIfPaused blocks while the job is paused and returns ctx.Err() if ctx ends first
+func (c *Controller) WaitIfPaused(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	resumed := c.resumed
+	c.mu.Unlock()
+
+	select {
+	case <-resumed:
+		return nil
+	default:
+	}
+
+	log.WithField("job_id", c.jobID).Info("⏸️ Worker paused at checkpoint")
+	select {
+	case <-resumed:
+		log.WithField("job_id", c.jobID).Info("▶️ Worker resumed from checkpoint")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Checkpoint records the next offset a worker will copy; while paused the
+// state file is refreshed so it shows where each worker stopped
+func (c *Controller) Checkpoint(key string, offset int64) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checkpoints[key] = offset
+	if c.state == StatePaused {
+		c.writeStateLocked()
+	}
+}
+
+// Finish reports how the job ended, so an SNA that restarted while the job ran (and so
+// is no longer the client's parent) can still tell success from failure
+func (c *Controller) Finish(err error) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case c.state == StateCancelled:
+	case err != nil:
+		c.state = StateFailed
+	default:
+		c.state = StateCompleted
+	}
+	c.writeStateLocked()
+}
+
+// Cancelled reports whether the job was cancelled
+func (c *Controller) Cancelled() bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state == StateCancelled
+}
+
+// Snapshot returns the current control state
+func (c *Controller) Snapshot() State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.snapshotLocked()
+}
+
+func (c *Controller) snapshotLocked() State {
+	checkpoints := make(map[string]int64, len(c.checkpoints))
+	for key, offset := range c.checkpoints {
+		checkpoints[key] = offset
+	}
+	return State{
+		JobID:       c.jobID,
+		State:       c.state,
+		Checkpoints: checkpoints,
+		UpdatedAt:   time.Now().UTC(),
+	}
+}
+
+// writeStateLocked reports the control state to the SNA (caller holds c.mu)
+func (c *Controller) writeStateLocked() {
+	data, err := json.Marshal(c.snapshotLocked())
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		log.WithError(err).Warn("Failed to create job control directory")
+		return
+	}
+	path := StatePath(c.dir, c.jobID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		log.WithError(err).Warn("Failed to write job control state")
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		log.WithError(err).Warn("Failed to write job control state")
+	}
+}
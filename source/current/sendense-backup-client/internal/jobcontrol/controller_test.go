@@ -0,0 +1,127 @@
+package jobcontrol
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPauseAndResume(t *testing.T) {
+	dir := t.TempDir()
+	c := New(dir, "backup-test-1")
+	ctx := context.Background()
+
+	// Running jobs never block
+	require.NoError(t, c.WaitIfPaused(ctx))
+
+	c.Apply(ActionPause)
+	assert.Equal(t, StatePaused, c.Snapshot().State)
+
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, c.WaitIfPaused(waitCtx), context.DeadlineExceeded)
+
+	// Checkpoints taken while paused are reported to the SNA
+	c.Checkpoint("2000/0", 64*1024*1024)
+	data, err := os.ReadFile(StatePath(dir, "backup-test-1"))
+	require.NoError(t, err)
+	var state State
+	require.NoError(t, json.Unmarshal(data, &state))
+	assert.Equal(t, StatePaused, state.State)
+	assert.Equal(t, int64(64*1024*1024), state.Checkpoints["2000/0"])
+
+	done := make(chan error, 1)
+	go func() { done <- c.WaitIfPaused(ctx) }()
+	c.Apply(ActionResume)
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("worker was not resumed")
+	}
+	assert.Equal(t, StateRunning, c.Snapshot().State)
+}
+
+func TestCancelReleasesPausedWorkersAndCancelsContext(t *testing.T) {
+	c := New(t.TempDir(), "backup-test-2")
+	ctx, cancel := c.Bind(context.Background())
+	defer cancel()
+
+	c.Apply(ActionPause)
+	done := make(chan error, 1)
+	go func() { done <- c.WaitIfPaused(ctx) }()
+
+	c.Apply(ActionCancel)
+	assert.True(t, c.Cancelled())
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("bound context was not cancelled")
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("paused worker was not released")
+	}
+
+	// Later actions do not revive a cancelled job
+	c.Apply(ActionResume)
+	assert.Equal(t, StateCancelled, c.Snapshot().State)
+}
+
+func TestPollReadsRequestFile(t *testing.T) {
+	dir := t.TempDir()
+	c := New(dir, "backup-test-3")
+
+	require.NoError(t, os.WriteFile(RequestPath(dir, "backup-test-3"), []byte("pause\n"), 0600))
+	c.poll()
+	assert.Equal(t, StatePaused, c.Snapshot().State)
+
+	// Unknown actions are ignored
+	require.NoError(t, os.WriteFile(RequestPath(dir, "backup-test-3"), []byte("explode"), 0600))
+	c.poll()
+	assert.Equal(t, StatePaused, c.Snapshot().State)
+}
+
+func TestFinishReportsFinalState(t *testing.T) {
+	dir := t.TempDir()
+	read := func(jobID string) string {
+		data, err := os.ReadFile(StatePath(dir, jobID))
+		require.NoError(t, err)
+		var state State
+		require.NoError(t, json.Unmarshal(data, &state))
+		return state.State
+	}
+
+	New(dir, "backup-ok").Finish(nil)
+	assert.Equal(t, StateCompleted, read("backup-ok"))
+
+	New(dir, "backup-err").Finish(errors.New("nbd write failed"))
+	assert.Equal(t, StateFailed, read("backup-err"))
+
+	// A cancelled job stays cancelled even though the run returned an error
+	c := New(dir, "backup-cancel")
+	c.Apply(ActionCancel)
+	c.Finish(errors.New("context canceled"))
+	assert.Equal(t, StateCancelled, read("backup-cancel"))
+}
+
+func TestNilControllerIsInert(t *testing.T) {
+	var c *Controller
+	require.NoError(t, c.WaitIfPaused(context.Background()))
+	c.Checkpoint("2000/0", 1)
+	c.Finish(nil)
+	assert.False(t, c.Cancelled())
+
+	ctx, cancel := c.Bind(context.Background())
+	defer cancel()
+	assert.NoError(t, ctx.Err())
+}
@@ -0,0 +1,134 @@
+package kvm
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+)
+
+// NBDServer is where libvirt serves the pull-mode backup exports
+type NBDServer struct {
+	Host   string // TCP listen address (remote libvirt hosts)
+	Port   int
+	Socket string // Unix socket path (local libvirt only) - used instead of Host/Port
+}
+
+// URI returns the NBD URI of a disk's export on the server
+func (s NBDServer) URI(exportName string) string {
+	if s.Socket != "" {
+		return fmt.Sprintf("nbd+unix:///%s?socket=%s", exportName, s.Socket)
+	}
+	return fmt.Sprintf("nbd://%s:%d/%s", s.Host, s.Port, exportName)
+}
+
+// DiskBackup is one disk of a backup request
+type DiskBackup struct {
+	Disk       Disk
+	Excluded   bool   // Not exported and not tracked by the new checkpoint
+	Checkpoint string // Incremental base checkpoint ("" for a full backup)
+}
+
+// ExportName is the NBD export of the disk
+func (d DiskBackup) ExportName() string {
+	return d.Disk.Target
+}
+
+// BitmapName is the dirty bitmap exported with an incremental backup of the disk
+func (d DiskBackup) BitmapName() string {
+	return "backup-" + d.Disk.Target
+}
+
+// MetaContext is the NBD metadata context reporting the dirty blocks of the disk
+func (d DiskBackup) MetaContext() string {
+	return "qemu:dirty-bitmap:" + d.BitmapName()
+}
+
+type backupXML struct {
+	XMLName xml.Name `xml:"domainbackup"`
+	Mode    string   `xml:"mode,attr"`
+	Server  struct {
+		Transport string `xml:"transport,attr"`
+		Name      string `xml:"name,attr,omitempty"`
+		Port      int    `xml:"port,attr,omitempty"`
+		Socket    string `xml:"socket,attr,omitempty"`
+	} `xml:"server"`
+	Disks []backupDiskXML `xml:"disks>disk"`
+}
+
+type backupDiskXML struct {
+	Name         string `xml:"name,attr"`
+	Backup       string `xml:"backup,attr"`
+	BackupMode   string `xml:"backupmode,attr,omitempty"`
+	Incremental  string `xml:"incremental,attr,omitempty"`
+	ExportName   string `xml:"exportname,attr,omitempty"`
+	ExportBitmap string `xml:"exportbitmap,attr,omitempty"`
+	Scratch      *struct {
+		File string `xml:"file,attr"`
+	} `xml:"scratch,omitempty"`
+}
+
+// BuildBackupXML builds the pull-mode <domainbackup> definition. Each disk has its own
+// backup mode, so a disk added since the last backup is exported in full while the
+// others are incremental. Scratch files go to scratchDir when set (libvirt's default
+// location otherwise).
+func BuildBackupXML(server NBDServer, disks []DiskBackup, scratchDir, jobID string) ([]byte, error) {
+	def := backupXML{Mode: "pull"}
+	if server.Socket != "" {
+		def.Server.Transport = "unix"
+		def.Server.Socket = server.Socket
+	} else {
+		def.Server.Transport = "tcp"
+		def.Server.Name = server.Host
+		def.Server.Port = server.Port
+	}
+
+	for _, d := range disks {
+		disk := backupDiskXML{Name: d.Disk.Target, Backup: "no"}
+		if !d.Excluded {
+			disk.Backup = "yes"
+			disk.BackupMode = "full"
+			disk.ExportName = d.ExportName()
+			if d.Checkpoint != "" {
+				disk.BackupMode = "incremental"
+				disk.Incremental = d.Checkpoint
+				disk.ExportBitmap = d.BitmapName()
+			}
+			if scratchDir != "" {
+				disk.Scratch = &struct {
+					File string `xml:"file,attr"`
+				}{File: filepath.Join(scratchDir, fmt.Sprintf("%s-%s.scratch.qcow2", jobID, d.Disk.Target))}
+			}
+		}
+		def.Disks = append(def.Disks, disk)
+	}
+
+	return xml.MarshalIndent(def, "", "  ")
+}
+
+type checkpointXML struct {
+	XMLName     xml.Name `xml:"domaincheckpoint"`
+	Name        string   `xml:"name"`
+	Description string   `xml:"description,omitempty"`
+	Disks       []struct {
+		Name       string `xml:"name,attr"`
+		Checkpoint string `xml:"checkpoint,attr"`
+	} `xml:"disks>disk"`
+}
+
+// BuildCheckpointXML builds the <domaincheckpoint> created with the backup; included
+// disks get a persistent dirty bitmap that the next incremental is taken against
+func BuildCheckpointXML(name, description string, disks []DiskBackup) ([]byte, error) {
+	def := checkpointXML{Name: name, Description: description}
+	for _, d := range disks {
+		mode := "bitmap"
+		if d.Excluded {
+			mode = "no"
+		}
+		def.Disks = append(def.Disks, struct {
+			Name       string `xml:"name,attr"`
+			Checkpoint string `xml:"checkpoint,attr"`
+		}{Name: d.Disk.Target, Checkpoint: mode})
+	}
+
+	return xml.MarshalIndent(def, "", "  ")
+}
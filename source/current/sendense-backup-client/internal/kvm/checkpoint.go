@@ -0,0 +1,40 @@
+package kvm
+
+import "strings"
+
+// CheckpointPrefix returns the prefix of the checkpoints a job type owns. Like VMware
+// snapshots, backup jobs use "sbak-" and replication jobs "srep-", so one job type
+// never deletes the incremental base of the other.
+func CheckpointPrefix(jobID string) string {
+	if strings.HasPrefix(jobID, "backup-") {
+		return "sbak-"
+	}
+	return "srep-"
+}
+
+// CheckpointName returns the name of the checkpoint created by a job
+func CheckpointName(jobID string) string {
+	return CheckpointPrefix(jobID) + jobID
+}
+
+// SupersededCheckpoints returns the checkpoints of the job type other than keep;
+// once keep is recorded as every disk's change ID they are no longer needed as a base
+func SupersededCheckpoints(names []string, prefix, keep string) []string {
+	var superseded []string
+	for _, name := range names {
+		if name != keep && strings.HasPrefix(name, prefix) {
+			superseded = append(superseded, name)
+		}
+	}
+	return superseded
+}
+
+// HasCheckpoint reports whether a checkpoint exists
+func HasCheckpoint(names []string, name string) bool {
+	for _, existing := range names {
+		if existing == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+// Package kvm backs up KVM/QEMU VMs managed by libvirt.
+//
+// Backups use libvirt's pull-mode backup API: backup-begin exports each disk over NBD
+// at a consistent point in time and creates a checkpoint, which QEMU tracks as a
+// persistent dirty bitmap in the disk image. The next backup passes that checkpoint
+// as its incremental base and only copies the blocks the bitmap marks dirty. The
+// checkpoint name is recorded in the SHA as the disk's change ID
+// ("<domain uuid>/<checkpoint name>"), so the same NBD targets, BackupEngine and
+// repositories serve VMware and KVM sources.
+package kvm
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// FirstDiskKey is the disk key of the first disk; disks are numbered in domain XML
+// order like VMware disk keys (2000, 2001...), which is how the SHA maps NBD targets
+const FirstDiskKey = 2000
+
+// Domain is the part of a libvirt domain definition backups need
+type Domain struct {
+	Name  string
+	UUID  string
+	Disks []Disk
+}
+
+// Disk is a block device of a domain
+type Disk struct {
+	Key      int32  // Synthetic disk key (FirstDiskKey + position)
+	Target   string // Guest device name (vda, sdb...) - names the disk in backup XML
+	Source   string // Image file, block device or network volume name
+	Format   string // Driver type (qcow2, raw)
+	ReadOnly bool
+}
+
+// domainXML maps the elements of `virsh dumpxml` used here
+type domainXML struct {
+	Name    string `xml:"name"`
+	UUID    string `xml:"uuid"`
+	Devices struct {
+		Disks []struct {
+			Device string `xml:"device,attr"`
+			Driver struct {
+				Type string `xml:"type,attr"`
+			} `xml:"driver"`
+			Source struct {
+				File string `xml:"file,attr"`
+				Dev  string `xml:"dev,attr"`
+				Name string `xml:"name,attr"`
+			} `xml:"source"`
+			Target struct {
+				Dev string `xml:"dev,attr"`
+			} `xml:"target"`
+			ReadOnly *struct{} `xml:"readonly"`
+		} `xml:"disk"`
+	} `xml:"devices"`
+}
+
+// ParseDomainXML reads the name, UUID and disks (CD-ROMs and floppies skipped) of a domain
+func ParseDomainXML(data []byte) (*Domain, error) {
+	var parsed domainXML
+	if err := xml.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse domain XML: %w", err)
+	}
+	if parsed.UUID == "" {
+		return nil, fmt.Errorf("domain XML has no UUID")
+	}
+
+	domain := &Domain{Name: parsed.Name, UUID: parsed.UUID}
+	for _, d := range parsed.Devices.Disks {
+		if d.Device != "" && d.Device != "disk" {
+			continue
+		}
+
+		source := d.Source.File
+		if source == "" {
+			source = d.Source.Dev
+		}
+		if source == "" {
+			source = d.Source.Name
+		}
+
+		domain.Disks = append(domain.Disks, Disk{
+			Key:      int32(FirstDiskKey + len(domain.Disks)),
+			Target:   d.Target.Dev,
+			Source:   source,
+			Format:   d.Driver.Type,
+			ReadOnly: d.ReadOnly != nil,
+		})
+	}
+
+	return domain, nil
+}
+
+// VirtualDisk describes the disk in the VMware device form the NBD targets work with,
+// carrying the change ID the backup will record for it
+func (d *Disk) VirtualDisk(changeID string) *types.VirtualDisk {
+	return &types.VirtualDisk{
+		VirtualDevice: types.VirtualDevice{
+			Key: d.Key,
+			Backing: &types.VirtualDiskFlatVer2BackingInfo{
+				VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+					FileName: d.Source,
+				},
+				ChangeId: changeID,
+			},
+		},
+	}
+}
+
+// ChangeID returns the change ID recorded for a backup taken at a checkpoint
+func ChangeID(domainUUID, checkpointName string) string {
+	return domainUUID + "/" + checkpointName
+}
@@ -0,0 +1,62 @@
+package kvm
+
+// Flags of NBD block status extents
+const (
+	StateHole  = 1 // base:allocation - not allocated in the image
+	StateZero  = 2 // base:allocation - reads as zeros
+	StateDirty = 1 // qemu:dirty-bitmap - written since the checkpoint
+)
+
+// AllocationContext is the NBD metadata context reporting allocated blocks
+const AllocationContext = "base:allocation"
+
+// Extent is a byte range of a disk
+type Extent struct {
+	Offset int64
+	Length int64
+}
+
+// ExtentFilter decides from its block status flags whether an extent is copied
+type ExtentFilter func(flags uint32) bool
+
+// AllocatedFilter selects data a clean target does not already read back: blocks
+// that are neither holes nor known zeros
+func AllocatedFilter(flags uint32) bool {
+	return flags&(StateHole|StateZero) == 0
+}
+
+// DirtyFilter selects blocks changed since the incremental base checkpoint
+func DirtyFilter(flags uint32) bool {
+	return flags&StateDirty != 0
+}
+
+// AppendExtents decodes the (length, flags) pairs of a block status reply starting at
+// offset, appending the selected ranges (merged with an adjacent previous range) and
+// returning the offset the reply ends at
+func AppendExtents(extents []Extent, offset uint64, entries []uint32, filter ExtentFilter) ([]Extent, uint64) {
+	for i := 0; i+1 < len(entries); i += 2 {
+		length := uint64(entries[i])
+		if length == 0 {
+			continue
+		}
+
+		if filter(entries[i+1]) {
+			if n := len(extents); n > 0 && extents[n-1].Offset+extents[n-1].Length == int64(offset) {
+				extents[n-1].Length += int64(length)
+			} else {
+				extents = append(extents, Extent{Offset: int64(offset), Length: int64(length)})
+			}
+		}
+		offset += length
+	}
+	return extents, offset
+}
+
+// TotalBytes returns the sum of extent lengths
+func TotalBytes(extents []Extent) int64 {
+	var total int64
+	for _, extent := range extents {
+		total += extent.Length
+	}
+	return total
+}
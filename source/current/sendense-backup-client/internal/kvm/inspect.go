@@ -0,0 +1,53 @@
+package kvm
+
+import (
+	"context"
+	"fmt"
+)
+
+// DomainInfo describes a domain and its disks to the SHA, which records the disks
+// before a backup like VMware discovery does for vCenter VMs
+type DomainInfo struct {
+	Name  string     `json:"name"`
+	UUID  string     `json:"uuid"`
+	Disks []DiskInfo `json:"disks"`
+}
+
+// DiskInfo is a domain disk with its disk key and virtual size
+type DiskInfo struct {
+	Key           int32  `json:"key"`
+	Target        string `json:"target"`
+	Source        string `json:"source"`
+	Format        string `json:"format"`
+	ReadOnly      bool   `json:"read_only"` // Never backed up
+	CapacityBytes int64  `json:"capacity_bytes"`
+}
+
+// Inspect reads a domain's definition and the capacity of each of its disks
+func Inspect(ctx context.Context, virsh *Virsh, name string) (*DomainInfo, error) {
+	domainXML, err := virsh.DumpXML(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	domain, err := ParseDomainXML(domainXML)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &DomainInfo{Name: domain.Name, UUID: domain.UUID, Disks: make([]DiskInfo, 0, len(domain.Disks))}
+	for _, disk := range domain.Disks {
+		capacity, err := virsh.BlockCapacity(ctx, domain.UUID, disk.Target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read capacity of disk %s: %w", disk.Target, err)
+		}
+		info.Disks = append(info.Disks, DiskInfo{
+			Key:           disk.Key,
+			Target:        disk.Target,
+			Source:        disk.Source,
+			Format:        disk.Format,
+			ReadOnly:      disk.ReadOnly,
+			CapacityBytes: capacity,
+		})
+	}
+	return info, nil
+}
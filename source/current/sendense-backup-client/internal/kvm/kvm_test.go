@@ -0,0 +1,130 @@
+package kvm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vexxhost/migratekit/internal/vmware"
+)
+
+const testDomainXML = `<domain type='kvm' id='4'>
+  <name>pgtest1</name>
+  <uuid>8a6d1e5c-1f2b-4c3d-9e8f-0a1b2c3d4e5f</uuid>
+  <devices>
+    <disk type='file' device='disk'>
+      <driver name='qemu' type='qcow2'/>
+      <source file='/var/lib/libvirt/images/pgtest1.qcow2'/>
+      <target dev='vda' bus='virtio'/>
+    </disk>
+    <disk type='file' device='cdrom'>
+      <source file='/var/lib/libvirt/images/seed.iso'/>
+      <target dev='sda' bus='sata'/>
+      <readonly/>
+    </disk>
+    <disk type='block' device='disk'>
+      <driver name='qemu' type='raw'/>
+      <source dev='/dev/vg0/pgtest1-data'/>
+      <target dev='vdb' bus='virtio'/>
+    </disk>
+  </devices>
+</domain>`
+
+func TestParseDomainXMLNumbersDisksLikeVMwareKeys(t *testing.T) {
+	domain, err := ParseDomainXML([]byte(testDomainXML))
+	require.NoError(t, err)
+
+	assert.Equal(t, "pgtest1", domain.Name)
+	assert.Equal(t, "8a6d1e5c-1f2b-4c3d-9e8f-0a1b2c3d4e5f", domain.UUID)
+	assert.Equal(t, []Disk{
+		{Key: 2000, Target: "vda", Source: "/var/lib/libvirt/images/pgtest1.qcow2", Format: "qcow2"},
+		{Key: 2001, Target: "vdb", Source: "/dev/vg0/pgtest1-data", Format: "raw"},
+	}, domain.Disks)
+
+	_, err = ParseDomainXML([]byte(`<domain><name>x</name></domain>`))
+	assert.Error(t, err)
+}
+
+func TestVirtualDiskCarriesParseableChangeID(t *testing.T) {
+	disk := Disk{Key: 2001, Target: "vdb", Source: "/dev/vg0/data"}
+	changeID := ChangeID("8a6d1e5c", CheckpointName("backup-pgtest1-20251018"))
+
+	parsed, err := vmware.GetChangeID(disk.VirtualDisk(changeID))
+	require.NoError(t, err)
+	assert.Equal(t, "8a6d1e5c", parsed.UUID)
+	assert.Equal(t, "sbak-backup-pgtest1-20251018", parsed.Number)
+	assert.Equal(t, int32(2001), disk.VirtualDisk(changeID).Key)
+}
+
+func TestBuildBackupXMLMixesFullIncrementalAndExcludedDisks(t *testing.T) {
+	disks := []DiskBackup{
+		{Disk: Disk{Target: "vda"}, Checkpoint: "sbak-backup-1"},
+		{Disk: Disk{Target: "vdb"}},
+		{Disk: Disk{Target: "vdc"}, Excluded: true},
+	}
+
+	backup, err := BuildBackupXML(NBDServer{Host: "10.0.0.5", Port: 10809}, disks, "/var/tmp", "backup-2")
+	require.NoError(t, err)
+	xml := string(backup)
+
+	assert.Contains(t, xml, `<domainbackup mode="pull">`)
+	assert.Contains(t, xml, `<server transport="tcp" name="10.0.0.5" port="10809"></server>`)
+	assert.Contains(t, xml, `<disk name="vda" backup="yes" backupmode="incremental" incremental="sbak-backup-1" exportname="vda" exportbitmap="backup-vda">`)
+	assert.Contains(t, xml, `<disk name="vdb" backup="yes" backupmode="full" exportname="vdb">`)
+	assert.Contains(t, xml, `<scratch file="/var/tmp/backup-2-vdb.scratch.qcow2"></scratch>`)
+	assert.Contains(t, xml, `<disk name="vdc" backup="no"></disk>`)
+
+	checkpoint, err := BuildCheckpointXML("sbak-backup-2", "", disks)
+	require.NoError(t, err)
+	assert.Contains(t, string(checkpoint), `<name>sbak-backup-2</name>`)
+	assert.Contains(t, string(checkpoint), `<disk name="vda" checkpoint="bitmap"></disk>`)
+	assert.Contains(t, string(checkpoint), `<disk name="vdc" checkpoint="no"></disk>`)
+
+	unix, err := BuildBackupXML(NBDServer{Socket: "/run/sendense/backup.sock"}, disks[:1], "", "backup-2")
+	require.NoError(t, err)
+	assert.Contains(t, string(unix), `<server transport="unix" socket="/run/sendense/backup.sock"></server>`)
+	assert.False(t, strings.Contains(string(unix), "scratch"))
+}
+
+func TestNBDServerURI(t *testing.T) {
+	assert.Equal(t, "nbd://10.0.0.5:10809/vda", NBDServer{Host: "10.0.0.5", Port: 10809}.URI("vda"))
+	assert.Equal(t, "nbd+unix:///vdb?socket=/run/backup.sock", NBDServer{Socket: "/run/backup.sock"}.URI("vdb"))
+}
+
+func TestAppendExtentsSelectsAndMergesRanges(t *testing.T) {
+	// Dirty bitmap reply: 4K dirty, 4K dirty, 8K clean, 4K dirty
+	extents, end := AppendExtents(nil, 0, []uint32{4096, 1, 4096, 1, 8192, 0, 4096, 1}, DirtyFilter)
+	assert.Equal(t, []Extent{{Offset: 0, Length: 8192}, {Offset: 16384, Length: 4096}}, extents)
+	assert.Equal(t, uint64(20480), end)
+
+	// A following reply continues the last range
+	extents, end = AppendExtents(extents, end, []uint32{4096, 1}, DirtyFilter)
+	assert.Equal(t, []Extent{{Offset: 0, Length: 8192}, {Offset: 16384, Length: 8192}}, extents)
+	assert.Equal(t, uint64(24576), end)
+	assert.Equal(t, int64(16384), TotalBytes(extents))
+
+	// Allocation reply: data, hole, zero, data
+	extents, _ = AppendExtents(nil, 0, []uint32{1024, 0, 1024, StateHole | StateZero, 1024, StateZero, 1024, 0}, AllocatedFilter)
+	assert.Equal(t, []Extent{{Offset: 0, Length: 1024}, {Offset: 3072, Length: 1024}}, extents)
+}
+
+func TestSupersededCheckpointsKeepOtherJobTypes(t *testing.T) {
+	names := []string{"sbak-backup-1", "srep-repl-1", "sbak-backup-2", "manual"}
+
+	assert.Equal(t, "sbak-", CheckpointPrefix("backup-2"))
+	assert.Equal(t, "srep-", CheckpointPrefix("job-20251018"))
+	assert.Equal(t, []string{"sbak-backup-1"}, SupersededCheckpoints(names, "sbak-", "sbak-backup-2"))
+	assert.True(t, HasCheckpoint(names, "srep-repl-1"))
+	assert.False(t, HasCheckpoint(names, "sbak-backup-3"))
+}
+
+func TestParseBlockCapacity(t *testing.T) {
+	out := []byte("Capacity:       10737418240\nAllocation:     2147483648\nPhysical:       2147483648\n")
+	capacity, err := parseBlockCapacity(out)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10737418240), capacity)
+
+	_, err = parseBlockCapacity([]byte("Allocation: 1\n"))
+	assert.Error(t, err)
+}
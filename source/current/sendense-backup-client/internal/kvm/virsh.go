@@ -0,0 +1,137 @@
+package kvm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Virsh runs virsh against one libvirt connection
+type Virsh struct {
+	uri string
+}
+
+// NewVirsh creates a virsh runner for a libvirt connection URI
+// (qemu:///system, qemu+ssh://root@host/system...); empty uses virsh's default
+func NewVirsh(uri string) *Virsh {
+	return &Virsh{uri: uri}
+}
+
+func (v *Virsh) run(ctx context.Context, args ...string) ([]byte, error) {
+	command := args[0]
+	if v.uri != "" {
+		args = append([]string{"-c", v.uri}, args...)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "virsh", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	log.WithField("args", args).Debug("Running virsh")
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("virsh %s failed: %w: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// DumpXML returns the live definition of a domain (name or UUID)
+func (v *Virsh) DumpXML(ctx context.Context, domain string) ([]byte, error) {
+	return v.run(ctx, "dumpxml", domain)
+}
+
+// BlockCapacity returns the virtual size in bytes of a domain disk (guest device name)
+func (v *Virsh) BlockCapacity(ctx context.Context, domain, target string) (int64, error) {
+	out, err := v.run(ctx, "domblkinfo", domain, target)
+	if err != nil {
+		return 0, err
+	}
+	return parseBlockCapacity(out)
+}
+
+// parseBlockCapacity reads the Capacity line (bytes) of `virsh domblkinfo` output
+func parseBlockCapacity(out []byte) (int64, error) {
+	for _, line := range strings.Split(string(out), "\n") {
+		name, value, found := strings.Cut(line, ":")
+		if found && strings.TrimSpace(name) == "Capacity" {
+			capacity, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid disk capacity %q: %w", strings.TrimSpace(value), err)
+			}
+			return capacity, nil
+		}
+	}
+	return 0, fmt.Errorf("no capacity in domblkinfo output")
+}
+
+// ListCheckpoints returns the names of a domain's checkpoints
+func (v *Virsh) ListCheckpoints(ctx context.Context, domain string) ([]string, error) {
+	out, err := v.run(ctx, "checkpoint-list", domain, "--name")
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// BackupBegin starts a pull-mode backup job exporting the domain's disks over NBD and
+// creates the checkpoint the next incremental will be based on. Quiesce freezes guest
+// filesystems through the QEMU guest agent while the point in time is taken.
+func (v *Virsh) BackupBegin(ctx context.Context, domain string, backupXML, checkpointXML []byte, quiesce bool) error {
+	backupFile, err := writeTempXML("backup", backupXML)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(backupFile)
+
+	checkpointFile, err := writeTempXML("checkpoint", checkpointXML)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(checkpointFile)
+
+	args := []string{"backup-begin", domain, "--backupxml", backupFile, "--checkpointxml", checkpointFile}
+	if quiesce {
+		args = append(args, "--quiesce")
+	}
+	_, err = v.run(ctx, args...)
+	return err
+}
+
+// DomJobAbort ends the domain's backup job, stopping the NBD exports
+func (v *Virsh) DomJobAbort(ctx context.Context, domain string) error {
+	_, err := v.run(ctx, "domjobabort", domain)
+	return err
+}
+
+// DeleteCheckpoint deletes a checkpoint; its dirty bitmaps are merged into the parent
+func (v *Virsh) DeleteCheckpoint(ctx context.Context, domain, name string) error {
+	_, err := v.run(ctx, "checkpoint-delete", domain, name)
+	return err
+}
+
+func writeTempXML(kind string, data []byte) (string, error) {
+	file, err := os.CreateTemp("", "sendense-"+kind+"-*.xml")
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s XML file: %w", kind, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write %s XML file: %w", kind, err)
+	}
+	return file.Name(), nil
+}
@@ -0,0 +1,373 @@
+// Package kvm_nbd copies the disks of libvirt pull-mode backups to the NBD targets
+package kvm_nbd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vexxhost/migratekit/internal/events"
+	"github.com/vexxhost/migratekit/internal/jobcontrol"
+	"github.com/vexxhost/migratekit/internal/kvm"
+	"github.com/vexxhost/migratekit/internal/target"
+	"github.com/vexxhost/migratekit/internal/telemetry"
+	"github.com/vexxhost/migratekit/internal/vmware"
+	"github.com/vexxhost/migratekit/internal/vmware_nbdkit"
+	"libguestfs.org/libnbd"
+)
+
+// blockStatusChunk limits the range of one block status request
+const blockStatusChunk = 1 << 30
+
+// BackupConfig describes a backup of one libvirt domain
+type BackupConfig struct {
+	LibvirtURI       string
+	Domain           string // Domain name or UUID
+	Server           kvm.NBDServer
+	ScratchDir       string
+	Consistency      string // vmware.Consistency* level requested
+	ExcludedDiskKeys []int
+	JobID            string
+}
+
+// diskJob is a disk being backed up with its NBD target
+type diskJob struct {
+	kvm.DiskBackup
+	target        *target.NBDTarget
+	targetIsClean bool
+}
+
+// Backup runs a libvirt backup job
+type Backup struct {
+	config     BackupConfig
+	virsh      *kvm.Virsh
+	domain     *kvm.Domain
+	checkpoint string
+	disks      []*diskJob
+}
+
+// NewBackup creates a backup of a libvirt domain
+func NewBackup(config BackupConfig) *Backup {
+	return &Backup{
+		config:     config,
+		virsh:      kvm.NewVirsh(config.LibvirtURI),
+		checkpoint: kvm.CheckpointName(config.JobID),
+	}
+}
+
+// Run starts the libvirt backup job, copies each included disk (in full or the blocks
+// dirtied since its last checkpoint) and records the new checkpoint as the disk's
+// change ID. The checkpoint is deleted again if any disk fails, so the next backup
+// stays incremental against the last complete one.
+func (b *Backup) Run(ctx context.Context) (err error) {
+	domainXML, err := b.virsh.DumpXML(ctx, b.config.Domain)
+	if err != nil {
+		return events.WithCode(events.CodeSnapshot, err)
+	}
+	b.domain, err = kvm.ParseDomainXML(domainXML)
+	if err != nil {
+		return events.WithCode(events.CodeSnapshot, err)
+	}
+
+	logger := log.WithFields(log.Fields{
+		"domain":     b.domain.Name,
+		"uuid":       b.domain.UUID,
+		"checkpoint": b.checkpoint,
+	})
+
+	checkpoints, err := b.virsh.ListCheckpoints(ctx, b.domain.UUID)
+	if err != nil {
+		return events.WithCode(events.CodeSnapshot, err)
+	}
+
+	if err := b.prepareDisks(ctx, checkpoints); err != nil {
+		return err
+	}
+	defer func() {
+		for _, disk := range b.disks {
+			if disk.target != nil {
+				disk.target.Disconnect(ctx)
+			}
+		}
+	}()
+
+	if err := b.begin(ctx); err != nil {
+		return events.WithCode(events.CodeSnapshot, err)
+	}
+
+	// The exports and scratch files go away with the job; a failed backup also drops
+	// its checkpoint, whose bitmaps never made it into a recorded change ID
+	finish := func(succeeded bool) {
+		cleanupCtx := context.Background()
+		if abortErr := b.virsh.DomJobAbort(cleanupCtx, b.domain.UUID); abortErr != nil {
+			logger.WithError(abortErr).Warn("⚠️ Failed to end libvirt backup job")
+		}
+		if !succeeded {
+			if deleteErr := b.virsh.DeleteCheckpoint(cleanupCtx, b.domain.UUID, b.checkpoint); deleteErr != nil {
+				logger.WithError(deleteErr).Warn("⚠️ Failed to delete checkpoint of failed backup")
+			}
+		}
+	}
+	defer func() {
+		finish(err == nil)
+	}()
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(c)
+	go func() {
+		if _, ok := <-c; !ok {
+			return
+		}
+		log.Warn("Received interrupt signal, cleaning up...")
+		finish(false)
+		os.Exit(1)
+	}()
+
+	// A cancel from the SNA stops the copy; the deferred cleanup still ends the job
+	copyCtx, cancelCopy := jobcontrol.FromContext(ctx).Bind(ctx)
+	defer cancelCopy()
+
+	events.FromContext(ctx).Status("running", "transferring")
+
+	for _, disk := range b.disks {
+		if disk.Excluded {
+			continue
+		}
+		if err := b.copyDisk(copyCtx, disk); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range kvm.SupersededCheckpoints(checkpoints, kvm.CheckpointPrefix(b.config.JobID), b.checkpoint) {
+		if deleteErr := b.virsh.DeleteCheckpoint(ctx, b.domain.UUID, name); deleteErr != nil {
+			logger.WithError(deleteErr).WithField("old_checkpoint", name).Warn("⚠️ Failed to delete superseded checkpoint")
+		} else {
+			logger.WithField("old_checkpoint", name).Info("🗑️  Deleted superseded checkpoint")
+		}
+	}
+
+	logger.Info("✅ KVM backup completed")
+	return nil
+}
+
+// prepareDisks connects each included disk's NBD target and decides between a full and
+// an incremental backup; an incremental needs the recorded base checkpoint to still exist
+func (b *Backup) prepareDisks(ctx context.Context, checkpoints []string) error {
+	changeID := kvm.ChangeID(b.domain.UUID, b.checkpoint)
+
+	for i := range b.domain.Disks {
+		disk := &diskJob{DiskBackup: kvm.DiskBackup{Disk: b.domain.Disks[i]}}
+		b.disks = append(b.disks, disk)
+
+		if disk.Disk.ReadOnly || slices.Contains(b.config.ExcludedDiskKeys, int(disk.Disk.Key)) {
+			log.WithFields(log.Fields{
+				"disk":     disk.Disk.Target,
+				"disk_key": disk.Disk.Key,
+			}).Info("⏭️ Skipping excluded disk")
+			disk.Excluded = true
+			continue
+		}
+
+		disk.target = target.NewNBDTargetForSource(b.domain.Name, disk.Disk.VirtualDisk(changeID))
+		if err := disk.target.Connect(ctx); err != nil {
+			return events.WithCode(events.CodeTarget, err)
+		}
+
+		needFullCopy, targetIsClean, err := target.NeedsFullCopy(ctx, disk.target)
+		if err != nil {
+			return events.WithCode(events.CodeTarget, err)
+		}
+		disk.targetIsClean = targetIsClean
+		if needFullCopy {
+			continue
+		}
+
+		current, err := disk.target.GetCurrentChangeID(ctx)
+		if err != nil {
+			return events.WithCode(events.CodeChangeID, err)
+		}
+		if !kvm.HasCheckpoint(checkpoints, current.Number) {
+			log.WithFields(log.Fields{
+				"disk":       disk.Disk.Target,
+				"checkpoint": current.Number,
+			}).Warn("Base checkpoint no longer exists on the domain, full copy needed")
+			continue
+		}
+		disk.Checkpoint = current.Number
+	}
+
+	return nil
+}
+
+// begin starts the pull-mode backup, quiescing the guest through the QEMU guest
+// agent unless crash consistency was requested; without a responding agent the
+// backup falls back to crash consistency
+func (b *Backup) begin(ctx context.Context) error {
+	backups := make([]kvm.DiskBackup, len(b.disks))
+	for i, disk := range b.disks {
+		backups[i] = disk.DiskBackup
+	}
+
+	backupXML, err := kvm.BuildBackupXML(b.config.Server, backups, b.config.ScratchDir, b.config.JobID)
+	if err != nil {
+		return err
+	}
+	checkpointXML, err := kvm.BuildCheckpointXML(b.checkpoint, "Sendense job "+b.config.JobID, backups)
+	if err != nil {
+		return err
+	}
+
+	achieved, fallbackReason := vmware.ConsistencyCrash, ""
+	if b.config.Consistency != vmware.ConsistencyCrash {
+		err = b.virsh.BackupBegin(ctx, b.domain.UUID, backupXML, checkpointXML, true)
+		if err == nil {
+			achieved = vmware.ConsistencyFilesystem
+			if b.config.Consistency == vmware.ConsistencyApplication {
+				fallbackReason = "KVM guests are quiesced with guest agent fsfreeze hooks"
+			}
+		} else {
+			log.WithError(err).Warn("⚠️ Quiesced backup failed (is the QEMU guest agent running?) - retrying crash-consistent")
+			fallbackReason = fmt.Sprintf("guest agent quiesce failed: %v", err)
+			err = b.virsh.BackupBegin(ctx, b.domain.UUID, backupXML, checkpointXML, false)
+		}
+	} else {
+		err = b.virsh.BackupBegin(ctx, b.domain.UUID, backupXML, checkpointXML, false)
+	}
+	if err != nil {
+		return err
+	}
+
+	if tracker, ok := ctx.Value("telemetryTracker").(*telemetry.ProgressTracker); ok {
+		tracker.SetConsistency(achieved, fallbackReason)
+	}
+	log.WithFields(log.Fields{
+		"domain":      b.domain.Name,
+		"checkpoint":  b.checkpoint,
+		"consistency": achieved,
+	}).Info("📸 Started libvirt backup job")
+	return nil
+}
+
+// copyDisk copies one disk's export to its target and records the new change ID
+func (b *Backup) copyDisk(ctx context.Context, disk *diskJob) error {
+	sourceURI := b.config.Server.URI(disk.ExportName())
+
+	syncType := "full"
+	if disk.Checkpoint != "" {
+		syncType = "incremental"
+	}
+	events.FromContext(ctx).Transfer(disk.Disk.Key, syncType)
+
+	extents, err := queryExtents(sourceURI, disk)
+	if err != nil {
+		return events.WithCode(events.CodeTransfer, err)
+	}
+
+	log.WithFields(log.Fields{
+		"disk":      disk.Disk.Target,
+		"sync_type": syncType,
+		"extents":   len(extents),
+		"bytes":     kvm.TotalBytes(extents),
+	}).Info("📊 Queried blocks to copy")
+
+	targetNBD := disk.target.GetNBDHandle()
+	copyExtents := make([]vmware_nbdkit.DiskExtent, len(extents))
+	for i, extent := range extents {
+		copyExtents[i] = vmware_nbdkit.DiskExtent{Offset: extent.Offset, Length: extent.Length}
+	}
+
+	// Exports end with the libvirt job, so an interrupted copy can't be resumed
+	// against the same point in time and no transfer checkpoint is kept
+	err = vmware_nbdkit.CopyExtentsToTarget(ctx, vmware_nbdkit.ExtentCopy{
+		SourceURI: sourceURI,
+		TargetNBD: targetNBD,
+		Extents:   copyExtents,
+		DiskKey:   disk.Disk.Key,
+	})
+	if err != nil {
+		return events.WithCode(events.CodeTransfer, err)
+	}
+
+	if err := targetNBD.Flush(nil); err != nil {
+		return events.WithCode(events.CodeTarget, fmt.Errorf("failed to flush target: %w", err))
+	}
+
+	changeID, err := vmware.GetChangeID(disk.target.GetDisk())
+	if err != nil {
+		return events.WithCode(events.CodeChangeID, err)
+	}
+	if err := disk.target.WriteChangeID(ctx, changeID); err != nil {
+		return events.WithCode(events.CodeChangeID, err)
+	}
+	events.FromContext(ctx).ChangeID(disk.Disk.Key, changeID.Value)
+
+	return nil
+}
+
+// queryExtents lists the blocks to copy from a disk's export: the dirty bitmap of an
+// incremental, the allocated blocks of a full copy to a clean target, or the whole
+// disk when the target may hold stale data
+func queryExtents(sourceURI string, disk *diskJob) ([]kvm.Extent, error) {
+	metaContext, filter := kvm.AllocationContext, kvm.ExtentFilter(kvm.AllocatedFilter)
+	if disk.Checkpoint != "" {
+		metaContext, filter = disk.MetaContext(), kvm.DirtyFilter
+	}
+
+	handle, err := libnbd.Create()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create NBD handle: %w", err)
+	}
+	defer handle.Close()
+
+	if err := handle.AddMetaContext(metaContext); err != nil {
+		return nil, fmt.Errorf("failed to request %s: %w", metaContext, err)
+	}
+	if err := handle.ConnectUri(sourceURI); err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", sourceURI, err)
+	}
+
+	size, err := handle.GetSize()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get export size: %w", err)
+	}
+
+	if disk.Checkpoint == "" && !disk.targetIsClean {
+		return []kvm.Extent{{Offset: 0, Length: int64(size)}}, nil
+	}
+
+	supported, err := handle.CanMetaContext(metaContext)
+	if err != nil || !supported {
+		return nil, fmt.Errorf("export %s does not report %s", sourceURI, metaContext)
+	}
+
+	var extents []kvm.Extent
+	for offset := uint64(0); offset < size; {
+		count := size - offset
+		if count > blockStatusChunk {
+			count = blockStatusChunk
+		}
+
+		end := offset
+		err := handle.BlockStatus(count, offset, func(context string, start uint64, entries []uint32, _ *int) int {
+			if context != metaContext {
+				return 0
+			}
+			extents, end = kvm.AppendExtents(extents, start, entries, filter)
+			return 0
+		}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("block status failed at offset %d: %w", offset, err)
+		}
+		if end <= offset {
+			return nil, fmt.Errorf("block status made no progress at offset %d", offset)
+		}
+		offset = end
+	}
+
+	return extents, nil
+}
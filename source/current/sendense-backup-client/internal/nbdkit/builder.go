@@ -24,6 +24,7 @@ type NbdkitBuilder struct {
 	snapshot    string
 	filename    string
 	compression CompressionMethod
+	writable    bool
 }
 
 func NewNbdkitBuilder() *NbdkitBuilder {
@@ -70,6 +71,12 @@ func (b *NbdkitBuilder) Compression(method CompressionMethod) *NbdkitBuilder {
 	return b
 }
 
+// Writable opens the disk for writing (restores) - requires no snapshot so writes land on the base disk
+func (b *NbdkitBuilder) Writable() *NbdkitBuilder {
+	b.writable = true
+	return b
+}
+
 func (b *NbdkitBuilder) Build() (*NbdkitServer, error) {
 	tmp, err := os.MkdirTemp("", "migratekit-")
 	if err != nil {
@@ -80,10 +87,11 @@ func (b *NbdkitBuilder) Build() (*NbdkitServer, error) {
 	pidFile := fmt.Sprintf("%s/nbdkit.pid", tmp)
 
 	os.Setenv("LD_LIBRARY_PATH", "/usr/lib64/vmware-vix-disklib/lib64")
-	cmd := exec.Command(
-		"nbdkit",
-		"--exit-with-parent",
-		"--readonly",
+	args := []string{"--exit-with-parent"}
+	if !b.writable {
+		args = append(args, "--readonly")
+	}
+	args = append(args,
 		"--foreground",
 		fmt.Sprintf("--unix=%s", socket),
 		fmt.Sprintf("--pidfile=%s", pidFile),
@@ -94,10 +102,13 @@ func (b *NbdkitBuilder) Build() (*NbdkitServer, error) {
 		fmt.Sprintf("thumbprint=%s", b.thumbprint),
 		fmt.Sprintf("compression=%s", b.compression),
 		fmt.Sprintf("vm=moref=%s", b.vm),
-		fmt.Sprintf("snapshot=%s", b.snapshot),
-		"transports=file:nbdssl:nbd",
-		b.filename,
 	)
+	if b.snapshot != "" {
+		args = append(args, fmt.Sprintf("snapshot=%s", b.snapshot))
+	}
+	args = append(args, "transports=file:nbdssl:nbd", b.filename)
+
+	cmd := exec.Command("nbdkit", args...)
 
 	return &NbdkitServer{
 		cmd:     cmd,
@@ -28,6 +28,7 @@ type NBDTarget struct {
 	nbdPort        string
 	nbdExportName  string
 	SSHTarget      string
+	SourcePath     string // VM path of non-VMware sources (e.g. the libvirt domain name)
 }
 
 type NBDVolumeCreateOpts struct {
@@ -43,6 +44,23 @@ func NewNBDTarget(ctx context.Context, vm *object.VirtualMachine, disk *types.Vi
 	}, nil
 }
 
+// NewNBDTargetForSource creates an NBD target for a disk of a non-VMware source VM; the
+// disk carries the source driver's disk key and change ID
+func NewNBDTargetForSource(sourcePath string, disk *types.VirtualDisk) *NBDTarget {
+	return &NBDTarget{
+		Disk:       disk,
+		SourcePath: sourcePath,
+	}
+}
+
+// vmPath returns the VM path change IDs are stored under in the SHA
+func (t *NBDTarget) vmPath() string {
+	if t.VirtualMachine != nil {
+		return t.VirtualMachine.InventoryPath
+	}
+	return t.SourcePath
+}
+
 func (t *NBDTarget) GetDisk() *types.VirtualDisk {
 	return t.Disk
 }
@@ -169,7 +187,7 @@ func (t *NBDTarget) Disconnect(ctx context.Context) error {
 
 func (t *NBDTarget) Exists(ctx context.Context) (bool, error) {
 	// Check if we have a stored ChangeID in SHA database via API
-	vmPath := t.VirtualMachine.InventoryPath
+	vmPath := t.vmPath()
 
 	changeID, err := t.getChangeIDFromOMA(vmPath)
 	if err != nil {
@@ -188,7 +206,7 @@ func (t *NBDTarget) Exists(ctx context.Context) (bool, error) {
 
 func (t *NBDTarget) GetCurrentChangeID(ctx context.Context) (*vmware.ChangeID, error) {
 	// Get ChangeID from SHA database via API
-	vmPath := t.VirtualMachine.InventoryPath
+	vmPath := t.vmPath()
 
 	changeIDStr, err := t.getChangeIDFromOMA(vmPath)
 	if err != nil {
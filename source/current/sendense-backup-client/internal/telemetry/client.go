@@ -29,7 +29,13 @@ func NewClient(shaURL string) *Client {
 // SendBackupUpdate sends a backup telemetry update to SHA
 // POST /api/v1/telemetry/backup/{job_id}
 func (c *Client) SendBackupUpdate(jobID string, update *TelemetryUpdate) error {
-	url := fmt.Sprintf("%s/api/v1/telemetry/backup/%s", c.shaURL, jobID)
+	return c.SendUpdate("backup", jobID, update)
+}
+
+// SendUpdate sends a telemetry update for any job type ("backup", "restore", ...) to SHA
+// POST /api/v1/telemetry/{job_type}/{job_id}
+func (c *Client) SendUpdate(jobType, jobID string, update *TelemetryUpdate) error {
+	url := fmt.Sprintf("%s/api/v1/telemetry/%s/%s", c.shaURL, jobType, jobID)
 	
 	log.WithFields(log.Fields{
 		"job_id":   jobID,
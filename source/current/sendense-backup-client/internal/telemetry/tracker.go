@@ -5,6 +5,7 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/vexxhost/migratekit/internal/events"
 )
 
 // ProgressTracker implements hybrid cadence telemetry sending
@@ -12,10 +13,18 @@ import (
 type ProgressTracker struct {
 	client           *Client
 	jobID            string
+	jobType          string
 	lastSentTime     time.Time
 	lastSentProgress float64
 	timeInterval     time.Duration
 	progressInterval float64
+
+	// Snapshot consistency, attached to every update once known
+	consistencyLevel          string
+	consistencyFallbackReason string
+
+	// Progress and phase changes are mirrored to the SNA event stream
+	events *events.Emitter
 }
 
 // NewProgressTracker creates a new progress tracker
@@ -23,6 +32,7 @@ func NewProgressTracker(client *Client, jobID string) *ProgressTracker {
 	return &ProgressTracker{
 		client:           client,
 		jobID:            jobID,
+		jobType:          "backup",
 		lastSentTime:     time.Now(), // Initialize to now
 		lastSentProgress: 0.0,
 		timeInterval:     5 * time.Second, // Send every 5 seconds
@@ -30,6 +40,23 @@ func NewProgressTracker(client *Client, jobID string) *ProgressTracker {
 	}
 }
 
+// SetJobType sets the job type updates are reported under (default "backup")
+func (pt *ProgressTracker) SetJobType(jobType string) {
+	pt.jobType = jobType
+}
+
+// SetEventEmitter mirrors progress and phase changes to the SNA event stream; final
+// statuses are emitted once when the client exits
+func (pt *ProgressTracker) SetEventEmitter(emitter *events.Emitter) {
+	pt.events = emitter
+}
+
+// SetConsistency records the snapshot consistency achieved for this job
+func (pt *ProgressTracker) SetConsistency(level, fallbackReason string) {
+	pt.consistencyLevel = level
+	pt.consistencyFallbackReason = fallbackReason
+}
+
 // ShouldSend determines if telemetry should be sent based on hybrid cadence rules
 // Returns true if ANY of these conditions are met:
 // 1. Time-based: 5 seconds elapsed since last send
@@ -86,7 +113,7 @@ func (pt *ProgressTracker) SendIfNeeded(jobID string, update *TelemetryUpdate) e
 			"status": update.Status,
 		}).Debug("Sending telemetry for non-running state")
 		
-		err := pt.client.SendBackupUpdate(jobID, update)
+		err := pt.client.SendUpdate(pt.jobType, jobID, update)
 		if err == nil {
 			pt.lastSentTime = time.Now()
 			pt.lastSentProgress = update.ProgressPercent
@@ -113,7 +140,7 @@ func (pt *ProgressTracker) SendIfNeeded(jobID string, update *TelemetryUpdate) e
 			"speed":    update.TransferSpeedBps,
 		}).Info("📤 ATTEMPTING HTTP send to SHA")
 		
-		err := pt.client.SendBackupUpdate(jobID, update)
+		err := pt.client.SendUpdate(pt.jobType, jobID, update)
 		if err == nil {
 			pt.lastSentTime = time.Now()
 			pt.lastSentProgress = update.ProgressPercent
@@ -157,7 +184,7 @@ func (pt *ProgressTracker) UpdateProgress(
 	// Build complete telemetry update with all required fields
 	update := &TelemetryUpdate{
 		JobID:            pt.jobID,                         // ✅ FIX: Populate job ID
-		JobType:          "backup",                         // ✅ FIX: Set job type
+		JobType:          pt.jobType,                       // ✅ FIX: Set job type
 		Status:           "running",
 		CurrentPhase:     currentPhase,                     // ✅ FIX: Pass actual phase
 		BytesTransferred: bytesTransferred,
@@ -167,6 +194,9 @@ func (pt *ProgressTracker) UpdateProgress(
 		ProgressPercent:  progressPercent,                  // ✅ FIX: Calculate from bytes!
 		Timestamp:        time.Now().Format(time.RFC3339), // ✅ FIX: Add timestamp
 		Disks:            []DiskTelemetry{},                // ✅ FIX: Initialize (per-disk coming later)
+
+		ConsistencyLevel:          pt.consistencyLevel,
+		ConsistencyFallbackReason: pt.consistencyFallbackReason,
 	}
 	
 	// Log before sending for debugging
@@ -179,6 +209,8 @@ func (pt *ProgressTracker) UpdateProgress(
 		"phase":     currentPhase,
 	}).Info("🚀 Sending telemetry update to SHA")
 	
+	pt.events.Progress(bytesTransferred, totalBytes, transferSpeedBps, etaSeconds)
+
 	// Send via SendIfNeeded which handles cadence logic
 	if err := pt.SendIfNeeded(pt.jobID, update); err != nil {
 		log.WithError(err).Error("❌ FAILED to send telemetry update to SHA")
@@ -191,11 +223,14 @@ func (pt *ProgressTracker) UpdateJobStatus(ctx context.Context, status, currentP
 	// Build complete telemetry update for status change
 	update := &TelemetryUpdate{
 		JobID:        pt.jobID,                         // ✅ FIX: Include job ID
-		JobType:      "backup",                         // ✅ FIX: Set job type
+		JobType:      pt.jobType,                       // ✅ FIX: Set job type
 		Status:       status,
 		CurrentPhase: currentPhase,
 		Timestamp:    time.Now().Format(time.RFC3339), // ✅ FIX: Add timestamp
 		Disks:        []DiskTelemetry{},                // ✅ FIX: Initialize
+
+		ConsistencyLevel:          pt.consistencyLevel,
+		ConsistencyFallbackReason: pt.consistencyFallbackReason,
 	}
 	
 	if errorMessage != "" {
@@ -205,6 +240,10 @@ func (pt *ProgressTracker) UpdateJobStatus(ctx context.Context, status, currentP
 		}
 	}
 	
+	if status == "running" {
+		pt.events.Status(status, currentPhase)
+	}
+
 	log.WithFields(log.Fields{
 		"job_id": pt.jobID,
 		"status": status,
@@ -212,7 +251,7 @@ func (pt *ProgressTracker) UpdateJobStatus(ctx context.Context, status, currentP
 	}).Info("🚀 Sending job status telemetry to SHA")
 	
 	// Always send status changes (non-running state triggers immediate send)
-	if err := pt.client.SendBackupUpdate(pt.jobID, update); err != nil {
+	if err := pt.client.SendUpdate(pt.jobType, pt.jobID, update); err != nil {
 		log.WithError(err).Error("❌ FAILED to send job status telemetry to SHA")
 	}
 }
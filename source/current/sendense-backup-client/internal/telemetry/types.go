@@ -14,6 +14,10 @@ type TelemetryUpdate struct {
 	Disks            []DiskTelemetry `json:"disks"`
 	Error            *ErrorInfo      `json:"error,omitempty"`
 	Timestamp        string          `json:"timestamp"`
+
+	// Snapshot consistency actually achieved ("application", "filesystem", "crash")
+	ConsistencyLevel          string `json:"consistency_level,omitempty"`
+	ConsistencyFallbackReason string `json:"consistency_fallback_reason,omitempty"`
 }
 
 // DiskTelemetry represents per-disk progress
@@ -0,0 +1,144 @@
+package vmware
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Snapshot consistency levels, strongest first
+const (
+	ConsistencyApplication = "application" // VSS writers (Windows) - databases flushed and log-consistent
+	ConsistencyFilesystem  = "filesystem"  // VMware Tools quiesce - file systems flushed and frozen
+	ConsistencyCrash       = "crash"       // No quiescing - equivalent to pulling the power cord
+)
+
+// VSS backup types for application-consistent snapshots
+const (
+	VSSBackupTypeFull = "full" // Truncates application logs (e.g. Exchange, SQL Server)
+	VSSBackupTypeCopy = "copy" // Leaves application logs untouched
+)
+
+// VSS_BACKUP_TYPE values passed to VMware Tools
+const (
+	vssBTFull int32 = 1
+	vssBTCopy int32 = 5
+)
+
+// quiesceTimeoutMinutes is how long VMware Tools may hold the guest quiesced (vSphere minimum is 5)
+const quiesceTimeoutMinutes = 5
+
+// ConsistencyPolicy is the snapshot consistency requested for a VM
+type ConsistencyPolicy struct {
+	Level         string
+	VSSBackupType string
+}
+
+// Validate checks the policy uses known levels and VSS backup types
+func (p ConsistencyPolicy) Validate() error {
+	switch p.Level {
+	case ConsistencyApplication, ConsistencyFilesystem, ConsistencyCrash:
+	default:
+		return fmt.Errorf("invalid consistency level %q (must be application, filesystem or crash)", p.Level)
+	}
+
+	switch p.VSSBackupType {
+	case "", VSSBackupTypeFull, VSSBackupTypeCopy:
+	default:
+		return fmt.Errorf("invalid VSS backup type %q (must be full or copy)", p.VSSBackupType)
+	}
+
+	return nil
+}
+
+// SnapshotConsistency records the consistency a snapshot actually achieved
+type SnapshotConsistency struct {
+	Requested      string
+	Achieved       string
+	FallbackReason string // Why the requested level was not achieved (empty when it was)
+}
+
+// SnapshotPlan is the ordered list of consistency levels to attempt for a VM,
+// from the requested level down to crash-consistent
+type SnapshotPlan struct {
+	Requested string
+	Levels    []string
+	Reason    string // Why the plan starts below the requested level, if it does
+
+	vm            *object.VirtualMachine
+	windows       bool
+	vssBackupType string
+}
+
+// PlanSnapshot builds the fallback chain for a consistency policy
+func PlanSnapshot(ctx context.Context, vm *object.VirtualMachine, policy ConsistencyPolicy) *SnapshotPlan {
+	plan := &SnapshotPlan{
+		Requested:     policy.Level,
+		vm:            vm,
+		vssBackupType: policy.VSSBackupType,
+	}
+
+	if policy.Level != ConsistencyCrash {
+		windows, err := isWindowsGuest(ctx, vm)
+		if err != nil {
+			log.WithError(err).Warn("⚠️ Could not determine guest OS family, assuming non-Windows for quiescing")
+		}
+		plan.windows = windows
+	}
+
+	switch policy.Level {
+	case ConsistencyApplication:
+		if plan.windows {
+			plan.Levels = []string{ConsistencyApplication, ConsistencyFilesystem, ConsistencyCrash}
+		} else {
+			// Non-Windows guests have no VSS writers - use pre-snapshot hooks for application freezes
+			plan.Levels = []string{ConsistencyFilesystem, ConsistencyCrash}
+			plan.Reason = "application-consistent VSS snapshots require a Windows guest"
+		}
+	case ConsistencyFilesystem:
+		plan.Levels = []string{ConsistencyFilesystem, ConsistencyCrash}
+	default:
+		plan.Levels = []string{ConsistencyCrash}
+	}
+
+	return plan
+}
+
+// CreateSnapshot starts a snapshot task quiesced for the given consistency level
+func (p *SnapshotPlan) CreateSnapshot(ctx context.Context, name, description, level string) (*object.Task, error) {
+	switch level {
+	case ConsistencyApplication:
+		bootable := true
+		return p.vm.CreateSnapshotEx(ctx, name, description, false, &types.VirtualMachineWindowsQuiesceSpec{
+			VirtualMachineGuestQuiesceSpec: types.VirtualMachineGuestQuiesceSpec{Timeout: quiesceTimeoutMinutes},
+			VssBackupType:                  p.vssBackupTypeValue(),
+			VssBootableSystemState:         &bootable,
+			VssBackupContext:               string(types.VirtualMachineWindowsQuiesceSpecVssBackupContextCtx_backup),
+		})
+	case ConsistencyFilesystem:
+		if p.windows {
+			// File-share backup context quiesces NTFS without invoking application writers
+			return p.vm.CreateSnapshotEx(ctx, name, description, false, &types.VirtualMachineWindowsQuiesceSpec{
+				VirtualMachineGuestQuiesceSpec: types.VirtualMachineGuestQuiesceSpec{Timeout: quiesceTimeoutMinutes},
+				VssBackupType:                  vssBTCopy,
+				VssBackupContext:               string(types.VirtualMachineWindowsQuiesceSpecVssBackupContextCtx_file_share_backup),
+			})
+		}
+		return p.vm.CreateSnapshotEx(ctx, name, description, false, &types.VirtualMachineGuestQuiesceSpec{
+			Timeout: quiesceTimeoutMinutes,
+		})
+	default:
+		return p.vm.CreateSnapshot(ctx, name, description, false, false)
+	}
+}
+
+// vssBackupTypeValue maps the policy's VSS backup type to VSS_BACKUP_TYPE (copy by default)
+func (p *SnapshotPlan) vssBackupTypeValue() int32 {
+	if p.vssBackupType == VSSBackupTypeFull {
+		return vssBTFull
+	}
+	return vssBTCopy
+}
@@ -0,0 +1,167 @@
+package vmware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vmware/govmomi/guest"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Guest hook types
+const (
+	GuestHookPreSnapshot  = "pre_snapshot"
+	GuestHookPostSnapshot = "post_snapshot"
+)
+
+// GuestHook is a customer script run inside the guest via VMware Tools around snapshot creation
+type GuestHook struct {
+	Name           string `json:"name"`
+	HookType       string `json:"hook_type"`
+	Command        string `json:"command"`
+	TimeoutSeconds int    `json:"timeout_seconds"`
+	OnFailure      string `json:"on_failure"` // "fail" or "continue"
+	GuestUsername  string `json:"guest_username"`
+	GuestPassword  string `json:"guest_password"`
+}
+
+// LoadGuestHooks reads the hooks file written by the SNA and removes it,
+// since it contains guest credentials
+func LoadGuestHooks(path string) ([]GuestHook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read guest hooks file: %w", err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		log.WithError(err).WithField("path", path).Warn("⚠️ Failed to remove guest hooks file")
+	}
+
+	var hooks []GuestHook
+	if err := json.Unmarshal(data, &hooks); err != nil {
+		return nil, fmt.Errorf("failed to parse guest hooks file: %w", err)
+	}
+
+	return hooks, nil
+}
+
+// RunGuestHooks runs all hooks of the given type in order
+// Returns an error only when a hook with on_failure=fail fails
+func RunGuestHooks(ctx context.Context, vm *object.VirtualMachine, hooks []GuestHook, hookType string) error {
+	for _, hook := range hooks {
+		if hook.HookType != hookType {
+			continue
+		}
+
+		logger := log.WithFields(log.Fields{
+			"hook":      hook.Name,
+			"hook_type": hook.HookType,
+		})
+		logger.Info("🪝 Running guest hook via VMware Tools")
+
+		if err := runGuestHook(ctx, vm, hook); err != nil {
+			if hook.OnFailure == "continue" {
+				logger.WithError(err).Warn("⚠️ Guest hook failed, continuing per on_failure policy")
+				continue
+			}
+			return fmt.Errorf("%s hook %s failed: %w", hookType, hook.Name, err)
+		}
+
+		logger.Info("✅ Guest hook completed")
+	}
+
+	return nil
+}
+
+// runGuestHook starts the hook command in the guest and waits for it to exit
+func runGuestHook(ctx context.Context, vm *object.VirtualMachine, hook GuestHook) error {
+	timeout := time.Duration(hook.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 300 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	opsManager := guest.NewOperationsManager(vm.Client(), vm.Reference())
+	processManager, err := opsManager.ProcessManager(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get guest process manager: %w", err)
+	}
+
+	auth := &types.NamePasswordAuthentication{
+		Username: hook.GuestUsername,
+		Password: hook.GuestPassword,
+	}
+
+	spec, err := guestProgramSpec(ctx, vm, hook.Command)
+	if err != nil {
+		return err
+	}
+
+	pid, err := processManager.StartProgram(ctx, auth, spec)
+	if err != nil {
+		return fmt.Errorf("failed to start guest program: %w", err)
+	}
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		processes, err := processManager.ListProcesses(ctx, auth, []int64{pid})
+		if err != nil {
+			return fmt.Errorf("failed to query guest process %d: %w", pid, err)
+		}
+
+		if len(processes) > 0 && processes[0].EndTime != nil {
+			if processes[0].ExitCode != 0 {
+				return fmt.Errorf("guest command exited with code %d", processes[0].ExitCode)
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			// Best effort - don't leave a hung freeze script running in the guest
+			processManager.TerminateProcess(context.Background(), auth, pid)
+			return fmt.Errorf("guest command timed out after %s", timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// guestProgramSpec wraps the command in the guest's shell based on its OS family
+func guestProgramSpec(ctx context.Context, vm *object.VirtualMachine, command string) (*types.GuestProgramSpec, error) {
+	windows, err := isWindowsGuest(ctx, vm)
+	if err != nil {
+		return nil, err
+	}
+
+	if windows {
+		return &types.GuestProgramSpec{
+			ProgramPath: `C:\Windows\System32\cmd.exe`,
+			Arguments:   "/c " + command,
+		}, nil
+	}
+
+	return &types.GuestProgramSpec{
+		ProgramPath: "/bin/sh",
+		Arguments:   "-c '" + strings.ReplaceAll(command, "'", `'\''`) + "'",
+	}, nil
+}
+
+// isWindowsGuest reports whether VMware Tools identifies the guest as Windows
+func isWindowsGuest(ctx context.Context, vm *object.VirtualMachine) (bool, error) {
+	var o mo.VirtualMachine
+	if err := vm.Properties(ctx, vm.Reference(), []string{"guest.guestFamily"}, &o); err != nil {
+		return false, fmt.Errorf("failed to get guest family: %w", err)
+	}
+
+	return o.Guest != nil && o.Guest.GuestFamily == string(types.VirtualMachineGuestOsFamilyWindowsGuest), nil
+}
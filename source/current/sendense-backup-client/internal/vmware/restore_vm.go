@@ -0,0 +1,520 @@
+package vmware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Restore modes
+const (
+	RestoreModeNewVM   = "new_vm"   // Create a new VM from the captured configuration
+	RestoreModeInPlace = "in_place" // Overwrite the disks of the original (powered off) VM
+)
+
+// RestoreSpec describes a restore of a backup to VMware, written by the SNA for the restore command
+type RestoreSpec struct {
+	Mode         string            `json:"mode"`
+	VMPath       string            `json:"vm_path,omitempty"`     // In-place: VM whose disks are overwritten
+	TargetName   string            `json:"target_name,omitempty"` // New VM: name of the VM to create
+	Datacenter   string            `json:"datacenter,omitempty"`
+	Folder       string            `json:"folder,omitempty"`
+	ResourcePool string            `json:"resource_pool,omitempty"`
+	Datastore    string            `json:"datastore,omitempty"`
+	NetworkMap   map[string]string `json:"network_map,omitempty"` // Source network name -> target network name
+	PreserveMACs bool              `json:"preserve_macs"`
+	PowerOn      bool              `json:"power_on"`
+	VMConfig     *VMConfiguration  `json:"vm_config,omitempty"`
+	Disks        []RestoreDisk     `json:"disks"`
+}
+
+// RestoreDisk is one backup disk to write back, matched to the VM by its VMware disk key
+type RestoreDisk struct {
+	DiskKey   int32  `json:"disk_key"`
+	SizeBytes int64  `json:"size_bytes"`
+	SourceURL string `json:"source_url,omitempty"` // nbd://host:port/export serving the backup QCOW2 (empty when Blank)
+	Blank     bool   `json:"blank"`                // Disk was excluded from the backup - left empty
+	ChangeID  string `json:"change_id,omitempty"`  // CBT change ID recorded by the backup (in-place only)
+}
+
+// VMConfiguration mirrors the VM configuration sidecar captured by the SHA with each backup
+// Only the fields needed to recreate a VM are declared
+type VMConfiguration struct {
+	Name              string               `json:"name"`
+	GuestID           string               `json:"guest_id"`
+	HardwareVersion   string               `json:"hardware_version"`
+	Annotation        string               `json:"annotation,omitempty"`
+	NumCPUs           int                  `json:"num_cpus"`
+	CoresPerSocket    int                  `json:"cores_per_socket"`
+	MemoryMB          int                  `json:"memory_mb"`
+	CPUHotAddEnabled  bool                 `json:"cpu_hot_add_enabled"`
+	MemHotAddEnabled  bool                 `json:"memory_hot_add_enabled"`
+	CPUReservationMHz int64                `json:"cpu_reservation_mhz,omitempty"`
+	MemReservationMB  int64                `json:"memory_reservation_mb,omitempty"`
+	Firmware          string               `json:"firmware"`
+	SecureBoot        bool                 `json:"secure_boot"`
+	VTPM              bool                 `json:"vtpm"`
+	NICs              []VMConfigNIC        `json:"nics"`
+	Controllers       []VMConfigController `json:"controllers"`
+	Disks             []VMConfigDisk       `json:"disks"`
+	CustomAttributes  map[string]string    `json:"custom_attributes,omitempty"`
+	Tags              []VMConfigTag        `json:"tags,omitempty"`
+}
+
+// VMConfigNIC describes one virtual network adapter
+type VMConfigNIC struct {
+	Key            int32  `json:"key"`
+	AdapterType    string `json:"adapter_type"`
+	MACAddress     string `json:"mac_address"`
+	NetworkName    string `json:"network_name"`
+	Connected      bool   `json:"connected"`
+	StartConnected bool   `json:"start_connected"`
+}
+
+// VMConfigController describes one storage controller
+type VMConfigController struct {
+	Key       int32  `json:"key"`
+	Type      string `json:"type"`
+	BusNumber int32  `json:"bus_number"`
+	SharedBus string `json:"shared_bus,omitempty"`
+}
+
+// VMConfigDisk describes one virtual disk's placement on its controller
+type VMConfigDisk struct {
+	Key           int32  `json:"key"`
+	ControllerKey int32  `json:"controller_key"`
+	UnitNumber    int32  `json:"unit_number"`
+	CapacityBytes int64  `json:"capacity_bytes"`
+	DiskMode      string `json:"disk_mode,omitempty"`
+}
+
+// VMConfigTag is a vSphere tag attached to the VM
+type VMConfigTag struct {
+	Category string `json:"category"`
+	Name     string `json:"name"`
+}
+
+// LoadRestoreSpec reads the restore spec file written by the SNA and removes it
+func LoadRestoreSpec(path string) (*RestoreSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read restore spec file: %w", err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		log.WithError(err).WithField("path", path).Warn("⚠️ Failed to remove restore spec file")
+	}
+
+	var spec RestoreSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse restore spec file: %w", err)
+	}
+
+	switch spec.Mode {
+	case RestoreModeNewVM:
+		if spec.VMConfig == nil {
+			return nil, fmt.Errorf("new VM restore requires the backup's VM configuration")
+		}
+		if spec.TargetName == "" {
+			spec.TargetName = spec.VMConfig.Name
+		}
+	case RestoreModeInPlace:
+		if spec.VMPath == "" {
+			return nil, fmt.Errorf("in-place restore requires vm_path")
+		}
+	default:
+		return nil, fmt.Errorf("invalid restore mode %q (valid: %s, %s)", spec.Mode, RestoreModeNewVM, RestoreModeInPlace)
+	}
+
+	if len(spec.Disks) == 0 {
+		return nil, fmt.Errorf("restore spec contains no disks")
+	}
+
+	return &spec, nil
+}
+
+// CreateRestoreVM creates a new VM from the backup's VM configuration and returns
+// it with its new disks keyed by the source VM's disk keys
+func CreateRestoreVM(ctx context.Context, c *vim25.Client, spec *RestoreSpec) (*object.VirtualMachine, map[int32]*types.VirtualDisk, error) {
+	cfg := spec.VMConfig
+
+	finder := find.NewFinder(c, true)
+	dc, err := finder.DatacenterOrDefault(ctx, spec.Datacenter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find datacenter: %w", err)
+	}
+	finder.SetDatacenter(dc)
+
+	if _, err := finder.VirtualMachine(ctx, spec.TargetName); err == nil {
+		return nil, nil, fmt.Errorf("a VM named %s already exists", spec.TargetName)
+	}
+
+	folder, err := finder.FolderOrDefault(ctx, spec.Folder)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find folder: %w", err)
+	}
+	pool, err := finder.ResourcePoolOrDefault(ctx, spec.ResourcePool)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find resource pool: %w", err)
+	}
+	datastore, err := finder.DatastoreOrDefault(ctx, spec.Datastore)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find datastore: %w", err)
+	}
+	datastorePath := fmt.Sprintf("[%s]", datastore.Name())
+
+	var deviceChange []types.BaseVirtualDeviceConfigSpec
+	addDevice := func(device types.BaseVirtualDevice, fileOp types.VirtualDeviceConfigSpecFileOperation) {
+		deviceChange = append(deviceChange, &types.VirtualDeviceConfigSpec{
+			Operation:     types.VirtualDeviceConfigSpecOperationAdd,
+			FileOperation: fileOp,
+			Device:        device,
+		})
+	}
+
+	// Controllers use temporary negative keys so disks can reference them in the same spec
+	// IDE controllers always exist on a new VM (keys 200 and 201)
+	controllerKeys := make(map[int32]int32, len(cfg.Controllers))
+	controllerTypes := make(map[int32]VMConfigController, len(cfg.Controllers))
+	nextKey := int32(-100)
+	for _, controller := range cfg.Controllers {
+		controllerTypes[controller.Key] = controller
+		if controller.Type == "ide" {
+			controllerKeys[controller.Key] = 200 + controller.BusNumber
+			continue
+		}
+
+		device, err := newRestoreController(controller, nextKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		controllerKeys[controller.Key] = nextKey
+		addDevice(device, "")
+		nextKey--
+	}
+
+	// Disks are created thin on the target datastore, keeping their controller placement
+	placements := make(map[int32]string, len(cfg.Disks))
+	for _, disk := range cfg.Disks {
+		controllerKey, ok := controllerKeys[disk.ControllerKey]
+		if !ok {
+			return nil, nil, fmt.Errorf("disk %d references unknown controller %d", disk.Key, disk.ControllerKey)
+		}
+
+		capacity := disk.CapacityBytes
+		for _, restoreDisk := range spec.Disks {
+			if restoreDisk.DiskKey == disk.Key && restoreDisk.SizeBytes > capacity {
+				capacity = restoreDisk.SizeBytes
+			}
+		}
+
+		diskMode := disk.DiskMode
+		if diskMode == "" {
+			diskMode = string(types.VirtualDiskModePersistent)
+		}
+
+		unitNumber := disk.UnitNumber
+		thin := true
+		addDevice(&types.VirtualDisk{
+			VirtualDevice: types.VirtualDevice{
+				Key:           nextKey,
+				ControllerKey: controllerKey,
+				UnitNumber:    &unitNumber,
+				Backing: &types.VirtualDiskFlatVer2BackingInfo{
+					VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{FileName: datastorePath},
+					DiskMode:                     diskMode,
+					ThinProvisioned:              &thin,
+				},
+			},
+			CapacityInBytes: capacity,
+		}, types.VirtualDeviceConfigSpecFileOperationCreate)
+		nextKey--
+
+		controller := controllerTypes[disk.ControllerKey]
+		placements[disk.Key] = diskPlacement(controller.Type, controller.BusNumber, disk.UnitNumber)
+	}
+
+	// NICs are attached to the mapped target networks
+	for _, nic := range cfg.NICs {
+		networkName := nic.NetworkName
+		if mapped, ok := spec.NetworkMap[networkName]; ok {
+			networkName = mapped
+		}
+
+		network, err := finder.Network(ctx, networkName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to find network %s for NIC %d: %w", networkName, nic.Key, err)
+		}
+		backing, err := network.EthernetCardBackingInfo(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get backing for network %s: %w", networkName, err)
+		}
+
+		adapterType := nic.AdapterType
+		if adapterType == "" || adapterType == "unknown" {
+			adapterType = "vmxnet3"
+		}
+		device, err := object.EthernetCardTypes().CreateEthernetCard(adapterType, backing)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create %s NIC: %w", adapterType, err)
+		}
+
+		card := device.(types.BaseVirtualEthernetCard).GetVirtualEthernetCard()
+		card.Connectable = &types.VirtualDeviceConnectInfo{
+			StartConnected:    nic.StartConnected,
+			AllowGuestControl: true,
+		}
+		if spec.PreserveMACs && nic.MACAddress != "" {
+			card.AddressType = string(types.VirtualEthernetCardMacTypeManual)
+			card.MacAddress = nic.MACAddress
+		}
+		addDevice(device, "")
+	}
+
+	if cfg.VTPM {
+		log.Warn("⚠️ Source VM had a vTPM - not recreated (requires a key provider on the target)")
+	}
+
+	configSpec := newRestoreConfigSpec(cfg)
+	configSpec.Name = spec.TargetName
+	configSpec.GuestId = cfg.GuestID
+	configSpec.Version = cfg.HardwareVersion
+	configSpec.Firmware = cfg.Firmware
+	configSpec.Files = &types.VirtualMachineFileInfo{VmPathName: datastorePath}
+	configSpec.DeviceChange = deviceChange
+	if cfg.Firmware == string(types.GuestOsDescriptorFirmwareTypeEfi) {
+		secureBoot := cfg.SecureBoot
+		configSpec.BootOptions = &types.VirtualMachineBootOptions{EfiSecureBootEnabled: &secureBoot}
+	}
+
+	log.WithFields(log.Fields{
+		"vm_name":   spec.TargetName,
+		"datastore": datastore.Name(),
+		"disks":     len(cfg.Disks),
+		"nics":      len(cfg.NICs),
+		"firmware":  cfg.Firmware,
+	}).Info("🏗️ Creating restore VM")
+
+	task, err := folder.CreateVM(ctx, configSpec, pool, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create VM %s: %w", spec.TargetName, err)
+	}
+	info, err := task.WaitForResult(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("VM creation task failed: %w", err)
+	}
+
+	vm := object.NewVirtualMachine(c, info.Result.(types.ManagedObjectReference))
+
+	// Map the new disks back to the source disk keys by controller placement
+	devices, err := vm.Device(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list devices of restored VM: %w", err)
+	}
+	byPlacement := make(map[string]*types.VirtualDisk)
+	for _, device := range devices.SelectByType((*types.VirtualDisk)(nil)) {
+		disk := device.(*types.VirtualDisk)
+		controller, ok := devices.FindByKey(disk.ControllerKey).(types.BaseVirtualController)
+		if !ok || disk.UnitNumber == nil {
+			continue
+		}
+		byPlacement[diskPlacement(controllerType(controller), controller.GetVirtualController().BusNumber, *disk.UnitNumber)] = disk
+	}
+
+	disks := make(map[int32]*types.VirtualDisk, len(placements))
+	for sourceKey, placement := range placements {
+		disk, ok := byPlacement[placement]
+		if !ok {
+			return nil, nil, fmt.Errorf("restored VM is missing disk %d (%s)", sourceKey, placement)
+		}
+		disks[sourceKey] = disk
+	}
+
+	log.WithField("vm", vm.Reference().Value).Info("✅ Restore VM created")
+	return vm, disks, nil
+}
+
+// PrepareInPlaceRestore checks the VM can be overwritten and returns its disks by key
+// The VM must be powered off and have no snapshots so writes land on the base disks
+func PrepareInPlaceRestore(ctx context.Context, vm *object.VirtualMachine) (map[int32]*types.VirtualDisk, error) {
+	var o mo.VirtualMachine
+	if err := vm.Properties(ctx, vm.Reference(), []string{"config", "runtime.powerState", "snapshot"}, &o); err != nil {
+		return nil, fmt.Errorf("failed to get VM properties: %w", err)
+	}
+
+	if o.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOff {
+		return nil, fmt.Errorf("VM must be powered off for an in-place restore (current state: %s)", o.Runtime.PowerState)
+	}
+	if o.Snapshot != nil && len(o.Snapshot.RootSnapshotList) > 0 {
+		return nil, fmt.Errorf("VM has snapshots - remove them before an in-place restore")
+	}
+
+	disks := make(map[int32]*types.VirtualDisk)
+	for _, device := range o.Config.Hardware.Device {
+		if disk, ok := device.(*types.VirtualDisk); ok {
+			disks[disk.Key] = disk
+		}
+	}
+
+	return disks, nil
+}
+
+// ReapplyVMConfig restores compute settings of the captured configuration on an existing VM
+func ReapplyVMConfig(ctx context.Context, vm *object.VirtualMachine, cfg *VMConfiguration) error {
+	configSpec := newRestoreConfigSpec(cfg)
+
+	task, err := vm.Reconfigure(ctx, configSpec)
+	if err != nil {
+		return fmt.Errorf("failed to reconfigure VM: %w", err)
+	}
+	if err := task.Wait(ctx); err != nil {
+		return fmt.Errorf("VM reconfigure task failed: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"num_cpus":  cfg.NumCPUs,
+		"memory_mb": cfg.MemoryMB,
+	}).Info("✅ VM compute configuration reapplied")
+	return nil
+}
+
+// ApplyVMMetadata restores custom attributes and tags on the VM
+// Failures are logged - metadata never fails a restore whose data was written
+func ApplyVMMetadata(ctx context.Context, c *vim25.Client, user *url.Userinfo, vm *object.VirtualMachine, cfg *VMConfiguration) {
+	if len(cfg.CustomAttributes) > 0 {
+		fields := object.NewCustomFieldsManager(c)
+		for name, value := range cfg.CustomAttributes {
+			key, err := fields.FindKey(ctx, name)
+			if err != nil {
+				def, addErr := fields.Add(ctx, name, "VirtualMachine", nil, nil)
+				if addErr != nil {
+					log.WithError(addErr).WithField("attribute", name).Warn("⚠️ Failed to create custom attribute")
+					continue
+				}
+				key = def.Key
+			}
+			if err := fields.Set(ctx, vm.Reference(), key, value); err != nil {
+				log.WithError(err).WithField("attribute", name).Warn("⚠️ Failed to set custom attribute")
+			}
+		}
+	}
+
+	if len(cfg.Tags) == 0 {
+		return
+	}
+
+	restClient := rest.NewClient(c)
+	if err := restClient.Login(ctx, user); err != nil {
+		log.WithError(err).Warn("⚠️ Failed to log in to vAPI endpoint - tags not restored")
+		return
+	}
+	defer restClient.Logout(ctx)
+
+	manager := tags.NewManager(restClient)
+	for _, tag := range cfg.Tags {
+		vsphereTag, err := manager.GetTagForCategory(ctx, tag.Name, tag.Category)
+		if err != nil {
+			log.WithError(err).WithField("tag", tag.Category+"/"+tag.Name).Warn("⚠️ Tag not found on target vCenter")
+			continue
+		}
+		if err := manager.AttachTag(ctx, vsphereTag.ID, vm.Reference()); err != nil {
+			log.WithError(err).WithField("tag", tag.Category+"/"+tag.Name).Warn("⚠️ Failed to attach tag")
+		}
+	}
+}
+
+// newRestoreConfigSpec builds the compute part of a config spec from the captured configuration
+func newRestoreConfigSpec(cfg *VMConfiguration) types.VirtualMachineConfigSpec {
+	cpuHotAdd := cfg.CPUHotAddEnabled
+	memHotAdd := cfg.MemHotAddEnabled
+	cbtEnabled := true
+
+	configSpec := types.VirtualMachineConfigSpec{
+		Annotation:            cfg.Annotation,
+		NumCPUs:               int32(cfg.NumCPUs),
+		NumCoresPerSocket:     int32(cfg.CoresPerSocket),
+		MemoryMB:              int64(cfg.MemoryMB),
+		CpuHotAddEnabled:      &cpuHotAdd,
+		MemoryHotAddEnabled:   &memHotAdd,
+		ChangeTrackingEnabled: &cbtEnabled,
+	}
+	if cfg.CPUReservationMHz > 0 {
+		reservation := cfg.CPUReservationMHz
+		configSpec.CpuAllocation = &types.ResourceAllocationInfo{Reservation: &reservation}
+	}
+	if cfg.MemReservationMB > 0 {
+		reservation := cfg.MemReservationMB
+		configSpec.MemoryAllocation = &types.ResourceAllocationInfo{Reservation: &reservation}
+	}
+
+	return configSpec
+}
+
+// newRestoreController creates a storage controller device of the captured type
+func newRestoreController(controller VMConfigController, key int32) (types.BaseVirtualDevice, error) {
+	base := types.VirtualController{
+		VirtualDevice: types.VirtualDevice{Key: key},
+		BusNumber:     controller.BusNumber,
+	}
+
+	sharedBus := types.VirtualSCSISharing(controller.SharedBus)
+	if sharedBus == "" {
+		sharedBus = types.VirtualSCSISharingNoSharing
+	}
+	scsi := types.VirtualSCSIController{VirtualController: base, SharedBus: sharedBus}
+
+	switch controller.Type {
+	case "pvscsi":
+		return &types.ParaVirtualSCSIController{VirtualSCSIController: scsi}, nil
+	case "lsilogic":
+		return &types.VirtualLsiLogicController{VirtualSCSIController: scsi}, nil
+	case "lsilogic-sas":
+		return &types.VirtualLsiLogicSASController{VirtualSCSIController: scsi}, nil
+	case "buslogic":
+		return &types.VirtualBusLogicController{VirtualSCSIController: scsi}, nil
+	case "sata":
+		return &types.VirtualAHCIController{VirtualSATAController: types.VirtualSATAController{VirtualController: base}}, nil
+	case "nvme":
+		return &types.VirtualNVMEController{VirtualController: base}, nil
+	default:
+		return nil, fmt.Errorf("unsupported controller type %q", controller.Type)
+	}
+}
+
+// controllerType returns the configuration type name of a storage controller
+func controllerType(controller types.BaseVirtualController) string {
+	switch controller.(type) {
+	case *types.ParaVirtualSCSIController:
+		return "pvscsi"
+	case *types.VirtualLsiLogicController:
+		return "lsilogic"
+	case *types.VirtualLsiLogicSASController:
+		return "lsilogic-sas"
+	case *types.VirtualBusLogicController:
+		return "buslogic"
+	case *types.VirtualAHCIController:
+		return "sata"
+	case *types.VirtualNVMEController:
+		return "nvme"
+	case *types.VirtualIDEController:
+		return "ide"
+	default:
+		return "unknown"
+	}
+}
+
+// diskPlacement identifies a disk slot independently of device keys
+func diskPlacement(controllerType string, busNumber, unitNumber int32) string {
+	return fmt.Sprintf("%s:%d:%d", controllerType, busNumber, unitNumber)
+}
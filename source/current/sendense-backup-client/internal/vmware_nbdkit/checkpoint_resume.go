@@ -0,0 +1,115 @@
+package vmware_nbdkit
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vexxhost/migratekit/internal/checkpoint"
+	"github.com/vexxhost/migratekit/internal/jobcontrol"
+	vmware "github.com/vexxhost/migratekit/internal/vmware"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"libguestfs.org/libnbd"
+)
+
+// resumeSnapshot reuses the snapshot kept by an interrupted run of this job, so a
+// retry copies the same point in time and skips ranges it already copied
+func (s *NbdkitServers) resumeSnapshot(ctx context.Context) bool {
+	state := s.Checkpoints.Resumable(s.VirtualMachine.Reference().Value)
+	if state == nil {
+		return false
+	}
+
+	ref := types.ManagedObjectReference{Type: "VirtualMachineSnapshot", Value: state.SnapshotRef}
+	var snapshot mo.VirtualMachineSnapshot
+	if err := s.VirtualMachine.Properties(ctx, ref, []string{"config.changeTrackingEnabled"}, &snapshot); err != nil {
+		log.WithError(err).WithField("snapshot_ref", state.SnapshotRef).Warn("⚠️ Checkpointed snapshot is gone - starting over")
+		return false
+	}
+
+	s.SnapshotRef = ref
+	s.Consistency = vmware.SnapshotConsistency{
+		Requested:      state.ConsistencyRequested,
+		Achieved:       state.ConsistencyAchieved,
+		FallbackReason: state.ConsistencyFallback,
+	}
+
+	log.WithFields(log.Fields{
+		"job_id":        s.JobID,
+		"snapshot_name": state.SnapshotName,
+		"snapshot_ref":  state.SnapshotRef,
+	}).Info("♻️ Resuming interrupted transfer from kept snapshot")
+	s.reportConsistency(ctx)
+	return true
+}
+
+// removeStaleSnapshots removes snapshots kept for interrupted runs of other jobs of
+// this VM - those runs will never be resumed
+func (s *NbdkitServers) removeStaleSnapshots(ctx context.Context) {
+	for _, state := range checkpoint.Stale(checkpoint.DefaultDir, s.VirtualMachine.Reference().Value, s.JobID) {
+		logger := log.WithFields(log.Fields{
+			"job_id":       state.JobID,
+			"snapshot_ref": state.SnapshotRef,
+		})
+
+		if state.VMRef == s.VirtualMachine.Reference().Value && state.SnapshotRef != "" {
+			consolidate := true
+			task, err := s.VirtualMachine.RemoveSnapshot(ctx, state.SnapshotRef, false, &consolidate)
+			if err == nil {
+				err = task.Wait(ctx)
+			}
+			if err != nil {
+				logger.WithError(err).Warn("⚠️ Failed to remove snapshot kept for an abandoned transfer")
+			} else {
+				logger.Info("🧹 Removed snapshot kept for an abandoned transfer")
+			}
+		}
+		checkpoint.Remove(checkpoint.DefaultDir, state.JobID)
+	}
+}
+
+// beginCheckpoint records the snapshot this run copies from
+func (s *NbdkitServers) beginCheckpoint() {
+	s.Checkpoints.Begin(checkpoint.State{
+		VMRef:                s.VirtualMachine.Reference().Value,
+		SnapshotRef:          s.SnapshotRef.Value,
+		SnapshotName:         s.SnapshotPrefix + s.JobID,
+		ConsistencyRequested: s.Consistency.Requested,
+		ConsistencyAchieved:  s.Consistency.Achieved,
+		ConsistencyFallback:  s.Consistency.FallbackReason,
+	})
+}
+
+// keepSnapshotForResume reports whether an interrupted run should keep its snapshot:
+// only when copied ranges are checkpointed and the job was not cancelled
+func (s *NbdkitServers) keepSnapshotForResume(ctx context.Context) bool {
+	if jobcontrol.FromContext(ctx).Cancelled() {
+		s.Checkpoints.Discard()
+		return false
+	}
+	return s.Checkpoints.HasProgress()
+}
+
+// diskCheckpoint returns the checkpoint tracker of this disk's transfer; completed
+// ranges are recorded only after the target has flushed them
+func (s *NbdkitServer) diskCheckpoint(mode, path, baseChangeID string, targetNBD *libnbd.Libnbd) *checkpoint.Tracker {
+	changeID, err := vmware.GetChangeID(s.Disk)
+	if err != nil {
+		return nil
+	}
+
+	tracker := s.Servers.Checkpoints.Disk(s.Disk.Key, mode, path, changeID.Value, baseChangeID)
+	tracker.SetFlush(func() error {
+		return targetNBD.Flush(nil)
+	})
+	return tracker
+}
+
+// remainingRanges returns the parts of a worker's range not copied by an earlier run
+func remainingRanges(completed checkpoint.Ranges, r OffsetRange) []OffsetRange {
+	var remaining []OffsetRange
+	for _, left := range completed.Remaining(r.Start, r.Length) {
+		remaining = append(remaining, OffsetRange{Start: left.Start, Length: left.Length})
+	}
+	return remaining
+}
@@ -0,0 +1,139 @@
+package vmware_nbdkit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vexxhost/migratekit/internal/checkpoint"
+	"github.com/vexxhost/migratekit/internal/events"
+	"github.com/vexxhost/migratekit/internal/jobcontrol"
+	"github.com/vexxhost/migratekit/internal/progress"
+	"github.com/vexxhost/migratekit/internal/telemetry"
+	"libguestfs.org/libnbd"
+)
+
+// ExtentCopy describes a copy of disk extents from an NBD export of another source
+// driver (e.g. a libvirt pull-mode backup) to an NBD target
+type ExtentCopy struct {
+	SourceURI   string         // NBD URI of the source export
+	TargetNBD   *libnbd.Libnbd // Connected target handle
+	Extents     []DiskExtent   // Areas to copy (allocated or changed blocks)
+	DiskKey     int32          // Disk key used for progress events and checkpoint keys
+	Checkpoints *checkpoint.Tracker
+}
+
+// CopyExtentsToTarget copies extents with the parallel NBD workers used for VMware
+// incrementals, reporting progress to the SNA, SHA telemetry and the event stream
+func CopyExtentsToTarget(ctx context.Context, req ExtentCopy) error {
+	logger := log.WithFields(log.Fields{
+		"source":   req.SourceURI,
+		"disk_key": req.DiskKey,
+	})
+
+	coalescedExtents := coalesceExtents(req.Extents, CoalesceGapThreshold, MaxChunkSize)
+	totalBytes := calculateTotalBytes(coalescedExtents)
+
+	// Extents copied by an interrupted run of this job are skipped
+	completed := req.Checkpoints.Completed()
+	pendingExtents := make([]CoalescedExtent, 0, len(coalescedExtents))
+	var resumedBytes int64
+	for _, extent := range coalescedExtents {
+		if completed.Covers(extent.Offset, extent.Length) {
+			resumedBytes += extent.Length
+			continue
+		}
+		pendingExtents = append(pendingExtents, extent)
+	}
+
+	numWorkers := determineWorkerCount(len(pendingExtents))
+	workerExtents := splitExtentsAcrossWorkers(pendingExtents, numWorkers)
+
+	logger.WithFields(log.Fields{
+		"extents":       len(coalescedExtents),
+		"total_bytes":   totalBytes,
+		"resumed_bytes": resumedBytes,
+		"workers":       numWorkers,
+	}).Info("🚀 Starting parallel extent copy")
+
+	var snaClient *progress.SNAProgressClient
+	if vpc := ctx.Value("snaProgressClient"); vpc != nil {
+		if client, ok := vpc.(*progress.SNAProgressClient); ok {
+			snaClient = client
+		}
+	}
+
+	progressAggregator := NewProgressAggregator(totalBytes, snaClient)
+	progressAggregator.SetEventEmitter(events.FromContext(ctx), req.DiskKey)
+	if tracker, ok := ctx.Value("telemetryTracker").(*telemetry.ProgressTracker); ok {
+		progressAggregator.SetTelemetryTracker(tracker)
+	}
+
+	progressChan := make(chan int64, 1000)
+	errorChan := make(chan error, numWorkers)
+
+	aggregatorCtx, aggregatorCancel := context.WithCancel(ctx)
+	defer aggregatorCancel()
+	go progressAggregator.Run(aggregatorCtx, progressChan)
+	if resumedBytes > 0 {
+		progressChan <- resumedBytes
+	}
+
+	jobControl := jobcontrol.FromContext(ctx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		if len(workerExtents[i]) == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go copyWorker(
+			ctx,
+			WorkerConfig{
+				WorkerID:      i,
+				SourceURI:     req.SourceURI,
+				TargetNBD:     req.TargetNBD,
+				Extents:       workerExtents[i],
+				MaxRetries:    MaxRetries,
+				RetryDelay:    InitialRetryDelay,
+				Control:       jobControl,
+				CheckpointKey: fmt.Sprintf("%d/%d", req.DiskKey, i),
+				Checkpoints:   req.Checkpoints,
+			},
+			progressChan,
+			errorChan,
+			&wg,
+		)
+	}
+
+	wg.Wait()
+	close(progressChan)
+	close(errorChan)
+
+	req.Checkpoints.Save()
+
+	var workerErrors []error
+	for err := range errorChan {
+		workerErrors = append(workerErrors, err)
+	}
+	if len(workerErrors) > 0 {
+		for _, err := range workerErrors {
+			logger.WithError(err).Error("Worker error")
+		}
+		return fmt.Errorf("parallel copy failed with %d worker errors", len(workerErrors))
+	}
+
+	// Cancelled workers stop without an error - the copy is incomplete
+	if ctx.Err() != nil {
+		return fmt.Errorf("parallel copy interrupted: %w", ctx.Err())
+	}
+
+	if snaClient != nil && snaClient.IsEnabled() {
+		progressAggregator.SendFinalUpdate()
+	}
+
+	logger.WithField("bytes_copied", totalBytes).Info("✅ Parallel extent copy completed")
+	return nil
+}
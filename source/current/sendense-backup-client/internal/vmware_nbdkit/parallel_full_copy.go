@@ -9,6 +9,9 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/vexxhost/migratekit/internal/checkpoint"
+	"github.com/vexxhost/migratekit/internal/events"
+	"github.com/vexxhost/migratekit/internal/jobcontrol"
 	"github.com/vexxhost/migratekit/internal/progress"
 	"github.com/vexxhost/migratekit/internal/target"
 	"github.com/vexxhost/migratekit/internal/telemetry"
@@ -83,6 +86,16 @@ func (s *NbdkitServer) ParallelFullCopyToTarget(ctx context.Context, t target.Ta
 	// Divide disk into equal ranges for workers
 	workerRanges := divideRangesAcrossWorkers(diskSize, numWorkers)
 
+	// Ranges copied by an interrupted run of this job against the same snapshot are skipped
+	checkpoints := s.diskCheckpoint(checkpoint.ModeFull, path, "", nbdTarget)
+	completed := checkpoints.Completed()
+	if resumedBytes := completed.Total(); resumedBytes > 0 {
+		logger.WithFields(log.Fields{
+			"resumed_bytes": resumedBytes,
+			"resumed_gb":    resumedBytes / (1024 * 1024 * 1024),
+		}).Info("♻️ Resuming full copy from checkpoint")
+	}
+
 	// Setup progress aggregator
 	var snaClient *progress.SNAProgressClient
 	if vpc := ctx.Value("snaProgressClient"); vpc != nil {
@@ -92,6 +105,7 @@ func (s *NbdkitServer) ParallelFullCopyToTarget(ctx context.Context, t target.Ta
 	}
 
 	progressAggregator := NewProgressAggregator(totalBytes, snaClient)
+	progressAggregator.SetEventEmitter(events.FromContext(ctx), s.Disk.Key)
 	
 	// 🆕 NEW: Set telemetry tracker from context (SHA push-based real-time progress)
 	if telemetryTracker := ctx.Value("telemetryTracker"); telemetryTracker != nil {
@@ -107,11 +121,18 @@ func (s *NbdkitServer) ParallelFullCopyToTarget(ctx context.Context, t target.Ta
 	aggregatorCtx, aggregatorCancel := context.WithCancel(ctx)
 	defer aggregatorCancel()
 	go progressAggregator.Run(aggregatorCtx, progressChan)
+	if resumedBytes := completed.Total(); resumedBytes > 0 {
+		progressChan <- resumedBytes
+	}
+
+	// Workers pause at chunk boundaries and record where they stopped (SNA job control)
+	jobControl := jobcontrol.FromContext(ctx)
 
 	// Launch worker pool for full copy
 	var wg sync.WaitGroup
 	for i := 0; i < numWorkers; i++ {
-		if workerRanges[i].Length == 0 {
+		remaining := remainingRanges(completed, workerRanges[i])
+		if len(remaining) == 0 {
 			continue
 		}
 
@@ -119,12 +140,15 @@ func (s *NbdkitServer) ParallelFullCopyToTarget(ctx context.Context, t target.Ta
 		go fullCopyWorker(
 			ctx,
 			FullCopyWorkerConfig{
-				WorkerID:     i,
-				SourceSocket: s.Nbdkit.Socket(),
-				TargetNBD:    nbdTarget,
-				OffsetRange:  workerRanges[i],
-				MaxRetries:   MaxRetries,
-				RetryDelay:   InitialRetryDelay,
+				WorkerID:      i,
+				SourceSocket:  s.Nbdkit.Socket(),
+				TargetNBD:     nbdTarget,
+				OffsetRanges:  remaining,
+				MaxRetries:    MaxRetries,
+				RetryDelay:    InitialRetryDelay,
+				Control:       jobControl,
+				CheckpointKey: fmt.Sprintf("%d/%d", s.Disk.Key, i),
+				Checkpoints:   checkpoints,
 			},
 			progressChan,
 			errorChan,
@@ -137,6 +161,9 @@ func (s *NbdkitServer) ParallelFullCopyToTarget(ctx context.Context, t target.Ta
 	close(progressChan)
 	close(errorChan)
 
+	// Record everything copied so far - a failed run is resumed from here
+	checkpoints.Save()
+
 	// Check for errors
 	var workerErrors []error
 	for err := range errorChan {
@@ -151,6 +178,11 @@ func (s *NbdkitServer) ParallelFullCopyToTarget(ctx context.Context, t target.Ta
 		return fmt.Errorf("parallel full copy failed with %d worker errors", len(workerErrors))
 	}
 
+	// Cancelled workers stop without an error - the copy is incomplete
+	if ctx.Err() != nil {
+		return fmt.Errorf("parallel full copy interrupted: %w", ctx.Err())
+	}
+
 	// Send final 100% progress update
 	if snaClient != nil && snaClient.IsEnabled() {
 		progressAggregator.SendFinalUpdate()
@@ -170,9 +202,13 @@ type FullCopyWorkerConfig struct {
 	WorkerID     int
 	SourceSocket string
 	TargetNBD    *libnbd.Libnbd
-	OffsetRange  OffsetRange
+	OffsetRanges []OffsetRange // Ranges still to copy, in disk order
 	MaxRetries   int
 	RetryDelay   time.Duration
+
+	Control       *jobcontrol.Controller // Pause/cancel requests from the SNA (may be nil)
+	CheckpointKey string                 // "<disk_key>/<worker_id>" for checkpoint reporting
+	Checkpoints   *checkpoint.Tracker    // Completed ranges kept for retries (may be nil)
 }
 
 // fullCopyWorker processes a continuous disk range using dedicated NBD connection
@@ -185,11 +221,16 @@ func fullCopyWorker(
 ) {
 	defer wg.Done()
 
+	var rangeBytes int64
+	for _, r := range config.OffsetRanges {
+		rangeBytes += r.Length
+	}
+	last := config.OffsetRanges[len(config.OffsetRanges)-1]
 	logger := log.WithFields(log.Fields{
 		"worker_id": config.WorkerID,
-		"start":     config.OffsetRange.Start,
-		"end":       config.OffsetRange.Start + config.OffsetRange.Length,
-		"size_mb":   config.OffsetRange.Length / (1024 * 1024),
+		"start":     config.OffsetRanges[0].Start,
+		"end":       last.Start + last.Length,
+		"size_mb":   rangeBytes / (1024 * 1024),
 	})
 	startTime := time.Now()
 
@@ -217,75 +258,85 @@ func fullCopyWorker(
 		sparseBytesSaved int64
 	)
 
-	// Process assigned disk range in chunks
-	currentOffset := config.OffsetRange.Start
-	endOffset := config.OffsetRange.Start + config.OffsetRange.Length
+	// Process assigned disk ranges in chunks
+	for _, offsetRange := range config.OffsetRanges {
+		currentOffset := offsetRange.Start
+		endOffset := offsetRange.Start + offsetRange.Length
+
+		for currentOffset < endOffset {
+			// Check for context cancellation
+			select {
+			case <-ctx.Done():
+				logger.Warn("Worker cancelled by context")
+				return
+			default:
+			}
 
-	for currentOffset < endOffset {
-		// Check for context cancellation
-		select {
-		case <-ctx.Done():
-			logger.Warn("Worker cancelled by context")
-			return
-		default:
-		}
+			// Hold here while the job is paused - the next chunk starts at currentOffset
+			if err := config.Control.WaitIfPaused(ctx); err != nil {
+				logger.WithField("offset", currentOffset).Warn("Worker cancelled while paused")
+				return
+			}
 
-		// Calculate chunk size (max 32MB)
-		chunkSize := int64(MaxChunkSize)
-		if currentOffset+chunkSize > endOffset {
-			chunkSize = endOffset - currentOffset
-		}
+			// Calculate chunk size (max 32MB)
+			chunkSize := int64(MaxChunkSize)
+			if currentOffset+chunkSize > endOffset {
+				chunkSize = endOffset - currentOffset
+			}
 
-		// Copy this chunk with retries
-		wasSparse, err := copyChunkWithRetry(
-			ctx,
-			sourceNBD,
-			config.TargetNBD,
-			currentOffset,
-			chunkSize,
-			config.MaxRetries,
-			config.RetryDelay,
-			logger,
-		)
+			// Copy this chunk with retries
+			wasSparse, err := copyChunkWithRetry(
+				ctx,
+				sourceNBD,
+				config.TargetNBD,
+				currentOffset,
+				chunkSize,
+				config.MaxRetries,
+				config.RetryDelay,
+				logger,
+			)
 
-		if err != nil {
-			errorChan <- fmt.Errorf("worker %d: failed to copy chunk at offset %d: %w",
-				config.WorkerID, currentOffset, err)
-			return // Fatal error, stop this worker
-		}
+			if err != nil {
+				errorChan <- fmt.Errorf("worker %d: failed to copy chunk at offset %d: %w",
+					config.WorkerID, currentOffset, err)
+				return // Fatal error, stop this worker
+			}
 
-		// Track sparse optimization
-		if wasSparse {
-			sparseSkipped++
-			sparseBytesSaved += chunkSize
-		}
+			// Track sparse optimization
+			if wasSparse {
+				sparseSkipped++
+				sparseBytesSaved += chunkSize
+			}
 
-		// Update progress
-		bytesProcessed += chunkSize
-		chunksProcessed++
+			// Update progress
+			bytesProcessed += chunkSize
+			chunksProcessed++
 
-		// Send progress update (non-blocking)
-		select {
-		case progressChan <- chunkSize:
-		default:
-			// Channel full, skip this update
-		}
+			// Send progress update (non-blocking)
+			select {
+			case progressChan <- chunkSize:
+			default:
+				// Channel full, skip this update
+			}
 
-		// Log progress every 10 chunks
-		if chunksProcessed%10 == 0 {
-			elapsed := time.Since(startTime).Seconds()
-			throughputMBps := float64(bytesProcessed) / elapsed / (1024 * 1024)
-
-			logger.WithFields(log.Fields{
-				"chunks_processed": chunksProcessed,
-				"bytes_processed":  bytesProcessed,
-				"mb_processed":     bytesProcessed / (1024 * 1024),
-				"throughput_mbps":  fmt.Sprintf("%.2f", throughputMBps),
-				"sparse_saved_mb":  sparseBytesSaved / (1024 * 1024),
-			}).Debug("📊 Worker progress")
-		}
+			// Log progress every 10 chunks
+			if chunksProcessed%10 == 0 {
+				elapsed := time.Since(startTime).Seconds()
+				throughputMBps := float64(bytesProcessed) / elapsed / (1024 * 1024)
 
-		currentOffset += chunkSize
+				logger.WithFields(log.Fields{
+					"chunks_processed": chunksProcessed,
+					"bytes_processed":  bytesProcessed,
+					"mb_processed":     bytesProcessed / (1024 * 1024),
+					"throughput_mbps":  fmt.Sprintf("%.2f", throughputMBps),
+					"sparse_saved_mb":  sparseBytesSaved / (1024 * 1024),
+				}).Debug("📊 Worker progress")
+			}
+
+			config.Checkpoints.MarkCompleted(currentOffset, chunkSize)
+			currentOffset += chunkSize
+			config.Control.Checkpoint(config.CheckpointKey, currentOffset)
+		}
 	}
 
 	// Final statistics
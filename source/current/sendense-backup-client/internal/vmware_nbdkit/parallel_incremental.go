@@ -8,6 +8,9 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/vexxhost/migratekit/internal/checkpoint"
+	"github.com/vexxhost/migratekit/internal/events"
+	"github.com/vexxhost/migratekit/internal/jobcontrol"
 	"github.com/vexxhost/migratekit/internal/progress"
 	"github.com/vexxhost/migratekit/internal/target"
 	"github.com/vexxhost/migratekit/internal/telemetry"
@@ -110,12 +113,32 @@ func (s *NbdkitServer) ParallelIncrementalCopyToTarget(ctx context.Context, t ta
 	}
 	defer nbdTarget.Close()
 
+	// Extents copied by an interrupted run of this job against the same snapshot and
+	// base change ID are skipped
+	checkpoints := s.diskCheckpoint(checkpoint.ModeIncremental, path, currentChangeId.Value, nbdTarget)
+	completed := checkpoints.Completed()
+	pendingExtents := make([]CoalescedExtent, 0, len(coalescedExtents))
+	var resumedBytes int64
+	for _, extent := range coalescedExtents {
+		if completed.Covers(extent.Offset, extent.Length) {
+			resumedBytes += extent.Length
+			continue
+		}
+		pendingExtents = append(pendingExtents, extent)
+	}
+	if resumedBytes > 0 {
+		logger.WithFields(log.Fields{
+			"resumed_extents": len(coalescedExtents) - len(pendingExtents),
+			"resumed_bytes":   resumedBytes,
+		}).Info("♻️ Resuming incremental copy from checkpoint")
+	}
+
 	// Step 5: Determine optimal worker count
-	numWorkers := determineWorkerCount(len(coalescedExtents))
+	numWorkers := determineWorkerCount(len(pendingExtents))
 	logger.Infof("🔧 Using %d parallel workers", numWorkers)
 
 	// Step 6: Split extents across workers
-	workerExtents := splitExtentsAcrossWorkers(coalescedExtents, numWorkers)
+	workerExtents := splitExtentsAcrossWorkers(pendingExtents, numWorkers)
 
 	// Step 7: Setup progress aggregator
 	var snaClient *progress.SNAProgressClient
@@ -126,6 +149,7 @@ func (s *NbdkitServer) ParallelIncrementalCopyToTarget(ctx context.Context, t ta
 	}
 
 	progressAggregator := NewProgressAggregator(totalBytes, snaClient)
+	progressAggregator.SetEventEmitter(events.FromContext(ctx), s.Disk.Key)
 	
 	// 🆕 NEW: Set telemetry tracker from context (SHA push-based real-time progress)
 	if telemetryTracker := ctx.Value("telemetryTracker"); telemetryTracker != nil {
@@ -142,6 +166,12 @@ func (s *NbdkitServer) ParallelIncrementalCopyToTarget(ctx context.Context, t ta
 	aggregatorCtx, aggregatorCancel := context.WithCancel(ctx)
 	defer aggregatorCancel()
 	go progressAggregator.Run(aggregatorCtx, progressChan)
+	if resumedBytes > 0 {
+		progressChan <- resumedBytes
+	}
+
+	// Workers pause between extents and record where they stopped (SNA job control)
+	jobControl := jobcontrol.FromContext(ctx)
 
 	// Step 8: Launch worker pool
 	var wg sync.WaitGroup
@@ -154,12 +184,15 @@ func (s *NbdkitServer) ParallelIncrementalCopyToTarget(ctx context.Context, t ta
 		go copyWorker(
 			ctx,
 			WorkerConfig{
-				WorkerID:     i,
-				SourceSocket: s.Nbdkit.Socket(),
-				TargetNBD:    nbdTarget,
-				Extents:      workerExtents[i],
-				MaxRetries:   MaxRetries,
-				RetryDelay:   InitialRetryDelay,
+				WorkerID:      i,
+				SourceSocket:  s.Nbdkit.Socket(),
+				TargetNBD:     nbdTarget,
+				Extents:       workerExtents[i],
+				MaxRetries:    MaxRetries,
+				RetryDelay:    InitialRetryDelay,
+				Control:       jobControl,
+				CheckpointKey: fmt.Sprintf("%d/%d", s.Disk.Key, i),
+				Checkpoints:   checkpoints,
 			},
 			progressChan,
 			errorChan,
@@ -172,6 +205,9 @@ func (s *NbdkitServer) ParallelIncrementalCopyToTarget(ctx context.Context, t ta
 	close(progressChan)
 	close(errorChan)
 
+	// Record everything copied so far - a failed run is resumed from here
+	checkpoints.Save()
+
 	// Check for errors
 	var workerErrors []error
 	for err := range errorChan {
@@ -186,6 +222,11 @@ func (s *NbdkitServer) ParallelIncrementalCopyToTarget(ctx context.Context, t ta
 		return fmt.Errorf("parallel copy failed with %d worker errors", len(workerErrors))
 	}
 
+	// Cancelled workers stop without an error - the copy is incomplete
+	if ctx.Err() != nil {
+		return fmt.Errorf("parallel copy interrupted: %w", ctx.Err())
+	}
+
 	// Send final 100% progress update
 	if snaClient != nil && snaClient.IsEnabled() {
 		progressAggregator.SendFinalUpdate()
@@ -305,6 +346,9 @@ func (s *NbdkitServer) IncrementalCopyToTargetAutoSelect(ctx context.Context, t
 	if ParallelIncrementalCopyEnabled() {
 		logger.Info("🚀 Parallel NBD copy enabled via MIGRATEKIT_PARALLEL_NBD")
 		err := s.ParallelIncrementalCopyToTarget(ctx, t, path)
+		if err != nil && ctx.Err() != nil {
+			return err // Cancelled - no fallback
+		}
 		if err != nil {
 			logger.WithError(err).Warn("⚠️ Parallel copy failed, falling back to serial copy")
 			return s.IncrementalCopyToTarget(ctx, t, path)
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/vexxhost/migratekit/internal/checkpoint"
+	"github.com/vexxhost/migratekit/internal/jobcontrol"
 	"libguestfs.org/libnbd"
 )
 
@@ -14,10 +16,15 @@ import (
 type WorkerConfig struct {
 	WorkerID     int
 	SourceSocket string // NBD socket for VMware connection
+	SourceURI    string // NBD URI of a non-VMware source (used instead of SourceSocket)
 	TargetNBD    *libnbd.Libnbd
 	Extents      []CoalescedExtent
 	MaxRetries   int
 	RetryDelay   time.Duration
+
+	Control       *jobcontrol.Controller // Pause/cancel requests from the SNA (may be nil)
+	CheckpointKey string                 // "<disk_key>/<worker_id>" for checkpoint reporting
+	Checkpoints   *checkpoint.Tracker    // Completed extents kept for retries (may be nil)
 }
 
 // WorkerResult contains statistics from a completed worker
@@ -51,8 +58,12 @@ func copyWorker(
 	}
 	defer sourceNBD.Close()
 
-	// Connect to VMware NBD source via socket
-	err = sourceNBD.ConnectUnix(config.SourceSocket)
+	// Connect to VMware NBD source via socket (or the export of another source driver)
+	if config.SourceURI != "" {
+		err = sourceNBD.ConnectUri(config.SourceURI)
+	} else {
+		err = sourceNBD.ConnectUnix(config.SourceSocket)
+	}
 	if err != nil {
 		errorChan <- fmt.Errorf("worker %d: failed to connect to source NBD: %w", config.WorkerID, err)
 		return
@@ -75,6 +86,12 @@ func copyWorker(
 		default:
 		}
 
+		// Hold here while the job is paused - the next extent starts at extent.Offset
+		if err := config.Control.WaitIfPaused(ctx); err != nil {
+			logger.WithField("offset", extent.Offset).Warn("Worker cancelled while paused")
+			return
+		}
+
 		// Copy this extent with retries
 		err := copyExtentWithRetry(
 			ctx,
@@ -95,6 +112,8 @@ func copyWorker(
 		// Update progress
 		bytesProcessed += extent.Length
 		extentsProcessed++
+		config.Checkpoints.MarkCompleted(extent.Offset, extent.Length)
+		config.Control.Checkpoint(config.CheckpointKey, extent.Offset+extent.Length)
 
 		// Send progress update (non-blocking)
 		select {
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/vexxhost/migratekit/internal/events"
 	"github.com/vexxhost/migratekit/internal/progress"
 	"github.com/vexxhost/migratekit/internal/telemetry"
 )
@@ -20,7 +21,9 @@ type ProgressAggregator struct {
 	lastProgressPercent  float64
 	snaProgressClient    *progress.SNAProgressClient
 	telemetryTracker     *telemetry.ProgressTracker // 🆕 NEW: SHA telemetry
-	jobID                string                       // 🆕 NEW: Job ID for telemetry
+	jobID                string                     // 🆕 NEW: Job ID for telemetry
+	events               *events.Emitter            // Per-disk events for the SNA
+	diskKey              int32
 	updateInterval       time.Duration
 	progressPercentDelta float64 // Minimum percent change to trigger update
 }
@@ -48,6 +51,12 @@ func (pa *ProgressAggregator) SetTelemetryTracker(tracker *telemetry.ProgressTra
 	pa.telemetryTracker = tracker
 }
 
+// SetEventEmitter reports this disk's progress on the SNA event stream
+func (pa *ProgressAggregator) SetEventEmitter(emitter *events.Emitter, diskKey int32) {
+	pa.events = emitter
+	pa.diskKey = diskKey
+}
+
 // Run starts the progress aggregator goroutine
 func (pa *ProgressAggregator) Run(ctx context.Context, progressChan <-chan int64) {
 	logger := log.WithField("component", "progress_aggregator")
@@ -143,12 +152,16 @@ func (pa *ProgressAggregator) maybeUpdateVMA(logger *log.Entry) {
 		}).Debug("🚀 SHA telemetry update sent")
 	}
 
+	pa.events.Disk(pa.diskKey, "transferring", currentBytes, pa.totalBytes, throughputBPS)
+
 	pa.lastUpdateTime = time.Now()
 	pa.lastProgressPercent = currentPercent
 }
 
 // SendFinalUpdate sends the final 100% completion update to SNA + SHA telemetry
 func (pa *ProgressAggregator) SendFinalUpdate() error {
+	pa.events.Disk(pa.diskKey, "completed", pa.totalBytes, pa.totalBytes, 0)
+
 	// 1️⃣ Send SNA completion update (backward compatibility)
 	if pa.snaProgressClient != nil && pa.snaProgressClient.IsEnabled() {
 		err := pa.snaProgressClient.SendUpdate(progress.SNAProgressUpdate{
@@ -0,0 +1,271 @@
+package vmware_nbdkit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vexxhost/migratekit/internal/nbdkit"
+	"github.com/vexxhost/migratekit/internal/telemetry"
+	vmware "github.com/vexxhost/migratekit/internal/vmware"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+	"libguestfs.org/libnbd"
+)
+
+// restoreCoalesceGap merges changed areas closer than this into one write during in-place restores
+const restoreCoalesceGap = 1024 * 1024
+
+// RestoreServers writes backup disk images back to the disks of a VMware VM
+// through writable nbdkit VDDK servers (no snapshot - writes land on the base disks)
+type RestoreServers struct {
+	VddkConfig     *VddkConfig
+	VirtualMachine *object.VirtualMachine
+	Spec           *vmware.RestoreSpec
+	JobID          string
+
+	// TargetIsClean is set for freshly created disks so zero chunks can be skipped
+	TargetIsClean bool
+
+	startTime        time.Time
+	totalBytes       int64
+	bytesTransferred int64
+	lastReport       time.Time
+}
+
+// restoreDiskPlan is one disk write: the target disk and the byte ranges to copy
+type restoreDiskPlan struct {
+	source  vmware.RestoreDisk
+	disk    *types.VirtualDisk
+	extents []CoalescedExtent
+	full    bool
+}
+
+// NewRestoreServers creates a restore of spec onto vm
+func NewRestoreServers(vddk *VddkConfig, vm *object.VirtualMachine, spec *vmware.RestoreSpec, jobID string) *RestoreServers {
+	return &RestoreServers{
+		VddkConfig:     vddk,
+		VirtualMachine: vm,
+		Spec:           spec,
+		JobID:          jobID,
+	}
+}
+
+// RestoreDisks writes every non-blank backup disk to the matching VM disk
+// In-place restores with a backup change ID only write the blocks CBT reports
+// as changed since the backup; everything else is a full copy
+func (r *RestoreServers) RestoreDisks(ctx context.Context, disks map[int32]*types.VirtualDisk) error {
+	var plans []restoreDiskPlan
+	for _, source := range r.Spec.Disks {
+		if source.Blank {
+			log.WithField("disk_key", source.DiskKey).Info("⏭️ Leaving disk excluded from the backup empty")
+			continue
+		}
+
+		disk, ok := disks[source.DiskKey]
+		if !ok {
+			return fmt.Errorf("target VM has no disk matching backup disk key %d", source.DiskKey)
+		}
+		if source.SizeBytes > disk.CapacityInBytes {
+			return fmt.Errorf("target disk %d (%d bytes) is smaller than the backup disk (%d bytes)",
+				disk.Key, disk.CapacityInBytes, source.SizeBytes)
+		}
+
+		plan := restoreDiskPlan{source: source, disk: disk, full: true}
+		if r.Spec.Mode == vmware.RestoreModeInPlace && source.ChangeID != "" {
+			extents, err := r.changedAreasSince(ctx, disk, source.ChangeID)
+			if err != nil {
+				log.WithError(err).WithField("disk_key", disk.Key).Warn("⚠️ CBT query failed - falling back to full disk restore")
+			} else {
+				plan.extents = coalesceExtents(extents, restoreCoalesceGap, MaxChunkSize)
+				plan.full = false
+			}
+		}
+		if plan.full {
+			plan.extents = []CoalescedExtent{{Offset: 0, Length: source.SizeBytes, OriginalCount: 1}}
+		}
+
+		r.totalBytes += calculateTotalBytes(plan.extents)
+		plans = append(plans, plan)
+	}
+
+	log.WithFields(log.Fields{
+		"job_id":      r.JobID,
+		"mode":        r.Spec.Mode,
+		"disks":       len(plans),
+		"total_bytes": r.totalBytes,
+	}).Info("📋 Restore plan ready")
+
+	r.startTime = time.Now()
+	for _, plan := range plans {
+		if err := r.restoreDisk(ctx, plan); err != nil {
+			return fmt.Errorf("failed to restore disk %d: %w", plan.disk.Key, err)
+		}
+	}
+
+	r.reportProgress(ctx, true)
+	return nil
+}
+
+// restoreDisk copies one backup disk to its target through a writable nbdkit server
+func (r *RestoreServers) restoreDisk(ctx context.Context, plan restoreDiskPlan) error {
+	logger := log.WithFields(log.Fields{
+		"disk_key": plan.disk.Key,
+		"source":   plan.source.SourceURL,
+		"full":     plan.full,
+		"extents":  len(plan.extents),
+	})
+	logger.Info("💾 Restoring disk")
+
+	backing := plan.disk.Backing.(types.BaseVirtualDeviceFileBackingInfo)
+	password, _ := r.VddkConfig.Endpoint.User.Password()
+	server, err := nbdkit.NewNbdkitBuilder().
+		Server(r.VddkConfig.Endpoint.Host).
+		Username(r.VddkConfig.Endpoint.User.Username()).
+		Password(password).
+		Thumbprint(r.VddkConfig.Thumbprint).
+		VirtualMachine(r.VirtualMachine.Reference().Value).
+		Filename(backing.GetVirtualDeviceFileBackingInfo().FileName).
+		Compression(r.VddkConfig.Compression).
+		Writable().
+		Build()
+	if err != nil {
+		return err
+	}
+	if err := server.Start(); err != nil {
+		return err
+	}
+	defer server.Stop()
+
+	sourceNBD, err := libnbd.Create()
+	if err != nil {
+		return fmt.Errorf("failed to create source NBD handle: %w", err)
+	}
+	defer sourceNBD.Close()
+	if err := sourceNBD.ConnectUri(plan.source.SourceURL); err != nil {
+		return fmt.Errorf("failed to connect to backup export %s: %w", plan.source.SourceURL, err)
+	}
+
+	targetNBD, err := libnbd.Create()
+	if err != nil {
+		return fmt.Errorf("failed to create target NBD handle: %w", err)
+	}
+	defer targetNBD.Close()
+	if err := targetNBD.ConnectUri(server.LibNBDExportName()); err != nil {
+		return fmt.Errorf("failed to connect to VMware disk: %w", err)
+	}
+
+	for _, extent := range plan.extents {
+		if plan.full {
+			err = r.copyRange(ctx, sourceNBD, targetNBD, extent)
+		} else {
+			err = copyExtent(sourceNBD, targetNBD, extent)
+			r.bytesTransferred += extent.Length
+			r.reportProgress(ctx, false)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := targetNBD.Flush(nil); err != nil {
+		return fmt.Errorf("failed to flush VMware disk: %w", err)
+	}
+
+	logger.Info("✅ Disk restored")
+	return nil
+}
+
+// copyRange copies a whole range chunk by chunk, skipping zero chunks on clean targets
+func (r *RestoreServers) copyRange(ctx context.Context, sourceNBD, targetNBD *libnbd.Libnbd, extent CoalescedExtent) error {
+	buffer := make([]byte, MaxChunkSize)
+	for offset := extent.Offset; offset < extent.Offset+extent.Length; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		chunk := buffer
+		if remaining := extent.Offset + extent.Length - offset; remaining < int64(len(chunk)) {
+			chunk = buffer[:remaining]
+		}
+
+		if err := sourceNBD.Pread(chunk, uint64(offset), nil); err != nil {
+			return fmt.Errorf("source read failed at offset %d: %w", offset, err)
+		}
+
+		if isZeroBlock(chunk) {
+			if !r.TargetIsClean {
+				if err := targetNBD.Zero(uint64(len(chunk)), uint64(offset), nil); err != nil {
+					if err := targetNBD.Pwrite(chunk, uint64(offset), nil); err != nil {
+						return fmt.Errorf("target zero/write fallback failed at offset %d: %w", offset, err)
+					}
+				}
+			}
+		} else if err := targetNBD.Pwrite(chunk, uint64(offset), nil); err != nil {
+			return fmt.Errorf("target write failed at offset %d: %w", offset, err)
+		}
+
+		offset += int64(len(chunk))
+		r.bytesTransferred += int64(len(chunk))
+		r.reportProgress(ctx, false)
+	}
+
+	return nil
+}
+
+// changedAreasSince returns the areas of a disk changed since the backup's change ID
+// The VM is powered off, so no snapshot is needed to query CBT
+func (r *RestoreServers) changedAreasSince(ctx context.Context, disk *types.VirtualDisk, changeID string) ([]DiskExtent, error) {
+	var extents []DiskExtent
+	for startOffset := int64(0); startOffset < disk.CapacityInBytes; {
+		req := types.QueryChangedDiskAreas{
+			This:        r.VirtualMachine.Reference(),
+			DeviceKey:   disk.Key,
+			StartOffset: startOffset,
+			ChangeId:    changeID,
+		}
+
+		res, err := methods.QueryChangedDiskAreas(ctx, r.VirtualMachine.Client(), &req)
+		if err != nil {
+			return nil, fmt.Errorf("QueryChangedDiskAreas failed at offset %d: %w", startOffset, err)
+		}
+
+		for _, area := range res.Returnval.ChangedArea {
+			extents = append(extents, DiskExtent{Offset: area.Start, Length: area.Length})
+		}
+		startOffset = res.Returnval.StartOffset + res.Returnval.Length
+	}
+
+	log.WithFields(log.Fields{
+		"disk_key":     disk.Key,
+		"change_id":    changeID,
+		"extent_count": len(extents),
+	}).Info("📊 Changed areas since backup queried")
+	return extents, nil
+}
+
+// reportProgress sends restore telemetry to the SHA at most every 2 seconds
+func (r *RestoreServers) reportProgress(ctx context.Context, final bool) {
+	if !final && time.Since(r.lastReport) < 2*time.Second {
+		return
+	}
+	r.lastReport = time.Now()
+
+	tracker, ok := ctx.Value("telemetryTracker").(*telemetry.ProgressTracker)
+	if !ok {
+		return
+	}
+
+	var throughputBPS int64
+	var etaSeconds int
+	if elapsed := time.Since(r.startTime).Seconds(); elapsed > 0 {
+		throughputBPS = int64(float64(r.bytesTransferred) / elapsed)
+		if throughputBPS > 0 {
+			etaSeconds = int(float64(r.totalBytes-r.bytesTransferred) / float64(throughputBPS))
+		}
+	}
+
+	tracker.UpdateProgress(ctx, r.bytesTransferred, r.totalBytes, throughputBPS, etaSeconds, "restoring")
+}
@@ -13,9 +13,13 @@ import (
 	"unsafe"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/vexxhost/migratekit/internal/checkpoint"
+	"github.com/vexxhost/migratekit/internal/events"
+	"github.com/vexxhost/migratekit/internal/jobcontrol"
 	"github.com/vexxhost/migratekit/internal/nbdkit"
 	"github.com/vexxhost/migratekit/internal/progress"
 	"github.com/vexxhost/migratekit/internal/target"
+	"github.com/vexxhost/migratekit/internal/telemetry"
 	vmware "github.com/vexxhost/migratekit/internal/vmware"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/vim25/methods"
@@ -36,11 +40,23 @@ func getSnapshotPrefix(jobID string) string {
 }
 
 type VddkConfig struct {
-	Debug       bool
-	Endpoint    *url.URL
-	Thumbprint  string
-	Compression nbdkit.CompressionMethod
-	Quiesce     bool
+	Debug            bool
+	Endpoint         *url.URL
+	Thumbprint       string
+	Compression      nbdkit.CompressionMethod
+	Consistency      vmware.ConsistencyPolicy // Requested snapshot consistency (falls back automatically)
+	GuestHooks       []vmware.GuestHook       // In-guest pre/post snapshot hooks
+	ExcludedDiskKeys []int                    // VMware disk keys skipped by backup policy
+}
+
+// isDiskExcluded reports whether a disk was excluded from the job by policy
+func (c *VddkConfig) isDiskExcluded(disk *types.VirtualDisk) bool {
+	for _, key := range c.ExcludedDiskKeys {
+		if int32(key) == disk.Key {
+			return true
+		}
+	}
+	return false
 }
 
 type NbdkitServers struct {
@@ -50,6 +66,9 @@ type NbdkitServers struct {
 	Servers          []*NbdkitServer
 	JobID            string // Full job identifier (e.g., "backup-backup-pgtest3-1760025105")
 	SnapshotPrefix   string // Computed prefix: "sbak-" for backups, "srep-" for replications
+	Consistency      vmware.SnapshotConsistency // Consistency level the snapshot actually achieved
+	Checkpoints      *checkpoint.Store          // Copied ranges kept so an interrupted run can resume
+	keepSnapshot     bool                       // Interrupted with checkpointed progress - keep the snapshot for a retry
 }
 
 type NbdkitServer struct {
@@ -77,6 +96,8 @@ func (s *NbdkitServers) createSnapshot(ctx context.Context) error {
 		}
 	}
 
+	events.FromContext(ctx).Status("running", "snapshot")
+
 	// Use job-specific snapshot name with type prefix (sbak- or srep-)
 	snapshotName := s.SnapshotPrefix + s.JobID
 	log.WithFields(log.Fields{
@@ -85,23 +106,35 @@ func (s *NbdkitServers) createSnapshot(ctx context.Context) error {
 		"prefix":        s.SnapshotPrefix,
 	}).Info("📸 Creating job-specific snapshot")
 
-	task, err := s.VirtualMachine.CreateSnapshot(ctx, snapshotName, "Sendense backup/replication snapshot", false, s.VddkConfig.Quiesce)
-	if err != nil {
+	// Pre-snapshot hooks (e.g. freeze non-VSS databases) run in the guest via VMware Tools
+	if err := vmware.RunGuestHooks(ctx, s.VirtualMachine, s.VddkConfig.GuestHooks, vmware.GuestHookPreSnapshot); err != nil {
+		// Thaw anything the earlier hooks froze before bailing out
+		if postErr := vmware.RunGuestHooks(ctx, s.VirtualMachine, s.VddkConfig.GuestHooks, vmware.GuestHookPostSnapshot); postErr != nil {
+			log.WithError(postErr).Warn("⚠️ Post-snapshot hooks failed after pre-snapshot hook failure")
+		}
 		return err
 	}
 
-	bar := progress.NewVMwareProgressBar("Creating snapshot")
-	ctx, cancel := context.WithCancel(ctx)
-	go func() {
-		bar.Loop(ctx.Done())
-	}()
-	defer cancel()
+	info, err := s.waitForSnapshot(ctx, snapshotName)
+	if err == nil {
+		s.SnapshotRef = info.Result.(types.ManagedObjectReference)
+	}
 
-	info, err := task.WaitForResult(ctx, bar)
+	// Post-snapshot hooks (e.g. thaw databases) always run once the snapshot attempt is over
+	if postErr := vmware.RunGuestHooks(ctx, s.VirtualMachine, s.VddkConfig.GuestHooks, vmware.GuestHookPostSnapshot); postErr != nil {
+		if err != nil {
+			log.WithError(postErr).Warn("⚠️ Post-snapshot hooks failed after snapshot failure")
+		} else {
+			err = postErr
+		}
+	}
 	if err != nil {
 		return err
 	}
 
+	s.reportConsistency(ctx)
+	events.FromContext(ctx).Snapshot("created", s.VirtualMachine.Reference().Value, s.SnapshotRef.Value, snapshotName)
+
 	// Send SNA progress update for snapshot creation completion
 	if snaProgressClient := ctx.Value("snaProgressClient"); snaProgressClient != nil {
 		if vpc, ok := snaProgressClient.(*progress.SNAProgressClient); ok && vpc.IsEnabled() {
@@ -109,18 +142,90 @@ func (s *NbdkitServers) createSnapshot(ctx context.Context) error {
 		}
 	}
 
-	s.SnapshotRef = info.Result.(types.ManagedObjectReference)
 	return nil
 }
 
-func (s *NbdkitServers) Start(ctx context.Context) error {
-	err := s.createSnapshot(ctx)
+// waitForSnapshot creates the job snapshot at the strongest consistency level
+// the VM allows, falling back towards crash-consistent when quiescing fails
+func (s *NbdkitServers) waitForSnapshot(ctx context.Context, snapshotName string) (*types.TaskInfo, error) {
+	plan := vmware.PlanSnapshot(ctx, s.VirtualMachine, s.VddkConfig.Consistency)
+	reason := plan.Reason
+
+	var lastErr error
+	for _, level := range plan.Levels {
+		info, err := s.snapshotAtLevel(ctx, plan, snapshotName, level)
+		if err == nil {
+			s.Consistency = vmware.SnapshotConsistency{
+				Requested:      plan.Requested,
+				Achieved:       level,
+				FallbackReason: reason,
+			}
+			return info, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		log.WithError(err).WithField("consistency_level", level).Warn("⚠️ Snapshot failed at this consistency level, falling back")
+		reason = fmt.Sprintf("%s snapshot failed: %v", level, err)
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
+
+// snapshotAtLevel creates one snapshot attempt and waits for the task to finish
+func (s *NbdkitServers) snapshotAtLevel(ctx context.Context, plan *vmware.SnapshotPlan, snapshotName, level string) (*types.TaskInfo, error) {
+	task, err := plan.CreateSnapshot(ctx, snapshotName, "Sendense backup/replication snapshot", level)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	bar := progress.NewVMwareProgressBar("Creating snapshot")
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		bar.Loop(ctx.Done())
+	}()
+	defer cancel()
+
+	return task.WaitForResult(ctx, bar)
+}
+
+// reportConsistency logs the achieved consistency level and pushes it to SHA
+// so it is recorded on the backup - degraded snapshots must never go unnoticed
+func (s *NbdkitServers) reportConsistency(ctx context.Context) {
+	logger := log.WithFields(log.Fields{
+		"requested": s.Consistency.Requested,
+		"achieved":  s.Consistency.Achieved,
+	})
+	if s.Consistency.FallbackReason != "" {
+		logger.WithField("reason", s.Consistency.FallbackReason).Warn("⚠️ Snapshot consistency degraded")
+	} else {
+		logger.Info("✅ Snapshot created at requested consistency level")
+	}
+
+	if telemetryTracker := ctx.Value("telemetryTracker"); telemetryTracker != nil {
+		if tracker, ok := telemetryTracker.(*telemetry.ProgressTracker); ok {
+			tracker.SetConsistency(s.Consistency.Achieved, s.Consistency.FallbackReason)
+			tracker.UpdateJobStatus(ctx, "running", "snapshot", "")
+		}
+	}
+}
+
+func (s *NbdkitServers) Start(ctx context.Context) error {
+	// A retried job picks up the snapshot and copied ranges of its interrupted run
+	s.Checkpoints = checkpoint.Open(checkpoint.DefaultDir, s.JobID)
+	s.removeStaleSnapshots(ctx)
+
+	if !s.resumeSnapshot(ctx) {
+		if err := s.createSnapshot(ctx); err != nil {
+			return events.WithCode(events.CodeSnapshot, err)
+		}
 	}
+	s.beginCheckpoint()
 
 	var snapshot mo.VirtualMachineSnapshot
-	err = s.VirtualMachine.Properties(ctx, s.SnapshotRef, []string{"config.hardware"}, &snapshot)
+	err := s.VirtualMachine.Properties(ctx, s.SnapshotRef, []string{"config.hardware"}, &snapshot)
 	if err != nil {
 		return err
 	}
@@ -128,6 +233,11 @@ func (s *NbdkitServers) Start(ctx context.Context) error {
 	for _, device := range snapshot.Config.Hardware.Device {
 		switch disk := device.(type) {
 		case *types.VirtualDisk:
+			if s.VddkConfig.isDiskExcluded(disk) {
+				log.WithField("disk_key", disk.Key).Info("⏭️ Skipping disk excluded by backup policy")
+				continue
+			}
+
 			backing := disk.Backing.(types.BaseVirtualDeviceFileBackingInfo)
 			info := backing.GetVirtualDeviceFileBackingInfo()
 
@@ -164,6 +274,7 @@ func (s *NbdkitServers) Start(ctx context.Context) error {
 		<-c
 		log.Warn("Received interrupt signal, cleaning up...")
 
+		s.keepSnapshot = s.keepSnapshotForResume(ctx)
 		err := s.Stop(ctx)
 		if err != nil {
 			log.WithError(err).Fatal("Failed to stop nbdkit servers")
@@ -194,6 +305,7 @@ func (s *NbdkitServers) removeSnapshot(ctx context.Context) error {
 		return err
 	}
 
+	events.FromContext(ctx).Snapshot("removed", s.VirtualMachine.Reference().Value, s.SnapshotRef.Value, "")
 	return nil
 }
 
@@ -204,6 +316,11 @@ func (s *NbdkitServers) Stop(ctx context.Context) error {
 		}
 	}
 
+	if s.keepSnapshot {
+		log.WithField("job_id", s.JobID).Warn("⏸️ Keeping snapshot so a retry can resume the interrupted transfer")
+		return nil
+	}
+
 	err := s.removeSnapshot(ctx)
 	if err != nil {
 		return err
@@ -224,22 +341,31 @@ func (s *NbdkitServers) MigrationCycle(ctx context.Context, runV2V bool) error {
 		}
 	}()
 
+	// A cancel from the SNA stops the copy only; Stop above still removes the snapshot
+	copyCtx, cancelCopy := jobcontrol.FromContext(ctx).Bind(ctx)
+	defer cancelCopy()
+
+	events.FromContext(ctx).Status("running", "transferring")
+
 	for index, server := range s.Servers {
-		t, err := target.NewNBDTarget(ctx, s.VirtualMachine, server.Disk)
+		t, err := target.NewNBDTarget(copyCtx, s.VirtualMachine, server.Disk)
 		if err != nil {
-			return err
+			s.keepSnapshot = s.keepSnapshotForResume(ctx)
+			return events.WithCode(events.CodeTarget, err)
 		}
 
 		if index != 0 {
 			runV2V = false
 		}
 
-		err = server.SyncToTarget(ctx, t, runV2V)
+		err = server.SyncToTarget(copyCtx, t, runV2V)
 		if err != nil {
-			return err
+			s.keepSnapshot = s.keepSnapshotForResume(ctx)
+			return events.WithCode(events.CodeTransfer, err)
 		}
 	}
 
+	s.Checkpoints.Discard()
 	return nil
 }
 
@@ -937,12 +1063,16 @@ func (s *NbdkitServer) SyncToTarget(ctx context.Context, t target.Target, runV2V
 	}
 
 	if needFullCopy {
+		events.FromContext(ctx).Transfer(s.Disk.Key, "full")
+
 		// Use parallel NBD copy for full disk migrations (highest throughput)
 		err = s.ParallelFullCopyToTarget(ctx, t, path, targetIsClean)
 		if err != nil {
 			return err
 		}
 	} else {
+		events.FromContext(ctx).Transfer(s.Disk.Key, "incremental")
+
 		// Use parallel NBD copy (with auto-fallback to serial on error)
 		err = s.ParallelIncrementalCopyToTarget(ctx, t, path)
 		if err != nil {
@@ -972,13 +1102,14 @@ func (s *NbdkitServer) SyncToTarget(ctx context.Context, t target.Target, runV2V
 
 		err = t.WriteChangeID(ctx, &vmware.ChangeID{})
 		if err != nil {
-			return err
+			return events.WithCode(events.CodeChangeID, err)
 		}
 	} else {
 		err = t.WriteChangeID(ctx, snapshotChangeId)
 		if err != nil {
-			return err
+			return events.WithCode(events.CodeChangeID, err)
 		}
+		events.FromContext(ctx).ChangeID(s.Disk.Key, snapshotChangeId.Value)
 	}
 
 	return nil
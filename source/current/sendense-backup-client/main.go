@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -14,6 +16,12 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/thediveo/enumflag/v2"
 	"github.com/vexxhost/migratekit/cmd"
+	"github.com/vexxhost/migratekit/internal/checkpoint"
+	"github.com/vexxhost/migratekit/internal/credentials"
+	"github.com/vexxhost/migratekit/internal/events"
+	"github.com/vexxhost/migratekit/internal/jobcontrol"
+	"github.com/vexxhost/migratekit/internal/kvm"
+	"github.com/vexxhost/migratekit/internal/kvm_nbd"
 	"github.com/vexxhost/migratekit/internal/nbdkit"
 	// "github.com/vexxhost/migratekit/internal/openstack"
 	"github.com/vexxhost/migratekit/internal/progress"
@@ -64,6 +72,10 @@ var (
 	endpoint             string
 	username             string
 	password             string
+	passwordSource       = credentials.Source{PasswordFD: -1, TokenFD: -1}
+	activeJobControl     *jobcontrol.Controller
+	activeEvents         *events.Emitter
+	eventFD              = -1
 	path                 string
 	compressionMethod    CompressionMethodOpts = Skipz
 	flavorId             string
@@ -82,6 +94,18 @@ var (
 	quiesceSnapshot      bool
 	enableQemuGuestAgent bool
 	jobID                string
+	guestHooksFile       string
+	consistencyLevel     string
+	vssBackupType        string
+	excludeDiskKeys      []int
+	restoreSpecFile      string
+	cleanupSnapshotRef   string
+	libvirtURI           string
+	kvmDomain            string
+	kvmNBDHost           string
+	kvmNBDPort           int
+	kvmNBDSocket         string
+	kvmScratchDir        string
 )
 
 // getSnapshotPrefix determines the snapshot prefix based on job ID
@@ -131,6 +155,10 @@ var rootCmd = &cobra.Command{
 			log.SetLevel(log.DebugLevel)
 		}
 
+		if err := resolvePassword(); err != nil {
+			return err
+		}
+
 		endpointUrl := &url.URL{
 			Scheme: "https",
 			Host:   endpoint,
@@ -152,23 +180,8 @@ var rootCmd = &cobra.Command{
 
 		ctx := context.TODO()
 
-		soapClient := soap.NewClient(endpointUrl, true)
-		vimClient, err := vim25.NewClient(ctx, soapClient)
+		vimClient, err := connectVMware(ctx, endpointUrl)
 		if err != nil {
-			log.WithError(err).Error("Failed to create VMware client")
-			return err
-		}
-
-		vimClient.RoundTripper = keepalive.NewHandlerSOAP(
-			vimClient.RoundTripper,
-			15*time.Second,
-			nil,
-		)
-
-		mgr := session.NewManager(vimClient)
-		err = mgr.Login(ctx, endpointUrl.User)
-		if err != nil {
-			log.WithError(err).Error("Failed to login to VMware")
 			return err
 		}
 
@@ -260,13 +273,40 @@ var rootCmd = &cobra.Command{
 			}
 		}
 
+		// 🪝 Load in-guest pre/post snapshot hooks handed over by the SNA
+		var guestHooks []vmware.GuestHook
+		if guestHooksFile != "" {
+			guestHooks, err = vmware.LoadGuestHooks(guestHooksFile)
+			if err != nil {
+				return err
+			}
+			log.WithField("hook_count", len(guestHooks)).Info("🪝 Loaded guest snapshot hooks")
+		}
+
+		// 📸 Snapshot consistency policy - legacy --quiesce-snapshot maps to filesystem/crash
+		consistency := vmware.ConsistencyPolicy{
+			Level:         consistencyLevel,
+			VSSBackupType: vssBackupType,
+		}
+		if consistency.Level == "" {
+			consistency.Level = vmware.ConsistencyCrash
+			if quiesceSnapshot {
+				consistency.Level = vmware.ConsistencyFilesystem
+			}
+		}
+		if err := consistency.Validate(); err != nil {
+			return err
+		}
+
 		ctx = context.WithValue(ctx, "vm", vm)
 		ctx = context.WithValue(ctx, "vddkConfig", &vmware_nbdkit.VddkConfig{
-			Debug:       debug,
-			Endpoint:    endpointUrl,
-			Thumbprint:  thumbprint,
-			Compression: nbdkit.CompressionMethod(CompressionMethodOptsIds[compressionMethod][0]),
-			Quiesce:     quiesceSnapshot,
+			Debug:            debug,
+			Endpoint:         endpointUrl,
+			Thumbprint:       thumbprint,
+			Compression:      nbdkit.CompressionMethod(CompressionMethodOptsIds[compressionMethod][0]),
+			Consistency:      consistency,
+			GuestHooks:       guestHooks,
+			ExcludedDiskKeys: excludeDiskKeys,
 		})
 
 		log.Info("Setting Disk Bus: ", BusTypeOptsIds[busType][0])
@@ -298,56 +338,7 @@ var rootCmd = &cobra.Command{
 			}
 		}
 
-		// 🎯 CRITICAL: Set environment variable for progress tracking
-		// This enables the progress system to use the command line job ID
-		// while keeping CBT functionality separate (uses MIGRATEKIT_JOB_ID)
-		if jobID != "" {
-			os.Setenv("MIGRATEKIT_PROGRESS_JOB_ID", jobID)
-			log.WithField("job_id", jobID).Info("Set progress tracking job ID from command line flag")
-
-			// 🎯 CRITICAL: Initialize SNA progress client for real-time tracking
-			snaProgressClient := progress.NewVMAProgressClient()
-			if snaProgressClient.IsEnabled() {
-				log.WithFields(log.Fields{
-					"job_id":  snaProgressClient.GetJobID(),
-					"vma_url": "http://localhost:8081",
-				}).Info("🎯 SNA progress tracking enabled")
-				log.WithField("job_id", jobID).Info("🎯 Early progress tracking enabled - monitoring all migration phases")
-
-				// Add to context for use throughout migration
-				ctx = context.WithValue(ctx, "snaProgressClient", snaProgressClient)
-
-				// Send initial progress update
-				snaProgressClient.SendStageUpdate("Initializing", 5)
-			} else {
-				log.Warn("❌ SNA progress tracking failed to initialize - check MIGRATEKIT_PROGRESS_JOB_ID")
-			}
-
-			// 🆕 NEW: Initialize telemetry client for real-time SHA tracking (replaces polling)
-			shaURL := os.Getenv("SHA_API_URL")
-			if shaURL == "" {
-				shaURL = "http://localhost:8082" // Default tunnel endpoint
-			}
-			telemetryClient := telemetry.NewClient(shaURL)
-			
-			// Determine job type from job ID prefix (backup-*, replication-*, etc.)
-			jobType := "backup"
-			if !strings.HasPrefix(jobID, "backup-") {
-				jobType = "replication" // For replication jobs in the future
-			}
-			
-			// Create telemetry tracker
-			telemetryTracker := telemetry.NewProgressTracker(telemetryClient, jobID)
-			
-			ctx = context.WithValue(ctx, "telemetryClient", telemetryClient)
-			ctx = context.WithValue(ctx, "telemetryTracker", telemetryTracker)
-			
-			log.WithFields(log.Fields{
-				"job_id":  jobID,
-				"job_type": jobType,
-				"sha_url": shaURL,
-			}).Info("🚀 SHA telemetry tracking initialized (push-based real-time progress)")
-		}
+		ctx = initProgressTracking(ctx)
 
 		cmd.SetContext(ctx)
 
@@ -376,6 +367,13 @@ It handles the following additional cases as well:
 		servers := vmware_nbdkit.NewNbdkitServers(vddkConfig, vm, jobID)
 		err := servers.MigrationCycle(ctx, false)
 		if err != nil {
+			// Cancelled through the SNA - snapshot and nbdkit are already cleaned up
+			if jobcontrol.FromContext(ctx).Cancelled() {
+				log.WithField("job_id", jobID).Warn("🛑 Job cancelled")
+				if tracker, ok := ctx.Value("telemetryTracker").(*telemetry.ProgressTracker); ok {
+					tracker.UpdateJobStatus(ctx, "cancelled", "cancelled", "")
+				}
+			}
 			return err
 		}
 
@@ -487,6 +485,332 @@ var cutoverCmd = &cobra.Command{
 	},
 }
 
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore a backup to VMware as a new VM or in place",
+	Long: `This command writes backup disk images (served over NBD by the SHA) back to VMware vSphere:
+
+- new_vm: creates a new VM from the backup's captured VM configuration and writes all disks.
+- in_place: overwrites the disks of the original, powered off VM. When the backup recorded
+  CBT change IDs, only the blocks changed since the backup are written.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if debug {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		if err := resolvePassword(); err != nil {
+			return err
+		}
+
+		endpointUrl := &url.URL{
+			Scheme: "https",
+			Host:   endpoint,
+			User:   url.UserPassword(username, password),
+			Path:   "sdk",
+		}
+
+		thumbprint, err := vmware.GetEndpointThumbprint(endpointUrl)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.TODO()
+
+		vimClient, err := connectVMware(ctx, endpointUrl)
+		if err != nil {
+			return err
+		}
+
+		ctx = context.WithValue(ctx, "vimClient", vimClient)
+		ctx = context.WithValue(ctx, "vddkConfig", &vmware_nbdkit.VddkConfig{
+			Debug:       debug,
+			Endpoint:    endpointUrl,
+			Thumbprint:  thumbprint,
+			Compression: nbdkit.CompressionMethod(CompressionMethodOptsIds[compressionMethod][0]),
+		})
+		ctx = context.WithValue(ctx, "jobID", jobID)
+
+		// 🆕 NEW: Restore progress is pushed to SHA under the "restore" telemetry job type
+		if jobID != "" {
+			shaURL := os.Getenv("SHA_API_URL")
+			if shaURL == "" {
+				shaURL = "http://localhost:8082" // Default tunnel endpoint
+			}
+			telemetryTracker := telemetry.NewProgressTracker(telemetry.NewClient(shaURL), jobID)
+			telemetryTracker.SetJobType("restore")
+			ctx = context.WithValue(ctx, "telemetryTracker", telemetryTracker)
+			ctx = openEventStream(ctx, telemetryTracker)
+
+			log.WithFields(log.Fields{
+				"job_id":  jobID,
+				"sha_url": shaURL,
+			}).Info("🚀 SHA telemetry tracking initialized for restore")
+		}
+
+		cmd.SetContext(ctx)
+
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		vimClient := ctx.Value("vimClient").(*vim25.Client)
+		vddkConfig := ctx.Value("vddkConfig").(*vmware_nbdkit.VddkConfig)
+		jobID := ctx.Value("jobID").(string)
+		tracker, _ := ctx.Value("telemetryTracker").(*telemetry.ProgressTracker)
+
+		reportStatus := func(status, phase, errorMessage string) {
+			if tracker != nil {
+				tracker.UpdateJobStatus(ctx, status, phase, errorMessage)
+			}
+		}
+		fail := func(phase string, err error) error {
+			log.WithError(err).WithField("phase", phase).Error("❌ Restore failed")
+			reportStatus("failed", phase, err.Error())
+			return err
+		}
+
+		spec, err := vmware.LoadRestoreSpec(restoreSpecFile)
+		if err != nil {
+			return fail("preparing", err)
+		}
+
+		log.WithFields(log.Fields{
+			"job_id": jobID,
+			"mode":   spec.Mode,
+			"disks":  len(spec.Disks),
+		}).Info("♻️ Starting VMware restore")
+		reportStatus("running", "preparing", "")
+
+		var vm *object.VirtualMachine
+		var disks map[int32]*types.VirtualDisk
+		switch spec.Mode {
+		case vmware.RestoreModeNewVM:
+			reportStatus("running", "creating_vm", "")
+			vm, disks, err = vmware.CreateRestoreVM(ctx, vimClient, spec)
+			if err != nil {
+				return fail("creating_vm", err)
+			}
+		case vmware.RestoreModeInPlace:
+			finder := find.NewFinder(vimClient)
+			vm, err = finder.VirtualMachine(ctx, spec.VMPath)
+			if err != nil {
+				return fail("preparing", fmt.Errorf("failed to find VM %s: %w", spec.VMPath, err))
+			}
+			disks, err = vmware.PrepareInPlaceRestore(ctx, vm)
+			if err != nil {
+				return fail("preparing", err)
+			}
+		}
+
+		reportStatus("running", "restoring", "")
+		servers := vmware_nbdkit.NewRestoreServers(vddkConfig, vm, spec, jobID)
+		servers.TargetIsClean = spec.Mode == vmware.RestoreModeNewVM
+		if err := servers.RestoreDisks(ctx, disks); err != nil {
+			if spec.Mode == vmware.RestoreModeNewVM {
+				// Don't leave a half-written VM behind
+				if task, destroyErr := vm.Destroy(ctx); destroyErr == nil {
+					destroyErr = task.Wait(ctx)
+					if destroyErr != nil {
+						log.WithError(destroyErr).Warn("⚠️ Failed to remove partially restored VM")
+					}
+				}
+			}
+			return fail("restoring", err)
+		}
+
+		if spec.VMConfig != nil {
+			reportStatus("running", "applying_config", "")
+			if spec.Mode == vmware.RestoreModeInPlace {
+				if err := vmware.ReapplyVMConfig(ctx, vm, spec.VMConfig); err != nil {
+					return fail("applying_config", err)
+				}
+			}
+			vmware.ApplyVMMetadata(ctx, vimClient, vddkConfig.Endpoint.User, vm, spec.VMConfig)
+		}
+
+		if spec.PowerOn {
+			reportStatus("running", "powering_on", "")
+			task, err := vm.PowerOn(ctx)
+			if err == nil {
+				err = task.Wait(ctx)
+			}
+			if err != nil {
+				return fail("powering_on", fmt.Errorf("disks restored but power on failed: %w", err))
+			}
+		}
+
+		reportStatus("completed", "completed", "")
+		log.WithField("vm", vm.Reference().Value).Info("✅ VMware restore completed")
+		return nil
+	},
+}
+
+var cleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Remove the snapshot left behind by a backup client that did not exit cleanly",
+	Long: `This command is run by the SNA when it finds, after restarting, that the backup client of a
+job is gone. It removes the job's snapshot (the one recorded in its transfer checkpoint) and
+the checkpoint itself, since the SHA has already marked the job as failed.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if debug {
+			log.SetLevel(log.DebugLevel)
+		}
+		return resolvePassword()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		vimClient, err := connectVMware(ctx, &url.URL{
+			Scheme: "https",
+			Host:   endpoint,
+			User:   url.UserPassword(username, password),
+			Path:   "sdk",
+		})
+		if err != nil {
+			return err
+		}
+
+		vm, err := find.NewFinder(vimClient).VirtualMachine(ctx, path)
+		if err != nil {
+			return fmt.Errorf("failed to find VM %s: %w", path, err)
+		}
+
+		logger := log.WithFields(log.Fields{
+			"job_id":       jobID,
+			"vm":           vm.Reference().Value,
+			"snapshot_ref": cleanupSnapshotRef,
+		})
+
+		consolidate := true
+		task, err := vm.RemoveSnapshot(ctx, cleanupSnapshotRef, false, &consolidate)
+		if err == nil {
+			err = task.Wait(ctx)
+		}
+		if err != nil {
+			var snapshot mo.VirtualMachineSnapshot
+			ref := types.ManagedObjectReference{Type: "VirtualMachineSnapshot", Value: cleanupSnapshotRef}
+			if vm.Properties(ctx, ref, []string{"config.name"}, &snapshot) == nil {
+				return fmt.Errorf("failed to remove snapshot %s: %w", cleanupSnapshotRef, err)
+			}
+			logger.Info("Snapshot already gone")
+		} else {
+			logger.Info("🧹 Removed snapshot of interrupted job")
+		}
+
+		if jobID != "" {
+			checkpoint.Remove(checkpoint.DefaultDir, jobID)
+		}
+		return nil
+	},
+}
+
+var kvmBackupCmd = &cobra.Command{
+	Use:   "kvm-backup",
+	Short: "Back up a KVM/QEMU VM managed by libvirt",
+	Long: `This command backs up a libvirt domain to the NBD targets given with --nbd-targets (disk
+keys 2000, 2001... in domain XML order) using libvirt's pull-mode backup API:
+
+- If no change ID is recorded for a disk, or its checkpoint no longer exists, it copies the disk in full.
+- Otherwise it copies only the blocks in the QEMU dirty bitmap of the recorded checkpoint.
+
+Each run creates a checkpoint, recorded as the disks' change ID, and deletes the checkpoints of
+earlier runs of the same job type. The domain must use qcow2 disks for persistent bitmaps.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if debug {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		// The vCenter flags are required by the VMware commands only
+		for _, name := range []string{"vmware-endpoint", "vmware-username", "vmware-path"} {
+			cmd.Flags().SetAnnotation(name, cobra.BashCompOneRequiredFlag, []string{"false"})
+		}
+
+		if kvmNBDSocket == "" && kvmNBDHost == "" {
+			return fmt.Errorf("--kvm-nbd-host or --kvm-nbd-socket is required")
+		}
+
+		ctx := context.TODO()
+		ctx = context.WithValue(ctx, "nbdHost", nbdHost)
+		ctx = context.WithValue(ctx, "nbdPort", nbdPort)
+		ctx = context.WithValue(ctx, "nbdExportName", nbdExportName)
+		ctx = context.WithValue(ctx, "nbdTargets", nbdTargets)
+		ctx = context.WithValue(ctx, "jobID", jobID)
+		ctx = initProgressTracking(ctx)
+
+		cmd.SetContext(ctx)
+
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		// Same policy flags as VMware backups; guest agent quiescing is filesystem level
+		consistency := consistencyLevel
+		if consistency == "" {
+			consistency = vmware.ConsistencyCrash
+			if quiesceSnapshot {
+				consistency = vmware.ConsistencyFilesystem
+			}
+		}
+		if err := (vmware.ConsistencyPolicy{Level: consistency}).Validate(); err != nil {
+			return err
+		}
+
+		backup := kvm_nbd.NewBackup(kvm_nbd.BackupConfig{
+			LibvirtURI: libvirtURI,
+			Domain:     kvmDomain,
+			Server: kvm.NBDServer{
+				Host:   kvmNBDHost,
+				Port:   kvmNBDPort,
+				Socket: kvmNBDSocket,
+			},
+			ScratchDir:       kvmScratchDir,
+			Consistency:      consistency,
+			ExcludedDiskKeys: excludeDiskKeys,
+			JobID:            jobID,
+		})
+		err := backup.Run(ctx)
+		if err != nil {
+			if jobcontrol.FromContext(ctx).Cancelled() {
+				log.WithField("job_id", jobID).Warn("🛑 Job cancelled")
+				if tracker, ok := ctx.Value("telemetryTracker").(*telemetry.ProgressTracker); ok {
+					tracker.UpdateJobStatus(ctx, "cancelled", "cancelled", "")
+				}
+			}
+			return err
+		}
+
+		log.Info("KVM backup completed")
+		return nil
+	},
+}
+
+var kvmInspectCmd = &cobra.Command{
+	Use:   "kvm-inspect",
+	Short: "Print a libvirt domain's disks as JSON",
+	Long: `This command prints the UUID and disks of a libvirt domain, with the disk keys and
+capacities kvm-backup uses, so the SHA can record the disks before the first backup.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if debug {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		// The vCenter flags are required by the VMware commands only
+		for _, name := range []string{"vmware-endpoint", "vmware-username", "vmware-path"} {
+			cmd.Flags().SetAnnotation(name, cobra.BashCompOneRequiredFlag, []string{"false"})
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info, err := kvm.Inspect(cmd.Context(), kvm.NewVirsh(libvirtURI), kvmDomain)
+		if err != nil {
+			return err
+		}
+		return json.NewEncoder(os.Stdout).Encode(info)
+	},
+}
+
 func init() {
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Enable debug logging")
 
@@ -496,8 +820,12 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&username, "vmware-username", "", "VMware username")
 	rootCmd.MarkPersistentFlagRequired("vmware-username")
 
-	rootCmd.PersistentFlags().StringVar(&password, "vmware-password", "", "VMware password")
-	rootCmd.MarkPersistentFlagRequired("vmware-password")
+	// The password is kept off the command line by the SNA - see internal/credentials
+	rootCmd.PersistentFlags().StringVar(&passwordSource.Password, "vmware-password", "", "VMware password (visible in ps - prefer --vmware-password-fd or --vmware-credential-ref)")
+	rootCmd.PersistentFlags().IntVar(&passwordSource.PasswordFD, "vmware-password-fd", -1, "Read the VMware password from this inherited file descriptor (0 = stdin)")
+	rootCmd.PersistentFlags().StringVar(&passwordSource.CredentialsFile, "vmware-credentials-file", "", "JSON file with a password or credential_id and token (must be 0600, removed after loading)")
+	rootCmd.PersistentFlags().IntVar(&passwordSource.CredentialRef, "vmware-credential-ref", 0, "SHA VMware credential ID to resolve with a one-time token")
+	rootCmd.PersistentFlags().IntVar(&passwordSource.TokenFD, "vmware-credential-token-fd", -1, "Read the one-time credential token from this inherited file descriptor")
 
 	rootCmd.PersistentFlags().StringVar(&path, "vmware-path", "", "VMware VM path (e.g. '/Datacenter/vm/VM')")
 	rootCmd.MarkPersistentFlagRequired("vmware-path")
@@ -507,7 +835,12 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&nbdExportName, "nbd-export-name", "migration", "NBD export name for CloudStack target (single-disk mode)")
 	rootCmd.PersistentFlags().StringVar(&nbdTargets, "nbd-targets", "", "NBD targets for multi-disk VMs (format: vm_disk_id:nbd_url,vm_disk_id:nbd_url)")
 	rootCmd.PersistentFlags().BoolVar(&quiesceSnapshot, "quiesce-snapshot", true, "Enable quiesced snapshots for file-system consistency (requires VMware Tools)")
+	rootCmd.PersistentFlags().StringVar(&consistencyLevel, "consistency-level", "", "Snapshot consistency: application (VSS), filesystem or crash - falls back automatically (overrides --quiesce-snapshot)")
+	rootCmd.PersistentFlags().StringVar(&vssBackupType, "vss-backup-type", vmware.VSSBackupTypeCopy, "VSS backup type for application-consistent snapshots: full (truncates logs) or copy")
+	rootCmd.PersistentFlags().IntSliceVar(&excludeDiskKeys, "exclude-disk-keys", nil, "VMware disk keys to skip (e.g. '2001,2002') - excluded disks are not transferred")
 	rootCmd.PersistentFlags().StringVar(&jobID, "job-id", "", "Job ID for progress tracking (e.g. 'job-20250905-162427')")
+	rootCmd.PersistentFlags().StringVar(&guestHooksFile, "guest-hooks-file", "", "JSON file of in-guest pre/post snapshot hooks (removed after loading)")
+	rootCmd.PersistentFlags().IntVar(&eventFD, "event-fd", -1, "Inherited descriptor to write the structured job event stream to (JSON lines, set by the SNA)")
 
 	rootCmd.PersistentFlags().Var(enumflag.New(&compressionMethod, "compression-method", CompressionMethodOptsIds, enumflag.EnumCaseInsensitive), "compression-method", "Specifies the compression method to use for the disk")
 
@@ -536,8 +869,151 @@ func init() {
 	cutoverCmd.Flags().StringVar(&availabilityZone, "availability-zone", "", "OpenStack availability zone for blockdevice & server")
 	cutoverCmd.MarkFlagRequired("availability-zone")
 
+	restoreCmd.Flags().StringVar(&restoreSpecFile, "restore-spec-file", "", "JSON restore spec written by the SNA (removed after loading)")
+	restoreCmd.MarkFlagRequired("restore-spec-file")
+
+	cleanupCmd.Flags().StringVar(&cleanupSnapshotRef, "snapshot-ref", "", "Managed object ID of the snapshot to remove (e.g. 'snapshot-123')")
+	cleanupCmd.MarkFlagRequired("snapshot-ref")
+
 	rootCmd.AddCommand(migrateCmd)
 	rootCmd.AddCommand(cutoverCmd)
+	rootCmd.AddCommand(restoreCmd)
+	kvmBackupCmd.Flags().StringVar(&libvirtURI, "libvirt-uri", "qemu:///system", "libvirt connection URI (e.g. 'qemu+ssh://root@kvm1/system')")
+	kvmBackupCmd.Flags().StringVar(&kvmDomain, "domain", "", "libvirt domain name or UUID")
+	kvmBackupCmd.MarkFlagRequired("domain")
+	kvmBackupCmd.Flags().StringVar(&kvmNBDHost, "kvm-nbd-host", "", "Address libvirt serves the backup NBD exports on (reachable from this host)")
+	kvmBackupCmd.Flags().IntVar(&kvmNBDPort, "kvm-nbd-port", 10809, "Port libvirt serves the backup NBD exports on")
+	kvmBackupCmd.Flags().StringVar(&kvmNBDSocket, "kvm-nbd-socket", "", "Unix socket libvirt serves the backup NBD exports on (local libvirt only, instead of --kvm-nbd-host)")
+	kvmBackupCmd.Flags().StringVar(&kvmScratchDir, "kvm-scratch-dir", "", "Directory on the KVM host for backup scratch files (default: libvirt's)")
+
+	rootCmd.AddCommand(cleanupCmd)
+	rootCmd.AddCommand(kvmBackupCmd)
+	kvmInspectCmd.Flags().StringVar(&libvirtURI, "libvirt-uri", "qemu:///system", "libvirt connection URI (e.g. 'qemu+ssh://root@kvm1/system')")
+	kvmInspectCmd.Flags().StringVar(&kvmDomain, "domain", "", "libvirt domain name or UUID")
+	kvmInspectCmd.MarkFlagRequired("domain")
+	rootCmd.AddCommand(kvmInspectCmd)
+}
+
+// initProgressTracking sets up SNA progress, SHA telemetry, the event stream and job
+// control for the job given with --job-id
+func initProgressTracking(ctx context.Context) context.Context {
+	if jobID == "" {
+		return ctx
+	}
+
+	// 🎯 CRITICAL: Set environment variable for progress tracking
+	// This enables the progress system to use the command line job ID
+	// while keeping CBT functionality separate (uses MIGRATEKIT_JOB_ID)
+	// The SNA event stream replaces the SNA progress API when the SNA passed a descriptor
+	if eventFD < 0 {
+		os.Setenv("MIGRATEKIT_PROGRESS_JOB_ID", jobID)
+		log.WithField("job_id", jobID).Info("Set progress tracking job ID from command line flag")
+
+		// 🎯 CRITICAL: Initialize SNA progress client for real-time tracking
+		snaProgressClient := progress.NewVMAProgressClient()
+		if snaProgressClient.IsEnabled() {
+			log.WithFields(log.Fields{
+				"job_id":  snaProgressClient.GetJobID(),
+				"vma_url": "http://localhost:8081",
+			}).Info("🎯 SNA progress tracking enabled")
+			log.WithField("job_id", jobID).Info("🎯 Early progress tracking enabled - monitoring all migration phases")
+
+			// Add to context for use throughout migration
+			ctx = context.WithValue(ctx, "snaProgressClient", snaProgressClient)
+
+			// Send initial progress update
+			snaProgressClient.SendStageUpdate("Initializing", 5)
+		} else {
+			log.Warn("❌ SNA progress tracking failed to initialize - check MIGRATEKIT_PROGRESS_JOB_ID")
+		}
+	}
+
+	// 🆕 NEW: Initialize telemetry client for real-time SHA tracking (replaces polling)
+	shaURL := os.Getenv("SHA_API_URL")
+	if shaURL == "" {
+		shaURL = "http://localhost:8082" // Default tunnel endpoint
+	}
+	telemetryClient := telemetry.NewClient(shaURL)
+
+	// Determine job type from job ID prefix (backup-*, replication-*, etc.)
+	jobType := "backup"
+	if !strings.HasPrefix(jobID, "backup-") {
+		jobType = "replication" // For replication jobs in the future
+	}
+
+	// Create telemetry tracker
+	telemetryTracker := telemetry.NewProgressTracker(telemetryClient, jobID)
+
+	ctx = context.WithValue(ctx, "telemetryClient", telemetryClient)
+	ctx = context.WithValue(ctx, "telemetryTracker", telemetryTracker)
+	ctx = openEventStream(ctx, telemetryTracker)
+
+	log.WithFields(log.Fields{
+		"job_id":   jobID,
+		"job_type": jobType,
+		"sha_url":  shaURL,
+	}).Info("🚀 SHA telemetry tracking initialized (push-based real-time progress)")
+
+	// 🆕 NEW: Pause/resume/cancel requests from the SNA (control file + SIGUSR1)
+	jobControl := jobcontrol.New(jobcontrol.DefaultDir, jobID)
+	go jobControl.Watch(ctx)
+	activeJobControl = jobControl
+	return context.WithValue(ctx, "jobControl", jobControl)
+}
+
+// openEventStream starts the structured event stream to the SNA when it passed a descriptor
+func openEventStream(ctx context.Context, tracker *telemetry.ProgressTracker) context.Context {
+	if eventFD < 0 {
+		return ctx
+	}
+	emitter, err := events.Open(eventFD, filepath.Join(jobcontrol.DefaultDir, events.SocketName), jobID)
+	if err != nil {
+		log.WithError(err).Warn("Failed to open SNA event stream")
+		return ctx
+	}
+	activeEvents = emitter
+	tracker.SetEventEmitter(emitter)
+	return context.WithValue(ctx, "eventEmitter", emitter)
+}
+
+// resolvePassword loads the vCenter password from the configured flags, environment or SHA
+func resolvePassword() error {
+	if err := passwordSource.ApplyEnv(); err != nil {
+		return err
+	}
+	passwordSource.SHAURL = os.Getenv("SHA_API_URL")
+
+	resolved, err := passwordSource.Resolve(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to resolve vCenter password: %w", err)
+	}
+	password = resolved
+	return nil
+}
+
+// connectVMware creates a logged-in vCenter client with session keepalive
+func connectVMware(ctx context.Context, endpointUrl *url.URL) (*vim25.Client, error) {
+	soapClient := soap.NewClient(endpointUrl, true)
+	vimClient, err := vim25.NewClient(ctx, soapClient)
+	if err != nil {
+		log.WithError(err).Error("Failed to create VMware client")
+		return nil, events.WithCode(events.CodeVMwareConnect, err)
+	}
+
+	vimClient.RoundTripper = keepalive.NewHandlerSOAP(
+		vimClient.RoundTripper,
+		15*time.Second,
+		nil,
+	)
+
+	mgr := session.NewManager(vimClient)
+	err = mgr.Login(ctx, endpointUrl.User)
+	if err != nil {
+		log.WithError(err).Error("Failed to login to VMware")
+		return nil, events.WithCode(events.CodeVMwareConnect, err)
+	}
+
+	return vimClient, nil
 }
 
 // enableCBTDirectly enables CBT using the existing vCenter connection and VM object
@@ -606,7 +1082,12 @@ func enableCBTDirectly(ctx context.Context, vm *object.VirtualMachine) error {
 }
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+
+	// The final state lets an SNA that restarted mid-job report how it ended
+	activeEvents.Finish(err, activeJobControl.Cancelled())
+	activeJobControl.Finish(err)
+	if err != nil {
 		os.Exit(1)
 	}
 }
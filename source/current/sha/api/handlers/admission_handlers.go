@@ -0,0 +1,67 @@
+// Package handlers provides REST API endpoints for job admission control
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/services"
+)
+
+// AdmissionHandler handles the job admission queue and limits
+type AdmissionHandler struct {
+	admission *services.AdmissionController
+}
+
+// NewAdmissionHandler creates a new admission handler
+func NewAdmissionHandler(admission *services.AdmissionController) *AdmissionHandler {
+	return &AdmissionHandler{
+		admission: admission,
+	}
+}
+
+// GetQueue handles GET /api/v1/admission/queue
+// and shows the limits, current resource usage and the jobs waiting for capacity
+func (h *AdmissionHandler) GetQueue(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, h.admission.GetStatus(r.Context()))
+}
+
+// GetLimits handles GET /api/v1/admission/limits
+func (h *AdmissionHandler) GetLimits(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, h.admission.GetLimits())
+}
+
+// UpdateLimits handles PUT /api/v1/admission/limits
+// Limits are kept in memory; SHA_ADMISSION_* environment variables set them at startup
+func (h *AdmissionHandler) UpdateLimits(w http.ResponseWriter, r *http.Request) {
+	// Start from the current limits so partial updates keep the other values
+	limits := h.admission.GetLimits()
+	if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+		h.sendError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	if err := h.admission.SetLimits(limits); err != nil {
+		log.WithError(err).Error("Failed to update admission limits")
+		h.sendError(w, http.StatusBadRequest, "Failed to update admission limits", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, limits)
+}
+
+// sendError sends a standardized error response
+func (h *AdmissionHandler) sendError(w http.ResponseWriter, statusCode int, message, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: message, Details: details})
+}
+
+// writeJSON sends a JSON response
+func (h *AdmissionHandler) writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
@@ -4,9 +4,12 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -15,6 +18,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/vexxhost/migratekit-sha/database"
+	"github.com/vexxhost/migratekit-sha/models"
 	"github.com/vexxhost/migratekit-sha/services"
 	"github.com/vexxhost/migratekit-sha/storage"
 	"github.com/vexxhost/migratekit-sha/workflows"
@@ -30,6 +34,11 @@ type BackupHandler struct {
 	portAllocator     *services.NBDPortAllocator       // 🆕 NEW: Dynamic NBD port allocation
 	qemuManager       *services.QemuNBDManager         // 🆕 NEW: qemu-nbd process management
 	credentialService *services.VMwareCredentialService // 🆕 NEW: For getting decrypted vCenter credentials
+	jobHookService    *services.JobHookService          // 🆕 NEW: For resolving in-guest snapshot hooks
+	capacityService   *services.RepositoryCapacityService // 🆕 NEW: Pre-flight repository space reservation
+	snaRouter         *services.SNARouter                 // 🆕 NEW: Routes each backup to the SNA serving the VM
+	diskChanges       *services.DiskChangeService         // 🆕 NEW: Detects disks added, removed or resized since the last backup
+	kvmSources        *services.KVMSourceService          // 🆕 NEW: Reads the disks of KVM/libvirt VMs through the SNA
 	db                database.Connection
 }
 
@@ -40,6 +49,7 @@ func NewBackupHandler(
 	portAllocator *services.NBDPortAllocator,
 	qemuManager *services.QemuNBDManager,
 	credentialService *services.VMwareCredentialService,
+	jobHookService *services.JobHookService,
 ) *BackupHandler {
 	return &BackupHandler{
 		backupEngine:      backupEngine,
@@ -49,10 +59,32 @@ func NewBackupHandler(
 		portAllocator:     portAllocator,                                   // 🆕 NEW: NBD port allocation
 		qemuManager:       qemuManager,                                     // 🆕 NEW: qemu-nbd management
 		credentialService: credentialService,                               // 🆕 NEW: VMware credential service
+		jobHookService:    jobHookService,                                  // 🆕 NEW: Guest snapshot hooks
 		db:                db,
 	}
 }
 
+// SetCapacityService makes backups reserve repository space before they start
+func (bh *BackupHandler) SetCapacityService(capacityService *services.RepositoryCapacityService) {
+	bh.capacityService = capacityService
+}
+
+// SetSNARouter routes backups to the SNA serving each VM's placement
+func (bh *BackupHandler) SetSNARouter(router *services.SNARouter) {
+	bh.snaRouter = router
+}
+
+// SetDiskChangeService refreshes vm_disks from vCenter before each backup so added,
+// removed and resized disks are handled instead of breaking the incremental
+func (bh *BackupHandler) SetDiskChangeService(diskChanges *services.DiskChangeService) {
+	bh.diskChanges = diskChanges
+}
+
+// SetKVMSourceService refreshes the vm_disks of KVM VMs from libvirt before each backup
+func (bh *BackupHandler) SetKVMSourceService(kvmSources *services.KVMSourceService) {
+	bh.kvmSources = kvmSources
+}
+
 // ========================================================================
 // REQUEST/RESPONSE MODELS
 // ========================================================================
@@ -64,6 +96,7 @@ type BackupStartRequest struct {
 	BackupType   string            `json:"backup_type"`              // Required: "full" or "incremental"
 	RepositoryID string            `json:"repository_id"`            // Required: Target repository ID
 	PolicyID     string            `json:"policy_id,omitempty"`      // Optional: Backup policy ID
+	FlowID       string            `json:"flow_id,omitempty"`        // Optional: Originating protection flow (for snapshot hooks)
 	Tags         map[string]string `json:"tags,omitempty"`           // Optional: Custom tags
 	// NO disk_id field - backups are VM-level to prevent data corruption from multiple snapshots
 }
@@ -71,6 +104,7 @@ type BackupStartRequest struct {
 // DiskBackupResult represents backup result for a single disk in a multi-disk VM
 type DiskBackupResult struct {
 	DiskID       int    `json:"disk_id"`              // Disk number (0, 1, 2...)
+	VMwareDiskKey int   `json:"vmware_disk_key"`      // VMware disk key (2000, 2001...)
 	NBDPort      int    `json:"nbd_port"`             // Allocated NBD port
 	ExportName   string `json:"nbd_export_name"`      // NBD export name
 	QCOW2Path    string `json:"qcow2_path"`           // QCOW2 file path
@@ -102,6 +136,10 @@ type BackupResponse struct {
 	TransferSpeedBps int64               `json:"transfer_speed_bps"`
 	ProgressPercent  float64             `json:"progress_percent"`
 	LastTelemetryAt  string              `json:"last_telemetry_at,omitempty"`
+	// 🆕 NEW: Snapshot consistency requested vs actually achieved
+	ConsistencyRequested      string `json:"consistency_requested,omitempty"`
+	ConsistencyLevel          string `json:"consistency_level,omitempty"`
+	ConsistencyFallbackReason string `json:"consistency_fallback_reason,omitempty"`
 	CreatedAt        string              `json:"created_at"`
 	StartedAt        string              `json:"started_at,omitempty"`
 	CompletedAt      string              `json:"completed_at,omitempty"`
@@ -176,16 +214,76 @@ func (bh *BackupHandler) StartBackup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// ========================================================================
+	// STEP 1.5: Get VMware credentials using credential service
+	// (KVM VMs are reached with the SNA's own libvirt access instead)
+	// ========================================================================
+	var creds *database.VMwareCredentials
+	var kvmSource *database.KVMSource
+	if vmContext.IsKVM() {
+		kvmSource, err = database.ParseKVMSource(vmContext.KVMSource)
+		if err != nil {
+			log.WithError(err).Error("VM context has no valid KVM source")
+			bh.sendError(w, http.StatusBadRequest, "VM context has an invalid kvm_source", err.Error())
+			return
+		}
+	} else {
+		if vmContext.CredentialID == nil {
+			log.Error("VM context has no credential_id set")
+			bh.sendError(w, http.StatusBadRequest, "VM context missing credential_id", "")
+			return
+		}
+
+		creds, err = bh.credentialService.GetCredentials(r.Context(), *vmContext.CredentialID)
+		if err != nil {
+			log.WithError(err).Error("Failed to get VMware credentials")
+			bh.sendError(w, http.StatusInternalServerError, "failed to get VMware credentials", err.Error())
+			return
+		}
+	}
+
+	// ========================================================================
+	// STEP 1.6: Refresh vm_disks with disks added, removed or resized since the last job
+	// (best effort - the backup continues with the stored disks if the source can't be checked)
+	// ========================================================================
+	removedDiskIDs := map[string]bool{}
+	var changes *services.DiskChangeSet
+	var refreshErr error
+	switch {
+	case vmContext.IsKVM() && bh.kvmSources != nil:
+		changes, refreshErr = bh.kvmSources.RefreshVMDisks(ctx, vmContext)
+	case !vmContext.IsKVM() && bh.diskChanges != nil:
+		changes, refreshErr = bh.diskChanges.RefreshVMDisks(ctx, vmContext, creds)
+	}
+	if refreshErr != nil {
+		log.WithError(refreshErr).WithField("vm_name", req.VMName).Warn("⚠️ Could not check VM for disk changes - using stored disks")
+	} else if changes != nil {
+		removedDiskIDs = changes.RemovedDiskIDs()
+	}
+
 	// ========================================================================
 	// STEP 2: Get ALL disks for VM
 	// ========================================================================
-	vmDisks, err := bh.vmDiskRepo.GetByVMContextID(vmContext.ContextID)
+	storedDisks, err := bh.vmDiskRepo.GetByVMContextID(vmContext.ContextID)
 	if err != nil {
 		log.WithError(err).Error("Failed to get VM disks")
 		bh.sendError(w, http.StatusInternalServerError, "failed to get VM disks", err.Error())
 		return
 	}
 
+	// Disks removed from the VM keep their vm_disks records for replication failover
+	vmDisks := make([]database.VMDisk, 0, len(storedDisks))
+	for _, vmDisk := range storedDisks {
+		if removedDiskIDs[vmDisk.DiskID] {
+			log.WithFields(log.Fields{
+				"disk_id": vmDisk.DiskID,
+				"label":   vmDisk.Label,
+			}).Info("➖ Disk was removed from the source VM - skipping it")
+			continue
+		}
+		vmDisks = append(vmDisks, vmDisk)
+	}
+
 	if len(vmDisks) == 0 {
 		log.Error("No disks found for VM")
 		bh.sendError(w, http.StatusNotFound, "No disks found for VM",
@@ -198,6 +296,81 @@ func (bh *BackupHandler) StartBackup(w http.ResponseWriter, r *http.Request) {
 		"disk_count": len(vmDisks),
 	}).Info("📀 Found disks for multi-disk backup")
 
+	// STEP 2.1: Resolve per-disk exclusions (VM context + originating flow)
+	exclusions, err := bh.resolveDiskExclusions(vmContext, req.FlowID)
+	if err != nil {
+		log.WithError(err).Error("Failed to resolve disk exclusions")
+		bh.sendError(w, http.StatusInternalServerError, "failed to resolve disk exclusions", err.Error())
+		return
+	}
+
+	includedCount := 0
+	for i := range vmDisks {
+		if _, excluded := database.IsDiskExcluded(&vmDisks[i], exclusions); !excluded {
+			includedCount++
+		}
+	}
+	if includedCount == 0 {
+		bh.sendError(w, http.StatusBadRequest, "all disks are excluded from backup",
+			"adjust excluded_disks on the VM context or protection flow")
+		return
+	}
+
+	// ========================================================================
+	// STEP 2.15: Resume the VM's interrupted backup instead of starting over
+	// (KVM backup clients keep no checkpoint to resume from)
+	// ========================================================================
+	if !vmContext.IsKVM() && bh.resumeInterruptedBackup(ctx, w, &req, vmContext, creds, vmDisks, exclusions) {
+		return
+	}
+
+	// ========================================================================
+	// STEP 2.2: Reserve repository space (may redirect to the alternate repository)
+	// ========================================================================
+	backupJobID := fmt.Sprintf("backup-%s-%d", req.VMName, time.Now().Unix())
+	backupStarted := false
+
+	if bh.capacityService != nil {
+		var diskBytes int64
+		for i := range vmDisks {
+			if _, excluded := database.IsDiskExcluded(&vmDisks[i], exclusions); !excluded {
+				diskBytes += backupDiskBytes(&vmDisks[i])
+			}
+		}
+
+		reservation, err := bh.capacityService.ReserveForBackup(ctx, services.BackupSpaceRequest{
+			BackupJobID:  backupJobID,
+			VMContextID:  vmContext.ContextID,
+			VMName:       req.VMName,
+			RepositoryID: req.RepositoryID,
+			BackupType:   req.BackupType,
+			DiskBytes:    diskBytes,
+		})
+		if err != nil {
+			var spaceErr *storage.InsufficientSpaceError
+			if errors.As(err, &spaceErr) {
+				log.WithError(err).WithField("repository_id", req.RepositoryID).Warn("❌ Repository has insufficient space for backup")
+				bh.sendError(w, http.StatusInsufficientStorage, "insufficient repository space", err.Error())
+				return
+			}
+			log.WithError(err).Error("Failed to reserve repository space")
+			bh.sendError(w, http.StatusInternalServerError, "failed to reserve repository space", err.Error())
+			return
+		}
+
+		// A redirected backup continues in the alternate repository (as a full if it has no chain there)
+		req.RepositoryID = reservation.RepositoryID
+		req.BackupType = reservation.BackupType
+
+		defer func() {
+			if !backupStarted {
+				if err := bh.capacityService.Release(context.Background(), backupJobID); err != nil {
+					log.WithError(err).WithField("backup_job_id", backupJobID).Warn("Failed to release repository space reservation")
+				}
+			}
+		}()
+	}
+
 	// ========================================================================
 	// STEP 2.5: Find or create vm_backup_contexts record (NEW ARCHITECTURE!)
 	// ========================================================================
@@ -229,11 +402,72 @@ func (bh *BackupHandler) StartBackup(w http.ResponseWriter, r *http.Request) {
 		log.WithField("context_id", vmBackupContext.ContextID).Info("📋 Using existing vm_backup_context")
 	}
 
+	// ========================================================================
+	// STEP 2.6: Map each disk to its backup chain (disk index) by VMware disk key, so
+	// chains survive disks being added or removed, and find incremental parents
+	// ========================================================================
+	diskKeys := make([]int, len(vmDisks))
+	for i := range vmDisks {
+		diskKeys[i] = backupDiskKey(&vmDisks[i], i)
+	}
+	diskIndices, retiredIndices, err := bh.resolveDiskIndices(req.VMName, diskKeys)
+	if err != nil {
+		log.WithError(err).Error("Failed to resolve backup disk indices")
+		bh.sendError(w, http.StatusInternalServerError, "failed to resolve backup disk indices", err.Error())
+		return
+	}
+
+	previousChangeIDs := map[int]string{}
+	if req.BackupType == "incremental" {
+		for i := range vmDisks {
+			if _, excluded := database.IsDiskExcluded(&vmDisks[i], exclusions); excluded {
+				continue
+			}
+			diskIndex := diskIndices[diskKeys[i]]
+
+			// NEW ARCHITECTURE: Query backup_disks table with JOIN to vm_backup_contexts
+			// This uses the vm_backup_contexts + backup_disks architecture (v2.16.0+)
+			var prevDisk database.BackupDisk
+			err := bh.db.GetGormDB().
+				Table("backup_disks bd").
+				Select("bd.*").
+				Joins("JOIN vm_backup_contexts vbc ON bd.vm_backup_context_id = vbc.context_id").
+				Where("vbc.vm_name = ? AND bd.disk_index = ? AND bd.status = ? AND bd.disk_change_id IS NOT NULL", req.VMName, diskIndex, "completed").
+				Order("bd.completed_at DESC").
+				First(&prevDisk).Error
+			if err != nil {
+				// A disk added since the last backup starts a new chain with a full backup
+				log.WithFields(log.Fields{
+					"vm_name":    req.VMName,
+					"disk_index": diskIndex,
+					"disk_key":   diskKeys[i],
+				}).Info("➕ No previous backup for disk - it starts a new chain with a full backup")
+				continue
+			}
+
+			previousChangeIDs[diskIndex] = ""
+			if prevDisk.DiskChangeID != nil {
+				previousChangeIDs[diskIndex] = *prevDisk.DiskChangeID
+			}
+			log.WithFields(log.Fields{
+				"disk_index":         diskIndex,
+				"previous_backup_id": prevDisk.BackupJobID,
+				"previous_change_id": previousChangeIDs[diskIndex],
+			}).Info("📎 Found previous backup for incremental")
+		}
+
+		if len(previousChangeIDs) == 0 {
+			log.WithField("vm_name", req.VMName).Error("❌ No previous backup found for incremental - full backup required first")
+			bh.sendError(w, http.StatusBadRequest, "no previous backup found", "full backup required before incremental")
+			return
+		}
+	}
+
 	// ========================================================================
 	// STEP 3: Prepare backup for each disk using BackupEngine
 	// ========================================================================
-	backupJobID := fmt.Sprintf("backup-%s-%d", req.VMName, time.Now().Unix())
-	diskResults := make([]DiskBackupResult, len(vmDisks))
+	diskResults := make([]DiskBackupResult, 0, includedCount)
+	excludedDiskKeys := []int{}
 	var preparationErr error
 
 	// STEP 3.1: Create parent backup_jobs record FIRST (for backup_disks FK constraint)
@@ -242,12 +476,14 @@ func (bh *BackupHandler) StartBackup(w http.ResponseWriter, r *http.Request) {
 	parentJobInsert := `
 		INSERT INTO backup_jobs (
 			id, vm_backup_context_id, vm_context_id, vm_name, repository_id,
-			backup_type, status, repository_path, created_at, started_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			backup_type, status, repository_path, created_at, started_at,
+			consistency_requested
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	err = bh.db.GetGormDB().Exec(parentJobInsert,
 		backupJobID, vmBackupContext.ContextID, vmContext.ContextID, req.VMName, req.RepositoryID,
 		req.BackupType, "running", "/multi-disk-parent", now, now,  // ✅ FIX: Set started_at = created_at
+		vmContext.ConsistencyLevel, // Achieved level is reported back by the backup client via telemetry
 	).Error
 	
 	if err != nil {
@@ -279,41 +515,35 @@ func (bh *BackupHandler) StartBackup(w http.ResponseWriter, r *http.Request) {
 
 	// Prepare each disk backup using BackupEngine
 	for i, vmDisk := range vmDisks {
-		// Use loop index as disk ID since unit_number can be duplicated (VMware bug)
-		diskIndex := i
-		
-		// For incremental backups, look up previous change_id
-		var previousChangeID string
-		if req.BackupType == "incremental" {
-			// NEW ARCHITECTURE: Query backup_disks table with JOIN to vm_backup_contexts
-			// This uses the vm_backup_contexts + backup_disks architecture (v2.16.0+)
-			var prevDisk database.BackupDisk
-			err := bh.db.GetGormDB().
-				Table("backup_disks bd").
-				Select("bd.*").
-				Joins("JOIN vm_backup_contexts vbc ON bd.vm_backup_context_id = vbc.context_id").
-				Where("vbc.vm_name = ? AND bd.disk_index = ? AND bd.status = ? AND bd.disk_change_id IS NOT NULL", req.VMName, diskIndex, "completed").
-				Order("bd.completed_at DESC").
-				First(&prevDisk).Error
-			
-			if err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"vm_name":    req.VMName,
-					"disk_index": diskIndex,
-				}).Error("❌ No previous backup found for incremental - full backup required first")
-				preparationErr = fmt.Errorf("no previous backup found for incremental - full backup required first")
-				bh.sendError(w, http.StatusBadRequest, "no previous backup found", "full backup required before incremental")
+		// Disk index is resolved per VMware disk key since unit_number can be duplicated (VMware bug)
+		// Excluded disks keep their index so backup chains stay aligned when selection changes
+		diskKey := diskKeys[i]
+		diskIndex := diskIndices[diskKey]
+
+		if rule, excluded := database.IsDiskExcluded(&vmDisk, exclusions); excluded {
+			if err := bh.recordExcludedDisk(vmBackupContext.ContextID, backupJobID, diskIndex, diskKey, &vmDisk); err != nil {
+				log.WithError(err).WithField("disk_index", diskIndex).Error("Failed to record excluded disk")
+				preparationErr = err
+				bh.sendError(w, http.StatusInternalServerError, "failed to record excluded disk", err.Error())
 				return
 			}
-			
-			if prevDisk.DiskChangeID != nil {
-				previousChangeID = *prevDisk.DiskChangeID
-			}
+			excludedDiskKeys = append(excludedDiskKeys, diskKey)
+
 			log.WithFields(log.Fields{
-				"disk_index":         diskIndex,
-				"previous_backup_id": prevDisk.BackupJobID,
-				"previous_change_id": previousChangeID,
-			}).Info("📎 Found previous backup for incremental")
+				"disk_index": diskIndex,
+				"disk_key":   diskKey,
+				"label":      vmDisk.Label,
+				"size_gb":    vmDisk.SizeGB,
+				"reason":     rule.Reason,
+			}).Info("⏭️ Disk excluded from backup by policy")
+			continue
+		}
+		
+		// Incrementals continue each disk's chain; disks without one start a new chain
+		diskBackupType := storage.BackupType(req.BackupType)
+		previousChangeID, hasChain := previousChangeIDs[diskIndex]
+		if diskBackupType == storage.BackupTypeIncremental && !hasChain {
+			diskBackupType = storage.BackupTypeFull
 		}
 
 		// Build BackupRequest for this disk
@@ -322,13 +552,23 @@ func (bh *BackupHandler) StartBackup(w http.ResponseWriter, r *http.Request) {
 			VMBackupContextID: vmBackupContext.ContextID,          // NEW: Backup context for proper parent-child relationships
 			ParentJobID:       backupJobID,                        // NEW: Parent job ID that backup client knows about
 			VMName:            req.VMName,
-			DiskID:            diskIndex, // Use loop index (0, 1, 2...) to avoid unit_number duplicates
-			BackupType:        storage.BackupType(req.BackupType),
+			DiskID:            diskIndex, // Stable per VMware disk key (0, 1, 2...) to avoid unit_number duplicates
+			VMwareDiskKey:     diskKey,
+			BackupType:        diskBackupType,
 			RepositoryID:      req.RepositoryID,
-			TotalBytes:        int64(vmDisk.SizeGB) * 1024 * 1024 * 1024,
+			TotalBytes:        backupDiskBytes(&vmDisk), // Current capacity - grows the QCOW2 of a resized disk
 			PreviousChangeID:  previousChangeID, // For incremental backups
 			Tags:              req.Tags,
 		}
+		if kvmSource != nil {
+			backupReq.Metadata.KVMInfo = &storage.KVMMetadata{
+				LibvirtURI: kvmSource.LibvirtURI,
+				DomainUUID: vmContext.VMwareVMID,
+				DomainName: vmContext.VMName,
+				DiskTarget: vmDisk.Label,
+				DiskSource: vmDisk.VMDKPath,
+			}
+		}
 
 		// Call BackupEngine to prepare disk (parent lookup + QCOW2 create + qemu-nbd)
 		result, err := bh.backupEngine.PrepareBackupDisk(ctx, backupReq)
@@ -343,15 +583,16 @@ func (bh *BackupHandler) StartBackup(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Store result
-		diskResults[i] = DiskBackupResult{
-			DiskID:        diskIndex, // Use loop index consistently
+		diskResults = append(diskResults, DiskBackupResult{
+			DiskID:        diskIndex, // Stable disk index consistently
+			VMwareDiskKey: diskKey,
 			NBDPort:       result.NBDPort,
 			ExportName:    result.NBDExportName,
 			QCOW2Path:     result.FilePath,
 			QemuNBDPID:    result.QemuNBDPID,
 			Status:        "prepared",
 			ErrorMessage:  "",
-		}
+		})
 
 		log.WithFields(log.Fields{
 			"disk_id":      vmDisk.UnitNumber,
@@ -368,8 +609,8 @@ func (bh *BackupHandler) StartBackup(w http.ResponseWriter, r *http.Request) {
 	// Format: "vmware_disk_key:nbd://host:port/export,vmware_disk_key:nbd://..."
 	nbdTargets := []string{}
 	for i, result := range diskResults {
-		// Calculate VMware disk key (use loop index since unit_number broken - both disks = 0)
-		diskKey := i + 2000
+		// VMware disk key resolved per disk during preparation (excluded disks have no target)
+		diskKey := result.VMwareDiskKey
 		
 		// 🔍 DEBUG: Log disk key calculation
 		log.WithFields(log.Fields{
@@ -394,55 +635,16 @@ func (bh *BackupHandler) StartBackup(w http.ResponseWriter, r *http.Request) {
 	log.WithField("final_nbd_targets", nbdTargetsString).Info("🔍 DEBUG: Final NBD targets string to be sent to SNA")
 
 	// ========================================================================
-	// STEP 6.5: Get VMware credentials using credential service
-	// ========================================================================
-	if vmContext.CredentialID == nil {
-		log.Error("VM context has no credential_id set")
-		bh.sendError(w, http.StatusBadRequest, "VM context missing credential_id", "")
-		return
-	}
-	
-	creds, err := bh.credentialService.GetCredentials(r.Context(), *vmContext.CredentialID)
-	if err != nil {
-		log.WithError(err).Error("Failed to get VMware credentials")
-		bh.sendError(w, http.StatusInternalServerError, "failed to get VMware credentials", err.Error())
-		return
-	}
-	
-	// ========================================================================
-	// STEP 7: Call SNA VMA API (via reverse tunnel on port 9081)
+	// STEP 6.6-7: Call SNA VMA API (via the reverse tunnel of the SNA serving the VM)
 	// ========================================================================
-	snaReq := map[string]interface{}{
-		"vm_name":           req.VMName,
-		"vcenter_host":      creds.VCenterHost,
-		"vcenter_user":      creds.Username,
-		"vcenter_password":  creds.Password, // Already decrypted by credential service
-		"vm_path":           vmContext.VMPath,
-		"nbd_host":          "127.0.0.1",      // Via SSH tunnel
-		"nbd_targets":       nbdTargetsString, // ← Multi-disk NBD targets!
-		"job_id":            backupJobID,
-		"backup_type":       req.BackupType,
-		"previous_change_id": "PLACEHOLDER",  // ✅ NEW: Backup client queries SHA database per-disk for actual change_ids
-	}
-
-	jsonData, _ := json.Marshal(snaReq)
-	snaURL := "http://localhost:9081/api/v1/backup/start"
-
-	resp, err := http.Post(snaURL, "application/json", bytes.NewBuffer(jsonData))
+	snaURL, err := bh.startSNABackup(ctx, w, req.FlowID, vmContext, creds, snaBackupStart{
+		JobID:            backupJobID,
+		BackupType:       req.BackupType,
+		NBDTargets:       nbdTargetsString,
+		ExcludedDiskKeys: excludedDiskKeys,
+	})
 	if err != nil {
-		log.WithError(err).Error("❌ Failed to call SNA VMA API")
 		preparationErr = err
-		bh.sendError(w, http.StatusInternalServerError, "failed to call SNA API", err.Error())
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
-		log.WithFields(log.Fields{
-			"status": resp.StatusCode,
-		}).Error("❌ SNA VMA API returned error")
-		preparationErr = fmt.Errorf("SNA API error: %d", resp.StatusCode)
-		bh.sendError(w, http.StatusInternalServerError, "SNA API error", fmt.Sprintf("status: %d", resp.StatusCode))
 		return
 	}
 
@@ -452,6 +654,19 @@ func (bh *BackupHandler) StartBackup(w http.ResponseWriter, r *http.Request) {
 		"sna_url":       snaURL,
 		"backup_job_id": backupJobID,
 	}).Info("✅ SNA VMA API called successfully for multi-disk backup")
+	backupStarted = true
+
+	// STEP 7.4: Close the chains of disks removed from the VM - they stay restorable until retention expires them
+	for _, diskIndex := range retiredIndices {
+		if err := bh.backupEngine.CloseDiskChain(ctx, vmContext.ContextID, diskIndex); err != nil {
+			log.WithError(err).WithField("disk_index", diskIndex).Warn("⚠️ Failed to close backup chain of removed disk")
+		}
+	}
+
+	// STEP 7.5: Capture VM configuration (VMX) alongside disk data - best effort, never fails the backup
+	if !vmContext.IsKVM() {
+		go bh.captureVMConfig(backupJobID, vmContext, creds, diskResults[0].QCOW2Path)
+	}
 
 	// ========================================================================
 	// NOTE: Parent backup_jobs record already created via RAW SQL at line ~243
@@ -484,6 +699,225 @@ func (bh *BackupHandler) StartBackup(w http.ResponseWriter, r *http.Request) {
 	}).Info("🎉 Multi-disk VM backup started successfully")
 }
 
+// snaBackupStart is what the SNA needs to launch the backup client for a job
+type snaBackupStart struct {
+	JobID            string
+	BackupType       string
+	NBDTargets       string
+	ExcludedDiskKeys []int
+	Resume           bool // Same job ID as an interrupted run - the client continues from its checkpoint
+}
+
+// startSNABackup resolves the job's guest hooks, issues its one-time credential token
+// and asks the SNA serving the VM to run the backup client. On failure it has already
+// answered the request.
+func (bh *BackupHandler) startSNABackup(ctx context.Context, w http.ResponseWriter, flowID string, vmContext *database.VMReplicationContext, creds *database.VMwareCredentials, start snaBackupStart) (string, error) {
+	// ========================================================================
+	// STEP 6.6: Resolve in-guest pre/post snapshot hooks for flow-driven backups
+	// (a resumed job reuses the snapshot its hooks already ran around)
+	// ========================================================================
+	var guestHooks []services.GuestHook
+	if flowID != "" && bh.jobHookService != nil && !start.Resume {
+		owners, err := bh.jobHookService.OwnersForFlow(ctx, flowID)
+		if err == nil {
+			guestHooks, err = bh.jobHookService.ResolveGuestHooks(ctx, owners)
+		}
+		if err != nil {
+			log.WithError(err).WithField("flow_id", flowID).Error("Failed to resolve guest snapshot hooks")
+			bh.sendError(w, http.StatusInternalServerError, "failed to resolve snapshot hooks", err.Error())
+			return "", err
+		}
+		if len(guestHooks) > 0 {
+			log.WithFields(log.Fields{
+				"flow_id":    flowID,
+				"hook_count": len(guestHooks),
+			}).Info("🪝 Passing guest snapshot hooks to SNA")
+		}
+	}
+	if len(guestHooks) > 0 && vmContext.IsKVM() {
+		// Guest hooks run through VMware Tools
+		log.WithField("flow_id", flowID).Warn("⚠️ Guest snapshot hooks are not supported for KVM VMs - skipping them")
+		guestHooks = nil
+	}
+
+	// ========================================================================
+	// STEP 7: Call SNA VMA API (via the reverse tunnel of the SNA serving the VM)
+	// ========================================================================
+	snaReq := map[string]interface{}{
+		"vm_name":            vmContext.VMName,
+		"vm_path":            vmContext.VMPath,
+		"nbd_host":           "127.0.0.1",      // Via SSH tunnel
+		"nbd_targets":        start.NBDTargets, // ← Multi-disk NBD targets!
+		"job_id":             start.JobID,
+		"backup_type":        start.BackupType,
+		"previous_change_id": "PLACEHOLDER",              // ✅ NEW: Backup client queries SHA database per-disk for actual change_ids
+		"guest_hooks":        guestHooks,                 // 🆕 NEW: pre/post snapshot hooks run in guest via VMware Tools
+		"consistency_level":  vmContext.ConsistencyLevel, // 🆕 NEW: per-VM crash/filesystem/application policy
+		"vss_backup_type":    vmContext.VSSBackupType,
+		"excluded_disk_keys": start.ExcludedDiskKeys, // 🆕 NEW: disks skipped by policy (no NBD target)
+		"resume":             start.Resume,
+	}
+
+	if vmContext.IsKVM() {
+		// The SNA runs kvm-backup against the domain with its own libvirt access
+		kvmSource, err := database.ParseKVMSource(vmContext.KVMSource)
+		if err != nil {
+			log.WithError(err).Error("VM context has no valid KVM source")
+			bh.sendError(w, http.StatusBadRequest, "VM context has an invalid kvm_source", err.Error())
+			return "", err
+		}
+		snaReq["source_type"] = database.SourceTypeKVM
+		snaReq["kvm"] = kvmSource
+		delete(snaReq, "vss_backup_type")
+	} else {
+		// ========================================================================
+		// STEP 6.7: Issue a one-time credential token - the backup client redeems it over
+		// the tunnel, so the vCenter password never travels to the SNA or its command line
+		// ========================================================================
+		credentialToken, err := bh.credentialService.IssueCredentialToken(ctx, creds.ID, start.JobID)
+		if err != nil {
+			log.WithError(err).Error("Failed to issue VMware credential token")
+			bh.sendError(w, http.StatusInternalServerError, "failed to issue VMware credential token", err.Error())
+			return "", err
+		}
+		snaReq["vcenter_host"] = creds.VCenterHost
+		snaReq["vcenter_user"] = creds.Username
+		snaReq["vmware_credential"] = map[string]interface{}{ // 🔐 Resolved by the backup client with the one-time token
+			"credential_id": creds.ID,
+			"token":         credentialToken,
+		}
+	}
+
+	jsonData, _ := json.Marshal(snaReq)
+
+	// The checkpoint of an interrupted run lives on the SNA that ran it
+	snaEndpoint := ""
+	if start.Resume {
+		snaEndpoint = bh.snaRouter.EndpointForJob(ctx, start.JobID, "")
+	}
+	if snaEndpoint == "" {
+		var err error
+		snaEndpoint, err = bh.snaRouter.RouteJob(ctx, start.JobID, "backup", vmContext.ContextID, services.DefaultSNAEndpoint)
+		if err != nil {
+			log.WithError(err).Error("❌ No SNA available for backup")
+			bh.sendError(w, http.StatusServiceUnavailable, "no SNA available for VM", err.Error())
+			return "", err
+		}
+	}
+	snaURL := snaEndpoint + "/api/v1/backup/start"
+
+	resp, err := http.Post(snaURL, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		log.WithError(err).Error("❌ Failed to call SNA VMA API")
+		bh.sendError(w, http.StatusInternalServerError, "failed to call SNA API", err.Error())
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		log.WithFields(log.Fields{
+			"status": resp.StatusCode,
+		}).Error("❌ SNA VMA API returned error")
+		bh.sendError(w, http.StatusInternalServerError, "SNA API error", fmt.Sprintf("status: %d", resp.StatusCode))
+		return "", fmt.Errorf("SNA API error: %d", resp.StatusCode)
+	}
+
+	return snaURL, nil
+}
+
+// resumeInterruptedBackup continues the VM's latest backup under its own job ID when it
+// failed recently over the same disks - the backup client picks up the snapshot and
+// checkpoint the interrupted run kept instead of starting over. It reports whether it
+// answered the request; false means a new backup should be started.
+func (bh *BackupHandler) resumeInterruptedBackup(ctx context.Context, w http.ResponseWriter, req *BackupStartRequest, vmContext *database.VMReplicationContext, creds *database.VMwareCredentials, vmDisks []database.VMDisk, exclusions []database.DiskExclusion) bool {
+	includedKeys := []int{}
+	for i := range vmDisks {
+		if _, excluded := database.IsDiskExcluded(&vmDisks[i], exclusions); !excluded {
+			includedKeys = append(includedKeys, backupDiskKey(&vmDisks[i], i))
+		}
+	}
+
+	interrupted, err := bh.backupEngine.FindResumableBackup(ctx, req.VMName, req.RepositoryID, includedKeys)
+	if err != nil {
+		log.WithError(err).WithField("vm_name", req.VMName).Warn("Failed to look for an interrupted backup - starting a new one")
+		return false
+	}
+	// A full backup is not satisfied by resuming an incremental
+	if interrupted == nil || (req.BackupType == "full" && interrupted.BackupType != "full") {
+		return false
+	}
+
+	resumed, err := bh.backupEngine.ResumeBackup(ctx, interrupted.ID)
+	if err != nil {
+		log.WithError(err).WithField("backup_id", interrupted.ID).Warn("⚠️ Cannot resume interrupted backup - starting a new one")
+		return false
+	}
+	if bh.capacityService != nil {
+		if err := bh.capacityService.Reopen(ctx, resumed.BackupID); err != nil {
+			log.WithError(err).WithField("backup_job_id", resumed.BackupID).Warn("Failed to reopen repository space reservation")
+		}
+	}
+
+	nbdTargetsString := resumed.NBDTargets()
+	snaURL, err := bh.startSNABackup(ctx, w, req.FlowID, vmContext, creds, snaBackupStart{
+		JobID:            resumed.BackupID,
+		BackupType:       resumed.BackupType,
+		NBDTargets:       nbdTargetsString,
+		ExcludedDiskKeys: resumed.ExcludedDiskKeys,
+		Resume:           true,
+	})
+	if err != nil {
+		// Failed again, so the next attempt can still resume it
+		if failErr := bh.backupEngine.FailBackup(context.Background(), resumed.BackupID, err.Error()); failErr != nil {
+			log.WithError(failErr).WithField("backup_id", resumed.BackupID).Error("Failed to mark backup failed after resume attempt")
+		}
+		for _, disk := range resumed.Disks {
+			bh.qemuManager.Stop(disk.NBDPort)
+			bh.portAllocator.Release(disk.NBDPort)
+		}
+		return true
+	}
+
+	diskResults := make([]DiskBackupResult, len(resumed.Disks))
+	for i, disk := range resumed.Disks {
+		diskResults[i] = DiskBackupResult{
+			DiskID:        disk.DiskIndex,
+			VMwareDiskKey: disk.VMwareDiskKey,
+			NBDPort:       disk.NBDPort,
+			ExportName:    disk.ExportName,
+			QCOW2Path:     disk.QCOW2Path,
+			QemuNBDPID:    disk.QemuNBDPID,
+			Status:        "prepared",
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"backup_id":  resumed.BackupID,
+		"vm_name":    req.VMName,
+		"disk_count": len(diskResults),
+		"sna_url":    snaURL,
+	}).Info("♻️ Interrupted backup resumed under its own job ID")
+
+	response := BackupResponse{
+		BackupID:         resumed.BackupID,
+		VMContextID:      vmContext.ContextID,
+		VMName:           req.VMName,
+		DiskResults:      diskResults,
+		NBDTargetsString: nbdTargetsString,
+		BackupType:       resumed.BackupType,
+		RepositoryID:     req.RepositoryID,
+		PolicyID:         req.PolicyID,
+		Status:           "resumed",
+		CreatedAt:        interrupted.CreatedAt.Format(time.RFC3339),
+		Tags:             req.Tags,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+	return true
+}
+
 // ListBackups handles GET /api/v1/backup/list
 // Returns a list of backups with optional filtering
 func (bh *BackupHandler) ListBackups(w http.ResponseWriter, r *http.Request) {
@@ -940,10 +1374,152 @@ func (bh *BackupHandler) convertToBackupResponse(job *database.BackupJob) *Backu
 	if job.LastTelemetryAt != nil {
 		response.LastTelemetryAt = job.LastTelemetryAt.Format("2006-01-02T15:04:05Z")
 	}
+	if job.ConsistencyRequested != nil {
+		response.ConsistencyRequested = *job.ConsistencyRequested
+	}
+	if job.ConsistencyLevel != nil {
+		response.ConsistencyLevel = *job.ConsistencyLevel
+	}
+	if job.ConsistencyFallbackReason != nil {
+		response.ConsistencyFallbackReason = *job.ConsistencyFallbackReason
+	}
 
 	return response
 }
 
+// resolveDiskExclusions merges the VM context's disk exclusions with those of the originating flow
+func (bh *BackupHandler) resolveDiskExclusions(vmContext *database.VMReplicationContext, flowID string) ([]database.DiskExclusion, error) {
+	exclusions, err := database.ParseDiskExclusions(vmContext.ExcludedDisks)
+	if err != nil {
+		return nil, err
+	}
+
+	if flowID != "" {
+		var flow database.ProtectionFlow
+		if err := bh.db.GetGormDB().Where("id = ?", flowID).First(&flow).Error; err != nil {
+			return nil, fmt.Errorf("failed to load protection flow %s: %w", flowID, err)
+		}
+		flowExclusions, err := database.ParseDiskExclusions(flow.ExcludedDisks)
+		if err != nil {
+			return nil, err
+		}
+		exclusions = append(exclusions, flowExclusions...)
+	}
+
+	return exclusions, nil
+}
+
+// recordExcludedDisk stores a backup_disks row for a skipped disk so restores
+// can recreate it as an empty volume of the right size
+func (bh *BackupHandler) recordExcludedDisk(vmBackupContextID, backupJobID string, diskIndex, diskKey int, vmDisk *database.VMDisk) error {
+	unitNumber := vmDisk.UnitNumber
+	now := time.Now()
+	// Round the capacity up like the other backup_disks rows so a blank disk is never too small
+	sizeGB := (backupDiskBytes(vmDisk) + 1073741823) / 1073741824
+	return bh.db.GetGormDB().Create(&database.BackupDisk{
+		VMBackupContextID: vmBackupContextID,
+		BackupJobID:       backupJobID,
+		DiskIndex:         diskIndex,
+		VMwareDiskKey:     diskKey,
+		SizeGB:            sizeGB,
+		UnitNumber:        &unitNumber,
+		Status:            database.BackupDiskStatusExcluded,
+		CreatedAt:         now,
+		CompletedAt:       &now,
+	}).Error
+}
+
+// backupDiskKey returns the VMware disk key recorded at discovery ("disk-2001"),
+// falling back to the positional key when it is missing
+func backupDiskKey(vmDisk *database.VMDisk, index int) int {
+	if key, ok := database.VMwareDiskKey(vmDisk.DiskID); ok {
+		return key
+	}
+	return index + 2000
+}
+
+// backupDiskBytes returns a disk's current capacity, falling back to its rounded size
+func backupDiskBytes(vmDisk *database.VMDisk) int64 {
+	if vmDisk.CapacityBytes > 0 {
+		return vmDisk.CapacityBytes
+	}
+	return int64(vmDisk.SizeGB) * 1024 * 1024 * 1024
+}
+
+// resolveDiskIndices maps each VMware disk key to the disk index of its backup chain.
+// Known keys keep their most recent index, new keys get the next free index, and the
+// indices of keys no longer on the VM are returned as retired. VMs without usable key
+// history (first backup or pre-key records) keep positional indices.
+func (bh *BackupHandler) resolveDiskIndices(vmName string, diskKeys []int) (map[int]int, []int, error) {
+	var history []struct {
+		DiskIndex     int
+		VMwareDiskKey int
+	}
+	err := bh.db.GetGormDB().
+		Table("backup_disks bd").
+		Select("bd.disk_index, bd.vmware_disk_key").
+		Joins("JOIN vm_backup_contexts vbc ON bd.vm_backup_context_id = vbc.context_id").
+		Where("vbc.vm_name = ? AND bd.vmware_disk_key > 0", vmName).
+		Order("bd.created_at DESC").
+		Scan(&history).Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load backup disk history: %w", err)
+	}
+
+	knownIndices := map[int]int{}
+	nextIndex := 0
+	for _, h := range history {
+		if _, ok := knownIndices[h.VMwareDiskKey]; !ok {
+			knownIndices[h.VMwareDiskKey] = h.DiskIndex
+		}
+		if h.DiskIndex >= nextIndex {
+			nextIndex = h.DiskIndex + 1
+		}
+	}
+
+	matched := false
+	for _, key := range diskKeys {
+		if _, ok := knownIndices[key]; ok {
+			matched = true
+			break
+		}
+	}
+
+	indices := make(map[int]int, len(diskKeys))
+	if !matched {
+		for i, key := range diskKeys {
+			indices[key] = i
+		}
+		return indices, nil, nil
+	}
+
+	used := map[int]bool{}
+	for _, key := range diskKeys {
+		if index, ok := knownIndices[key]; ok && !used[index] {
+			indices[key] = index
+			used[index] = true
+		}
+	}
+	for _, key := range diskKeys {
+		if _, ok := indices[key]; !ok {
+			indices[key] = nextIndex
+			used[nextIndex] = true
+			nextIndex++
+		}
+	}
+
+	var retired []int
+	for _, index := range knownIndices {
+		if !used[index] {
+			used[index] = true
+			retired = append(retired, index)
+		}
+	}
+	sort.Ints(retired)
+
+	return indices, retired, nil
+}
+
 // filterBackups applies additional filtering to backup list
 func (bh *BackupHandler) filterBackups(backups []*database.BackupJob, backupType, status string) []*database.BackupJob {
 	if backupType == "" && status == "" {
@@ -1014,13 +1590,162 @@ func (bh *BackupHandler) RegisterRoutes(r *mux.Router) {
 	// 6. POST /api/v1/backups/{backup_id}/complete - Complete backup and record change_id (MUST come before /{backup_id})
 	r.HandleFunc("/backups/{backup_id}/complete", bh.CompleteBackup).Methods("POST")
 
+	// 6.5 GET /api/v1/backups/{backup_id}/vm-config[/diff] - Captured VM configuration (MUST come before /{backup_id})
+	r.HandleFunc("/backups/{backup_id}/vm-config", bh.GetBackupVMConfig).Methods("GET")
+	r.HandleFunc("/backups/{backup_id}/vm-config/diff", bh.DiffBackupVMConfig).Methods("GET")
+
 	// 7. GET /api/v1/backups/{backup_id} - Get backup details
 	r.HandleFunc("/backups/{backup_id}", bh.GetBackupDetails).Methods("GET")
 
 	// 8. DELETE /api/v1/backups/{backup_id} - Delete backup
 	r.HandleFunc("/backups/{backup_id}", bh.DeleteBackup).Methods("DELETE")
 
-	log.Info("✅ Backup API routes registered - 8 RESTful endpoints (start, stats, complete, changeid, list, get, delete, chain)")
+	log.Info("✅ Backup API routes registered - 10 RESTful endpoints (start, stats, complete, changeid, list, get, delete, chain, vm-config, vm-config diff)")
+}
+
+// captureVMConfig asks the SNA for the VM's full configuration and stores it as a
+// versioned sidecar next to the backup's disks, recording its path on the backup job
+func (bh *BackupHandler) captureVMConfig(backupJobID string, vmContext *database.VMReplicationContext, creds *database.VMwareCredentials, diskFilePath string) {
+	logger := log.WithFields(log.Fields{
+		"backup_job_id": backupJobID,
+		"vm_name":       vmContext.VMName,
+	})
+
+	// The SNA resolves the password itself with a one-time token
+	credentialToken, err := bh.credentialService.IssueCredentialToken(context.Background(), creds.ID, backupJobID)
+	if err != nil {
+		logger.WithError(err).Warn("⚠️ Failed to issue VMware credential token for VM configuration capture")
+		return
+	}
+
+	snaReq := map[string]interface{}{
+		"vcenter":  creds.VCenterHost,
+		"username": creds.Username,
+		"vmware_credential": map[string]interface{}{
+			"credential_id": creds.ID,
+			"token":         credentialToken,
+		},
+		"datacenter": vmContext.Datacenter,
+		"vm_path":    vmContext.VMPath,
+	}
+	jsonData, _ := json.Marshal(snaReq)
+
+	snaEndpoint := bh.snaRouter.EndpointForJob(context.Background(), backupJobID, services.DefaultSNAEndpoint)
+	client := &http.Client{Timeout: 2 * time.Minute}
+	resp, err := client.Post(snaEndpoint+"/api/v1/vm-config", "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		logger.WithError(err).Warn("⚠️ Failed to capture VM configuration from SNA")
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		logger.WithField("status", resp.StatusCode).Warn("⚠️ SNA returned error for VM configuration capture")
+		return
+	}
+
+	var vmConfig models.VMConfiguration
+	if err := json.NewDecoder(resp.Body).Decode(&vmConfig); err != nil {
+		logger.WithError(err).Warn("⚠️ Failed to decode VM configuration from SNA")
+		return
+	}
+
+	configPath := storage.GetVMConfigPathForDisk(diskFilePath, backupJobID)
+	sidecar := &storage.VMConfigSidecar{
+		BackupID:    backupJobID,
+		VMContextID: vmContext.ContextID,
+		VMName:      vmContext.VMName,
+		CapturedAt:  time.Now().UTC(),
+		Config:      vmConfig,
+	}
+	if err := storage.SaveVMConfig(configPath, sidecar); err != nil {
+		logger.WithError(err).Warn("⚠️ Failed to save VM configuration sidecar")
+		return
+	}
+
+	if err := bh.backupJobRepo.Update(context.Background(), backupJobID, map[string]interface{}{
+		"vm_config_path": configPath,
+	}); err != nil {
+		logger.WithError(err).Warn("⚠️ Failed to record VM configuration path on backup job")
+		return
+	}
+
+	logger.WithFields(log.Fields{
+		"config_path": configPath,
+		"firmware":    vmConfig.Firmware,
+		"nics":        len(vmConfig.NICs),
+	}).Info("📋 VM configuration captured with backup")
+}
+
+// loadBackupVMConfig loads the VM configuration sidecar captured for a backup
+func (bh *BackupHandler) loadBackupVMConfig(backup *database.BackupJob) (*storage.VMConfigSidecar, error) {
+	if backup.VMConfigPath == nil || *backup.VMConfigPath == "" {
+		return nil, fmt.Errorf("no VM configuration captured for backup %s", backup.ID)
+	}
+	return storage.LoadVMConfig(*backup.VMConfigPath)
+}
+
+// GetBackupVMConfig handles GET /api/v1/backups/{backup_id}/vm-config
+// Returns the VM configuration captured with a backup
+func (bh *BackupHandler) GetBackupVMConfig(w http.ResponseWriter, r *http.Request) {
+	backupID := mux.Vars(r)["backup_id"]
+
+	backup, err := bh.backupJobRepo.GetByID(r.Context(), backupID)
+	if err != nil {
+		bh.sendError(w, http.StatusNotFound, "backup not found", err.Error())
+		return
+	}
+
+	sidecar, err := bh.loadBackupVMConfig(backup)
+	if err != nil {
+		bh.sendError(w, http.StatusNotFound, "VM configuration not available", err.Error())
+		return
+	}
+
+	bh.sendJSON(w, http.StatusOK, sidecar)
+}
+
+// DiffBackupVMConfig handles GET /api/v1/backups/{backup_id}/vm-config/diff?against={backup_id}
+// Compares a backup's VM configuration with another backup (default: the previous one captured)
+func (bh *BackupHandler) DiffBackupVMConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	backupID := mux.Vars(r)["backup_id"]
+
+	backup, err := bh.backupJobRepo.GetByID(ctx, backupID)
+	if err != nil {
+		bh.sendError(w, http.StatusNotFound, "backup not found", err.Error())
+		return
+	}
+
+	var baseline *database.BackupJob
+	if againstID := r.URL.Query().Get("against"); againstID != "" {
+		baseline, err = bh.backupJobRepo.GetByID(ctx, againstID)
+	} else {
+		baseline, err = bh.backupJobRepo.GetPreviousWithVMConfig(ctx, backup.VMContextID, backup.CreatedAt)
+	}
+	if err != nil {
+		bh.sendError(w, http.StatusNotFound, "baseline backup not found", err.Error())
+		return
+	}
+
+	current, err := bh.loadBackupVMConfig(backup)
+	if err != nil {
+		bh.sendError(w, http.StatusNotFound, "VM configuration not available", err.Error())
+		return
+	}
+	previous, err := bh.loadBackupVMConfig(baseline)
+	if err != nil {
+		bh.sendError(w, http.StatusNotFound, "baseline VM configuration not available", err.Error())
+		return
+	}
+
+	changes := storage.DiffVMConfig(&previous.Config, &current.Config)
+	bh.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"backup_id":   backup.ID,
+		"against":     baseline.ID,
+		"has_changes": len(changes) > 0,
+		"changes":     changes,
+	})
 }
 
 // GetBackupStats returns backup statistics for a VM in a specific repository
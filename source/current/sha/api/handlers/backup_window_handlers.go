@@ -0,0 +1,241 @@
+// Package handlers provides REST API endpoints for backup windows and blackout periods
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+	"github.com/vexxhost/migratekit-sha/services"
+)
+
+// BackupWindowHandler handles backup window and blackout management
+type BackupWindowHandler struct {
+	windowService *services.BackupWindowService
+}
+
+// NewBackupWindowHandler creates a new backup window handler
+func NewBackupWindowHandler(windowService *services.BackupWindowService) *BackupWindowHandler {
+	return &BackupWindowHandler{
+		windowService: windowService,
+	}
+}
+
+// UpdateBackupWindowRequest represents a request to update an existing backup window
+type UpdateBackupWindowRequest struct {
+	Name          *string    `json:"name,omitempty"`
+	Description   *string    `json:"description,omitempty"`
+	RuleType      *string    `json:"rule_type,omitempty"`
+	DaysOfWeek    *string    `json:"days_of_week,omitempty"`
+	DaysOfMonth   *string    `json:"days_of_month,omitempty"`
+	StartTime     *string    `json:"start_time,omitempty"`
+	EndTime       *string    `json:"end_time,omitempty"`
+	Timezone      *string    `json:"timezone,omitempty"`
+	StartsAt      *time.Time `json:"starts_at,omitempty"`
+	EndsAt        *time.Time `json:"ends_at,omitempty"`
+	OutsideAction *string    `json:"outside_action,omitempty"`
+	OverrunAction *string    `json:"overrun_action,omitempty"`
+	Enabled       *bool      `json:"enabled,omitempty"`
+}
+
+// CreateWindow handles POST /api/v1/backup-windows
+func (h *BackupWindowHandler) CreateWindow(w http.ResponseWriter, r *http.Request) {
+	var req services.CreateBackupWindowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	window, err := h.windowService.CreateWindow(r.Context(), req)
+	if err != nil {
+		log.WithError(err).Error("Failed to create backup window")
+		h.sendError(w, http.StatusBadRequest, "Failed to create backup window", err.Error())
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"window_id": window.ID,
+		"name":      window.Name,
+		"rule_type": window.RuleType,
+		"scope":     window.Scope,
+		"scope_id":  window.ScopeID,
+	}).Info("Backup window created successfully")
+
+	h.writeJSON(w, http.StatusCreated, window)
+}
+
+// ListWindows handles GET /api/v1/backup-windows?scope=&scope_id=&enabled=
+func (h *BackupWindowHandler) ListWindows(w http.ResponseWriter, r *http.Request) {
+	filters := database.BackupWindowFilters{}
+	if scope := r.URL.Query().Get("scope"); scope != "" {
+		filters.Scope = &scope
+	}
+	if scopeID := r.URL.Query().Get("scope_id"); scopeID != "" {
+		filters.ScopeID = &scopeID
+	}
+	if enabledStr := r.URL.Query().Get("enabled"); enabledStr != "" {
+		if enabled, err := strconv.ParseBool(enabledStr); err == nil {
+			filters.Enabled = &enabled
+		}
+	}
+
+	windows, err := h.windowService.ListWindows(r.Context(), filters)
+	if err != nil {
+		log.WithError(err).Error("Failed to list backup windows")
+		h.sendError(w, http.StatusInternalServerError, "Failed to list backup windows", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"windows": windows,
+		"total":   len(windows),
+	})
+}
+
+// GetWindow handles GET /api/v1/backup-windows/{id}
+func (h *BackupWindowHandler) GetWindow(w http.ResponseWriter, r *http.Request) {
+	windowID := mux.Vars(r)["id"]
+
+	window, err := h.windowService.GetWindow(r.Context(), windowID)
+	if err != nil {
+		h.sendError(w, http.StatusNotFound, "Backup window not found", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, window)
+}
+
+// UpdateWindow handles PUT /api/v1/backup-windows/{id}
+func (h *BackupWindowHandler) UpdateWindow(w http.ResponseWriter, r *http.Request) {
+	windowID := mux.Vars(r)["id"]
+
+	var req UpdateBackupWindowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Description != nil {
+		updates["description"] = req.Description
+	}
+	if req.RuleType != nil {
+		updates["rule_type"] = *req.RuleType
+	}
+	if req.DaysOfWeek != nil {
+		updates["days_of_week"] = req.DaysOfWeek
+	}
+	if req.DaysOfMonth != nil {
+		updates["days_of_month"] = req.DaysOfMonth
+	}
+	if req.StartTime != nil {
+		updates["start_time"] = req.StartTime
+	}
+	if req.EndTime != nil {
+		updates["end_time"] = req.EndTime
+	}
+	if req.Timezone != nil {
+		updates["timezone"] = *req.Timezone
+	}
+	if req.StartsAt != nil {
+		updates["starts_at"] = req.StartsAt
+	}
+	if req.EndsAt != nil {
+		updates["ends_at"] = req.EndsAt
+	}
+	if req.OutsideAction != nil {
+		updates["outside_action"] = *req.OutsideAction
+	}
+	if req.OverrunAction != nil {
+		updates["overrun_action"] = *req.OverrunAction
+	}
+	if req.Enabled != nil {
+		updates["enabled"] = *req.Enabled
+	}
+
+	if len(updates) == 0 {
+		h.sendError(w, http.StatusBadRequest, "No updates provided", "")
+		return
+	}
+
+	window, err := h.windowService.UpdateWindow(r.Context(), windowID, updates)
+	if err != nil {
+		log.WithError(err).WithField("window_id", windowID).Error("Failed to update backup window")
+		h.sendError(w, http.StatusBadRequest, "Failed to update backup window", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, window)
+}
+
+// DeleteWindow handles DELETE /api/v1/backup-windows/{id}
+func (h *BackupWindowHandler) DeleteWindow(w http.ResponseWriter, r *http.Request) {
+	windowID := mux.Vars(r)["id"]
+
+	if err := h.windowService.DeleteWindow(r.Context(), windowID); err != nil {
+		log.WithError(err).WithField("window_id", windowID).Error("Failed to delete backup window")
+		h.sendError(w, http.StatusNotFound, "Failed to delete backup window", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"message":   "Backup window deleted successfully",
+		"window_id": windowID,
+	})
+}
+
+// EvaluateWindows handles GET /api/v1/backup-windows/evaluate?scope=&scope_id=&at=
+// and reports whether a job for the given schedule or flow may run at that time
+func (h *BackupWindowHandler) EvaluateWindows(w http.ResponseWriter, r *http.Request) {
+	at := time.Now()
+	if atStr := r.URL.Query().Get("at"); atStr != "" {
+		parsed, err := time.Parse(time.RFC3339, atStr)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, "Invalid at parameter", "expected RFC3339 timestamp")
+			return
+		}
+		at = parsed
+	}
+
+	var owners []services.WindowOwner
+	scope := r.URL.Query().Get("scope")
+	scopeID := r.URL.Query().Get("scope_id")
+	if scope != "" && scope != database.WindowScopeGlobal {
+		if scopeID == "" {
+			h.sendError(w, http.StatusBadRequest, "scope_id is required for scope "+scope, "")
+			return
+		}
+		owners = append(owners, services.WindowOwner{Scope: scope, ID: scopeID})
+	}
+
+	decision, err := h.windowService.Evaluate(r.Context(), at, owners...)
+	if err != nil {
+		log.WithError(err).Error("Failed to evaluate backup windows")
+		h.sendError(w, http.StatusInternalServerError, "Failed to evaluate backup windows", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, decision)
+}
+
+// sendError sends a standardized error response
+func (h *BackupWindowHandler) sendError(w http.ResponseWriter, statusCode int, message, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: message, Details: details})
+}
+
+// writeJSON sends a JSON response
+func (h *BackupWindowHandler) writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
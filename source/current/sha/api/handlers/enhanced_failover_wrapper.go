@@ -119,7 +119,7 @@ func NewEnhancedFailoverHandler(db database.Connection) *FailoverHandler {
 	// Create enhanced cleanup service with JobLog integration
 	log.Info("🐛 DEBUG: About to create enhanced cleanup service")
 	// Initialize SNA client for power management (credentials passed per-operation from VM context)
-	var snaClient failover.SNAClient = failover.NewVMAClientForFailover()
+	var snaClient failover.SNAClient = failover.NewVMAClientForFailover(services.NewSNARouter(db))
 	if snaClient == nil {
 		log.Error("Failed to initialize SNA client for power management - using null client")
 		// Continue with null client - power management will be disabled
@@ -140,7 +140,7 @@ func (fh *FailoverHandler) initializeEngines() {
 	}
 
 	// Initialize SNA client for power management (credentials passed per-operation from VM context)
-	var snaClient failover.SNAClient = failover.NewVMAClientForFailover()
+	var snaClient failover.SNAClient = failover.NewVMAClientForFailover(services.NewSNARouter(fh.db))
 	if snaClient == nil {
 		log.Error("Failed to initialize SNA client for power management")
 		// Continue without SNA client - power management will be disabled
@@ -0,0 +1,58 @@
+// Package handlers provides REST API endpoints for SHA high availability status
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/services"
+)
+
+// HAHandler reports the active/standby state of this SHA instance
+type HAHandler struct {
+	leader *services.LeaderElector
+}
+
+// NewHAHandler creates a new HA handler
+func NewHAHandler(leader *services.LeaderElector) *HAHandler {
+	return &HAHandler{
+		leader: leader,
+	}
+}
+
+// GetStatus handles GET /api/v1/ha/status
+// and shows whether this instance is the leader and which instance holds the lease
+func (h *HAHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	if h.leader == nil {
+		h.writeJSON(w, http.StatusOK, map[string]interface{}{
+			"ha_enabled": false,
+			"is_leader":  true,
+		})
+		return
+	}
+
+	status, err := h.leader.Status(r.Context())
+	if err != nil {
+		log.WithError(err).Error("Failed to get leader election status")
+		h.sendError(w, http.StatusInternalServerError, "Failed to get HA status", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, status)
+}
+
+// sendError sends a standardized error response
+func (h *HAHandler) sendError(w http.ResponseWriter, statusCode int, message, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: message, Details: details})
+}
+
+// writeJSON sends a JSON response
+func (h *HAHandler) writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
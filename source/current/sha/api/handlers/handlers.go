@@ -46,6 +46,19 @@ type Handlers struct {
 	Backup                 *BackupHandler                 // 🆕 NEW: Backup API endpoints (Task 5 - 2025-10-05)
 	ProtectionFlow         *ProtectionFlowHandler         // 🆕 NEW: Protection Flow orchestration (Phase 1 Extension)
 	Telemetry              *TelemetryHandler              // 🆕 NEW: Real-time telemetry from SBC (2025-10-10)
+	JobHook                *JobHookHandler                // 🆕 NEW: Pre/post job and snapshot hooks
+	VMwareRestore          *VMwareRestoreHandler          // 🆕 NEW: Restore backups to VMware vSphere
+	BackupWindow           *BackupWindowHandler           // 🆕 NEW: Backup windows and blackout periods
+	RPO                    *RPOHandler                    // 🆕 NEW: RPO compliance and breach history
+	Admission              *AdmissionHandler              // 🆕 NEW: Job admission control per datastore, ESXi host and SNA
+	HA                     *HAHandler                     // 🆕 NEW: Active/standby leader election status
+	JobQueue               *JobQueueHandler               // 🆕 NEW: Durable queue of long-running operations
+	JobControl             *JobControlHandler             // 🆕 NEW: Pause, resume and cancel running jobs
+	Planning               *PlanningHandler               // 🆕 NEW: What-if planning of schedules and flows
+	RepositoryCapacity     *RepositoryCapacityHandler     // 🆕 NEW: Repository capacity forecasts, policies and alerts
+	SNARegistry            *SNARegistryHandler            // 🆕 NEW: Registered SNAs, their scopes and capacity
+	LiveEvents             *LiveEventHandler              // 🆕 NEW: Server-sent stream of live job updates
+	KVMSource              *KVMSourceHandler              // 🆕 NEW: Registers KVM/libvirt VMs for backup
 
 	// 🚨 REMOVED (2025-10-10): Old polling-based SNA progress client/poller
 	// Replaced by push-based telemetry framework (TelemetryHandler above)
@@ -54,7 +67,7 @@ type Handlers struct {
 }
 
 // NewHandlers creates a new handlers instance with database connection and mount manager
-func NewHandlers(db database.Connection) (*Handlers, error) {
+func NewHandlers(db database.Connection, leader *services.LeaderElector) (*Handlers, error) {
 	// Initialize volume mount manager with default mount path
 	// Note: We pass nil for database since mount manager can work without it for basic operations
 	mountManager := volume.NewMountManager(nil, "/mnt/migration")
@@ -109,6 +122,9 @@ func NewHandlers(db database.Connection) (*Handlers, error) {
 	// Initialize flow-related services and repositories
 	flowRepo := database.NewFlowRepository(db)
 
+	// Live job updates for API subscribers (telemetry, JobLog transitions, flow executions)
+	liveEventHub := services.NewLiveEventHub()
+
 	// Initialize joblog tracker (mandatory for all operations)
 	var jobTracker *joblog.Tracker
 	if sqlDB, err := db.GetGormDB().DB(); err == nil {
@@ -134,19 +150,70 @@ func NewHandlers(db database.Connection) (*Handlers, error) {
 
 	// ✅ UPDATED: Scheduler service now uses SNA discovery + SHA API (aligned with GUI workflow)
 	// No longer needs direct Migration Engine access - uses same API path as GUI
-	snaAPIEndpoint := "http://localhost:9081" // SNA API via tunnel
+	snaAPIEndpoint := services.DefaultSNAEndpoint // SNA API via tunnel (used until SNAs register their scopes)
 	schedulerService := services.NewSchedulerService(schedulerRepo, replicationRepo, jobTracker, snaAPIEndpoint, flowService)
 
+	// 🆕 NEW: Multi-SNA routing - SNA calls go to the SNA serving each VM's vCenter placement
+	snaRouter := services.NewSNARouter(db)
+	schedulerService.SetSNARouter(snaRouter)
+
 	// Set the scheduler service reference in flow service (resolve circular dependency)
 	flowService.SetSchedulerService(schedulerService)
 
+	// 🆕 NEW: Backup windows and blackouts - must be set before the scheduler starts
+	// so deferred runs recovered at startup honour them
+	backupWindowService := services.NewBackupWindowService(db)
+	schedulerService.SetBackupWindowService(backupWindowService)
+
+	// 🆕 NEW: Job control - pause/resume/cancel through the SNA; also applies backup window overrun actions
+	jobControlService := services.NewJobControlService(db, snaAPIEndpoint)
+	jobControlService.SetSNARouter(snaRouter)
+	backupWindowEnforcer := services.NewBackupWindowEnforcer(db, backupWindowService)
+	backupWindowEnforcer.SetOverrunHandler(jobControlService)
+	leader.OnElected("backup-window-enforcer", backupWindowEnforcer.Start)
+
+	// 🆕 NEW: Durable job queue - flow executions survive SHA restarts; workers run on the leader
+	jobQueue := services.NewJobQueue(db, services.DefaultInstanceID())
+	flowService.SetJobQueue(jobQueue)
+	leader.OnElected("job-queue", jobQueue.Run)
+
+	// 🆕 NEW: Admission control - scheduled jobs wait for datastore, host and SNA capacity
+	admissionController := services.NewAdmissionController(db, services.DefaultAdmissionLimits())
+	admissionController.SetSNARouter(snaRouter)
+	schedulerService.SetAdmissionController(admissionController)
+	flowService.SetAdmissionController(admissionController)
+	flowService.SetEventHub(liveEventHub)
+	go admissionController.Start(context.Background())
+
+	// 🆕 NEW: The leader recovers jobs left in flight by the previous leader before scheduling,
+	// once the SNA can report on them
+	snaProgressClient := services.NewVMAProgressClient(snaAPIEndpoint)
+	snaProgressClient.SetSNARouter(snaRouter)
+	jobRecovery := services.NewProductionJobRecovery(db, snaProgressClient, nil)
+	leader.OnTakeover("job-recovery", jobRecovery.RecoverOrphanedJobsOnTakeover)
+
 	// 🚀 CRITICAL: Start the scheduler service to enable automatic job scheduling
-	log.Info("🚀 Starting scheduler service for automatic job execution")
-	if err := schedulerService.Start(context.Background()); err != nil {
-		log.WithError(err).Error("Failed to start scheduler service")
-		return nil, err
-	}
-	log.Info("✅ Scheduler service started - automatic jobs will now trigger at scheduled times")
+	// (only on the elected leader - standby instances serve the API only)
+	log.Info("🚀 Scheduler service will run while this SHA is the control-plane leader")
+	leader.OnElected("scheduler", schedulerService.RunAsLeader)
+
+	// 🆕 NEW: Initialize job hook service (pre/post job hooks on SHA, snapshot hooks in guest via SNA)
+	jobHookService := services.NewJobHookService(db, jobTracker, encryptionService)
+	flowService.SetJobHookService(jobHookService)
+	schedulerService.SetJobHookService(jobHookService)
+
+	// 🆕 NEW: RPO-driven scheduling for flows with an RPO target
+	rpoService := services.NewRPOService(db, flowService)
+	rpoService.SetBackupWindowService(backupWindowService)
+	leader.OnElected("rpo-scheduler", rpoService.Start)
+
+	// 🆕 NEW: What-if planning - forecasts runs, data volume and repository growth before enabling
+	planningService := services.NewPlanningService(db, jobTracker, backupWindowService)
+	planningService.SetAdmissionController(admissionController)
+
+	// 🆕 NEW: Repository capacity - space reservation at backup start, growth history and alerts
+	capacityService := services.NewRepositoryCapacityService(db)
+	leader.OnElected("repository-capacity", capacityService.Start)
 
 	// Initialize machine group service
 	machineGroupService := services.NewMachineGroupService(schedulerRepo, jobTracker)
@@ -157,6 +224,8 @@ func NewHandlers(db database.Connection) (*Handlers, error) {
 	// Initialize enhanced discovery service with SNA API endpoint
 	// 🆕 Pass main database connection (not schedulerRepo) for vm_disks creation
 	enhancedDiscoveryService := services.NewEnhancedDiscoveryService(vmContextRepo, db, jobTracker, snaAPIEndpoint)
+	enhancedDiscoveryService.SetSNARouter(snaRouter)
+	enhancedDiscoveryService.SetMachineGroupService(machineGroupService)
 
 	// 🆕 NEW: Initialize VMware credentials management services
 	// Note: encryptionService already initialized earlier for OSSEA config repository
@@ -165,6 +234,8 @@ func NewHandlers(db database.Connection) (*Handlers, error) {
 		log.Warn("⚠️ VMware credential service running without encryption (development mode)")
 	}
 	vmwareCredentialService := services.NewVMwareCredentialService(&db, encryptionService)
+	enhancedDiscoveryService.SetCredentialService(vmwareCredentialService)
+	leader.OnElected("scheduled-discovery", enhancedDiscoveryService.RunScheduledDiscovery)
 
 	// 🆕 NEW: Initialize SNA enrollment services (commented out for simple version)
 	// snaEnrollmentRepo := database.NewVMAEnrollmentRepository(db)
@@ -192,11 +263,24 @@ func NewHandlers(db database.Connection) (*Handlers, error) {
 		StreamlinedOSSEA:       NewStreamlinedOSSEAConfigHandler(db),                 // 🆕 NEW: Streamlined OSSEA configuration
 		SNAReal:                NewVMARealHandler(db),                                // 🆕 NEW: SNA enrollment system (real implementation)
 		CloudStackSettings:     NewCloudStackSettingsHandler(db),                     // 🆕 NEW: CloudStack validation & settings
+		JobHook:                NewJobHookHandler(jobHookService),                    // 🆕 NEW: Pre/post job and snapshot hooks
+		BackupWindow:           NewBackupWindowHandler(backupWindowService),          // 🆕 NEW: Backup windows and blackout periods
+		RPO:                    NewRPOHandler(rpoService),                            // 🆕 NEW: RPO compliance and breach history
+		Admission:              NewAdmissionHandler(admissionController),             // 🆕 NEW: Job admission control
+		HA:                     NewHAHandler(leader),                                 // 🆕 NEW: Active/standby leader election status
+		JobQueue:               NewJobQueueHandler(jobQueue),                         // 🆕 NEW: Durable queue of long-running operations
+		JobControl:             NewJobControlHandler(jobControlService),              // 🆕 NEW: Pause, resume and cancel running jobs
+		Planning:               NewPlanningHandler(planningService),                  // 🆕 NEW: What-if planning of schedules and flows
+		RepositoryCapacity:     NewRepositoryCapacityHandler(capacityService),        // 🆕 NEW: Repository capacity forecasts and alerts
+		SNARegistry:            NewSNARegistryHandler(services.NewSNARegistryService(db)), // 🆕 NEW: SNA registration and scopes
+		LiveEvents:             NewLiveEventHandler(liveEventHub, flowRepo),                // 🆕 NEW: Live job updates without polling
 		
 		// 🚨 REMOVED (2025-10-10): Old SNA progress services
 		// Now using push-based telemetry (TelemetryHandler)
 	}
 
+	handlers.Replication.SetSNARouter(snaRouter)
+
 	// Initialize Repository handler (requires separate initialization due to error handling)
 	sqlDB, err := handlers.extractSQLDB(db)
 	if err != nil {
@@ -218,6 +302,10 @@ func NewHandlers(db database.Connection) (*Handlers, error) {
 			log.Info("✅ Backup policy management enabled (Enterprise 3-2-1 backup rule support)")
 		}
 
+		// Live repository capacity for planning forecasts
+		planningService.SetRepositoryManager(repositoryHandler.repoManager)
+		capacityService.SetRepositoryManager(repositoryHandler.repoManager)
+
 		// Initialize Restore handler (Task 4: File-Level Restore)
 		restoreHandler := NewRestoreHandlers(db, repositoryHandler.repoManager)
 		handlers.Restore = restoreHandler
@@ -228,17 +316,36 @@ func NewHandlers(db database.Connection) (*Handlers, error) {
 		
 		// 🆕 Initialize NBD Port Allocator (10100-10200 range for 100 concurrent backups)
 		nbdPortAllocator := services.NewNBDPortAllocator(10100, 10200)
+		admissionController.SetPortAllocator(nbdPortAllocator)
 		
 		// 🆕 Initialize qemu-nbd Process Manager with automatic port release
 		qemuNBDManager := services.NewQemuNBDManager(nbdPortAllocator)
 		
 		// Initialize BackupEngine with NBD infrastructure
 		backupEngine := workflows.NewBackupEngine(db, repositoryHandler.repoManager, nbdPortAllocator, qemuNBDManager, snaAPIEndpoint)
+		backupEngine.SetSNARouter(snaRouter)
+		jobControlService.SetBackupCanceller(backupEngine)
 		
-		backupHandler := NewBackupHandler(db, backupEngine, nbdPortAllocator, qemuNBDManager, vmwareCredentialService)
+		backupHandler := NewBackupHandler(db, backupEngine, nbdPortAllocator, qemuNBDManager, vmwareCredentialService, jobHookService)
+		backupHandler.SetCapacityService(capacityService)
+		backupHandler.SetSNARouter(snaRouter)
+		diskChangeService := services.NewDiskChangeService(db)
+		diskChangeService.SetSNARouter(snaRouter)
+		diskChangeService.SetCredentialService(vmwareCredentialService)
+		backupHandler.SetDiskChangeService(diskChangeService)
+		kvmSourceService := services.NewKVMSourceService(db, diskChangeService)
+		kvmSourceService.SetSNARouter(snaRouter)
+		backupHandler.SetKVMSourceService(kvmSourceService)
 		handlers.Backup = backupHandler
+		handlers.KVMSource = NewKVMSourceHandler(kvmSourceService)
 		log.Info("✅ Backup API endpoints enabled (Task 5: Start, list, delete backups via REST API + Unified NBD Architecture)")
 
+		// Initialize VMware restore handler (exports backup disks over the same NBD infrastructure)
+		vmwareRestoreService := services.NewVMwareRestoreService(db, vmwareCredentialService, nbdPortAllocator, qemuNBDManager, jobTracker, snaAPIEndpoint)
+		vmwareRestoreService.SetSNARouter(snaRouter)
+		handlers.VMwareRestore = NewVMwareRestoreHandler(vmwareRestoreService)
+		log.Info("✅ VMware restore API endpoints enabled (new VM and in-place restores)")
+
 		// Initialize Protection Flow handler (Phase 1 Extension: Protection Flows)
 		protectionFlowHandler := NewProtectionFlowHandler(flowService, jobTracker)
 		handlers.ProtectionFlow = protectionFlowHandler
@@ -246,6 +353,9 @@ func NewHandlers(db database.Connection) (*Handlers, error) {
 
 		// Initialize Telemetry handler (Real-time progress tracking)
 		telemetryHandler := NewTelemetryHandler(db)
+		telemetryHandler.SetCredentialService(vmwareCredentialService)
+		telemetryHandler.SetBackupEngine(backupEngine)
+		telemetryHandler.SetEventHub(liveEventHub)
 		handlers.Telemetry = telemetryHandler
 		log.Info("✅ Telemetry API endpoints enabled (Real-time SBC progress tracking)")
 	}
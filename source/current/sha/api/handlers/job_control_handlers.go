@@ -0,0 +1,95 @@
+// Package handlers provides REST API endpoints for pausing, resuming and cancelling running jobs
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/services"
+)
+
+// JobControlHandler handles pause, resume and cancel requests for backup and replication jobs
+type JobControlHandler struct {
+	jobControl *services.JobControlService
+}
+
+// NewJobControlHandler creates a new job control handler
+func NewJobControlHandler(jobControl *services.JobControlService) *JobControlHandler {
+	return &JobControlHandler{
+		jobControl: jobControl,
+	}
+}
+
+// CancelJobRequest represents an optional reason for cancelling a job
+type CancelJobRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// CancelJob handles POST /api/v1/jobs/{id}/cancel
+func (h *JobControlHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	var req CancelJobRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.sendError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+	}
+
+	h.apply(w, r, "cancel", func(ctx context.Context, jobID string) (*services.JobControlResult, error) {
+		return h.jobControl.CancelJob(ctx, jobID, req.Reason)
+	})
+}
+
+// PauseJob handles POST /api/v1/jobs/{id}/pause
+func (h *JobControlHandler) PauseJob(w http.ResponseWriter, r *http.Request) {
+	h.apply(w, r, "pause", h.jobControl.PauseJob)
+}
+
+// ResumeJob handles POST /api/v1/jobs/{id}/resume
+func (h *JobControlHandler) ResumeJob(w http.ResponseWriter, r *http.Request) {
+	h.apply(w, r, "resume", h.jobControl.ResumeJob)
+}
+
+// apply runs a job control action and maps its errors to HTTP status codes
+func (h *JobControlHandler) apply(w http.ResponseWriter, r *http.Request, action string,
+	fn func(ctx context.Context, jobID string) (*services.JobControlResult, error)) {
+	jobID := mux.Vars(r)["id"]
+
+	result, err := fn(r.Context(), jobID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrControlJobNotFound):
+			h.sendError(w, http.StatusNotFound, "Job not found", err.Error())
+		case errors.Is(err, services.ErrJobStateConflict):
+			h.sendError(w, http.StatusConflict, "Job state does not allow "+action, err.Error())
+		default:
+			log.WithError(err).WithFields(log.Fields{
+				"job_id": jobID,
+				"action": action,
+			}).Error("Job control request failed")
+			h.sendError(w, http.StatusBadGateway, "Job control request failed", err.Error())
+		}
+		return
+	}
+
+	h.writeJSON(w, http.StatusAccepted, result)
+}
+
+// sendError sends a standardized error response
+func (h *JobControlHandler) sendError(w http.ResponseWriter, statusCode int, message, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: message, Details: details})
+}
+
+// writeJSON sends a JSON response
+func (h *JobControlHandler) writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
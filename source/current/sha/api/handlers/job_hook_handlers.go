@@ -0,0 +1,198 @@
+// Package handlers provides REST API endpoints for job hook management
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+	"github.com/vexxhost/migratekit-sha/services"
+)
+
+// JobHookHandler handles pre/post job and snapshot hook CRUD API endpoints
+type JobHookHandler struct {
+	hookService *services.JobHookService
+}
+
+// NewJobHookHandler creates a new job hook handler
+func NewJobHookHandler(hookService *services.JobHookService) *JobHookHandler {
+	return &JobHookHandler{
+		hookService: hookService,
+	}
+}
+
+// UpdateHookRequest represents a request to update an existing hook
+type UpdateHookRequest struct {
+	Name           *string `json:"name,omitempty"`
+	Description    *string `json:"description,omitempty"`
+	Command        *string `json:"command,omitempty"`
+	TimeoutSeconds *int    `json:"timeout_seconds,omitempty"`
+	OnFailure      *string `json:"on_failure,omitempty"`
+	ExecutionOrder *int    `json:"execution_order,omitempty"`
+	GuestUsername  *string `json:"guest_username,omitempty"`
+	GuestPassword  *string `json:"guest_password,omitempty"`
+	Enabled        *bool   `json:"enabled,omitempty"`
+}
+
+// CreateHook handles POST /api/v1/job-hooks
+func (h *JobHookHandler) CreateHook(w http.ResponseWriter, r *http.Request) {
+	var req services.CreateHookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	hook, err := h.hookService.CreateHook(r.Context(), req)
+	if err != nil {
+		log.WithError(err).Error("Failed to create job hook")
+		h.sendError(w, http.StatusBadRequest, "Failed to create hook", err.Error())
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"hook_id":    hook.ID,
+		"hook_name":  hook.Name,
+		"hook_type":  hook.HookType,
+		"owner_type": hook.OwnerType,
+		"owner_id":   hook.OwnerID,
+	}).Info("Job hook created successfully")
+
+	h.writeJSON(w, http.StatusCreated, hook)
+}
+
+// ListHooks handles GET /api/v1/job-hooks?owner_type=&owner_id=&hook_type=&enabled=
+func (h *JobHookHandler) ListHooks(w http.ResponseWriter, r *http.Request) {
+	filters := database.JobHookFilters{}
+	if ownerType := r.URL.Query().Get("owner_type"); ownerType != "" {
+		filters.OwnerType = &ownerType
+	}
+	if ownerID := r.URL.Query().Get("owner_id"); ownerID != "" {
+		filters.OwnerID = &ownerID
+	}
+	if hookType := r.URL.Query().Get("hook_type"); hookType != "" {
+		filters.HookType = &hookType
+	}
+	if enabledStr := r.URL.Query().Get("enabled"); enabledStr != "" {
+		if enabled, err := strconv.ParseBool(enabledStr); err == nil {
+			filters.Enabled = &enabled
+		}
+	}
+
+	hooks, err := h.hookService.ListHooks(r.Context(), filters)
+	if err != nil {
+		log.WithError(err).Error("Failed to list job hooks")
+		h.sendError(w, http.StatusInternalServerError, "Failed to list hooks", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"hooks": hooks,
+		"total": len(hooks),
+	})
+}
+
+// GetHook handles GET /api/v1/job-hooks/{id}
+func (h *JobHookHandler) GetHook(w http.ResponseWriter, r *http.Request) {
+	hookID := mux.Vars(r)["id"]
+
+	hook, err := h.hookService.GetHook(r.Context(), hookID)
+	if err != nil {
+		h.sendError(w, http.StatusNotFound, "Hook not found", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, hook)
+}
+
+// UpdateHook handles PUT /api/v1/job-hooks/{id}
+func (h *JobHookHandler) UpdateHook(w http.ResponseWriter, r *http.Request) {
+	hookID := mux.Vars(r)["id"]
+
+	var req UpdateHookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.Command != nil {
+		updates["command"] = *req.Command
+	}
+	if req.TimeoutSeconds != nil {
+		updates["timeout_seconds"] = *req.TimeoutSeconds
+	}
+	if req.OnFailure != nil {
+		updates["on_failure"] = *req.OnFailure
+	}
+	if req.ExecutionOrder != nil {
+		updates["execution_order"] = *req.ExecutionOrder
+	}
+	if req.GuestUsername != nil {
+		updates["guest_username"] = *req.GuestUsername
+	}
+	if req.GuestPassword != nil {
+		updates["guest_password"] = *req.GuestPassword
+	}
+	if req.Enabled != nil {
+		updates["enabled"] = *req.Enabled
+	}
+
+	if len(updates) == 0 {
+		h.sendError(w, http.StatusBadRequest, "No updates provided", "")
+		return
+	}
+
+	if err := h.hookService.UpdateHook(r.Context(), hookID, updates); err != nil {
+		log.WithError(err).WithField("hook_id", hookID).Error("Failed to update job hook")
+		h.sendError(w, http.StatusInternalServerError, "Failed to update hook", err.Error())
+		return
+	}
+
+	hook, err := h.hookService.GetHook(r.Context(), hookID)
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, "Failed to reload hook", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, hook)
+}
+
+// DeleteHook handles DELETE /api/v1/job-hooks/{id}
+func (h *JobHookHandler) DeleteHook(w http.ResponseWriter, r *http.Request) {
+	hookID := mux.Vars(r)["id"]
+
+	if err := h.hookService.DeleteHook(r.Context(), hookID); err != nil {
+		log.WithError(err).WithField("hook_id", hookID).Error("Failed to delete job hook")
+		h.sendError(w, http.StatusNotFound, "Failed to delete hook", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Hook deleted successfully",
+		"hook_id": hookID,
+	})
+}
+
+// sendError sends a standardized error response
+func (h *JobHookHandler) sendError(w http.ResponseWriter, statusCode int, message, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: message, Details: details})
+}
+
+// writeJSON sends a JSON response
+func (h *JobHookHandler) writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
@@ -0,0 +1,113 @@
+// Package handlers provides REST API endpoints for the durable job queue
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+	"github.com/vexxhost/migratekit-sha/services"
+)
+
+// JobQueueHandler handles queued long-running operations
+type JobQueueHandler struct {
+	jobQueue *services.JobQueue
+}
+
+// NewJobQueueHandler creates a new job queue handler
+func NewJobQueueHandler(jobQueue *services.JobQueue) *JobQueueHandler {
+	return &JobQueueHandler{
+		jobQueue: jobQueue,
+	}
+}
+
+// ListJobs handles GET /api/v1/queue/jobs?kind=&resource_id=&state=&limit=
+func (h *JobQueueHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	filters := database.QueuedJobFilters{Limit: 100}
+	if kind := r.URL.Query().Get("kind"); kind != "" {
+		filters.Kind = &kind
+	}
+	if resourceID := r.URL.Query().Get("resource_id"); resourceID != "" {
+		filters.ResourceID = &resourceID
+	}
+	if state := r.URL.Query().Get("state"); state != "" {
+		filters.State = &state
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 && limit <= 1000 {
+			filters.Limit = limit
+		}
+	}
+
+	jobs, err := h.jobQueue.ListJobs(r.Context(), filters)
+	if err != nil {
+		log.WithError(err).Error("Failed to list queued jobs")
+		h.sendError(w, http.StatusInternalServerError, "Failed to list queued jobs", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"jobs":  jobs,
+		"total": len(jobs),
+	})
+}
+
+// GetJob handles GET /api/v1/queue/jobs/{id}
+func (h *JobQueueHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["id"]
+
+	job, err := h.jobQueue.GetJob(r.Context(), jobID)
+	if err != nil {
+		h.sendError(w, http.StatusNotFound, "Queued job not found", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, job)
+}
+
+// CancelJob handles POST /api/v1/queue/jobs/{id}/cancel
+// Pending jobs are cancelled at once; running jobs stop at their next heartbeat
+func (h *JobQueueHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["id"]
+
+	job, err := h.jobQueue.CancelJob(r.Context(), jobID)
+	if err != nil {
+		log.WithError(err).WithField("queue_job_id", jobID).Error("Failed to cancel queued job")
+		h.sendError(w, http.StatusNotFound, "Failed to cancel queued job", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusAccepted, job)
+}
+
+// RequeueJob handles POST /api/v1/queue/jobs/{id}/requeue
+func (h *JobQueueHandler) RequeueJob(w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["id"]
+
+	job, err := h.jobQueue.RequeueJob(r.Context(), jobID)
+	if err != nil {
+		log.WithError(err).WithField("queue_job_id", jobID).Error("Failed to requeue job")
+		h.sendError(w, http.StatusBadRequest, "Failed to requeue job", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, job)
+}
+
+// sendError sends a standardized error response
+func (h *JobQueueHandler) sendError(w http.ResponseWriter, statusCode int, message, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: message, Details: details})
+}
+
+// writeJSON sends a JSON response
+func (h *JobQueueHandler) writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
@@ -0,0 +1,68 @@
+// Package handlers provides REST API endpoints for registering KVM/libvirt VMs for backup
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+	"github.com/vexxhost/migratekit-sha/services"
+)
+
+// KVMSourceHandler handles KVM VM registration
+type KVMSourceHandler struct {
+	kvmSources *services.KVMSourceService
+}
+
+// NewKVMSourceHandler creates a new KVM source handler
+func NewKVMSourceHandler(kvmSources *services.KVMSourceService) *KVMSourceHandler {
+	return &KVMSourceHandler{
+		kvmSources: kvmSources,
+	}
+}
+
+// RegisterVM handles POST /api/v1/kvm/vms - reads a libvirt domain's disks through the
+// SNA and creates its VM context, after which it is backed up like any other VM
+func (h *KVMSourceHandler) RegisterVM(w http.ResponseWriter, r *http.Request) {
+	var source database.KVMSource
+	if err := json.NewDecoder(r.Body).Decode(&source); err != nil {
+		h.sendError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	vmContext, err := h.kvmSources.RegisterDomain(r.Context(), source)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "kvm source requires"), strings.Contains(err.Error(), "invalid nbd_port"):
+			h.sendError(w, http.StatusBadRequest, "Invalid KVM source", err.Error())
+		case strings.Contains(err.Error(), "already exists"):
+			h.sendError(w, http.StatusConflict, "VM already registered", err.Error())
+		case strings.Contains(err.Error(), "SNA"):
+			log.WithError(err).WithField("domain", source.Domain).Error("Failed to inspect KVM domain")
+			h.sendError(w, http.StatusBadGateway, "Failed to inspect KVM domain", err.Error())
+		default:
+			log.WithError(err).WithField("domain", source.Domain).Error("Failed to register KVM VM")
+			h.sendError(w, http.StatusInternalServerError, "Failed to register KVM VM", err.Error())
+		}
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, vmContext)
+}
+
+// sendError sends an error response
+func (h *KVMSourceHandler) sendError(w http.ResponseWriter, statusCode int, message, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: message, Details: details})
+}
+
+// writeJSON sends a JSON response
+func (h *KVMSourceHandler) writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
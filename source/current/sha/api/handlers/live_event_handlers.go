@@ -0,0 +1,161 @@
+// Package handlers provides the server-sent event stream of live job updates
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+	"github.com/vexxhost/migratekit-sha/services"
+)
+
+// liveEventKeepalive is how often an idle stream sends a comment so proxies keep it open
+const liveEventKeepalive = 15 * time.Second
+
+// LiveEventHandler streams telemetry, JobLog step transitions and flow execution changes
+type LiveEventHandler struct {
+	hub      *services.LiveEventHub
+	flowRepo *database.FlowRepository
+}
+
+// NewLiveEventHandler creates a new live event handler
+func NewLiveEventHandler(hub *services.LiveEventHub, flowRepo *database.FlowRepository) *LiveEventHandler {
+	return &LiveEventHandler{
+		hub:      hub,
+		flowRepo: flowRepo,
+	}
+}
+
+// StreamEvents handles GET /api/v1/events as a server-sent event stream
+// Query: job_id (job ID or JobLog external ID) or execution_id (protection flow
+// execution); neither subscribes to all jobs. Reconnecting clients resume after the
+// Last-Event-ID header (or last_event_id) while the events are still buffered.
+func (h *LiveEventHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := services.LiveEventFilter{
+		JobID:       query.Get("job_id"),
+		ExecutionID: query.Get("execution_id"),
+	}
+	if filter.JobID != "" && filter.ExecutionID != "" {
+		h.sendError(w, http.StatusBadRequest, "Subscribe to a job or an execution, not both", "")
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = query.Get("last_event_id")
+	}
+	var lastID uint64
+	if lastEventID != "" {
+		id, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, "Invalid last event ID", err.Error())
+			return
+		}
+		lastID = id
+	}
+
+	var jobIDs []string
+	if filter.ExecutionID != "" {
+		ids, err := h.executionJobIDs(r, filter.ExecutionID)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				h.sendError(w, http.StatusNotFound, "Flow execution not found", err.Error())
+				return
+			}
+			h.sendError(w, http.StatusInternalServerError, "Failed to load flow execution", err.Error())
+			return
+		}
+		jobIDs = ids
+	}
+
+	// Streams outlive the server write timeout
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.WithError(err).Debug("Live event stream keeps the server write timeout")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		log.WithError(err).Error("Live event stream cannot be flushed")
+		return
+	}
+
+	sub := h.hub.Subscribe(filter, jobIDs, lastID)
+	defer h.hub.Unsubscribe(sub)
+
+	log.WithFields(log.Fields{
+		"job_id":        filter.JobID,
+		"execution_id":  filter.ExecutionID,
+		"last_event_id": lastID,
+	}).Info("📡 Live event subscriber connected")
+
+	keepalive := time.NewTicker(liveEventKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case event, ok := <-sub.Events:
+			if !ok {
+				// Fell behind or replay overflowed - the client reconnects with its last event ID
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				log.WithError(err).WithField("event_id", event.ID).Warn("Failed to encode live event")
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// executionJobIDs returns the jobs a protection flow execution created so far; jobs it
+// starts later are followed through their execution_job events
+func (h *LiveEventHandler) executionJobIDs(r *http.Request, executionID string) ([]string, error) {
+	execution, err := h.flowRepo.GetExecution(r.Context(), executionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobIDs []string
+	if execution.CreatedJobIDs != nil && *execution.CreatedJobIDs != "" {
+		if err := json.Unmarshal([]byte(*execution.CreatedJobIDs), &jobIDs); err != nil {
+			return nil, fmt.Errorf("invalid created_job_ids: %w", err)
+		}
+	}
+	return jobIDs, nil
+}
+
+// sendError sends an error response
+func (h *LiveEventHandler) sendError(w http.ResponseWriter, statusCode int, message, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: message, Details: details})
+}
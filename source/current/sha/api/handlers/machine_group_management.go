@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -47,6 +49,8 @@ type CreateGroupRequest struct {
 	MaxConcurrentVMs int     `json:"max_concurrent_vms" binding:"min=1,max=100"`
 	Priority         int     `json:"priority" binding:"min=0,max=100"`
 	CreatedBy        string  `json:"created_by,omitempty"`
+
+	MembershipRules *services.MembershipRules `json:"membership_rules,omitempty"` // Makes the group dynamic
 }
 
 // UpdateGroupRequest represents a request to update an existing machine group
@@ -56,6 +60,8 @@ type UpdateGroupRequest struct {
 	ScheduleID       *string `json:"schedule_id,omitempty"`
 	MaxConcurrentVMs *int    `json:"max_concurrent_vms,omitempty" binding:"omitempty,min=1,max=100"`
 	Priority         *int    `json:"priority,omitempty" binding:"omitempty,min=0,max=100"`
+
+	MembershipRules *services.MembershipRules `json:"membership_rules,omitempty"` // Empty object: static group
 }
 
 // GroupResponse represents a machine group with full details
@@ -77,6 +83,8 @@ type GroupResponse struct {
 	UpdatedAt        time.Time                     `json:"updated_at"`
 	Schedule         *database.ReplicationSchedule `json:"schedule,omitempty"`
 	Memberships      []database.VMGroupMembership  `json:"memberships,omitempty"`
+	MembershipRules  *services.MembershipRules     `json:"membership_rules,omitempty"`
+	RulesEvaluatedAt *time.Time                    `json:"rules_evaluated_at,omitempty"`
 }
 
 // GroupListResponse represents a list of machine groups
@@ -129,6 +137,7 @@ func (h *MachineGroupManagementHandler) CreateGroup(w http.ResponseWriter, r *ht
 			MaxConcurrentVMs: request.MaxConcurrentVMs,
 			Priority:         request.Priority,
 			CreatedBy:        request.CreatedBy,
+			MembershipRules:  request.MembershipRules,
 		}
 
 		createdGroup, err := h.machineGroupService.CreateGroup(ctx, serviceReq)
@@ -313,6 +322,7 @@ func (h *MachineGroupManagementHandler) UpdateGroup(w http.ResponseWriter, r *ht
 			ScheduleID:       request.ScheduleID,
 			MaxConcurrentVMs: request.MaxConcurrentVMs,
 			Priority:         request.Priority,
+			MembershipRules:  request.MembershipRules,
 		}
 
 		group, err := h.machineGroupService.UpdateGroup(ctx, groupID, serviceReq)
@@ -454,6 +464,68 @@ func (h *MachineGroupManagementHandler) GetGroupMembers(w http.ResponseWriter, r
 	h.writeJSONResponse(w, http.StatusOK, response)
 }
 
+// EvaluateGroupRules re-evaluates the membership rules of a dynamic group now
+// POST /api/v1/machine-groups/{id}/evaluate
+func (h *MachineGroupManagementHandler) EvaluateGroupRules(w http.ResponseWriter, r *http.Request) {
+	groupID := mux.Vars(r)["id"]
+	if groupID == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Group ID is required")
+		return
+	}
+
+	result, err := h.machineGroupService.EvaluateGroupRules(r.Context(), groupID, services.RuleEvaluationManual)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			h.writeErrorResponse(w, http.StatusNotFound, "Group not found: "+err.Error())
+		case strings.Contains(err.Error(), "no membership rules"), strings.Contains(err.Error(), "invalid membership rules"):
+			h.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		default:
+			h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to evaluate group rules: "+err.Error())
+		}
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, result)
+}
+
+// GetMembershipChanges returns the membership changes made by a group's rules, newest first
+// GET /api/v1/machine-groups/{id}/membership-changes?limit=100
+func (h *MachineGroupManagementHandler) GetMembershipChanges(w http.ResponseWriter, r *http.Request) {
+	groupID := mux.Vars(r)["id"]
+	if groupID == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Group ID is required")
+		return
+	}
+
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			h.writeErrorResponse(w, http.StatusBadRequest, "Invalid limit: "+limitStr)
+			return
+		}
+		limit = parsed
+	}
+
+	changes, err := h.machineGroupService.GetMembershipChanges(r.Context(), groupID, limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			h.writeErrorResponse(w, http.StatusNotFound, "Group not found: "+err.Error())
+			return
+		}
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to get membership changes: "+err.Error())
+		return
+	}
+
+	response := map[string]interface{}{
+		"group_id": groupID,
+		"changes":  changes,
+		"count":    len(changes),
+	}
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
 // convertToGroupResponse converts a database group and optional summary to API response format
 func (h *MachineGroupManagementHandler) convertToGroupResponse(group *database.VMMachineGroup, summary *services.GroupSummary) GroupResponse {
 	response := GroupResponse{
@@ -466,6 +538,13 @@ func (h *MachineGroupManagementHandler) convertToGroupResponse(group *database.V
 		CreatedBy:        group.CreatedBy,
 		CreatedAt:        group.CreatedAt,
 		UpdatedAt:        group.UpdatedAt,
+		RulesEvaluatedAt: group.RulesEvaluatedAt,
+	}
+
+	if rules, err := services.ParseMembershipRules(group); err != nil {
+		log.WithError(err).WithField("group_id", group.ID).Warn("Machine group has invalid membership rules")
+	} else {
+		response.MembershipRules = rules
 	}
 
 	// Add schedule name if schedule is loaded
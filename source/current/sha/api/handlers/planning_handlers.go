@@ -0,0 +1,81 @@
+// Package handlers provides REST API endpoints for what-if planning of schedules and protection flows
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/services"
+)
+
+// PlanningHandler handles dry-run planning requests
+type PlanningHandler struct {
+	planning *services.PlanningService
+}
+
+// NewPlanningHandler creates a new planning handler
+func NewPlanningHandler(planning *services.PlanningService) *PlanningHandler {
+	return &PlanningHandler{
+		planning: planning,
+	}
+}
+
+// PlanFlow handles GET /api/v1/protection-flows/{id}/plan?runs=
+func (h *PlanningHandler) PlanFlow(w http.ResponseWriter, r *http.Request) {
+	h.plan(w, r, "Flow", h.planning.PlanFlow)
+}
+
+// PlanSchedule handles GET /api/v1/schedules/{id}/plan?runs=
+func (h *PlanningHandler) PlanSchedule(w http.ResponseWriter, r *http.Request) {
+	h.plan(w, r, "Schedule", h.planning.PlanSchedule)
+}
+
+// plan builds a plan for the {id} in the path and maps lookup failures to 404
+func (h *PlanningHandler) plan(w http.ResponseWriter, r *http.Request, kind string,
+	fn func(ctx context.Context, id string, runs int) (*services.ExecutionPlan, error)) {
+	id := mux.Vars(r)["id"]
+
+	runs := services.DefaultPlanRuns
+	if runsStr := r.URL.Query().Get("runs"); runsStr != "" {
+		parsed, err := strconv.Atoi(runsStr)
+		if err != nil || parsed < 1 || parsed > services.MaxPlanRuns {
+			h.sendError(w, http.StatusBadRequest, "Invalid runs parameter",
+				"expected a number from 1 to "+strconv.Itoa(services.MaxPlanRuns))
+			return
+		}
+		runs = parsed
+	}
+
+	plan, err := fn(r.Context(), id, runs)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			h.sendError(w, http.StatusNotFound, kind+" not found", err.Error())
+			return
+		}
+		log.WithError(err).WithField("id", id).Errorf("Failed to plan %s", strings.ToLower(kind))
+		h.sendError(w, http.StatusInternalServerError, "Failed to build plan", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, plan)
+}
+
+// sendError sends a standardized error response
+func (h *PlanningHandler) sendError(w http.ResponseWriter, statusCode int, message, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: message, Details: details})
+}
+
+// writeJSON sends a JSON response
+func (h *PlanningHandler) writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
@@ -47,7 +47,10 @@ type CreateFlowRequest struct {
 	RepositoryID *string `json:"repository_id,omitempty"`
 	PolicyID     *string `json:"policy_id,omitempty"`
 	ScheduleID   *string `json:"schedule_id,omitempty"`
+	RPOMinutes   *int    `json:"rpo_minutes,omitempty"`
 	Enabled      *bool   `json:"enabled,omitempty"`
+
+	ExcludedDisks []database.DiskExclusion `json:"excluded_disks,omitempty"`
 }
 
 // UpdateFlowRequest represents a request to update an existing protection flow
@@ -57,7 +60,10 @@ type UpdateFlowRequest struct {
 	RepositoryID *string `json:"repository_id,omitempty"`
 	PolicyID     *string `json:"policy_id,omitempty"`
 	ScheduleID   *string `json:"schedule_id,omitempty"`
+	RPOMinutes   *int    `json:"rpo_minutes,omitempty"` // 0 clears the RPO target
 	Enabled      *bool   `json:"enabled,omitempty"`
+
+	ExcludedDisks *[]database.DiskExclusion `json:"excluded_disks,omitempty"` // Empty list clears exclusions
 }
 
 // FlowResponse represents a protection flow in API responses
@@ -76,6 +82,8 @@ type FlowResponse struct {
 	ScheduleID   *string                `json:"schedule_id,omitempty"`
 	ScheduleName *string                `json:"schedule_name,omitempty"` // Resolved name
 	ScheduleCron *string                `json:"schedule_cron,omitempty"` // Cron expression
+	RPOMinutes   *int                   `json:"rpo_minutes,omitempty"`
+	ExcludedDisks []database.DiskExclusion `json:"excluded_disks,omitempty"`
 	Enabled      bool                   `json:"enabled"`
 	Status       FlowStatusResponse     `json:"status"`
 	CreatedAt    time.Time              `json:"created_at"`
@@ -157,7 +165,9 @@ func (h *ProtectionFlowHandler) CreateFlow(w http.ResponseWriter, r *http.Reques
 		RepositoryID: req.RepositoryID,
 		PolicyID:     req.PolicyID,
 		ScheduleID:   req.ScheduleID,
+		RPOMinutes:   req.RPOMinutes,
 		Enabled:      req.Enabled,
+		ExcludedDisks: req.ExcludedDisks,
 	})
 	if err != nil {
 		log.WithError(err).Error("Failed to create protection flow")
@@ -272,6 +282,24 @@ func (h *ProtectionFlowHandler) UpdateFlow(w http.ResponseWriter, r *http.Reques
 	if req.Enabled != nil {
 		updates["enabled"] = *req.Enabled
 	}
+	if req.RPOMinutes != nil {
+		if *req.RPOMinutes == 0 {
+			updates["rpo_minutes"] = nil
+		} else if err := services.ValidateRPOMinutes(*req.RPOMinutes); err != nil {
+			h.sendError(w, http.StatusBadRequest, "Invalid rpo_minutes", err.Error())
+			return
+		} else {
+			updates["rpo_minutes"] = *req.RPOMinutes
+		}
+	}
+	if req.ExcludedDisks != nil {
+		excludedDisks, err := database.EncodeDiskExclusions(*req.ExcludedDisks)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, "Invalid excluded_disks", err.Error())
+			return
+		}
+		updates["excluded_disks"] = excludedDisks
+	}
 
 	if err := h.flowService.UpdateFlow(ctx, flowID, updates); err != nil {
 		log.WithError(err).WithField("flow_id", flowID).Error("Failed to update protection flow")
@@ -356,15 +384,24 @@ func (h *ProtectionFlowHandler) ExecuteFlow(w http.ResponseWriter, r *http.Reque
 	ctx := r.Context()
 	flowID := mux.Vars(r)["id"]
 
-	execution, err := h.flowService.ExecuteFlow(ctx, flowID, "manual")
+	// The execution runs through the durable job queue - follow it via the queue job or
+	// GET /protection-flows/{id}/executions
+	queued, created, err := h.flowService.QueueFlowExecution(ctx, flowID, "manual", "api")
 	if err != nil {
 		log.WithError(err).WithField("flow_id", flowID).Error("Failed to execute protection flow")
 		h.sendError(w, http.StatusInternalServerError, "Failed to execute flow", err.Error())
 		return
 	}
 
-	response := h.convertExecutionToResponse(execution)
-	h.writeJSON(w, http.StatusOK, response)
+	message := "Flow execution queued"
+	if !created {
+		message = "Flow execution already queued or running"
+	}
+	h.writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"message":   message,
+		"flow_id":   flowID,
+		"queue_job": queued,
+	})
 }
 
 // GetFlowExecutions handles GET /api/v1/protection-flows/{id}/executions
@@ -618,6 +655,10 @@ func (h *ProtectionFlowHandler) convertFlowToResponse(flow *database.ProtectionF
 	if flow.ScheduleID != nil {
 		response.ScheduleID = flow.ScheduleID
 	}
+	response.RPOMinutes = flow.RPOMinutes
+	if excludedDisks, err := database.ParseDiskExclusions(flow.ExcludedDisks); err == nil {
+		response.ExcludedDisks = excludedDisks
+	}
 
 	// Resolve related names (simplified - could be enhanced with joins)
 	if flow.Schedule != nil {
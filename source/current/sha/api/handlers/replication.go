@@ -35,6 +35,7 @@ type ReplicationHandler struct {
 	migrationEngine *workflows.MigrationEngine
 	replicationRepo *database.ReplicationJobRepository // Repository for database operations
 	jobs            []ReplicationJob                   // Legacy in-memory storage (being phased out)
+	snaRouter       *services.SNARouter                // Routes SNA calls to the SNA serving each VM
 }
 
 // CreateMigrationRequest represents a simplified migration creation request
@@ -243,6 +244,12 @@ func NewReplicationHandler(db database.Connection, mountManager *volume.MountMan
 	}
 }
 
+// SetSNARouter routes replications and their progress calls to the SNA serving each VM
+func (h *ReplicationHandler) SetSNARouter(router *services.SNARouter) {
+	h.snaRouter = router
+	h.migrationEngine.SetSNARouter(router)
+}
+
 // List handles replication job list requests
 // @Summary List replication jobs
 // @Description Get list of all replication jobs with their current status
@@ -1129,7 +1136,7 @@ func (h *ReplicationHandler) GetVMAProgressProxy(w http.ResponseWriter, r *http.
 	log.WithField("job_id", jobID).Debug("Proxying SNA progress request through tunnel")
 
 	// Create HTTP client for SNA API call (following project rules: all traffic via tunnel)
-	snaURL := fmt.Sprintf("http://localhost:9081/api/v1/progress/%s", jobID)
+	snaURL := fmt.Sprintf("%s/api/v1/progress/%s", h.snaRouter.EndpointForJob(r.Context(), jobID, services.DefaultSNAEndpoint), jobID)
 
 	client := &http.Client{
 		Timeout: 10 * time.Second,
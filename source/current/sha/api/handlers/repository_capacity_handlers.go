@@ -0,0 +1,162 @@
+// Package handlers provides REST API endpoints for repository capacity forecasting and policies
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+	"github.com/vexxhost/migratekit-sha/services"
+)
+
+// RepositoryCapacityHandler handles repository capacity forecasts, policies and alerts
+type RepositoryCapacityHandler struct {
+	capacityService *services.RepositoryCapacityService
+}
+
+// NewRepositoryCapacityHandler creates a new repository capacity handler
+func NewRepositoryCapacityHandler(capacityService *services.RepositoryCapacityService) *RepositoryCapacityHandler {
+	return &RepositoryCapacityHandler{
+		capacityService: capacityService,
+	}
+}
+
+// ListForecasts handles GET /api/v1/repositories/capacity
+func (h *RepositoryCapacityHandler) ListForecasts(w http.ResponseWriter, r *http.Request) {
+	forecasts, err := h.capacityService.ListForecasts(r.Context())
+	if err != nil {
+		log.WithError(err).Error("Failed to forecast repository capacity")
+		h.sendError(w, http.StatusInternalServerError, "Failed to forecast repository capacity", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"repositories": forecasts,
+		"total":        len(forecasts),
+	})
+}
+
+// GetForecast handles GET /api/v1/repositories/{id}/capacity
+func (h *RepositoryCapacityHandler) GetForecast(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	forecast, err := h.capacityService.GetForecast(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			h.sendError(w, http.StatusNotFound, "Repository not found", err.Error())
+			return
+		}
+		log.WithError(err).WithField("repository_id", id).Error("Failed to forecast repository capacity")
+		h.sendError(w, http.StatusInternalServerError, "Failed to forecast repository capacity", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, forecast)
+}
+
+// GetPolicy handles GET /api/v1/repositories/{id}/capacity-policy
+func (h *RepositoryCapacityHandler) GetPolicy(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	policy, err := h.capacityService.GetPolicy(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			h.sendError(w, http.StatusNotFound, "Repository not found", err.Error())
+			return
+		}
+		log.WithError(err).WithField("repository_id", id).Error("Failed to get capacity policy")
+		h.sendError(w, http.StatusInternalServerError, "Failed to get capacity policy", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, policy)
+}
+
+// UpdatePolicy handles PUT /api/v1/repositories/{id}/capacity-policy
+func (h *RepositoryCapacityHandler) UpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	// Start from the current policy so omitted fields keep their value
+	policy, err := h.capacityService.GetPolicy(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			h.sendError(w, http.StatusNotFound, "Repository not found", err.Error())
+			return
+		}
+		h.sendError(w, http.StatusInternalServerError, "Failed to get capacity policy", err.Error())
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(policy); err != nil {
+		h.sendError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	policy.RepositoryID = id
+
+	if err := h.capacityService.UpdatePolicy(r.Context(), policy); err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid policy"):
+			h.sendError(w, http.StatusBadRequest, "Invalid capacity policy", err.Error())
+		case strings.Contains(err.Error(), "not found"):
+			h.sendError(w, http.StatusNotFound, "Repository not found", err.Error())
+		default:
+			log.WithError(err).WithField("repository_id", id).Error("Failed to update capacity policy")
+			h.sendError(w, http.StatusInternalServerError, "Failed to update capacity policy", err.Error())
+		}
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"repository_id":   id,
+		"on_insufficient": policy.OnInsufficient,
+	}).Info("✅ Repository capacity policy updated")
+	h.writeJSON(w, http.StatusOK, policy)
+}
+
+// ListAlerts handles GET /api/v1/repositories/capacity-alerts?repository_id=&open=&limit=
+func (h *RepositoryCapacityHandler) ListAlerts(w http.ResponseWriter, r *http.Request) {
+	filters := database.CapacityAlertFilters{Limit: 100}
+	if repositoryID := r.URL.Query().Get("repository_id"); repositoryID != "" {
+		filters.RepositoryID = &repositoryID
+	}
+	if openStr := r.URL.Query().Get("open"); openStr != "" {
+		if open, err := strconv.ParseBool(openStr); err == nil {
+			filters.OpenOnly = open
+		}
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
+			filters.Limit = limit
+		}
+	}
+
+	alerts, err := h.capacityService.ListAlerts(r.Context(), filters)
+	if err != nil {
+		log.WithError(err).Error("Failed to list capacity alerts")
+		h.sendError(w, http.StatusInternalServerError, "Failed to list capacity alerts", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"alerts": alerts,
+		"total":  len(alerts),
+	})
+}
+
+// sendError sends a standardized error response
+func (h *RepositoryCapacityHandler) sendError(w http.ResponseWriter, statusCode int, message, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: message, Details: details})
+}
+
+// writeJSON sends a JSON response
+func (h *RepositoryCapacityHandler) writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
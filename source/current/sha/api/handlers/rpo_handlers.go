@@ -0,0 +1,104 @@
+// Package handlers provides REST API endpoints for RPO compliance reporting
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+	"github.com/vexxhost/migratekit-sha/services"
+)
+
+// RPOHandler handles RPO compliance and breach history queries
+type RPOHandler struct {
+	rpoService *services.RPOService
+}
+
+// NewRPOHandler creates a new RPO handler
+func NewRPOHandler(rpoService *services.RPOService) *RPOHandler {
+	return &RPOHandler{
+		rpoService: rpoService,
+	}
+}
+
+// GetCompliance handles GET /api/v1/rpo/compliance?flow_id=&status=
+func (h *RPOHandler) GetCompliance(w http.ResponseWriter, r *http.Request) {
+	report, err := h.rpoService.GetCompliance(r.Context(), r.URL.Query().Get("flow_id"))
+	if err != nil {
+		log.WithError(err).Error("Failed to build RPO compliance report")
+		h.sendError(w, http.StatusInternalServerError, "Failed to get RPO compliance", err.Error())
+		return
+	}
+
+	// Narrow the VM list (e.g. status=breached) - the summary still covers every VM
+	if status := r.URL.Query().Get("status"); status != "" {
+		vms := make([]services.VMRPOStatus, 0, len(report.VMs))
+		for _, vm := range report.VMs {
+			if vm.Status == status {
+				vms = append(vms, vm)
+			}
+		}
+		report.VMs = vms
+	}
+
+	h.writeJSON(w, http.StatusOK, report)
+}
+
+// ListBreaches handles GET /api/v1/rpo/breaches?flow_id=&vm_context_id=&open=&since=&limit=
+func (h *RPOHandler) ListBreaches(w http.ResponseWriter, r *http.Request) {
+	filters := database.RPOBreachFilters{Limit: 100}
+	if flowID := r.URL.Query().Get("flow_id"); flowID != "" {
+		filters.FlowID = &flowID
+	}
+	if vmContextID := r.URL.Query().Get("vm_context_id"); vmContextID != "" {
+		filters.VMContextID = &vmContextID
+	}
+	if openStr := r.URL.Query().Get("open"); openStr != "" {
+		if open, err := strconv.ParseBool(openStr); err == nil {
+			filters.OpenOnly = open
+		}
+	}
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, "Invalid since parameter", "expected RFC3339 timestamp")
+			return
+		}
+		filters.Since = &since
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
+			filters.Limit = limit
+		}
+	}
+
+	breaches, err := h.rpoService.ListBreaches(r.Context(), filters)
+	if err != nil {
+		log.WithError(err).Error("Failed to list RPO breaches")
+		h.sendError(w, http.StatusInternalServerError, "Failed to list RPO breaches", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"breaches": breaches,
+		"total":    len(breaches),
+	})
+}
+
+// sendError sends a standardized error response
+func (h *RPOHandler) sendError(w http.ResponseWriter, statusCode int, message, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: message, Details: details})
+}
+
+// writeJSON sends a JSON response
+func (h *RPOHandler) writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
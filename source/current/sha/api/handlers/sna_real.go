@@ -306,6 +306,18 @@ func (vrh *SNARealHandler) ApproveEnrollment(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	// 🆕 NEW: Register the SNA with its own reverse tunnel port so several SNAs can serve this SHA
+	snaName := enrollment.ID
+	if enrollment.SNAName != nil {
+		snaName = *enrollment.SNAName
+	}
+	appliance, err := database.NewSNARegistryRepository(vrh.db).CreateEnrolledAppliance(r.Context(), enrollment.ID, snaName)
+	if err != nil {
+		log.WithError(err).Error("Failed to register SNA for enrollment")
+		writeVMAErrorResponse(w, http.StatusInternalServerError, "Failed to register SNA", err.Error())
+		return
+	}
+
 	// 🆕 NEW: Add SSH key management after database approval
 	sshManager, err := services.NewVMASSHManager()
 	if err != nil {
@@ -313,7 +325,7 @@ func (vrh *SNARealHandler) ApproveEnrollment(w http.ResponseWriter, r *http.Requ
 		// Continue with approval but log failure - don't fail the approval
 	} else {
 		// Install SNA SSH key for tunnel access
-		if err := sshManager.AddVMAKey(enrollment.SNAPublicKey, *enrollment.SNAFingerprint); err != nil {
+		if err := sshManager.AddVMAKey(enrollment.SNAPublicKey, *enrollment.SNAFingerprint, appliance.TunnelPort); err != nil {
 			log.WithError(err).Error("Failed to install SNA SSH key - manual setup required")
 			// Continue with approval but log failure
 		}
@@ -343,14 +355,18 @@ func (vrh *SNARealHandler) ApproveEnrollment(w http.ResponseWriter, r *http.Requ
 		"enrollment_id": enrollment.ID,
 		"vma_name":      enrollment.SNAName,
 		"approved_by":   req.ApprovedBy,
+		"sna_id":        appliance.ID,
+		"tunnel_port":   appliance.TunnelPort,
 	}).Info("✅ SNA enrollment approved by administrator with SSH access configured")
 
 	response := map[string]interface{}{
-		"success":   true,
-		"message":   "SNA enrollment approved successfully with tunnel access configured",
-		"status":    enrollment.Status,
-		"ssh_user":  "vma_tunnel",
-		"ssh_setup": "SSH key installed for tunnel access",
+		"success":     true,
+		"message":     "SNA enrollment approved successfully with tunnel access configured",
+		"status":      enrollment.Status,
+		"ssh_user":    "vma_tunnel",
+		"ssh_setup":   "SSH key installed for tunnel access",
+		"sna_id":      appliance.ID,
+		"tunnel_port": appliance.TunnelPort,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -377,6 +393,13 @@ func (vrh *SNARealHandler) GetEnrollmentResult(w http.ResponseWriter, r *http.Re
 		"message": fmt.Sprintf("Enrollment status: %s", enrollment.Status),
 	}
 
+	// Approved SNAs learn which SHA-side port their API reverse tunnel must use
+	if enrollment.Status == models.EnrollmentStatusApproved {
+		if appliance, err := database.NewSNARegistryRepository(vrh.db).GetApplianceByEnrollment(r.Context(), enrollment.ID); err == nil {
+			result["tunnel_port"] = appliance.TunnelPort
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(result)
 }
@@ -497,6 +520,11 @@ func (vrh *SNARealHandler) RevokeVMAAccess(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
+	// Stop routing jobs to the revoked SNA
+	if err := database.NewSNARegistryRepository(vrh.db).SetStateByEnrollment(r.Context(), enrollmentID, database.SNAStateDisabled); err != nil {
+		log.WithError(err).Warn("Failed to disable revoked SNA in registry")
+	}
+
 	log.WithFields(log.Fields{
 		"enrollment_id": enrollmentID,
 		"vma_name":      connection.SNAName,
@@ -0,0 +1,121 @@
+// Package handlers provides REST API endpoints for SNA registration, scopes and capacity
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/services"
+)
+
+// SNARegistryHandler handles SNA registration and administration
+type SNARegistryHandler struct {
+	registryService *services.SNARegistryService
+}
+
+// NewSNARegistryHandler creates a new SNA registry handler
+func NewSNARegistryHandler(registryService *services.SNARegistryService) *SNARegistryHandler {
+	return &SNARegistryHandler{
+		registryService: registryService,
+	}
+}
+
+// Register handles POST /api/v1/snas/register (called by each SNA on startup and as a heartbeat)
+func (h *SNARegistryHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var reg services.SNARegistration
+	if err := json.NewDecoder(r.Body).Decode(&reg); err != nil {
+		h.sendError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	appliance, err := h.registryService.Register(r.Context(), reg)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid registration") {
+			h.sendError(w, http.StatusBadRequest, "Invalid SNA registration", err.Error())
+			return
+		}
+		log.WithError(err).WithField("name", reg.Name).Error("Failed to register SNA")
+		h.sendError(w, http.StatusInternalServerError, "Failed to register SNA", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, appliance)
+}
+
+// ListSNAs handles GET /api/v1/snas
+func (h *SNARegistryHandler) ListSNAs(w http.ResponseWriter, r *http.Request) {
+	snas, err := h.registryService.List(r.Context())
+	if err != nil {
+		log.WithError(err).Error("Failed to list SNAs")
+		h.sendError(w, http.StatusInternalServerError, "Failed to list SNAs", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"snas":  snas,
+		"total": len(snas),
+	})
+}
+
+// GetSNA handles GET /api/v1/snas/{id}
+func (h *SNARegistryHandler) GetSNA(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	sna, err := h.registryService.Get(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			h.sendError(w, http.StatusNotFound, "SNA not found", err.Error())
+			return
+		}
+		log.WithError(err).WithField("sna_id", id).Error("Failed to get SNA")
+		h.sendError(w, http.StatusInternalServerError, "Failed to get SNA", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, sna)
+}
+
+// UpdateSNA handles PUT /api/v1/snas/{id} (state, max_concurrent_jobs, scopes)
+func (h *SNARegistryHandler) UpdateSNA(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var update services.SNAUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		h.sendError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	sna, err := h.registryService.Update(r.Context(), id, update)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid SNA update"):
+			h.sendError(w, http.StatusBadRequest, "Invalid SNA update", err.Error())
+		case strings.Contains(err.Error(), "not found"):
+			h.sendError(w, http.StatusNotFound, "SNA not found", err.Error())
+		default:
+			log.WithError(err).WithField("sna_id", id).Error("Failed to update SNA")
+			h.sendError(w, http.StatusInternalServerError, "Failed to update SNA", err.Error())
+		}
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, sna)
+}
+
+// sendError sends a standardized error response
+func (h *SNARegistryHandler) sendError(w http.ResponseWriter, statusCode int, message, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: message, Details: details})
+}
+
+// writeJSON sends a JSON response
+func (h *SNARegistryHandler) writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -10,12 +12,15 @@ import (
 
 	"github.com/vexxhost/migratekit-sha/database"
 	"github.com/vexxhost/migratekit-sha/services"
+	"github.com/vexxhost/migratekit-sha/workflows"
 )
 
 // TelemetryHandler handles real-time telemetry updates from SBC
 type TelemetryHandler struct {
-	db        database.Connection
-	telemetry *services.TelemetryService
+	db                database.Connection
+	telemetry         *services.TelemetryService
+	credentialService *services.VMwareCredentialService // Optional - issues snapshot cleanup tokens for lost jobs
+	backupEngine      *workflows.BackupEngine           // Optional - resumes lost backups that kept a checkpoint
 }
 
 // NewTelemetryHandler creates a new telemetry handler
@@ -26,6 +31,29 @@ func NewTelemetryHandler(db database.Connection) *TelemetryHandler {
 	}
 }
 
+// SetCredentialService enables snapshot cleanup credentials for lost job reports
+func (th *TelemetryHandler) SetCredentialService(credentialService *services.VMwareCredentialService) {
+	th.credentialService = credentialService
+}
+
+// SetBackupEngine lets lost backups with a client checkpoint resume instead of failing
+func (th *TelemetryHandler) SetBackupEngine(backupEngine *workflows.BackupEngine) {
+	th.backupEngine = backupEngine
+}
+
+// SetEventHub pushes processed telemetry to live event subscribers
+func (th *TelemetryHandler) SetEventHub(events *services.LiveEventHub) {
+	th.telemetry.SetEventHub(events)
+}
+
+// LostJobReport is sent by an SNA that restarted and found a job's client gone
+// without a final state
+type LostJobReport struct {
+	Error       string `json:"error"`
+	SnapshotRef string `json:"snapshot_ref,omitempty"` // Snapshot the client left behind (empty = nothing to clean up)
+	Resumable   bool   `json:"resumable,omitempty"`    // The client checkpointed its transfer and kept the snapshot
+}
+
 // ReceiveTelemetry handles POST /api/v1/telemetry/{job_type}/{job_id}
 // Receives real-time progress updates from SBC during backup/replication operations
 func (th *TelemetryHandler) ReceiveTelemetry(w http.ResponseWriter, r *http.Request) {
@@ -91,10 +119,147 @@ func (th *TelemetryHandler) ReceiveTelemetry(w http.ResponseWriter, r *http.Requ
 	})
 }
 
+// ReportLostJob handles POST /api/v1/telemetry/{job_type}/{job_id}/lost
+// Marks a job failed after its SNA lost the backup client across a restart. When the
+// client left a snapshot behind, the response carries a one-time credential token so
+// the SNA can remove it. A backup whose client checkpointed its transfer is re-exported
+// and handed back to the SNA to resume under the same job ID instead.
+func (th *TelemetryHandler) ReportLostJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobType := vars["job_type"]
+	jobID := vars["job_id"]
+
+	var report LostJobReport
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if report.Error == "" {
+		report.Error = "SNA lost the job process"
+	}
+
+	logger := log.WithFields(log.Fields{
+		"job_type":     jobType,
+		"job_id":       jobID,
+		"snapshot_ref": report.SnapshotRef,
+	})
+
+	// Only jobs the SHA still considers active are failed here - a job that already
+	// reached a final state (or was failed by the phantom detector) is left alone
+	credentialID, err := th.telemetry.ActiveJobCredential(r.Context(), jobType, jobID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not active") {
+			logger.Info("Lost job report for a job that already ended")
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		logger.WithError(err).Error("Failed to look up lost job")
+		http.Error(w, "Failed to look up job", http.StatusInternalServerError)
+		return
+	}
+
+	resumable := report.Resumable && jobType == "backup"
+	if resumable && credentialID != nil && th.credentialService != nil && th.backupEngine != nil {
+		response, err := th.resumeLostBackup(r.Context(), jobID, *credentialID)
+		if err == nil {
+			logger.Info("♻️ Lost backup handed back to SNA for resume")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+		logger.WithError(err).Warn("⚠️ Cannot resume lost backup - failing it and keeping its snapshot for the next attempt")
+	}
+
+	response := map[string]interface{}{
+		"status": "failed",
+		"job_id": jobID,
+	}
+	if resumable {
+		// The next backup of the VM resumes from the kept snapshot and checkpoint
+		response["keep_snapshot"] = true
+	} else if report.SnapshotRef != "" && credentialID != nil && th.credentialService != nil {
+		creds, err := th.credentialService.GetCredentials(r.Context(), *credentialID)
+		if err == nil {
+			var token string
+			if token, err = th.credentialService.IssueCredentialToken(r.Context(), creds.ID, jobID); err == nil {
+				response["vcenter_host"] = creds.VCenterHost
+				response["vcenter_user"] = creds.Username
+				response["vmware_credential"] = map[string]interface{}{
+					"credential_id": creds.ID,
+					"token":         token,
+				}
+			}
+		}
+		if err != nil {
+			// The job is still failed; the snapshot is left for manual cleanup
+			logger.WithError(err).Warn("Failed to issue snapshot cleanup credentials for lost job")
+		}
+	}
+
+	update := &services.TelemetryUpdate{
+		Status:       "failed",
+		CurrentPhase: "lost",
+		Error: &services.TelemetryError{
+			Message:   report.Error,
+			Code:      "SNA_JOB_LOST",
+			Timestamp: time.Now().Format(time.RFC3339),
+		},
+	}
+	if err := th.telemetry.ProcessTelemetryUpdate(r.Context(), jobType, jobID, update); err != nil {
+		logger.WithError(err).Error("Failed to mark lost job as failed")
+		http.Error(w, "Failed to update job", http.StatusInternalServerError)
+		return
+	}
+
+	logger.WithField("error", report.Error).Warn("⚠️ Job lost by SNA marked as failed")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// resumeLostBackup re-exports a lost backup and returns what its SNA needs to relaunch
+// the backup client: the new NBD targets and a one-time credential token
+func (th *TelemetryHandler) resumeLostBackup(ctx context.Context, jobID string, credentialID int) (map[string]interface{}, error) {
+	creds, err := th.credentialService.GetCredentials(ctx, credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	resumed, err := th.backupEngine.ResumeBackup(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := th.credentialService.IssueCredentialToken(ctx, creds.ID, jobID)
+	if err != nil {
+		if failErr := th.backupEngine.FailBackup(ctx, jobID, "SNA lost the job process"); failErr != nil {
+			log.WithError(failErr).WithField("job_id", jobID).Warn("Failed to mark lost backup as failed")
+		}
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"status":             "resumed",
+		"job_id":             jobID,
+		"resume":             true,
+		"nbd_targets":        resumed.NBDTargets(),
+		"excluded_disk_keys": resumed.ExcludedDiskKeys,
+		"vcenter_host":       creds.VCenterHost,
+		"vcenter_user":       creds.Username,
+		"vmware_credential": map[string]interface{}{
+			"credential_id": creds.ID,
+			"token":         token,
+		},
+	}, nil
+}
+
 // RegisterRoutes registers telemetry endpoints
 func (th *TelemetryHandler) RegisterRoutes(r *mux.Router) {
 	r.HandleFunc("/telemetry/{job_type}/{job_id}", th.ReceiveTelemetry).Methods("POST")
+	r.HandleFunc("/telemetry/{job_type}/{job_id}/lost", th.ReportLostJob).Methods("POST")
 	
-	log.Info("✅ Telemetry API routes registered: POST /api/v1/telemetry/{job_type}/{job_id}")
+	log.Info("✅ Telemetry API routes registered: POST /api/v1/telemetry/{job_type}/{job_id}, POST /api/v1/telemetry/{job_type}/{job_id}/lost")
 }
 
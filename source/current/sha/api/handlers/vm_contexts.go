@@ -467,6 +467,101 @@ func (h *VMContextHandler) GetVMDisks(w http.ResponseWriter, r *http.Request) {
 	h.writeJSONResponse(w, http.StatusOK, response)
 }
 
+// ConsistencyPolicyRequest represents a per-VM snapshot consistency policy update
+type ConsistencyPolicyRequest struct {
+	ConsistencyLevel string `json:"consistency_level"`         // "crash", "filesystem" or "application"
+	VSSBackupType    string `json:"vss_backup_type,omitempty"` // "full" or "copy" (default "copy")
+}
+
+// UpdateConsistencyPolicy sets the snapshot consistency policy for a VM
+// PUT /api/v1/vm-contexts/{context_id}/consistency
+func (h *VMContextHandler) UpdateConsistencyPolicy(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	contextID := vars["context_id"]
+
+	if contextID == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Context ID is required")
+		return
+	}
+
+	var req ConsistencyPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	if req.VSSBackupType == "" {
+		req.VSSBackupType = database.VSSBackupTypeCopy
+	}
+
+	if err := h.vmContextRepo.UpdateConsistencyPolicy(contextID, req.ConsistencyLevel, req.VSSBackupType); err != nil {
+		log.WithError(err).WithField("context_id", contextID).Error("Failed to update consistency policy")
+		if strings.HasPrefix(err.Error(), "invalid") {
+			h.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to update consistency policy: "+err.Error())
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"context_id":        contextID,
+		"consistency_level": req.ConsistencyLevel,
+		"vss_backup_type":   req.VSSBackupType,
+	}).Info("📸 VM snapshot consistency policy updated")
+
+	h.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"context_id":        contextID,
+		"consistency_level": req.ConsistencyLevel,
+		"vss_backup_type":   req.VSSBackupType,
+	})
+}
+
+// ExcludedDisksRequest represents a per-VM backup disk exclusion update
+type ExcludedDisksRequest struct {
+	ExcludedDisks []database.DiskExclusion `json:"excluded_disks"` // Empty list includes all disks
+}
+
+// UpdateExcludedDisks sets which disks are skipped when backing up a VM
+// PUT /api/v1/vm-contexts/{context_id}/excluded-disks
+func (h *VMContextHandler) UpdateExcludedDisks(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	contextID := vars["context_id"]
+
+	if contextID == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Context ID is required")
+		return
+	}
+
+	var req ExcludedDisksRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	for _, exclusion := range req.ExcludedDisks {
+		if err := exclusion.Validate(); err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if err := h.vmContextRepo.UpdateExcludedDisks(contextID, req.ExcludedDisks); err != nil {
+		log.WithError(err).WithField("context_id", contextID).Error("Failed to update excluded disks")
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to update excluded disks: "+err.Error())
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"context_id":     contextID,
+		"excluded_count": len(req.ExcludedDisks),
+	}).Info("💿 VM backup disk exclusions updated")
+
+	h.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"context_id":     contextID,
+		"excluded_disks": req.ExcludedDisks,
+	})
+}
+
 func extractSanitizedError(steps []joblog.StepRecord) (string, string, []string) {
 	// Find the failed step
 	for _, step := range steps {
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
@@ -204,6 +205,54 @@ func (vch *VMwareCredentialsHandler) GetCredentials(w http.ResponseWriter, r *ht
 	log.WithField("credential_id", id).Info("✅ VMware credentials retrieved successfully")
 }
 
+// ResolveCredentials handles POST /api/v1/vmware-credentials/{id}/resolve - redeem a one-time
+// credential token (called by the backup client over the tunnel; the token is the authorization)
+func (vch *VMwareCredentialsHandler) ResolveCredentials(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	credentialID := vars["id"]
+
+	id := 0
+	if _, err := fmt.Sscanf(credentialID, "%d", &id); err != nil {
+		log.WithError(err).Error("Invalid credential ID format")
+		http.Error(w, "Invalid credential ID", http.StatusBadRequest)
+		return
+	}
+
+	var request struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	credentials, err := vch.credentialService.RedeemCredentialToken(r.Context(), id, request.Token)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid credential token") {
+			log.WithField("credential_id", id).Warn("⚠️ Rejected VMware credential token")
+			http.Error(w, "Invalid or expired credential token", http.StatusUnauthorized)
+			return
+		}
+		log.WithError(err).Error("Failed to resolve VMware credentials")
+		http.Error(w, "Failed to resolve credentials", http.StatusInternalServerError)
+		return
+	}
+
+	// VMwareCredentials never serializes its password, so build the response explicitly
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"vcenter_host": credentials.VCenterHost,
+		"username":     credentials.Username,
+		"password":     credentials.Password,
+		"datacenter":   credentials.Datacenter,
+	}); err != nil {
+		log.WithError(err).Error("Failed to encode credentials response")
+		return
+	}
+
+	log.WithField("credential_id", id).Info("🔐 VMware credentials resolved with one-time token")
+}
+
 // UpdateCredentials handles PUT /api/v1/vmware-credentials/{id} - update credentials
 func (vch *VMwareCredentialsHandler) UpdateCredentials(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
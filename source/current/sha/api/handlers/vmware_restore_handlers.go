@@ -0,0 +1,95 @@
+// Package handlers provides REST API endpoints for restoring backups to VMware
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/services"
+)
+
+// VMwareRestoreHandler handles restores of backups to VMware vSphere
+type VMwareRestoreHandler struct {
+	restoreService *services.VMwareRestoreService
+}
+
+// NewVMwareRestoreHandler creates a new VMware restore handler
+func NewVMwareRestoreHandler(restoreService *services.VMwareRestoreService) *VMwareRestoreHandler {
+	return &VMwareRestoreHandler{
+		restoreService: restoreService,
+	}
+}
+
+// StartRestore handles POST /api/v1/restores/vmware
+func (h *VMwareRestoreHandler) StartRestore(w http.ResponseWriter, r *http.Request) {
+	var req services.VMwareRestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if req.BackupID == "" {
+		h.sendError(w, http.StatusBadRequest, "backup_id is required", "")
+		return
+	}
+
+	job, err := h.restoreService.StartRestore(r.Context(), &req)
+	if err != nil {
+		log.WithError(err).WithField("backup_id", req.BackupID).Error("Failed to start VMware restore")
+		h.sendError(w, http.StatusBadRequest, "Failed to start restore", err.Error())
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"restore_id": job.ID,
+		"backup_id":  job.BackupID,
+		"mode":       job.Mode,
+		"target_vm":  job.TargetVMName,
+	}).Info("VMware restore started")
+
+	h.writeJSON(w, http.StatusAccepted, job)
+}
+
+// ListRestores handles GET /api/v1/restores/vmware?vm_context_id=
+func (h *VMwareRestoreHandler) ListRestores(w http.ResponseWriter, r *http.Request) {
+	restores, err := h.restoreService.ListRestores(r.Context(), r.URL.Query().Get("vm_context_id"))
+	if err != nil {
+		log.WithError(err).Error("Failed to list VMware restores")
+		h.sendError(w, http.StatusInternalServerError, "Failed to list restores", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"restores": restores,
+		"total":    len(restores),
+	})
+}
+
+// GetRestore handles GET /api/v1/restores/vmware/{restore_id}
+func (h *VMwareRestoreHandler) GetRestore(w http.ResponseWriter, r *http.Request) {
+	restoreID := mux.Vars(r)["restore_id"]
+
+	job, err := h.restoreService.GetRestore(r.Context(), restoreID)
+	if err != nil {
+		h.sendError(w, http.StatusNotFound, "Restore not found", err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, job)
+}
+
+// sendError sends a standardized error response
+func (h *VMwareRestoreHandler) sendError(w http.ResponseWriter, statusCode int, message, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: message, Details: details})
+}
+
+// writeJSON sends a JSON response
+func (h *VMwareRestoreHandler) writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/vexxhost/migratekit-sha/api/handlers"
 	"github.com/vexxhost/migratekit-sha/database"
 	"github.com/vexxhost/migratekit-sha/middleware"
+	"github.com/vexxhost/migratekit-sha/services"
 )
 
 // Server represents the main SHA API server
@@ -30,10 +31,11 @@ type Server struct {
 
 // Config contains server configuration
 type Config struct {
-	Port        int                 `json:"port"`
-	AuthEnabled bool                `json:"auth_enabled"`
-	Database    database.Connection `json:"-"` // Don't serialize DB connection
-	Debug       bool                `json:"debug"`
+	Port        int                     `json:"port"`
+	AuthEnabled bool                    `json:"auth_enabled"`
+	Database    database.Connection     `json:"-"` // Don't serialize DB connection
+	Leader      *services.LeaderElector `json:"-"` // Control-plane leader election (nil = always leader)
+	Debug       bool                    `json:"debug"`
 }
 
 // NewServer creates a new SHA API server instance
@@ -52,7 +54,7 @@ func NewServer(config *Config) (*Server, error) {
 
 	// Initialize handlers with database connection
 	var err error
-	server.handlers, err = handlers.NewHandlers(config.Database)
+	server.handlers, err = handlers.NewHandlers(config.Database, config.Leader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize handlers: %w", err)
 	}
@@ -106,6 +108,8 @@ func (s *Server) setupRoutes() {
 	api.HandleFunc("/vm-contexts/by-id/{context_id}", s.requireAuth(s.handlers.VMContext.GetVMContextByID)).Methods("GET")
 	api.HandleFunc("/vm-contexts/{context_id}/disks", s.requireAuth(s.handlers.VMContext.GetVMDisks)).Methods("GET")
 	api.HandleFunc("/vm-contexts/{context_id}/recent-jobs", s.requireAuth(s.handlers.VMContext.GetRecentJobs)).Methods("GET")
+	api.HandleFunc("/vm-contexts/{context_id}/consistency", s.requireAuth(s.handlers.VMContext.UpdateConsistencyPolicy)).Methods("PUT")
+	api.HandleFunc("/vm-contexts/{context_id}/excluded-disks", s.requireAuth(s.handlers.VMContext.UpdateExcludedDisks)).Methods("PUT")
 
 	// OSSEA configuration - SINGLE UNIFIED ENDPOINT (following project rules)
 	api.HandleFunc("/ossea/config", s.requireAuth(s.handlers.OSSEA.HandleConfig)).Methods("POST")
@@ -178,6 +182,8 @@ func (s *Server) setupRoutes() {
 	api.HandleFunc("/machine-groups/{id}", s.requireAuth(s.handlers.MachineGroupManagement.GetGroup)).Methods("GET")
 	api.HandleFunc("/machine-groups/{id}", s.requireAuth(s.handlers.MachineGroupManagement.UpdateGroup)).Methods("PUT")
 	api.HandleFunc("/machine-groups/{id}", s.requireAuth(s.handlers.MachineGroupManagement.DeleteGroup)).Methods("DELETE")
+	api.HandleFunc("/machine-groups/{id}/evaluate", s.requireAuth(s.handlers.MachineGroupManagement.EvaluateGroupRules)).Methods("POST")
+	api.HandleFunc("/machine-groups/{id}/membership-changes", s.requireAuth(s.handlers.MachineGroupManagement.GetMembershipChanges)).Methods("GET")
 
 	// VM Group Assignment endpoints
 	api.HandleFunc("/machine-groups/{id}/vms", s.requireAuth(s.handlers.VMGroupAssignment.AssignVMToGroup)).Methods("POST")
@@ -202,6 +208,8 @@ func (s *Server) setupRoutes() {
 	api.HandleFunc("/vmware-credentials/{id}/set-default", s.requireAuth(s.handlers.VMwareCredentials.SetDefaultCredentials)).Methods("PUT")
 	api.HandleFunc("/vmware-credentials/{id}/test", s.requireAuth(s.handlers.VMwareCredentials.TestCredentials)).Methods("POST")
 	api.HandleFunc("/vmware-credentials/default", s.requireAuth(s.handlers.VMwareCredentials.GetDefaultCredentials)).Methods("GET")
+	// One-time token redemption by the backup client (no session - the token authorizes)
+	api.HandleFunc("/vmware-credentials/{id}/resolve", s.handlers.VMwareCredentials.ResolveCredentials).Methods("POST")
 
 	// 🆕 NEW: CloudStack Settings and Validation endpoints
 	api.HandleFunc("/settings/cloudstack/test-connection", s.requireAuth(s.handlers.CloudStackSettings.TestConnection)).Methods("POST")
@@ -257,6 +265,22 @@ func (s *Server) setupRoutes() {
 		log.Info("✅ Backup API routes registered (start, list, get, delete, chain)")
 	}
 
+	// 🆕 NEW: KVM VM registration - KVM VMs are then backed up through /backups like VMware VMs
+	if s.handlers.KVMSource != nil {
+		api.HandleFunc("/kvm/vms", s.requireAuth(s.handlers.KVMSource.RegisterVM)).Methods("POST")
+
+		log.Info("✅ KVM VM registration route registered")
+	}
+
+	// 🆕 NEW: VMware restore endpoints (restore backups as a new VM or in place)
+	if s.handlers.VMwareRestore != nil {
+		api.HandleFunc("/restores/vmware", s.requireAuth(s.handlers.VMwareRestore.StartRestore)).Methods("POST")
+		api.HandleFunc("/restores/vmware", s.requireAuth(s.handlers.VMwareRestore.ListRestores)).Methods("GET")
+		api.HandleFunc("/restores/vmware/{restore_id}", s.requireAuth(s.handlers.VMwareRestore.GetRestore)).Methods("GET")
+
+		log.Info("✅ VMware restore API routes registered (start, list, get)")
+	}
+
 	// 🆕 NEW: Telemetry API endpoints (Real-time progress tracking - 2025-10-10)
 	if s.handlers.Telemetry != nil {
 		s.handlers.Telemetry.RegisterRoutes(api)
@@ -296,7 +320,109 @@ func (s *Server) setupRoutes() {
 		log.Info("✅ Protection Flow API routes registered (Phase 1 Extension: Unified backup orchestration)")
 	}
 
-	log.WithField("endpoints", 96).Info("SHA API routes configured - includes file-level restore (Task 4) + backup operations (Task 5) + protection flows (Phase 1 Extension)")
+	// 🆕 NEW: Job hook endpoints (pre/post job and snapshot hooks)
+	if s.handlers.JobHook != nil {
+		api.HandleFunc("/job-hooks", s.requireAuth(s.handlers.JobHook.CreateHook)).Methods("POST")
+		api.HandleFunc("/job-hooks", s.requireAuth(s.handlers.JobHook.ListHooks)).Methods("GET")
+		api.HandleFunc("/job-hooks/{id}", s.requireAuth(s.handlers.JobHook.GetHook)).Methods("GET")
+		api.HandleFunc("/job-hooks/{id}", s.requireAuth(s.handlers.JobHook.UpdateHook)).Methods("PUT")
+		api.HandleFunc("/job-hooks/{id}", s.requireAuth(s.handlers.JobHook.DeleteHook)).Methods("DELETE")
+
+		log.Info("✅ Job hook API routes registered (pre/post job and snapshot hooks)")
+	}
+
+	// 🆕 NEW: Backup windows and blackout periods for schedules and flows
+	if s.handlers.BackupWindow != nil {
+		api.HandleFunc("/backup-windows", s.requireAuth(s.handlers.BackupWindow.CreateWindow)).Methods("POST")
+		api.HandleFunc("/backup-windows", s.requireAuth(s.handlers.BackupWindow.ListWindows)).Methods("GET")
+		api.HandleFunc("/backup-windows/evaluate", s.requireAuth(s.handlers.BackupWindow.EvaluateWindows)).Methods("GET")
+		api.HandleFunc("/backup-windows/{id}", s.requireAuth(s.handlers.BackupWindow.GetWindow)).Methods("GET")
+		api.HandleFunc("/backup-windows/{id}", s.requireAuth(s.handlers.BackupWindow.UpdateWindow)).Methods("PUT")
+		api.HandleFunc("/backup-windows/{id}", s.requireAuth(s.handlers.BackupWindow.DeleteWindow)).Methods("DELETE")
+
+		log.Info("✅ Backup window API routes registered (windows, blackouts, evaluate)")
+	}
+
+	// 🆕 NEW: RPO compliance reporting (RPO targets are set on protection flows)
+	if s.handlers.RPO != nil {
+		api.HandleFunc("/rpo/compliance", s.requireAuth(s.handlers.RPO.GetCompliance)).Methods("GET")
+		api.HandleFunc("/rpo/breaches", s.requireAuth(s.handlers.RPO.ListBreaches)).Methods("GET")
+
+		log.Info("✅ RPO API routes registered (compliance, breach history)")
+	}
+
+	// 🆕 NEW: Job admission control (queue and limits per datastore, ESXi host and SNA)
+	if s.handlers.Admission != nil {
+		api.HandleFunc("/admission/queue", s.requireAuth(s.handlers.Admission.GetQueue)).Methods("GET")
+		api.HandleFunc("/admission/limits", s.requireAuth(s.handlers.Admission.GetLimits)).Methods("GET")
+		api.HandleFunc("/admission/limits", s.requireAuth(s.handlers.Admission.UpdateLimits)).Methods("PUT")
+
+		log.Info("✅ Admission control API routes registered (queue, limits)")
+	}
+
+	// 🆕 NEW: High availability - which SHA instance is the control-plane leader
+	if s.handlers.HA != nil {
+		api.HandleFunc("/ha/status", s.requireAuth(s.handlers.HA.GetStatus)).Methods("GET")
+
+		log.Info("✅ HA API routes registered (leader status)")
+	}
+
+	// 🆕 NEW: Durable job queue (queued long-running operations, cancel and requeue)
+	if s.handlers.JobQueue != nil {
+		api.HandleFunc("/queue/jobs", s.requireAuth(s.handlers.JobQueue.ListJobs)).Methods("GET")
+		api.HandleFunc("/queue/jobs/{id}", s.requireAuth(s.handlers.JobQueue.GetJob)).Methods("GET")
+		api.HandleFunc("/queue/jobs/{id}/cancel", s.requireAuth(s.handlers.JobQueue.CancelJob)).Methods("POST")
+		api.HandleFunc("/queue/jobs/{id}/requeue", s.requireAuth(s.handlers.JobQueue.RequeueJob)).Methods("POST")
+
+		log.Info("✅ Job queue API routes registered (list, get, cancel, requeue)")
+	}
+
+	// 🆕 NEW: Job control (pause, resume and cancel running backup and replication jobs)
+	if s.handlers.JobControl != nil {
+		api.HandleFunc("/jobs/{id}/pause", s.requireAuth(s.handlers.JobControl.PauseJob)).Methods("POST")
+		api.HandleFunc("/jobs/{id}/resume", s.requireAuth(s.handlers.JobControl.ResumeJob)).Methods("POST")
+		api.HandleFunc("/jobs/{id}/cancel", s.requireAuth(s.handlers.JobControl.CancelJob)).Methods("POST")
+
+		log.Info("✅ Job control API routes registered (pause, resume, cancel)")
+	}
+
+	// 🆕 NEW: What-if planning (VMs, data volume, duration, repository growth and window violations of the next runs)
+	if s.handlers.Planning != nil {
+		api.HandleFunc("/protection-flows/{id}/plan", s.requireAuth(s.handlers.Planning.PlanFlow)).Methods("GET")
+		api.HandleFunc("/schedules/{id}/plan", s.requireAuth(s.handlers.Planning.PlanSchedule)).Methods("GET")
+
+		log.Info("✅ Planning API routes registered (flow and schedule dry runs)")
+	}
+
+	// 🆕 NEW: Repository capacity forecasting, reservation policies and threshold alerts
+	if s.handlers.RepositoryCapacity != nil {
+		api.HandleFunc("/repositories/capacity", s.requireAuth(s.handlers.RepositoryCapacity.ListForecasts)).Methods("GET")
+		api.HandleFunc("/repositories/capacity-alerts", s.requireAuth(s.handlers.RepositoryCapacity.ListAlerts)).Methods("GET")
+		api.HandleFunc("/repositories/{id}/capacity", s.requireAuth(s.handlers.RepositoryCapacity.GetForecast)).Methods("GET")
+		api.HandleFunc("/repositories/{id}/capacity-policy", s.requireAuth(s.handlers.RepositoryCapacity.GetPolicy)).Methods("GET")
+		api.HandleFunc("/repositories/{id}/capacity-policy", s.requireAuth(s.handlers.RepositoryCapacity.UpdatePolicy)).Methods("PUT")
+
+		log.Info("✅ Repository capacity API routes registered (forecasts, policies, alerts)")
+	}
+
+	// 🆕 NEW: Multi-SNA registry - SNAs register their vCenter scopes and capacity over their tunnel
+	if s.handlers.SNARegistry != nil {
+		api.HandleFunc("/snas/register", s.handlers.SNARegistry.Register).Methods("POST") // Called by the SNA (like telemetry)
+		api.HandleFunc("/snas", s.requireAuth(s.handlers.SNARegistry.ListSNAs)).Methods("GET")
+		api.HandleFunc("/snas/{id}", s.requireAuth(s.handlers.SNARegistry.GetSNA)).Methods("GET")
+		api.HandleFunc("/snas/{id}", s.requireAuth(s.handlers.SNARegistry.UpdateSNA)).Methods("PUT")
+
+		log.Info("✅ SNA registry API routes registered (registration, scopes, capacity)")
+	}
+
+	// 🆕 NEW: Live job updates pushed as server-sent events instead of polling
+	if s.handlers.LiveEvents != nil {
+		api.HandleFunc("/events", s.requireAuth(s.handlers.LiveEvents.StreamEvents)).Methods("GET")
+
+		log.Info("✅ Live event stream route registered (telemetry, job steps, flow executions)")
+	}
+
+	log.WithField("endpoints", 115).Info("SHA API routes configured - includes file-level restore (Task 4) + backup operations (Task 5) + protection flows (Phase 1 Extension)")
 }
 
 // Middleware functions
@@ -382,6 +508,11 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer (flushing live event streams)
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 func generateRequestID() string {
 	return fmt.Sprintf("req-%d", time.Now().UnixNano())
 }
@@ -29,6 +29,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -37,6 +38,7 @@ import (
 
 	"github.com/vexxhost/migratekit-sha/api"
 	"github.com/vexxhost/migratekit-sha/database"
+	"github.com/vexxhost/migratekit-sha/joblog"
 	"github.com/vexxhost/migratekit-sha/services"
 )
 
@@ -88,11 +90,19 @@ func main() {
 		log.Info("Using in-memory storage (no persistence)")
 	}
 
+	// 🆕 NEW: Active/standby control plane - SHA instances sharing the database elect a
+	// leader; only the leader runs schedulers and background workers
+	var leader *services.LeaderElector
+	if db != nil {
+		leader = services.NewLeaderElector(db, services.DefaultInstanceID())
+	}
+
 	// Create and configure the API server
 	serverConfig := &api.Config{
 		Port:        *port,
 		AuthEnabled: *authEnabled,
 		Database:    db,
+		Leader:      leader,
 	}
 
 	apiServer, err := api.NewServer(serverConfig)
@@ -108,13 +118,42 @@ func main() {
 	// 🚨 NEW: Stale job detector for telemetry-based progress tracking
 	log.Info("🚨 Starting stale job detector for real-time telemetry monitoring")
 	staleDetector := services.NewStaleJobDetector(db)
-	go staleDetector.Start(context.Background())
+	leader.OnElected("stale-job-detector", staleDetector.Start)
 
 	// 🆕 NEW: Execution monitor to update flow execution status when jobs complete
 	log.Info("🔍 Starting execution monitor for flow completion tracking")
 	flowRepo := database.NewFlowRepository(db)
-	executionMonitor := services.NewExecutionMonitor(flowRepo, db)
-	executionMonitor.Start()
+	var hookService *services.JobHookService
+	if sqlDB, err := db.GetGormDB().DB(); err == nil {
+		// 🆕 NEW: post_job hooks run once a flow execution's jobs have finished
+		stdoutHandler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo})
+		hookTracker := joblog.New(sqlDB, stdoutHandler, joblog.NewDBHandler(sqlDB, joblog.DefaultDBHandlerConfig()))
+		hookService = services.NewJobHookService(db, hookTracker, nil) // post_job hooks need no guest credentials
+	} else {
+		log.WithError(err).Warn("⚠️ JobLog unavailable - post_job hooks disabled")
+	}
+	leader.OnElected("execution-monitor", func(ctx context.Context) {
+		// A monitor cannot be restarted once stopped - use a fresh one per leadership term
+		executionMonitor := services.NewExecutionMonitor(flowRepo, db)
+		if hookService != nil {
+			executionMonitor.SetJobHookService(hookService)
+		}
+		executionMonitor.Start()
+		<-ctx.Done()
+		executionMonitor.Stop()
+	})
+
+	// Take part in the leader election - workers start once this instance is elected
+	electionCtx, stopElection := context.WithCancel(context.Background())
+	electionDone := make(chan struct{})
+	if leader != nil {
+		go func() {
+			defer close(electionDone)
+			leader.Run(electionCtx)
+		}()
+	} else {
+		close(electionDone)
+	}
 
 	// Setup graceful shutdown
 	c := make(chan os.Signal, 1)
@@ -123,6 +162,10 @@ func main() {
 	go func() {
 		<-c
 		log.Info("🛑 Shutdown signal received, stopping SHA API server")
+		// Hand leadership to a standby straight away instead of waiting for the lease to expire;
+		// Run resigns as it returns, so wait for it before exiting
+		stopElection()
+		<-electionDone
 		// Database cleanup would go here when implemented
 		os.Exit(0)
 	}()
@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -15,8 +17,11 @@ import (
 
 // VolumeClient provides a simple interface to the Volume Management Daemon
 type VolumeClient struct {
-	baseURL string
-	client  *http.Client
+	baseURL      string
+	client       *http.Client
+	streamClient *http.Client // No timeout: event streams stay open until the operation ends
+
+	eventsUnsupported atomic.Bool // Set once the daemon answers 404 for the event stream
 }
 
 // CreateVolumeRequest represents a volume creation request
@@ -102,6 +107,7 @@ func NewVolumeClient(baseURL string) *VolumeClient {
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		streamClient: &http.Client{},
 	}
 }
 
@@ -136,6 +142,13 @@ func (vc *VolumeClient) DetachVolume(ctx context.Context, volumeID string) (*Vol
 	return vc.doVolumeOperation(ctx, "POST", endpoint, nil)
 }
 
+// ResizeVolume grows a volume to sizeBytes via the daemon
+func (vc *VolumeClient) ResizeVolume(ctx context.Context, volumeID string, sizeBytes int64) (*VolumeOperation, error) {
+	req := map[string]interface{}{"size": sizeBytes}
+	endpoint := fmt.Sprintf("/api/v1/volumes/%s/resize", volumeID)
+	return vc.doVolumeOperation(ctx, "POST", endpoint, req)
+}
+
 // CleanupTestFailover performs complete test failover cleanup via the daemon
 func (vc *VolumeClient) CleanupTestFailover(ctx context.Context, testVMID, volumeID, shaVMID string, deleteVM bool) (*VolumeOperation, error) {
 	log.WithFields(log.Fields{
@@ -253,19 +266,73 @@ func (vc *VolumeClient) WaitForCompletion(ctx context.Context, operationID strin
 	return vc.WaitForCompletionWithTimeout(ctx, operationID, 5*time.Minute)
 }
 
-// WaitForCompletionWithTimeout waits for an operation to complete with a timeout
+// WaitForCompletionWithTimeout waits for an operation to complete with a timeout.
+// It follows the daemon's operation event stream and falls back to polling the
+// operation when the daemon has no event stream.
 func (vc *VolumeClient) WaitForCompletionWithTimeout(ctx context.Context, operationID string, timeout time.Duration) (*VolumeOperation, error) {
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
-
 	log.WithFields(log.Fields{
 		"operation_id": operationID,
 		"timeout":      timeout,
 	}).Info("Waiting for volume operation completion")
 
+	var lastEventID uint64
+	backoff := operationStreamBackoff
+	for !vc.eventsUnsupported.Load() {
+		resumeID := lastEventID
+		terminal, err := vc.followOperationEvents(ctx, operationID, &lastEventID)
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("operation timeout or cancelled: %w", ctx.Err())
+		}
+		if err != nil {
+			if errors.Is(err, errOperationEventsUnsupported) {
+				vc.eventsUnsupported.Store(true)
+				log.Info("Volume daemon has no operation event stream - polling instead")
+			} else {
+				log.WithError(err).Warn("Operation event stream failed - polling instead")
+			}
+			break
+		}
+
+		// The stream ended (terminal event, or the daemon dropped us) - check the operation
+		// before reconnecting from the last event ID
+		op, err := vc.GetOperation(ctx, operationID)
+		if err != nil {
+			log.WithError(err).Warn("Failed to get operation status - polling instead")
+			break
+		}
+		if done, err := operationResult(op); done {
+			return op, err
+		}
+		if terminal {
+			log.WithField("operation_id", operationID).Warn("Terminal operation event but operation still running - polling instead")
+			break
+		}
+
+		// Don't hammer a daemon that keeps closing the stream without sending anything
+		if lastEventID != resumeID {
+			backoff = operationStreamBackoff
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("operation timeout or cancelled: %w", ctx.Err())
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > operationStreamMaxBackoff {
+			backoff = operationStreamMaxBackoff
+		}
+	}
+
+	return vc.pollForCompletion(ctx, operationID)
+}
+
+// pollForCompletion polls the operation until it completes or fails
+func (vc *VolumeClient) pollForCompletion(ctx context.Context, operationID string) (*VolumeOperation, error) {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -282,23 +349,32 @@ func (vc *VolumeClient) WaitForCompletionWithTimeout(ctx context.Context, operat
 				"status":       op.Status,
 			}).Debug("Operation status check")
 
-			switch op.Status {
-			case "completed":
-				log.WithFields(log.Fields{
-					"operation_id": operationID,
-					"duration":     time.Since(op.CreatedAt),
-				}).Info("Volume operation completed successfully")
-				return op, nil
-			case "failed":
-				return op, fmt.Errorf("operation failed: %s", op.Error)
-			case "cancelled":
-				return op, fmt.Errorf("operation was cancelled")
+			if done, err := operationResult(op); done {
+				return op, err
 			}
 			// Continue waiting for pending/executing
 		}
 	}
 }
 
+// operationResult maps a finished operation to its result; done is false while it is
+// still pending or executing
+func operationResult(op *VolumeOperation) (bool, error) {
+	switch op.Status {
+	case "completed":
+		log.WithFields(log.Fields{
+			"operation_id": op.ID,
+			"duration":     time.Since(op.CreatedAt),
+		}).Info("Volume operation completed successfully")
+		return true, nil
+	case "failed":
+		return true, fmt.Errorf("operation failed: %s", op.Error)
+	case "cancelled":
+		return true, fmt.Errorf("operation was cancelled")
+	}
+	return false, nil
+}
+
 // HealthCheck checks if the volume daemon is healthy
 func (vc *VolumeClient) HealthCheck(ctx context.Context) error {
 	resp, err := vc.doRequest(ctx, "GET", "/health", nil)
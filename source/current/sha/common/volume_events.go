@@ -0,0 +1,141 @@
+package common
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// operationStreamBackoff is the first pause before reconnecting to an operation event
+	// stream the daemon closed; it doubles up to operationStreamMaxBackoff while
+	// reconnects deliver no events
+	operationStreamBackoff    = 250 * time.Millisecond
+	operationStreamMaxBackoff = 5 * time.Second
+)
+
+// errOperationEventsUnsupported is returned when the daemon predates the event stream
+var errOperationEventsUnsupported = errors.New("volume daemon does not serve operation events")
+
+// VolumeOperationEvent represents one lifecycle event of a volume operation
+type VolumeOperationEvent struct {
+	ID            uint64                 `json:"id"`
+	OperationID   string                 `json:"operation_id"`
+	OperationType string                 `json:"operation_type"`
+	EventType     string                 `json:"event_type"` // created, provider-submitted, device-detected, export-created, completed, failed
+	Status        string                 `json:"status"`
+	VolumeID      string                 `json:"volume_id"`
+	VMID          *string                `json:"vm_id"`
+	Data          map[string]interface{} `json:"data"`
+	Error         *string                `json:"error"`
+	CreatedAt     time.Time              `json:"created_at"`
+}
+
+// IsTerminal reports whether the event ends its operation
+func (e *VolumeOperationEvent) IsTerminal() bool {
+	return e.EventType == "completed" || e.EventType == "failed"
+}
+
+// GetVolumeEvents gets the recorded event trail of a volume after the given event ID
+func (vc *VolumeClient) GetVolumeEvents(ctx context.Context, volumeID string, afterID uint64) ([]VolumeOperationEvent, error) {
+	endpoint := fmt.Sprintf("/api/v1/volumes/%s/events?after=%d", volumeID, afterID)
+
+	resp, err := vc.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, vc.handleErrorResponse(resp)
+	}
+
+	var events []VolumeOperationEvent
+	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+		return nil, fmt.Errorf("failed to decode volume events: %w", err)
+	}
+
+	return events, nil
+}
+
+// followOperationEvents streams an operation's events, resuming after *lastEventID and
+// advancing it as events arrive. It returns true once a terminal event is seen, or false
+// with a nil error when the daemon closes the stream first.
+func (vc *VolumeClient) followOperationEvents(ctx context.Context, operationID string, lastEventID *uint64) (bool, error) {
+	endpoint := "/api/v1/events?operation_id=" + url.QueryEscape(operationID)
+	req, err := http.NewRequestWithContext(ctx, "GET", vc.baseURL+endpoint, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if *lastEventID > 0 {
+		req.Header.Set("Last-Event-ID", strconv.FormatUint(*lastEventID, 10))
+	}
+
+	resp, err := vc.streamClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to open operation event stream: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, errOperationEventsUnsupported
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, vc.handleErrorResponse(resp)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var eventID, data string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// Blank line ends the event
+			if data == "" {
+				continue
+			}
+			if id, err := strconv.ParseUint(eventID, 10, 64); err == nil {
+				*lastEventID = id
+			}
+
+			var event VolumeOperationEvent
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				log.WithError(err).Warn("Failed to decode operation event")
+			} else {
+				log.WithFields(log.Fields{
+					"operation_id": event.OperationID,
+					"event_type":   event.EventType,
+					"event_id":     event.ID,
+				}).Debug("Volume operation event")
+
+				if event.IsTerminal() {
+					return true, nil
+				}
+			}
+			eventID, data = "", ""
+		case strings.HasPrefix(line, ":"):
+			// Keepalive comment
+		case strings.HasPrefix(line, "id:"):
+			eventID = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+		case strings.HasPrefix(line, "data:"):
+			data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return false, fmt.Errorf("operation event stream interrupted: %w", err)
+	}
+	return false, nil
+}
@@ -32,6 +32,12 @@ type BackupJob struct {
 	ETASeconds          int        `gorm:"column:eta_seconds;default:0" json:"eta_seconds"`
 	ProgressPercent     float64    `gorm:"column:progress_percent;default:0.0" json:"progress_percent"`
 	LastTelemetryAt     *time.Time `gorm:"column:last_telemetry_at" json:"last_telemetry_at"`
+	// Snapshot consistency - requested by VM policy vs actually achieved after fallback
+	ConsistencyRequested      *string `gorm:"column:consistency_requested" json:"consistency_requested"`
+	ConsistencyLevel          *string `gorm:"column:consistency_level" json:"consistency_level"`
+	ConsistencyFallbackReason *string `gorm:"column:consistency_fallback_reason" json:"consistency_fallback_reason,omitempty"`
+	// VM configuration sidecar (CPU, memory, firmware, NICs, controllers, tags) captured at backup start
+	VMConfigPath        *string    `gorm:"column:vm_config_path" json:"vm_config_path,omitempty"`
 	CreatedAt           time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
 	StartedAt           *time.Time `gorm:"column:started_at" json:"started_at"`
 	CompletedAt         *time.Time `gorm:"column:completed_at" json:"completed_at"`
@@ -81,7 +87,7 @@ type BackupDisk struct {
 	QCOW2Path           *string    `gorm:"column:qcow2_path" json:"qcow2_path"`
 	BytesTransferred    int64      `gorm:"column:bytes_transferred;default:0" json:"bytes_transferred"`
 	ProgressPercent     float64    `gorm:"column:progress_percent;default:0.0" json:"progress_percent"` // Per-disk progress tracking
-	Status              string     `gorm:"column:status;not null;default:'pending'" json:"status"` // pending, running, completed, failed
+	Status              string     `gorm:"column:status;not null;default:'pending'" json:"status"` // pending, running, completed, failed, excluded, cancelled
 	ErrorMessage        *string    `gorm:"column:error_message" json:"error_message"`
 	CreatedAt           time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
 	CompletedAt         *time.Time `gorm:"column:completed_at" json:"completed_at"`
@@ -245,6 +251,20 @@ func (r *BackupJobRepository) GetLatestBackup(ctx context.Context, vmContextID s
 	return &job, nil
 }
 
+// GetPreviousWithVMConfig returns the most recent backup of a VM created before the given
+// backup that has a captured VM configuration
+func (r *BackupJobRepository) GetPreviousWithVMConfig(ctx context.Context, vmContextID string, before time.Time) (*BackupJob, error) {
+	var job BackupJob
+	result := r.conn.GetGormDB().WithContext(ctx).
+		Where("vm_context_id = ? AND created_at < ? AND vm_config_path IS NOT NULL", vmContextID, before).
+		Order("created_at DESC").
+		First(&job)
+	if result.Error != nil {
+		return nil, fmt.Errorf("no earlier backup with VM configuration found: %w", result.Error)
+	}
+	return &job, nil
+}
+
 // GetBackupStatistics retrieves backup statistics for a VM context
 func (r *BackupJobRepository) GetBackupStatistics(ctx context.Context, vmContextID string) (map[string]interface{}, error) {
 	var stats struct {
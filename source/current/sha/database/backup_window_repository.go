@@ -0,0 +1,216 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// =============================================================================
+// BACKUP WINDOW REPOSITORY - Backup windows and blackout periods
+// =============================================================================
+// Handles CRUD for the time rules that gate schedules and protection flows
+
+// Backup window scopes
+const (
+	WindowScopeGlobal   = "global"
+	WindowScopeSchedule = "schedule"
+	WindowScopeFlow     = "flow"
+)
+
+// Backup window rule types
+const (
+	WindowRuleWindow   = "window"
+	WindowRuleBlackout = "blackout"
+)
+
+// What happens to jobs that cannot start inside the window
+const (
+	WindowOutsideDefer = "defer"
+	WindowOutsideSkip  = "skip"
+)
+
+// What happens to running jobs when the window closes
+const (
+	WindowOverrunNone   = "none"
+	WindowOverrunPause  = "pause"
+	WindowOverrunCancel = "cancel"
+)
+
+// BackupWindowRepository handles all backup window database operations
+type BackupWindowRepository struct {
+	db *gorm.DB
+}
+
+// NewBackupWindowRepository creates a new backup window repository
+func NewBackupWindowRepository(conn Connection) *BackupWindowRepository {
+	return &BackupWindowRepository{
+		db: conn.GetGormDB(),
+	}
+}
+
+// BackupWindowFilters represents filtering options for backup window queries
+type BackupWindowFilters struct {
+	Scope   *string
+	ScopeID *string
+	Enabled *bool
+}
+
+// CreateWindow creates a new backup window
+func (r *BackupWindowRepository) CreateWindow(ctx context.Context, window *BackupWindow) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	log.WithFields(log.Fields{
+		"window_name": window.Name,
+		"scope":       window.Scope,
+		"rule_type":   window.RuleType,
+	}).Info("Creating backup window")
+
+	if err := r.db.Create(window).Error; err != nil {
+		log.WithError(err).WithField("window_name", window.Name).Error("Failed to create backup window")
+		return fmt.Errorf("failed to create backup window: %w", err)
+	}
+
+	return nil
+}
+
+// GetWindowByID retrieves a backup window by ID
+func (r *BackupWindowRepository) GetWindowByID(ctx context.Context, id string) (*BackupWindow, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var window BackupWindow
+	if err := r.db.Where("id = ?", id).First(&window).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("backup window not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get backup window: %w", err)
+	}
+
+	return &window, nil
+}
+
+// ListWindows retrieves backup windows with optional filtering
+func (r *BackupWindowRepository) ListWindows(ctx context.Context, filters BackupWindowFilters) ([]*BackupWindow, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var windows []*BackupWindow
+	query := r.db.Model(&BackupWindow{})
+
+	if filters.Scope != nil {
+		query = query.Where("scope = ?", *filters.Scope)
+	}
+	if filters.ScopeID != nil {
+		query = query.Where("scope_id = ?", *filters.ScopeID)
+	}
+	if filters.Enabled != nil {
+		query = query.Where("enabled = ?", *filters.Enabled)
+	}
+
+	if err := query.Order("scope ASC, name ASC").Find(&windows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list backup windows: %w", err)
+	}
+
+	return windows, nil
+}
+
+// GetApplicableWindows returns the enabled global rules plus the rules of one schedule or flow
+func (r *BackupWindowRepository) GetApplicableWindows(ctx context.Context, scope, scopeID string) ([]*BackupWindow, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var windows []*BackupWindow
+	if err := r.db.Where("enabled = ? AND (scope = ? OR (scope = ? AND scope_id = ?))",
+		true, WindowScopeGlobal, scope, scopeID).
+		Find(&windows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get backup windows for %s %s: %w", scope, scopeID, err)
+	}
+
+	return windows, nil
+}
+
+// UpdateWindow updates a backup window with the provided updates map
+func (r *BackupWindowRepository) UpdateWindow(ctx context.Context, id string, updates map[string]interface{}) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	updates["updated_at"] = time.Now()
+
+	result := r.db.Model(&BackupWindow{}).Where("id = ?", id).Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update backup window: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("backup window not found: %s", id)
+	}
+
+	return nil
+}
+
+// DeleteWindow deletes a backup window by ID
+func (r *BackupWindowRepository) DeleteWindow(ctx context.Context, id string) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	log.WithField("window_id", id).Info("Deleting backup window")
+
+	result := r.db.Where("id = ?", id).Delete(&BackupWindow{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete backup window: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("backup window not found: %s", id)
+	}
+
+	return nil
+}
+
+// RecordPausedJob remembers a job paused by the backup window enforcer
+func (r *BackupWindowRepository) RecordPausedJob(ctx context.Context, job *BackupWindowPausedJob) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	if err := r.db.WithContext(ctx).Save(job).Error; err != nil {
+		return fmt.Errorf("failed to record paused job: %w", err)
+	}
+	return nil
+}
+
+// ListPausedJobs retrieves the jobs paused by the backup window enforcer
+func (r *BackupWindowRepository) ListPausedJobs(ctx context.Context) ([]*BackupWindowPausedJob, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var jobs []*BackupWindowPausedJob
+	if err := r.db.WithContext(ctx).Order("paused_at").Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list paused jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// DeletePausedJob forgets a job paused by the backup window enforcer
+func (r *BackupWindowRepository) DeletePausedJob(ctx context.Context, jobID string) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	if err := r.db.WithContext(ctx).Where("job_id = ?", jobID).Delete(&BackupWindowPausedJob{}).Error; err != nil {
+		return fmt.Errorf("failed to delete paused job: %w", err)
+	}
+	return nil
+}
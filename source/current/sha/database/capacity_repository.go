@@ -0,0 +1,413 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// =============================================================================
+// CAPACITY REPOSITORY - Repository space reservations, history and alerts
+// =============================================================================
+// Backups hold a reservation on their repository from start until the backup job
+// settles. A reservation counts against free space only for what the job has not
+// written yet, so space is not counted twice as the backup fills the repository.
+
+// reservationGracePeriod is how long a reservation counts before its backup job row exists
+const reservationGracePeriod = 10 * time.Minute
+
+// activeBackupStatuses are backup_jobs states whose reservations are still held
+var activeBackupStatuses = []string{"pending", "running", "paused"}
+
+// CapacityRepository handles repository capacity database operations
+type CapacityRepository struct {
+	db *gorm.DB
+}
+
+// NewCapacityRepository creates a new capacity repository
+func NewCapacityRepository(conn Connection) *CapacityRepository {
+	return &CapacityRepository{
+		db: conn.GetGormDB(),
+	}
+}
+
+// RepositoryUsage is the last recorded capacity of a backup repository
+type RepositoryUsage struct {
+	ID                 string
+	Name               string
+	Enabled            bool
+	TotalSizeBytes     int64
+	UsedSizeBytes      int64
+	AvailableSizeBytes int64
+	LastCheckAt        *time.Time
+}
+
+// CapacityAlertFilters represents filtering options for capacity alert queries
+type CapacityAlertFilters struct {
+	RepositoryID *string
+	OpenOnly     bool
+	Limit        int
+}
+
+// DefaultCapacityPolicy returns the settings used for repositories without a stored policy
+func DefaultCapacityPolicy(repositoryID string) *RepositoryCapacityPolicy {
+	return &RepositoryCapacityPolicy{
+		RepositoryID:         repositoryID,
+		OnInsufficient:       CapacityOnInsufficientFail,
+		ReserveMarginPercent: 10,
+		WarnPercent:          80,
+		CriticalPercent:      90,
+		WarnDays:             14,
+	}
+}
+
+// =============================================================================
+// REPOSITORIES AND POLICIES
+// =============================================================================
+
+// ListRepositoryUsage retrieves the last recorded capacity of every repository
+func (r *CapacityRepository) ListRepositoryUsage(ctx context.Context) ([]RepositoryUsage, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var repos []RepositoryUsage
+	if err := r.db.WithContext(ctx).Table("backup_repositories").
+		Select("id, name, enabled, total_size_bytes, used_size_bytes, available_size_bytes, last_check_at").
+		Order("name ASC").
+		Scan(&repos).Error; err != nil {
+		return nil, fmt.Errorf("failed to list repositories: %w", err)
+	}
+	return repos, nil
+}
+
+// GetRepositoryUsage retrieves the last recorded capacity of one repository
+func (r *CapacityRepository) GetRepositoryUsage(ctx context.Context, repositoryID string) (*RepositoryUsage, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var repo RepositoryUsage
+	result := r.db.WithContext(ctx).Table("backup_repositories").
+		Select("id, name, enabled, total_size_bytes, used_size_bytes, available_size_bytes, last_check_at").
+		Where("id = ?", repositoryID).
+		Limit(1).
+		Scan(&repo)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get repository: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("repository not found: %s", repositoryID)
+	}
+	return &repo, nil
+}
+
+// GetPolicy retrieves the capacity policy of a repository (defaults when none is stored)
+func (r *CapacityRepository) GetPolicy(ctx context.Context, repositoryID string) (*RepositoryCapacityPolicy, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var policy RepositoryCapacityPolicy
+	if err := r.db.WithContext(ctx).Where("repository_id = ?", repositoryID).First(&policy).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return DefaultCapacityPolicy(repositoryID), nil
+		}
+		return nil, fmt.Errorf("failed to get capacity policy: %w", err)
+	}
+	return &policy, nil
+}
+
+// SavePolicy creates or replaces the capacity policy of a repository
+func (r *CapacityRepository) SavePolicy(ctx context.Context, policy *RepositoryCapacityPolicy) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "repository_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"on_insufficient", "alternate_repository_id", "reserve_margin_percent",
+			"warn_percent", "critical_percent", "warn_days", "updated_at",
+		}),
+	}).Create(policy).Error; err != nil {
+		return fmt.Errorf("failed to save capacity policy: %w", err)
+	}
+	return nil
+}
+
+// =============================================================================
+// SPACE RESERVATIONS
+// =============================================================================
+
+// ReserveSpace records a reservation if fits accepts it given the bytes already held by
+// other in-flight backups; the repository row is locked so concurrent starts queue up
+func (r *CapacityRepository) ReserveSpace(ctx context.Context, reservation *RepositorySpaceReservation, fits func(outstanding int64) error) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked struct{ ID string }
+		if err := tx.Table("backup_repositories").
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ?", reservation.RepositoryID).
+			Scan(&locked).Error; err != nil {
+			return fmt.Errorf("failed to lock repository: %w", err)
+		}
+		if locked.ID == "" {
+			return fmt.Errorf("repository not found: %s", reservation.RepositoryID)
+		}
+
+		outstanding, err := outstandingReservedBytes(tx, reservation.RepositoryID)
+		if err != nil {
+			return err
+		}
+		if err := fits(outstanding); err != nil {
+			return err
+		}
+
+		if err := tx.Create(reservation).Error; err != nil {
+			return fmt.Errorf("failed to create space reservation: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetOutstandingReservedBytes returns the space in-flight backups still expect to write to a repository
+func (r *CapacityRepository) GetOutstandingReservedBytes(ctx context.Context, repositoryID string) (int64, error) {
+	if r.db == nil {
+		return 0, fmt.Errorf("database not available")
+	}
+	return outstandingReservedBytes(r.db.WithContext(ctx), repositoryID)
+}
+
+// outstandingReservedBytes sums open reservations less what their backups have written so far
+func outstandingReservedBytes(db *gorm.DB, repositoryID string) (int64, error) {
+	var outstanding int64
+	if err := db.Table("repository_space_reservations r").
+		Select("COALESCE(SUM(GREATEST(r.reserved_bytes - COALESCE(j.bytes_transferred, 0), 0)), 0)").
+		Joins("LEFT JOIN backup_jobs j ON j.id = r.backup_job_id").
+		Where("r.repository_id = ? AND r.released_at IS NULL", repositoryID).
+		Where("j.status IN ? OR (j.id IS NULL AND r.created_at > ?)", activeBackupStatuses, time.Now().Add(-reservationGracePeriod)).
+		Scan(&outstanding).Error; err != nil {
+		return 0, fmt.Errorf("failed to sum space reservations: %w", err)
+	}
+	return outstanding, nil
+}
+
+// ReleaseReservation releases the space held for a backup job
+func (r *CapacityRepository) ReleaseReservation(ctx context.Context, backupJobID string) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	if err := r.db.WithContext(ctx).Model(&RepositorySpaceReservation{}).
+		Where("backup_job_id = ? AND released_at IS NULL", backupJobID).
+		Update("released_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("failed to release space reservation: %w", err)
+	}
+	return nil
+}
+
+// ReopenReservation holds the space of a resumed backup job again
+func (r *CapacityRepository) ReopenReservation(ctx context.Context, backupJobID string) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	if err := r.db.WithContext(ctx).Model(&RepositorySpaceReservation{}).
+		Where("backup_job_id = ?", backupJobID).
+		Update("released_at", nil).Error; err != nil {
+		return fmt.Errorf("failed to reopen space reservation: %w", err)
+	}
+	return nil
+}
+
+// ReleaseSettledReservations releases reservations whose backup has finished or never started
+func (r *CapacityRepository) ReleaseSettledReservations(ctx context.Context) (int64, error) {
+	if r.db == nil {
+		return 0, fmt.Errorf("database not available")
+	}
+
+	active := r.db.Table("backup_jobs").Select("id").Where("status IN ?", activeBackupStatuses)
+	existing := r.db.Table("backup_jobs").Select("id")
+
+	result := r.db.WithContext(ctx).Model(&RepositorySpaceReservation{}).
+		Where("released_at IS NULL").
+		Where("backup_job_id NOT IN (?)", active).
+		Where("backup_job_id IN (?) OR created_at <= ?", existing, time.Now().Add(-reservationGracePeriod)).
+		Update("released_at", time.Now())
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to release settled reservations: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// ListOpenReservations retrieves the reservations still held on a repository
+func (r *CapacityRepository) ListOpenReservations(ctx context.Context, repositoryID string) ([]*RepositorySpaceReservation, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var reservations []*RepositorySpaceReservation
+	if err := r.db.WithContext(ctx).
+		Where("repository_id = ? AND released_at IS NULL", repositoryID).
+		Order("created_at ASC").
+		Find(&reservations).Error; err != nil {
+		return nil, fmt.Errorf("failed to list space reservations: %w", err)
+	}
+	return reservations, nil
+}
+
+// =============================================================================
+// CAPACITY HISTORY
+// =============================================================================
+
+// RecordSample stores a capacity sample of a repository
+func (r *CapacityRepository) RecordSample(ctx context.Context, sample *RepositoryCapacitySample) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	if err := r.db.WithContext(ctx).Create(sample).Error; err != nil {
+		return fmt.Errorf("failed to record capacity sample: %w", err)
+	}
+	return nil
+}
+
+// GetSamples retrieves the capacity samples of a repository since a point in time, oldest first
+func (r *CapacityRepository) GetSamples(ctx context.Context, repositoryID string, since time.Time) ([]RepositoryCapacitySample, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var samples []RepositoryCapacitySample
+	if err := r.db.WithContext(ctx).
+		Where("repository_id = ? AND recorded_at >= ?", repositoryID, since).
+		Order("recorded_at ASC").
+		Find(&samples).Error; err != nil {
+		return nil, fmt.Errorf("failed to get capacity history: %w", err)
+	}
+	return samples, nil
+}
+
+// GetLastSampleTime returns when any repository was last sampled (nil if never)
+func (r *CapacityRepository) GetLastSampleTime(ctx context.Context) (*time.Time, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var last struct {
+		RecordedAt *time.Time
+	}
+	if err := r.db.WithContext(ctx).Table("repository_capacity_history").
+		Select("MAX(recorded_at) AS recorded_at").
+		Scan(&last).Error; err != nil {
+		return nil, fmt.Errorf("failed to get last capacity sample: %w", err)
+	}
+	return last.RecordedAt, nil
+}
+
+// PruneSamples deletes capacity samples older than a point in time
+func (r *CapacityRepository) PruneSamples(ctx context.Context, before time.Time) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	if err := r.db.WithContext(ctx).
+		Where("recorded_at < ?", before).
+		Delete(&RepositoryCapacitySample{}).Error; err != nil {
+		return fmt.Errorf("failed to prune capacity history: %w", err)
+	}
+	return nil
+}
+
+// =============================================================================
+// CAPACITY ALERTS
+// =============================================================================
+
+// CapacityAlertKey identifies an open alert by repository and type
+func CapacityAlertKey(repositoryID, alertType string) string {
+	return repositoryID + "/" + alertType
+}
+
+// GetOpenAlerts retrieves ongoing capacity alerts keyed by repository ID and alert type
+func (r *CapacityRepository) GetOpenAlerts(ctx context.Context) (map[string]*RepositoryCapacityAlert, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var alerts []*RepositoryCapacityAlert
+	if err := r.db.WithContext(ctx).Where("resolved_at IS NULL").Find(&alerts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get open capacity alerts: %w", err)
+	}
+
+	open := make(map[string]*RepositoryCapacityAlert, len(alerts))
+	for _, alert := range alerts {
+		open[CapacityAlertKey(alert.RepositoryID, alert.AlertType)] = alert
+	}
+	return open, nil
+}
+
+// CreateAlert records a repository crossing a capacity threshold
+func (r *CapacityRepository) CreateAlert(ctx context.Context, alert *RepositoryCapacityAlert) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	if err := r.db.WithContext(ctx).Create(alert).Error; err != nil {
+		return fmt.Errorf("failed to create capacity alert: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"alert_id":      alert.ID,
+		"repository_id": alert.RepositoryID,
+		"alert_type":    alert.AlertType,
+		"used_percent":  alert.UsedPercent,
+	}).Warn("Repository capacity alert raised")
+	return nil
+}
+
+// ResolveAlert closes a capacity alert once its condition has cleared
+func (r *CapacityRepository) ResolveAlert(ctx context.Context, id string, resolvedAt time.Time) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	if err := r.db.WithContext(ctx).Model(&RepositoryCapacityAlert{}).
+		Where("id = ? AND resolved_at IS NULL", id).
+		Update("resolved_at", resolvedAt).Error; err != nil {
+		return fmt.Errorf("failed to resolve capacity alert: %w", err)
+	}
+	return nil
+}
+
+// ListAlerts retrieves capacity alert history, newest first
+func (r *CapacityRepository) ListAlerts(ctx context.Context, filters CapacityAlertFilters) ([]*RepositoryCapacityAlert, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	query := r.db.WithContext(ctx).Model(&RepositoryCapacityAlert{})
+	if filters.RepositoryID != nil {
+		query = query.Where("repository_id = ?", *filters.RepositoryID)
+	}
+	if filters.OpenOnly {
+		query = query.Where("resolved_at IS NULL")
+	}
+	if filters.Limit > 0 {
+		query = query.Limit(filters.Limit)
+	}
+
+	var alerts []*RepositoryCapacityAlert
+	if err := query.Order("started_at DESC").Find(&alerts).Error; err != nil {
+		return nil, fmt.Errorf("failed to list capacity alerts: %w", err)
+	}
+	return alerts, nil
+}
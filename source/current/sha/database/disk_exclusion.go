@@ -0,0 +1,131 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// DISK EXCLUSIONS - Per-disk include/exclude for backups
+// =============================================================================
+// Exclusions are stored as JSON on VM contexts and protection flows and match
+// disks by VMware disk key or label, so they keep applying when disks are added
+// or reordered (new disks are included by default)
+
+// BackupDiskStatusExcluded marks a backup_disks row for a disk skipped by policy
+const BackupDiskStatusExcluded = "excluded"
+
+// DiskExclusion selects a VM disk to skip during backup
+type DiskExclusion struct {
+	DiskKey string `json:"disk_key,omitempty"` // VMware disk key, e.g. "2001" or "disk-2001"
+	Label   string `json:"label,omitempty"`    // VMware disk label, e.g. "Hard disk 3"
+	Reason  string `json:"reason,omitempty"`   // Free text, e.g. "TempDB scratch volume"
+}
+
+// Validate checks the exclusion identifies a disk
+func (e DiskExclusion) Validate() error {
+	if e.DiskKey == "" && e.Label == "" {
+		return fmt.Errorf("disk exclusion requires disk_key or label")
+	}
+	if e.DiskKey != "" {
+		if _, ok := VMwareDiskKey(e.DiskKey); !ok {
+			return fmt.Errorf("invalid disk_key: %s", e.DiskKey)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the exclusion selects the given disk
+func (e DiskExclusion) Matches(disk *VMDisk) bool {
+	if e.DiskKey != "" {
+		want, _ := VMwareDiskKey(e.DiskKey)
+		if got, ok := VMwareDiskKey(disk.DiskID); ok && got == want {
+			return true
+		}
+	}
+	return e.Label != "" && strings.EqualFold(e.Label, disk.Label)
+}
+
+// VMwareDiskKey parses a VMware disk key from "2001" or the discovery form "disk-2001"
+func VMwareDiskKey(id string) (int, bool) {
+	key, err := strconv.Atoi(strings.TrimPrefix(id, "disk-"))
+	if err != nil || key <= 0 {
+		return 0, false
+	}
+	return key, true
+}
+
+// ParseDiskExclusions decodes a JSON exclusion list column (NULL means no exclusions)
+func ParseDiskExclusions(raw *string) ([]DiskExclusion, error) {
+	if raw == nil || *raw == "" {
+		return nil, nil
+	}
+
+	var exclusions []DiskExclusion
+	if err := json.Unmarshal([]byte(*raw), &exclusions); err != nil {
+		return nil, fmt.Errorf("failed to parse excluded_disks: %w", err)
+	}
+	return exclusions, nil
+}
+
+// EncodeDiskExclusions validates and encodes an exclusion list for storage (empty list clears it)
+func EncodeDiskExclusions(exclusions []DiskExclusion) (*string, error) {
+	if len(exclusions) == 0 {
+		return nil, nil
+	}
+
+	for _, e := range exclusions {
+		if err := e.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := json.Marshal(exclusions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode excluded_disks: %w", err)
+	}
+	encoded := string(data)
+	return &encoded, nil
+}
+
+// IsDiskExcluded reports whether any exclusion selects the disk, returning the matching rule
+func IsDiskExcluded(disk *VMDisk, exclusions []DiskExclusion) (DiskExclusion, bool) {
+	for _, e := range exclusions {
+		if e.Matches(disk) {
+			return e, true
+		}
+	}
+	return DiskExclusion{}, false
+}
+
+// UpdateExcludedDisks replaces the disk exclusion list for a VM context
+func (r *VMReplicationContextRepository) UpdateExcludedDisks(contextID string, exclusions []DiskExclusion) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	encoded, err := EncodeDiskExclusions(exclusions)
+	if err != nil {
+		return err
+	}
+
+	result := r.db.Model(&VMReplicationContext{}).
+		Where("context_id = ?", contextID).
+		Updates(map[string]interface{}{
+			"excluded_disks": encoded,
+			"updated_at":     time.Now(),
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update excluded disks for %s: %w", contextID, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("VM context not found: %s", contextID)
+	}
+
+	return nil
+}
@@ -377,6 +377,32 @@ func (r *FlowRepository) UpdateExecutionStatus(ctx context.Context, id string, s
 	return nil
 }
 
+// AppendExecutionJob records a job started by an execution as soon as it exists, so an
+// interrupted execution knows which jobs it already started
+func (r *FlowRepository) AppendExecutionJob(ctx context.Context, executionID, jobID string) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	result := r.db.Model(&ProtectionFlowExecution{}).Where("id = ?", executionID).Updates(map[string]interface{}{
+		"created_job_ids": gorm.Expr("JSON_ARRAY_APPEND(COALESCE(created_job_ids, JSON_ARRAY()), '$', ?)", jobID),
+		"jobs_created":    gorm.Expr("jobs_created + 1"),
+	})
+	if result.Error != nil {
+		log.WithError(result.Error).WithFields(log.Fields{
+			"execution_id": executionID,
+			"job_id":       jobID,
+		}).Error("Failed to record execution job")
+		return fmt.Errorf("failed to record execution job: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("execution not found: %s", executionID)
+	}
+
+	return nil
+}
+
 // =============================================================================
 // QUERY METHODS
 // =============================================================================
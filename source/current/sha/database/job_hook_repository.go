@@ -0,0 +1,189 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// =============================================================================
+// JOB HOOK REPOSITORY - Pre/post job and snapshot hook definitions
+// =============================================================================
+// Handles CRUD for hooks attached to protection flows, schedules and failovers
+
+// Hook owner types
+const (
+	HookOwnerFlow     = "flow"
+	HookOwnerSchedule = "schedule"
+	HookOwnerFailover = "failover" // owner_id is the VM context_id being failed over
+)
+
+// Hook types
+const (
+	HookTypePreJob       = "pre_job"
+	HookTypePostJob      = "post_job"
+	HookTypePreSnapshot  = "pre_snapshot"
+	HookTypePostSnapshot = "post_snapshot"
+)
+
+// Hook failure policies
+const (
+	HookOnFailureFail     = "fail"
+	HookOnFailureContinue = "continue"
+)
+
+// JobHookRepository handles all job hook database operations
+type JobHookRepository struct {
+	db *gorm.DB
+}
+
+// NewJobHookRepository creates a new job hook repository
+func NewJobHookRepository(conn Connection) *JobHookRepository {
+	return &JobHookRepository{
+		db: conn.GetGormDB(),
+	}
+}
+
+// JobHookFilters represents filtering options for hook queries
+type JobHookFilters struct {
+	OwnerType *string
+	OwnerID   *string
+	HookType  *string
+	Enabled   *bool
+}
+
+// CreateHook creates a new job hook
+func (r *JobHookRepository) CreateHook(ctx context.Context, hook *JobHook) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	log.WithFields(log.Fields{
+		"hook_name":  hook.Name,
+		"owner_type": hook.OwnerType,
+		"owner_id":   hook.OwnerID,
+		"hook_type":  hook.HookType,
+	}).Info("Creating job hook")
+
+	if err := r.db.Create(hook).Error; err != nil {
+		log.WithError(err).WithField("hook_name", hook.Name).Error("Failed to create job hook")
+		return fmt.Errorf("failed to create job hook: %w", err)
+	}
+
+	return nil
+}
+
+// GetHookByID retrieves a hook by ID
+func (r *JobHookRepository) GetHookByID(ctx context.Context, id string) (*JobHook, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var hook JobHook
+	if err := r.db.Where("id = ?", id).First(&hook).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("job hook not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get job hook: %w", err)
+	}
+
+	return &hook, nil
+}
+
+// ListHooks retrieves hooks with optional filtering, in execution order
+func (r *JobHookRepository) ListHooks(ctx context.Context, filters JobHookFilters) ([]*JobHook, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var hooks []*JobHook
+	query := r.db.Model(&JobHook{})
+
+	if filters.OwnerType != nil {
+		query = query.Where("owner_type = ?", *filters.OwnerType)
+	}
+	if filters.OwnerID != nil {
+		query = query.Where("owner_id = ?", *filters.OwnerID)
+	}
+	if filters.HookType != nil {
+		query = query.Where("hook_type = ?", *filters.HookType)
+	}
+	if filters.Enabled != nil {
+		query = query.Where("enabled = ?", *filters.Enabled)
+	}
+
+	if err := query.Order("execution_order ASC, created_at ASC").Find(&hooks).Error; err != nil {
+		log.WithError(err).Error("Failed to list job hooks")
+		return nil, fmt.Errorf("failed to list job hooks: %w", err)
+	}
+
+	return hooks, nil
+}
+
+// GetEnabledHooks returns the enabled hooks of one type for an owner, in execution order
+func (r *JobHookRepository) GetEnabledHooks(ctx context.Context, ownerType, ownerID, hookType string) ([]*JobHook, error) {
+	enabled := true
+	return r.ListHooks(ctx, JobHookFilters{
+		OwnerType: &ownerType,
+		OwnerID:   &ownerID,
+		HookType:  &hookType,
+		Enabled:   &enabled,
+	})
+}
+
+// UpdateHook updates a hook with the provided updates map
+func (r *JobHookRepository) UpdateHook(ctx context.Context, id string, updates map[string]interface{}) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	updates["updated_at"] = time.Now()
+
+	result := r.db.Model(&JobHook{}).Where("id = ?", id).Updates(updates)
+	if result.Error != nil {
+		log.WithError(result.Error).WithField("hook_id", id).Error("Failed to update job hook")
+		return fmt.Errorf("failed to update job hook: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("job hook not found: %s", id)
+	}
+
+	return nil
+}
+
+// DeleteHook deletes a hook by ID
+func (r *JobHookRepository) DeleteHook(ctx context.Context, id string) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	log.WithField("hook_id", id).Info("Deleting job hook")
+
+	result := r.db.Where("id = ?", id).Delete(&JobHook{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete job hook: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("job hook not found: %s", id)
+	}
+
+	return nil
+}
+
+// DeleteHooksForOwner removes all hooks attached to an owner (used when a flow or schedule is deleted)
+func (r *JobHookRepository) DeleteHooksForOwner(ctx context.Context, ownerType, ownerID string) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	if err := r.db.Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).Delete(&JobHook{}).Error; err != nil {
+		return fmt.Errorf("failed to delete hooks for %s %s: %w", ownerType, ownerID, err)
+	}
+
+	return nil
+}
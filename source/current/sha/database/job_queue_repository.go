@@ -0,0 +1,360 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// =============================================================================
+// JOB QUEUE REPOSITORY - Durable queue of long-running SHA operations
+// =============================================================================
+// Jobs are claimed with a lease that the running worker extends through heartbeats.
+// All lease timing uses the database clock so every SHA instance agrees on expiry.
+
+// Job queue states
+const (
+	QueueStatePending   = "pending"
+	QueueStateRunning   = "running"
+	QueueStateSucceeded = "succeeded"
+	QueueStateFailed    = "failed"
+	QueueStateCancelled = "cancelled"
+)
+
+// ActiveQueueStates are the states of jobs that have not finished yet
+var ActiveQueueStates = []string{QueueStatePending, QueueStateRunning}
+
+// JobQueueRepository handles durable job queue database operations
+type JobQueueRepository struct {
+	db *gorm.DB
+}
+
+// NewJobQueueRepository creates a new job queue repository
+func NewJobQueueRepository(conn Connection) *JobQueueRepository {
+	return &JobQueueRepository{
+		db: conn.GetGormDB(),
+	}
+}
+
+// QueuedJobFilters represents filtering options for job queue queries
+type QueuedJobFilters struct {
+	Kind       *string
+	ResourceID *string
+	State      *string
+	Limit      int
+}
+
+// Enqueue stores a new pending job
+func (r *JobQueueRepository) Enqueue(ctx context.Context, job *QueuedJob) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	job.State = QueueStatePending
+	if err := r.db.WithContext(ctx).Create(job).Error; err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return nil
+}
+
+// GetJob retrieves a queued job by ID
+func (r *JobQueueRepository) GetJob(ctx context.Context, id string) (*QueuedJob, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var job QueuedJob
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("queued job not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get queued job: %w", err)
+	}
+	return &job, nil
+}
+
+// GetJobByIdempotencyKey retrieves the job enqueued with an idempotency key (nil if none)
+func (r *JobQueueRepository) GetJobByIdempotencyKey(ctx context.Context, key string) (*QueuedJob, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var jobs []QueuedJob
+	if err := r.db.WithContext(ctx).Where("idempotency_key = ?", key).Limit(1).Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get queued job by idempotency key: %w", err)
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	return &jobs[0], nil
+}
+
+// GetActiveJob retrieves an unfinished job of a kind for a resource (nil if none)
+func (r *JobQueueRepository) GetActiveJob(ctx context.Context, kind, resourceID string) (*QueuedJob, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var jobs []QueuedJob
+	if err := r.db.WithContext(ctx).
+		Where("kind = ? AND resource_id = ? AND state IN ?", kind, resourceID, ActiveQueueStates).
+		Order("created_at ASC").
+		Limit(1).
+		Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get active queued job: %w", err)
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	return &jobs[0], nil
+}
+
+// ListJobs retrieves queued jobs, newest first
+func (r *JobQueueRepository) ListJobs(ctx context.Context, filters QueuedJobFilters) ([]*QueuedJob, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	query := r.db.WithContext(ctx).Model(&QueuedJob{})
+	if filters.Kind != nil {
+		query = query.Where("kind = ?", *filters.Kind)
+	}
+	if filters.ResourceID != nil {
+		query = query.Where("resource_id = ?", *filters.ResourceID)
+	}
+	if filters.State != nil {
+		query = query.Where("state = ?", *filters.State)
+	}
+	if filters.Limit > 0 {
+		query = query.Limit(filters.Limit)
+	}
+
+	var jobs []*QueuedJob
+	if err := query.Order("created_at DESC").Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list queued jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// ClaimNext leases the oldest due pending job of the given kinds to a worker (nil if none)
+func (r *JobQueueRepository) ClaimNext(ctx context.Context, kinds []string, worker string, leaseTTL time.Duration) (*QueuedJob, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+
+	db := r.db.WithContext(ctx)
+	for {
+		var candidates []QueuedJob
+		if err := db.Select("id").
+			Where("state = ? AND kind IN ? AND run_at <= NOW(6) AND cancel_requested = ?", QueueStatePending, kinds, false).
+			Order("run_at ASC, created_at ASC").
+			Limit(1).
+			Find(&candidates).Error; err != nil {
+			return nil, fmt.Errorf("failed to find pending jobs: %w", err)
+		}
+		if len(candidates) == 0 {
+			return nil, nil
+		}
+
+		// Conditional update - another worker may have claimed the same job first
+		result := db.Exec(`UPDATE job_queue
+			SET state = ?, lease_owner = ?, lease_expires_at = NOW(6) + INTERVAL ? MICROSECOND,
+			    heartbeat_at = NOW(6), attempts = attempts + 1, started_at = NOW(6)
+			WHERE id = ? AND state = ?`,
+			QueueStateRunning, worker, leaseTTL.Microseconds(), candidates[0].ID, QueueStatePending)
+		if result.Error != nil {
+			return nil, fmt.Errorf("failed to claim job: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		return r.GetJob(ctx, candidates[0].ID)
+	}
+}
+
+// Heartbeat extends the lease of a running job; it reports whether the worker still
+// holds the lease and whether cancellation was requested
+func (r *JobQueueRepository) Heartbeat(ctx context.Context, id, worker string, leaseTTL time.Duration) (held bool, cancelRequested bool, err error) {
+	if r.db == nil {
+		return false, false, fmt.Errorf("database not available")
+	}
+
+	result := r.db.WithContext(ctx).Exec(`UPDATE job_queue
+		SET lease_expires_at = NOW(6) + INTERVAL ? MICROSECOND, heartbeat_at = NOW(6)
+		WHERE id = ? AND state = ? AND lease_owner = ?`,
+		leaseTTL.Microseconds(), id, QueueStateRunning, worker)
+	if result.Error != nil {
+		return false, false, fmt.Errorf("failed to heartbeat job: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return false, false, nil
+	}
+
+	var job QueuedJob
+	if err := r.db.WithContext(ctx).Select("cancel_requested").Where("id = ?", id).First(&job).Error; err != nil {
+		return true, false, fmt.Errorf("failed to read job cancellation: %w", err)
+	}
+	return true, job.CancelRequested, nil
+}
+
+// SaveCheckpoint records handler progress for a running job
+func (r *JobQueueRepository) SaveCheckpoint(ctx context.Context, id, worker, checkpoint string) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	result := r.db.WithContext(ctx).Model(&QueuedJob{}).
+		Where("id = ? AND state = ? AND lease_owner = ?", id, QueueStateRunning, worker).
+		Update("checkpoint", checkpoint)
+	if result.Error != nil {
+		return fmt.Errorf("failed to save job checkpoint: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("job %s is no longer leased by %s", id, worker)
+	}
+	return nil
+}
+
+// Finish moves a job leased by worker to a final state
+func (r *JobQueueRepository) Finish(ctx context.Context, id, worker, state string, lastError *string) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	result := r.db.WithContext(ctx).Model(&QueuedJob{}).
+		Where("id = ? AND state = ? AND lease_owner = ?", id, QueueStateRunning, worker).
+		Updates(map[string]interface{}{
+			"state":            state,
+			"last_error":       lastError,
+			"lease_owner":      nil,
+			"lease_expires_at": nil,
+			"completed_at":     time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to finish job: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("job %s is no longer leased by %s", id, worker)
+	}
+	return nil
+}
+
+// Reschedule returns a job leased by worker to pending so it is retried at runAt
+func (r *JobQueueRepository) Reschedule(ctx context.Context, id, worker string, runAt time.Time, lastError string) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	result := r.db.WithContext(ctx).Model(&QueuedJob{}).
+		Where("id = ? AND state = ? AND lease_owner = ?", id, QueueStateRunning, worker).
+		Updates(map[string]interface{}{
+			"state":            QueueStatePending,
+			"run_at":           runAt,
+			"last_error":       lastError,
+			"lease_owner":      nil,
+			"lease_expires_at": nil,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to reschedule job: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("job %s is no longer leased by %s", id, worker)
+	}
+	return nil
+}
+
+// GetExpiredJobs retrieves running jobs whose lease expired (their worker died)
+func (r *JobQueueRepository) GetExpiredJobs(ctx context.Context, kinds []string) ([]*QueuedJob, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var jobs []*QueuedJob
+	if err := r.db.WithContext(ctx).
+		Where("state = ? AND kind IN ? AND lease_expires_at <= NOW(6)", QueueStateRunning, kinds).
+		Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get expired jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// TakeOverExpired leases an abandoned job to worker so it can be compensated or retried
+func (r *JobQueueRepository) TakeOverExpired(ctx context.Context, id, worker string, leaseTTL time.Duration) (bool, error) {
+	if r.db == nil {
+		return false, fmt.Errorf("database not available")
+	}
+
+	result := r.db.WithContext(ctx).Exec(`UPDATE job_queue
+		SET lease_owner = ?, lease_expires_at = NOW(6) + INTERVAL ? MICROSECOND, heartbeat_at = NOW(6)
+		WHERE id = ? AND state = ? AND lease_expires_at <= NOW(6)`,
+		worker, leaseTTL.Microseconds(), id, QueueStateRunning)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to take over expired job: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RequestCancel cancels a pending job at once or flags a running job for cancellation;
+// it returns the job's state afterwards
+func (r *JobQueueRepository) RequestCancel(ctx context.Context, id string) (string, error) {
+	if r.db == nil {
+		return "", fmt.Errorf("database not available")
+	}
+
+	db := r.db.WithContext(ctx)
+	result := db.Model(&QueuedJob{}).
+		Where("id = ? AND state = ?", id, QueueStatePending).
+		Updates(map[string]interface{}{
+			"state":            QueueStateCancelled,
+			"cancel_requested": true,
+			"completed_at":     time.Now(),
+		})
+	if result.Error != nil {
+		return "", fmt.Errorf("failed to cancel job: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return QueueStateCancelled, nil
+	}
+
+	if err := db.Model(&QueuedJob{}).
+		Where("id = ? AND state = ?", id, QueueStateRunning).
+		Update("cancel_requested", true).Error; err != nil {
+		return "", fmt.Errorf("failed to request job cancellation: %w", err)
+	}
+
+	job, err := r.GetJob(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return job.State, nil
+}
+
+// Requeue makes a failed or cancelled job pending again with a fresh attempt budget
+func (r *JobQueueRepository) Requeue(ctx context.Context, id string) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	result := r.db.WithContext(ctx).Model(&QueuedJob{}).
+		Where("id = ? AND state IN ?", id, []string{QueueStateFailed, QueueStateCancelled}).
+		Updates(map[string]interface{}{
+			"state":            QueueStatePending,
+			"attempts":         0,
+			"cancel_requested": false,
+			"run_at":           time.Now(),
+			"completed_at":     nil,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to requeue job: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("only failed or cancelled jobs can be requeued")
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// =============================================================================
+// KVM SOURCES - libvirt domains backed up through the SNA
+// =============================================================================
+// A KVM VM context keeps the domain name in vm_name and vm_path and the domain
+// UUID in vmware_vm_id, so name-based lookups (change IDs, flows) work unchanged.
+// Disk IDs use the same "disk-<key>" form, with keys numbered like VMware's.
+
+// Source types of a VM context
+const (
+	SourceTypeVMware = "vmware"
+	SourceTypeKVM    = "kvm"
+)
+
+// KVMSource locates a libvirt domain and the NBD server libvirt serves its backup exports on
+type KVMSource struct {
+	LibvirtURI string `json:"libvirt_uri"`           // e.g. "qemu+ssh://root@kvm1/system"
+	Domain     string `json:"domain"`                // Domain name
+	DomainUUID string `json:"domain_uuid"`           // Stable across renames
+	NBDHost    string `json:"nbd_host,omitempty"`    // Address libvirt serves the backup exports on
+	NBDPort    int    `json:"nbd_port,omitempty"`    // Defaults to 10809
+	NBDSocket  string `json:"nbd_socket,omitempty"`  // Unix socket instead of nbd_host (local libvirt only)
+	ScratchDir string `json:"scratch_dir,omitempty"` // Backup scratch files on the KVM host
+}
+
+// Validate checks the source identifies a domain and an NBD server
+func (s KVMSource) Validate() error {
+	if s.Domain == "" {
+		return fmt.Errorf("kvm source requires domain")
+	}
+	if s.NBDHost == "" && s.NBDSocket == "" {
+		return fmt.Errorf("kvm source requires nbd_host or nbd_socket")
+	}
+	if s.NBDPort < 0 || s.NBDPort > 65535 {
+		return fmt.Errorf("invalid nbd_port: %d", s.NBDPort)
+	}
+	return nil
+}
+
+// IsKVM reports whether the VM context is a libvirt domain
+func (c *VMReplicationContext) IsKVM() bool {
+	return c.SourceType == SourceTypeKVM
+}
+
+// ParseKVMSource decodes the kvm_source column of a KVM VM context
+func ParseKVMSource(raw *string) (*KVMSource, error) {
+	if raw == nil || *raw == "" {
+		return nil, fmt.Errorf("kvm_source is not set")
+	}
+
+	var source KVMSource
+	if err := json.Unmarshal([]byte(*raw), &source); err != nil {
+		return nil, fmt.Errorf("failed to parse kvm_source: %w", err)
+	}
+	return &source, nil
+}
+
+// EncodeKVMSource validates and encodes a KVM source for storage
+func EncodeKVMSource(source KVMSource) (*string, error) {
+	if err := source.Validate(); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode kvm_source: %w", err)
+	}
+	encoded := string(data)
+	return &encoded, nil
+}
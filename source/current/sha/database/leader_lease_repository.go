@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// =============================================================================
+// LEADER LEASE REPOSITORY - DB-backed leases for SHA leader election
+// =============================================================================
+// All lease timing uses the database clock (NOW(6)) so SHA instances with
+// drifting clocks still agree on when a lease has expired
+
+// LeaderLeaseRepository handles leader lease database operations
+type LeaderLeaseRepository struct {
+	db *gorm.DB
+}
+
+// NewLeaderLeaseRepository creates a new leader lease repository
+func NewLeaderLeaseRepository(conn Connection) *LeaderLeaseRepository {
+	return &LeaderLeaseRepository{
+		db: conn.GetGormDB(),
+	}
+}
+
+// TryAcquire takes or renews a lease for holderID; it returns true while holderID
+// holds the lease. A lease held by another instance is only taken once it expired.
+func (r *LeaderLeaseRepository) TryAcquire(ctx context.Context, name, holderID string, ttl time.Duration) (bool, error) {
+	if r.db == nil {
+		return false, fmt.Errorf("database not available")
+	}
+
+	db := r.db.WithContext(ctx)
+
+	// First use of the lease name - create it already expired so anyone may take it
+	if err := db.Exec(
+		"INSERT IGNORE INTO leader_leases (name, holder_id, epoch, acquired_at, renewed_at, expires_at) VALUES (?, '', 0, NOW(6), NOW(6), NOW(6))",
+		name).Error; err != nil {
+		return false, fmt.Errorf("failed to initialize leader lease: %w", err)
+	}
+
+	// Assignments run left to right, so holder_id is compared before it is overwritten
+	result := db.Exec(`UPDATE leader_leases
+		SET epoch = IF(holder_id = ?, epoch, epoch + 1),
+		    acquired_at = IF(holder_id = ?, acquired_at, NOW(6)),
+		    renewed_at = NOW(6),
+		    expires_at = NOW(6) + INTERVAL ? MICROSECOND,
+		    holder_id = ?
+		WHERE name = ? AND (holder_id = ? OR expires_at <= NOW(6))`,
+		holderID, holderID, ttl.Microseconds(), holderID, name, holderID)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to acquire leader lease: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Release gives up a lease held by holderID so a standby can take over immediately
+func (r *LeaderLeaseRepository) Release(ctx context.Context, name, holderID string) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	if err := r.db.WithContext(ctx).Exec(
+		"UPDATE leader_leases SET expires_at = NOW(6) WHERE name = ? AND holder_id = ?",
+		name, holderID).Error; err != nil {
+		return fmt.Errorf("failed to release leader lease: %w", err)
+	}
+	return nil
+}
+
+// GetLease retrieves a lease by name (nil if it was never acquired)
+func (r *LeaderLeaseRepository) GetLease(ctx context.Context, name string) (*LeaderLease, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var leases []LeaderLease
+	if err := r.db.WithContext(ctx).Where("name = ?", name).Limit(1).Find(&leases).Error; err != nil {
+		return nil, fmt.Errorf("failed to get leader lease: %w", err)
+	}
+	if len(leases) == 0 {
+		return nil, nil
+	}
+	return &leases[0], nil
+}
+
+// LeaseExpired reports whether a lease has expired by the database clock
+func (r *LeaderLeaseRepository) LeaseExpired(ctx context.Context, name string) (bool, error) {
+	if r.db == nil {
+		return false, fmt.Errorf("database not available")
+	}
+
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&LeaderLease{}).
+		Where("name = ? AND expires_at > NOW(6)", name).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check leader lease: %w", err)
+	}
+	return count == 0, nil
+}
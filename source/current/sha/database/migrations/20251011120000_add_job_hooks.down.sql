@@ -0,0 +1,5 @@
+-- Migration: Drop Job Hooks Table
+-- Date: 2025-10-11
+-- Purpose: Reverse migration for job hooks
+
+DROP TABLE IF EXISTS job_hooks;
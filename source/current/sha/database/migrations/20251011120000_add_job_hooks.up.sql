@@ -0,0 +1,39 @@
+-- Migration: Add Job Hooks Table
+-- Date: 2025-10-11
+-- Purpose: Customer-defined scripts that run around backup, replication and failover jobs
+--         pre_job/post_job hooks run on the SHA, pre_snapshot/post_snapshot hooks run
+--         inside the guest via VMware Tools (driven by the SNA)
+
+CREATE TABLE IF NOT EXISTS job_hooks (
+    id VARCHAR(64) PRIMARY KEY DEFAULT (UUID()),
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+
+    -- Attachment point
+    owner_type ENUM('flow', 'schedule', 'failover') NOT NULL,
+    owner_id VARCHAR(64) NOT NULL,
+
+    -- Hook definition
+    hook_type ENUM('pre_job', 'post_job', 'pre_snapshot', 'post_snapshot') NOT NULL,
+    command TEXT NOT NULL,
+    timeout_seconds INT NOT NULL DEFAULT 300,
+    on_failure ENUM('fail', 'continue') NOT NULL DEFAULT 'fail',
+    execution_order INT NOT NULL DEFAULT 0,
+
+    -- Guest credentials (pre_snapshot/post_snapshot only)
+    guest_username VARCHAR(255),
+    guest_password_encrypted TEXT,
+
+    -- Control
+    enabled BOOLEAN DEFAULT true,
+
+    -- Metadata
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    created_by VARCHAR(255) DEFAULT 'system',
+
+    -- Indexes
+    INDEX idx_job_hooks_owner (owner_type, owner_id),
+    INDEX idx_job_hooks_type (hook_type),
+    INDEX idx_job_hooks_enabled (enabled)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
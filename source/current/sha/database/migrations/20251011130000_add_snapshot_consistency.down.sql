@@ -0,0 +1,14 @@
+-- Migration: Remove snapshot consistency policy and tracking
+-- Date: 2025-10-11
+-- Purpose: Reverse migration for snapshot consistency
+
+DROP INDEX idx_backup_jobs_consistency ON backup_jobs;
+
+ALTER TABLE backup_jobs
+    DROP COLUMN consistency_fallback_reason,
+    DROP COLUMN consistency_level,
+    DROP COLUMN consistency_requested;
+
+ALTER TABLE vm_replication_contexts
+    DROP COLUMN vss_backup_type,
+    DROP COLUMN consistency_level;
@@ -0,0 +1,17 @@
+-- Migration: Add snapshot consistency policy and achieved consistency tracking
+-- Date: 2025-10-11
+-- Purpose: Per-VM crash/filesystem/application (VSS) consistency policy, and record
+--          the consistency each backup actually achieved after automatic fallback
+
+-- Per-VM consistency policy (filesystem matches the previous --quiesce-snapshot default)
+ALTER TABLE vm_replication_contexts
+    ADD COLUMN consistency_level ENUM('crash','filesystem','application') NOT NULL DEFAULT 'filesystem' COMMENT 'Requested snapshot consistency level',
+    ADD COLUMN vss_backup_type ENUM('full','copy') NOT NULL DEFAULT 'copy' COMMENT 'VSS backup type for application-consistent snapshots';
+
+-- Requested vs achieved consistency on each restore point
+ALTER TABLE backup_jobs
+    ADD COLUMN consistency_requested VARCHAR(20) NULL COMMENT 'Consistency level requested by the VM policy',
+    ADD COLUMN consistency_level VARCHAR(20) NULL COMMENT 'Consistency level the snapshot actually achieved',
+    ADD COLUMN consistency_fallback_reason TEXT NULL COMMENT 'Why the requested consistency level was not achieved';
+
+CREATE INDEX idx_backup_jobs_consistency ON backup_jobs(consistency_level);
@@ -0,0 +1,14 @@
+-- Migration: Remove per-disk backup exclusions
+-- Date: 2025-10-11
+-- Purpose: Reverse migration for disk exclusions
+
+DELETE FROM backup_disks WHERE status = 'excluded';
+
+ALTER TABLE backup_disks
+    MODIFY COLUMN status ENUM('pending', 'running', 'completed', 'failed') DEFAULT 'pending';
+
+ALTER TABLE protection_flows
+    DROP COLUMN excluded_disks;
+
+ALTER TABLE vm_replication_contexts
+    DROP COLUMN excluded_disks;
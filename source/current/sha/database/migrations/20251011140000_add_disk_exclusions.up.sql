@@ -0,0 +1,15 @@
+-- Migration: Add per-disk backup exclusions
+-- Date: 2025-10-11
+-- Purpose: Let VM contexts and protection flows exclude disks (scratch, swap, temp volumes)
+--          from backups by VMware disk key or label, and record skipped disks per backup
+
+-- JSON list of {disk_key, label, reason} selectors
+ALTER TABLE vm_replication_contexts
+    ADD COLUMN excluded_disks JSON NULL COMMENT 'Disks excluded from backup (by VMware disk key or label)';
+
+ALTER TABLE protection_flows
+    ADD COLUMN excluded_disks JSON NULL COMMENT 'Disks excluded from backup for every VM in the flow';
+
+-- Excluded disks are recorded so restores can recreate them as empty volumes of the right size
+ALTER TABLE backup_disks
+    MODIFY COLUMN status ENUM('pending', 'running', 'completed', 'failed', 'excluded') DEFAULT 'pending';
@@ -0,0 +1,6 @@
+-- Migration: Remove VM configuration capture from backups
+-- Date: 2025-10-11
+-- Purpose: Reverse migration for backup VM configuration sidecars
+
+ALTER TABLE backup_jobs
+    DROP COLUMN vm_config_path;
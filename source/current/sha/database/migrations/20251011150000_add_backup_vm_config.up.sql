@@ -0,0 +1,7 @@
+-- Migration: Add VM configuration capture to backups
+-- Date: 2025-10-11
+-- Purpose: Record where each backup's VM configuration sidecar (CPU, memory, firmware,
+--          NICs, controllers, custom attributes, tags) is stored in the repository
+
+ALTER TABLE backup_jobs
+    ADD COLUMN vm_config_path VARCHAR(1024) NULL COMMENT 'Path to the VM configuration sidecar captured for this backup';
@@ -0,0 +1,5 @@
+-- Migration: Drop VMware Restore Jobs Table
+-- Date: 2025-10-11
+-- Purpose: Reverse migration for VMware restore jobs
+
+DROP TABLE IF EXISTS vmware_restore_jobs;
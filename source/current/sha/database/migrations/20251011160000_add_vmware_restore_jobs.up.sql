@@ -0,0 +1,37 @@
+-- Migration: Add VMware Restore Jobs Table
+-- Date: 2025-10-11
+-- Purpose: Track restores of backups back to VMware vSphere, either as a new VM built
+--          from the captured VM configuration or in place over the original VM's disks
+
+CREATE TABLE IF NOT EXISTS vmware_restore_jobs (
+    id VARCHAR(64) PRIMARY KEY,
+    backup_id VARCHAR(64) NOT NULL,
+    vm_context_id VARCHAR(191) NOT NULL,
+
+    -- Restore target
+    mode ENUM('new_vm', 'in_place') NOT NULL,
+    target_vm_name VARCHAR(255) NOT NULL,
+    target_vm_path VARCHAR(1024) NULL,
+
+    -- Progress (pushed by the backup client via telemetry)
+    status ENUM('pending', 'running', 'completed', 'failed') NOT NULL DEFAULT 'pending',
+    current_phase VARCHAR(64) NOT NULL DEFAULT 'pending',
+    bytes_transferred BIGINT NOT NULL DEFAULT 0,
+    total_bytes BIGINT NOT NULL DEFAULT 0,
+    progress_percent DECIMAL(5,2) NOT NULL DEFAULT 0.00,
+    transfer_speed_bps BIGINT NOT NULL DEFAULT 0,
+    error_message TEXT NULL,
+
+    -- Unified job log tracking
+    job_log_id VARCHAR(64) NULL,
+
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP NULL,
+    completed_at TIMESTAMP NULL,
+    last_telemetry_at TIMESTAMP NULL,
+
+    INDEX idx_vmware_restore_jobs_backup (backup_id),
+    INDEX idx_vmware_restore_jobs_context (vm_context_id),
+    INDEX idx_vmware_restore_jobs_status (status),
+    CONSTRAINT fk_vmware_restore_jobs_backup FOREIGN KEY (backup_id) REFERENCES backup_jobs(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
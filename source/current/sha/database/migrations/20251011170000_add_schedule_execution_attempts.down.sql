@@ -0,0 +1,9 @@
+-- Migration: Remove schedule execution attempts
+-- Date: 2025-10-11
+-- Purpose: Reverse migration for schedule retry and chain tracking
+
+ALTER TABLE schedule_executions
+    DROP INDEX idx_schedule_executions_parent,
+    DROP COLUMN outcome_evaluated_at,
+    DROP COLUMN parent_execution_id,
+    DROP COLUMN attempt;
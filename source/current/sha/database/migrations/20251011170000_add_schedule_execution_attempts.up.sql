@@ -0,0 +1,15 @@
+-- Migration: Add Schedule Execution Attempts
+-- Date: 2025-10-11
+-- Purpose: Record retry attempts and chained executions on schedule_executions so the
+--          scheduler can honour retry_attempts/retry_delay_minutes and chain schedules
+
+ALTER TABLE schedule_executions
+    ADD COLUMN attempt INT NOT NULL DEFAULT 1 COMMENT 'Attempt number (1 = original run, >1 = retry of failed VMs)' AFTER status,
+    ADD COLUMN parent_execution_id VARCHAR(64) NULL COMMENT 'Execution this one retries (retry) or follows (chain)' AFTER attempt,
+    ADD COLUMN outcome_evaluated_at TIMESTAMP NULL COMMENT 'When the outcome of the dispatched jobs was evaluated for retries/chains' AFTER completed_at,
+    ADD INDEX idx_schedule_executions_parent (parent_execution_id);
+
+-- Existing executions are already settled - never retry or chain them after upgrade
+UPDATE schedule_executions
+SET outcome_evaluated_at = COALESCE(completed_at, created_at)
+WHERE outcome_evaluated_at IS NULL;
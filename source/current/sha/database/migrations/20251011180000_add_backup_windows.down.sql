@@ -0,0 +1,5 @@
+-- Migration: Remove backup windows and blackout periods
+-- Date: 2025-10-11
+-- Purpose: Reverse migration for backup windows
+
+DROP TABLE IF EXISTS backup_windows;
@@ -0,0 +1,39 @@
+-- Migration: Add Backup Windows and Blackout Periods
+-- Date: 2025-10-11
+-- Purpose: Restrict when schedules and protection flows may start jobs. "window" rules
+--          allow jobs only inside recurring time ranges; "blackout" rules forbid jobs
+--          inside recurring ranges (e.g. month-end close) or fixed periods (change freezes)
+
+CREATE TABLE IF NOT EXISTS backup_windows (
+    id VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NULL,
+
+    -- What the rule applies to (global rules apply to every schedule and flow)
+    scope ENUM('global', 'schedule', 'flow') NOT NULL DEFAULT 'global',
+    scope_id VARCHAR(64) NULL COMMENT 'replication_schedules.id or protection_flows.id (NULL for global)',
+
+    rule_type ENUM('window', 'blackout') NOT NULL DEFAULT 'window',
+
+    -- Recurring range: start/end as HH:MM in the rule timezone (end before start crosses midnight)
+    days_of_week VARCHAR(64) NULL COMMENT 'Comma-separated mon..sun (NULL = every day)',
+    days_of_month VARCHAR(128) NULL COMMENT 'Comma-separated 1..31, negative counts from month end (-1 = last day)',
+    start_time CHAR(5) NULL,
+    end_time CHAR(5) NULL,
+    timezone VARCHAR(50) NOT NULL DEFAULT 'UTC',
+
+    -- Fixed blackout period (change freezes) - used instead of the recurring range when set
+    starts_at TIMESTAMP NULL,
+    ends_at TIMESTAMP NULL,
+
+    -- Behaviour
+    outside_action ENUM('defer', 'skip') NOT NULL DEFAULT 'defer' COMMENT 'Jobs that cannot start now',
+    overrun_action ENUM('none', 'pause', 'cancel') NOT NULL DEFAULT 'none' COMMENT 'Running jobs when the window closes',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX idx_backup_windows_scope (scope, scope_id),
+    INDEX idx_backup_windows_enabled (enabled)
+);
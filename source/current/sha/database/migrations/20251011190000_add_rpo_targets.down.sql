@@ -0,0 +1,9 @@
+-- Migration: Remove RPO targets and breach history
+-- Date: 2025-10-11
+-- Purpose: Reverse migration for RPO targets
+
+DROP TABLE IF EXISTS rpo_breaches;
+
+ALTER TABLE protection_flows
+    DROP INDEX idx_protection_flows_rpo,
+    DROP COLUMN rpo_minutes;
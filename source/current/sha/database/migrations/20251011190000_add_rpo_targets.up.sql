@@ -0,0 +1,34 @@
+-- Migration: Add RPO Targets and Breach History
+-- Date: 2025-10-11
+-- Purpose: Let protection flows carry an RPO target (maximum age of the newest restore point
+--          for every VM in the flow) that drives scheduling, and keep a history of RPO breaches
+--          per VM for SLA compliance reporting
+
+ALTER TABLE protection_flows
+    ADD COLUMN rpo_minutes INT NULL COMMENT 'RPO target - newest restore point must be younger than this (NULL = cron only)' AFTER schedule_id,
+    ADD INDEX idx_protection_flows_rpo (rpo_minutes);
+
+CREATE TABLE IF NOT EXISTS rpo_breaches (
+    id VARCHAR(64) NOT NULL PRIMARY KEY,
+    flow_id VARCHAR(64) NOT NULL COMMENT 'Protection flow whose RPO target was missed',
+    vm_context_id VARCHAR(64) NOT NULL COMMENT 'References vm_replication_contexts.context_id',
+    vm_name VARCHAR(255) NOT NULL,
+    rpo_minutes INT NOT NULL COMMENT 'RPO target in force when the breach started',
+
+    last_restore_point_at DATETIME NULL COMMENT 'Newest restore point when the breach started (NULL = never protected)',
+    breach_started_at DATETIME NOT NULL COMMENT 'When the newest restore point became older than the RPO',
+    resolved_at DATETIME NULL COMMENT 'When a new restore point brought the VM back within RPO (NULL = ongoing)',
+    resolved_by_restore_point_at DATETIME NULL,
+
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX idx_rpo_breaches_flow (flow_id),
+    INDEX idx_rpo_breaches_vm (vm_context_id),
+    INDEX idx_rpo_breaches_open (resolved_at),
+    INDEX idx_rpo_breaches_started (breach_started_at),
+
+    CONSTRAINT fk_rpo_breaches_flow FOREIGN KEY (flow_id)
+        REFERENCES protection_flows(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+COMMENT='History of VMs falling outside their protection flow RPO target';
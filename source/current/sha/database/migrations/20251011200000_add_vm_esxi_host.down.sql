@@ -0,0 +1,7 @@
+-- Migration: Remove ESXi host placement from VM contexts
+-- Date: 2025-10-11
+-- Purpose: Reverse migration for VM ESXi host placement
+
+ALTER TABLE vm_replication_contexts
+    DROP INDEX idx_vm_replication_contexts_esxi_host,
+    DROP COLUMN esxi_host;
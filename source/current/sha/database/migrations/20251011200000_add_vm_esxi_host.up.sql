@@ -0,0 +1,8 @@
+-- Migration: Add ESXi Host Placement to VM Contexts
+-- Date: 2025-10-11
+-- Purpose: Record the ESXi host a VM runs on (from SNA discovery) so job admission
+--          control can cap simultaneous snapshot/read jobs per host
+
+ALTER TABLE vm_replication_contexts
+    ADD COLUMN esxi_host VARCHAR(255) NULL COMMENT 'ESXi host the VM ran on at last discovery' AFTER datacenter,
+    ADD INDEX idx_vm_replication_contexts_esxi_host (esxi_host);
@@ -0,0 +1,5 @@
+-- Migration: Remove leader leases
+-- Date: 2025-10-11
+-- Purpose: Reverse migration for leader leases
+
+DROP TABLE IF EXISTS leader_leases;
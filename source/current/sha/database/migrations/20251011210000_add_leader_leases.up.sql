@@ -0,0 +1,18 @@
+-- Migration: Add Leader Leases
+-- Date: 2025-10-11
+-- Purpose: Let several SHA instances share one database in active/standby mode. The instance
+--          holding the control-plane lease is the leader and alone runs schedulers and
+--          background workers; the lease expires unless the leader keeps renewing it
+
+CREATE TABLE IF NOT EXISTS leader_leases (
+    name VARCHAR(64) NOT NULL PRIMARY KEY COMMENT 'Lease name (one per elected role)',
+    holder_id VARCHAR(191) NOT NULL DEFAULT '' COMMENT 'SHA instance holding the lease',
+    epoch BIGINT NOT NULL DEFAULT 0 COMMENT 'Incremented every time the lease changes holder',
+
+    acquired_at DATETIME(6) NOT NULL COMMENT 'When the current holder took the lease',
+    renewed_at DATETIME(6) NOT NULL COMMENT 'Last renewal by the current holder',
+    expires_at DATETIME(6) NOT NULL COMMENT 'Lease is free for takeover after this time',
+
+    INDEX idx_leader_leases_expires (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+COMMENT='DB-backed leases for SHA leader election';
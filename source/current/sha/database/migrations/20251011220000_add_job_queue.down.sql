@@ -0,0 +1,5 @@
+-- Migration: Remove durable job queue
+-- Date: 2025-10-11
+-- Purpose: Reverse migration for the durable job queue
+
+DROP TABLE IF EXISTS job_queue;
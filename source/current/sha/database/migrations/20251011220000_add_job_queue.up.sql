@@ -0,0 +1,39 @@
+-- Migration: Add Durable Job Queue
+-- Date: 2025-10-11
+-- Purpose: Run long-running SHA operations (protection flow executions) through a DB-backed
+--          queue instead of in-process goroutines, so they survive SHA restarts and leader
+--          failover. Running jobs hold a lease kept alive by heartbeats; a job whose lease
+--          expires is retried or compensated by the next worker
+
+CREATE TABLE IF NOT EXISTS job_queue (
+    id VARCHAR(64) NOT NULL PRIMARY KEY,
+    kind VARCHAR(64) NOT NULL COMMENT 'Operation type - selects the handler that runs the job',
+    resource_id VARCHAR(191) NULL COMMENT 'Object the job works on (e.g. protection flow ID)',
+    idempotency_key VARCHAR(191) NULL COMMENT 'Enqueueing the same key again returns the existing job',
+
+    payload JSON NULL COMMENT 'Handler input',
+    checkpoint JSON NULL COMMENT 'Progress recorded by the handler, used to resume or compensate',
+
+    state ENUM('pending', 'running', 'succeeded', 'failed', 'cancelled') NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 3,
+    run_at DATETIME(6) NOT NULL COMMENT 'Not started before this time (retry backoff)',
+
+    lease_owner VARCHAR(191) NULL COMMENT 'Worker running the job',
+    lease_expires_at DATETIME(6) NULL COMMENT 'Job is considered abandoned after this time',
+    heartbeat_at DATETIME(6) NULL,
+    cancel_requested BOOLEAN NOT NULL DEFAULT FALSE,
+
+    last_error TEXT NULL,
+    created_by VARCHAR(255) NULL,
+    started_at DATETIME(6) NULL,
+    completed_at DATETIME(6) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE INDEX idx_job_queue_idempotency (idempotency_key),
+    INDEX idx_job_queue_claim (state, run_at),
+    INDEX idx_job_queue_resource (kind, resource_id, state),
+    INDEX idx_job_queue_lease (state, lease_expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+COMMENT='Durable queue of long-running SHA operations';
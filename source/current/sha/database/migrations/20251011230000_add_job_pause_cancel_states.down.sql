@@ -0,0 +1,13 @@
+-- Migration: Remove job pause and cancel states
+-- Date: 2025-10-11
+-- Purpose: Reverse migration for job pause and cancel states
+
+UPDATE backup_disks SET status = 'failed' WHERE status = 'cancelled';
+
+ALTER TABLE backup_disks
+    MODIFY COLUMN status ENUM('pending', 'running', 'completed', 'failed', 'excluded') DEFAULT 'pending';
+
+UPDATE backup_jobs SET status = 'cancelled' WHERE status = 'paused';
+
+ALTER TABLE backup_jobs
+    MODIFY COLUMN status ENUM('pending', 'running', 'completed', 'failed', 'cancelled') NOT NULL DEFAULT 'pending';
@@ -0,0 +1,11 @@
+-- Migration: Add job pause and cancel states
+-- Date: 2025-10-11
+-- Purpose: Let operators and backup windows pause, resume and cancel running backups.
+--          Paused backups keep their VMware snapshot and qemu-nbd exports; cancelled
+--          backups record which disks were still in flight when they were stopped
+
+ALTER TABLE backup_jobs
+    MODIFY COLUMN status ENUM('pending', 'running', 'paused', 'completed', 'failed', 'cancelled') NOT NULL DEFAULT 'pending';
+
+ALTER TABLE backup_disks
+    MODIFY COLUMN status ENUM('pending', 'running', 'completed', 'failed', 'excluded', 'cancelled') DEFAULT 'pending';
@@ -0,0 +1,8 @@
+-- Migration: Remove repository capacity reservations, history and alerts
+-- Date: 2025-10-12
+-- Purpose: Reverse migration for repository capacity reservations and forecasting
+
+DROP TABLE IF EXISTS repository_capacity_alerts;
+DROP TABLE IF EXISTS repository_capacity_history;
+DROP TABLE IF EXISTS repository_space_reservations;
+DROP TABLE IF EXISTS repository_capacity_policies;
@@ -0,0 +1,82 @@
+-- Migration: Add Repository Capacity Reservations, History and Alerts
+-- Date: 2025-10-12
+-- Purpose: Reserve repository space when a backup starts (disk size for fulls, CBT delta
+--          estimate for incrementals) so backups that cannot fit fail fast or are redirected
+--          to an alternate repository, and keep capacity history per repository to forecast
+--          days-until-full and raise threshold alerts
+
+CREATE TABLE IF NOT EXISTS repository_capacity_policies (
+    repository_id VARCHAR(64) NOT NULL PRIMARY KEY,
+    on_insufficient ENUM('fail', 'redirect') NOT NULL DEFAULT 'fail' COMMENT 'What a backup that does not fit does',
+    alternate_repository_id VARCHAR(64) NULL COMMENT 'Repository backups are redirected to when on_insufficient = redirect',
+    reserve_margin_percent INT NOT NULL DEFAULT 10 COMMENT 'Added to every reservation for QCOW2 overhead and estimate error',
+    warn_percent INT NOT NULL DEFAULT 80 COMMENT 'Usage that raises a warning alert',
+    critical_percent INT NOT NULL DEFAULT 90 COMMENT 'Usage that raises a critical alert',
+    warn_days INT NOT NULL DEFAULT 14 COMMENT 'Forecast days-until-full that raises an alert',
+
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_capacity_policy_repository FOREIGN KEY (repository_id)
+        REFERENCES backup_repositories(id) ON DELETE CASCADE,
+    CONSTRAINT fk_capacity_policy_alternate FOREIGN KEY (alternate_repository_id)
+        REFERENCES backup_repositories(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+COMMENT='Space reservation and alerting settings per backup repository';
+
+CREATE TABLE IF NOT EXISTS repository_space_reservations (
+    id VARCHAR(64) NOT NULL PRIMARY KEY,
+    repository_id VARCHAR(64) NOT NULL,
+    backup_job_id VARCHAR(191) NOT NULL COMMENT 'Parent backup_jobs.id the space is held for',
+    vm_context_id VARCHAR(64) NOT NULL,
+    backup_type VARCHAR(32) NOT NULL,
+    reserved_bytes BIGINT NOT NULL COMMENT 'Estimate plus reserve margin',
+    estimate_source VARCHAR(32) NOT NULL COMMENT 'disk_size, cbt_history, backup_history or default_change_rate',
+    redirected_from VARCHAR(64) NULL COMMENT 'Repository the backup was requested for, if redirected',
+    released_at DATETIME NULL COMMENT 'NULL while the backup is in flight',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE INDEX idx_space_reservations_job (backup_job_id),
+    INDEX idx_space_reservations_open (repository_id, released_at),
+
+    CONSTRAINT fk_space_reservations_repository FOREIGN KEY (repository_id)
+        REFERENCES backup_repositories(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+COMMENT='Repository space held by in-flight backups';
+
+CREATE TABLE IF NOT EXISTS repository_capacity_history (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    repository_id VARCHAR(64) NOT NULL,
+    total_bytes BIGINT NOT NULL,
+    used_bytes BIGINT NOT NULL,
+    available_bytes BIGINT NOT NULL,
+    reserved_bytes BIGINT NOT NULL DEFAULT 0,
+    recorded_at DATETIME NOT NULL,
+
+    INDEX idx_capacity_history_repository (repository_id, recorded_at),
+
+    CONSTRAINT fk_capacity_history_repository FOREIGN KEY (repository_id)
+        REFERENCES backup_repositories(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+COMMENT='Periodic capacity samples used to forecast repository growth';
+
+CREATE TABLE IF NOT EXISTS repository_capacity_alerts (
+    id VARCHAR(64) NOT NULL PRIMARY KEY,
+    repository_id VARCHAR(64) NOT NULL,
+    alert_type ENUM('usage_warning', 'usage_critical', 'days_until_full') NOT NULL,
+    message VARCHAR(512) NOT NULL,
+    used_percent DOUBLE NOT NULL,
+    days_until_full DOUBLE NULL,
+    started_at DATETIME NOT NULL,
+    resolved_at DATETIME NULL COMMENT 'NULL while the condition persists',
+
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX idx_capacity_alerts_repository (repository_id, alert_type),
+    INDEX idx_capacity_alerts_open (resolved_at),
+
+    CONSTRAINT fk_capacity_alerts_repository FOREIGN KEY (repository_id)
+        REFERENCES backup_repositories(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+COMMENT='Repository usage and days-until-full threshold alerts';
@@ -0,0 +1,10 @@
+-- Migration: Remove multi-SNA registry and routing
+-- Date: 2025-10-12
+-- Purpose: Reverse migration for multi-SNA registry and routing
+
+ALTER TABLE vm_replication_contexts
+    DROP COLUMN cluster;
+
+DROP TABLE IF EXISTS sna_job_assignments;
+DROP TABLE IF EXISTS sna_scopes;
+DROP TABLE IF EXISTS sna_appliances;
@@ -0,0 +1,54 @@
+-- Migration: Add Multi-SNA Registry and Routing
+-- Date: 2025-10-12
+-- Purpose: Let many enrolled SNAs serve one SHA. Each SNA gets its own reverse tunnel
+--          port and registers the vCenters, datacenters and clusters it serves plus its
+--          job capacity; discovery, backup, replication, power and CBT calls are routed
+--          to an SNA serving the VM's placement, and the SNA that ran a job is recorded
+--          so progress and job control calls reach the same appliance
+
+CREATE TABLE IF NOT EXISTS sna_appliances (
+    id VARCHAR(64) NOT NULL PRIMARY KEY,
+    enrollment_id VARCHAR(64) NULL COMMENT 'vma_enrollments.id (NULL for the pre-enrollment tunnel)',
+    name VARCHAR(255) NOT NULL,
+    tunnel_port INT NOT NULL COMMENT 'SHA-side port of the SNA reverse tunnel to its API (8081)',
+    state ENUM('active', 'draining', 'disabled') NOT NULL DEFAULT 'active' COMMENT 'draining: finishes running jobs, takes no new ones',
+    max_concurrent_jobs INT NOT NULL DEFAULT 8 COMMENT 'Jobs the SNA runs at once (used for load balancing)',
+    version VARCHAR(64) NULL,
+    last_heartbeat_at DATETIME NULL COMMENT 'Last registration from the SNA itself',
+
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_sna_appliances_enrollment (enrollment_id),
+    UNIQUE KEY uk_sna_appliances_tunnel_port (tunnel_port)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+COMMENT='Source appliances reachable from this SHA';
+
+CREATE TABLE IF NOT EXISTS sna_scopes (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    sna_id VARCHAR(64) NOT NULL,
+    vcenter_host VARCHAR(255) NOT NULL,
+    datacenter VARCHAR(255) NOT NULL DEFAULT '' COMMENT 'Empty: every datacenter of the vCenter',
+    cluster VARCHAR(255) NOT NULL DEFAULT '' COMMENT 'Empty: every cluster of the datacenter',
+
+    UNIQUE KEY uk_sna_scopes (sna_id, vcenter_host, datacenter, cluster),
+    INDEX idx_sna_scopes_vcenter (vcenter_host),
+
+    CONSTRAINT fk_sna_scopes_appliance FOREIGN KEY (sna_id)
+        REFERENCES sna_appliances(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+COMMENT='vCenter placements each SNA serves';
+
+CREATE TABLE IF NOT EXISTS sna_job_assignments (
+    job_id VARCHAR(191) NOT NULL PRIMARY KEY,
+    sna_id VARCHAR(64) NOT NULL,
+    job_type VARCHAR(32) NOT NULL COMMENT 'backup, replication, restore',
+    vm_context_id VARCHAR(64) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_sna_job_assignments_sna (sna_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+COMMENT='SNA each job was routed to';
+
+ALTER TABLE vm_replication_contexts
+    ADD COLUMN cluster VARCHAR(255) NULL COMMENT 'vSphere cluster of the VM host at last discovery' AFTER esxi_host;
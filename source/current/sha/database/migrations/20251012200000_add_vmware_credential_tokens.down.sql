@@ -0,0 +1,5 @@
+-- Migration: Remove one-time VMware credential tokens
+-- Date: 2025-10-12
+-- Purpose: Reverse migration for one-time VMware credential tokens
+
+DROP TABLE IF EXISTS vmware_credential_tokens;
@@ -0,0 +1,19 @@
+-- Migration: Add One-Time VMware Credential Tokens
+-- Date: 2025-10-12
+-- Purpose: Let the SNA start the backup client with a credential reference instead of a
+--          plaintext vCenter password on its command line. The SHA issues a short-lived
+--          single-use token per job and the backup client redeems it over the tunnel.
+
+CREATE TABLE IF NOT EXISTS vmware_credential_tokens (
+    token_hash CHAR(64) NOT NULL PRIMARY KEY COMMENT 'SHA-256 of the token (the token itself is never stored)',
+    credential_id INT NOT NULL,
+    job_id VARCHAR(191) NULL COMMENT 'Job the token was issued for',
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL COMMENT 'Set when the token is redeemed',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_credential_tokens_expires (expires_at),
+    CONSTRAINT fk_credential_tokens_credential FOREIGN KEY (credential_id)
+        REFERENCES vmware_credentials(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+COMMENT='Single-use tokens that resolve a VMware credential for one job';
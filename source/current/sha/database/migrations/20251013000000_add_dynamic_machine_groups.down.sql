@@ -0,0 +1,15 @@
+-- Migration: Remove Rule-Based (Dynamic) Machine Groups
+-- Date: 2025-10-13
+-- Purpose: Reverse migration for dynamic machine group rules and VM vSphere metadata
+
+DROP TABLE IF EXISTS vm_group_membership_changes;
+
+ALTER TABLE vm_machine_groups
+    DROP COLUMN rules_evaluated_at,
+    DROP COLUMN membership_rules;
+
+ALTER TABLE vm_replication_contexts
+    DROP COLUMN datastores,
+    DROP COLUMN vsphere_tags,
+    DROP COLUMN resource_pool,
+    DROP COLUMN folder_path;
@@ -0,0 +1,34 @@
+-- Migration: Add Rule-Based (Dynamic) Machine Groups
+-- Date: 2025-10-13
+-- Purpose: Let a machine group select its VMs by rules over vSphere metadata (tags and
+--          categories, folder path, resource pool, cluster, datastore, name regex, guest
+--          OS). Discovery records that metadata on VM contexts and re-evaluates the rules,
+--          so new VMs tagged e.g. "backup:gold" join the group and are protected by the
+--          flow attached to it. Every membership change made by the rules is recorded.
+
+ALTER TABLE vm_replication_contexts
+    ADD COLUMN folder_path VARCHAR(1024) NULL COMMENT 'vCenter folder path at last discovery' AFTER cluster,
+    ADD COLUMN resource_pool VARCHAR(255) NULL COMMENT 'Resource pool at last discovery' AFTER folder_path,
+    ADD COLUMN vsphere_tags JSON NULL COMMENT 'Attached vSphere tags as "category:tag" at last discovery' AFTER resource_pool,
+    ADD COLUMN datastores JSON NULL COMMENT 'Datastores of the VM disks at last discovery' AFTER vsphere_tags;
+
+ALTER TABLE vm_machine_groups
+    ADD COLUMN membership_rules JSON NULL COMMENT 'Dynamic membership rules over vSphere metadata (NULL: static group)' AFTER priority,
+    ADD COLUMN rules_evaluated_at DATETIME NULL COMMENT 'Last evaluation of the membership rules' AFTER membership_rules;
+
+CREATE TABLE IF NOT EXISTS vm_group_membership_changes (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    group_id VARCHAR(64) NOT NULL,
+    vm_context_id VARCHAR(64) NOT NULL COMMENT 'vm_replication_contexts.context_id (kept after the VM context is removed)',
+    vm_name VARCHAR(255) NOT NULL,
+    action ENUM('added', 'removed') NOT NULL,
+    reason VARCHAR(255) NOT NULL COMMENT 'What triggered the evaluation: discovery, rules-updated, manual',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_vm_group_membership_changes_group (group_id, created_at),
+    INDEX idx_vm_group_membership_changes_vm (vm_context_id),
+
+    CONSTRAINT fk_vm_group_membership_changes_group FOREIGN KEY (group_id)
+        REFERENCES vm_machine_groups(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+COMMENT='Membership changes made by machine group rules';
@@ -0,0 +1,6 @@
+-- Migration: Remove Backup Chain Closing
+-- Date: 2025-10-14
+-- Purpose: Reverse migration for closed backup chains of removed disks
+
+ALTER TABLE backup_chains
+    DROP COLUMN closed_at;
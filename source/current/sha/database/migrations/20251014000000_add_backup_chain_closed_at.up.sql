@@ -0,0 +1,10 @@
+-- Migration: Close Backup Chains of Removed Disks
+-- Date: 2025-10-14
+-- Purpose: Backups react to disks added, removed or resized in vCenter since the last job.
+--          A removed disk's chain is closed instead of extended; its backups stay
+--          restorable until retention expires them. A new full backup reopens the chain.
+
+ALTER TABLE backup_chains
+    ADD COLUMN closed_at TIMESTAMP NULL DEFAULT NULL
+        COMMENT 'Set when the disk was removed from the VM'
+        AFTER updated_at;
@@ -0,0 +1,6 @@
+-- Migration: Remove Flow Execution Dispatch Tracking
+-- Date: 2025-10-15
+-- Purpose: Reverse migration for flow execution dispatch tracking
+
+ALTER TABLE protection_flow_executions
+    DROP COLUMN dispatched_at;
@@ -0,0 +1,16 @@
+-- Migration: Track Flow Execution Dispatch
+-- Date: 2025-10-15
+-- Purpose: Flow executions record each backup job in created_job_ids as it starts, so a
+--          retried execution skips VMs it already started. dispatched_at marks the point
+--          where every job has been started; the execution monitor only settles an
+--          execution after it.
+
+ALTER TABLE protection_flow_executions
+    ADD COLUMN dispatched_at TIMESTAMP NULL DEFAULT NULL
+        COMMENT 'Set once every job of the execution has been started'
+        AFTER schedule_execution_id;
+
+-- Executions from before this migration wrote created_job_ids only after dispatch
+UPDATE protection_flow_executions
+    SET dispatched_at = COALESCE(started_at, created_at)
+    WHERE created_job_ids IS NOT NULL;
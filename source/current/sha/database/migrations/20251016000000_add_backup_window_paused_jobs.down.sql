@@ -0,0 +1,5 @@
+-- Migration: Remove backup window paused jobs
+-- Date: 2025-10-16
+-- Purpose: Reverse migration for backup window paused jobs
+
+DROP TABLE IF EXISTS backup_window_paused_jobs;
@@ -0,0 +1,18 @@
+-- Migration: Add Backup Window Paused Jobs
+-- Date: 2025-10-16
+-- Purpose: Remember jobs the backup window enforcer paused at window close, so they are
+--          resumed when the window reopens even after an SHA restart or leader takeover
+
+CREATE TABLE IF NOT EXISTS backup_window_paused_jobs (
+    job_id VARCHAR(191) PRIMARY KEY,
+    job_type ENUM('replication', 'backup') NOT NULL,
+    vm_context_id VARCHAR(64) NOT NULL,
+
+    -- Schedule or flow that started the job (NULL = only global rules apply)
+    owner_scope ENUM('schedule', 'flow') NULL,
+    owner_id VARCHAR(64) NULL,
+
+    window_id VARCHAR(64) NULL COMMENT 'backup_windows.id of the rule that closed',
+    reason VARCHAR(512) NOT NULL DEFAULT '',
+    paused_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,7 @@
+-- Migration: Remove Schedule Execution Deferred Until
+-- Date: 2025-10-17
+-- Purpose: Reverse migration for schedule execution deferred_until
+
+ALTER TABLE schedule_executions
+    DROP INDEX idx_schedule_executions_deferred_until,
+    DROP COLUMN deferred_until;
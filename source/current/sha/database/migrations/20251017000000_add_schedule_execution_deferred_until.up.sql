@@ -0,0 +1,14 @@
+-- Migration: Add Schedule Execution Deferred Until
+-- Date: 2025-10-17
+-- Purpose: Mark the schedule executions the scheduler queued for a later start (retries,
+--          chains and backup window deferrals) so legacy "scheduled" rows are never run
+
+ALTER TABLE schedule_executions
+    ADD COLUMN deferred_until TIMESTAMP NULL COMMENT 'When a queued retry, chained or window-deferred run is due (NULL = not queued by the scheduler)' AFTER outcome_evaluated_at,
+    ADD INDEX idx_schedule_executions_deferred_until (deferred_until);
+
+-- Rows queued by the scheduler so far are the unsettled retries, chains and deferrals
+UPDATE schedule_executions
+SET deferred_until = scheduled_at
+WHERE status = 'scheduled'
+  AND triggered_by IN ('scheduler-retry', 'scheduler-chain', 'scheduler-window');
@@ -0,0 +1,7 @@
+-- Migration: Remove VM Source Type
+-- Date: 2025-10-18
+-- Purpose: Reverse migration for VM source type
+
+ALTER TABLE vm_replication_contexts
+    DROP COLUMN kvm_source,
+    DROP COLUMN source_type;
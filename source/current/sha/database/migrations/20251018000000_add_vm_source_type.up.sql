@@ -0,0 +1,8 @@
+-- Migration: Add VM Source Type
+-- Date: 2025-10-18
+-- Purpose: Let VM contexts describe KVM/libvirt domains as well as vCenter VMs, so
+--          backups of KVM domains are started by the SHA like VMware backups
+
+ALTER TABLE vm_replication_contexts
+    ADD COLUMN source_type ENUM('vmware','kvm') NOT NULL DEFAULT 'vmware' COMMENT 'Hypervisor the VM is backed up from' AFTER vm_path,
+    ADD COLUMN kvm_source JSON NULL COMMENT 'libvirt URI, domain and NBD server of a KVM VM (NULL for VMware)' AFTER source_type;
@@ -355,6 +355,12 @@ type VMReplicationContext struct {
 	VMPath              string     `json:"vm_path" gorm:"column:vm_path;not null;type:varchar(500)"`
 	VCenterHost         string     `json:"vcenter_host" gorm:"column:vcenter_host;not null;index"`
 	Datacenter          string     `json:"datacenter" gorm:"column:datacenter;not null"`
+	ESXiHost            *string    `json:"esxi_host" gorm:"column:esxi_host;type:varchar(255);index"` // Host at last discovery (admission control)
+	Cluster             *string    `json:"cluster" gorm:"column:cluster;type:varchar(255)"`           // Cluster at last discovery (SNA routing)
+	FolderPath          *string    `json:"folder_path" gorm:"column:folder_path;type:varchar(1024)"`  // vSphere metadata at last discovery (machine group rules)
+	ResourcePool        *string    `json:"resource_pool" gorm:"column:resource_pool;type:varchar(255)"`
+	VSphereTags         *string    `json:"vsphere_tags" gorm:"column:vsphere_tags;type:json"` // JSON list of "category:tag"
+	Datastores          *string    `json:"datastores" gorm:"column:datastores;type:json"`     // JSON list of disk datastores
 	CurrentStatus       string     `json:"current_status" gorm:"column:current_status;type:enum('discovered','replicating','ready_for_failover','failed_over_test','failed_over_live','completed','failed','cleanup_required');default:'discovered';index"`
 	CurrentJobID        *string    `json:"current_job_id" gorm:"column:current_job_id;type:varchar(191);index"`
 	TotalJobsRun        int        `json:"total_jobs_run" gorm:"column:total_jobs_run;default:0"`
@@ -384,6 +390,17 @@ type VMReplicationContext struct {
 	
 	// Operation Summary - For persistent visibility of failover/rollback operations
 	LastOperationSummary *string `json:"last_operation_summary" gorm:"column:last_operation_summary;type:json"`
+
+	// Snapshot Consistency Policy - crash, filesystem or application (VSS)
+	ConsistencyLevel string `json:"consistency_level" gorm:"column:consistency_level;type:enum('crash','filesystem','application');default:'filesystem'"`
+	VSSBackupType    string `json:"vss_backup_type" gorm:"column:vss_backup_type;type:enum('full','copy');default:'copy'"`
+
+	// Disk Selection - JSON list of DiskExclusion (by VMware disk key or label)
+	ExcludedDisks *string `json:"excluded_disks" gorm:"column:excluded_disks;type:json"`
+
+	// Source Hypervisor - KVM contexts carry a JSON KVMSource instead of vCenter details
+	SourceType string  `json:"source_type" gorm:"column:source_type;type:enum('vmware','kvm');default:'vmware'"`
+	KVMSource  *string `json:"kvm_source" gorm:"column:kvm_source;type:json"`
 }
 
 func (VMReplicationContext) TableName() string {
@@ -445,6 +462,10 @@ type VMMachineGroup struct {
 	MaxConcurrentVMs int `json:"max_concurrent_vms" gorm:"default:5"`
 	Priority         int `json:"priority" gorm:"default:0;index"`
 
+	// Dynamic membership - JSON MembershipRules over vSphere metadata (nil = static group)
+	MembershipRules  *string    `json:"membership_rules" gorm:"type:json"`
+	RulesEvaluatedAt *time.Time `json:"rules_evaluated_at"`
+
 	// Metadata
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
@@ -485,6 +506,21 @@ func (VMGroupMembership) TableName() string {
 	return "vm_group_memberships"
 }
 
+// VMGroupMembershipChange records a VM joining or leaving a group through its membership rules
+type VMGroupMembershipChange struct {
+	ID          int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	GroupID     string    `json:"group_id" gorm:"not null;type:varchar(64);index"`
+	VMContextID string    `json:"vm_context_id" gorm:"not null;type:varchar(64);index"`
+	VMName      string    `json:"vm_name" gorm:"not null;type:varchar(255)"`
+	Action      string    `json:"action" gorm:"type:enum('added','removed');not null"`
+	Reason      string    `json:"reason" gorm:"not null;type:varchar(255)"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (VMGroupMembershipChange) TableName() string {
+	return "vm_group_membership_changes"
+}
+
 // ScheduleExecution represents execution tracking for scheduled operations
 type ScheduleExecution struct {
 	ID         string  `json:"id" gorm:"primaryKey;type:varchar(64);default:uuid()"`
@@ -499,6 +535,12 @@ type ScheduleExecution struct {
 	// Execution status
 	Status string `json:"status" gorm:"type:enum('scheduled','running','completed','failed','skipped','cancelled');default:'scheduled';index"`
 
+	// Retry and chain tracking
+	Attempt            int        `json:"attempt" gorm:"default:1;comment:1 = original run, >1 = retry of failed VMs"`
+	ParentExecutionID  *string    `json:"parent_execution_id" gorm:"type:varchar(64);index;comment:Execution this one retries or is chained from"`
+	OutcomeEvaluatedAt *time.Time `json:"outcome_evaluated_at" gorm:"comment:When dispatched job outcomes were evaluated for retries/chains"`
+	DeferredUntil      *time.Time `json:"deferred_until" gorm:"index;comment:When a queued retry, chained or window-deferred run is due (NULL = not queued by the scheduler)"`
+
 	// Job statistics - tracks VMs by context_id
 	VMsEligible   int `json:"vms_eligible" gorm:"default:0"`
 	JobsCreated   int `json:"jobs_created" gorm:"default:0"`
@@ -559,6 +601,21 @@ type VMwareCredentials struct {
 	IsDefault   bool   `json:"is_default"`
 }
 
+// VMwareCredentialToken is a single-use token that resolves a VMware credential for one job
+type VMwareCredentialToken struct {
+	TokenHash    string     `json:"-" gorm:"column:token_hash;primaryKey;type:char(64)"`
+	CredentialID int        `json:"credential_id" gorm:"column:credential_id;not null"`
+	JobID        *string    `json:"job_id,omitempty" gorm:"column:job_id"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"column:expires_at;not null"`
+	UsedAt       *time.Time `json:"used_at,omitempty" gorm:"column:used_at"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+// TableName specifies the table name for VMwareCredentialToken
+func (VMwareCredentialToken) TableName() string {
+	return "vmware_credential_tokens"
+}
+
 // BackupRepository represents a backup storage repository
 type BackupRepository struct {
 	ID          string `json:"id" gorm:"primaryKey;type:varchar(64)"`
@@ -610,6 +667,10 @@ type ProtectionFlow struct {
 
 	// Scheduling
 	ScheduleID *string `json:"schedule_id" gorm:"type:varchar(64);index"`
+	RPOMinutes *int    `json:"rpo_minutes" gorm:"column:rpo_minutes;index"` // RPO target - schedules runs from the newest restore point
+
+	// Disk selection - JSON list of DiskExclusion applied to every VM in the flow
+	ExcludedDisks *string `json:"excluded_disks" gorm:"column:excluded_disks;type:json"`
 
 	// Control
 	Enabled bool `json:"enabled" gorm:"default:true;index"`
@@ -666,8 +727,9 @@ type ProtectionFlowExecution struct {
 	ExecutionMetadata *string `json:"execution_metadata" gorm:"type:json"`
 
 	// Links
-	CreatedJobIDs        *string `json:"created_job_ids" gorm:"type:json"`
-	ScheduleExecutionID  *string `json:"schedule_execution_id" gorm:"type:varchar(64)"`
+	CreatedJobIDs       *string    `json:"created_job_ids" gorm:"type:json"` // Appended as each job starts
+	ScheduleExecutionID *string    `json:"schedule_execution_id" gorm:"type:varchar(64)"`
+	DispatchedAt        *time.Time `json:"dispatched_at"` // Set once every job of the execution has been started
 
 	// Metadata
 	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
@@ -680,3 +742,303 @@ type ProtectionFlowExecution struct {
 func (ProtectionFlowExecution) TableName() string {
 	return "protection_flow_executions"
 }
+
+// JobHook is a customer-defined script that runs around backup, replication and failover jobs
+// pre_job/post_job hooks run on the SHA; pre_snapshot/post_snapshot hooks run in the guest via VMware Tools
+type JobHook struct {
+	ID          string  `json:"id" gorm:"primaryKey;type:varchar(64);default:uuid()"`
+	Name        string  `json:"name" gorm:"not null;type:varchar(255)"`
+	Description *string `json:"description" gorm:"type:text"`
+
+	// Attachment point
+	OwnerType string `json:"owner_type" gorm:"type:enum('flow','schedule','failover');not null"`
+	OwnerID   string `json:"owner_id" gorm:"type:varchar(64);not null;index"`
+
+	// Hook definition
+	HookType       string `json:"hook_type" gorm:"type:enum('pre_job','post_job','pre_snapshot','post_snapshot');not null;index"`
+	Command        string `json:"command" gorm:"type:text;not null"`
+	TimeoutSeconds int    `json:"timeout_seconds" gorm:"not null;default:300"`
+	OnFailure      string `json:"on_failure" gorm:"type:enum('fail','continue');not null;default:'fail'"`
+	ExecutionOrder int    `json:"execution_order" gorm:"not null;default:0"`
+
+	// Guest credentials (pre_snapshot/post_snapshot only)
+	GuestUsername          *string `json:"guest_username,omitempty" gorm:"type:varchar(255)"`
+	GuestPasswordEncrypted *string `json:"-" gorm:"type:text"`
+
+	// Control
+	Enabled bool `json:"enabled" gorm:"default:true;index"`
+
+	// Metadata
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	CreatedBy string    `json:"created_by" gorm:"default:'system';type:varchar(255)"`
+}
+
+func (JobHook) TableName() string {
+	return "job_hooks"
+}
+
+// BackupWindow restricts when schedules and protection flows may start jobs
+// "window" rules allow jobs only inside their range; "blackout" rules forbid jobs inside it
+type BackupWindow struct {
+	ID          string  `json:"id" gorm:"primaryKey;type:varchar(64);default:uuid()"`
+	Name        string  `json:"name" gorm:"not null;type:varchar(255)"`
+	Description *string `json:"description" gorm:"type:text"`
+
+	// Attachment point (global rules apply to every schedule and flow)
+	Scope   string  `json:"scope" gorm:"type:enum('global','schedule','flow');not null;default:'global'"`
+	ScopeID *string `json:"scope_id" gorm:"type:varchar(64);index"`
+
+	RuleType string `json:"rule_type" gorm:"type:enum('window','blackout');not null;default:'window'"`
+
+	// Recurring range in the rule timezone (end_time before start_time crosses midnight)
+	DaysOfWeek  *string `json:"days_of_week" gorm:"type:varchar(64)"`   // "mon,tue,..." (NULL = every day)
+	DaysOfMonth *string `json:"days_of_month" gorm:"type:varchar(128)"` // "1,15,-1" (-1 = last day of month)
+	StartTime   *string `json:"start_time" gorm:"type:char(5)"`         // "HH:MM"
+	EndTime     *string `json:"end_time" gorm:"type:char(5)"`           // "HH:MM"
+	Timezone    string  `json:"timezone" gorm:"type:varchar(50);not null;default:'UTC'"`
+
+	// Fixed period (change freezes) - used instead of the recurring range when set
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+
+	// Behaviour
+	OutsideAction string `json:"outside_action" gorm:"type:enum('defer','skip');not null;default:'defer'"`
+	OverrunAction string `json:"overrun_action" gorm:"type:enum('none','pause','cancel');not null;default:'none'"`
+	Enabled       bool   `json:"enabled" gorm:"default:true;index"`
+
+	// Metadata
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (BackupWindow) TableName() string {
+	return "backup_windows"
+}
+
+// BackupWindowPausedJob is a job the backup window enforcer paused when its window
+// closed; it is resumed once the window reopens
+type BackupWindowPausedJob struct {
+	JobID       string  `json:"job_id" gorm:"primaryKey;type:varchar(191)"`
+	JobType     string  `json:"job_type" gorm:"type:enum('replication','backup');not null"`
+	VMContextID string  `json:"vm_context_id" gorm:"type:varchar(64);not null"`
+	OwnerScope  *string `json:"owner_scope" gorm:"type:enum('schedule','flow')"` // NULL = only global rules apply
+	OwnerID     *string `json:"owner_id" gorm:"type:varchar(64)"`
+	WindowID    *string `json:"window_id" gorm:"type:varchar(64)"` // Rule that closed
+	Reason      string  `json:"reason" gorm:"type:varchar(512);not null;default:''"`
+
+	PausedAt time.Time `json:"paused_at" gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for BackupWindowPausedJob
+func (BackupWindowPausedJob) TableName() string {
+	return "backup_window_paused_jobs"
+}
+
+// RPOBreach records a VM whose newest restore point was older than its flow's RPO target
+type RPOBreach struct {
+	ID          string `json:"id" gorm:"primaryKey;type:varchar(64)"`
+	FlowID      string `json:"flow_id" gorm:"type:varchar(64);not null;index"`
+	VMContextID string `json:"vm_context_id" gorm:"type:varchar(64);not null;index"`
+	VMName      string `json:"vm_name" gorm:"type:varchar(255);not null"`
+	RPOMinutes  int    `json:"rpo_minutes" gorm:"not null"`
+
+	// Breach timing - resolved_at is NULL while the VM is still outside its RPO
+	LastRestorePointAt       *time.Time `json:"last_restore_point_at"`
+	BreachStartedAt          time.Time  `json:"breach_started_at" gorm:"not null;index"`
+	ResolvedAt               *time.Time `json:"resolved_at" gorm:"index"`
+	ResolvedByRestorePointAt *time.Time `json:"resolved_by_restore_point_at"`
+
+	// Metadata
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (RPOBreach) TableName() string {
+	return "rpo_breaches"
+}
+
+// LeaderLease is a DB-backed lease that elects one SHA instance for a role
+type LeaderLease struct {
+	Name     string `json:"name" gorm:"primaryKey;type:varchar(64)"`
+	HolderID string `json:"holder_id" gorm:"type:varchar(191);not null;default:''"`
+	Epoch    int64  `json:"epoch" gorm:"not null;default:0"` // Incremented on every change of holder
+
+	AcquiredAt time.Time `json:"acquired_at" gorm:"type:datetime(6);not null"`
+	RenewedAt  time.Time `json:"renewed_at" gorm:"type:datetime(6);not null"`
+	ExpiresAt  time.Time `json:"expires_at" gorm:"type:datetime(6);not null;index"`
+}
+
+func (LeaderLease) TableName() string {
+	return "leader_leases"
+}
+
+// QueuedJob is a long-running SHA operation in the durable job queue
+type QueuedJob struct {
+	ID             string  `json:"id" gorm:"primaryKey;type:varchar(64)"`
+	Kind           string  `json:"kind" gorm:"type:varchar(64);not null"`
+	ResourceID     *string `json:"resource_id,omitempty" gorm:"type:varchar(191)"`
+	IdempotencyKey *string `json:"idempotency_key,omitempty" gorm:"type:varchar(191);uniqueIndex"`
+
+	Payload    *string `json:"payload,omitempty" gorm:"type:json"`
+	Checkpoint *string `json:"checkpoint,omitempty" gorm:"type:json"`
+
+	State       string    `json:"state" gorm:"type:enum('pending','running','succeeded','failed','cancelled');not null;default:'pending'"`
+	Attempts    int       `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts int       `json:"max_attempts" gorm:"not null;default:3"`
+	RunAt       time.Time `json:"run_at" gorm:"type:datetime(6);not null"`
+
+	// Lease held by the worker running the job, extended by heartbeats
+	LeaseOwner      *string    `json:"lease_owner,omitempty" gorm:"type:varchar(191)"`
+	LeaseExpiresAt  *time.Time `json:"lease_expires_at,omitempty" gorm:"type:datetime(6)"`
+	HeartbeatAt     *time.Time `json:"heartbeat_at,omitempty" gorm:"type:datetime(6)"`
+	CancelRequested bool       `json:"cancel_requested" gorm:"not null;default:false"`
+
+	LastError   *string    `json:"last_error,omitempty" gorm:"type:text"`
+	CreatedBy   *string    `json:"created_by,omitempty" gorm:"type:varchar(255)"`
+	StartedAt   *time.Time `json:"started_at,omitempty" gorm:"type:datetime(6)"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" gorm:"type:datetime(6)"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (QueuedJob) TableName() string {
+	return "job_queue"
+}
+
+// Repository capacity policy actions for backups that do not fit
+const (
+	CapacityOnInsufficientFail     = "fail"
+	CapacityOnInsufficientRedirect = "redirect"
+)
+
+// Repository capacity alert types
+const (
+	CapacityAlertUsageWarning  = "usage_warning"
+	CapacityAlertUsageCritical = "usage_critical"
+	CapacityAlertDaysUntilFull = "days_until_full"
+)
+
+// RepositoryCapacityPolicy holds space reservation and alerting settings of a backup repository
+type RepositoryCapacityPolicy struct {
+	RepositoryID          string  `json:"repository_id" gorm:"primaryKey;type:varchar(64)"`
+	OnInsufficient        string  `json:"on_insufficient" gorm:"type:enum('fail','redirect');not null;default:'fail'"`
+	AlternateRepositoryID *string `json:"alternate_repository_id,omitempty" gorm:"type:varchar(64)"`
+	ReserveMarginPercent  int     `json:"reserve_margin_percent" gorm:"not null;default:10"`
+	WarnPercent           int     `json:"warn_percent" gorm:"not null;default:80"`
+	CriticalPercent       int     `json:"critical_percent" gorm:"not null;default:90"`
+	WarnDays              int     `json:"warn_days" gorm:"not null;default:14"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (RepositoryCapacityPolicy) TableName() string {
+	return "repository_capacity_policies"
+}
+
+// RepositorySpaceReservation is repository space held by an in-flight backup
+type RepositorySpaceReservation struct {
+	ID             string     `json:"id" gorm:"primaryKey;type:varchar(64)"`
+	RepositoryID   string     `json:"repository_id" gorm:"type:varchar(64);not null;index"`
+	BackupJobID    string     `json:"backup_job_id" gorm:"type:varchar(191);not null;uniqueIndex"`
+	VMContextID    string     `json:"vm_context_id" gorm:"type:varchar(64);not null"`
+	BackupType     string     `json:"backup_type" gorm:"type:varchar(32);not null"`
+	ReservedBytes  int64      `json:"reserved_bytes" gorm:"not null"`
+	EstimateSource string     `json:"estimate_source" gorm:"type:varchar(32);not null"`
+	RedirectedFrom *string    `json:"redirected_from,omitempty" gorm:"type:varchar(64)"`
+	ReleasedAt     *time.Time `json:"released_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (RepositorySpaceReservation) TableName() string {
+	return "repository_space_reservations"
+}
+
+// RepositoryCapacitySample is a point-in-time capacity record of a backup repository
+type RepositoryCapacitySample struct {
+	ID             int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	RepositoryID   string    `json:"repository_id" gorm:"type:varchar(64);not null;index"`
+	TotalBytes     int64     `json:"total_bytes" gorm:"not null"`
+	UsedBytes      int64     `json:"used_bytes" gorm:"not null"`
+	AvailableBytes int64     `json:"available_bytes" gorm:"not null"`
+	ReservedBytes  int64     `json:"reserved_bytes" gorm:"not null;default:0"`
+	RecordedAt     time.Time `json:"recorded_at" gorm:"not null"`
+}
+
+func (RepositoryCapacitySample) TableName() string {
+	return "repository_capacity_history"
+}
+
+// RepositoryCapacityAlert records a repository crossing a usage or days-until-full threshold
+type RepositoryCapacityAlert struct {
+	ID            string     `json:"id" gorm:"primaryKey;type:varchar(64)"`
+	RepositoryID  string     `json:"repository_id" gorm:"type:varchar(64);not null;index"`
+	AlertType     string     `json:"alert_type" gorm:"type:enum('usage_warning','usage_critical','days_until_full');not null"`
+	Message       string     `json:"message" gorm:"type:varchar(512);not null"`
+	UsedPercent   float64    `json:"used_percent" gorm:"not null"`
+	DaysUntilFull *float64   `json:"days_until_full,omitempty"`
+	StartedAt     time.Time  `json:"started_at" gorm:"not null"`
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty" gorm:"index"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (RepositoryCapacityAlert) TableName() string {
+	return "repository_capacity_alerts"
+}
+
+// SNA appliance states
+const (
+	SNAStateActive   = "active"
+	SNAStateDraining = "draining" // Finishes running jobs, takes no new ones
+	SNAStateDisabled = "disabled"
+)
+
+// SNAAppliance is a source appliance reachable over its own reverse tunnel
+type SNAAppliance struct {
+	ID                string     `json:"id" gorm:"primaryKey;type:varchar(64)"`
+	EnrollmentID      *string    `json:"enrollment_id,omitempty" gorm:"type:varchar(64);uniqueIndex"`
+	Name              string     `json:"name" gorm:"type:varchar(255);not null"`
+	TunnelPort        int        `json:"tunnel_port" gorm:"not null;uniqueIndex"`
+	State             string     `json:"state" gorm:"type:enum('active','draining','disabled');not null;default:'active'"`
+	MaxConcurrentJobs int        `json:"max_concurrent_jobs" gorm:"not null;default:8"`
+	Version           *string    `json:"version,omitempty" gorm:"type:varchar(64)"`
+	LastHeartbeatAt   *time.Time `json:"last_heartbeat_at,omitempty"`
+
+	Scopes []SNAScope `json:"scopes" gorm:"foreignKey:SNAID;constraint:OnDelete:CASCADE"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (SNAAppliance) TableName() string {
+	return "sna_appliances"
+}
+
+// SNAScope is a vCenter placement an SNA serves (empty datacenter or cluster matches any)
+type SNAScope struct {
+	ID          int64  `json:"-" gorm:"primaryKey;autoIncrement"`
+	SNAID       string `json:"-" gorm:"column:sna_id;type:varchar(64);not null"`
+	VCenterHost string `json:"vcenter_host" gorm:"column:vcenter_host;type:varchar(255);not null"`
+	Datacenter  string `json:"datacenter,omitempty" gorm:"type:varchar(255);not null;default:''"`
+	Cluster     string `json:"cluster,omitempty" gorm:"type:varchar(255);not null;default:''"`
+}
+
+func (SNAScope) TableName() string {
+	return "sna_scopes"
+}
+
+// SNAJobAssignment records the SNA a job was routed to
+type SNAJobAssignment struct {
+	JobID       string    `json:"job_id" gorm:"primaryKey;type:varchar(191)"`
+	SNAID       string    `json:"sna_id" gorm:"column:sna_id;type:varchar(64);not null;index"`
+	JobType     string    `json:"job_type" gorm:"type:varchar(32);not null"`
+	VMContextID *string   `json:"vm_context_id,omitempty" gorm:"type:varchar(64)"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (SNAJobAssignment) TableName() string {
+	return "sna_job_assignments"
+}
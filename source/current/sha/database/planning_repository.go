@@ -0,0 +1,270 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// =============================================================================
+// PLANNING REPOSITORY - History used to forecast schedule and flow runs
+// =============================================================================
+// Read-only queries behind the what-if planning API: which VMs a schedule or flow
+// covers, how big their disks are, how much changed in recent incremental runs and
+// how fast recent jobs moved data
+
+// PlanningRepository handles planning database queries
+type PlanningRepository struct {
+	db *gorm.DB
+}
+
+// NewPlanningRepository creates a new planning repository
+func NewPlanningRepository(conn Connection) *PlanningRepository {
+	return &PlanningRepository{
+		db: conn.GetGormDB(),
+	}
+}
+
+// PlanTargetVM is a VM a schedule or flow would run a job for
+type PlanTargetVM struct {
+	ContextID           string
+	VMName              string
+	GroupID             string
+	SchedulerEnabled    bool
+	CurrentStatus       string
+	LastSuccessfulJobID *string
+}
+
+// planTargetColumns selects the PlanTargetVM fields from vm_replication_contexts
+const planTargetColumns = "vm_replication_contexts.context_id, vm_replication_contexts.vm_name, " +
+	"vm_replication_contexts.scheduler_enabled, vm_replication_contexts.current_status, " +
+	"vm_replication_contexts.last_successful_job_id"
+
+// GetScheduleTargetVMs resolves the enabled members of the groups a schedule runs
+func (r *PlanningRepository) GetScheduleTargetVMs(ctx context.Context, scheduleID string) ([]PlanTargetVM, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var vms []PlanTargetVM
+	if err := r.db.WithContext(ctx).Table("vm_replication_contexts").
+		Select(planTargetColumns+", vm_machine_groups.id AS group_id").
+		Joins("JOIN vm_group_memberships ON vm_group_memberships.vm_context_id = vm_replication_contexts.context_id").
+		Joins("JOIN vm_machine_groups ON vm_machine_groups.id = vm_group_memberships.group_id").
+		Where("vm_machine_groups.schedule_id = ? AND vm_group_memberships.enabled = ?", scheduleID, true).
+		Order("vm_machine_groups.priority DESC, vm_group_memberships.priority DESC, vm_replication_contexts.vm_name ASC").
+		Scan(&vms).Error; err != nil {
+		return nil, fmt.Errorf("failed to resolve schedule target VMs: %w", err)
+	}
+	return vms, nil
+}
+
+// GetFlowTargetVMs resolves the VMs a flow protects (the VM itself or the enabled group members)
+func (r *PlanningRepository) GetFlowTargetVMs(ctx context.Context, flow *ProtectionFlow) ([]PlanTargetVM, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	query := r.db.WithContext(ctx).Table("vm_replication_contexts")
+	switch flow.TargetType {
+	case "vm":
+		query = query.Select(planTargetColumns).
+			Where("vm_replication_contexts.context_id = ?", flow.TargetID)
+	case "group":
+		query = query.Select(planTargetColumns+", vm_group_memberships.group_id").
+			Joins("JOIN vm_group_memberships ON vm_group_memberships.vm_context_id = vm_replication_contexts.context_id").
+			Where("vm_group_memberships.group_id = ? AND vm_group_memberships.enabled = ?", flow.TargetID, true)
+	default:
+		return nil, fmt.Errorf("unsupported target type: %s", flow.TargetType)
+	}
+
+	var vms []PlanTargetVM
+	if err := query.Order("vm_replication_contexts.vm_name ASC").Scan(&vms).Error; err != nil {
+		return nil, fmt.Errorf("failed to resolve flow target VMs: %w", err)
+	}
+	return vms, nil
+}
+
+// GetScheduleFlows retrieves the enabled backup flows triggered by a schedule
+func (r *PlanningRepository) GetScheduleFlows(ctx context.Context, scheduleID string) ([]*ProtectionFlow, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var flows []*ProtectionFlow
+	if err := r.db.WithContext(ctx).
+		Where("schedule_id = ? AND flow_type = ? AND enabled = ?", scheduleID, "backup", true).
+		Order("name ASC").
+		Find(&flows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get schedule flows: %w", err)
+	}
+	return flows, nil
+}
+
+// GetProvisionedBytes returns the total disk capacity of a VM as last discovered (0 if unknown)
+func (r *PlanningRepository) GetProvisionedBytes(ctx context.Context, vmContextID string) (int64, error) {
+	if r.db == nil {
+		return 0, fmt.Errorf("database not available")
+	}
+
+	// vm_disks keeps a row per disk per job - take each disk once
+	var disks []struct {
+		CapacityBytes int64
+		SizeGB        int64
+	}
+	if err := r.db.WithContext(ctx).Table("vm_disks").
+		Select("disk_id, MAX(capacity_bytes) AS capacity_bytes, MAX(size_gb) AS size_gb").
+		Where("vm_context_id = ?", vmContextID).
+		Group("disk_id").
+		Scan(&disks).Error; err != nil {
+		return 0, fmt.Errorf("failed to get VM disk sizes: %w", err)
+	}
+
+	var total int64
+	for _, disk := range disks {
+		if disk.CapacityBytes > 0 {
+			total += disk.CapacityBytes
+		} else {
+			total += disk.SizeGB * 1024 * 1024 * 1024
+		}
+	}
+	return total, nil
+}
+
+// GetAverageCBTDelta returns the mean bytes moved by the last successful incremental
+// syncs of a VM according to cbt_history, and how many syncs were averaged
+func (r *PlanningRepository) GetAverageCBTDelta(ctx context.Context, vmContextID string, sample int) (int64, int, error) {
+	if r.db == nil {
+		return 0, 0, fmt.Errorf("database not available")
+	}
+
+	// One row per job - a job's disks are recorded separately
+	var syncs []struct {
+		JobID string
+		Bytes int64
+	}
+	if err := r.db.WithContext(ctx).Table("cbt_history").
+		Select("job_id, SUM(bytes_transferred) AS bytes, MAX(created_at) AS synced_at").
+		Where("vm_context_id = ? AND sync_type = ? AND sync_success = ?", vmContextID, "incremental", true).
+		Group("job_id").
+		Order("synced_at DESC").
+		Limit(sample).
+		Scan(&syncs).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to get CBT history: %w", err)
+	}
+	if len(syncs) == 0 {
+		return 0, 0, nil
+	}
+
+	var total int64
+	for _, sync := range syncs {
+		total += sync.Bytes
+	}
+	return total / int64(len(syncs)), len(syncs), nil
+}
+
+// GetAverageBackupDelta returns the mean bytes moved by the last completed incremental
+// backups of a VM, and how many backups were averaged
+func (r *PlanningRepository) GetAverageBackupDelta(ctx context.Context, vmContextID string, sample int) (int64, int, error) {
+	if r.db == nil {
+		return 0, 0, fmt.Errorf("database not available")
+	}
+
+	var jobs []struct {
+		BytesTransferred int64
+	}
+	if err := r.db.WithContext(ctx).Table("backup_jobs").
+		Select("bytes_transferred").
+		Where("vm_context_id = ? AND backup_type = ? AND status = ? AND bytes_transferred > 0", vmContextID, "incremental", "completed").
+		Order("completed_at DESC").
+		Limit(sample).
+		Scan(&jobs).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to get backup history: %w", err)
+	}
+	if len(jobs) == 0 {
+		return 0, 0, nil
+	}
+
+	var total int64
+	for _, job := range jobs {
+		total += job.BytesTransferred
+	}
+	return total / int64(len(jobs)), len(jobs), nil
+}
+
+// GetAverageTransferSpeed returns the mean transfer speed of the last completed backup and
+// replication jobs - of one VM, or of all VMs when vmContextID is empty (0 if none)
+func (r *PlanningRepository) GetAverageTransferSpeed(ctx context.Context, vmContextID string, sample int) (int64, error) {
+	if r.db == nil {
+		return 0, fmt.Errorf("database not available")
+	}
+
+	var total int64
+	counted := 0
+	for _, table := range []string{"backup_jobs", "replication_jobs"} {
+		query := r.db.WithContext(ctx).Table(table).
+			Select("transfer_speed_bps").
+			Where("status = ? AND transfer_speed_bps > 0", "completed")
+		if vmContextID != "" {
+			query = query.Where("vm_context_id = ?", vmContextID)
+		}
+
+		var jobs []struct {
+			TransferSpeedBps int64
+		}
+		if err := query.Order("completed_at DESC").Limit(sample).Scan(&jobs).Error; err != nil {
+			return 0, fmt.Errorf("failed to get transfer speeds: %w", err)
+		}
+		for _, job := range jobs {
+			total += job.TransferSpeedBps
+			counted++
+		}
+	}
+	if counted == 0 {
+		return 0, nil
+	}
+	return total / int64(counted), nil
+}
+
+// HasCompletedFullBackup reports whether a VM already has a completed full backup in a repository
+func (r *PlanningRepository) HasCompletedFullBackup(ctx context.Context, vmContextID, repositoryID string) (bool, error) {
+	if r.db == nil {
+		return false, fmt.Errorf("database not available")
+	}
+
+	var count int64
+	if err := r.db.WithContext(ctx).Table("backup_jobs").
+		Where("vm_context_id = ? AND repository_id = ? AND backup_type = ? AND status = ?", vmContextID, repositoryID, "full", "completed").
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check for a full backup: %w", err)
+	}
+	return count > 0, nil
+}
+
+// GetRepositoryUsage returns the last recorded capacity of a backup repository
+func (r *PlanningRepository) GetRepositoryUsage(ctx context.Context, repositoryID string) (total, used, available int64, checkedAt *time.Time, err error) {
+	if r.db == nil {
+		return 0, 0, 0, nil, fmt.Errorf("database not available")
+	}
+
+	var row struct {
+		TotalSizeBytes     int64
+		UsedSizeBytes      int64
+		AvailableSizeBytes int64
+		LastCheckAt        *time.Time
+	}
+	result := r.db.WithContext(ctx).Table("backup_repositories").
+		Select("total_size_bytes, used_size_bytes, available_size_bytes, last_check_at").
+		Where("id = ?", repositoryID).
+		Limit(1).
+		Scan(&row)
+	if result.Error != nil {
+		return 0, 0, 0, nil, fmt.Errorf("failed to get repository usage: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return 0, 0, 0, nil, fmt.Errorf("repository not found: %s", repositoryID)
+	}
+	return row.TotalSizeBytes, row.UsedSizeBytes, row.AvailableSizeBytes, row.LastCheckAt, nil
+}
@@ -2359,6 +2359,24 @@ func (r *VMReplicationContextRepository) GetVMContextByName(vmName string) (*VMR
 	return &context, nil
 }
 
+// GetVMContextByID retrieves a VM context by context_id
+func (r *VMReplicationContextRepository) GetVMContextByID(contextID string) (*VMReplicationContext, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	if contextID == "" {
+		return nil, fmt.Errorf("context ID is required")
+	}
+
+	var context VMReplicationContext
+	if err := r.db.Where("context_id = ?", contextID).First(&context).Error; err != nil {
+		return nil, fmt.Errorf("failed to get VM context %s: %w", contextID, err)
+	}
+
+	return &context, nil
+}
+
 // UpdateVMContextStatus updates the current status of a VM context
 func (r *VMReplicationContextRepository) UpdateVMContextStatus(contextID, newStatus string) error {
 	if r.db == nil {
@@ -2393,6 +2411,56 @@ func (r *VMReplicationContextRepository) UpdateVMContextStatus(contextID, newSta
 	return nil
 }
 
+// Snapshot consistency levels, strongest first
+const (
+	ConsistencyApplication = "application" // VSS application-consistent (Windows guests)
+	ConsistencyFilesystem  = "filesystem"  // VMware Tools file-system quiesce
+	ConsistencyCrash       = "crash"       // No quiescing
+)
+
+// VSS backup types for application-consistent snapshots
+const (
+	VSSBackupTypeFull = "full"
+	VSSBackupTypeCopy = "copy"
+)
+
+// UpdateConsistencyPolicy sets the snapshot consistency policy for a VM context
+func (r *VMReplicationContextRepository) UpdateConsistencyPolicy(contextID, level, vssBackupType string) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	switch level {
+	case ConsistencyApplication, ConsistencyFilesystem, ConsistencyCrash:
+	default:
+		return fmt.Errorf("invalid consistency level: %s", level)
+	}
+
+	switch vssBackupType {
+	case VSSBackupTypeFull, VSSBackupTypeCopy:
+	default:
+		return fmt.Errorf("invalid VSS backup type: %s", vssBackupType)
+	}
+
+	result := r.db.Model(&VMReplicationContext{}).
+		Where("context_id = ?", contextID).
+		Updates(map[string]interface{}{
+			"consistency_level": level,
+			"vss_backup_type":   vssBackupType,
+			"updated_at":        time.Now(),
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update consistency policy for %s: %w", contextID, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("VM context not found: %s", contextID)
+	}
+
+	return nil
+}
+
 // =============================================================================
 // OSSEA CONFIG REPOSITORY - Encryption and Validation Helpers
 // =============================================================================
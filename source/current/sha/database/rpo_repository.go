@@ -0,0 +1,297 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// =============================================================================
+// RPO REPOSITORY - Restore point ages and RPO breach history
+// =============================================================================
+// Reads the newest restore point of each VM in an RPO-driven protection flow and
+// keeps the history of VMs falling outside their RPO target
+
+// RPORepository handles RPO tracking database operations
+type RPORepository struct {
+	db *gorm.DB
+}
+
+// NewRPORepository creates a new RPO repository
+func NewRPORepository(conn Connection) *RPORepository {
+	return &RPORepository{
+		db: conn.GetGormDB(),
+	}
+}
+
+// RPOTargetVM is a VM covered by an RPO-driven flow
+type RPOTargetVM struct {
+	ContextID string
+	VMName    string
+	CreatedAt time.Time
+}
+
+// RPOBreachFilters represents filtering options for breach history queries
+type RPOBreachFilters struct {
+	FlowID      *string
+	VMContextID *string
+	OpenOnly    bool
+	Since       *time.Time
+	Limit       int
+}
+
+// GetRPOFlows retrieves enabled protection flows that carry an RPO target
+func (r *RPORepository) GetRPOFlows(ctx context.Context) ([]*ProtectionFlow, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var flows []*ProtectionFlow
+	if err := r.db.WithContext(ctx).
+		Where("enabled = ? AND rpo_minutes IS NOT NULL AND rpo_minutes > 0", true).
+		Order("name ASC").
+		Find(&flows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get RPO flows: %w", err)
+	}
+	return flows, nil
+}
+
+// GetFlowTargetVMs resolves the VMs a flow protects (the VM itself or the enabled group members)
+func (r *RPORepository) GetFlowTargetVMs(ctx context.Context, flow *ProtectionFlow) ([]RPOTargetVM, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	query := r.db.WithContext(ctx).Table("vm_replication_contexts").
+		Select("vm_replication_contexts.context_id, vm_replication_contexts.vm_name, vm_replication_contexts.created_at")
+
+	switch flow.TargetType {
+	case "vm":
+		query = query.Where("vm_replication_contexts.context_id = ?", flow.TargetID)
+	case "group":
+		query = query.
+			Joins("JOIN vm_group_memberships ON vm_group_memberships.vm_context_id = vm_replication_contexts.context_id").
+			Where("vm_group_memberships.group_id = ? AND vm_group_memberships.enabled = ?", flow.TargetID, true)
+	default:
+		return nil, fmt.Errorf("unsupported target type: %s", flow.TargetType)
+	}
+
+	var vms []RPOTargetVM
+	if err := query.Order("vm_replication_contexts.vm_name ASC").Scan(&vms).Error; err != nil {
+		return nil, fmt.Errorf("failed to resolve flow target VMs: %w", err)
+	}
+	return vms, nil
+}
+
+// restorePointTable returns the job table whose completed jobs are the restore points of a flow type
+func restorePointTable(flowType string) (string, error) {
+	switch flowType {
+	case "backup":
+		return "backup_jobs", nil
+	case "replication":
+		return "replication_jobs", nil
+	default:
+		return "", fmt.Errorf("unknown flow type: %s", flowType)
+	}
+}
+
+// GetLastRestorePoint returns when the newest completed backup or replication of a VM finished (nil if none)
+func (r *RPORepository) GetLastRestorePoint(ctx context.Context, flowType, vmContextID string) (*time.Time, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+	table, err := restorePointTable(flowType)
+	if err != nil {
+		return nil, err
+	}
+
+	var job struct {
+		CompletedAt *time.Time
+	}
+	result := r.db.WithContext(ctx).Table(table).
+		Select("completed_at").
+		Where("vm_context_id = ? AND status = ? AND completed_at IS NOT NULL", vmContextID, "completed").
+		Order("completed_at DESC").
+		Limit(1).
+		Scan(&job)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get last restore point: %w", result.Error)
+	}
+	return job.CompletedAt, nil
+}
+
+// GetAverageJobDuration returns the mean run time of the last completed jobs of a VM (0 if none)
+func (r *RPORepository) GetAverageJobDuration(ctx context.Context, flowType, vmContextID string, sample int) (time.Duration, error) {
+	if r.db == nil {
+		return 0, fmt.Errorf("database not available")
+	}
+	table, err := restorePointTable(flowType)
+	if err != nil {
+		return 0, err
+	}
+
+	var jobs []struct {
+		StartedAt   *time.Time
+		CompletedAt *time.Time
+	}
+	if err := r.db.WithContext(ctx).Table(table).
+		Select("started_at, completed_at").
+		Where("vm_context_id = ? AND status = ? AND started_at IS NOT NULL AND completed_at IS NOT NULL", vmContextID, "completed").
+		Order("completed_at DESC").
+		Limit(sample).
+		Scan(&jobs).Error; err != nil {
+		return 0, fmt.Errorf("failed to get job durations: %w", err)
+	}
+
+	var total time.Duration
+	counted := 0
+	for _, job := range jobs {
+		if duration := job.CompletedAt.Sub(*job.StartedAt); duration > 0 {
+			total += duration
+			counted++
+		}
+	}
+	if counted == 0 {
+		return 0, nil
+	}
+	return total / time.Duration(counted), nil
+}
+
+// CountRunningJobs returns the number of backup and replication jobs currently moving data
+func (r *RPORepository) CountRunningJobs(ctx context.Context) (int64, error) {
+	if r.db == nil {
+		return 0, fmt.Errorf("database not available")
+	}
+
+	var backups, replications int64
+	if err := r.db.WithContext(ctx).Table("backup_jobs").Where("status = ?", "running").Count(&backups).Error; err != nil {
+		return 0, fmt.Errorf("failed to count running backups: %w", err)
+	}
+	if err := r.db.WithContext(ctx).Table("replication_jobs").Where("status = ?", "replicating").Count(&replications).Error; err != nil {
+		return 0, fmt.Errorf("failed to count running replications: %w", err)
+	}
+	return backups + replications, nil
+}
+
+// HasRunningExecution reports whether a flow has an execution still in progress
+func (r *RPORepository) HasRunningExecution(ctx context.Context, flowID string) (bool, error) {
+	if r.db == nil {
+		return false, fmt.Errorf("database not available")
+	}
+
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&ProtectionFlowExecution{}).
+		Where("flow_id = ? AND status IN ?", flowID, []string{"pending", "running"}).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check running executions: %w", err)
+	}
+	return count > 0, nil
+}
+
+// SetNextExecutionTime records when the RPO scheduler plans to run a flow next
+func (r *RPORepository) SetNextExecutionTime(ctx context.Context, flowID string, next *time.Time) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	return r.db.WithContext(ctx).Model(&ProtectionFlow{}).
+		Where("id = ?", flowID).
+		UpdateColumn("next_execution_time", next).Error
+}
+
+// =============================================================================
+// BREACH HISTORY
+// =============================================================================
+
+// GetOpenBreaches retrieves ongoing breaches keyed by flow ID and VM context ID
+func (r *RPORepository) GetOpenBreaches(ctx context.Context) (map[string]*RPOBreach, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var breaches []*RPOBreach
+	if err := r.db.WithContext(ctx).Where("resolved_at IS NULL").Find(&breaches).Error; err != nil {
+		return nil, fmt.Errorf("failed to get open RPO breaches: %w", err)
+	}
+
+	open := make(map[string]*RPOBreach, len(breaches))
+	for _, breach := range breaches {
+		open[RPOBreachKey(breach.FlowID, breach.VMContextID)] = breach
+	}
+	return open, nil
+}
+
+// RPOBreachKey identifies the breach of one VM in one flow
+func RPOBreachKey(flowID, vmContextID string) string {
+	return flowID + "/" + vmContextID
+}
+
+// CreateBreach records the start of an RPO breach
+func (r *RPORepository) CreateBreach(ctx context.Context, breach *RPOBreach) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	if err := r.db.WithContext(ctx).Create(breach).Error; err != nil {
+		return fmt.Errorf("failed to create RPO breach: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"breach_id":     breach.ID,
+		"flow_id":       breach.FlowID,
+		"vm_context_id": breach.VMContextID,
+		"rpo_minutes":   breach.RPOMinutes,
+	}).Warn("RPO breach recorded")
+	return nil
+}
+
+// ResolveBreach closes an RPO breach once a new restore point is inside the RPO again
+func (r *RPORepository) ResolveBreach(ctx context.Context, id string, resolvedAt time.Time, restorePointAt *time.Time) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	result := r.db.WithContext(ctx).Model(&RPOBreach{}).
+		Where("id = ? AND resolved_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"resolved_at":                  resolvedAt,
+			"resolved_by_restore_point_at": restorePointAt,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to resolve RPO breach: %w", result.Error)
+	}
+	return nil
+}
+
+// ListBreaches retrieves RPO breach history, newest first
+func (r *RPORepository) ListBreaches(ctx context.Context, filters RPOBreachFilters) ([]*RPOBreach, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	query := r.db.WithContext(ctx).Model(&RPOBreach{})
+	if filters.FlowID != nil {
+		query = query.Where("flow_id = ?", *filters.FlowID)
+	}
+	if filters.VMContextID != nil {
+		query = query.Where("vm_context_id = ?", *filters.VMContextID)
+	}
+	if filters.OpenOnly {
+		query = query.Where("resolved_at IS NULL")
+	}
+	if filters.Since != nil {
+		query = query.Where("resolved_at IS NULL OR resolved_at >= ?", *filters.Since)
+	}
+	if filters.Limit > 0 {
+		query = query.Limit(filters.Limit)
+	}
+
+	var breaches []*RPOBreach
+	if err := query.Order("breach_started_at DESC").Find(&breaches).Error; err != nil {
+		return nil, fmt.Errorf("failed to list RPO breaches: %w", err)
+	}
+	return breaches, nil
+}
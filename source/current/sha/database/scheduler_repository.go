@@ -103,6 +103,29 @@ func (r *SchedulerRepository) ListSchedules(enabledOnly bool, preload ...string)
 	return schedules, nil
 }
 
+// GetScheduleChangeMarker returns a value that changes whenever a schedule is created,
+// updated or deleted, so a scheduler can notice edits made through another SHA instance
+func (r *SchedulerRepository) GetScheduleChangeMarker() (string, error) {
+	if r.db == nil {
+		return "", fmt.Errorf("database not available")
+	}
+
+	var marker struct {
+		Total     int64
+		UpdatedAt *time.Time
+	}
+	if err := r.db.Model(&ReplicationSchedule{}).
+		Select("COUNT(*) AS total, MAX(updated_at) AS updated_at").
+		Scan(&marker).Error; err != nil {
+		return "", fmt.Errorf("failed to get schedule change marker: %w", err)
+	}
+
+	if marker.UpdatedAt == nil {
+		return fmt.Sprintf("%d", marker.Total), nil
+	}
+	return fmt.Sprintf("%d/%d", marker.Total, marker.UpdatedAt.UnixNano()), nil
+}
+
 // UpdateSchedule updates an existing schedule
 func (r *SchedulerRepository) UpdateSchedule(scheduleID string, updates map[string]interface{}) error {
 	if r.db == nil {
@@ -523,6 +546,72 @@ func (r *SchedulerRepository) GetScheduleExecutions(scheduleID string, limit, of
 	return executions, nil
 }
 
+// ListDeferredScheduleExecutions retrieves executions queued for a later start (retries,
+// chains and backup window deferrals). Legacy "scheduled" rows without deferred_until
+// were never queued and are left alone.
+func (r *SchedulerRepository) ListDeferredScheduleExecutions() ([]ScheduleExecution, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var executions []ScheduleExecution
+	if err := r.db.Where("status = ? AND deferred_until IS NOT NULL", "scheduled").
+		Order("deferred_until ASC").
+		Find(&executions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get deferred schedule executions: %w", err)
+	}
+
+	return executions, nil
+}
+
+// ListUnevaluatedScheduleExecutions retrieves dispatched executions whose job outcomes
+// have not yet been evaluated for retries and chained schedules
+func (r *SchedulerRepository) ListUnevaluatedScheduleExecutions(since time.Time) ([]ScheduleExecution, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var executions []ScheduleExecution
+	if err := r.db.Where("status IN ? AND outcome_evaluated_at IS NULL AND scheduled_at >= ?",
+		[]string{"completed", "failed"}, since).
+		Find(&executions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get unevaluated schedule executions: %w", err)
+	}
+
+	return executions, nil
+}
+
+// GetScheduleExecutionJobs retrieves the replication jobs created by a schedule execution
+func (r *SchedulerRepository) GetScheduleExecutionJobs(executionID string) ([]ReplicationJob, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var jobs []ReplicationJob
+	if err := r.db.Where("schedule_execution_id = ?", executionID).
+		Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get jobs for schedule execution: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// GetChainChildSchedules retrieves enabled chain schedules that follow a parent schedule
+func (r *SchedulerRepository) GetChainChildSchedules(parentScheduleID string) ([]ReplicationSchedule, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var schedules []ReplicationSchedule
+	if err := r.db.Where("chain_parent_schedule_id = ? AND schedule_type = ? AND enabled = ?",
+		parentScheduleID, "chain", true).
+		Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to get chain child schedules: %w", err)
+	}
+
+	return schedules, nil
+}
+
 // =============================================================================
 // VM CONTEXT ENHANCEMENT - Add discovery without jobs
 // =============================================================================
@@ -931,3 +1020,77 @@ func (r *SchedulerRepository) GetVMContextsWithoutGroups() ([]VMReplicationConte
 	log.WithField("count", len(contexts)).Debug("Retrieved VM contexts without groups")
 	return contexts, nil
 }
+
+// ListDynamicGroups returns the machine groups that have membership rules
+func (r *SchedulerRepository) ListDynamicGroups() ([]VMMachineGroup, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var groups []VMMachineGroup
+	err := r.db.Where("membership_rules IS NOT NULL").Order("priority ASC, name ASC").Find(&groups).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dynamic groups: %w", err)
+	}
+
+	log.WithField("count", len(groups)).Debug("Listed dynamic machine groups")
+	return groups, nil
+}
+
+// ListVMContexts returns every VM context (for evaluating machine group rules)
+func (r *SchedulerRepository) ListVMContexts() ([]VMReplicationContext, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var contexts []VMReplicationContext
+	if err := r.db.Order("vm_name ASC").Find(&contexts).Error; err != nil {
+		return nil, fmt.Errorf("failed to list VM contexts: %w", err)
+	}
+
+	return contexts, nil
+}
+
+// RecordMembershipChange records a VM joining or leaving a group through its rules
+func (r *SchedulerRepository) RecordMembershipChange(change *VMGroupMembershipChange) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	if err := r.db.Create(change).Error; err != nil {
+		return fmt.Errorf("failed to record membership change: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"group_id":      change.GroupID,
+		"vm_context_id": change.VMContextID,
+		"action":        change.Action,
+		"reason":        change.Reason,
+	}).Info("Recorded machine group membership change")
+
+	return nil
+}
+
+// GetMembershipChanges retrieves the most recent rule membership changes of a group
+func (r *SchedulerRepository) GetMembershipChanges(groupID string, limit int) ([]VMGroupMembershipChange, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	query := r.db.Where("group_id = ?", groupID).Order("created_at DESC, id DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var changes []VMGroupMembershipChange
+	if err := query.Find(&changes).Error; err != nil {
+		return nil, fmt.Errorf("failed to get membership changes: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"group_id": groupID,
+		"count":    len(changes),
+	}).Debug("Retrieved group membership changes")
+
+	return changes, nil
+}
@@ -0,0 +1,318 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// =============================================================================
+// SNA REGISTRY REPOSITORY - Enrolled SNAs, their scopes and job assignments
+// =============================================================================
+// Every SNA reaches the SHA API over its own reverse tunnel port. The SNA that a
+// job was routed to is recorded so progress and job control calls for that job
+// reach the same appliance, and so each SNA's load can be counted.
+
+// SNA reverse tunnel ports handed out at enrollment (9081 is the pre-enrollment tunnel)
+const (
+	SNATunnelPortFirst = 9081
+	SNATunnelPortLast  = 9180
+)
+
+// snaAssignmentGracePeriod is how long an assignment counts before its job row exists
+const snaAssignmentGracePeriod = 10 * time.Minute
+
+// Job states that still occupy an SNA
+var (
+	snaActiveBackupStatuses      = []string{"pending", "running", "paused"}
+	snaActiveReplicationStatuses = []string{"pending", "provisioning", "replicating", "paused"}
+	snaActiveRestoreStatuses     = []string{VMwareRestoreStatusPending, VMwareRestoreStatusRunning}
+)
+
+// SNARegistryRepository handles SNA registry database operations
+type SNARegistryRepository struct {
+	db *gorm.DB
+}
+
+// NewSNARegistryRepository creates a new SNA registry repository
+func NewSNARegistryRepository(conn Connection) *SNARegistryRepository {
+	return &SNARegistryRepository{
+		db: conn.GetGormDB(),
+	}
+}
+
+// =============================================================================
+// APPLIANCES
+// =============================================================================
+
+// ListAppliances retrieves every registered SNA with its scopes
+func (r *SNARegistryRepository) ListAppliances(ctx context.Context) ([]*SNAAppliance, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var appliances []*SNAAppliance
+	if err := r.db.WithContext(ctx).Preload("Scopes").Order("name ASC").Find(&appliances).Error; err != nil {
+		return nil, fmt.Errorf("failed to list SNAs: %w", err)
+	}
+	return appliances, nil
+}
+
+// GetAppliance retrieves an SNA with its scopes
+func (r *SNARegistryRepository) GetAppliance(ctx context.Context, id string) (*SNAAppliance, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var appliance SNAAppliance
+	if err := r.db.WithContext(ctx).Preload("Scopes").Where("id = ?", id).First(&appliance).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("SNA not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get SNA: %w", err)
+	}
+	return &appliance, nil
+}
+
+// GetApplianceByEnrollment retrieves the SNA created when an enrollment was approved
+func (r *SNARegistryRepository) GetApplianceByEnrollment(ctx context.Context, enrollmentID string) (*SNAAppliance, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var appliance SNAAppliance
+	if err := r.db.WithContext(ctx).Preload("Scopes").Where("enrollment_id = ?", enrollmentID).First(&appliance).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("SNA not found for enrollment: %s", enrollmentID)
+		}
+		return nil, fmt.Errorf("failed to get SNA: %w", err)
+	}
+	return &appliance, nil
+}
+
+// GetApplianceByTunnelPort retrieves the SNA reachable on a tunnel port
+func (r *SNARegistryRepository) GetApplianceByTunnelPort(ctx context.Context, port int) (*SNAAppliance, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var appliance SNAAppliance
+	if err := r.db.WithContext(ctx).Preload("Scopes").Where("tunnel_port = ?", port).First(&appliance).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("SNA not found on tunnel port %d", port)
+		}
+		return nil, fmt.Errorf("failed to get SNA: %w", err)
+	}
+	return &appliance, nil
+}
+
+// CreateEnrolledAppliance creates the SNA for an approved enrollment on the lowest free
+// tunnel port, or returns the existing one if the enrollment was already approved
+func (r *SNARegistryRepository) CreateEnrolledAppliance(ctx context.Context, enrollmentID, name string) (*SNAAppliance, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var appliance SNAAppliance
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("enrollment_id = ?", enrollmentID).First(&appliance).Error; err == nil {
+			return nil
+		} else if err != gorm.ErrRecordNotFound {
+			return fmt.Errorf("failed to check existing SNA: %w", err)
+		}
+
+		var used []int
+		if err := tx.Model(&SNAAppliance{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Pluck("tunnel_port", &used).Error; err != nil {
+			return fmt.Errorf("failed to load tunnel ports: %w", err)
+		}
+		taken := make(map[int]bool, len(used))
+		for _, port := range used {
+			taken[port] = true
+		}
+
+		port := 0
+		for candidate := SNATunnelPortFirst; candidate <= SNATunnelPortLast; candidate++ {
+			if !taken[candidate] {
+				port = candidate
+				break
+			}
+		}
+		if port == 0 {
+			return fmt.Errorf("no free SNA tunnel port in %d-%d", SNATunnelPortFirst, SNATunnelPortLast)
+		}
+
+		appliance = SNAAppliance{
+			ID:                uuid.New().String(),
+			EnrollmentID:      &enrollmentID,
+			Name:              name,
+			TunnelPort:        port,
+			State:             SNAStateActive,
+			MaxConcurrentJobs: 8,
+		}
+		if err := tx.Create(&appliance).Error; err != nil {
+			return fmt.Errorf("failed to create SNA: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &appliance, nil
+}
+
+// CreateAppliance creates an SNA row
+func (r *SNARegistryRepository) CreateAppliance(ctx context.Context, appliance *SNAAppliance) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	if appliance.ID == "" {
+		appliance.ID = uuid.New().String()
+	}
+	if err := r.db.WithContext(ctx).Omit("Scopes").Create(appliance).Error; err != nil {
+		return fmt.Errorf("failed to create SNA: %w", err)
+	}
+	return nil
+}
+
+// UpdateAppliance updates specific fields of an SNA
+func (r *SNARegistryRepository) UpdateAppliance(ctx context.Context, id string, updates map[string]interface{}) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	if err := r.db.WithContext(ctx).Model(&SNAAppliance{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update SNA: %w", err)
+	}
+	return nil
+}
+
+// SetStateByEnrollment changes the state of the SNA created for an enrollment
+func (r *SNARegistryRepository) SetStateByEnrollment(ctx context.Context, enrollmentID, state string) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	if err := r.db.WithContext(ctx).Model(&SNAAppliance{}).
+		Where("enrollment_id = ?", enrollmentID).
+		Update("state", state).Error; err != nil {
+		return fmt.Errorf("failed to update SNA state: %w", err)
+	}
+	return nil
+}
+
+// ReplaceScopes replaces the vCenter placements an SNA serves
+func (r *SNARegistryRepository) ReplaceScopes(ctx context.Context, snaID string, scopes []SNAScope) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("sna_id = ?", snaID).Delete(&SNAScope{}).Error; err != nil {
+			return fmt.Errorf("failed to clear SNA scopes: %w", err)
+		}
+		if len(scopes) == 0 {
+			return nil
+		}
+		for i := range scopes {
+			scopes[i].ID = 0
+			scopes[i].SNAID = snaID
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&scopes).Error; err != nil {
+			return fmt.Errorf("failed to save SNA scopes: %w", err)
+		}
+		return nil
+	})
+}
+
+// =============================================================================
+// JOB ASSIGNMENTS
+// =============================================================================
+
+// UpsertAssignment records (or moves) the SNA a job is routed to
+func (r *SNARegistryRepository) UpsertAssignment(ctx context.Context, assignment *SNAJobAssignment) error {
+	if r.db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "job_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"sna_id", "job_type", "vm_context_id"}),
+	}).Create(assignment).Error; err != nil {
+		return fmt.Errorf("failed to save SNA job assignment: %w", err)
+	}
+	return nil
+}
+
+// GetAssignment retrieves the SNA assignment of a job
+func (r *SNARegistryRepository) GetAssignment(ctx context.Context, jobID string) (*SNAJobAssignment, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var assignment SNAJobAssignment
+	if err := r.db.WithContext(ctx).Where("job_id = ?", jobID).First(&assignment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("SNA job assignment not found: %s", jobID)
+		}
+		return nil, fmt.Errorf("failed to get SNA job assignment: %w", err)
+	}
+	return &assignment, nil
+}
+
+// GetAssignments retrieves the SNA of each job, keyed by job ID
+func (r *SNARegistryRepository) GetAssignments(ctx context.Context, jobIDs []string) (map[string]string, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	assigned := make(map[string]string, len(jobIDs))
+	if len(jobIDs) == 0 {
+		return assigned, nil
+	}
+
+	var assignments []SNAJobAssignment
+	if err := r.db.WithContext(ctx).Where("job_id IN ?", jobIDs).Find(&assignments).Error; err != nil {
+		return nil, fmt.Errorf("failed to get SNA job assignments: %w", err)
+	}
+	for _, assignment := range assignments {
+		assigned[assignment.JobID] = assignment.SNAID
+	}
+	return assigned, nil
+}
+
+// CountActiveJobs counts the jobs each SNA is still running, keyed by SNA ID
+func (r *SNARegistryRepository) CountActiveJobs(ctx context.Context) (map[string]int, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	var rows []struct {
+		SNAID string
+		Jobs  int
+	}
+	if err := r.db.WithContext(ctx).Table("sna_job_assignments a").
+		Select("a.sna_id, COUNT(*) AS jobs").
+		Joins("LEFT JOIN backup_jobs b ON b.id = a.job_id").
+		Joins("LEFT JOIN replication_jobs rj ON rj.id = a.job_id").
+		Joins("LEFT JOIN vmware_restore_jobs vr ON vr.id = a.job_id").
+		Where("b.status IN ? OR rj.status IN ? OR vr.status IN ? OR (b.id IS NULL AND rj.id IS NULL AND vr.id IS NULL AND a.created_at > ?)",
+			snaActiveBackupStatuses, snaActiveReplicationStatuses, snaActiveRestoreStatuses,
+			time.Now().Add(-snaAssignmentGracePeriod)).
+		Group("a.sna_id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to count SNA jobs: %w", err)
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.SNAID] = row.Jobs
+	}
+	return counts, nil
+}
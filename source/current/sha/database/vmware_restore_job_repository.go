@@ -0,0 +1,138 @@
+// Package database provides database operations using repository pattern
+// VMware Restore Job Repository - restores of backups back to vSphere
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// VMware restore modes
+const (
+	VMwareRestoreModeNewVM   = "new_vm"
+	VMwareRestoreModeInPlace = "in_place"
+)
+
+// VMware restore job statuses
+const (
+	VMwareRestoreStatusPending   = "pending"
+	VMwareRestoreStatusRunning   = "running"
+	VMwareRestoreStatusCompleted = "completed"
+	VMwareRestoreStatusFailed    = "failed"
+)
+
+// VMwareRestoreJob tracks a restore of a backup to VMware vSphere
+// Maps to vmware_restore_jobs table (created in 20251011160000_add_vmware_restore_jobs.up.sql)
+type VMwareRestoreJob struct {
+	ID           string  `gorm:"column:id;primaryKey" json:"id"`
+	BackupID     string  `gorm:"column:backup_id;not null;index" json:"backup_id"`
+	VMContextID  string  `gorm:"column:vm_context_id;not null;index" json:"vm_context_id"`
+	Mode         string  `gorm:"column:mode;not null" json:"mode"` // new_vm, in_place
+	TargetVMName string  `gorm:"column:target_vm_name;not null" json:"target_vm_name"`
+	TargetVMPath *string `gorm:"column:target_vm_path" json:"target_vm_path,omitempty"`
+	// Telemetry fields pushed by the backup client
+	Status           string     `gorm:"column:status;not null;default:'pending'" json:"status"`
+	CurrentPhase     string     `gorm:"column:current_phase;default:'pending'" json:"current_phase"`
+	BytesTransferred int64      `gorm:"column:bytes_transferred;default:0" json:"bytes_transferred"`
+	TotalBytes       int64      `gorm:"column:total_bytes;default:0" json:"total_bytes"`
+	ProgressPercent  float64    `gorm:"column:progress_percent;default:0.0" json:"progress_percent"`
+	TransferSpeedBps int64      `gorm:"column:transfer_speed_bps;default:0" json:"transfer_speed_bps"`
+	ErrorMessage     string     `gorm:"column:error_message" json:"error_message,omitempty"`
+	JobLogID         *string    `gorm:"column:job_log_id" json:"job_log_id,omitempty"`
+	CreatedAt        time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
+	StartedAt        *time.Time `gorm:"column:started_at" json:"started_at"`
+	CompletedAt      *time.Time `gorm:"column:completed_at" json:"completed_at"`
+	LastTelemetryAt  *time.Time `gorm:"column:last_telemetry_at" json:"last_telemetry_at"`
+}
+
+// TableName returns the table name for VMwareRestoreJob
+func (VMwareRestoreJob) TableName() string {
+	return "vmware_restore_jobs"
+}
+
+// IsTerminal reports whether the restore has finished (successfully or not)
+func (j *VMwareRestoreJob) IsTerminal() bool {
+	return j.Status == VMwareRestoreStatusCompleted || j.Status == VMwareRestoreStatusFailed
+}
+
+// VMwareRestoreJobRepository handles database operations for VMware restore jobs
+type VMwareRestoreJobRepository struct {
+	db Connection
+}
+
+// NewVMwareRestoreJobRepository creates a new VMware restore job repository
+func NewVMwareRestoreJobRepository(db Connection) *VMwareRestoreJobRepository {
+	return &VMwareRestoreJobRepository{db: db}
+}
+
+// Create creates a new VMware restore job record
+func (r *VMwareRestoreJobRepository) Create(ctx context.Context, job *VMwareRestoreJob) error {
+	if err := r.db.GetGormDB().WithContext(ctx).Create(job).Error; err != nil {
+		return fmt.Errorf("failed to create VMware restore job: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"restore_id": job.ID,
+		"backup_id":  job.BackupID,
+		"mode":       job.Mode,
+		"target_vm":  job.TargetVMName,
+	}).Info("✅ VMware restore job record created")
+	return nil
+}
+
+// GetByID retrieves a VMware restore job by ID
+func (r *VMwareRestoreJobRepository) GetByID(ctx context.Context, id string) (*VMwareRestoreJob, error) {
+	var job VMwareRestoreJob
+	err := r.db.GetGormDB().WithContext(ctx).Where("id = ?", id).First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("VMware restore job not found: %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get VMware restore job: %w", err)
+	}
+	return &job, nil
+}
+
+// ListByVMContext lists VMware restore jobs, newest first, optionally for one VM context
+func (r *VMwareRestoreJobRepository) ListByVMContext(ctx context.Context, vmContextID string) ([]*VMwareRestoreJob, error) {
+	query := r.db.GetGormDB().WithContext(ctx).Order("created_at DESC")
+	if vmContextID != "" {
+		query = query.Where("vm_context_id = ?", vmContextID)
+	}
+
+	var jobs []*VMwareRestoreJob
+	if err := query.Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list VMware restore jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// Update applies field updates to a VMware restore job
+func (r *VMwareRestoreJobRepository) Update(ctx context.Context, id string, updates map[string]interface{}) error {
+	result := r.db.GetGormDB().WithContext(ctx).
+		Model(&VMwareRestoreJob{}).
+		Where("id = ?", id).
+		Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update VMware restore job: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("VMware restore job not found: %s", id)
+	}
+	return nil
+}
+
+// MarkFailed records a restore failure
+func (r *VMwareRestoreJobRepository) MarkFailed(ctx context.Context, id string, reason string) error {
+	now := time.Now()
+	return r.Update(ctx, id, map[string]interface{}{
+		"status":        VMwareRestoreStatusFailed,
+		"error_message": reason,
+		"completed_at":  now,
+	})
+}
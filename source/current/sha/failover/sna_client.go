@@ -9,11 +9,14 @@ import (
 	"net/http"
 	"net/url"
 	"time"
+
+	"github.com/vexxhost/migratekit-sha/services"
 )
 
 // SNAClientImpl implements SNAClient interface for power management operations
 type SNAClientImpl struct {
 	snaHost  string // SNA tunnel endpoint (e.g., "http://localhost:9081")
+	router   *services.SNARouter // Optional: picks the SNA serving the vCenter per call
 	vcenter  string // vCenter hostname/IP
 	username string // vCenter username
 	password string // vCenter password
@@ -63,10 +66,12 @@ func NewVMAClient(snaHost, vcenter, username, password string) *SNAClientImpl {
 }
 
 // NewVMAClientForFailover creates a SNA client for failover operations with VM context credentials
-// vCenter credentials are obtained from the VM context and passed to SNA API calls
-func NewVMAClientForFailover() *SNAClientImpl {
+// vCenter credentials are obtained from the VM context and passed to SNA API calls; each call
+// goes to the SNA the router picks for the VM's vCenter
+func NewVMAClientForFailover(router *services.SNARouter) *SNAClientImpl {
 	return &SNAClientImpl{
-		snaHost:  "http://localhost:9081", // SNA tunnel endpoint
+		snaHost:  services.DefaultSNAEndpoint, // SNA tunnel endpoint when no SNA registered the vCenter
+		router:   router,
 		vcenter:  "",                      // Will be set per-operation from VM context
 		username: "",                      // Will be set per-operation from VM context
 		password: "",                      // Will be set per-operation from VM context
@@ -74,6 +79,15 @@ func NewVMAClientForFailover() *SNAClientImpl {
 	}
 }
 
+// endpointFor returns the endpoint of the SNA serving a vCenter
+func (vmc *SNAClientImpl) endpointFor(ctx context.Context, vcenter string) (string, error) {
+	endpoint, err := vmc.router.EndpointForScope(ctx, services.SNAPlacement{VCenterHost: vcenter}, vmc.snaHost)
+	if err != nil {
+		return "", fmt.Errorf("failed to route to SNA: %w", err)
+	}
+	return endpoint, nil
+}
+
 // PowerOnSourceVM powers on a source VM via SNA
 func (vmc *SNAClientImpl) PowerOnSourceVM(ctx context.Context, vmwareVMID, vcenter, username, password string) error {
 	// Create request payload
@@ -91,7 +105,11 @@ func (vmc *SNAClientImpl) PowerOnSourceVM(ctx context.Context, vmwareVMID, vcent
 	}
 
 	// Create HTTP request
-	url := fmt.Sprintf("%s/api/v1/vm/%s/power-on", vmc.snaHost, vmwareVMID)
+	snaHost, err := vmc.endpointFor(ctx, vcenter)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/api/v1/vm/%s/power-on", snaHost, vmwareVMID)
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(requestBody))
 	if err != nil {
 		return fmt.Errorf("failed to create power-on request: %w", err)
@@ -148,7 +166,11 @@ func (vmc *SNAClientImpl) PowerOffSourceVM(ctx context.Context, vmwareVMID, vcen
 	}
 
 	// Create HTTP request
-	url := fmt.Sprintf("%s/api/v1/vm/%s/power-off", vmc.snaHost, vmwareVMID)
+	snaHost, err := vmc.endpointFor(ctx, vcenter)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/api/v1/vm/%s/power-off", snaHost, vmwareVMID)
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(requestBody))
 	if err != nil {
 		return fmt.Errorf("failed to create power-off request: %w", err)
@@ -218,7 +240,11 @@ func (vmc *SNAClientImpl) GetVMPowerState(ctx context.Context, vmwareVMID, vcent
 	params.Add("password", password)
 
 	// Create HTTP request
-	url := fmt.Sprintf("%s/api/v1/vm/%s/power-state?%s", vmc.snaHost, vmwareVMID, params.Encode())
+	snaHost, err := vmc.endpointFor(ctx, vcenter)
+	if err != nil {
+		return "unknown", err
+	}
+	url := fmt.Sprintf("%s/api/v1/vm/%s/power-state?%s", snaHost, vmwareVMID, params.Encode())
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return "unknown", fmt.Errorf("failed to create power-state request: %w", err)
@@ -57,6 +57,9 @@ type UnifiedFailoverEngine struct {
 	// 🆕 NEW: Multi-volume snapshot service for complete VM protection
 	multiVolumeSnapshotService *MultiVolumeSnapshotService
 
+	// 🆕 NEW: Customer pre/post job hooks attached to the VM context
+	jobHookService *services.JobHookService
+
 	// Enhanced services
 	networkMappingService *services.NetworkMappingService
 	networkConfigProvider *NetworkConfigProvider
@@ -66,6 +69,7 @@ type UnifiedFailoverEngine struct {
 	vmInfoService         services.VMInfoProvider
 	validator             *PreFailoverValidator
 	snaClient             SNAClient
+	snaRouter             *services.SNARouter // Picks the SNA serving each VM's vCenter
 }
 
 // UnifiedFailoverResult represents the result of a unified failover operation
@@ -129,6 +133,7 @@ func NewUnifiedFailoverEngine(
 		validation:                 validation,
 		helpers:                    helpers,
 		multiVolumeSnapshotService: multiVolumeSnapshotService, // 🆕 NEW: Multi-volume snapshot support
+		jobHookService:             services.NewJobHookService(db, jobTracker, nil), // Failover hooks are SHA-side only (no guest credentials)
 		networkMappingService:      networkMappingService,
 		networkConfigProvider:      networkConfigProvider,
 		volumeClient:               volumeClient,
@@ -137,6 +142,7 @@ func NewUnifiedFailoverEngine(
 		vmInfoService:              vmInfoService,
 		validator:                  validator,
 		snaClient:                  snaClient,
+		snaRouter:                  services.NewSNARouter(db),
 	}
 }
 
@@ -194,11 +200,27 @@ func (ufe *UnifiedFailoverEngine) ExecuteUnifiedFailover(ctx context.Context, co
 		Metadata:        make(map[string]interface{}),
 	}
 
+	hookOwner := services.HookOwner{Type: database.HookOwnerFailover, ID: config.ContextID}
+	hookCtx := services.HookContext{
+		JobID:       config.FailoverJobID,
+		JobType:     "failover",
+		Operation:   fmt.Sprintf("unified-%s-failover", config.FailoverType),
+		VMName:      config.VMName,
+		VMContextID: config.ContextID,
+	}
+
 	// Ensure job completion is tracked with sanitized error messages
 	defer func() {
 		result.EndTime = time.Now()
 		result.Duration = result.EndTime.Sub(result.StartTime)
 
+		// post_job hooks run regardless of outcome; their failure never changes the failover result
+		hookCtx.Status = result.Status
+		hookCtx.ErrorMessage = result.Error
+		if err := ufe.jobHookService.RunHooks(ctx, jobID, database.HookTypePostJob, hookCtx, hookOwner); err != nil {
+			ufe.jobTracker.Logger(ctx).Warn("post_job hook failed", "error", err)
+		}
+
 		if result.Error != "" {
 			// Sanitize error for JobLog (keeps technical details but adds user message)
 			ufe.jobTracker.EndJob(ctx, jobID, joblog.StatusFailed, fmt.Errorf(result.Error))
@@ -213,6 +235,13 @@ func (ufe *UnifiedFailoverEngine) ExecuteUnifiedFailover(ctx context.Context, co
 		}
 	}()
 
+	// Run pre_job hooks before touching the VM
+	if err := ufe.jobHookService.RunHooks(ctx, jobID, database.HookTypePreJob, hookCtx, hookOwner); err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		return result, err
+	}
+
 	// Execute the 9-phase unified failover workflow
 	if err := ufe.executeUnifiedWorkflow(ctx, jobID, config, result); err != nil {
 		result.Status = "failed"
@@ -1347,9 +1376,16 @@ func (ufe *UnifiedFailoverEngine) discoverVMFromVMA(ctx context.Context, vmName,
 		return nil, fmt.Errorf("failed to marshal SNA discovery request: %w", err)
 	}
 
-	// Make HTTP POST call to SNA discovery API (same pattern as GUI)
+	// Make HTTP POST call to the discovery API of the SNA serving the vCenter (same pattern as GUI)
+	snaEndpoint, err := ufe.snaRouter.EndpointForScope(ctx, services.SNAPlacement{
+		VCenterHost: vCenterHost,
+		Datacenter:  datacenter,
+	}, services.DefaultSNAEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to route SNA discovery: %w", err)
+	}
 	client := &http.Client{Timeout: 60 * time.Second}
-	resp, err := client.Post("http://localhost:9081/api/v1/discover", "application/json", bytes.NewBuffer(requestBytes))
+	resp, err := client.Post(snaEndpoint+"/api/v1/discover", "application/json", bytes.NewBuffer(requestBytes))
 	if err != nil {
 		return nil, fmt.Errorf("SNA discovery HTTP call failed: %w", err)
 	}
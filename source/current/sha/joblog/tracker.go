@@ -111,6 +111,9 @@ func (t *Tracker) StartJob(ctx context.Context, input JobStart) (context.Context
 		slog.Any("owner", input.Owner),
 	)
 
+	rememberJob(jobID, input)
+	notifyTransition(Transition{JobID: jobID, Status: StatusRunning, Time: now})
+
 	return ctxWithJob, jobID, nil
 }
 
@@ -178,6 +181,12 @@ func (t *Tracker) EndJob(ctx context.Context, jobID string, status Status, err e
 		slog.Any("error", errorMessage),
 	)
 
+	transition := Transition{JobID: jobID, Status: status, Time: now}
+	if errorMessage != nil {
+		transition.Error = *errorMessage
+	}
+	notifyTransition(transition)
+
 	return nil
 }
 
@@ -193,7 +202,8 @@ func (t *Tracker) MarkJobProgress(ctx context.Context, jobID string, percent uin
 		WHERE id = ?
 	`
 
-	result, err := t.db.ExecContext(ctx, query, percent, time.Now(), jobID)
+	now := time.Now()
+	result, err := t.db.ExecContext(ctx, query, percent, now, jobID)
 	if err != nil {
 		return fmt.Errorf("failed to update job progress: %w", err)
 	}
@@ -210,6 +220,8 @@ func (t *Tracker) MarkJobProgress(ctx context.Context, jobID string, percent uin
 		slog.Int("percent", int(percent)),
 	)
 
+	notifyTransition(Transition{JobID: jobID, Status: StatusRunning, Percent: &percent, Time: now})
+
 	return nil
 }
 
@@ -275,6 +287,8 @@ func (t *Tracker) StartStep(ctx context.Context, jobID string, input StepStart)
 		slog.Int("sequence", input.Seq),
 	)
 
+	notifyTransition(Transition{JobID: jobID, StepID: stepID, StepName: input.Name, Status: StatusRunning, Time: now})
+
 	return ctxWithStep, stepID, nil
 }
 
@@ -344,6 +358,12 @@ func (t *Tracker) EndStep(stepID int64, status Status, err error) error {
 			slog.String("status", string(status)),
 			slog.Any("error", errorMessage),
 		)
+
+		transition := Transition{JobID: jobID, StepID: stepID, StepName: stepName, Status: status, Time: now}
+		if errorMessage != nil {
+			transition.Error = *errorMessage
+		}
+		notifyTransition(transition)
 	}
 
 	return nil
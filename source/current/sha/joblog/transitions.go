@@ -0,0 +1,75 @@
+package joblog
+
+import (
+	"sync"
+	"time"
+)
+
+// Transition is a job or step lifecycle change reported to transition listeners
+type Transition struct {
+	JobID         string    `json:"job_id"`
+	ExternalJobID string    `json:"external_job_id,omitempty"`
+	JobType       string    `json:"job_type,omitempty"`
+	Operation     string    `json:"operation,omitempty"`
+	StepID        int64     `json:"step_id,omitempty"`   // 0 for job transitions
+	StepName      string    `json:"step_name,omitempty"` // Empty for job transitions
+	Status        Status    `json:"status"`
+	Percent       *uint8    `json:"percent,omitempty"` // Set by MarkJobProgress
+	Error         string    `json:"error,omitempty"`
+	Time          time.Time `json:"time"`
+}
+
+// TransitionListener receives transitions; it runs on the caller's goroutine and must not block
+type TransitionListener func(Transition)
+
+// Subsystems create their own trackers, so listeners are registered for the whole
+// process rather than per tracker
+var transitions = struct {
+	mu        sync.RWMutex
+	listeners []TransitionListener
+	jobs      map[string]Transition // Identity of running jobs, for step transitions
+}{jobs: make(map[string]Transition)}
+
+// AddTransitionListener registers a listener for the transitions of every tracker
+func AddTransitionListener(listener TransitionListener) {
+	transitions.mu.Lock()
+	defer transitions.mu.Unlock()
+	transitions.listeners = append(transitions.listeners, listener)
+}
+
+// rememberJob records the identity of a started job so its step transitions carry it
+func rememberJob(jobID string, input JobStart) {
+	identity := Transition{JobID: jobID, JobType: input.JobType, Operation: input.Operation}
+	if input.ExternalJobID != nil {
+		identity.ExternalJobID = *input.ExternalJobID
+	}
+
+	transitions.mu.Lock()
+	defer transitions.mu.Unlock()
+	if len(transitions.listeners) > 0 {
+		transitions.jobs[jobID] = identity
+	}
+}
+
+// notifyTransition fills in the job identity and passes a transition to the listeners
+func notifyTransition(transition Transition) {
+	transitions.mu.Lock()
+	identity, known := transitions.jobs[transition.JobID]
+	if transition.StepID == 0 && transition.Status.IsTerminal() {
+		delete(transitions.jobs, transition.JobID)
+	}
+	listeners := transitions.listeners
+	transitions.mu.Unlock()
+
+	if known {
+		transition.ExternalJobID = identity.ExternalJobID
+		transition.JobType = identity.JobType
+		transition.Operation = identity.Operation
+	}
+	if transition.Time.IsZero() {
+		transition.Time = time.Now()
+	}
+	for _, listener := range listeners {
+		listener(transition)
+	}
+}
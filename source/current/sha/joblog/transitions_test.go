@@ -0,0 +1,84 @@
+package joblog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransitionListener(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	handler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+	tracker := New(db, handler)
+	defer tracker.Close()
+
+	var mu sync.Mutex
+	var received []Transition
+	AddTransitionListener(func(transition Transition) {
+		mu.Lock()
+		defer mu.Unlock()
+		if transition.ExternalJobID == "failover-vm-1" {
+			received = append(received, transition)
+		}
+	})
+
+	ctx := context.Background()
+
+	mock.ExpectExec("INSERT INTO job_tracking").WillReturnResult(sqlmock.NewResult(1, 1))
+	ctx, jobID, err := tracker.StartJob(ctx, JobStart{
+		JobType:       "failover",
+		Operation:     "live-failover",
+		ExternalJobID: stringPtr("failover-vm-1"),
+	})
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT COALESCE\\(MAX\\(seq\\), 0\\) \\+ 1").
+		WithArgs(jobID).
+		WillReturnRows(sqlmock.NewRows([]string{"seq"}).AddRow(1))
+	mock.ExpectExec("INSERT INTO job_steps").WillReturnResult(sqlmock.NewResult(7, 1))
+	_, stepID, err := tracker.StartStep(ctx, jobID, StepStart{Name: "vm-power-off"})
+	require.NoError(t, err)
+
+	mock.ExpectExec("UPDATE job_steps").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT job_id, name FROM job_steps").
+		WithArgs(stepID).
+		WillReturnRows(sqlmock.NewRows([]string{"job_id", "name"}).AddRow(jobID, "vm-power-off"))
+	require.NoError(t, tracker.EndStep(stepID, StatusFailed, errors.New("power off timed out")))
+
+	mock.ExpectExec("UPDATE job_tracking").WillReturnResult(sqlmock.NewResult(1, 1))
+	require.NoError(t, tracker.EndJob(ctx, jobID, StatusFailed, errors.New("power off timed out")))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, 4)
+	for _, transition := range received {
+		assert.Equal(t, jobID, transition.JobID)
+		assert.Equal(t, "failover", transition.JobType)
+		assert.False(t, transition.Time.IsZero())
+	}
+	assert.Equal(t, StatusRunning, received[0].Status)
+	assert.Zero(t, received[0].StepID)
+	assert.Equal(t, "vm-power-off", received[1].StepName)
+	assert.Equal(t, StatusRunning, received[1].Status)
+	assert.Equal(t, int64(7), received[2].StepID)
+	assert.Equal(t, StatusFailed, received[2].Status)
+	assert.Equal(t, "power off timed out", received[2].Error)
+	assert.Equal(t, StatusFailed, received[3].Status)
+
+	// Finished jobs are forgotten
+	transitions.mu.RLock()
+	_, known := transitions.jobs[jobID]
+	transitions.mu.RUnlock()
+	assert.False(t, known)
+}
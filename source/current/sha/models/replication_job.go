@@ -35,6 +35,8 @@ type VMInfo struct {
 	Name       string        `json:"name" binding:"required"`
 	Path       string        `json:"path" binding:"required"`
 	Datacenter string        `json:"datacenter" binding:"required"`
+	ESXiHost   string        `json:"esxi_host,omitempty"` // ESXi host the VM runs on
+	Cluster    string        `json:"cluster,omitempty"`   // Cluster of that host (empty for standalone hosts)
 	CPUs       int           `json:"cpus" binding:"required"`
 	MemoryMB   int           `json:"memory_mb" binding:"required"`
 	Disks      []DiskInfo    `json:"disks" binding:"required"`
@@ -49,6 +51,10 @@ type VMInfo struct {
 	FolderPath         string `json:"folder_path"`          // vCenter folder path
 	VMwareToolsStatus  string `json:"vmware_tools_status"`  // VMware Tools status
 	VMwareToolsVersion string `json:"vmware_tools_version"` // VMware Tools version
+
+	// vSphere metadata for rule-based machine groups
+	ResourcePool string   `json:"resource_pool,omitempty"` // Resource pool name
+	Tags         []string `json:"tags"`                    // Attached tags as "category:tag" (nil: tags could not be read)
 }
 
 // DiskInfo represents VM disk information
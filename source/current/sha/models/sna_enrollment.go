@@ -118,6 +118,7 @@ type EnrollmentResult struct {
 	SSHUser     string `json:"ssh_user,omitempty" example:"vma_tunnel"`
 	SSHOptions  string `json:"ssh_options,omitempty" example:"restrict,permitopen=..."`
 	HostKeyHash string `json:"host_key_hash,omitempty" example:"SHA256:abc123..."`
+	TunnelPort  int    `json:"tunnel_port,omitempty" example:"9082"` // SHA-side port for the SNA API reverse tunnel
 	Message     string `json:"message,omitempty" example:"Enrollment approved, connection authorized"`
 }
 
@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// VMConfiguration is the full VMware VM configuration captured alongside a
+// backup's disk data, so restores can recreate a faithful VM
+type VMConfiguration struct {
+	// Identity
+	UUID            string `json:"uuid"`
+	InstanceUUID    string `json:"instance_uuid,omitempty"`
+	Name            string `json:"name"`
+	GuestID         string `json:"guest_id"`
+	GuestFullName   string `json:"guest_full_name,omitempty"`
+	HardwareVersion string `json:"hardware_version"` // e.g. "vmx-19"
+	Annotation      string `json:"annotation,omitempty"`
+	FolderPath      string `json:"folder_path,omitempty"`
+
+	// Compute
+	NumCPUs           int   `json:"num_cpus"`
+	CoresPerSocket    int   `json:"cores_per_socket"`
+	MemoryMB          int   `json:"memory_mb"`
+	CPUHotAddEnabled  bool  `json:"cpu_hot_add_enabled"`
+	MemHotAddEnabled  bool  `json:"memory_hot_add_enabled"`
+	CPUReservationMHz int64 `json:"cpu_reservation_mhz,omitempty"`
+	MemReservationMB  int64 `json:"memory_reservation_mb,omitempty"`
+
+	// Firmware
+	Firmware   string `json:"firmware"` // "bios" or "efi"
+	SecureBoot bool   `json:"secure_boot"`
+	VTPM       bool   `json:"vtpm"`
+
+	// Devices
+	NICs        []VMConfigNIC        `json:"nics"`
+	Controllers []VMConfigController `json:"controllers"`
+	Disks       []VMConfigDisk       `json:"disks"`
+
+	// Metadata
+	CustomAttributes map[string]string `json:"custom_attributes,omitempty"`
+	Tags             []VMConfigTag     `json:"tags,omitempty"`
+
+	CollectedAt time.Time `json:"collected_at"`
+}
+
+// VMConfigNIC describes one virtual network adapter
+type VMConfigNIC struct {
+	Key            int32  `json:"key"`
+	Label          string `json:"label"`
+	AdapterType    string `json:"adapter_type"` // vmxnet3, e1000, e1000e, ...
+	MACAddress     string `json:"mac_address"`
+	AddressType    string `json:"address_type"` // "assigned", "manual" or "generated"
+	NetworkName    string `json:"network_name"`
+	PortGroupKey   string `json:"port_group_key,omitempty"` // Distributed port group key (DVS backings only)
+	Connected      bool   `json:"connected"`
+	StartConnected bool   `json:"start_connected"`
+}
+
+// VMConfigController describes one storage controller
+type VMConfigController struct {
+	Key       int32  `json:"key"`
+	Label     string `json:"label"`
+	Type      string `json:"type"` // pvscsi, lsilogic, lsilogic-sas, buslogic, sata, nvme, ide
+	BusNumber int32  `json:"bus_number"`
+	SharedBus string `json:"shared_bus,omitempty"` // SCSI bus sharing mode
+}
+
+// VMConfigDisk describes one virtual disk's placement on its controller
+type VMConfigDisk struct {
+	Key             int32  `json:"key"`
+	Label           string `json:"label"`
+	ControllerKey   int32  `json:"controller_key"`
+	UnitNumber      int32  `json:"unit_number"`
+	CapacityBytes   int64  `json:"capacity_bytes"`
+	FileName        string `json:"file_name"`
+	DiskMode        string `json:"disk_mode,omitempty"`
+	ThinProvisioned bool   `json:"thin_provisioned"`
+}
+
+// VMConfigTag is a vSphere tag attached to the VM
+type VMConfigTag struct {
+	Category string `json:"category"`
+	Name     string `json:"name"`
+}
@@ -0,0 +1,62 @@
+// Package restore provides per-disk restore planning for whole-VM restores
+// Disks excluded from a backup by policy are planned as blank disks of the
+// original size; the SNA creates them empty so the restored VM keeps its disk layout
+package restore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vexxhost/migratekit-sha/database"
+)
+
+// DiskRestorePlan describes how one disk of a backup is restored
+type DiskRestorePlan struct {
+	BackupDiskID  int64  `json:"backup_disk_id"`
+	DiskIndex     int    `json:"disk_index"`
+	VMwareDiskKey int    `json:"vmware_disk_key"`
+	UnitNumber    *int   `json:"unit_number,omitempty"`
+	SizeBytes     int64  `json:"size_bytes"`
+	SourcePath    string `json:"source_path,omitempty"` // QCOW2 to restore from (empty when Blank)
+	Blank         bool   `json:"blank"`                 // Disk was excluded - create an empty volume
+}
+
+// PlanDiskRestore lists every disk of a backup in disk order, marking excluded
+// disks as blank so callers create empty volumes instead of copying data
+func PlanDiskRestore(ctx context.Context, db database.Connection, backupID string) ([]DiskRestorePlan, error) {
+	var disks []database.BackupDisk
+	err := db.GetGormDB().WithContext(ctx).
+		Where("backup_job_id = ? AND status IN ?", backupID,
+			[]string{"completed", database.BackupDiskStatusExcluded}).
+		Order("disk_index ASC").
+		Find(&disks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load backup disks for %s: %w", backupID, err)
+	}
+	if len(disks) == 0 {
+		return nil, fmt.Errorf("no restorable disks found for backup %s", backupID)
+	}
+
+	plans := make([]DiskRestorePlan, 0, len(disks))
+	for _, disk := range disks {
+		plan := DiskRestorePlan{
+			BackupDiskID:  disk.ID,
+			DiskIndex:     disk.DiskIndex,
+			VMwareDiskKey: disk.VMwareDiskKey,
+			UnitNumber:    disk.UnitNumber,
+			SizeBytes:     disk.SizeGB * 1024 * 1024 * 1024,
+			Blank:         disk.Status == database.BackupDiskStatusExcluded,
+		}
+
+		if !plan.Blank {
+			if disk.QCOW2Path == nil || *disk.QCOW2Path == "" {
+				return nil, fmt.Errorf("backup disk %d of %s has no QCOW2 path", disk.DiskIndex, backupID)
+			}
+			plan.SourcePath = *disk.QCOW2Path
+		}
+
+		plans = append(plans, plan)
+	}
+
+	return plans, nil
+}
@@ -331,6 +331,15 @@ func (mm *MountManager) findBackupDiskFile(ctx context.Context, backupID string,
 		First(&disk).Error
 
 	if err != nil {
+		// Give a clear answer for disks skipped by backup policy rather than "not found"
+		var excludedCount int64
+		mm.db.GetGormDB().WithContext(ctx).
+			Table("backup_disks").
+			Where("backup_job_id = ? AND disk_index = ? AND status = ?", backupID, diskIndex, database.BackupDiskStatusExcluded).
+			Count(&excludedCount)
+		if excludedCount > 0 {
+			return 0, "", fmt.Errorf("disk %d was excluded from backup %s by policy and has no data to mount", diskIndex, backupID)
+		}
 		return 0, "", fmt.Errorf("disk not found: backup_id=%s, disk_index=%d: %w", backupID, diskIndex, err)
 	}
 
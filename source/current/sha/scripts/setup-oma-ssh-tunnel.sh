@@ -47,7 +47,9 @@ Match User vma_tunnel
     AllowStreamLocalForwarding no
     GatewayPorts no
     PermitOpen 127.0.0.1:10809
-    PermitListen 127.0.0.1:9081
+    # Each enrolled SNA gets its own API tunnel port (9081-9180); the per-key
+    # permitlisten option in authorized_keys pins every SNA to its port
+    PermitListen 127.0.0.1:*
 EOF
 fi
 
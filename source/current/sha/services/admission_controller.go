@@ -0,0 +1,581 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/config"
+	"github.com/vexxhost/migratekit-sha/database"
+)
+
+// =============================================================================
+// ADMISSION CONTROL - Caps data-moving jobs on shared infrastructure
+// =============================================================================
+// Scheduled replications and protection flow backups ask for admission before their
+// job is started. A job is admitted only while its datastores, ESXi host and SNA are
+// below their job caps, the SHA write throughput is below its cap and enough NBD ports
+// are free; otherwise it waits in a FIFO queue (first fit, so a job on an idle datastore
+// is not held up by one waiting on a busy LUN). Usage is read from the running jobs in
+// the database plus jobs admitted but not yet started, so it survives SHA restarts.
+
+const (
+	// admissionCheckInterval re-checks queued jobs as running jobs finish
+	admissionCheckInterval = 10 * time.Second
+	// defaultAdmissionMaxWait drops queued jobs that never got capacity
+	defaultAdmissionMaxWait = 4 * time.Hour
+
+	// DefaultSNAID identifies the SNA jobs run through when a VM has no SNA assignment
+	DefaultSNAID = "default"
+)
+
+// AdmissionLimits caps simultaneous data-moving jobs on shared resources (0 = unlimited)
+type AdmissionLimits struct {
+	MaxJobsPerDatastore int   `json:"max_jobs_per_datastore"`
+	MaxJobsPerESXiHost  int   `json:"max_jobs_per_esxi_host"`
+	MaxJobsPerSNA       int   `json:"max_jobs_per_sna"`
+	MaxSHAWriteBps      int64 `json:"max_sha_write_bps"`
+	MinFreeNBDPorts     int   `json:"min_free_nbd_ports"` // Ports kept free for manual jobs
+}
+
+// DefaultAdmissionLimits returns the admission limits from the environment (SHA_ADMISSION_*)
+func DefaultAdmissionLimits() AdmissionLimits {
+	return AdmissionLimits{
+		MaxJobsPerDatastore: config.GetEnvAsInt("SHA_ADMISSION_MAX_PER_DATASTORE", 4),
+		MaxJobsPerESXiHost:  config.GetEnvAsInt("SHA_ADMISSION_MAX_PER_ESXI_HOST", 6),
+		MaxJobsPerSNA:       config.GetEnvAsInt("SHA_ADMISSION_MAX_PER_SNA", 16),
+		MaxSHAWriteBps:      int64(config.GetEnvAsInt("SHA_ADMISSION_MAX_WRITE_MBPS", 0)) * 1024 * 1024,
+		MinFreeNBDPorts:     config.GetEnvAsInt("SHA_ADMISSION_MIN_FREE_NBD_PORTS", 0),
+	}
+}
+
+// Validate checks the limits are usable
+func (l AdmissionLimits) Validate() error {
+	if l.MaxJobsPerDatastore < 0 || l.MaxJobsPerESXiHost < 0 || l.MaxJobsPerSNA < 0 ||
+		l.MaxSHAWriteBps < 0 || l.MinFreeNBDPorts < 0 {
+		return fmt.Errorf("admission limits must not be negative")
+	}
+	return nil
+}
+
+// AdmissionRequest describes the resources a job will load
+type AdmissionRequest struct {
+	JobType     string   `json:"job_type"` // "backup" or "replication"
+	VMContextID string   `json:"vm_context_id"`
+	VMName      string   `json:"vm_name"`
+	Owner       string   `json:"owner,omitempty"` // "schedule:<id>" or "flow:<id>"
+	Datastores  []string `json:"datastores"`
+	ESXiHost    string   `json:"esxi_host,omitempty"`
+	SNAID       string   `json:"sna_id"`
+	NBDPorts    int      `json:"nbd_ports"` // SHA NBD ports the job allocates (one per disk for backups)
+}
+
+// AdmissionTicket is a queued or admitted job
+type AdmissionTicket struct {
+	ID         string           `json:"id"`
+	Request    AdmissionRequest `json:"request"`
+	QueuedAt   time.Time        `json:"queued_at"`
+	AdmittedAt *time.Time       `json:"admitted_at,omitempty"`
+	WaitReason string           `json:"wait_reason,omitempty"` // Why a queued job is still waiting
+}
+
+// AdmissionUsage is the load on shared resources, including admitted jobs not yet started
+type AdmissionUsage struct {
+	Datastores   map[string]int `json:"datastores"`
+	ESXiHosts    map[string]int `json:"esxi_hosts"`
+	SNAs         map[string]int `json:"snas"`
+	SNALimits    map[string]int `json:"sna_limits,omitempty"` // Job capacity each registered SNA declared
+	RunningJobs  int            `json:"running_jobs"`
+	WriteBps     int64          `json:"write_bps"`
+	NBDPortsFree *int           `json:"nbd_ports_free,omitempty"` // nil when no port allocator is available
+}
+
+// AdmissionStatus is the admission queue as shown through the API
+type AdmissionStatus struct {
+	Limits   AdmissionLimits   `json:"limits"`
+	Usage    *AdmissionUsage   `json:"usage,omitempty"`
+	Queued   []AdmissionTicket `json:"queued"`
+	Admitted []AdmissionTicket `json:"admitted"` // Admitted, job not started yet
+}
+
+type admissionWaiter struct {
+	ticket *AdmissionTicket
+	ready  chan struct{}
+}
+
+// AdmissionController admits scheduled jobs as datastore, host, SNA and SHA capacity allows
+type AdmissionController struct {
+	db            database.Connection
+	portAllocator *NBDPortAllocator
+	snaRouter     *SNARouter
+	maxWait       time.Duration
+
+	mu       sync.Mutex
+	limits   AdmissionLimits
+	queue    []*admissionWaiter
+	admitted map[string]*AdmissionTicket
+	wake     chan struct{}
+}
+
+// NewAdmissionController creates a new admission controller
+func NewAdmissionController(db database.Connection, limits AdmissionLimits) *AdmissionController {
+	return &AdmissionController{
+		db:       db,
+		maxWait:  defaultAdmissionMaxWait,
+		limits:   limits,
+		admitted: make(map[string]*AdmissionTicket),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// SetPortAllocator enables the NBD port capacity check
+func (a *AdmissionController) SetPortAllocator(portAllocator *NBDPortAllocator) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.portAllocator = portAllocator
+}
+
+// SetSNARouter attributes jobs to the SNA serving their VM instead of a single default SNA
+func (a *AdmissionController) SetSNARouter(router *SNARouter) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.snaRouter = router
+}
+
+// Start admits queued jobs as capacity frees up until ctx is cancelled
+func (a *AdmissionController) Start(ctx context.Context) {
+	ticker := time.NewTicker(admissionCheckInterval)
+	defer ticker.Stop()
+
+	log.WithField("limits", a.GetLimits()).Info("🚦 Job admission controller started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("🛑 Job admission controller stopped")
+			return
+		case <-ticker.C:
+		case <-a.wake:
+		}
+		a.admitQueued(ctx)
+	}
+}
+
+// GetLimits returns the current admission limits
+func (a *AdmissionController) GetLimits() AdmissionLimits {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.limits
+}
+
+// SetLimits replaces the admission limits and re-checks the queue
+func (a *AdmissionController) SetLimits(limits AdmissionLimits) error {
+	if err := limits.Validate(); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.limits = limits
+	a.mu.Unlock()
+
+	log.WithField("limits", limits).Info("Job admission limits updated")
+	a.poke()
+	return nil
+}
+
+// BuildRequest resolves where a VM's job will read and write from its discovered placement
+func (a *AdmissionController) BuildRequest(ctx context.Context, jobType, vmContextID, owner string) AdmissionRequest {
+	req := AdmissionRequest{
+		JobType:     jobType,
+		VMContextID: vmContextID,
+		Owner:       owner,
+		SNAID:       DefaultSNAID,
+	}
+	if a == nil {
+		return req
+	}
+
+	gormDB := a.db.GetGormDB().WithContext(ctx)
+
+	var vmCtx database.VMReplicationContext
+	if err := gormDB.Select("context_id, vm_name, esxi_host").Where("context_id = ?", vmContextID).First(&vmCtx).Error; err == nil {
+		req.VMName = vmCtx.VMName
+		if vmCtx.ESXiHost != nil {
+			req.ESXiHost = *vmCtx.ESXiHost
+		}
+	}
+
+	var disks []database.VMDisk
+	if err := gormDB.Select("id, disk_id, datastore").Where("vm_context_id = ?", vmContextID).Find(&disks).Error; err == nil {
+		// vm_disks keeps a row per disk per job, so de-duplicate by datastore and disk
+		seenDatastores := make(map[string]bool)
+		seenDisks := make(map[string]bool)
+		for _, disk := range disks {
+			if disk.Datastore != "" && !seenDatastores[disk.Datastore] {
+				seenDatastores[disk.Datastore] = true
+				req.Datastores = append(req.Datastores, disk.Datastore)
+			}
+			seenDisks[disk.DiskID] = true
+		}
+		sort.Strings(req.Datastores)
+		if jobType == "backup" {
+			req.NBDPorts = len(seenDisks)
+		}
+	}
+	if jobType == "backup" && req.NBDPorts == 0 {
+		req.NBDPorts = 1
+	}
+
+	if a.snaRouter != nil {
+		if route, err := a.snaRouter.SelectForVM(ctx, vmContextID, DefaultSNAEndpoint); err == nil {
+			req.SNAID = route.SNAID
+		}
+	}
+
+	return req
+}
+
+// Admit queues jobs and delivers each ticket once its job may start; tickets of jobs that
+// wait longer than the maximum wait (or until ctx is cancelled) are never delivered.
+// Every delivered ticket must be released once its job has been started (or failed to).
+// A nil controller admits everything immediately.
+func (a *AdmissionController) Admit(ctx context.Context, requests []AdmissionRequest) <-chan *AdmissionTicket {
+	out := make(chan *AdmissionTicket, len(requests))
+
+	if a == nil {
+		now := time.Now()
+		for _, req := range requests {
+			out <- &AdmissionTicket{ID: uuid.New().String(), Request: req, QueuedAt: now, AdmittedAt: &now}
+		}
+		close(out)
+		return out
+	}
+
+	var wg sync.WaitGroup
+	a.mu.Lock()
+	for _, req := range requests {
+		waiter := &admissionWaiter{
+			ticket: &AdmissionTicket{ID: uuid.New().String(), Request: req, QueuedAt: time.Now()},
+			ready:  make(chan struct{}),
+		}
+		a.queue = append(a.queue, waiter)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			timeout := time.NewTimer(a.maxWait)
+			defer timeout.Stop()
+
+			select {
+			case <-waiter.ready:
+				out <- waiter.ticket
+			case <-ctx.Done():
+				a.withdraw(waiter)
+			case <-timeout.C:
+				log.WithFields(log.Fields{
+					"vm_context_id": waiter.ticket.Request.VMContextID,
+					"job_type":      waiter.ticket.Request.JobType,
+					"wait_reason":   waiter.ticket.WaitReason,
+					"max_wait":      a.maxWait,
+				}).Warn("Job gave up waiting for admission")
+				a.withdraw(waiter)
+			}
+		}()
+	}
+	a.mu.Unlock()
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	a.poke()
+	return out
+}
+
+// Release frees the reservation of an admitted job once it has started (or failed to)
+func (a *AdmissionController) Release(ticketID string) {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	delete(a.admitted, ticketID)
+	a.mu.Unlock()
+	a.poke()
+}
+
+// Discard releases the remaining tickets of an Admit call that is no longer consumed;
+// cancel the context passed to Admit first so queued jobs are withdrawn
+func (a *AdmissionController) Discard(tickets <-chan *AdmissionTicket) {
+	go func() {
+		for ticket := range tickets {
+			a.Release(ticket.ID)
+		}
+	}()
+}
+
+// withdraw removes a waiter that stopped waiting, releasing it if it was admitted meanwhile
+func (a *AdmissionController) withdraw(waiter *admissionWaiter) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i, queued := range a.queue {
+		if queued == waiter {
+			a.queue = append(a.queue[:i], a.queue[i+1:]...)
+			return
+		}
+	}
+	delete(a.admitted, waiter.ticket.ID)
+}
+
+// poke schedules an admission pass
+func (a *AdmissionController) poke() {
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
+// admitQueued admits every queued job that fits, in queue order
+func (a *AdmissionController) admitQueued(ctx context.Context) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.queue) == 0 {
+		return
+	}
+
+	usage, err := a.loadUsage(ctx)
+	if err != nil {
+		log.WithError(err).Warn("Failed to load resource usage for job admission - queued jobs keep waiting")
+		return
+	}
+
+	// Admitted jobs have no transfer rate yet - assume they'll move data like the running ones
+	var perJobBps int64
+	if usage.RunningJobs > 0 {
+		perJobBps = usage.WriteBps / int64(usage.RunningJobs)
+	}
+
+	remaining := a.queue[:0]
+	for _, waiter := range a.queue {
+		req := waiter.ticket.Request
+		if reason := a.blockReason(usage, req); reason != "" {
+			waiter.ticket.WaitReason = reason
+			remaining = append(remaining, waiter)
+			continue
+		}
+
+		usage.add(req, perJobBps)
+		now := time.Now()
+		waiter.ticket.AdmittedAt = &now
+		waiter.ticket.WaitReason = ""
+		a.admitted[waiter.ticket.ID] = waiter.ticket
+		close(waiter.ready)
+
+		log.WithFields(log.Fields{
+			"vm_context_id": req.VMContextID,
+			"vm_name":       req.VMName,
+			"job_type":      req.JobType,
+			"owner":         req.Owner,
+			"waited":        now.Sub(waiter.ticket.QueuedAt).Round(time.Second),
+		}).Info("🚦 Job admitted")
+	}
+	a.queue = remaining
+}
+
+// blockReason explains why a job cannot start under the current usage ("" if it can)
+func (a *AdmissionController) blockReason(usage *AdmissionUsage, req AdmissionRequest) string {
+	limits := a.limits
+
+	if limits.MaxJobsPerDatastore > 0 {
+		for _, datastore := range req.Datastores {
+			if usage.Datastores[datastore] >= limits.MaxJobsPerDatastore {
+				return fmt.Sprintf("datastore %s at limit (%d jobs)", datastore, limits.MaxJobsPerDatastore)
+			}
+		}
+	}
+	if limits.MaxJobsPerESXiHost > 0 && req.ESXiHost != "" && usage.ESXiHosts[req.ESXiHost] >= limits.MaxJobsPerESXiHost {
+		return fmt.Sprintf("ESXi host %s at limit (%d jobs)", req.ESXiHost, limits.MaxJobsPerESXiHost)
+	}
+	snaLimit := limits.MaxJobsPerSNA
+	if declared := usage.SNALimits[req.SNAID]; declared > 0 && (snaLimit == 0 || declared < snaLimit) {
+		snaLimit = declared
+	}
+	if snaLimit > 0 && usage.SNAs[req.SNAID] >= snaLimit {
+		return fmt.Sprintf("SNA %s at limit (%d jobs)", req.SNAID, snaLimit)
+	}
+	if limits.MaxSHAWriteBps > 0 && usage.WriteBps >= limits.MaxSHAWriteBps {
+		return fmt.Sprintf("SHA write throughput at limit (%d B/s)", limits.MaxSHAWriteBps)
+	}
+	if usage.NBDPortsFree != nil && req.NBDPorts > 0 && *usage.NBDPortsFree-req.NBDPorts < limits.MinFreeNBDPorts {
+		return fmt.Sprintf("not enough free NBD ports (%d free, %d needed)", *usage.NBDPortsFree, req.NBDPorts+limits.MinFreeNBDPorts)
+	}
+	return ""
+}
+
+// add accounts for a job that is about to start
+func (u *AdmissionUsage) add(req AdmissionRequest, bps int64) {
+	for _, datastore := range req.Datastores {
+		u.Datastores[datastore]++
+	}
+	if req.ESXiHost != "" {
+		u.ESXiHosts[req.ESXiHost]++
+	}
+	u.SNAs[req.SNAID]++
+	u.WriteBps += bps
+	if u.NBDPortsFree != nil {
+		free := *u.NBDPortsFree - req.NBDPorts
+		u.NBDPortsFree = &free
+	}
+}
+
+// loadUsage reads the load of running jobs from the database and adds admitted jobs
+func (a *AdmissionController) loadUsage(ctx context.Context) (*AdmissionUsage, error) {
+	gormDB := a.db.GetGormDB().WithContext(ctx)
+
+	var running []struct {
+		ID               string
+		VMContextID      string
+		TransferSpeedBps int64
+	}
+	if err := gormDB.Table("backup_jobs").
+		Select("id, vm_context_id, transfer_speed_bps").
+		Where("status IN ?", []string{"pending", "running"}).
+		Scan(&running).Error; err != nil {
+		return nil, fmt.Errorf("failed to load running backups: %w", err)
+	}
+	var replications []struct {
+		ID               string
+		VMContextID      string
+		TransferSpeedBps int64
+	}
+	if err := gormDB.Table("replication_jobs").
+		Select("id, vm_context_id, transfer_speed_bps").
+		Where("status IN ?", []string{"pending", "replicating", "provisioning"}).
+		Scan(&replications).Error; err != nil {
+		return nil, fmt.Errorf("failed to load running replications: %w", err)
+	}
+	running = append(running, replications...)
+
+	usage := &AdmissionUsage{
+		Datastores:  make(map[string]int),
+		ESXiHosts:   make(map[string]int),
+		SNAs:        make(map[string]int),
+		RunningJobs: len(running),
+	}
+
+	contextIDs := make([]string, 0, len(running))
+	jobIDs := make([]string, 0, len(running))
+	for _, job := range running {
+		usage.WriteBps += job.TransferSpeedBps
+		contextIDs = append(contextIDs, job.VMContextID)
+		jobIDs = append(jobIDs, job.ID)
+	}
+
+	snaOfJob := map[string]string{}
+	if a.snaRouter != nil {
+		var err error
+		if snaOfJob, err = a.snaRouter.SNAForJobs(ctx, jobIDs); err != nil {
+			return nil, err
+		}
+		if usage.SNALimits, err = a.snaRouter.Capacities(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(contextIDs) > 0 {
+		var placements []struct {
+			VMContextID string
+			Datastore   string
+		}
+		if err := gormDB.Table("vm_disks").
+			Select("DISTINCT vm_context_id, datastore").
+			Where("vm_context_id IN ? AND datastore <> ''", contextIDs).
+			Scan(&placements).Error; err != nil {
+			return nil, fmt.Errorf("failed to load VM datastores: %w", err)
+		}
+		datastores := make(map[string][]string)
+		for _, placement := range placements {
+			datastores[placement.VMContextID] = append(datastores[placement.VMContextID], placement.Datastore)
+		}
+
+		var hosts []struct {
+			ContextID string
+			ESXiHost  *string
+		}
+		if err := gormDB.Table("vm_replication_contexts").
+			Select("context_id, esxi_host").
+			Where("context_id IN ?", contextIDs).
+			Scan(&hosts).Error; err != nil {
+			return nil, fmt.Errorf("failed to load VM hosts: %w", err)
+		}
+		esxiHosts := make(map[string]string)
+		for _, host := range hosts {
+			if host.ESXiHost != nil {
+				esxiHosts[host.ContextID] = *host.ESXiHost
+			}
+		}
+
+		for _, job := range running {
+			for _, datastore := range datastores[job.VMContextID] {
+				usage.Datastores[datastore]++
+			}
+			if host := esxiHosts[job.VMContextID]; host != "" {
+				usage.ESXiHosts[host]++
+			}
+			if snaID, ok := snaOfJob[job.ID]; ok {
+				usage.SNAs[snaID]++
+			} else {
+				usage.SNAs[DefaultSNAID]++
+			}
+		}
+	}
+
+	if a.portAllocator != nil {
+		free := a.portAllocator.GetAvailableCount()
+		usage.NBDPortsFree = &free
+	}
+
+	for _, ticket := range a.admitted {
+		usage.add(ticket.Request, 0)
+	}
+
+	return usage, nil
+}
+
+// GetStatus returns the admission limits, resource usage and queue
+func (a *AdmissionController) GetStatus(ctx context.Context) *AdmissionStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	status := &AdmissionStatus{
+		Limits:   a.limits,
+		Queued:   make([]AdmissionTicket, 0, len(a.queue)),
+		Admitted: make([]AdmissionTicket, 0, len(a.admitted)),
+	}
+	for _, waiter := range a.queue {
+		status.Queued = append(status.Queued, *waiter.ticket)
+	}
+	for _, ticket := range a.admitted {
+		status.Admitted = append(status.Admitted, *ticket)
+	}
+	sort.Slice(status.Admitted, func(i, j int) bool {
+		return status.Admitted[i].QueuedAt.Before(status.Admitted[j].QueuedAt)
+	})
+
+	usage, err := a.loadUsage(ctx)
+	if err != nil {
+		log.WithError(err).Warn("Failed to load resource usage for admission status")
+	} else {
+		status.Usage = usage
+	}
+
+	return status
+}
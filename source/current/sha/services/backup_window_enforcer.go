@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+)
+
+// WindowOverrunJob is a running job whose backup window has closed
+type WindowOverrunJob struct {
+	JobID       string       `json:"job_id"`
+	JobType     string       `json:"job_type"` // "replication" or "backup"
+	VMContextID string       `json:"vm_context_id"`
+	Owner       *WindowOwner `json:"owner,omitempty"` // Schedule or flow that started the job
+	Action      string       `json:"action"`          // "pause", "cancel", or "resume" once the window reopens
+	Reason      string       `json:"reason"`
+	WindowID    string       `json:"window_id,omitempty"` // Rule that closed
+}
+
+// WindowOverrunHandler pauses or cancels jobs that overrun their backup window, and
+// resumes the paused ones when their window reopens
+type WindowOverrunHandler interface {
+	HandleWindowOverrun(ctx context.Context, job WindowOverrunJob) error
+}
+
+// BackupWindowEnforcer watches running jobs and applies the overrun action of
+// the backup window rules once their window closes
+type BackupWindowEnforcer struct {
+	db             database.Connection
+	windowRepo     *database.BackupWindowRepository
+	windowService  *BackupWindowService
+	overrunHandler WindowOverrunHandler
+	checkInterval  time.Duration
+
+	// Jobs already handled, so each overrun is acted on once
+	handled map[string]bool
+
+	// Jobs paused by the enforcer, resumed once their window allows them again
+	// (persisted so a restarted SHA or a new leader still resumes them)
+	paused map[string]WindowOverrunJob
+}
+
+// NewBackupWindowEnforcer creates a new backup window enforcer
+func NewBackupWindowEnforcer(db database.Connection, windowService *BackupWindowService) *BackupWindowEnforcer {
+	return &BackupWindowEnforcer{
+		db:            db,
+		windowRepo:    database.NewBackupWindowRepository(db),
+		windowService: windowService,
+		checkInterval: time.Minute,
+		handled:       make(map[string]bool),
+		paused:        make(map[string]WindowOverrunJob),
+	}
+}
+
+// SetOverrunHandler sets the handler that pauses or cancels overrunning jobs
+func (e *BackupWindowEnforcer) SetOverrunHandler(handler WindowOverrunHandler) {
+	e.overrunHandler = handler
+}
+
+// Start checks running jobs against their backup windows until ctx is cancelled
+func (e *BackupWindowEnforcer) Start(ctx context.Context) {
+	ticker := time.NewTicker(e.checkInterval)
+	defer ticker.Stop()
+
+	e.loadPausedJobs(ctx)
+	log.WithFields(log.Fields{
+		"check_interval": e.checkInterval,
+		"paused_jobs":    len(e.paused),
+	}).Info("🕗 Backup window enforcer started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("🛑 Backup window enforcer stopped")
+			return
+		case <-ticker.C:
+			e.checkRunningJobs(ctx)
+		}
+	}
+}
+
+// checkRunningJobs applies overrun actions to running jobs outside their window
+func (e *BackupWindowEnforcer) checkRunningJobs(ctx context.Context) {
+	jobs, err := e.runningJobs()
+	if err != nil {
+		log.WithError(err).Warn("Failed to list running jobs for backup window enforcement")
+		return
+	}
+
+	now := time.Now()
+	running := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		running[job.JobID] = true
+		if e.handled[job.JobID] {
+			continue
+		}
+
+		var owners []WindowOwner
+		if job.Owner != nil {
+			owners = append(owners, *job.Owner)
+		}
+		decision, err := e.windowService.Check(ctx, now, owners...)
+		if err != nil {
+			log.WithError(err).WithField("job_id", job.JobID).Warn("Failed to evaluate backup window for running job")
+			continue
+		}
+		if decision.Allowed {
+			continue
+		}
+		if decision.OverrunAction == database.WindowOverrunNone {
+			// The window lets overrunning jobs finish - don't check this run again
+			e.handled[job.JobID] = true
+			continue
+		}
+
+		job.Action = decision.OverrunAction
+		job.Reason = decision.Reason
+		job.WindowID = decision.WindowID
+		e.handled[job.JobID] = true
+
+		logger := log.WithFields(log.Fields{
+			"job_id":   job.JobID,
+			"job_type": job.JobType,
+			"action":   job.Action,
+			"reason":   job.Reason,
+		})
+		if e.overrunHandler == nil {
+			logger.Warn("⚠️ Job overran its backup window - no job control available to apply overrun action")
+			continue
+		}
+		if err := e.overrunHandler.HandleWindowOverrun(ctx, job); err != nil {
+			logger.WithError(err).Error("Failed to apply backup window overrun action")
+			delete(e.handled, job.JobID) // Try again on the next check
+			continue
+		}
+		logger.Info("⏸️ Applied backup window overrun action")
+		if job.Action == database.WindowOverrunPause {
+			e.paused[job.JobID] = job
+			e.recordPausedJob(ctx, job)
+		}
+	}
+
+	e.resumePausedJobs(ctx, now)
+
+	// Forget jobs that are no longer running
+	for jobID := range e.handled {
+		if !running[jobID] {
+			delete(e.handled, jobID)
+		}
+	}
+}
+
+// resumePausedJobs resumes jobs paused by the enforcer once their backup window reopens
+func (e *BackupWindowEnforcer) resumePausedJobs(ctx context.Context, now time.Time) {
+	if e.overrunHandler == nil {
+		return
+	}
+
+	for jobID, job := range e.paused {
+		var owners []WindowOwner
+		if job.Owner != nil {
+			owners = append(owners, *job.Owner)
+		}
+		decision, err := e.windowService.Check(ctx, now, owners...)
+		if err != nil || !decision.Allowed {
+			continue
+		}
+
+		job.Action = "resume"
+		job.Reason = "backup window reopened"
+		logger := log.WithFields(log.Fields{
+			"job_id":   jobID,
+			"job_type": job.JobType,
+		})
+		if err := e.overrunHandler.HandleWindowOverrun(ctx, job); err != nil {
+			logger.WithError(err).Warn("Failed to resume job paused by backup window - giving up")
+		} else {
+			logger.Info("▶️ Resumed job paused by backup window")
+		}
+		delete(e.paused, jobID)
+		if err := e.windowRepo.DeletePausedJob(ctx, jobID); err != nil {
+			logger.WithError(err).Warn("Failed to forget job paused by backup window")
+		}
+	}
+}
+
+// loadPausedJobs picks up the jobs paused by this or a previous leader
+func (e *BackupWindowEnforcer) loadPausedJobs(ctx context.Context) {
+	records, err := e.windowRepo.ListPausedJobs(ctx)
+	if err != nil {
+		log.WithError(err).Warn("Failed to load jobs paused by backup window")
+		return
+	}
+
+	e.paused = make(map[string]WindowOverrunJob, len(records))
+	for _, record := range records {
+		job := WindowOverrunJob{
+			JobID:       record.JobID,
+			JobType:     record.JobType,
+			VMContextID: record.VMContextID,
+			Action:      database.WindowOverrunPause,
+			Reason:      record.Reason,
+		}
+		if record.OwnerScope != nil && record.OwnerID != nil {
+			job.Owner = &WindowOwner{Scope: *record.OwnerScope, ID: *record.OwnerID}
+		}
+		if record.WindowID != nil {
+			job.WindowID = *record.WindowID
+		}
+		e.paused[job.JobID] = job
+	}
+}
+
+// recordPausedJob persists a job paused at window close
+func (e *BackupWindowEnforcer) recordPausedJob(ctx context.Context, job WindowOverrunJob) {
+	record := &database.BackupWindowPausedJob{
+		JobID:       job.JobID,
+		JobType:     job.JobType,
+		VMContextID: job.VMContextID,
+		Reason:      job.Reason,
+	}
+	if job.Owner != nil {
+		record.OwnerScope = &job.Owner.Scope
+		record.OwnerID = &job.Owner.ID
+	}
+	if job.WindowID != "" {
+		record.WindowID = &job.WindowID
+	}
+	if err := e.windowRepo.RecordPausedJob(ctx, record); err != nil {
+		// Still resumed by this leader; lost only if it stops before the window reopens
+		log.WithError(err).WithField("job_id", job.JobID).Warn("Failed to persist job paused by backup window")
+	}
+}
+
+// runningJobs lists running replication and backup jobs with the schedule or flow that started them
+func (e *BackupWindowEnforcer) runningJobs() ([]WindowOverrunJob, error) {
+	gormDB := e.db.GetGormDB()
+
+	var replications []struct {
+		ID          string
+		VMContextID string
+		ScheduleID  *string
+	}
+	if err := gormDB.Table("replication_jobs").
+		Select("replication_jobs.id, replication_jobs.vm_context_id, schedule_executions.schedule_id").
+		Joins("LEFT JOIN schedule_executions ON schedule_executions.id = replication_jobs.schedule_execution_id").
+		Where("replication_jobs.status = ?", "replicating").
+		Scan(&replications).Error; err != nil {
+		return nil, err
+	}
+
+	var backups []struct {
+		ID          string
+		VMContextID string
+	}
+	if err := gormDB.Table("backup_jobs").
+		Select("id, vm_context_id").
+		Where("status = ?", "running").
+		Scan(&backups).Error; err != nil {
+		return nil, err
+	}
+
+	// Backup jobs belong to the flow whose running execution created them
+	var executions []database.ProtectionFlowExecution
+	if err := gormDB.Where("status = ?", "running").Find(&executions).Error; err != nil {
+		return nil, err
+	}
+	jobFlows := make(map[string]string)
+	for _, execution := range executions {
+		if execution.CreatedJobIDs == nil {
+			continue
+		}
+		var jobIDs []string
+		if err := json.Unmarshal([]byte(*execution.CreatedJobIDs), &jobIDs); err != nil {
+			continue
+		}
+		for _, jobID := range jobIDs {
+			jobFlows[jobID] = execution.FlowID
+		}
+	}
+
+	jobs := make([]WindowOverrunJob, 0, len(replications)+len(backups))
+	for _, replication := range replications {
+		job := WindowOverrunJob{JobID: replication.ID, JobType: "replication", VMContextID: replication.VMContextID}
+		if replication.ScheduleID != nil {
+			job.Owner = &WindowOwner{Scope: database.WindowScopeSchedule, ID: *replication.ScheduleID}
+		}
+		jobs = append(jobs, job)
+	}
+	for _, backup := range backups {
+		job := WindowOverrunJob{JobID: backup.ID, JobType: "backup", VMContextID: backup.VMContextID}
+		if flowID, ok := jobFlows[backup.ID]; ok {
+			job.Owner = &WindowOwner{Scope: database.WindowScopeFlow, ID: flowID}
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
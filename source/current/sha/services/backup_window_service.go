@@ -0,0 +1,541 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vexxhost/migratekit-sha/database"
+)
+
+// =============================================================================
+// BACKUP WINDOW SERVICE - Backup windows and blackout periods
+// =============================================================================
+// Decides whether a schedule or protection flow may start jobs at a given time.
+// Blackouts always win. Window rules attached to the schedule/flow replace the
+// global window rules; when no window rules apply, jobs may run at any time.
+
+// backupWindowLookahead bounds the search for the next time jobs may start
+const backupWindowLookahead = 35 * 24 * time.Hour
+
+// WindowOwner identifies the schedule or flow whose windows are evaluated
+type WindowOwner struct {
+	Scope string `json:"scope"` // "schedule" or "flow"
+	ID    string `json:"id"`
+}
+
+// WindowDecision is the result of evaluating backup windows at a point in time
+type WindowDecision struct {
+	Allowed       bool       `json:"allowed"`
+	Reason        string     `json:"reason,omitempty"`
+	WindowID      string     `json:"window_id,omitempty"`      // Rule that blocks jobs
+	OutsideAction string     `json:"outside_action,omitempty"` // "defer" or "skip"
+	OverrunAction string     `json:"overrun_action,omitempty"` // "none", "pause" or "cancel" for running jobs
+	NextOpenAt    *time.Time `json:"next_open_at,omitempty"`
+}
+
+// BackupWindowService manages and evaluates backup windows
+type BackupWindowService struct {
+	windowRepo *database.BackupWindowRepository
+}
+
+// NewBackupWindowService creates a new backup window service
+func NewBackupWindowService(db database.Connection) *BackupWindowService {
+	return &BackupWindowService{
+		windowRepo: database.NewBackupWindowRepository(db),
+	}
+}
+
+// =============================================================================
+// WINDOW DEFINITIONS
+// =============================================================================
+
+// CreateBackupWindowRequest represents a request to define a backup window or blackout
+type CreateBackupWindowRequest struct {
+	Name          string     `json:"name"`
+	Description   *string    `json:"description,omitempty"`
+	Scope         string     `json:"scope"` // "global" (default), "schedule" or "flow"
+	ScopeID       *string    `json:"scope_id,omitempty"`
+	RuleType      string     `json:"rule_type"`               // "window" (default) or "blackout"
+	DaysOfWeek    *string    `json:"days_of_week,omitempty"`  // "mon,tue,wed,thu,fri"
+	DaysOfMonth   *string    `json:"days_of_month,omitempty"` // "-3,-2,-1" (last three days)
+	StartTime     *string    `json:"start_time,omitempty"`    // "20:00"
+	EndTime       *string    `json:"end_time,omitempty"`      // "06:00" (crosses midnight)
+	Timezone      string     `json:"timezone,omitempty"`      // Default UTC
+	StartsAt      *time.Time `json:"starts_at,omitempty"`     // Fixed blackout period
+	EndsAt        *time.Time `json:"ends_at,omitempty"`
+	OutsideAction string     `json:"outside_action,omitempty"` // "defer" (default) or "skip"
+	OverrunAction string     `json:"overrun_action,omitempty"` // "none" (default), "pause" or "cancel"
+	Enabled       *bool      `json:"enabled,omitempty"`
+}
+
+// CreateWindow validates and stores a new backup window
+func (s *BackupWindowService) CreateWindow(ctx context.Context, req CreateBackupWindowRequest) (*database.BackupWindow, error) {
+	window := &database.BackupWindow{
+		ID:            uuid.New().String(),
+		Name:          req.Name,
+		Description:   req.Description,
+		Scope:         req.Scope,
+		ScopeID:       req.ScopeID,
+		RuleType:      req.RuleType,
+		DaysOfWeek:    req.DaysOfWeek,
+		DaysOfMonth:   req.DaysOfMonth,
+		StartTime:     req.StartTime,
+		EndTime:       req.EndTime,
+		Timezone:      req.Timezone,
+		StartsAt:      req.StartsAt,
+		EndsAt:        req.EndsAt,
+		OutsideAction: req.OutsideAction,
+		OverrunAction: req.OverrunAction,
+		Enabled:       true,
+	}
+	if window.Scope == "" {
+		window.Scope = database.WindowScopeGlobal
+	}
+	if window.RuleType == "" {
+		window.RuleType = database.WindowRuleWindow
+	}
+	if window.Timezone == "" {
+		window.Timezone = "UTC"
+	}
+	if window.OutsideAction == "" {
+		window.OutsideAction = database.WindowOutsideDefer
+	}
+	if window.OverrunAction == "" {
+		window.OverrunAction = database.WindowOverrunNone
+	}
+	if req.Enabled != nil {
+		window.Enabled = *req.Enabled
+	}
+
+	if err := validateBackupWindow(window); err != nil {
+		return nil, err
+	}
+
+	if err := s.windowRepo.CreateWindow(ctx, window); err != nil {
+		return nil, err
+	}
+	return window, nil
+}
+
+// GetWindow retrieves a backup window by ID
+func (s *BackupWindowService) GetWindow(ctx context.Context, id string) (*database.BackupWindow, error) {
+	return s.windowRepo.GetWindowByID(ctx, id)
+}
+
+// ListWindows retrieves backup windows with optional filtering
+func (s *BackupWindowService) ListWindows(ctx context.Context, filters database.BackupWindowFilters) ([]*database.BackupWindow, error) {
+	return s.windowRepo.ListWindows(ctx, filters)
+}
+
+// UpdateWindow applies updates to a backup window, re-validating the resulting rule
+func (s *BackupWindowService) UpdateWindow(ctx context.Context, id string, updates map[string]interface{}) (*database.BackupWindow, error) {
+	window, err := s.windowRepo.GetWindowByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate the rule as it will be stored before touching the database
+	merged := *window
+	for field, value := range updates {
+		switch field {
+		case "name":
+			merged.Name, _ = value.(string)
+		case "rule_type":
+			merged.RuleType, _ = value.(string)
+		case "days_of_week":
+			merged.DaysOfWeek, _ = value.(*string)
+		case "days_of_month":
+			merged.DaysOfMonth, _ = value.(*string)
+		case "start_time":
+			merged.StartTime, _ = value.(*string)
+		case "end_time":
+			merged.EndTime, _ = value.(*string)
+		case "timezone":
+			merged.Timezone, _ = value.(string)
+		case "starts_at":
+			merged.StartsAt, _ = value.(*time.Time)
+		case "ends_at":
+			merged.EndsAt, _ = value.(*time.Time)
+		case "outside_action":
+			merged.OutsideAction, _ = value.(string)
+		case "overrun_action":
+			merged.OverrunAction, _ = value.(string)
+		}
+	}
+	if err := validateBackupWindow(&merged); err != nil {
+		return nil, err
+	}
+
+	if err := s.windowRepo.UpdateWindow(ctx, id, updates); err != nil {
+		return nil, err
+	}
+	return s.windowRepo.GetWindowByID(ctx, id)
+}
+
+// DeleteWindow deletes a backup window
+func (s *BackupWindowService) DeleteWindow(ctx context.Context, id string) error {
+	return s.windowRepo.DeleteWindow(ctx, id)
+}
+
+// validateBackupWindow checks a rule is complete and well-formed
+func validateBackupWindow(window *database.BackupWindow) error {
+	if window.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	switch window.Scope {
+	case database.WindowScopeGlobal:
+		if window.ScopeID != nil && *window.ScopeID != "" {
+			return fmt.Errorf("scope_id must be empty for global rules")
+		}
+	case database.WindowScopeSchedule, database.WindowScopeFlow:
+		if window.ScopeID == nil || *window.ScopeID == "" {
+			return fmt.Errorf("scope_id is required for %s rules", window.Scope)
+		}
+	default:
+		return fmt.Errorf("invalid scope: %s (valid: global, schedule, flow)", window.Scope)
+	}
+
+	if window.RuleType != database.WindowRuleWindow && window.RuleType != database.WindowRuleBlackout {
+		return fmt.Errorf("invalid rule_type: %s (valid: window, blackout)", window.RuleType)
+	}
+	if window.OutsideAction != database.WindowOutsideDefer && window.OutsideAction != database.WindowOutsideSkip {
+		return fmt.Errorf("invalid outside_action: %s (valid: defer, skip)", window.OutsideAction)
+	}
+	switch window.OverrunAction {
+	case database.WindowOverrunNone, database.WindowOverrunPause, database.WindowOverrunCancel:
+	default:
+		return fmt.Errorf("invalid overrun_action: %s (valid: none, pause, cancel)", window.OverrunAction)
+	}
+
+	if window.StartsAt != nil || window.EndsAt != nil {
+		if window.RuleType != database.WindowRuleBlackout {
+			return fmt.Errorf("starts_at/ends_at are only valid for blackout rules")
+		}
+		if window.StartsAt == nil || window.EndsAt == nil || !window.EndsAt.After(*window.StartsAt) {
+			return fmt.Errorf("fixed blackout periods need starts_at before ends_at")
+		}
+		return nil
+	}
+
+	if _, err := time.LoadLocation(window.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone: %s", window.Timezone)
+	}
+	if (window.StartTime == nil) != (window.EndTime == nil) {
+		return fmt.Errorf("start_time and end_time must be set together")
+	}
+	if window.StartTime == nil && window.DaysOfWeek == nil && window.DaysOfMonth == nil {
+		return fmt.Errorf("a rule needs start_time/end_time, days_of_week, days_of_month or starts_at/ends_at")
+	}
+	if window.StartTime != nil {
+		if _, err := parseClockMinutes(*window.StartTime); err != nil {
+			return fmt.Errorf("invalid start_time: %w", err)
+		}
+		if _, err := parseClockMinutes(*window.EndTime); err != nil {
+			return fmt.Errorf("invalid end_time: %w", err)
+		}
+	}
+	if window.DaysOfWeek != nil {
+		if _, err := parseDaysOfWeek(*window.DaysOfWeek); err != nil {
+			return err
+		}
+	}
+	if window.DaysOfMonth != nil {
+		if _, err := parseDaysOfMonth(*window.DaysOfMonth); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// =============================================================================
+// WINDOW EVALUATION
+// =============================================================================
+
+// Evaluate decides whether jobs of the given schedules/flows may start at a point in time
+// With no owners only global rules apply
+func (s *BackupWindowService) Evaluate(ctx context.Context, at time.Time, owners ...WindowOwner) (*WindowDecision, error) {
+	rules, err := s.applicableRules(ctx, owners...)
+	if err != nil {
+		return nil, err
+	}
+	return rules.evaluate(at), nil
+}
+
+// Check is Evaluate without the search for the next opening, for callers that only
+// need to know whether jobs may run at a point in time
+func (s *BackupWindowService) Check(ctx context.Context, at time.Time, owners ...WindowOwner) (*WindowDecision, error) {
+	rules, err := s.applicableRules(ctx, owners...)
+	if err != nil {
+		return nil, err
+	}
+	return rules.check(at), nil
+}
+
+// applicableRules loads the window rules that apply to the given schedules/flows
+func (s *BackupWindowService) applicableRules(ctx context.Context, owners ...WindowOwner) (backupWindowRules, error) {
+	if len(owners) == 0 {
+		owners = []WindowOwner{{Scope: database.WindowScopeGlobal}}
+	}
+
+	seen := make(map[string]bool)
+	var rules []*database.BackupWindow
+	for _, owner := range owners {
+		windows, err := s.windowRepo.GetApplicableWindows(ctx, owner.Scope, owner.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, window := range windows {
+			if !seen[window.ID] {
+				seen[window.ID] = true
+				rules = append(rules, window)
+			}
+		}
+	}
+	return compileBackupWindows(rules), nil
+}
+
+// backupWindowRule is a window rule with its timezone and day/time fields parsed once
+type backupWindowRule struct {
+	*database.BackupWindow
+	loc        *time.Location
+	clock      bool // Daily start/end times are set
+	start, end int  // Minutes after local midnight
+	weekdays   map[time.Weekday]bool
+	monthDays  []int
+}
+
+// backupWindowRules is the set of rules evaluated together for a schedule/flow
+type backupWindowRules []*backupWindowRule
+
+// compileBackupWindows parses the rules for repeated evaluation
+func compileBackupWindows(windows []*database.BackupWindow) backupWindowRules {
+	rules := make(backupWindowRules, 0, len(windows))
+	for _, window := range windows {
+		rule := &backupWindowRule{BackupWindow: window, loc: time.UTC}
+		if loc, err := time.LoadLocation(window.Timezone); err == nil {
+			rule.loc = loc
+		}
+		if window.StartTime != nil && window.EndTime != nil {
+			rule.clock = true
+			rule.start, _ = parseClockMinutes(*window.StartTime)
+			rule.end, _ = parseClockMinutes(*window.EndTime)
+		}
+		if window.DaysOfWeek != nil {
+			rule.weekdays, _ = parseDaysOfWeek(*window.DaysOfWeek)
+		}
+		if window.DaysOfMonth != nil {
+			rule.monthDays, _ = parseDaysOfMonth(*window.DaysOfMonth)
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// evaluate applies the rules at a point in time and, when jobs are deferred, finds
+// when they may start again by stepping through the rules' transitions
+func (rules backupWindowRules) evaluate(at time.Time) *WindowDecision {
+	decision := rules.check(at)
+	if decision.Allowed || decision.OutsideAction == database.WindowOutsideSkip {
+		return decision
+	}
+
+	limit := at.Add(backupWindowLookahead)
+	for t := rules.nextTransition(at); !t.IsZero() && t.Before(limit); t = rules.nextTransition(t) {
+		if rules.check(t).Allowed {
+			next := t
+			decision.NextOpenAt = &next
+			break
+		}
+	}
+	return decision
+}
+
+// nextTransition returns the earliest time after t at which any rule opens or closes
+// (zero when no rule changes again)
+func (rules backupWindowRules) nextTransition(t time.Time) time.Time {
+	var next time.Time
+	for _, rule := range rules {
+		if candidate := rule.nextTransition(t); !candidate.IsZero() && (next.IsZero() || candidate.Before(next)) {
+			next = candidate
+		}
+	}
+	return next
+}
+
+// check applies blackouts, then the window rules in effect
+func (rules backupWindowRules) check(at time.Time) *WindowDecision {
+	var scopedWindows, globalWindows backupWindowRules
+	for _, rule := range rules {
+		if rule.RuleType == database.WindowRuleBlackout {
+			if rule.active(at) {
+				return &WindowDecision{
+					Reason:        fmt.Sprintf("blackout period %q is in effect", rule.Name),
+					WindowID:      rule.ID,
+					OutsideAction: rule.OutsideAction,
+					OverrunAction: rule.OverrunAction,
+				}
+			}
+			continue
+		}
+		if rule.Scope == database.WindowScopeGlobal {
+			globalWindows = append(globalWindows, rule)
+		} else {
+			scopedWindows = append(scopedWindows, rule)
+		}
+	}
+
+	// Windows attached to the schedule/flow replace the global windows
+	windows := globalWindows
+	if len(scopedWindows) > 0 {
+		windows = scopedWindows
+	}
+	if len(windows) == 0 {
+		return &WindowDecision{Allowed: true}
+	}
+
+	for _, window := range windows {
+		if window.active(at) {
+			return &WindowDecision{Allowed: true, WindowID: window.ID}
+		}
+	}
+
+	// Outside every window - the strictest actions of the windows in effect apply
+	decision := &WindowDecision{
+		Reason:        fmt.Sprintf("outside backup window %q", windows[0].Name),
+		WindowID:      windows[0].ID,
+		OutsideAction: database.WindowOutsideDefer,
+		OverrunAction: database.WindowOverrunNone,
+	}
+	for _, window := range windows {
+		if window.OutsideAction == database.WindowOutsideSkip {
+			decision.OutsideAction = database.WindowOutsideSkip
+		}
+		if window.OverrunAction == database.WindowOverrunCancel ||
+			(window.OverrunAction == database.WindowOverrunPause && decision.OverrunAction == database.WindowOverrunNone) {
+			decision.OverrunAction = window.OverrunAction
+		}
+	}
+	return decision
+}
+
+// active reports whether the rule's range covers a point in time
+func (rule *backupWindowRule) active(at time.Time) bool {
+	if rule.StartsAt != nil && rule.EndsAt != nil {
+		return !at.Before(*rule.StartsAt) && at.Before(*rule.EndsAt)
+	}
+
+	local := at.In(rule.loc)
+	if !rule.clock || rule.start == rule.end {
+		// Whole day
+		return rule.dayMatches(local)
+	}
+
+	minute := local.Hour()*60 + local.Minute()
+	if rule.start < rule.end {
+		return minute >= rule.start && minute < rule.end && rule.dayMatches(local)
+	}
+
+	// Crosses midnight - the early-morning part belongs to the previous day's range
+	if minute >= rule.start {
+		return rule.dayMatches(local)
+	}
+	return minute < rule.end && rule.dayMatches(local.AddDate(0, 0, -1))
+}
+
+// nextTransition returns the earliest time after t at which the rule may open or close:
+// the fixed period bounds, or the next local midnight, start or end time
+func (rule *backupWindowRule) nextTransition(t time.Time) time.Time {
+	if rule.StartsAt != nil && rule.EndsAt != nil {
+		switch {
+		case t.Before(*rule.StartsAt):
+			return *rule.StartsAt
+		case t.Before(*rule.EndsAt):
+			return *rule.EndsAt
+		}
+		return time.Time{}
+	}
+
+	local := t.In(rule.loc)
+	var next time.Time
+	for day := 0; day <= 1; day++ {
+		candidates := []int{0}
+		if rule.clock {
+			candidates = append(candidates, rule.start, rule.end)
+		}
+		for _, minute := range candidates {
+			candidate := time.Date(local.Year(), local.Month(), local.Day()+day, 0, minute, 0, 0, rule.loc)
+			if candidate.After(t) && (next.IsZero() || candidate.Before(next)) {
+				next = candidate
+			}
+		}
+	}
+	return next
+}
+
+// dayMatches reports whether a local date is one of the rule's days
+func (rule *backupWindowRule) dayMatches(local time.Time) bool {
+	if rule.weekdays != nil && !rule.weekdays[local.Weekday()] {
+		return false
+	}
+	if rule.monthDays != nil {
+		daysInMonth := time.Date(local.Year(), local.Month()+1, 0, 0, 0, 0, 0, local.Location()).Day()
+		for _, day := range rule.monthDays {
+			if day == local.Day() || (day < 0 && daysInMonth+day+1 == local.Day()) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// parseClockMinutes parses "HH:MM" into minutes after midnight
+func parseClockMinutes(value string) (int, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", value)
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseDaysOfWeek parses "mon,tue,..." into a weekday set
+func parseDaysOfWeek(value string) (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) > 3 {
+			name = name[:3]
+		}
+		day, ok := weekdayNames[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid day of week: %q", name)
+		}
+		days[day] = true
+	}
+	return days, nil
+}
+
+// parseDaysOfMonth parses "1,15,-1" (negative counts from the end of the month)
+func parseDaysOfMonth(value string) ([]int, error) {
+	var days []int
+	for _, field := range strings.Split(value, ",") {
+		day, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || day == 0 || day < -31 || day > 31 {
+			return nil, fmt.Errorf("invalid day of month: %q", field)
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vexxhost/migratekit-sha/database"
+)
+
+func TestBackupWindowRulesEvaluate(t *testing.T) {
+	nightly := &database.BackupWindow{
+		ID:            "nightly",
+		Name:          "nightly",
+		Scope:         database.WindowScopeGlobal,
+		RuleType:      database.WindowRuleWindow,
+		DaysOfWeek:    stringPtr("mon,tue,wed,thu,fri"),
+		StartTime:     stringPtr("20:00"),
+		EndTime:       stringPtr("06:00"),
+		Timezone:      "Europe/Berlin",
+		OutsideAction: database.WindowOutsideDefer,
+		OverrunAction: database.WindowOverrunPause,
+	}
+	blackoutStart := time.Date(2025, 10, 20, 18, 0, 0, 0, time.UTC)
+	blackoutEnd := time.Date(2025, 10, 21, 3, 0, 0, 0, time.UTC)
+	blackout := &database.BackupWindow{
+		ID:            "freeze",
+		Name:          "freeze",
+		Scope:         database.WindowScopeGlobal,
+		RuleType:      database.WindowRuleBlackout,
+		StartsAt:      &blackoutStart,
+		EndsAt:        &blackoutEnd,
+		OutsideAction: database.WindowOutsideDefer,
+		OverrunAction: database.WindowOverrunCancel,
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		windows     []*database.BackupWindow
+		at          time.Time
+		wantAllowed bool
+		wantNext    time.Time
+	}{
+		{
+			name:        "inside window before midnight",
+			windows:     []*database.BackupWindow{nightly},
+			at:          time.Date(2025, 10, 15, 21, 30, 0, 0, berlin),
+			wantAllowed: true,
+		},
+		{
+			name:        "early morning belongs to previous weekday",
+			windows:     []*database.BackupWindow{nightly},
+			at:          time.Date(2025, 10, 18, 5, 0, 0, 0, berlin), // Saturday after Friday night
+			wantAllowed: true,
+		},
+		{
+			name:     "weekend waits for monday evening",
+			windows:  []*database.BackupWindow{nightly},
+			at:       time.Date(2025, 10, 18, 12, 0, 0, 0, berlin),
+			wantNext: time.Date(2025, 10, 20, 20, 0, 0, 0, berlin),
+		},
+		{
+			name:     "blackout holds back the window",
+			windows:  []*database.BackupWindow{nightly, blackout},
+			at:       time.Date(2025, 10, 20, 21, 0, 0, 0, berlin),
+			wantNext: blackoutEnd,
+		},
+		{
+			name:     "daylight saving change keeps local start time",
+			windows:  []*database.BackupWindow{nightly},
+			at:       time.Date(2025, 10, 25, 12, 0, 0, 0, berlin), // Saturday before clocks go back
+			wantNext: time.Date(2025, 10, 27, 20, 0, 0, 0, berlin),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := compileBackupWindows(tt.windows).evaluate(tt.at)
+			if decision.Allowed != tt.wantAllowed {
+				t.Fatalf("Allowed = %v, want %v (reason %q)", decision.Allowed, tt.wantAllowed, decision.Reason)
+			}
+			if tt.wantAllowed {
+				return
+			}
+			if decision.NextOpenAt == nil {
+				t.Fatalf("NextOpenAt = nil, want %s", tt.wantNext)
+			}
+			if !decision.NextOpenAt.Equal(tt.wantNext) {
+				t.Errorf("NextOpenAt = %s, want %s", decision.NextOpenAt, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestBackupWindowRulesEvaluateSkip(t *testing.T) {
+	rules := compileBackupWindows([]*database.BackupWindow{{
+		ID:            "business-hours",
+		Name:          "business-hours",
+		Scope:         database.WindowScopeSchedule,
+		RuleType:      database.WindowRuleWindow,
+		StartTime:     stringPtr("09:00"),
+		EndTime:       stringPtr("17:00"),
+		Timezone:      "UTC",
+		OutsideAction: database.WindowOutsideSkip,
+		OverrunAction: database.WindowOverrunNone,
+	}})
+
+	decision := rules.evaluate(time.Date(2025, 10, 15, 20, 0, 0, 0, time.UTC))
+	if decision.Allowed || decision.OutsideAction != database.WindowOutsideSkip {
+		t.Fatalf("decision = %+v, want a skip", decision)
+	}
+	if decision.NextOpenAt != nil {
+		t.Errorf("NextOpenAt = %s, want none for skipped runs", decision.NextOpenAt)
+	}
+}
@@ -0,0 +1,289 @@
+// Package services provides VM disk change detection between jobs
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+	"github.com/vexxhost/migratekit-sha/models"
+)
+
+// Disk change types reported by the SNA specification checker
+const (
+	DiskChangeAdded   = "added"
+	DiskChangeRemoved = "removed"
+	DiskChangeResized = "resized"
+)
+
+// DiskChange is a disk added, removed or resized on the source VM since vm_disks was last written
+type DiskChange struct {
+	DiskID           string           `json:"disk_id"`
+	Label            string           `json:"label"`
+	ChangeType       string           `json:"change_type"`
+	OldCapacityBytes int64            `json:"old_capacity_bytes,omitempty"`
+	NewCapacityBytes int64            `json:"new_capacity_bytes,omitempty"`
+	Disk             *models.DiskInfo `json:"disk"`
+}
+
+// DiskChangeSet groups the disk changes of one VM by type
+type DiskChangeSet struct {
+	Added   []DiskChange `json:"added"`
+	Removed []DiskChange `json:"removed"`
+	Resized []DiskChange `json:"resized"`
+}
+
+// HasChanges reports whether any disk was added, removed or resized
+func (cs *DiskChangeSet) HasChanges() bool {
+	return len(cs.Added)+len(cs.Removed)+len(cs.Resized) > 0
+}
+
+// RemovedDiskIDs returns the vm_disks disk IDs ("disk-2001") of the removed disks
+func (cs *DiskChangeSet) RemovedDiskIDs() map[string]bool {
+	removed := make(map[string]bool, len(cs.Removed))
+	for _, change := range cs.Removed {
+		removed[change.DiskID] = true
+	}
+	return removed
+}
+
+// DiskChangeService compares a VM's stored disks with vCenter through the SNA
+// specification checker and brings vm_disks up to date before a job runs
+type DiskChangeService struct {
+	vmDiskRepo  *database.VMDiskRepository
+	snaRouter   *SNARouter
+	credentials *VMwareCredentialService
+	client      *http.Client
+}
+
+// NewDiskChangeService creates a new disk change service
+func NewDiskChangeService(db database.Connection) *DiskChangeService {
+	return &DiskChangeService{
+		vmDiskRepo: database.NewVMDiskRepository(db),
+		client:     &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+// SetSNARouter asks the SNA serving each VM's placement for its current disks
+func (dcs *DiskChangeService) SetSNARouter(router *SNARouter) {
+	dcs.snaRouter = router
+}
+
+// SetCredentialService issues the one-time tokens the SNA resolves the vCenter password with
+func (dcs *DiskChangeService) SetCredentialService(credentials *VMwareCredentialService) {
+	dcs.credentials = credentials
+}
+
+// RefreshVMDisks detects disk changes for a VM and applies them to vm_disks.
+// Removed disks keep their records (replicated volumes still reference them) and are
+// only reported, so callers must skip them.
+func (dcs *DiskChangeService) RefreshVMDisks(ctx context.Context, vmContext *database.VMReplicationContext, creds *database.VMwareCredentials) (*DiskChangeSet, error) {
+	changes, err := dcs.DetectDiskChanges(ctx, vmContext, creds)
+	if err != nil {
+		return nil, err
+	}
+	if err := dcs.ApplyDiskChanges(vmContext.ContextID, changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// DetectDiskChanges asks the SNA to diff the stored vm_disks against the VM in vCenter
+func (dcs *DiskChangeService) DetectDiskChanges(ctx context.Context, vmContext *database.VMReplicationContext, creds *database.VMwareCredentials) (*DiskChangeSet, error) {
+	vmDisks, err := dcs.vmDiskRepo.GetByVMContextID(vmContext.ContextID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stored VM disks: %w", err)
+	}
+
+	storedVM := models.VMInfo{
+		ID:         vmContext.VMwareVMID,
+		Name:       vmContext.VMName,
+		Path:       vmContext.VMPath,
+		Datacenter: vmContext.Datacenter,
+	}
+	for _, vmDisk := range vmDisks {
+		storedVM.Disks = append(storedVM.Disks, models.DiskInfo{
+			ID:               vmDisk.DiskID,
+			Path:             vmDisk.VMDKPath,
+			VMDKPath:         vmDisk.VMDKPath,
+			SizeGB:           vmDisk.SizeGB,
+			Datastore:        vmDisk.Datastore,
+			ProvisioningType: vmDisk.ProvisioningType,
+			Label:            vmDisk.Label,
+			CapacityBytes:    vmDisk.CapacityBytes,
+			UnitNumber:       vmDisk.UnitNumber,
+		})
+	}
+
+	// The SNA resolves the password itself with a one-time token
+	if dcs.credentials == nil {
+		return nil, fmt.Errorf("no credential service to issue a VMware credential token")
+	}
+	credentialToken, err := dcs.credentials.IssueCredentialToken(ctx, creds.ID, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue VMware credential token: %w", err)
+	}
+
+	snaReq := map[string]interface{}{
+		"vcenter":  creds.VCenterHost,
+		"username": creds.Username,
+		"vmware_credential": map[string]interface{}{
+			"credential_id": creds.ID,
+			"token":         credentialToken,
+		},
+		"datacenter":     vmContext.Datacenter,
+		"vm_path":        vmContext.VMPath,
+		"stored_vm_info": storedVM,
+	}
+	jsonData, _ := json.Marshal(snaReq)
+
+	snaEndpoint := DefaultSNAEndpoint
+	if dcs.snaRouter != nil {
+		route, err := dcs.snaRouter.SelectForVM(ctx, vmContext.ContextID, DefaultSNAEndpoint)
+		if err != nil {
+			return nil, fmt.Errorf("no SNA available for VM: %w", err)
+		}
+		snaEndpoint = route.Endpoint
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, snaEndpoint+"/api/v1/vm-spec-changes", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SNA request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := dcs.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call SNA: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("SNA returned status %d", resp.StatusCode)
+	}
+
+	var specResp struct {
+		HasChanges   bool   `json:"has_changes"`
+		ChangesJSON  string `json:"changes_json"`
+		Status       string `json:"status"`
+		ErrorMessage string `json:"error_message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&specResp); err != nil {
+		return nil, fmt.Errorf("failed to decode SNA response: %w", err)
+	}
+	if specResp.Status != "success" {
+		return nil, fmt.Errorf("SNA specification check failed: %s", specResp.ErrorMessage)
+	}
+
+	changes := &DiskChangeSet{}
+	if !specResp.HasChanges || specResp.ChangesJSON == "" {
+		return changes, nil
+	}
+
+	var diff struct {
+		DiskChanges []DiskChange `json:"disk_changes"`
+	}
+	if err := json.Unmarshal([]byte(specResp.ChangesJSON), &diff); err != nil {
+		return nil, fmt.Errorf("failed to parse specification changes: %w", err)
+	}
+
+	for _, change := range diff.DiskChanges {
+		switch change.ChangeType {
+		case DiskChangeAdded:
+			changes.Added = append(changes.Added, change)
+		case DiskChangeRemoved:
+			changes.Removed = append(changes.Removed, change)
+		case DiskChangeResized:
+			changes.Resized = append(changes.Resized, change)
+		}
+	}
+
+	if changes.HasChanges() {
+		log.WithFields(log.Fields{
+			"vm_name": vmContext.VMName,
+			"added":   len(changes.Added),
+			"removed": len(changes.Removed),
+			"resized": len(changes.Resized),
+		}).Info("📀 Detected VM disk changes since last job")
+	}
+
+	return changes, nil
+}
+
+// ApplyDiskChanges records added disks and new capacities of resized disks in vm_disks
+func (dcs *DiskChangeService) ApplyDiskChanges(vmContextID string, changes *DiskChangeSet) error {
+	for _, change := range changes.Added {
+		if change.Disk == nil {
+			continue
+		}
+		existing, err := dcs.vmDiskRepo.FindByContextAndDiskID(vmContextID, change.DiskID)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			continue
+		}
+
+		vmDisk := &database.VMDisk{
+			VMContextID:      vmContextID,
+			DiskID:           change.DiskID,
+			VMDKPath:         diskVMDKPath(change.Disk),
+			SizeGB:           change.Disk.SizeGB,
+			Datastore:        change.Disk.Datastore,
+			UnitNumber:       change.Disk.UnitNumber,
+			Label:            change.Disk.Label,
+			CapacityBytes:    change.Disk.CapacityBytes,
+			ProvisioningType: change.Disk.ProvisioningType,
+			SyncStatus:       "pending",
+		}
+		if err := dcs.vmDiskRepo.Create(vmDisk); err != nil {
+			return fmt.Errorf("failed to record added disk %s: %w", change.DiskID, err)
+		}
+		log.WithFields(log.Fields{
+			"vm_context_id": vmContextID,
+			"disk_id":       change.DiskID,
+			"size_gb":       change.Disk.SizeGB,
+		}).Info("➕ Recorded disk added to the source VM")
+	}
+
+	for _, change := range changes.Resized {
+		if change.Disk == nil {
+			continue
+		}
+		vmDisk, err := dcs.vmDiskRepo.FindByContextAndDiskID(vmContextID, change.DiskID)
+		if err != nil {
+			return err
+		}
+		if vmDisk == nil {
+			continue
+		}
+
+		vmDisk.SizeGB = change.Disk.SizeGB
+		vmDisk.CapacityBytes = change.NewCapacityBytes
+		if err := dcs.vmDiskRepo.Update(vmDisk); err != nil {
+			return fmt.Errorf("failed to record resized disk %s: %w", change.DiskID, err)
+		}
+		log.WithFields(log.Fields{
+			"vm_context_id":      vmContextID,
+			"disk_id":            change.DiskID,
+			"old_capacity_bytes": change.OldCapacityBytes,
+			"new_capacity_bytes": change.NewCapacityBytes,
+		}).Info("📏 Recorded resized source VM disk")
+	}
+
+	return nil
+}
+
+// diskVMDKPath returns the VMDK path of a discovered disk (older SNAs only fill path)
+func diskVMDKPath(disk *models.DiskInfo) string {
+	if disk.VMDKPath != "" {
+		return disk.VMDKPath
+	}
+	return disk.Path
+}
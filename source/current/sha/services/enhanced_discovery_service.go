@@ -15,12 +15,19 @@ import (
 	"github.com/vexxhost/migratekit-sha/joblog"
 )
 
+// scheduledDiscoveryInterval is how often the leader re-discovers each vCenter, so
+// dynamic machine groups pick up VMs created, moved or retagged in vSphere
+const scheduledDiscoveryInterval = 30 * time.Minute
+
 // EnhancedDiscoveryService provides VM discovery without immediate job creation
 type EnhancedDiscoveryService struct {
 	vmContextRepo *database.VMReplicationContextRepository
 	db            database.Connection // 🆕 Changed to proper Connection type for vm_disks creation
 	tracker       *joblog.Tracker
-	snaBaseURL    string // SNA API URL via tunnel (e.g., "http://localhost:9081")
+	snaBaseURL    string                   // SNA API URL via tunnel (e.g., "http://localhost:9081")
+	snaRouter     *SNARouter               // Optional: picks the SNA serving the vCenter
+	machineGroups *MachineGroupService     // Optional: re-evaluates dynamic groups after discovery
+	credentials   *VMwareCredentialService // Optional: vCenters for scheduled discovery
 }
 
 // NewEnhancedDiscoveryService creates a new enhanced discovery service
@@ -34,6 +41,21 @@ func NewEnhancedDiscoveryService(vmContextRepo *database.VMReplicationContextRep
 	}
 }
 
+// SetSNARouter sets the router that picks the SNA serving each vCenter
+func (eds *EnhancedDiscoveryService) SetSNARouter(router *SNARouter) {
+	eds.snaRouter = router
+}
+
+// SetMachineGroupService sets the service whose dynamic groups follow discovery
+func (eds *EnhancedDiscoveryService) SetMachineGroupService(machineGroups *MachineGroupService) {
+	eds.machineGroups = machineGroups
+}
+
+// SetCredentialService sets the credentials whose vCenters scheduled discovery visits
+func (eds *EnhancedDiscoveryService) SetCredentialService(credentials *VMwareCredentialService) {
+	eds.credentials = credentials
+}
+
 // DiscoveryRequest represents a VM discovery request to SNA
 type DiscoveryRequest struct {
 	VCenter      string `json:"vcenter" binding:"required"`
@@ -58,6 +80,8 @@ type SNAVMInfo struct {
 	ID         string        `json:"id"`
 	Name       string        `json:"name"`
 	Path       string        `json:"path"`
+	ESXiHost   string        `json:"esxi_host,omitempty"`
+	Cluster    string        `json:"cluster,omitempty"`
 	PowerState string        `json:"power_state"`
 	GuestOS    string        `json:"guest_os"`
 	MemoryMB   int           `json:"memory_mb"`
@@ -65,6 +89,11 @@ type SNAVMInfo struct {
 	VMXVersion string        `json:"vmx_version,omitempty"`
 	Disks      []SNADiskInfo `json:"disks"`
 	Networks   []SNANetworkInfo `json:"networks"`
+
+	// vSphere metadata for dynamic machine groups
+	FolderPath   string   `json:"folder_path,omitempty"`
+	ResourcePool string   `json:"resource_pool,omitempty"`
+	Tags         []string `json:"tags"` // "category:tag"; nil when the SNA could not read tags
 }
 
 // SNADiskInfo represents disk information from SNA
@@ -134,8 +163,15 @@ func (eds *EnhancedDiscoveryService) DiscoverVMsFromVMA(ctx context.Context, req
 		"datacenter", request.Datacenter,
 		"filter", request.Filter)
 
-	// Prepare request to SNA
-	snaURL := fmt.Sprintf("%s/api/v1/discover", eds.snaBaseURL)
+	// Prepare request to the SNA serving this vCenter
+	snaEndpoint, err := eds.snaRouter.EndpointForScope(ctx, SNAPlacement{
+		VCenterHost: request.VCenter,
+		Datacenter:  request.Datacenter,
+	}, eds.snaBaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to route discovery to SNA: %w", err)
+	}
+	snaURL := fmt.Sprintf("%s/api/v1/discover", snaEndpoint)
 
 	requestBody, err := json.Marshal(request)
 	if err != nil {
@@ -218,9 +254,16 @@ func (eds *EnhancedDiscoveryService) AddVMsToOMAWithoutJobs(ctx context.Context,
 			"duration", result.DiscoveryDuration,
 			"discovered_vms", len(snaResponse.VMs))
 
-		// Step 2: Process discovered VMs
-		start = time.Now()
-		return eds.processDiscoveredVMs(ctx, snaResponse, selectedVMNames, result, discoveryRequest.CredentialID)
+		// Step 2: Process discovered VMs (all of them when none are selected)
+		selected := func(string) bool { return true }
+		if len(selectedVMNames) > 0 {
+			selectedMap := make(map[string]bool)
+			for _, vmName := range selectedVMNames {
+				selectedMap[vmName] = true
+			}
+			selected = func(vmName string) bool { return selectedMap[vmName] }
+		}
+		return eds.processDiscoveredVMs(ctx, snaResponse, selected, result, discoveryRequest.CredentialID)
 	})
 
 	// End job tracking
@@ -242,9 +285,107 @@ func (eds *EnhancedDiscoveryService) AddVMsToOMAWithoutJobs(ctx context.Context,
 	return result, nil
 }
 
-// processDiscoveredVMs processes VMs from SNA discovery and creates VM contexts
+// RunScheduledDiscovery re-discovers every vCenter with an active credential until ctx
+// is cancelled. Known VMs get their metadata refreshed, new VMs are added only when a
+// dynamic group rule matches them, and dynamic groups are re-evaluated. Runs on the leader.
+func (eds *EnhancedDiscoveryService) RunScheduledDiscovery(ctx context.Context) {
+	if eds.credentials == nil || eds.machineGroups == nil {
+		return
+	}
+
+	ticker := time.NewTicker(scheduledDiscoveryInterval)
+	defer ticker.Stop()
+
+	for {
+		eds.discoverAllVCenters(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// discoverAllVCenters runs one scheduled discovery of each vCenter and datacenter
+func (eds *EnhancedDiscoveryService) discoverAllVCenters(ctx context.Context) {
+	credentials, err := eds.credentials.ListCredentials(ctx)
+	if err != nil {
+		eds.tracker.Logger(ctx).Warn("Failed to list VMware credentials for scheduled discovery", "error", err)
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, credential := range credentials {
+		scope := credential.VCenterHost + "/" + credential.Datacenter
+		if !credential.IsActive || seen[scope] || ctx.Err() != nil {
+			continue
+		}
+		seen[scope] = true
+
+		creds, err := eds.credentials.GetCredentials(ctx, credential.ID)
+		if err != nil {
+			eds.tracker.Logger(ctx).Warn("Failed to load VMware credential for scheduled discovery",
+				"credential_id", credential.ID, "error", err)
+			continue
+		}
+		credentialID := credential.ID
+		eds.RefreshVCenter(ctx, DiscoveryRequest{
+			VCenter:      creds.VCenterHost,
+			Username:     creds.Username,
+			Password:     creds.Password,
+			Datacenter:   creds.Datacenter,
+			CredentialID: &credentialID,
+		})
+	}
+}
+
+// RefreshVCenter discovers one vCenter without adding VMs unless a dynamic group rule
+// matches them, then re-evaluates dynamic groups
+func (eds *EnhancedDiscoveryService) RefreshVCenter(ctx context.Context, request DiscoveryRequest) (*BulkAddResult, error) {
+	ctx, jobID, err := eds.tracker.StartJob(ctx, joblog.JobStart{
+		JobType:   "discovery",
+		Operation: "scheduled-discovery",
+		Owner:     stringPtr("scheduler-system"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start discovery job: %w", err)
+	}
+
+	result := &BulkAddResult{
+		AddedVMs:   make([]VMContextSummary, 0),
+		SkippedVMs: make([]VMSkipReason, 0),
+		FailedVMs:  make([]VMFailureReason, 0),
+	}
+	err = eds.tracker.RunStep(ctx, jobID, "vma-discovery", func(ctx context.Context) error {
+		start := time.Now()
+		snaResponse, err := eds.DiscoverVMsFromVMA(ctx, request)
+		if err != nil {
+			return fmt.Errorf("SNA discovery failed: %w", err)
+		}
+		result.DiscoveryDuration = time.Since(start)
+
+		noneSelected := func(string) bool { return false }
+		return eds.processDiscoveredVMs(ctx, snaResponse, noneSelected, result, request.CredentialID)
+	})
+	if err != nil {
+		eds.tracker.EndJob(ctx, jobID, joblog.StatusFailed, err)
+		return result, err
+	}
+	eds.tracker.EndJob(ctx, jobID, joblog.StatusCompleted, nil)
+
+	eds.tracker.Logger(ctx).Info("Scheduled discovery completed",
+		"vcenter", request.VCenter,
+		"datacenter", request.Datacenter,
+		"added_by_rules", result.SuccessfullyAdded,
+		"discovery_duration", result.DiscoveryDuration)
+	return result, nil
+}
+
+// processDiscoveredVMs processes VMs from SNA discovery and creates VM contexts for the
+// selected ones (and unselected ones matched by dynamic group rules)
 func (eds *EnhancedDiscoveryService) processDiscoveredVMs(ctx context.Context, snaResponse *SNADiscoveryResponse,
-	selectedVMNames []string, result *BulkAddResult, credentialID *int) error {
+	selected func(vmName string) bool, result *BulkAddResult, credentialID *int) error {
 
 	log := eds.tracker.Logger(ctx)
 	start := time.Now()
@@ -252,22 +393,33 @@ func (eds *EnhancedDiscoveryService) processDiscoveredVMs(ctx context.Context, s
 		result.ProcessingDuration = time.Since(start)
 	}()
 
-	// Create map for efficient lookup
-	selectedMap := make(map[string]bool)
-	for _, vmName := range selectedVMNames {
-		selectedMap[vmName] = true
+	// VMs picked up by dynamic group rules are added even when not selected
+	matchesDynamicGroup := func(VMMetadata) bool { return false }
+	if eds.machineGroups != nil {
+		matcher, err := eds.machineGroups.DynamicGroupMatcher()
+		if err != nil {
+			log.Warn("Failed to load dynamic machine group rules", "error", err)
+		} else {
+			matchesDynamicGroup = matcher
+		}
 	}
 
 	// Process each discovered VM
 	for _, vm := range snaResponse.VMs {
-		// Skip if not in selected list (for filtered addition)
-		if len(selectedVMNames) > 0 && !selectedMap[vm.Name] {
-			continue
-		}
-
-		// Check if VM already exists
 		existing, err := eds.vmContextRepo.GetVMContextByName(vm.Name)
-		if err == nil && existing != nil {
+		exists := err == nil && existing != nil
+
+		// Skip if not in selected list (for filtered addition), keeping known VMs current
+		if !selected(vm.Name) {
+			if exists {
+				eds.updateDiscoveredMetadata(ctx, existing, vm)
+				continue
+			}
+			if !matchesDynamicGroup(eds.vmMetadata(vm)) {
+				continue
+			}
+			log.Info("Adding VM matched by dynamic machine group rules", "vm_name", vm.Name)
+		} else if exists {
 			result.Skipped++
 			result.SkippedVMs = append(result.SkippedVMs, VMSkipReason{
 				VMName:   vm.Name,
@@ -276,6 +428,7 @@ func (eds *EnhancedDiscoveryService) processDiscoveredVMs(ctx context.Context, s
 				Existing: existing.ContextID,
 			})
 			log.Info("Skipping existing VM", "vm_name", vm.Name, "existing_context", existing.ContextID)
+			eds.updateDiscoveredMetadata(ctx, existing, vm)
 			continue
 		}
 
@@ -316,9 +469,94 @@ func (eds *EnhancedDiscoveryService) processDiscoveredVMs(ctx context.Context, s
 			"auto_added", true)
 	}
 
+	// Membership follows the metadata just recorded
+	if eds.machineGroups != nil {
+		if _, err := eds.machineGroups.EvaluateDynamicGroups(ctx, RuleEvaluationDiscovery); err != nil {
+			log.Warn("Failed to evaluate dynamic machine groups after discovery", "error", err)
+		}
+	}
+
 	return nil
 }
 
+// updateDiscoveredMetadata keeps an existing context's placement and vSphere metadata
+// current for admission control, SNA routing and dynamic groups (VMs move with
+// vMotion/DRS and get retagged)
+func (eds *EnhancedDiscoveryService) updateDiscoveredMetadata(ctx context.Context,
+	existing *database.VMReplicationContext, vm SNAVMInfo) {
+
+	log := eds.tracker.Logger(ctx)
+	metadata := eds.vmMetadata(vm)
+
+	updates := map[string]interface{}{}
+	if vm.ESXiHost != "" && (existing.ESXiHost == nil || *existing.ESXiHost != vm.ESXiHost) {
+		updates["esxi_host"] = vm.ESXiHost
+	}
+	if vm.Cluster != "" && (existing.Cluster == nil || *existing.Cluster != vm.Cluster) {
+		updates["cluster"] = vm.Cluster
+	}
+	if metadata.FolderPath != "" && (existing.FolderPath == nil || *existing.FolderPath != metadata.FolderPath) {
+		updates["folder_path"] = metadata.FolderPath
+	}
+	if vm.ResourcePool != "" && (existing.ResourcePool == nil || *existing.ResourcePool != vm.ResourcePool) {
+		updates["resource_pool"] = vm.ResourcePool
+	}
+	// nil tags mean the SNA could not read them - keep the last known tags
+	if tags := encodeStringList(vm.Tags); tags != nil && (existing.VSphereTags == nil || *existing.VSphereTags != *tags) {
+		updates["vsphere_tags"] = *tags
+	}
+	if datastores := encodeStringList(metadata.Datastores); datastores != nil &&
+		(existing.Datastores == nil || *existing.Datastores != *datastores) {
+		updates["datastores"] = *datastores
+	}
+	if len(updates) == 0 {
+		return
+	}
+
+	if err := eds.db.GetGormDB().Model(&database.VMReplicationContext{}).
+		Where("context_id = ?", existing.ContextID).
+		Updates(updates).Error; err != nil {
+		log.Warn("Failed to update VM metadata", "vm_name", vm.Name, "error", err)
+	}
+}
+
+// vmMetadata returns the metadata dynamic group rules see for a discovered VM
+func (eds *EnhancedDiscoveryService) vmMetadata(vm SNAVMInfo) VMMetadata {
+	metadata := VMMetadata{
+		Name:         vm.Name,
+		ResourcePool: vm.ResourcePool,
+		Cluster:      vm.Cluster,
+		OSType:       eds.determineOSType(vm.GuestOS),
+		Tags:         vm.Tags,
+	}
+	// The SNA reports "Unknown" when the inventory path is missing
+	if vm.FolderPath != "Unknown" {
+		metadata.FolderPath = vm.FolderPath
+	}
+
+	seen := make(map[string]bool)
+	for _, disk := range vm.Disks {
+		if disk.Datastore != "" && !seen[disk.Datastore] {
+			seen[disk.Datastore] = true
+			metadata.Datastores = append(metadata.Datastores, disk.Datastore)
+		}
+	}
+	return metadata
+}
+
+// encodeStringList encodes a list for a JSON column (nil for a nil list)
+func encodeStringList(values []string) *string {
+	if values == nil {
+		return nil
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil
+	}
+	encoded := string(data)
+	return &encoded
+}
+
 // createVMContext creates a new VM context from discovered VM information
 func (eds *EnhancedDiscoveryService) createVMContext(ctx context.Context, vm SNAVMInfo,
 	vcenter struct{ Host, Datacenter string }, credentialID *int) (string, error) {
@@ -341,6 +579,23 @@ func (eds *EnhancedDiscoveryService) createVMContext(ctx context.Context, vm SNA
 			"vm_name", vm.Name)
 	}
 
+	var esxiHost *string
+	if vm.ESXiHost != "" {
+		esxiHost = &vm.ESXiHost
+	}
+	var cluster *string
+	if vm.Cluster != "" {
+		cluster = &vm.Cluster
+	}
+	metadata := eds.vmMetadata(vm)
+	var folderPath, resourcePool *string
+	if metadata.FolderPath != "" {
+		folderPath = &metadata.FolderPath
+	}
+	if vm.ResourcePool != "" {
+		resourcePool = &vm.ResourcePool
+	}
+
 	// Create VM context
 	vmContext := database.VMReplicationContext{
 		ContextID:        contextID,
@@ -349,6 +604,12 @@ func (eds *EnhancedDiscoveryService) createVMContext(ctx context.Context, vm SNA
 		VMPath:           vm.Path,
 		VCenterHost:      vcenter.Host,
 		Datacenter:       vcenter.Datacenter,
+		ESXiHost:         esxiHost,
+		Cluster:          cluster,
+		FolderPath:       folderPath,
+		ResourcePool:     resourcePool,
+		VSphereTags:      encodeStringList(vm.Tags),
+		Datastores:       encodeStringList(metadata.Datastores),
 		CredentialID:     credentialID, // Link to vmware_credentials table
 		CurrentStatus:    "discovered",
 		OSSEAConfigID:    &osseaConfigID, // 🆕 Auto-assign active config
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/vexxhost/migratekit-sha/database"
@@ -13,6 +14,7 @@ import (
 type ExecutionMonitor struct {
 	flowRepo *database.FlowRepository
 	db       database.Connection
+	hooks    *JobHookService
 	ticker   *time.Ticker
 	stopChan chan struct{}
 }
@@ -26,6 +28,11 @@ func NewExecutionMonitor(flowRepo *database.FlowRepository, db database.Connecti
 	}
 }
 
+// SetJobHookService enables post_job hooks when executions complete
+func (em *ExecutionMonitor) SetJobHookService(hooks *JobHookService) {
+	em.hooks = hooks
+}
+
 // Start begins monitoring executions every 10 seconds
 func (em *ExecutionMonitor) Start() {
 	em.ticker = time.NewTicker(10 * time.Second)
@@ -81,6 +88,11 @@ func (em *ExecutionMonitor) checkRunningExecutions() {
 
 // checkExecution checks a single execution and updates its status if jobs are complete
 func (em *ExecutionMonitor) checkExecution(ctx context.Context, execution *database.ProtectionFlowExecution) {
+	// Jobs are recorded as they start; the execution is not settled while more may follow
+	if execution.DispatchedAt == nil {
+		return
+	}
+
 	// Get the backup job IDs from created_job_ids JSON field
 	if execution.CreatedJobIDs == nil || *execution.CreatedJobIDs == "" {
 		log.WithField("execution_id", execution.ID).Warn("Execution has no created_job_ids")
@@ -205,5 +217,38 @@ func (em *ExecutionMonitor) checkExecution(ctx context.Context, execution *datab
 		"flow_id":      execution.FlowID,
 		"status":       finalStatus,
 	}).Info("🎉 Execution monitoring complete - flow updated")
+
+	// Run post_job hooks now that the backup jobs have actually finished
+	em.runPostJobHooks(ctx, execution, flow, finalStatus, failed)
+}
+
+// runPostJobHooks runs the flow's post_job hooks for a finished execution
+func (em *ExecutionMonitor) runPostJobHooks(ctx context.Context, execution *database.ProtectionFlowExecution, flow *database.ProtectionFlow, finalStatus string, failed int) {
+	if em.hooks == nil {
+		return
+	}
+
+	owners, err := em.hooks.OwnersForFlow(ctx, flow.ID)
+	if err != nil {
+		log.WithError(err).WithField("flow_id", flow.ID).Error("Failed to resolve hook owners for post_job hooks")
+		return
+	}
+
+	hookCtx := HookContext{
+		JobID:       execution.ID,
+		JobType:     flow.FlowType,
+		Operation:   "flow_execution",
+		FlowID:      flow.ID,
+		ScheduleID:  stringPtrToString(flow.ScheduleID),
+		ExecutionID: execution.ID,
+		Status:      finalStatus,
+	}
+	if failed > 0 {
+		hookCtx.ErrorMessage = fmt.Sprintf("%d backup job(s) failed", failed)
+	}
+
+	if err := em.hooks.RunDetachedHooks(ctx, database.HookTypePostJob, hookCtx, owners...); err != nil {
+		log.WithError(err).WithField("execution_id", execution.ID).Warn("⚠️ post_job hooks failed")
+	}
 }
 
@@ -20,6 +20,7 @@ type JobConflictDetector struct {
 	replicationRepo *database.ReplicationJobRepository
 	schedulerRepo   *database.SchedulerRepository
 	jobTracker      *joblog.Tracker
+	windowService   *BackupWindowService // Optional: backup windows and blackouts
 }
 
 // ConflictType represents different types of job conflicts
@@ -33,6 +34,7 @@ const (
 	ConflictVMDisabled       ConflictType = "vm_disabled"       // VM membership disabled
 	ConflictScheduleDisabled ConflictType = "schedule_disabled" // Schedule is disabled
 	ConflictVMInFailover     ConflictType = "vm_in_failover"    // VM is in failover state
+	ConflictOutsideWindow    ConflictType = "outside_window"    // Outside the backup window or in a blackout
 )
 
 // ConflictResult represents the result of conflict detection for a VM
@@ -65,6 +67,7 @@ type ScheduleConstraints struct {
 	SkipIfRunning     bool   `json:"skip_if_running"`
 	MaxConcurrentJobs int    `json:"max_concurrent_jobs"`
 	Enabled           bool   `json:"enabled"`
+	WindowChecked     bool   `json:"-"` // Backup windows already allowed this run
 }
 
 // GroupConstraints represents group-level constraints for job validation
@@ -87,6 +90,11 @@ func NewJobConflictDetector(
 	}
 }
 
+// SetBackupWindowService enables backup window and blackout checks
+func (d *JobConflictDetector) SetBackupWindowService(windowService *BackupWindowService) {
+	d.windowService = windowService
+}
+
 // CheckVMConflicts analyzes a list of VM contexts for scheduling conflicts
 func (d *JobConflictDetector) CheckVMConflicts(
 	ctx context.Context,
@@ -142,6 +150,19 @@ func (d *JobConflictDetector) CheckVMConflicts(
 		return nil, err
 	}
 
+	// Backup windows apply to every VM of the schedule alike - evaluate once, unless the
+	// scheduler already let this run through its window check
+	var windowDecision *WindowDecision
+	if d.windowService != nil && !scheduleConstraints.WindowChecked {
+		windowDecision, err = d.windowService.Check(ctx, time.Now(),
+			WindowOwner{Scope: database.WindowScopeSchedule, ID: scheduleConstraints.ScheduleID})
+		if err != nil {
+			// Don't block scheduling on a window lookup failure
+			logger.Warn("Failed to evaluate backup windows", "error", err)
+			windowDecision = nil
+		}
+	}
+
 	// Create active job map by VM context ID for fast lookup
 	activeJobMap := make(map[string]*database.ReplicationJob)
 	for i := range activeJobs {
@@ -169,6 +190,7 @@ func (d *JobConflictDetector) CheckVMConflicts(
 			result := d.analyzeVMConflict(
 				vmCtx,
 				activeJobMap,
+				windowDecision,
 				scheduleConstraints,
 				groupConstraints,
 				currentScheduleJobs,
@@ -219,6 +241,7 @@ func (d *JobConflictDetector) CheckVMConflicts(
 func (d *JobConflictDetector) analyzeVMConflict(
 	vmCtx *database.VMReplicationContext,
 	activeJobMap map[string]*database.ReplicationJob,
+	windowDecision *WindowDecision,
 	scheduleConstraints *ScheduleConstraints,
 	groupConstraints *GroupConstraints,
 	currentScheduleJobs int,
@@ -240,6 +263,16 @@ func (d *JobConflictDetector) analyzeVMConflict(
 		return result
 	}
 
+	// Check 1b: Backup window / blackout period
+	if windowDecision != nil && !windowDecision.Allowed {
+		result.HasConflict = true
+		result.ConflictType = ConflictOutsideWindow
+		result.ConflictReason = fmt.Sprintf("Job cannot start now: %s", windowDecision.Reason)
+		result.CanSchedule = false
+		result.SkippedReason = &result.ConflictReason
+		return result
+	}
+
 	// Check 2: VM scheduler enabled
 	if !vmCtx.SchedulerEnabled {
 		result.HasConflict = true
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+)
+
+var (
+	// ErrControlJobNotFound is returned when no backup or replication job has the ID
+	ErrControlJobNotFound = errors.New("job not found")
+
+	// ErrJobStateConflict is returned when a job cannot take the requested action in its current state
+	ErrJobStateConflict = errors.New("job cannot take this action in its current state")
+)
+
+// Job control actions
+const (
+	JobActionPause  = "pause"
+	JobActionResume = "resume"
+	JobActionCancel = "cancel"
+)
+
+// BackupCanceller marks a backup cancelled and releases its qemu-nbd exports and NBD ports
+type BackupCanceller interface {
+	CancelBackup(ctx context.Context, backupID string, reason string) error
+}
+
+// JobControlResult reports the outcome of a pause, resume or cancel request
+type JobControlResult struct {
+	JobID       string           `json:"job_id"`
+	JobType     string           `json:"job_type"` // "backup" or "replication"
+	Action      string           `json:"action"`
+	Status      string           `json:"status"`    // Job status after the action
+	SNAState    string           `json:"sna_state"` // Control state reported by the SNA
+	Checkpoints map[string]int64 `json:"checkpoints,omitempty"`
+	Message     string           `json:"message,omitempty"`
+}
+
+// snaJobControlResponse is the SNA's reply to a job control request
+type snaJobControlResponse struct {
+	State          string           `json:"state"`
+	ProcessRunning bool             `json:"process_running"`
+	Checkpoints    map[string]int64 `json:"checkpoints"`
+	Message        string           `json:"message"`
+}
+
+// JobControlService pauses, resumes and cancels running backup and replication jobs.
+// The SNA stops the backup client (which removes its VMware snapshot); the SHA then
+// updates the job and releases the qemu-nbd exports the job held.
+type JobControlService struct {
+	db              database.Connection
+	snaAPIEndpoint  string
+	snaRouter       *SNARouter // Optional: reaches the SNA each job was routed to
+	httpClient      *http.Client
+	backupCanceller BackupCanceller
+
+	// How long to wait for a cancelled client to exit before releasing its exports
+	cancelExitTimeout time.Duration
+}
+
+// NewJobControlService creates a new job control service
+func NewJobControlService(db database.Connection, snaAPIEndpoint string) *JobControlService {
+	return &JobControlService{
+		db:                db,
+		snaAPIEndpoint:    snaAPIEndpoint,
+		httpClient:        &http.Client{Timeout: 30 * time.Second},
+		cancelExitTimeout: 3 * time.Minute,
+	}
+}
+
+// SetSNARouter sets the router used to reach the SNA a job runs on
+func (s *JobControlService) SetSNARouter(router *SNARouter) {
+	s.snaRouter = router
+}
+
+// SetBackupCanceller sets the backup engine used to clean up cancelled backups
+func (s *JobControlService) SetBackupCanceller(canceller BackupCanceller) {
+	s.backupCanceller = canceller
+}
+
+// PauseJob stops a running backup at its next chunk boundary; the snapshot and
+// qemu-nbd exports are kept so it can resume from its checkpoints
+func (s *JobControlService) PauseJob(ctx context.Context, jobID string) (*JobControlResult, error) {
+	jobType, status, err := s.lookupJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if !isActiveJobStatus(jobType, status) {
+		return nil, fmt.Errorf("%w: %s job %s is %s", ErrJobStateConflict, jobType, jobID, status)
+	}
+
+	snaResp, err := s.requestSNA(ctx, jobID, JobActionPause)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.updateJobStatus(jobType, jobID, "paused", ""); err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{"job_id": jobID, "job_type": jobType}).Info("⏸️ Job paused")
+	return s.result(jobID, jobType, JobActionPause, "paused", snaResp), nil
+}
+
+// ResumeJob lets a paused job continue from its checkpoints
+func (s *JobControlService) ResumeJob(ctx context.Context, jobID string) (*JobControlResult, error) {
+	jobType, status, err := s.lookupJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if status != "paused" {
+		return nil, fmt.Errorf("%w: %s job %s is %s", ErrJobStateConflict, jobType, jobID, status)
+	}
+
+	snaResp, err := s.requestSNA(ctx, jobID, JobActionResume)
+	if err != nil {
+		return nil, err
+	}
+	running := "running"
+	if jobType == "replication" {
+		running = "replicating"
+	}
+	if err := s.updateJobStatus(jobType, jobID, running, ""); err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{"job_id": jobID, "job_type": jobType}).Info("▶️ Job resumed")
+	return s.result(jobID, jobType, JobActionResume, running, snaResp), nil
+}
+
+// CancelJob stops a job for good. Backups are marked cancelled and their qemu-nbd
+// exports released once the backup client has exited and removed its snapshot.
+func (s *JobControlService) CancelJob(ctx context.Context, jobID, reason string) (*JobControlResult, error) {
+	jobType, status, err := s.lookupJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if !isActiveJobStatus(jobType, status) && status != "paused" && status != "pending" {
+		return nil, fmt.Errorf("%w: %s job %s is %s", ErrJobStateConflict, jobType, jobID, status)
+	}
+	if reason == "" {
+		reason = "cancelled by operator"
+	}
+
+	snaResp, err := s.requestSNA(ctx, jobID, JobActionCancel)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case jobType == "backup" && s.backupCanceller != nil && !snaResp.ProcessRunning:
+		if err := s.backupCanceller.CancelBackup(ctx, jobID, reason); err != nil {
+			return nil, err
+		}
+	case jobType == "backup" && s.backupCanceller != nil:
+		// qemu-nbd exports stay up until the client has stopped writing to them
+		if err := s.updateJobStatus(jobType, jobID, "cancelled", reason); err != nil {
+			return nil, err
+		}
+		go s.releaseAfterExit(jobID, reason)
+	default:
+		if err := s.updateJobStatus(jobType, jobID, "cancelled", reason); err != nil {
+			return nil, err
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"job_id":   jobID,
+		"job_type": jobType,
+		"reason":   reason,
+	}).Info("🛑 Job cancelled")
+	return s.result(jobID, jobType, JobActionCancel, "cancelled", snaResp), nil
+}
+
+// HandleWindowOverrun applies a backup window overrun action (WindowOverrunHandler)
+func (s *JobControlService) HandleWindowOverrun(ctx context.Context, job WindowOverrunJob) error {
+	var err error
+	switch job.Action {
+	case JobActionPause:
+		_, err = s.PauseJob(ctx, job.JobID)
+	case JobActionResume:
+		_, err = s.ResumeJob(ctx, job.JobID)
+	case JobActionCancel:
+		_, err = s.CancelJob(ctx, job.JobID, job.Reason)
+	default:
+		err = fmt.Errorf("unknown overrun action: %s", job.Action)
+	}
+	return err
+}
+
+// releaseAfterExit waits for a cancelled backup client to exit, then releases the
+// qemu-nbd exports it was writing to
+func (s *JobControlService) releaseAfterExit(jobID, reason string) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.cancelExitTimeout+time.Minute)
+	defer cancel()
+
+	deadline := time.Now().Add(s.cancelExitTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(5 * time.Second)
+
+		status, err := s.snaControlStatus(ctx, jobID)
+		if err != nil || status.ProcessRunning {
+			continue
+		}
+		break
+	}
+
+	if err := s.backupCanceller.CancelBackup(ctx, jobID, reason); err != nil {
+		log.WithError(err).WithField("job_id", jobID).Warn("Failed to release qemu-nbd exports of cancelled backup")
+	}
+}
+
+// lookupJob finds a job in backup_jobs, then replication_jobs
+func (s *JobControlService) lookupJob(jobID string) (string, string, error) {
+	gormDB := s.db.GetGormDB()
+
+	var backup database.BackupJob
+	if err := gormDB.Select("id, status").Where("id = ?", jobID).First(&backup).Error; err == nil {
+		return "backup", backup.Status, nil
+	}
+
+	var replication database.ReplicationJob
+	if err := gormDB.Select("id, status").Where("id = ?", jobID).First(&replication).Error; err == nil {
+		return "replication", replication.Status, nil
+	}
+
+	return "", "", fmt.Errorf("%w: %s", ErrControlJobNotFound, jobID)
+}
+
+// updateJobStatus records the job status after a control action
+func (s *JobControlService) updateJobStatus(jobType, jobID, status, reason string) error {
+	updates := map[string]interface{}{"status": status}
+	if reason != "" {
+		updates["error_message"] = reason
+	}
+	if status == "cancelled" {
+		updates["completed_at"] = time.Now()
+	}
+
+	var err error
+	if jobType == "backup" {
+		err = s.db.GetGormDB().Model(&database.BackupJob{}).Where("id = ?", jobID).Updates(updates).Error
+	} else {
+		err = s.db.GetGormDB().Model(&database.ReplicationJob{}).Where("id = ?", jobID).Updates(updates).Error
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update %s job status: %w", jobType, err)
+	}
+	return nil
+}
+
+// requestSNA sends a job control action to the SNA
+func (s *JobControlService) requestSNA(ctx context.Context, jobID, action string) (*snaJobControlResponse, error) {
+	url := fmt.Sprintf("%s/api/v1/jobs/%s/%s", s.snaRouter.EndpointForJob(ctx, jobID, s.snaAPIEndpoint), jobID, action)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SNA request: %w", err)
+	}
+	return s.doSNARequest(req)
+}
+
+// snaControlStatus fetches the control state of a job from the SNA
+func (s *JobControlService) snaControlStatus(ctx context.Context, jobID string) (*snaJobControlResponse, error) {
+	url := fmt.Sprintf("%s/api/v1/jobs/%s/control", s.snaRouter.EndpointForJob(ctx, jobID, s.snaAPIEndpoint), jobID)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SNA request: %w", err)
+	}
+	return s.doSNARequest(req)
+}
+
+func (s *JobControlService) doSNARequest(req *http.Request) (*snaJobControlResponse, error) {
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("SNA API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted:
+		var snaResp snaJobControlResponse
+		if err := json.NewDecoder(resp.Body).Decode(&snaResp); err != nil {
+			return nil, fmt.Errorf("failed to decode SNA response: %w", err)
+		}
+		return &snaResp, nil
+	case http.StatusNotFound, http.StatusConflict:
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%w: SNA: %s", ErrJobStateConflict, string(body))
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("SNA API returned status %d: %s", resp.StatusCode, string(body))
+	}
+}
+
+func (s *JobControlService) result(jobID, jobType, action, status string, snaResp *snaJobControlResponse) *JobControlResult {
+	return &JobControlResult{
+		JobID:       jobID,
+		JobType:     jobType,
+		Action:      action,
+		Status:      status,
+		SNAState:    snaResp.State,
+		Checkpoints: snaResp.Checkpoints,
+		Message:     snaResp.Message,
+	}
+}
+
+// isActiveJobStatus reports whether a job is running and can be paused or cancelled
+func isActiveJobStatus(jobType, status string) bool {
+	if jobType == "replication" {
+		return status == "replicating" || status == "running"
+	}
+	return status == "running"
+}
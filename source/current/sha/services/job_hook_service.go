@@ -0,0 +1,466 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/vexxhost/migratekit-sha/database"
+	"github.com/vexxhost/migratekit-sha/joblog"
+)
+
+// =============================================================================
+// JOB HOOK SERVICE - Pre/post job and snapshot hook execution
+// =============================================================================
+// pre_job/post_job hooks run on the SHA as shell commands with job metadata in
+// SENDENSE_* environment variables. pre_snapshot/post_snapshot hooks are resolved
+// here and handed to the SNA, which runs them in the guest via VMware Tools. Only
+// backup flows take snapshot hooks: those of the flow and of the flow's schedule.
+
+// maxHookOutputBytes caps the hook output recorded in joblog
+const maxHookOutputBytes = 8192
+
+// JobHookService runs customer-defined hooks around jobs
+type JobHookService struct {
+	hookRepo          *database.JobHookRepository
+	flowRepo          *database.FlowRepository
+	jobTracker        *joblog.Tracker
+	encryptionService *CredentialEncryptionService
+}
+
+// HookOwner identifies an entity hooks are attached to
+type HookOwner struct {
+	Type string // database.HookOwnerFlow, HookOwnerSchedule or HookOwnerFailover
+	ID   string
+}
+
+// HookContext carries job metadata exported to SHA-side hooks
+type HookContext struct {
+	JobID        string
+	JobType      string // "backup", "replication" or "failover"
+	Operation    string
+	VMName       string
+	VMContextID  string
+	FlowID       string
+	ScheduleID   string
+	ExecutionID  string
+	Status       string // Final status (post_job only)
+	ErrorMessage string // Failure reason (post_job only)
+}
+
+// GuestHook is a resolved in-guest hook sent to the SNA with a backup request
+type GuestHook struct {
+	Name           string `json:"name"`
+	HookType       string `json:"hook_type"` // "pre_snapshot" or "post_snapshot"
+	Command        string `json:"command"`
+	TimeoutSeconds int    `json:"timeout_seconds"`
+	OnFailure      string `json:"on_failure"`
+	GuestUsername  string `json:"guest_username"`
+	GuestPassword  string `json:"guest_password"`
+}
+
+// NewJobHookService creates a new job hook service
+// encryptionService may be nil (development mode - guest passwords stored as plaintext)
+func NewJobHookService(db database.Connection, jobTracker *joblog.Tracker, encryptionService *CredentialEncryptionService) *JobHookService {
+	return &JobHookService{
+		hookRepo:          database.NewJobHookRepository(db),
+		flowRepo:          database.NewFlowRepository(db),
+		jobTracker:        jobTracker,
+		encryptionService: encryptionService,
+	}
+}
+
+// =============================================================================
+// HOOK DEFINITIONS
+// =============================================================================
+
+// CreateHookRequest represents a request to attach a hook
+type CreateHookRequest struct {
+	Name           string  `json:"name"`
+	Description    *string `json:"description,omitempty"`
+	OwnerType      string  `json:"owner_type"` // "flow", "schedule" or "failover"
+	OwnerID        string  `json:"owner_id"`
+	HookType       string  `json:"hook_type"` // "pre_job", "post_job", "pre_snapshot", "post_snapshot"
+	Command        string  `json:"command"`
+	TimeoutSeconds int     `json:"timeout_seconds,omitempty"`
+	OnFailure      string  `json:"on_failure,omitempty"` // "fail" (default) or "continue"
+	ExecutionOrder int     `json:"execution_order,omitempty"`
+	GuestUsername  *string `json:"guest_username,omitempty"`
+	GuestPassword  *string `json:"guest_password,omitempty"`
+	Enabled        *bool   `json:"enabled,omitempty"`
+}
+
+// CreateHook validates and stores a new hook
+func (s *JobHookService) CreateHook(ctx context.Context, req CreateHookRequest) (*database.JobHook, error) {
+	if err := s.validateHookRequest(req); err != nil {
+		return nil, err
+	}
+	if req.HookType == database.HookTypePreSnapshot || req.HookType == database.HookTypePostSnapshot {
+		if err := s.validateSnapshotHookOwner(ctx, req.OwnerType, req.OwnerID); err != nil {
+			return nil, err
+		}
+	}
+
+	hook := &database.JobHook{
+		Name:           req.Name,
+		Description:    req.Description,
+		OwnerType:      req.OwnerType,
+		OwnerID:        req.OwnerID,
+		HookType:       req.HookType,
+		Command:        req.Command,
+		TimeoutSeconds: req.TimeoutSeconds,
+		OnFailure:      req.OnFailure,
+		ExecutionOrder: req.ExecutionOrder,
+		GuestUsername:  req.GuestUsername,
+		Enabled:        true,
+	}
+	if hook.TimeoutSeconds <= 0 {
+		hook.TimeoutSeconds = 300
+	}
+	if hook.OnFailure == "" {
+		hook.OnFailure = database.HookOnFailureFail
+	}
+	if req.Enabled != nil {
+		hook.Enabled = *req.Enabled
+	}
+
+	if req.GuestPassword != nil {
+		encrypted, err := s.encryptGuestPassword(*req.GuestPassword)
+		if err != nil {
+			return nil, err
+		}
+		hook.GuestPasswordEncrypted = &encrypted
+	}
+
+	if err := s.hookRepo.CreateHook(ctx, hook); err != nil {
+		return nil, err
+	}
+
+	return hook, nil
+}
+
+// GetHook retrieves a hook by ID
+func (s *JobHookService) GetHook(ctx context.Context, id string) (*database.JobHook, error) {
+	return s.hookRepo.GetHookByID(ctx, id)
+}
+
+// ListHooks retrieves hooks with optional filtering
+func (s *JobHookService) ListHooks(ctx context.Context, filters database.JobHookFilters) ([]*database.JobHook, error) {
+	return s.hookRepo.ListHooks(ctx, filters)
+}
+
+// UpdateHook updates a hook; a "guest_password" key is encrypted before storage
+func (s *JobHookService) UpdateHook(ctx context.Context, id string, updates map[string]interface{}) error {
+	if password, ok := updates["guest_password"]; ok {
+		delete(updates, "guest_password")
+		passwordStr, ok := password.(string)
+		if !ok {
+			return fmt.Errorf("guest_password must be a string")
+		}
+		encrypted, err := s.encryptGuestPassword(passwordStr)
+		if err != nil {
+			return err
+		}
+		updates["guest_password_encrypted"] = encrypted
+	}
+
+	if onFailure, ok := updates["on_failure"]; ok && onFailure != database.HookOnFailureFail && onFailure != database.HookOnFailureContinue {
+		return fmt.Errorf("on_failure must be 'fail' or 'continue'")
+	}
+
+	return s.hookRepo.UpdateHook(ctx, id, updates)
+}
+
+// DeleteHook removes a hook
+func (s *JobHookService) DeleteHook(ctx context.Context, id string) error {
+	return s.hookRepo.DeleteHook(ctx, id)
+}
+
+// validateHookRequest validates a hook creation request
+func (s *JobHookService) validateHookRequest(req CreateHookRequest) error {
+	if req.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if req.OwnerID == "" {
+		return fmt.Errorf("owner_id is required")
+	}
+	if req.Command == "" {
+		return fmt.Errorf("command is required")
+	}
+
+	switch req.OwnerType {
+	case database.HookOwnerFlow, database.HookOwnerSchedule, database.HookOwnerFailover:
+	default:
+		return fmt.Errorf("owner_type must be 'flow', 'schedule' or 'failover'")
+	}
+
+	switch req.HookType {
+	case database.HookTypePreJob, database.HookTypePostJob:
+	case database.HookTypePreSnapshot, database.HookTypePostSnapshot:
+		if req.OwnerType == database.HookOwnerFailover {
+			return fmt.Errorf("snapshot hooks are not supported for failover")
+		}
+		if req.GuestUsername == nil || *req.GuestUsername == "" {
+			return fmt.Errorf("guest_username is required for snapshot hooks")
+		}
+	default:
+		return fmt.Errorf("hook_type must be 'pre_job', 'post_job', 'pre_snapshot' or 'post_snapshot'")
+	}
+
+	if req.OnFailure != "" && req.OnFailure != database.HookOnFailureFail && req.OnFailure != database.HookOnFailureContinue {
+		return fmt.Errorf("on_failure must be 'fail' or 'continue'")
+	}
+
+	return nil
+}
+
+// validateSnapshotHookOwner rejects snapshot hooks on owners that start no backup.
+// Replications (replication flows and scheduled group replications) only run
+// pre_job/post_job hooks, so a snapshot hook there would silently never run.
+func (s *JobHookService) validateSnapshotHookOwner(ctx context.Context, ownerType, ownerID string) error {
+	switch ownerType {
+	case database.HookOwnerFlow:
+		flow, err := s.flowRepo.GetFlowByID(ctx, ownerID)
+		if err != nil {
+			return fmt.Errorf("failed to load flow %s: %w", ownerID, err)
+		}
+		if flow.FlowType != "backup" {
+			return fmt.Errorf("snapshot hooks only run for backup flows; flow %s is a %s flow", ownerID, flow.FlowType)
+		}
+
+	case database.HookOwnerSchedule:
+		backup := "backup"
+		flows, err := s.flowRepo.ListFlows(ctx, database.FlowFilters{ScheduleID: &ownerID, FlowType: &backup, Limit: 1})
+		if err != nil {
+			return fmt.Errorf("failed to load flows of schedule %s: %w", ownerID, err)
+		}
+		if len(flows) == 0 {
+			return fmt.Errorf("snapshot hooks on a schedule only run for backup flows using it; schedule %s drives no backup flow", ownerID)
+		}
+	}
+
+	return nil
+}
+
+// =============================================================================
+// HOOK RESOLUTION
+// =============================================================================
+
+// OwnersForFlow returns the hook owners for a flow execution: the flow itself and its schedule
+func (s *JobHookService) OwnersForFlow(ctx context.Context, flowID string) ([]HookOwner, error) {
+	flow, err := s.flowRepo.GetFlowByID(ctx, flowID)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := []HookOwner{{Type: database.HookOwnerFlow, ID: flow.ID}}
+	if flow.ScheduleID != nil && *flow.ScheduleID != "" {
+		owners = append(owners, HookOwner{Type: database.HookOwnerSchedule, ID: *flow.ScheduleID})
+	}
+	return owners, nil
+}
+
+// collectHooks gathers enabled hooks of one type across owners, preserving owner order
+func (s *JobHookService) collectHooks(ctx context.Context, hookType string, owners []HookOwner) ([]*database.JobHook, error) {
+	var hooks []*database.JobHook
+	for _, owner := range owners {
+		ownerHooks, err := s.hookRepo.GetEnabledHooks(ctx, owner.Type, owner.ID, hookType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s hooks for %s %s: %w", hookType, owner.Type, owner.ID, err)
+		}
+		hooks = append(hooks, ownerHooks...)
+	}
+	return hooks, nil
+}
+
+// ResolveGuestHooks returns the pre/post snapshot hooks for the owners with decrypted guest credentials
+func (s *JobHookService) ResolveGuestHooks(ctx context.Context, owners []HookOwner) ([]GuestHook, error) {
+	var guestHooks []GuestHook
+	for _, hookType := range []string{database.HookTypePreSnapshot, database.HookTypePostSnapshot} {
+		hooks, err := s.collectHooks(ctx, hookType, owners)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, hook := range hooks {
+			password, err := s.decryptGuestPassword(hook.GuestPasswordEncrypted)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt guest password for hook %s: %w", hook.Name, err)
+			}
+
+			guestHooks = append(guestHooks, GuestHook{
+				Name:           hook.Name,
+				HookType:       hook.HookType,
+				Command:        hook.Command,
+				TimeoutSeconds: hook.TimeoutSeconds,
+				OnFailure:      hook.OnFailure,
+				GuestUsername:  stringPtrToString(hook.GuestUsername),
+				GuestPassword:  password,
+			})
+		}
+	}
+	return guestHooks, nil
+}
+
+// =============================================================================
+// SHA-SIDE HOOK EXECUTION
+// =============================================================================
+
+// RunHooks executes the pre_job or post_job hooks of the owners, each as a joblog step of jobID
+// Returns an error only when a hook with on_failure=fail fails
+func (s *JobHookService) RunHooks(ctx context.Context, jobID string, hookType string, hookCtx HookContext, owners ...HookOwner) error {
+	if hookType != database.HookTypePreJob && hookType != database.HookTypePostJob {
+		return fmt.Errorf("hook type %s does not run on the SHA", hookType)
+	}
+
+	hooks, err := s.collectHooks(ctx, hookType, owners)
+	if err != nil {
+		return err
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	logger := s.jobTracker.Logger(ctx)
+	logger.Info("Running job hooks", "hook_type", hookType, "count", len(hooks))
+
+	for _, hook := range hooks {
+		stepName := fmt.Sprintf("hook-%s-%s", strings.ReplaceAll(hookType, "_", "-"), hook.Name)
+		stepErr := s.jobTracker.RunStep(ctx, jobID, stepName, func(ctx context.Context) error {
+			return s.executeHook(ctx, hook, hookCtx)
+		})
+		if stepErr == nil {
+			continue
+		}
+
+		if hook.OnFailure == database.HookOnFailureContinue {
+			logger.Warn("Hook failed, continuing per on_failure policy", "hook", hook.Name, "error", stepErr)
+			continue
+		}
+		return fmt.Errorf("%s hook %s failed: %w", hookType, hook.Name, stepErr)
+	}
+
+	return nil
+}
+
+// RunDetachedHooks runs hooks under their own joblog job, for callers without an active job
+// (e.g. the execution monitor running post_job hooks once background jobs finish)
+func (s *JobHookService) RunDetachedHooks(ctx context.Context, hookType string, hookCtx HookContext, owners ...HookOwner) error {
+	hooks, err := s.collectHooks(ctx, hookType, owners)
+	if err != nil || len(hooks) == 0 {
+		return err
+	}
+
+	owner := "system"
+	jobCtx, jobID, err := s.jobTracker.StartJob(ctx, joblog.JobStart{
+		JobType:   "hooks",
+		Operation: hookType + "_hooks",
+		Owner:     &owner,
+		Metadata: map[string]interface{}{
+			"job_id":       hookCtx.JobID,
+			"flow_id":      hookCtx.FlowID,
+			"execution_id": hookCtx.ExecutionID,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start job tracking: %w", err)
+	}
+
+	runErr := s.RunHooks(jobCtx, jobID, hookType, hookCtx, owners...)
+	if runErr != nil {
+		s.jobTracker.EndJob(jobCtx, jobID, joblog.StatusFailed, runErr)
+		return runErr
+	}
+
+	s.jobTracker.EndJob(jobCtx, jobID, joblog.StatusCompleted, nil)
+	return nil
+}
+
+// executeHook runs a single hook command with its timeout, recording output in joblog
+func (s *JobHookService) executeHook(ctx context.Context, hook *database.JobHook, hookCtx HookContext) error {
+	logger := s.jobTracker.Logger(ctx)
+
+	timeout := time.Duration(hook.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 300 * time.Second
+	}
+	hookExecCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(hookExecCtx, "/bin/sh", "-c", hook.Command)
+	cmd.Env = append(os.Environ(), hookCtx.environ(hook)...)
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	start := time.Now()
+	runErr := cmd.Run()
+	duration := time.Since(start)
+
+	out := output.String()
+	if len(out) > maxHookOutputBytes {
+		out = out[len(out)-maxHookOutputBytes:]
+	}
+
+	logger.Info("Hook output",
+		"hook", hook.Name,
+		"hook_id", hook.ID,
+		"duration_ms", duration.Milliseconds(),
+		"output", out)
+
+	if hookExecCtx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("hook timed out after %s", timeout)
+	}
+	if runErr != nil {
+		return fmt.Errorf("hook command failed: %w", runErr)
+	}
+	return nil
+}
+
+// environ renders the hook context as SENDENSE_* environment variables
+func (hc HookContext) environ(hook *database.JobHook) []string {
+	return []string{
+		"SENDENSE_HOOK_NAME=" + hook.Name,
+		"SENDENSE_HOOK_TYPE=" + hook.HookType,
+		"SENDENSE_JOB_ID=" + hc.JobID,
+		"SENDENSE_JOB_TYPE=" + hc.JobType,
+		"SENDENSE_OPERATION=" + hc.Operation,
+		"SENDENSE_VM_NAME=" + hc.VMName,
+		"SENDENSE_VM_CONTEXT_ID=" + hc.VMContextID,
+		"SENDENSE_FLOW_ID=" + hc.FlowID,
+		"SENDENSE_SCHEDULE_ID=" + hc.ScheduleID,
+		"SENDENSE_EXECUTION_ID=" + hc.ExecutionID,
+		"SENDENSE_JOB_STATUS=" + hc.Status,
+		"SENDENSE_JOB_ERROR=" + hc.ErrorMessage,
+	}
+}
+
+// =============================================================================
+// GUEST CREDENTIALS
+// =============================================================================
+
+func (s *JobHookService) encryptGuestPassword(password string) (string, error) {
+	if s.encryptionService == nil {
+		return password, nil
+	}
+	encrypted, err := s.encryptionService.EncryptPassword(password)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt guest password: %w", err)
+	}
+	return encrypted, nil
+}
+
+func (s *JobHookService) decryptGuestPassword(encrypted *string) (string, error) {
+	if encrypted == nil {
+		return "", nil
+	}
+	if s.encryptionService == nil {
+		return *encrypted, nil
+	}
+	return s.encryptionService.DecryptPassword(*encrypted)
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/vexxhost/migratekit-sha/database"
+)
+
+func TestValidateSnapshotHookOwner(t *testing.T) {
+	flowColumns := []string{"id", "name", "flow_type", "target_type", "target_id"}
+
+	tests := []struct {
+		name      string
+		ownerType string
+		ownerID   string
+		flowRows  *sqlmock.Rows
+		wantErr   bool
+	}{
+		{
+			name:      "backup flow",
+			ownerType: database.HookOwnerFlow,
+			ownerID:   "flow-1",
+			flowRows:  sqlmock.NewRows(flowColumns).AddRow("flow-1", "nightly", "backup", "vm", "vm-1"),
+		},
+		{
+			name:      "replication flow",
+			ownerType: database.HookOwnerFlow,
+			ownerID:   "flow-2",
+			flowRows:  sqlmock.NewRows(flowColumns).AddRow("flow-2", "dr", "replication", "vm", "vm-1"),
+			wantErr:   true,
+		},
+		{
+			name:      "schedule with a backup flow",
+			ownerType: database.HookOwnerSchedule,
+			ownerID:   "schedule-1",
+			flowRows:  sqlmock.NewRows(flowColumns).AddRow("flow-1", "nightly", "backup", "vm", "vm-1"),
+		},
+		{
+			name:      "schedule driving only replications",
+			ownerType: database.HookOwnerSchedule,
+			ownerID:   "schedule-2",
+			flowRows:  sqlmock.NewRows(flowColumns),
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock := newMockConnection(t)
+			if tt.flowRows != nil {
+				mock.ExpectQuery("SELECT \\* FROM `protection_flows`").WillReturnRows(tt.flowRows)
+			}
+			svc := NewJobHookService(conn, nil, nil)
+
+			err := svc.validateSnapshotHookOwner(context.Background(), tt.ownerType, tt.ownerID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSnapshotHookOwner() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet sql expectations: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,532 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+)
+
+// =============================================================================
+// DURABLE JOB QUEUE - Long-running SHA operations that survive restarts
+// =============================================================================
+// Operations are stored in the job_queue table and run by workers on the leader
+// SHA. A running job holds a lease that its worker extends through heartbeats; if
+// the SHA dies the lease expires and the next leader retries the job (handlers
+// resume from their checkpoint) or compensates it once its attempts are used up.
+// Cancellation is requested through the database and noticed on the next heartbeat.
+
+const (
+	defaultQueueWorkers           = 4
+	defaultQueueLeaseTTL          = 2 * time.Minute
+	defaultQueueHeartbeatInterval = 20 * time.Second
+	defaultQueuePollInterval      = 5 * time.Second
+	defaultQueueMaxAttempts       = 3
+
+	queueRetryBaseDelay = 30 * time.Second
+	queueRetryMaxDelay  = 15 * time.Minute
+)
+
+// QueueHandler runs one kind of queued job
+type QueueHandler interface {
+	// Run performs the job. A job interrupted by an SHA restart runs again, so Run
+	// must be idempotent - record progress with SaveQueueCheckpoint and resume from it.
+	Run(ctx context.Context, job *database.QueuedJob) error
+
+	// Compensate cleans up after a job that will not run again (failed, cancelled or abandoned)
+	Compensate(ctx context.Context, job *database.QueuedJob, reason string) error
+}
+
+// EnqueueRequest describes a job to add to the queue
+type EnqueueRequest struct {
+	Kind           string      `json:"kind"`
+	ResourceID     string      `json:"resource_id,omitempty"`     // Object the job works on
+	Payload        interface{} `json:"payload,omitempty"`         // Marshalled to JSON for the handler
+	IdempotencyKey string      `json:"idempotency_key,omitempty"` // Same key returns the existing job
+	Exclusive      bool        `json:"exclusive,omitempty"`       // Return the unfinished job for the same kind and resource instead
+	MaxAttempts    int         `json:"max_attempts,omitempty"`
+	RunAt          *time.Time  `json:"run_at,omitempty"`
+	CreatedBy      string      `json:"created_by,omitempty"`
+}
+
+// permanentQueueError marks a handler failure that retrying cannot fix
+type permanentQueueError struct {
+	err error
+}
+
+func (e *permanentQueueError) Error() string { return e.err.Error() }
+func (e *permanentQueueError) Unwrap() error { return e.err }
+
+// PermanentError wraps a handler error so the job fails without further attempts
+func PermanentError(err error) error {
+	return &permanentQueueError{err: err}
+}
+
+// runningQueueJob identifies the job a handler runs, for checkpointing through its context
+type runningQueueJob struct {
+	repo   *database.JobQueueRepository
+	jobID  string
+	worker string
+}
+
+type runningQueueJobKey struct{}
+
+// SaveQueueCheckpoint records progress of the queued job running in ctx; it does nothing
+// when ctx does not belong to a queued job
+func SaveQueueCheckpoint(ctx context.Context, checkpoint interface{}) error {
+	running, ok := ctx.Value(runningQueueJobKey{}).(*runningQueueJob)
+	if !ok {
+		return nil
+	}
+
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job checkpoint: %w", err)
+	}
+	return running.repo.SaveCheckpoint(ctx, running.jobID, running.worker, string(data))
+}
+
+// LoadQueueCheckpoint decodes the checkpoint of a queued job; it returns false if there is none
+func LoadQueueCheckpoint(job *database.QueuedJob, checkpoint interface{}) bool {
+	if job.Checkpoint == nil || *job.Checkpoint == "" {
+		return false
+	}
+	return json.Unmarshal([]byte(*job.Checkpoint), checkpoint) == nil
+}
+
+// DecodeQueuePayload decodes the payload of a queued job
+func DecodeQueuePayload(job *database.QueuedJob, payload interface{}) error {
+	if job.Payload == nil {
+		return PermanentError(fmt.Errorf("job %s has no payload", job.ID))
+	}
+	if err := json.Unmarshal([]byte(*job.Payload), payload); err != nil {
+		return PermanentError(fmt.Errorf("invalid payload for job %s: %w", job.ID, err))
+	}
+	return nil
+}
+
+// JobQueue stores long-running operations in the database and runs them on the leader
+type JobQueue struct {
+	repo              *database.JobQueueRepository
+	workerID          string
+	workers           int
+	leaseTTL          time.Duration
+	heartbeatInterval time.Duration
+	pollInterval      time.Duration
+
+	mu       sync.RWMutex
+	handlers map[string]QueueHandler
+	wake     chan struct{}
+}
+
+// NewJobQueue creates a durable job queue; instanceID identifies this SHA in job leases
+func NewJobQueue(db database.Connection, instanceID string) *JobQueue {
+	return &JobQueue{
+		repo:              database.NewJobQueueRepository(db),
+		workerID:          fmt.Sprintf("%s/%s", instanceID, uuid.New().String()[:8]),
+		workers:           defaultQueueWorkers,
+		leaseTTL:          defaultQueueLeaseTTL,
+		heartbeatInterval: defaultQueueHeartbeatInterval,
+		pollInterval:      defaultQueuePollInterval,
+		handlers:          make(map[string]QueueHandler),
+		wake:              make(chan struct{}, 1),
+	}
+}
+
+// RegisterHandler sets the handler that runs jobs of a kind
+func (q *JobQueue) RegisterHandler(kind string, handler QueueHandler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[kind] = handler
+}
+
+// Enqueue adds a job to the queue; it returns the existing job (and false) when the
+// idempotency key was used before or an exclusive job for the resource is unfinished
+func (q *JobQueue) Enqueue(ctx context.Context, req EnqueueRequest) (*database.QueuedJob, bool, error) {
+	q.mu.RLock()
+	_, known := q.handlers[req.Kind]
+	q.mu.RUnlock()
+	if !known {
+		return nil, false, fmt.Errorf("unknown job kind: %s", req.Kind)
+	}
+
+	if req.IdempotencyKey != "" {
+		existing, err := q.repo.GetJobByIdempotencyKey(ctx, req.IdempotencyKey)
+		if err != nil {
+			return nil, false, err
+		}
+		if existing != nil {
+			return existing, false, nil
+		}
+	}
+	if req.Exclusive && req.ResourceID != "" {
+		existing, err := q.repo.GetActiveJob(ctx, req.Kind, req.ResourceID)
+		if err != nil {
+			return nil, false, err
+		}
+		if existing != nil {
+			return existing, false, nil
+		}
+	}
+
+	job := &database.QueuedJob{
+		ID:          uuid.New().String(),
+		Kind:        req.Kind,
+		MaxAttempts: req.MaxAttempts,
+		RunAt:       time.Now(),
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = defaultQueueMaxAttempts
+	}
+	if req.RunAt != nil {
+		job.RunAt = *req.RunAt
+	}
+	if req.ResourceID != "" {
+		job.ResourceID = &req.ResourceID
+	}
+	if req.IdempotencyKey != "" {
+		job.IdempotencyKey = &req.IdempotencyKey
+	}
+	if req.CreatedBy != "" {
+		job.CreatedBy = &req.CreatedBy
+	}
+	if req.Payload != nil {
+		data, err := json.Marshal(req.Payload)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to marshal job payload: %w", err)
+		}
+		payload := string(data)
+		job.Payload = &payload
+	}
+
+	if err := q.repo.Enqueue(ctx, job); err != nil {
+		// Lost a race on the idempotency key - hand back the winner
+		if req.IdempotencyKey != "" {
+			if existing, getErr := q.repo.GetJobByIdempotencyKey(ctx, req.IdempotencyKey); getErr == nil && existing != nil {
+				return existing, false, nil
+			}
+		}
+		return nil, false, err
+	}
+
+	log.WithFields(log.Fields{
+		"queue_job_id": job.ID,
+		"kind":         job.Kind,
+		"resource_id":  req.ResourceID,
+		"run_at":       job.RunAt,
+	}).Info("📥 Job queued")
+
+	q.poke()
+	return job, true, nil
+}
+
+// GetJob retrieves a queued job
+func (q *JobQueue) GetJob(ctx context.Context, id string) (*database.QueuedJob, error) {
+	return q.repo.GetJob(ctx, id)
+}
+
+// ListJobs lists queued jobs
+func (q *JobQueue) ListJobs(ctx context.Context, filters database.QueuedJobFilters) ([]*database.QueuedJob, error) {
+	return q.repo.ListJobs(ctx, filters)
+}
+
+// CancelJob cancels a pending job, or asks the worker of a running job to stop
+func (q *JobQueue) CancelJob(ctx context.Context, id string) (*database.QueuedJob, error) {
+	state, err := q.repo.RequestCancel(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	log.WithFields(log.Fields{"queue_job_id": id, "state": state}).Info("Job cancellation requested")
+	return q.repo.GetJob(ctx, id)
+}
+
+// RequeueJob runs a failed or cancelled job again
+func (q *JobQueue) RequeueJob(ctx context.Context, id string) (*database.QueuedJob, error) {
+	if err := q.repo.Requeue(ctx, id); err != nil {
+		return nil, err
+	}
+	q.poke()
+	return q.repo.GetJob(ctx, id)
+}
+
+// Run processes queued jobs until ctx is cancelled (run it on the leader only)
+func (q *JobQueue) Run(ctx context.Context) {
+	log.WithFields(log.Fields{
+		"worker_id": q.workerID,
+		"workers":   q.workers,
+		"kinds":     q.kinds(),
+	}).Info("📦 Job queue workers started")
+
+	var wg sync.WaitGroup
+	for i := 0; i < q.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.workLoop(ctx)
+		}()
+	}
+
+	// Retry or compensate jobs whose worker died (e.g. the previous leader)
+	ticker := time.NewTicker(q.heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		q.reapExpired(ctx)
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			log.Info("🛑 Job queue workers stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// kinds returns the job kinds this queue has handlers for
+func (q *JobQueue) kinds() []string {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	kinds := make([]string, 0, len(q.handlers))
+	for kind := range q.handlers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// handler returns the handler for a job kind
+func (q *JobQueue) handler(kind string) QueueHandler {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.handlers[kind]
+}
+
+// poke wakes an idle worker
+func (q *JobQueue) poke() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// workLoop claims and runs jobs one at a time
+func (q *JobQueue) workLoop(ctx context.Context) {
+	for {
+		job, err := q.repo.ClaimNext(ctx, q.kinds(), q.workerID, q.leaseTTL)
+		if err != nil && ctx.Err() == nil {
+			log.WithError(err).Warn("Failed to claim queued job")
+		}
+		if job != nil {
+			q.runJob(ctx, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-time.After(q.pollInterval):
+		}
+	}
+}
+
+// runJob runs a claimed job with heartbeats and records its outcome
+func (q *JobQueue) runJob(ctx context.Context, job *database.QueuedJob) {
+	logger := log.WithFields(log.Fields{
+		"queue_job_id": job.ID,
+		"kind":         job.Kind,
+		"attempt":      job.Attempts,
+		"max_attempts": job.MaxAttempts,
+	})
+
+	handler := q.handler(job.Kind)
+	if handler == nil {
+		reason := fmt.Sprintf("no handler for job kind %s", job.Kind)
+		q.finish(job, database.QueueStateFailed, &reason)
+		return
+	}
+
+	jobCtx, cancel := context.WithCancel(context.WithValue(ctx, runningQueueJobKey{}, &runningQueueJob{
+		repo:   q.repo,
+		jobID:  job.ID,
+		worker: q.workerID,
+	}))
+	defer cancel()
+
+	// Heartbeats keep the lease and pick up cancellation requests
+	var leaseLost, cancelRequested bool
+	var flagsMu sync.Mutex
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		ticker := time.NewTicker(q.heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-jobCtx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			held, cancelled, err := q.repo.Heartbeat(context.Background(), job.ID, q.workerID, q.leaseTTL)
+			if err != nil {
+				logger.WithError(err).Warn("Failed to heartbeat queued job")
+				continue
+			}
+			if !held || cancelled {
+				flagsMu.Lock()
+				leaseLost = !held
+				cancelRequested = cancelled
+				flagsMu.Unlock()
+				cancel()
+				return
+			}
+		}
+	}()
+
+	logger.Info("▶️ Running queued job")
+	runErr := q.safeRun(jobCtx, handler, job)
+	cancel()
+	<-heartbeatDone
+
+	flagsMu.Lock()
+	lost, cancelled := leaseLost, cancelRequested
+	flagsMu.Unlock()
+
+	// Job state changes below must not be lost to the cancelled contexts
+	bg := context.Background()
+	switch {
+	case lost:
+		logger.Warn("Lost the lease of a queued job - another worker owns it now")
+
+	case cancelled:
+		reason := "cancelled by request"
+		q.compensate(handler, job, reason)
+		q.finish(job, database.QueueStateCancelled, &reason)
+		logger.Info("⏹️ Queued job cancelled")
+
+	case runErr == nil:
+		q.finish(job, database.QueueStateSucceeded, nil)
+		logger.Info("✅ Queued job succeeded")
+
+	case ctx.Err() != nil:
+		// Worker stopped (shutdown or leadership moved) - run the job again without waiting for its lease
+		if err := q.repo.Reschedule(bg, job.ID, q.workerID, time.Now(), "interrupted: "+runErr.Error()); err != nil {
+			logger.WithError(err).Warn("Failed to hand back interrupted job - it is retried once its lease expires")
+		}
+		logger.Info("Queued job interrupted - handed back to the queue")
+
+	default:
+		var permanent *permanentQueueError
+		if errors.As(runErr, &permanent) || job.Attempts >= job.MaxAttempts {
+			reason := runErr.Error()
+			q.compensate(handler, job, reason)
+			q.finish(job, database.QueueStateFailed, &reason)
+			logger.WithError(runErr).Error("❌ Queued job failed")
+			return
+		}
+
+		runAt := time.Now().Add(queueRetryDelay(job.Attempts))
+		if err := q.repo.Reschedule(bg, job.ID, q.workerID, runAt, runErr.Error()); err != nil {
+			logger.WithError(err).Warn("Failed to reschedule queued job")
+		}
+		logger.WithError(runErr).WithField("retry_at", runAt).Warn("Queued job failed - will retry")
+	}
+}
+
+// safeRun runs a handler, turning a panic into a permanent failure
+func (q *JobQueue) safeRun(ctx context.Context, handler QueueHandler, job *database.QueuedJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = PermanentError(fmt.Errorf("job handler panicked: %v", r))
+		}
+	}()
+	return handler.Run(ctx, job)
+}
+
+// compensate runs the handler's clean-up for a job that will not run again
+func (q *JobQueue) compensate(handler QueueHandler, job *database.QueuedJob, reason string) {
+	// Re-read so the handler sees the latest checkpoint
+	if latest, err := q.repo.GetJob(context.Background(), job.ID); err == nil {
+		job = latest
+	}
+	if err := handler.Compensate(context.Background(), job, reason); err != nil {
+		log.WithError(err).WithField("queue_job_id", job.ID).Error("Failed to compensate queued job")
+	}
+}
+
+// finish records the final state of a job this worker leases
+func (q *JobQueue) finish(job *database.QueuedJob, state string, reason *string) {
+	if err := q.repo.Finish(context.Background(), job.ID, q.workerID, state, reason); err != nil {
+		log.WithError(err).WithField("queue_job_id", job.ID).Warn("Failed to record queued job outcome")
+	}
+}
+
+// reapExpired takes over jobs whose worker stopped heartbeating and retries or compensates them
+func (q *JobQueue) reapExpired(ctx context.Context) {
+	jobs, err := q.repo.GetExpiredJobs(ctx, q.kinds())
+	if err != nil {
+		if ctx.Err() == nil {
+			log.WithError(err).Warn("Failed to look for abandoned queued jobs")
+		}
+		return
+	}
+
+	for _, job := range jobs {
+		taken, err := q.repo.TakeOverExpired(ctx, job.ID, q.workerID, q.leaseTTL)
+		if err != nil || !taken {
+			continue
+		}
+
+		logger := log.WithFields(log.Fields{
+			"queue_job_id":   job.ID,
+			"kind":           job.Kind,
+			"previous_owner": job.LeaseOwner,
+			"attempt":        job.Attempts,
+		})
+
+		handler := q.handler(job.Kind)
+		if job.CancelRequested || job.Attempts >= job.MaxAttempts {
+			reason := "abandoned: worker stopped heartbeating"
+			state := database.QueueStateFailed
+			if job.CancelRequested {
+				reason = "cancelled by request"
+				state = database.QueueStateCancelled
+			}
+			if handler != nil {
+				q.compensate(handler, job, reason)
+			}
+			q.finish(job, state, &reason)
+			logger.Warn("Abandoned queued job compensated")
+			continue
+		}
+
+		if err := q.repo.Reschedule(ctx, job.ID, q.workerID, time.Now(), "worker stopped heartbeating - retrying"); err != nil {
+			logger.WithError(err).Warn("Failed to retry abandoned queued job")
+			continue
+		}
+		logger.Info("🔁 Abandoned queued job returned to the queue")
+		q.poke()
+	}
+}
+
+// queueRetryDelay backs off exponentially between attempts
+func queueRetryDelay(attempt int) time.Duration {
+	delay := queueRetryBaseDelay
+	for i := 1; i < attempt && delay < queueRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > queueRetryMaxDelay {
+		delay = queueRetryMaxDelay
+	}
+	return delay
+}
@@ -24,6 +24,13 @@ type SNAStatusResult struct {
 	ResponseValid bool    // Whether we got a valid response
 }
 
+const (
+	// snaReachableTimeout bounds how long takeover recovery waits for the SNA
+	snaReachableTimeout = 5 * time.Minute
+	// snaReachableRetryInterval is how often the SNA health check is retried
+	snaReachableRetryInterval = 15 * time.Second
+)
+
 // ProductionJobRecovery provides job recovery with minimal dependencies
 type ProductionJobRecovery struct {
 	db                database.Connection
@@ -134,6 +141,64 @@ func (pjr *ProductionJobRecovery) RecoverOrphanedJobsOnStartup(ctx context.Conte
 	return nil
 }
 
+// RecoverOrphanedJobsOnTakeover runs startup recovery once the SNA answers its health check.
+// Recovery decides from what the SNA reports, so while the SNA is unreachable it is skipped
+// and orphaned jobs are left to the stale job detector rather than failed blindly.
+func (pjr *ProductionJobRecovery) RecoverOrphanedJobsOnTakeover(ctx context.Context) error {
+	if !pjr.waitForSNA(ctx) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("⚠️ SNA unreachable for %s - skipping job recovery, stale job detection will handle orphaned jobs",
+			snaReachableTimeout)
+		return nil
+	}
+	return pjr.RecoverOrphanedJobsOnStartup(ctx)
+}
+
+// waitForSNA polls the SNA health endpoint until it answers, the timeout passes or ctx ends
+func (pjr *ProductionJobRecovery) waitForSNA(ctx context.Context) bool {
+	if pjr.snaClient == nil {
+		return false
+	}
+
+	deadline := time.Now().Add(snaReachableTimeout)
+	for {
+		if pjr.snaClient.IsHealthy() {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+
+		log.Printf("⏳ SNA not reachable yet - waiting before job recovery")
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(snaReachableRetryInterval):
+		}
+	}
+}
+
+// hasRecordedResult reports whether a job recorded a CBT change ID or transferred all its bytes
+func (pjr *ProductionJobRecovery) hasRecordedResult(job *database.ReplicationJob) bool {
+	if job.ChangeID != "" {
+		return true
+	}
+	if job.TotalBytes > 0 && job.BytesTransferred >= job.TotalBytes {
+		return true
+	}
+
+	var disksWithChangeID int64
+	if err := pjr.db.GetGormDB().Model(&database.VMDisk{}).
+		Where("job_id = ? AND disk_change_id IS NOT NULL AND disk_change_id != ''", job.ID).
+		Count(&disksWithChangeID).Error; err != nil {
+		log.Printf("⚠️ Failed to check recorded change IDs for job %s: %v", job.ID, err)
+		return false
+	}
+	return disksWithChangeID > 0
+}
+
 // recoverJobWithVMAValidation makes intelligent recovery decision based on SNA status
 func (pjr *ProductionJobRecovery) recoverJobWithVMAValidation(
 	job *database.ReplicationJob,
@@ -163,16 +228,15 @@ func (pjr *ProductionJobRecovery) recoverJobWithVMAValidation(
 		return pjr.markAsFailed(job, snaStatus.ErrorMessage, "vma_reported_failure")
 
 	case "not_found":
-		// Job not found on SNA - decide based on progress
-		if job.ProgressPercent > 90.0 {
-			log.Printf("✅ Job %s not found on SNA but was >90%% complete - assuming completed",
+		// Job not found on SNA - only a job that recorded its result can be trusted as complete
+		if pjr.hasRecordedResult(job) {
+			log.Printf("✅ Job %s not found on SNA but recorded its change ID or final bytes - marking as completed",
 				job.ID)
 			return pjr.markAsCompleted(job, snaStatus)
-		} else {
-			log.Printf("❌ Job %s not found on SNA and was <90%% complete - marking as lost",
-				job.ID)
-			return pjr.markAsFailed(job, "Job lost on SNA (not found after restart)", "job_lost")
 		}
+		log.Printf("❌ Job %s not found on SNA and recorded no final result - marking as lost",
+			job.ID)
+		return pjr.markAsFailed(job, "Job lost on SNA (not found after restart)", "job_lost")
 
 	case "unreachable":
 		// SNA is unreachable - decide based on job age
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+	"github.com/vexxhost/migratekit-sha/models"
+)
+
+// =============================================================================
+// KVM SOURCES - libvirt domains backed up like vCenter VMs
+// =============================================================================
+// The SNA reads a domain's disks with the backup client (kvm-inspect). Registering
+// a domain creates its VM context and vm_disks, so backups of it go through the
+// same BackupHandler, BackupEngine, change ID and telemetry paths as VMware VMs.
+
+// kvmDatacenter is the datacenter (SNA routing scope) of KVM VM contexts
+const kvmDatacenter = "libvirt"
+
+// KVMDomainInfo is a libvirt domain as described by the backup client
+type KVMDomainInfo struct {
+	Name  string          `json:"name"`
+	UUID  string          `json:"uuid"`
+	Disks []KVMDiskDetail `json:"disks"`
+}
+
+// KVMDiskDetail is a domain disk; keys are numbered from 2000 like VMware disk keys
+type KVMDiskDetail struct {
+	Key           int32  `json:"key"`
+	Target        string `json:"target"` // Guest device name (vda, sdb...)
+	Source        string `json:"source"` // Image file or block device
+	Format        string `json:"format"`
+	ReadOnly      bool   `json:"read_only"` // Never backed up
+	CapacityBytes int64  `json:"capacity_bytes"`
+}
+
+// DiskID returns the vm_disks disk ID of the disk
+func (d KVMDiskDetail) DiskID() string {
+	return fmt.Sprintf("disk-%d", d.Key)
+}
+
+// KVMSourceService registers libvirt domains and keeps their vm_disks current
+type KVMSourceService struct {
+	vmContextRepo *database.VMReplicationContextRepository
+	vmDiskRepo    *database.VMDiskRepository
+	diskChanges   *DiskChangeService
+	snaRouter     *SNARouter
+	client        *http.Client
+}
+
+// NewKVMSourceService creates a new KVM source service
+func NewKVMSourceService(db database.Connection, diskChanges *DiskChangeService) *KVMSourceService {
+	return &KVMSourceService{
+		vmContextRepo: database.NewVMReplicationContextRepository(db),
+		vmDiskRepo:    database.NewVMDiskRepository(db),
+		diskChanges:   diskChanges,
+		client:        &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+// SetSNARouter asks the SNA serving each KVM host for its domains
+func (s *KVMSourceService) SetSNARouter(router *SNARouter) {
+	s.snaRouter = router
+}
+
+// KVMHost returns the libvirt host of a connection URI ("localhost" for local libvirt)
+func KVMHost(libvirtURI string) string {
+	parsed, err := url.Parse(libvirtURI)
+	if err != nil || parsed.Hostname() == "" {
+		return "localhost"
+	}
+	return parsed.Hostname()
+}
+
+// RegisterDomain reads a domain's disks through the SNA and creates its VM context and vm_disks
+func (s *KVMSourceService) RegisterDomain(ctx context.Context, source database.KVMSource) (*database.VMReplicationContext, error) {
+	if err := source.Validate(); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.vmContextRepo.GetVMContextByName(source.Domain)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("VM context already exists for %s", source.Domain)
+	}
+
+	host := KVMHost(source.LibvirtURI)
+	endpoint, err := s.snaRouter.EndpointForScope(ctx, SNAPlacement{VCenterHost: host, Datacenter: kvmDatacenter}, DefaultSNAEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("no SNA available for KVM host %s: %w", host, err)
+	}
+	domain, err := s.InspectDomain(ctx, endpoint, source)
+	if err != nil {
+		return nil, err
+	}
+
+	// The change ID lookup of the backup client goes by domain name
+	source.Domain = domain.Name
+	source.DomainUUID = domain.UUID
+	encoded, err := database.EncodeKVMSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	vmContext := &database.VMReplicationContext{
+		ContextID:        fmt.Sprintf("ctx-%s-%s", domain.Name, time.Now().Format("20060102-150405")),
+		VMName:           domain.Name,
+		VMwareVMID:       domain.UUID,
+		VMPath:           domain.Name,
+		VCenterHost:      host,
+		Datacenter:       kvmDatacenter,
+		SourceType:       database.SourceTypeKVM,
+		KVMSource:        encoded,
+		CurrentStatus:    "discovered",
+		SchedulerEnabled: true,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+		LastStatusChange: time.Now(),
+	}
+	if err := s.vmContextRepo.CreateVMContext(vmContext); err != nil {
+		return nil, err
+	}
+
+	if err := s.diskChanges.ApplyDiskChanges(vmContext.ContextID, diffKVMDisks(nil, domain)); err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		"context_id":  vmContext.ContextID,
+		"domain":      domain.Name,
+		"domain_uuid": domain.UUID,
+		"kvm_host":    host,
+		"disk_count":  len(domain.Disks),
+	}).Info("🐧 Registered KVM domain for backup")
+
+	return vmContext, nil
+}
+
+// RefreshVMDisks reads a KVM VM's disks through the SNA and applies added and resized
+// disks to vm_disks. Removed disks keep their records and are only reported, as for VMware.
+func (s *KVMSourceService) RefreshVMDisks(ctx context.Context, vmContext *database.VMReplicationContext) (*DiskChangeSet, error) {
+	source, err := database.ParseKVMSource(vmContext.KVMSource)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.vmDiskRepo.GetByVMContextID(vmContext.ContextID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stored VM disks: %w", err)
+	}
+
+	endpoint := DefaultSNAEndpoint
+	if s.snaRouter != nil {
+		route, err := s.snaRouter.SelectForVM(ctx, vmContext.ContextID, DefaultSNAEndpoint)
+		if err != nil {
+			return nil, fmt.Errorf("no SNA available for VM: %w", err)
+		}
+		endpoint = route.Endpoint
+	}
+	domain, err := s.InspectDomain(ctx, endpoint, *source)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := diffKVMDisks(stored, domain)
+	if changes.HasChanges() {
+		log.WithFields(log.Fields{
+			"vm_name": vmContext.VMName,
+			"added":   len(changes.Added),
+			"removed": len(changes.Removed),
+			"resized": len(changes.Resized),
+		}).Info("📀 Detected VM disk changes since last job")
+	}
+	if err := s.diskChanges.ApplyDiskChanges(vmContext.ContextID, changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// InspectDomain asks an SNA for a domain's UUID and disks
+func (s *KVMSourceService) InspectDomain(ctx context.Context, endpoint string, source database.KVMSource) (*KVMDomainInfo, error) {
+	// Prefer the UUID once known - it survives domain renames
+	domainRef := source.Domain
+	if source.DomainUUID != "" {
+		domainRef = source.DomainUUID
+	}
+	jsonData, _ := json.Marshal(map[string]interface{}{
+		"libvirt_uri": source.LibvirtURI,
+		"domain":      domainRef,
+	})
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/api/v1/kvm/domain", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SNA request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call SNA: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("SNA domain inspection failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var domain KVMDomainInfo
+	if err := json.NewDecoder(resp.Body).Decode(&domain); err != nil {
+		return nil, fmt.Errorf("failed to decode SNA response: %w", err)
+	}
+	if domain.Name == "" || domain.UUID == "" {
+		return nil, fmt.Errorf("SNA returned no domain name or UUID")
+	}
+	return &domain, nil
+}
+
+// diffKVMDisks compares stored vm_disks with a domain's writable disks
+func diffKVMDisks(stored []database.VMDisk, domain *KVMDomainInfo) *DiskChangeSet {
+	storedByID := make(map[string]*database.VMDisk, len(stored))
+	for i := range stored {
+		storedByID[stored[i].DiskID] = &stored[i]
+	}
+
+	changes := &DiskChangeSet{}
+	current := make(map[string]bool, len(domain.Disks))
+	for _, disk := range domain.Disks {
+		if disk.ReadOnly {
+			continue
+		}
+		diskID := disk.DiskID()
+		current[diskID] = true
+		info := kvmDiskInfo(disk)
+
+		vmDisk, exists := storedByID[diskID]
+		switch {
+		case !exists:
+			changes.Added = append(changes.Added, DiskChange{
+				DiskID:           diskID,
+				Label:            disk.Target,
+				ChangeType:       DiskChangeAdded,
+				NewCapacityBytes: disk.CapacityBytes,
+				Disk:             info,
+			})
+		case vmDisk.CapacityBytes != disk.CapacityBytes:
+			changes.Resized = append(changes.Resized, DiskChange{
+				DiskID:           diskID,
+				Label:            disk.Target,
+				ChangeType:       DiskChangeResized,
+				OldCapacityBytes: vmDisk.CapacityBytes,
+				NewCapacityBytes: disk.CapacityBytes,
+				Disk:             info,
+			})
+		}
+	}
+
+	for _, vmDisk := range stored {
+		if !current[vmDisk.DiskID] {
+			changes.Removed = append(changes.Removed, DiskChange{
+				DiskID:           vmDisk.DiskID,
+				Label:            vmDisk.Label,
+				ChangeType:       DiskChangeRemoved,
+				OldCapacityBytes: vmDisk.CapacityBytes,
+			})
+		}
+	}
+	return changes
+}
+
+// kvmDiskInfo converts a domain disk to the disk form vm_disks are written from
+func kvmDiskInfo(disk KVMDiskDetail) *models.DiskInfo {
+	const gib = int64(1024 * 1024 * 1024)
+	return &models.DiskInfo{
+		ID:            disk.DiskID(),
+		Path:          disk.Source,
+		VMDKPath:      disk.Source,
+		SizeGB:        int((disk.CapacityBytes + gib - 1) / gib),
+		Label:         disk.Target,
+		CapacityBytes: disk.CapacityBytes,
+	}
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/vexxhost/migratekit-sha/database"
+)
+
+func TestDiffKVMDisks(t *testing.T) {
+	const gib = int64(1024 * 1024 * 1024)
+	stored := []database.VMDisk{
+		{DiskID: "disk-2000", Label: "vda", CapacityBytes: 20 * gib},
+		{DiskID: "disk-2001", Label: "vdb", CapacityBytes: 10 * gib},
+		{DiskID: "disk-2003", Label: "vdd", CapacityBytes: 5 * gib},
+	}
+	domain := &KVMDomainInfo{
+		Name: "pgtest1",
+		UUID: "8a6d1e5c-1f2b-4c3d-9e8f-0a1b2c3d4e5f",
+		Disks: []KVMDiskDetail{
+			{Key: 2000, Target: "vda", Source: "/var/lib/libvirt/images/pgtest1.qcow2", CapacityBytes: 20 * gib},
+			{Key: 2001, Target: "vdb", Source: "/var/lib/libvirt/images/pgtest1-data.qcow2", CapacityBytes: 15 * gib},
+			{Key: 2002, Target: "sda", Source: "/var/lib/libvirt/images/seed.iso", ReadOnly: true, CapacityBytes: gib},
+			{Key: 2004, Target: "vde", Source: "/var/lib/libvirt/images/pgtest1-logs.qcow2", CapacityBytes: 3*gib + 1},
+		},
+	}
+
+	changes := diffKVMDisks(stored, domain)
+
+	if len(changes.Added) != 1 || changes.Added[0].DiskID != "disk-2004" {
+		t.Fatalf("added = %+v, want only disk-2004", changes.Added)
+	}
+	if added := changes.Added[0].Disk; added.SizeGB != 4 || added.VMDKPath != "/var/lib/libvirt/images/pgtest1-logs.qcow2" || added.Label != "vde" {
+		t.Errorf("added disk = %+v, want 4 GB vde with its image path", added)
+	}
+	if len(changes.Resized) != 1 || changes.Resized[0].DiskID != "disk-2001" ||
+		changes.Resized[0].OldCapacityBytes != 10*gib || changes.Resized[0].NewCapacityBytes != 15*gib {
+		t.Errorf("resized = %+v, want disk-2001 from 10 to 15 GiB", changes.Resized)
+	}
+	if removed := changes.RemovedDiskIDs(); len(removed) != 1 || !removed["disk-2003"] {
+		t.Errorf("removed = %v, want only disk-2003", removed)
+	}
+}
+
+func TestKVMHost(t *testing.T) {
+	tests := map[string]string{
+		"qemu+ssh://root@kvm1.example.com/system": "kvm1.example.com",
+		"qemu+tcp://10.0.0.5:16509/system":        "10.0.0.5",
+		"qemu:///system":                          "localhost",
+		"":                                        "localhost",
+	}
+	for uri, want := range tests {
+		if got := KVMHost(uri); got != want {
+			t.Errorf("KVMHost(%q) = %q, want %q", uri, got, want)
+		}
+	}
+}
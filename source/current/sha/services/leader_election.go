@@ -0,0 +1,340 @@
+package services
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+)
+
+// =============================================================================
+// LEADER ELECTION - Active/standby SHA control plane
+// =============================================================================
+// SHA instances sharing one database elect a leader through a DB-backed lease.
+// Only the leader runs schedulers and background workers (registered through
+// OnElected); the API is served by every instance. When the leader stops renewing
+// its lease a standby takes over, runs the takeover hooks (job recovery) and then
+// starts the workers. A leader that cannot renew stops its workers before the lease
+// can expire, so two instances never schedule at the same time.
+
+const (
+	// ControlPlaneLease is the lease that elects the SHA running schedulers and workers
+	ControlPlaneLease = "sha-control-plane"
+
+	defaultLeaseTTL      = 30 * time.Second
+	defaultRenewInterval = 10 * time.Second
+)
+
+// LeaderTask runs while this instance is the leader and must return once ctx is cancelled
+type LeaderTask func(ctx context.Context)
+
+// LeaderStatus describes the leader election as seen by this instance
+type LeaderStatus struct {
+	InstanceID   string                `json:"instance_id"`
+	IsLeader     bool                  `json:"is_leader"`
+	LeaderSince  *time.Time            `json:"leader_since,omitempty"`
+	Lease        *database.LeaderLease `json:"lease,omitempty"`
+	LeaseExpired bool                  `json:"lease_expired"`
+	Tasks        []string              `json:"tasks"`
+}
+
+type namedLeaderTask struct {
+	name string
+	run  LeaderTask
+}
+
+type namedTakeoverHook struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// LeaderElector elects this SHA instance as control-plane leader and runs leader-only work
+type LeaderElector struct {
+	repo          *database.LeaderLeaseRepository
+	leaseName     string
+	instanceID    string
+	leaseTTL      time.Duration
+	renewInterval time.Duration
+
+	mu          sync.Mutex
+	hooks       []namedTakeoverHook
+	tasks       []namedLeaderTask
+	leading     bool
+	leaderSince *time.Time
+	lastRenewed time.Time
+	leaderCtx   context.Context
+	cancel      context.CancelFunc
+	running     sync.WaitGroup
+}
+
+// NewLeaderElector creates a leader elector for the SHA control plane
+func NewLeaderElector(db database.Connection, instanceID string) *LeaderElector {
+	return &LeaderElector{
+		repo:          database.NewLeaderLeaseRepository(db),
+		leaseName:     ControlPlaneLease,
+		instanceID:    instanceID,
+		leaseTTL:      defaultLeaseTTL,
+		renewInterval: defaultRenewInterval,
+	}
+}
+
+// DefaultInstanceID identifies this SHA instance (SHA_INSTANCE_ID, else the hostname);
+// it is stable across restarts so a restarted leader takes its own lease straight back
+func DefaultInstanceID() string {
+	if id := os.Getenv("SHA_INSTANCE_ID"); id != "" {
+		return id
+	}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		return hostname
+	}
+	return "sha"
+}
+
+// OnTakeover registers a hook run each time this instance becomes leader, before any
+// leader task starts (e.g. recovering jobs left in flight by the previous leader).
+// Without an elector the hook runs immediately.
+func (e *LeaderElector) OnTakeover(name string, hook func(ctx context.Context) error) {
+	if e == nil {
+		if err := hook(context.Background()); err != nil {
+			log.WithError(err).WithField("hook", name).Error("Takeover hook failed")
+		}
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.hooks = append(e.hooks, namedTakeoverHook{name: name, run: hook})
+}
+
+// OnElected registers a task that runs while this instance is leader; it is started on
+// every election and its context is cancelled when leadership is lost.
+// Without an elector the task starts immediately and runs for the process lifetime.
+func (e *LeaderElector) OnElected(name string, task LeaderTask) {
+	if e == nil {
+		go task(context.Background())
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.tasks = append(e.tasks, namedLeaderTask{name: name, run: task})
+	if e.leading && e.leaderCtx != nil {
+		e.startTask(e.leaderCtx, namedLeaderTask{name: name, run: task})
+	}
+}
+
+// IsLeader reports whether this instance currently runs the leader-only work
+func (e *LeaderElector) IsLeader() bool {
+	if e == nil {
+		return true
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leading
+}
+
+// Run takes part in the leader election until ctx is cancelled; it resigns the
+// leadership before returning
+func (e *LeaderElector) Run(ctx context.Context) {
+	log.WithFields(log.Fields{
+		"instance_id":    e.instanceID,
+		"lease":          e.leaseName,
+		"lease_ttl":      e.leaseTTL,
+		"renew_interval": e.renewInterval,
+	}).Info("🗳️ Leader election started")
+
+	ticker := time.NewTicker(e.renewInterval)
+	defer ticker.Stop()
+
+	for {
+		e.renew(ctx)
+
+		select {
+		case <-ctx.Done():
+			releaseCtx, cancel := context.WithTimeout(context.Background(), e.renewInterval)
+			e.Resign(releaseCtx)
+			cancel()
+			log.Info("🛑 Leader election stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Resign stops the leader-only work and releases the lease so a standby takes over at once
+func (e *LeaderElector) Resign(ctx context.Context) {
+	if e == nil || !e.IsLeader() {
+		return
+	}
+
+	e.stepDown("resigned")
+	if err := e.repo.Release(ctx, e.leaseName, e.instanceID); err != nil {
+		log.WithError(err).Warn("Failed to release leader lease")
+	}
+}
+
+// renew acquires or renews the lease and starts or stops the leader-only work to match
+func (e *LeaderElector) renew(ctx context.Context) {
+	// The database sets the new expiry at some point during the call, so the lease
+	// is only known to run until leaseTTL after the attempt started
+	attempted := time.Now()
+
+	// A hung database call must not hold up fencing past the lease TTL
+	acquireCtx, cancel := context.WithTimeout(ctx, e.renewInterval)
+	held, err := e.repo.TryAcquire(acquireCtx, e.leaseName, e.instanceID, e.leaseTTL)
+	cancel()
+	if err != nil {
+		log.WithError(err).Warn("Failed to renew leader lease")
+
+		// Stop leading before the lease can expire and a standby takes over
+		e.mu.Lock()
+		fenced := e.leading && e.fenceDue(time.Now())
+		e.mu.Unlock()
+		if fenced {
+			e.stepDown("lease could not be renewed")
+		}
+		return
+	}
+
+	e.mu.Lock()
+	leading := e.leading
+	if held {
+		e.lastRenewed = attempted
+	}
+	e.mu.Unlock()
+
+	switch {
+	case held && !leading:
+		e.becomeLeader(ctx)
+	case !held && leading:
+		e.stepDown("lease held by another instance")
+	}
+}
+
+// fenceDue reports whether the next renewal attempt - starting up to renewInterval
+// from now and taking up to renewInterval - could fail after the lease expires, so
+// the leader has to stop now (caller holds e.mu)
+func (e *LeaderElector) fenceDue(now time.Time) bool {
+	return now.Sub(e.lastRenewed) > e.leaseTTL-2*e.renewInterval
+}
+
+// becomeLeader runs the takeover hooks and then starts every leader task
+func (e *LeaderElector) becomeLeader(ctx context.Context) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	now := time.Now()
+
+	e.mu.Lock()
+	e.leading = true
+	e.leaderSince = &now
+	e.leaderCtx = leaderCtx
+	e.cancel = cancel
+	hooks := append([]namedTakeoverHook(nil), e.hooks...)
+	e.mu.Unlock()
+
+	log.WithField("instance_id", e.instanceID).Info("👑 Elected SHA control-plane leader")
+
+	// Hooks can take a while (they talk to the SNA) - run them off the renewal loop
+	e.running.Add(1)
+	go func() {
+		defer e.running.Done()
+
+		for _, hook := range hooks {
+			if leaderCtx.Err() != nil {
+				return
+			}
+			log.WithField("hook", hook.name).Info("Running leader takeover hook")
+			if err := hook.run(leaderCtx); err != nil {
+				log.WithError(err).WithField("hook", hook.name).Error("Leader takeover hook failed")
+			}
+		}
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if leaderCtx.Err() != nil {
+			return
+		}
+		for _, task := range e.tasks {
+			e.startTask(leaderCtx, task)
+		}
+		log.WithField("tasks", len(e.tasks)).Info("✅ Leader tasks started")
+	}()
+}
+
+// startTask runs a leader task until leadership ends (caller holds e.mu)
+func (e *LeaderElector) startTask(ctx context.Context, task namedLeaderTask) {
+	e.running.Add(1)
+	go func() {
+		defer e.running.Done()
+		task.run(ctx)
+		log.WithField("task", task.name).Debug("Leader task returned")
+	}()
+}
+
+// stepDown stops every leader task and waits (bounded by the lease TTL) for them to return
+func (e *LeaderElector) stepDown(reason string) {
+	e.mu.Lock()
+	if !e.leading {
+		e.mu.Unlock()
+		return
+	}
+	e.leading = false
+	e.leaderSince = nil
+	e.leaderCtx = nil
+	cancel := e.cancel
+	e.cancel = nil
+	e.mu.Unlock()
+
+	log.WithFields(log.Fields{
+		"instance_id": e.instanceID,
+		"reason":      reason,
+	}).Warn("⬇️ Stepping down as SHA control-plane leader")
+
+	cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		e.running.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		log.Info("Leader tasks stopped")
+	case <-time.After(e.leaseTTL):
+		log.Warn("Timeout waiting for leader tasks to stop")
+	}
+}
+
+// Status returns the leader election state and the current lease
+func (e *LeaderElector) Status(ctx context.Context) (*LeaderStatus, error) {
+	e.mu.Lock()
+	status := &LeaderStatus{
+		InstanceID:  e.instanceID,
+		IsLeader:    e.leading,
+		LeaderSince: e.leaderSince,
+		Tasks:       make([]string, 0, len(e.tasks)),
+	}
+	for _, task := range e.tasks {
+		status.Tasks = append(status.Tasks, task.name)
+	}
+	e.mu.Unlock()
+
+	lease, err := e.repo.GetLease(ctx, e.leaseName)
+	if err != nil {
+		return nil, err
+	}
+	status.Lease = lease
+
+	if lease != nil {
+		expired, err := e.repo.LeaseExpired(ctx, e.leaseName)
+		if err != nil {
+			return nil, err
+		}
+		status.LeaseExpired = expired
+	}
+	return status, nil
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLeaderElectorFencesBeforeLeaseExpiry(t *testing.T) {
+	const (
+		leaseTTL      = 30 * time.Second
+		renewInterval = 10 * time.Second
+	)
+
+	tests := []struct {
+		name        string
+		sinceRenew  time.Duration
+		wantLeading bool
+	}{
+		{name: "next attempt ends before expiry", sinceRenew: 5 * time.Second, wantLeading: true},
+		// The next attempt may start at 21s and fail at 31s, after the lease expired
+		{name: "next attempt may end after expiry", sinceRenew: 11 * time.Second, wantLeading: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock := newMockConnection(t)
+			mock.ExpectExec("INSERT IGNORE INTO leader_leases").WillReturnError(errors.New("database unavailable"))
+
+			e := NewLeaderElector(conn, "sha-1")
+			e.leaseTTL = leaseTTL
+			e.renewInterval = renewInterval
+			e.becomeLeader(context.Background())
+			e.mu.Lock()
+			e.lastRenewed = time.Now().Add(-tt.sinceRenew)
+			e.mu.Unlock()
+
+			e.renew(context.Background())
+			if got := e.IsLeader(); got != tt.wantLeading {
+				t.Errorf("IsLeader() = %v, want %v", got, tt.wantLeading)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet sql expectations: %v", err)
+			}
+			e.stepDown("test finished")
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/joblog"
+)
+
+// Live event types pushed to API subscribers
+const (
+	LiveEventTelemetry     = "telemetry"      // Telemetry update from a backup client (Data: TelemetryUpdate)
+	LiveEventJob           = "job"            // JobLog job started, progressed or ended (Data: joblog.Transition)
+	LiveEventStep          = "job_step"       // JobLog step started or ended - failover phases are steps (Data: joblog.Transition)
+	LiveEventFlowExecution = "flow_execution" // Protection flow execution finished (Data: status and job counts)
+	LiveEventExecutionJob  = "execution_job"  // Protection flow execution started a job (JobID, ExecutionID)
+)
+
+const (
+	// liveEventHistory is how many recent events are kept so reconnecting subscribers can resume
+	liveEventHistory = 1024
+
+	// liveEventBuffer is how many events may queue for one subscriber before it is dropped;
+	// a replay that does not fit ends the stream so the client resumes from its last event
+	liveEventBuffer = 256
+)
+
+// LiveEvent is a job update pushed to API subscribers as it happens
+type LiveEvent struct {
+	ID            uint64      `json:"id"`
+	Type          string      `json:"type"`
+	JobID         string      `json:"job_id,omitempty"`
+	ExternalJobID string      `json:"external_job_id,omitempty"`
+	JobType       string      `json:"job_type,omitempty"`
+	ExecutionID   string      `json:"execution_id,omitempty"`
+	Time          time.Time   `json:"time"`
+	Data          interface{} `json:"data,omitempty"`
+}
+
+// LiveEventFilter selects the events of a subscription (empty = all jobs)
+type LiveEventFilter struct {
+	JobID       string // Matches the job ID or the JobLog external job ID
+	ExecutionID string // Matches the execution's own events and those of the jobs it created
+}
+
+// LiveSubscription receives the events matching its filter until it is closed. Events is
+// closed when the subscriber falls too far behind; it can resume from the last event ID.
+type LiveSubscription struct {
+	Events <-chan LiveEvent
+
+	events chan LiveEvent
+	filter LiveEventFilter
+	jobIDs map[string]bool // Jobs created by the filtered execution
+}
+
+// LiveEventHub fans telemetry, JobLog transitions and flow execution changes out to
+// API subscribers so they do not have to poll the database
+type LiveEventHub struct {
+	mu          sync.Mutex
+	nextID      uint64
+	history     []LiveEvent
+	subscribers map[*LiveSubscription]struct{}
+}
+
+// NewLiveEventHub creates an event hub and subscribes it to JobLog transitions
+func NewLiveEventHub() *LiveEventHub {
+	hub := &LiveEventHub{
+		subscribers: make(map[*LiveSubscription]struct{}),
+	}
+	joblog.AddTransitionListener(hub.publishTransition)
+	return hub
+}
+
+// Publish stamps an event and delivers it to matching subscribers; it never blocks
+func (h *LiveEventHub) Publish(event LiveEvent) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	event.ID = h.nextID
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	h.history = append(h.history, event)
+	if len(h.history) > liveEventHistory {
+		h.history = h.history[len(h.history)-liveEventHistory:]
+	}
+
+	for sub := range h.subscribers {
+		sub.observe(event)
+		if !sub.matches(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// Drop slow subscribers rather than stall publishers
+			log.WithField("event_id", event.ID).Warn("Live event subscriber fell behind - closing its stream")
+			delete(h.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// Subscribe starts a subscription. Events after lastID still in the history are
+// replayed first (0 = only new events). When more events are waiting than fit the
+// buffer, the subscription gets as many as fit and Events is then closed, so the
+// client reconnects from the last event it received.
+func (h *LiveEventHub) Subscribe(filter LiveEventFilter, jobIDs []string, lastID uint64) *LiveSubscription {
+	events := make(chan LiveEvent, liveEventBuffer)
+	sub := &LiveSubscription{
+		Events: events,
+		events: events,
+		filter: filter,
+	}
+	sub.setJobIDs(jobIDs)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, event := range h.history {
+		// Jobs started before the subscription still count for an execution filter
+		sub.observe(event)
+		if lastID == 0 || event.ID <= lastID || !sub.matches(event) {
+			continue
+		}
+		if len(sub.events) == liveEventBuffer {
+			log.WithField("last_event_id", lastID).Info("Live event replay exceeds the buffer - closing the stream after it")
+			// Not registered, so Unsubscribe leaves the closed channel alone
+			close(sub.events)
+			return sub
+		}
+		sub.events <- event
+	}
+	h.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe ends a subscription
+func (h *LiveEventHub) Unsubscribe(sub *LiveSubscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, exists := h.subscribers[sub]; exists {
+		delete(h.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// publishTransition forwards a JobLog transition
+func (h *LiveEventHub) publishTransition(transition joblog.Transition) {
+	eventType := LiveEventJob
+	if transition.StepID != 0 {
+		eventType = LiveEventStep
+	}
+	h.Publish(LiveEvent{
+		Type:          eventType,
+		JobID:         transition.JobID,
+		ExternalJobID: transition.ExternalJobID,
+		JobType:       transition.JobType,
+		Time:          transition.Time,
+		Data:          transition,
+	})
+}
+
+func (s *LiveSubscription) setJobIDs(jobIDs []string) {
+	s.jobIDs = make(map[string]bool, len(jobIDs))
+	for _, id := range jobIDs {
+		s.jobIDs[id] = true
+	}
+}
+
+// observe follows the jobs the filtered execution starts; the hub lock is held
+func (s *LiveSubscription) observe(event LiveEvent) {
+	if event.Type == LiveEventExecutionJob && s.filter.ExecutionID != "" &&
+		event.ExecutionID == s.filter.ExecutionID && event.JobID != "" {
+		s.jobIDs[event.JobID] = true
+	}
+}
+
+// matches reports whether an event passes the subscription filter; the hub lock is held
+func (s *LiveSubscription) matches(event LiveEvent) bool {
+	if s.filter.JobID != "" && event.JobID != s.filter.JobID && event.ExternalJobID != s.filter.JobID {
+		return false
+	}
+	if s.filter.ExecutionID != "" && event.ExecutionID != s.filter.ExecutionID &&
+		!s.jobIDs[event.JobID] && (event.ExternalJobID == "" || !s.jobIDs[event.ExternalJobID]) {
+		return false
+	}
+	return true
+}
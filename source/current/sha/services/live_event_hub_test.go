@@ -0,0 +1,83 @@
+package services
+
+import "testing"
+
+func drainLiveEvents(sub *LiveSubscription) ([]LiveEvent, bool) {
+	var events []LiveEvent
+	for {
+		select {
+		case event, ok := <-sub.Events:
+			if !ok {
+				return events, true
+			}
+			events = append(events, event)
+		default:
+			return events, false
+		}
+	}
+}
+
+func TestLiveEventHubFollowsExecutionJobs(t *testing.T) {
+	hub := NewLiveEventHub()
+	// A job started before the subscription is still followed
+	hub.Publish(LiveEvent{Type: LiveEventExecutionJob, JobID: "backup-1", ExecutionID: "exec-1"})
+
+	sub := hub.Subscribe(LiveEventFilter{ExecutionID: "exec-1"}, nil, 0)
+	defer hub.Unsubscribe(sub)
+
+	hub.Publish(LiveEvent{Type: LiveEventExecutionJob, JobID: "backup-2", ExecutionID: "exec-1"})
+	hub.Publish(LiveEvent{Type: LiveEventExecutionJob, JobID: "backup-3", ExecutionID: "exec-other"})
+	hub.Publish(LiveEvent{Type: LiveEventTelemetry, JobID: "backup-1"})
+	hub.Publish(LiveEvent{Type: LiveEventTelemetry, JobID: "backup-2"})
+	hub.Publish(LiveEvent{Type: LiveEventTelemetry, JobID: "backup-3"})
+	hub.Publish(LiveEvent{Type: LiveEventJob, JobID: "joblog-9", ExternalJobID: "backup-2"})
+
+	events, closed := drainLiveEvents(sub)
+	if closed {
+		t.Fatal("subscription closed unexpectedly")
+	}
+
+	var got []string
+	for _, event := range events {
+		got = append(got, event.Type+":"+event.JobID)
+	}
+	want := []string{"execution_job:backup-2", "telemetry:backup-1", "telemetry:backup-2", "job:joblog-9"}
+	if len(got) != len(want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLiveEventHubReplayOverflowClosesStream(t *testing.T) {
+	hub := NewLiveEventHub()
+	hub.Publish(LiveEvent{Type: LiveEventTelemetry, JobID: "backup-1"})
+	for i := 0; i < liveEventBuffer+10; i++ {
+		hub.Publish(LiveEvent{Type: LiveEventTelemetry, JobID: "backup-1"})
+	}
+
+	sub := hub.Subscribe(LiveEventFilter{JobID: "backup-1"}, nil, 1)
+	defer hub.Unsubscribe(sub)
+
+	events, closed := drainLiveEvents(sub)
+	if !closed {
+		t.Fatal("stream stayed open after a replay larger than the buffer")
+	}
+	if len(events) != liveEventBuffer {
+		t.Fatalf("replayed %d events, want %d", len(events), liveEventBuffer)
+	}
+	if events[0].ID != 2 || events[len(events)-1].ID != uint64(liveEventBuffer+1) {
+		t.Errorf("replayed IDs %d..%d, want 2..%d", events[0].ID, events[len(events)-1].ID, liveEventBuffer+1)
+	}
+
+	// Reconnecting from the last delivered event gets the rest
+	resumed := hub.Subscribe(LiveEventFilter{JobID: "backup-1"}, nil, events[len(events)-1].ID)
+	defer hub.Unsubscribe(resumed)
+	rest, closed := drainLiveEvents(resumed)
+	if closed || len(rest) != 10 {
+		t.Errorf("resumed replay = %d events (closed %v), want 10 on an open stream", len(rest), closed)
+	}
+}
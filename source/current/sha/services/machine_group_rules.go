@@ -0,0 +1,429 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/vexxhost/migratekit-sha/database"
+	"github.com/vexxhost/migratekit-sha/joblog"
+)
+
+// =============================================================================
+// DYNAMIC MACHINE GROUPS - membership by rules over vSphere metadata
+// =============================================================================
+// Discovery records each VM's tags, folder, resource pool, cluster and datastores on
+// its context and re-evaluates the rules, so matching VMs join the group (and the
+// flow attached to it) without manual assignment. Rules only remove the memberships
+// they added; VMs assigned by hand stay until removed by hand.
+
+// RuleMembershipAddedBy marks the memberships created by group rules
+const RuleMembershipAddedBy = "membership-rules"
+
+// Reasons recorded with rule membership changes
+const (
+	RuleEvaluationDiscovery    = "discovery"
+	RuleEvaluationRulesUpdated = "rules-updated"
+	RuleEvaluationManual       = "manual"
+)
+
+// MembershipRules select the VMs of a dynamic group. A VM must satisfy every criterion
+// that is set; the values of one criterion are alternatives. Names compare without case.
+type MembershipRules struct {
+	Tags          []string `json:"tags,omitempty"`           // "category:tag" - any attached
+	Categories    []string `json:"categories,omitempty"`     // Any tag of these categories attached
+	FolderPaths   []string `json:"folder_paths,omitempty"`   // VM folder is one of these or below it
+	ResourcePools []string `json:"resource_pools,omitempty"` // Resource pool name
+	Clusters      []string `json:"clusters,omitempty"`       // Cluster of the VM host
+	Datastores    []string `json:"datastores,omitempty"`     // Any VM disk on one of these
+	NameRegex     string   `json:"name_regex,omitempty"`     // Go regular expression over the VM name
+	GuestOS       []string `json:"guest_os,omitempty"`       // OS family: windows, linux, macos, other
+
+	nameRegex *regexp.Regexp
+}
+
+// VMMetadata is the vSphere metadata membership rules are evaluated against
+type VMMetadata struct {
+	Name         string
+	FolderPath   string
+	ResourcePool string
+	Cluster      string
+	OSType       string
+	Tags         []string
+	Datastores   []string
+}
+
+// MembershipEvaluationResult reports what one evaluation of a group's rules changed
+type MembershipEvaluationResult struct {
+	GroupID   string   `json:"group_id"`
+	GroupName string   `json:"group_name"`
+	Matched   int      `json:"matched"`
+	Added     []string `json:"added"`   // VM context IDs
+	Removed   []string `json:"removed"` // VM context IDs
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// IsEmpty reports whether no criterion is set
+func (r *MembershipRules) IsEmpty() bool {
+	return len(r.Tags) == 0 && len(r.Categories) == 0 && len(r.FolderPaths) == 0 &&
+		len(r.ResourcePools) == 0 && len(r.Clusters) == 0 && len(r.Datastores) == 0 &&
+		r.NameRegex == "" && len(r.GuestOS) == 0
+}
+
+// Validate checks the rules and compiles the name expression
+func (r *MembershipRules) Validate() error {
+	if r.IsEmpty() {
+		return fmt.Errorf("membership rules need at least one criterion")
+	}
+	for _, tag := range r.Tags {
+		if !strings.Contains(tag, ":") {
+			return fmt.Errorf("invalid tag %q: use category:tag", tag)
+		}
+	}
+	if r.NameRegex != "" {
+		re, err := regexp.Compile(r.NameRegex)
+		if err != nil {
+			return fmt.Errorf("invalid name_regex: %w", err)
+		}
+		r.nameRegex = re
+	}
+	return nil
+}
+
+// Matches reports whether a VM satisfies the rules; call Validate first
+func (r *MembershipRules) Matches(vm VMMetadata) bool {
+	if len(r.Tags) > 0 && !anyEqualFold(r.Tags, vm.Tags) {
+		return false
+	}
+	if len(r.Categories) > 0 {
+		categories := make([]string, 0, len(vm.Tags))
+		for _, tag := range vm.Tags {
+			if category, _, found := strings.Cut(tag, ":"); found {
+				categories = append(categories, category)
+			}
+		}
+		if !anyEqualFold(r.Categories, categories) {
+			return false
+		}
+	}
+	if len(r.FolderPaths) > 0 && !folderMatches(r.FolderPaths, vm.FolderPath) {
+		return false
+	}
+	if len(r.ResourcePools) > 0 && !anyEqualFold(r.ResourcePools, []string{vm.ResourcePool}) {
+		return false
+	}
+	if len(r.Clusters) > 0 && !anyEqualFold(r.Clusters, []string{vm.Cluster}) {
+		return false
+	}
+	if len(r.Datastores) > 0 && !anyEqualFold(r.Datastores, vm.Datastores) {
+		return false
+	}
+	if r.nameRegex != nil && !r.nameRegex.MatchString(vm.Name) {
+		return false
+	}
+	if len(r.GuestOS) > 0 && !anyEqualFold(r.GuestOS, []string{vm.OSType}) {
+		return false
+	}
+	return true
+}
+
+// ParseMembershipRules decodes and validates the rules stored on a group (nil for static groups)
+func ParseMembershipRules(group *database.VMMachineGroup) (*MembershipRules, error) {
+	if group.MembershipRules == nil || *group.MembershipRules == "" {
+		return nil, nil
+	}
+
+	var rules MembershipRules
+	if err := json.Unmarshal([]byte(*group.MembershipRules), &rules); err != nil {
+		return nil, fmt.Errorf("invalid membership rules on group %s: %w", group.ID, err)
+	}
+	if err := rules.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid membership rules on group %s: %w", group.ID, err)
+	}
+	return &rules, nil
+}
+
+// VMMetadataFromContext returns the metadata discovery recorded on a VM context
+func VMMetadataFromContext(vmCtx *database.VMReplicationContext) VMMetadata {
+	vm := VMMetadata{
+		Name:         vmCtx.VMName,
+		FolderPath:   stringPtrToString(vmCtx.FolderPath),
+		ResourcePool: stringPtrToString(vmCtx.ResourcePool),
+		Cluster:      stringPtrToString(vmCtx.Cluster),
+		OSType:       stringPtrToString(vmCtx.OSType),
+	}
+	if vmCtx.VSphereTags != nil {
+		json.Unmarshal([]byte(*vmCtx.VSphereTags), &vm.Tags)
+	}
+	if vmCtx.Datastores != nil {
+		json.Unmarshal([]byte(*vmCtx.Datastores), &vm.Datastores)
+	}
+	return vm
+}
+
+// DynamicGroupMatcher returns a function reporting whether a VM matches the rules of
+// any dynamic group, for deciding which undiscovered VMs to add
+func (s *MachineGroupService) DynamicGroupMatcher() (func(VMMetadata) bool, error) {
+	groups, err := s.schedulerRepo.ListDynamicGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	var ruleSets []*MembershipRules
+	for i := range groups {
+		// A group with broken rules is reported by its evaluation and must not
+		// keep the other groups from adding VMs
+		rules, err := ParseMembershipRules(&groups[i])
+		if err == nil && rules != nil {
+			ruleSets = append(ruleSets, rules)
+		}
+	}
+
+	return func(vm VMMetadata) bool {
+		for _, rules := range ruleSets {
+			if rules.Matches(vm) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// EvaluateDynamicGroups re-evaluates the rules of every dynamic group against all VM contexts
+func (s *MachineGroupService) EvaluateDynamicGroups(ctx context.Context, reason string) ([]*MembershipEvaluationResult, error) {
+	groups, err := s.schedulerRepo.ListDynamicGroups()
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	owner := "machine-group-service"
+	ctx, jobID, err := s.jobTracker.StartJob(ctx, joblog.JobStart{
+		JobType:   "group-management",
+		Operation: "evaluate-dynamic-groups",
+		Owner:     &owner,
+		Metadata: map[string]interface{}{
+			"group_count": len(groups),
+			"reason":      reason,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start group evaluation job tracking: %w", err)
+	}
+
+	logger := s.jobTracker.Logger(ctx)
+	logger.Info("🔎 Evaluating dynamic machine groups", "group_count", len(groups), "reason", reason)
+
+	var results []*MembershipEvaluationResult
+	err = s.jobTracker.RunStep(ctx, jobID, "evaluate-rules", func(ctx context.Context) error {
+		contexts, err := s.schedulerRepo.ListVMContexts()
+		if err != nil {
+			return err
+		}
+
+		for i := range groups {
+			result, err := s.evaluateGroup(ctx, &groups[i], contexts, reason)
+			if err != nil {
+				// One broken rule set must not hold back the other groups
+				logger.Error("Failed to evaluate machine group rules", "group_id", groups[i].ID, "error", err)
+				continue
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+
+	if err != nil {
+		s.jobTracker.EndJob(ctx, jobID, joblog.StatusFailed, err)
+		return results, err
+	}
+
+	s.jobTracker.EndJob(ctx, jobID, joblog.StatusCompleted, nil)
+	return results, nil
+}
+
+// EvaluateGroupRules re-evaluates the rules of one dynamic group against all VM contexts
+func (s *MachineGroupService) EvaluateGroupRules(ctx context.Context, groupID string, reason string) (*MembershipEvaluationResult, error) {
+	owner := "machine-group-service"
+	ctx, jobID, err := s.jobTracker.StartJob(ctx, joblog.JobStart{
+		JobType:   "group-management",
+		Operation: "evaluate-group-rules",
+		Owner:     &owner,
+		Metadata: map[string]interface{}{
+			"group_id": groupID,
+			"reason":   reason,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start group evaluation job tracking: %w", err)
+	}
+
+	var result *MembershipEvaluationResult
+	err = s.jobTracker.RunStep(ctx, jobID, "evaluate-rules", func(ctx context.Context) error {
+		group, err := s.schedulerRepo.GetGroupByID(groupID)
+		if err != nil {
+			return err
+		}
+		if group.MembershipRules == nil {
+			return fmt.Errorf("group %s has no membership rules", groupID)
+		}
+
+		contexts, err := s.schedulerRepo.ListVMContexts()
+		if err != nil {
+			return err
+		}
+
+		result, err = s.evaluateGroup(ctx, group, contexts, reason)
+		return err
+	})
+
+	if err != nil {
+		s.jobTracker.EndJob(ctx, jobID, joblog.StatusFailed, err)
+		return nil, err
+	}
+
+	s.jobTracker.EndJob(ctx, jobID, joblog.StatusCompleted, nil)
+	return result, nil
+}
+
+// GetMembershipChanges returns the most recent membership changes made by a group's rules
+func (s *MachineGroupService) GetMembershipChanges(ctx context.Context, groupID string, limit int) ([]database.VMGroupMembershipChange, error) {
+	if _, err := s.schedulerRepo.GetGroupByID(groupID); err != nil {
+		return nil, err
+	}
+	return s.schedulerRepo.GetMembershipChanges(groupID, limit)
+}
+
+// evaluateGroup adds the matching VMs a group is missing and removes the rule-added
+// members that no longer match, recording each change
+func (s *MachineGroupService) evaluateGroup(ctx context.Context, group *database.VMMachineGroup,
+	contexts []database.VMReplicationContext, reason string) (*MembershipEvaluationResult, error) {
+
+	logger := s.jobTracker.Logger(ctx)
+
+	rules, err := ParseMembershipRules(group)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships, err := s.schedulerRepo.GetGroupMemberships(group.ID, false)
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[string]database.VMGroupMembership, len(memberships))
+	for _, membership := range memberships {
+		members[membership.VMContextID] = membership
+	}
+
+	result := &MembershipEvaluationResult{
+		GroupID:   group.ID,
+		GroupName: group.Name,
+		Added:     make([]string, 0),
+		Removed:   make([]string, 0),
+	}
+
+	for i := range contexts {
+		vmCtx := &contexts[i]
+		membership, isMember := members[vmCtx.ContextID]
+
+		if rules.Matches(VMMetadataFromContext(vmCtx)) {
+			result.Matched++
+			if isMember {
+				continue
+			}
+			err := s.schedulerRepo.CreateMembership(&database.VMGroupMembership{
+				GroupID:     group.ID,
+				VMContextID: vmCtx.ContextID,
+				Enabled:     true,
+				AddedBy:     RuleMembershipAddedBy,
+			})
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", vmCtx.VMName, err))
+				continue
+			}
+			result.Added = append(result.Added, vmCtx.ContextID)
+			s.recordMembershipChange(ctx, group.ID, vmCtx, "added", reason)
+			continue
+		}
+
+		// Only undo what the rules did
+		if !isMember || membership.AddedBy != RuleMembershipAddedBy {
+			continue
+		}
+		if err := s.schedulerRepo.DeleteMembership(group.ID, vmCtx.ContextID); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", vmCtx.VMName, err))
+			continue
+		}
+		result.Removed = append(result.Removed, vmCtx.ContextID)
+		s.recordMembershipChange(ctx, group.ID, vmCtx, "removed", reason)
+	}
+
+	if err := s.schedulerRepo.UpdateGroup(group.ID, map[string]interface{}{
+		"rules_evaluated_at": time.Now(),
+	}); err != nil {
+		logger.Warn("Failed to record group rule evaluation time", "group_id", group.ID, "error", err)
+	}
+
+	logger.Info("Evaluated machine group rules",
+		"group_id", group.ID,
+		"group_name", group.Name,
+		"matched", result.Matched,
+		"added", len(result.Added),
+		"removed", len(result.Removed),
+		"errors", len(result.Errors),
+	)
+
+	return result, nil
+}
+
+// recordMembershipChange records a rule membership change; the change itself already happened
+func (s *MachineGroupService) recordMembershipChange(ctx context.Context, groupID string,
+	vmCtx *database.VMReplicationContext, action, reason string) {
+
+	change := &database.VMGroupMembershipChange{
+		GroupID:     groupID,
+		VMContextID: vmCtx.ContextID,
+		VMName:      vmCtx.VMName,
+		Action:      action,
+		Reason:      reason,
+	}
+	if err := s.schedulerRepo.RecordMembershipChange(change); err != nil {
+		s.jobTracker.Logger(ctx).Warn("Failed to record membership change",
+			"group_id", groupID,
+			"vm_context_id", vmCtx.ContextID,
+			"error", err,
+		)
+	}
+}
+
+// anyEqualFold reports whether any wanted value is among the values, ignoring case
+func anyEqualFold(wanted, values []string) bool {
+	for _, w := range wanted {
+		for _, v := range values {
+			if v != "" && strings.EqualFold(w, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// folderMatches reports whether a folder path is one of the folders or below one of them
+func folderMatches(folders []string, folderPath string) bool {
+	path := strings.ToLower(strings.Trim(folderPath, "/"))
+	if path == "" {
+		return false
+	}
+	for _, folder := range folders {
+		prefix := strings.ToLower(strings.Trim(folder, "/"))
+		if prefix != "" && (path == prefix || strings.HasPrefix(path, prefix+"/")) {
+			return true
+		}
+	}
+	return false
+}
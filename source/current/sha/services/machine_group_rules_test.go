@@ -0,0 +1,142 @@
+package services
+
+import "testing"
+
+func TestMembershipRulesMatches(t *testing.T) {
+	vm := VMMetadata{
+		Name:         "pgsql-prod-01",
+		FolderPath:   "/DC1/vm/Production/Databases",
+		ResourcePool: "Gold",
+		Cluster:      "Cluster-A",
+		OSType:       "linux",
+		Tags:         []string{"Backup:Daily", "Env:Prod"},
+		Datastores:   []string{"ds-ssd-01", "ds-ssd-02"},
+	}
+
+	tests := []struct {
+		name  string
+		rules MembershipRules
+		want  bool
+	}{
+		{
+			name:  "tag attached",
+			rules: MembershipRules{Tags: []string{"backup:daily"}},
+			want:  true,
+		},
+		{
+			name:  "tag not attached",
+			rules: MembershipRules{Tags: []string{"Backup:Weekly"}},
+			want:  false,
+		},
+		{
+			name:  "any tag of category",
+			rules: MembershipRules{Categories: []string{"env"}},
+			want:  true,
+		},
+		{
+			name:  "category not attached",
+			rules: MembershipRules{Categories: []string{"Owner"}},
+			want:  false,
+		},
+		{
+			name:  "folder below rule folder",
+			rules: MembershipRules{FolderPaths: []string{"/DC1/vm/Production"}},
+			want:  true,
+		},
+		{
+			name:  "resource pool and cluster",
+			rules: MembershipRules{ResourcePools: []string{"gold"}, Clusters: []string{"cluster-a"}},
+			want:  true,
+		},
+		{
+			name:  "cluster mismatch fails all criteria",
+			rules: MembershipRules{ResourcePools: []string{"Gold"}, Clusters: []string{"Cluster-B"}},
+			want:  false,
+		},
+		{
+			name:  "any disk on datastore",
+			rules: MembershipRules{Datastores: []string{"DS-SSD-02", "ds-hdd-01"}},
+			want:  true,
+		},
+		{
+			name:  "name expression",
+			rules: MembershipRules{NameRegex: `^pgsql-prod-\d+$`},
+			want:  true,
+		},
+		{
+			name:  "name expression mismatch",
+			rules: MembershipRules{NameRegex: `^web-`},
+			want:  false,
+		},
+		{
+			name:  "guest os family",
+			rules: MembershipRules{GuestOS: []string{"windows", "linux"}},
+			want:  true,
+		},
+		{
+			name:  "guest os mismatch",
+			rules: MembershipRules{GuestOS: []string{"windows"}},
+			want:  false,
+		},
+		{
+			name: "every criterion satisfied",
+			rules: MembershipRules{
+				Tags:        []string{"Env:Prod"},
+				FolderPaths: []string{"/DC1/vm/Production"},
+				Datastores:  []string{"ds-ssd-01"},
+				NameRegex:   "pgsql",
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := tt.rules
+			if err := rules.Validate(); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if got := rules.Matches(vm); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMembershipRulesMatchesVMWithoutMetadata(t *testing.T) {
+	rules := MembershipRules{ResourcePools: []string{"Gold"}}
+	if err := rules.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	// A VM discovered without the metadata a rule needs is not matched
+	if rules.Matches(VMMetadata{Name: "new-vm"}) {
+		t.Error("Matches() = true for a VM without a resource pool")
+	}
+}
+
+func TestFolderMatches(t *testing.T) {
+	tests := []struct {
+		name       string
+		folders    []string
+		folderPath string
+		want       bool
+	}{
+		{"exact folder", []string{"/DC1/vm/Production"}, "/DC1/vm/Production", true},
+		{"subfolder", []string{"/DC1/vm/Production"}, "/DC1/vm/Production/Databases", true},
+		{"ignores case and slashes", []string{"dc1/VM/production/"}, "/DC1/vm/Production/Web", true},
+		{"sibling with shared prefix", []string{"/DC1/vm/Prod"}, "/DC1/vm/Production", false},
+		{"parent of rule folder", []string{"/DC1/vm/Production/Databases"}, "/DC1/vm/Production", false},
+		{"any of several folders", []string{"/DC1/vm/Test", "/DC1/vm/Production"}, "/DC1/vm/Production/Web", true},
+		{"empty folder path", []string{"/DC1/vm"}, "", false},
+		{"empty rule folder ignored", []string{"/"}, "/DC1/vm/Production", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := folderMatches(tt.folders, tt.folderPath); got != tt.want {
+				t.Errorf("folderMatches(%v, %q) = %v, want %v", tt.folders, tt.folderPath, got, tt.want)
+			}
+		})
+	}
+}
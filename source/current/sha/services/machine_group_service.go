@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -29,6 +30,9 @@ type GroupCreateRequest struct {
 	MaxConcurrentVMs int     `json:"max_concurrent_vms" validate:"min=1,max=100"`
 	Priority         int     `json:"priority" validate:"min=0"`
 	CreatedBy        string  `json:"created_by,omitempty"`
+
+	// MembershipRules makes the group dynamic: matching VMs join it automatically
+	MembershipRules *MembershipRules `json:"membership_rules,omitempty"`
 }
 
 // GroupUpdateRequest represents a request to update an existing machine group
@@ -38,6 +42,10 @@ type GroupUpdateRequest struct {
 	ScheduleID       *string `json:"schedule_id,omitempty"`
 	MaxConcurrentVMs *int    `json:"max_concurrent_vms,omitempty" validate:"omitempty,min=1,max=100"`
 	Priority         *int    `json:"priority,omitempty" validate:"omitempty,min=0"`
+
+	// MembershipRules replaces the group rules; an empty object makes the group static
+	// again (members the rules added stay until removed)
+	MembershipRules *MembershipRules `json:"membership_rules,omitempty"`
 }
 
 // VMMembershipRequest represents a request to manage VM group membership
@@ -122,6 +130,11 @@ func (s *MachineGroupService) CreateGroup(ctx context.Context, req *GroupCreateR
 			}
 		}
 
+		membershipRules, err := encodeMembershipRules(req.MembershipRules)
+		if err != nil {
+			return err
+		}
+
 		// Create the group
 		group = &database.VMMachineGroup{
 			Name:             req.Name,
@@ -130,6 +143,7 @@ func (s *MachineGroupService) CreateGroup(ctx context.Context, req *GroupCreateR
 			MaxConcurrentVMs: req.MaxConcurrentVMs,
 			Priority:         req.Priority,
 			CreatedBy:        req.CreatedBy,
+			MembershipRules:  membershipRules,
 		}
 
 		if group.CreatedBy == "" {
@@ -154,6 +168,10 @@ func (s *MachineGroupService) CreateGroup(ctx context.Context, req *GroupCreateR
 	}
 
 	s.jobTracker.EndJob(ctx, jobID, joblog.StatusCompleted, nil)
+
+	if group.MembershipRules != nil {
+		s.evaluateAfterRulesChange(ctx, group.ID)
+	}
 	return group, nil
 }
 
@@ -203,6 +221,13 @@ func (s *MachineGroupService) UpdateGroup(ctx context.Context, groupID string, r
 		if req.Priority != nil {
 			updates["priority"] = *req.Priority
 		}
+		if req.MembershipRules != nil {
+			membershipRules, err := encodeMembershipRules(req.MembershipRules)
+			if err != nil {
+				return err
+			}
+			updates["membership_rules"] = membershipRules
+		}
 		updates["updated_at"] = time.Now()
 
 		if err := s.schedulerRepo.UpdateGroup(groupID, updates); err != nil {
@@ -229,6 +254,10 @@ func (s *MachineGroupService) UpdateGroup(ctx context.Context, groupID string, r
 	}
 
 	s.jobTracker.EndJob(ctx, jobID, joblog.StatusCompleted, nil)
+
+	if req.MembershipRules != nil && updatedGroup.MembershipRules != nil {
+		s.evaluateAfterRulesChange(ctx, groupID)
+	}
 	return updatedGroup, nil
 }
 
@@ -658,3 +687,30 @@ func (s *MachineGroupService) ChangeGroupSchedule(ctx context.Context, groupID s
 	_, err := s.UpdateGroup(ctx, groupID, updateReq)
 	return err
 }
+
+// encodeMembershipRules validates rules for storage on a group (nil for a static group)
+func encodeMembershipRules(rules *MembershipRules) (*string, error) {
+	if rules == nil || rules.IsEmpty() {
+		return nil, nil
+	}
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode membership rules: %w", err)
+	}
+	encoded := string(data)
+	return &encoded, nil
+}
+
+// evaluateAfterRulesChange applies new group rules at once rather than at the next discovery
+func (s *MachineGroupService) evaluateAfterRulesChange(ctx context.Context, groupID string) {
+	if _, err := s.EvaluateGroupRules(ctx, groupID, RuleEvaluationRulesUpdated); err != nil {
+		s.jobTracker.Logger(ctx).Warn("Failed to evaluate new membership rules",
+			"group_id", groupID,
+			"error", err,
+		)
+	}
+}
@@ -22,6 +22,7 @@ type PhantomJobDetector struct {
 	repository     *database.ReplicationJobRepository
 	jobTracker     *joblog.Tracker
 	snaAPIEndpoint string
+	snaRouter      *SNARouter // Optional: reaches the SNA each job was routed to
 	httpClient     *http.Client
 }
 
@@ -87,6 +88,11 @@ func NewPhantomJobDetector(
 	}
 }
 
+// SetSNARouter sets the router used to reach the SNA a job runs on
+func (p *PhantomJobDetector) SetSNARouter(router *SNARouter) {
+	p.snaRouter = router
+}
+
 // DetectPhantomJob analyzes a single job using multi-factor detection
 func (p *PhantomJobDetector) DetectPhantomJob(ctx context.Context, job *database.ReplicationJob) (*PhantomDetectionResult, error) {
 	logger := p.jobTracker.Logger(ctx)
@@ -315,7 +321,7 @@ func (p *PhantomJobDetector) checkVMAJobStatus(ctx context.Context, jobID string
 	}
 
 	// Build SNA API URL for job status
-	url := fmt.Sprintf("%s/api/v1/jobs/%s/status", p.snaAPIEndpoint, jobID)
+	url := fmt.Sprintf("%s/api/v1/jobs/%s/status", p.snaRouter.EndpointForJob(ctx, jobID, p.snaAPIEndpoint), jobID)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
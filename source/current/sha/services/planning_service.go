@@ -0,0 +1,780 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+	"github.com/vexxhost/migratekit-sha/joblog"
+	"github.com/vexxhost/migratekit-sha/storage"
+)
+
+// =============================================================================
+// PLANNING SERVICE - What-if forecasts for schedules and protection flows
+// =============================================================================
+// Before a schedule or flow is enabled on a large group, the planner answers: which
+// VMs would run, how much data they would move, how long that takes, whether the
+// backup repository has room and which runs fall outside their backup window.
+//
+// Estimates come from history: incremental volume from the last CBT deltas
+// (cbt_history, then completed incremental backups), full volume from disk sizes,
+// duration from the transfer speed of recent jobs. Repository growth assumes every
+// copied byte is kept - retention pruning is not modelled. Nothing is started or
+// changed; conflicts reflect the jobs running right now.
+
+const (
+	// DefaultPlanRuns is how many upcoming runs a plan covers when none is requested
+	DefaultPlanRuns = 5
+	// MaxPlanRuns bounds the timeline of a plan
+	MaxPlanRuns = 100
+
+	// planHistorySample is how many recent jobs the estimates are averaged over
+	planHistorySample = 5
+	// planDefaultChangePercent of the disk size is assumed to change between runs of a
+	// VM without incremental history
+	planDefaultChangePercent = 10
+	// planChainDepth bounds how far chained schedules are followed to their cron parent
+	planChainDepth = 10
+)
+
+// Sources of a VM's per-run data estimate
+const (
+	PlanEstimateCBTHistory    = "cbt_history"
+	PlanEstimateBackupHistory = "backup_history"
+	PlanEstimateDefaultRate   = "default_change_rate"
+)
+
+// PlannedVM is a VM a schedule or flow would run a job for
+type PlannedVM struct {
+	VMContextID      string          `json:"vm_context_id"`
+	VMName           string          `json:"vm_name"`
+	JobType          string          `json:"job_type"` // "backup" or "replication"
+	GroupID          string          `json:"group_id,omitempty"`
+	FlowID           string          `json:"flow_id,omitempty"`
+	RepositoryID     string          `json:"repository_id,omitempty"`
+	WouldRun         bool            `json:"would_run"`
+	SkipReason       string          `json:"skip_reason,omitempty"`
+	Conflict         *ConflictResult `json:"conflict,omitempty"`
+	FirstRunType     string          `json:"first_run_type"` // "full" or "incremental"
+	ProvisionedBytes int64           `json:"provisioned_bytes"`
+	IncrementalBytes int64           `json:"incremental_bytes"` // Expected bytes of each incremental run
+	EstimateSource   string          `json:"estimate_source"`
+	EstimateSamples  int             `json:"estimate_samples"`
+	TransferSpeedBps int64           `json:"transfer_speed_bps"` // 0 when no job has ever reported a speed
+}
+
+// PlannedRun is one upcoming run of a schedule or flow
+type PlannedRun struct {
+	Sequence                 int              `json:"sequence"`
+	ScheduledAt              time.Time        `json:"scheduled_at"`
+	StartAt                  *time.Time       `json:"start_at,omitempty"` // Nil when the run is skipped
+	Skipped                  bool             `json:"skipped"`
+	Deferred                 bool             `json:"deferred"`
+	WindowReason             string           `json:"window_reason,omitempty"`
+	VMs                      int              `json:"vms"`
+	FullJobs                 int              `json:"full_jobs"`
+	IncrementalJobs          int              `json:"incremental_jobs"`
+	EstimatedBytes           int64            `json:"estimated_bytes"`
+	EstimatedDurationSeconds int64            `json:"estimated_duration_seconds"`
+	EstimatedEndAt           *time.Time       `json:"estimated_end_at,omitempty"` // Nil when no transfer speed is known
+	OverrunsWindow           bool             `json:"overruns_window"`
+	OverrunAt                *time.Time       `json:"overrun_at,omitempty"`
+	OverrunAction            string           `json:"overrun_action,omitempty"`
+	OverlapsPreviousRun      bool             `json:"overlaps_previous_run"`
+	RepositoryGrowthBytes    map[string]int64 `json:"repository_growth_bytes,omitempty"` // By repository ID
+}
+
+// RepositoryForecast is the predicted capacity of a backup repository after the planned runs
+type RepositoryForecast struct {
+	RepositoryID            string     `json:"repository_id"`
+	TotalBytes              int64      `json:"total_bytes"`
+	UsedBytes               int64      `json:"used_bytes"`
+	AvailableBytes          int64      `json:"available_bytes"`
+	LastCheckAt             *time.Time `json:"last_check_at,omitempty"`
+	ProjectedGrowthBytes    int64      `json:"projected_growth_bytes"`
+	ProjectedAvailableBytes int64      `json:"projected_available_bytes"`
+	Fits                    bool       `json:"fits"`
+	FullAtRun               int        `json:"full_at_run,omitempty"` // First run that no longer fits
+}
+
+// ExecutionPlan is the what-if forecast of a schedule or protection flow
+type ExecutionPlan struct {
+	Scope               string               `json:"scope"` // "schedule" or "flow"
+	ID                  string               `json:"id"`
+	Name                string               `json:"name"`
+	Enabled             bool                 `json:"enabled"`
+	ScheduleID          string               `json:"schedule_id,omitempty"`
+	CronExpression      string               `json:"cron_expression,omitempty"`
+	Timezone            string               `json:"timezone,omitempty"`
+	GeneratedAt         time.Time            `json:"generated_at"`
+	Parallelism         int                  `json:"parallelism"`
+	VMs                 []PlannedVM          `json:"vms"`
+	VMsToRun            int                  `json:"vms_to_run"`
+	Runs                []PlannedRun         `json:"runs"`
+	TotalEstimatedBytes int64                `json:"total_estimated_bytes"`
+	Repositories        []RepositoryForecast `json:"repositories"`
+	Conflicts           []ConflictResult     `json:"conflicts"`
+	Warnings            []string             `json:"warnings"`
+}
+
+// PlanningService builds what-if forecasts for schedules and protection flows
+type PlanningService struct {
+	planRepo      *database.PlanningRepository
+	flowRepo      *database.FlowRepository
+	schedulerRepo *database.SchedulerRepository
+	detector      *JobConflictDetector
+	windowService *BackupWindowService
+	admission     *AdmissionController
+	repositories  *storage.RepositoryManager
+}
+
+// NewPlanningService creates a new planning service
+func NewPlanningService(db database.Connection, jobTracker *joblog.Tracker, windowService *BackupWindowService) *PlanningService {
+	schedulerRepo := database.NewSchedulerRepository(db)
+	return &PlanningService{
+		planRepo:      database.NewPlanningRepository(db),
+		flowRepo:      database.NewFlowRepository(db),
+		schedulerRepo: schedulerRepo,
+		// Windows are evaluated per planned run, not at the time of the request
+		detector:      NewJobConflictDetector(database.NewReplicationJobRepository(db), schedulerRepo, jobTracker),
+		windowService: windowService,
+	}
+}
+
+// SetAdmissionController bounds the planned parallelism of flows by the SNA job limit
+func (s *PlanningService) SetAdmissionController(admission *AdmissionController) {
+	s.admission = admission
+}
+
+// SetRepositoryManager enables live repository capacity checks (otherwise the last recorded capacity is used)
+func (s *PlanningService) SetRepositoryManager(repositories *storage.RepositoryManager) {
+	s.repositories = repositories
+}
+
+// plannedWorkload is what one schedule or flow run consists of
+type plannedWorkload struct {
+	owners      []WindowOwner
+	parallelism int
+}
+
+// PlanFlow forecasts the next runs of a protection flow
+func (s *PlanningService) PlanFlow(ctx context.Context, flowID string, runs int) (*ExecutionPlan, error) {
+	flow, err := s.flowRepo.GetFlowByID(ctx, flowID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := newExecutionPlan("flow", flow.ID, flow.Name, flow.Enabled)
+	owners := []WindowOwner{{Scope: database.WindowScopeFlow, ID: flow.ID}}
+
+	var schedule *database.ReplicationSchedule
+	if flow.ScheduleID != nil && *flow.ScheduleID != "" {
+		schedule, err = s.schedulerRepo.GetScheduleByID(*flow.ScheduleID)
+		if err != nil {
+			return nil, err
+		}
+		owners = append(owners, WindowOwner{Scope: database.WindowScopeSchedule, ID: schedule.ID})
+	}
+
+	vms, err := s.planFlowVMs(ctx, plan, flow, schedule)
+	if err != nil {
+		return nil, err
+	}
+	plan.VMs = append(plan.VMs, vms...)
+
+	workload := plannedWorkload{owners: owners, parallelism: s.flowParallelism(len(vms))}
+	times := s.upcomingRuns(plan, flow, schedule, runs)
+	if err := s.buildTimeline(ctx, plan, workload, times); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// PlanSchedule forecasts the next runs of a schedule: replications of its groups' VMs
+// and backups of the flows it triggers
+func (s *PlanningService) PlanSchedule(ctx context.Context, scheduleID string, runs int) (*ExecutionPlan, error) {
+	schedule, err := s.schedulerRepo.GetScheduleByID(scheduleID, "Groups")
+	if err != nil {
+		return nil, err
+	}
+
+	plan := newExecutionPlan("schedule", schedule.ID, schedule.Name, schedule.Enabled)
+
+	vms, err := s.planScheduleVMs(ctx, plan, schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	flows, err := s.planRepo.GetScheduleFlows(ctx, schedule.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, flow := range flows {
+		flowVMs, err := s.planFlowVMs(ctx, plan, flow, schedule)
+		if err != nil {
+			return nil, err
+		}
+		vms = append(vms, flowVMs...)
+	}
+	plan.VMs = append(plan.VMs, vms...)
+
+	// Groups dispatch up to max_concurrent_jobs replications; flow backups are paced by admission control
+	parallelism := schedule.MaxConcurrentJobs
+	if len(flows) > 0 {
+		parallelism += s.flowParallelism(len(vms))
+	}
+	workload := plannedWorkload{
+		owners:      []WindowOwner{{Scope: database.WindowScopeSchedule, ID: schedule.ID}},
+		parallelism: parallelism,
+	}
+	times := s.upcomingRuns(plan, nil, schedule, runs)
+	if err := s.buildTimeline(ctx, plan, workload, times); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// newExecutionPlan creates an empty plan
+func newExecutionPlan(scope, id, name string, enabled bool) *ExecutionPlan {
+	return &ExecutionPlan{
+		Scope:        scope,
+		ID:           id,
+		Name:         name,
+		Enabled:      enabled,
+		GeneratedAt:  time.Now(),
+		VMs:          []PlannedVM{},
+		Runs:         []PlannedRun{},
+		Repositories: []RepositoryForecast{},
+		Conflicts:    []ConflictResult{},
+		Warnings:     []string{},
+	}
+}
+
+// flowParallelism returns how many backups of a flow run at once
+func (s *PlanningService) flowParallelism(vmCount int) int {
+	parallelism := vmCount
+	if s.admission != nil {
+		if limit := s.admission.GetLimits().MaxJobsPerSNA; limit > 0 && limit < parallelism {
+			parallelism = limit
+		}
+	}
+	return parallelism
+}
+
+// =============================================================================
+// VM SELECTION AND ESTIMATES
+// =============================================================================
+
+// planScheduleVMs selects the group members a schedule would replicate, as executeGroup does
+func (s *PlanningService) planScheduleVMs(ctx context.Context, plan *ExecutionPlan, schedule *database.ReplicationSchedule) ([]PlannedVM, error) {
+	targets, err := s.planRepo.GetScheduleTargetVMs(ctx, schedule.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	byGroup := make(map[string][]database.PlanTargetVM)
+	for _, target := range targets {
+		byGroup[target.GroupID] = append(byGroup[target.GroupID], target)
+	}
+
+	// Plan the schedule as if it were enabled - that is the question being asked
+	constraints := &ScheduleConstraints{
+		ScheduleID:        schedule.ID,
+		SkipIfRunning:     schedule.SkipIfRunning,
+		MaxConcurrentJobs: schedule.MaxConcurrentJobs,
+		Enabled:           true,
+	}
+
+	var vms []PlannedVM
+	for _, group := range schedule.Groups {
+		members := byGroup[group.ID]
+		if len(members) == 0 {
+			continue
+		}
+
+		results := s.checkConflicts(ctx, plan, members, constraints, &GroupConstraints{
+			GroupID:          group.ID,
+			MaxConcurrentVMs: group.MaxConcurrentVMs,
+			Priority:         group.Priority,
+		})
+		for i, target := range members {
+			vm := PlannedVM{
+				VMContextID:  target.ContextID,
+				VMName:       target.VMName,
+				JobType:      "replication",
+				GroupID:      group.ID,
+				FirstRunType: "incremental",
+			}
+			if target.LastSuccessfulJobID == nil || schedule.ReplicationType == "full" {
+				vm.FirstRunType = "full"
+			}
+			applyConflict(&vm, results, i)
+
+			if err := s.estimateVM(ctx, plan, &vm); err != nil {
+				return nil, err
+			}
+			vms = append(vms, vm)
+		}
+	}
+
+	if schedule.ReplicationType == "full" {
+		plan.Warnings = append(plan.Warnings, "Schedule forces full replications - every run copies whole disks")
+	}
+	return vms, nil
+}
+
+// planFlowVMs selects the VMs a backup flow would back up
+func (s *PlanningService) planFlowVMs(ctx context.Context, plan *ExecutionPlan, flow *database.ProtectionFlow, schedule *database.ReplicationSchedule) ([]PlannedVM, error) {
+	if flow.FlowType != "backup" {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("Flow %s is a %s flow - only backup flows are planned", flow.Name, flow.FlowType))
+		return nil, nil
+	}
+	if flow.RepositoryID == nil || *flow.RepositoryID == "" {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("Flow %s has no repository - it cannot run", flow.Name))
+		return nil, nil
+	}
+
+	targets, err := s.planRepo.GetFlowTargetVMs(ctx, flow)
+	if err != nil {
+		return nil, err
+	}
+
+	// Flow backups are not limited per schedule or group - only running jobs and VM state conflict
+	constraints := &ScheduleConstraints{
+		ScheduleID:        flow.ID,
+		SkipIfRunning:     true,
+		MaxConcurrentJobs: len(targets),
+		Enabled:           true,
+	}
+	if schedule != nil {
+		constraints.ScheduleID = schedule.ID
+		constraints.SkipIfRunning = schedule.SkipIfRunning
+	}
+	results := s.checkConflicts(ctx, plan, targets, constraints, &GroupConstraints{
+		GroupID:          flow.TargetID,
+		MaxConcurrentVMs: len(targets),
+	})
+
+	vms := make([]PlannedVM, 0, len(targets))
+	for i, target := range targets {
+		vm := PlannedVM{
+			VMContextID:  target.ContextID,
+			VMName:       target.VMName,
+			JobType:      "backup",
+			GroupID:      target.GroupID,
+			FlowID:       flow.ID,
+			RepositoryID: *flow.RepositoryID,
+			FirstRunType: "incremental",
+		}
+		hasFull, err := s.planRepo.HasCompletedFullBackup(ctx, target.ContextID, *flow.RepositoryID)
+		if err != nil {
+			return nil, err
+		}
+		if !hasFull {
+			vm.FirstRunType = "full"
+		}
+		applyConflict(&vm, results, i)
+
+		if err := s.estimateVM(ctx, plan, &vm); err != nil {
+			return nil, err
+		}
+		vms = append(vms, vm)
+	}
+	return vms, nil
+}
+
+// checkConflicts runs the conflict detector over the planned VMs (nil results when it fails)
+func (s *PlanningService) checkConflicts(ctx context.Context, plan *ExecutionPlan, targets []database.PlanTargetVM, constraints *ScheduleConstraints, group *GroupConstraints) []ConflictResult {
+	vmContexts := make([]*database.VMReplicationContext, 0, len(targets))
+	for _, target := range targets {
+		vmContexts = append(vmContexts, &database.VMReplicationContext{
+			ContextID:        target.ContextID,
+			VMName:           target.VMName,
+			SchedulerEnabled: target.SchedulerEnabled,
+			CurrentStatus:    target.CurrentStatus,
+		})
+	}
+
+	summary, err := s.detector.CheckVMConflicts(ctx, vmContexts, constraints, group)
+	if err != nil {
+		log.WithError(err).Warn("Failed to check planned VMs for conflicts")
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("Conflict detection failed: %v", err))
+		return nil
+	}
+	for _, result := range summary.Results {
+		if result.HasConflict {
+			plan.Conflicts = append(plan.Conflicts, result)
+		}
+	}
+	return summary.Results
+}
+
+// applyConflict marks whether a VM would run according to its conflict result
+func applyConflict(vm *PlannedVM, results []ConflictResult, i int) {
+	vm.WouldRun = true
+	if i >= len(results) || !results[i].HasConflict {
+		return
+	}
+	result := results[i]
+	vm.Conflict = &result
+	vm.WouldRun = false
+	vm.SkipReason = result.ConflictReason
+}
+
+// estimateVM fills in the data volume and transfer speed expected for a VM
+func (s *PlanningService) estimateVM(ctx context.Context, plan *ExecutionPlan, vm *PlannedVM) error {
+	provisioned, err := s.planRepo.GetProvisionedBytes(ctx, vm.VMContextID)
+	if err != nil {
+		return err
+	}
+	vm.ProvisionedBytes = provisioned
+	if provisioned == 0 {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("No disk sizes known for VM %s - run discovery to include it in estimates", vm.VMName))
+	}
+
+	delta, source, samples, err := estimateIncrementalBytes(ctx, s.planRepo, vm.VMContextID, provisioned)
+	if err != nil {
+		return err
+	}
+	vm.IncrementalBytes = delta
+	vm.EstimateSource = source
+	vm.EstimateSamples = samples
+
+	speed, err := s.planRepo.GetAverageTransferSpeed(ctx, vm.VMContextID, planHistorySample)
+	if err != nil {
+		return err
+	}
+	vm.TransferSpeedBps = speed
+	return nil
+}
+
+// estimateIncrementalBytes predicts the bytes of a VM's next incremental run from its
+// recent history, falling back to planDefaultChangePercent of its disks
+func estimateIncrementalBytes(ctx context.Context, planRepo *database.PlanningRepository, vmContextID string, provisioned int64) (int64, string, int, error) {
+	delta, samples, err := planRepo.GetAverageCBTDelta(ctx, vmContextID, planHistorySample)
+	if err != nil {
+		return 0, "", 0, err
+	}
+	if samples > 0 {
+		return delta, PlanEstimateCBTHistory, samples, nil
+	}
+
+	delta, samples, err = planRepo.GetAverageBackupDelta(ctx, vmContextID, planHistorySample)
+	if err != nil {
+		return 0, "", 0, err
+	}
+	if samples > 0 {
+		return delta, PlanEstimateBackupHistory, samples, nil
+	}
+
+	return provisioned * planDefaultChangePercent / 100, PlanEstimateDefaultRate, 0, nil
+}
+
+// =============================================================================
+// TIMELINE
+// =============================================================================
+
+// upcomingRuns returns when the next runs of a flow or schedule are triggered
+func (s *PlanningService) upcomingRuns(plan *ExecutionPlan, flow *database.ProtectionFlow, schedule *database.ReplicationSchedule, runs int) []time.Time {
+	if runs <= 0 {
+		runs = DefaultPlanRuns
+	}
+	if runs > MaxPlanRuns {
+		runs = MaxPlanRuns
+	}
+
+	if schedule == nil {
+		if flow != nil && flow.RPOMinutes != nil && *flow.RPOMinutes > 0 {
+			plan.Warnings = append(plan.Warnings, "Flow is RPO-driven - runs start as restore points age, see the RPO compliance report")
+		} else {
+			plan.Warnings = append(plan.Warnings, "Flow has no schedule - it only runs when executed manually")
+		}
+		// A single run starting now still shows the workload
+		return []time.Time{plan.GeneratedAt}
+	}
+
+	plan.ScheduleID = schedule.ID
+	plan.CronExpression = schedule.CronExpression
+	plan.Timezone = schedule.Timezone
+
+	times, err := s.scheduleRunTimes(schedule, plan.GeneratedAt, runs, 0)
+	if err != nil {
+		plan.Warnings = append(plan.Warnings, err.Error())
+		return nil
+	}
+	if schedule.ScheduleType == "chain" {
+		plan.Warnings = append(plan.Warnings, "Chain schedule - runs are shown at the parent's start plus the chain delay; they actually follow the parent's completion")
+	}
+	return times
+}
+
+// scheduleRunTimes returns the next trigger times of a schedule, following chains to their cron parent
+func (s *PlanningService) scheduleRunTimes(schedule *database.ReplicationSchedule, from time.Time, runs, depth int) ([]time.Time, error) {
+	if schedule.ScheduleType == "chain" {
+		if schedule.ChainParentScheduleID == nil || depth >= planChainDepth {
+			return nil, fmt.Errorf("chain schedule %s has no cron schedule at its root", schedule.Name)
+		}
+		parent, err := s.schedulerRepo.GetScheduleByID(*schedule.ChainParentScheduleID)
+		if err != nil {
+			return nil, err
+		}
+		times, err := s.scheduleRunTimes(parent, from, runs, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		delay := time.Duration(schedule.ChainDelayMinutes) * time.Minute
+		for i := range times {
+			times[i] = times[i].Add(delay)
+		}
+		return times, nil
+	}
+
+	spec, err := scheduleCronSpec(schedule)
+	if err != nil {
+		return nil, err
+	}
+	// Same fields as the scheduler's cron (cron.WithSeconds)
+	parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+	cronSchedule, err := parser.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q for schedule %s: %w", schedule.CronExpression, schedule.Name, err)
+	}
+
+	times := make([]time.Time, 0, runs)
+	next := from
+	for len(times) < runs {
+		next = cronSchedule.Next(next)
+		if next.IsZero() {
+			break
+		}
+		times = append(times, next)
+	}
+	return times, nil
+}
+
+// buildTimeline estimates each run and the repository growth they cause
+func (s *PlanningService) buildTimeline(ctx context.Context, plan *ExecutionPlan, workload plannedWorkload, times []time.Time) error {
+	var rules backupWindowRules
+	if s.windowService != nil {
+		var err error
+		rules, err = s.windowService.applicableRules(ctx, workload.owners...)
+		if err != nil {
+			return fmt.Errorf("failed to load backup windows: %w", err)
+		}
+	}
+
+	plan.Parallelism = workload.parallelism
+	if plan.Parallelism < 1 {
+		plan.Parallelism = 1
+	}
+	for _, vm := range plan.VMs {
+		if vm.WouldRun {
+			plan.VMsToRun++
+		}
+	}
+	fallbackSpeed := s.fallbackSpeed(ctx, plan)
+
+	// A VM's first run may be full; after a completed run it is incremental
+	fullDone := make(map[string]bool)
+	growth := make(map[string]int64)
+	var previousEnd *time.Time
+
+	for i, at := range times {
+		run := PlannedRun{Sequence: i + 1, ScheduledAt: at}
+		start := at
+
+		if rules != nil {
+			decision := rules.evaluate(at)
+			if !decision.Allowed {
+				run.WindowReason = decision.Reason
+				if decision.OutsideAction == database.WindowOutsideSkip || decision.NextOpenAt == nil {
+					run.Skipped = true
+					plan.Runs = append(plan.Runs, run)
+					continue
+				}
+				run.Deferred = true
+				start = *decision.NextOpenAt
+			}
+		}
+		run.StartAt = &start
+
+		var longest, totalSeconds float64
+		for _, vm := range plan.VMs {
+			if !vm.WouldRun {
+				continue
+			}
+			bytes := vm.IncrementalBytes
+			if vm.FirstRunType == "full" && !fullDone[vm.VMContextID+vm.FlowID] {
+				bytes = vm.ProvisionedBytes
+				run.FullJobs++
+				fullDone[vm.VMContextID+vm.FlowID] = true
+			} else {
+				run.IncrementalJobs++
+			}
+			run.VMs++
+			run.EstimatedBytes += bytes
+			if vm.RepositoryID != "" {
+				if run.RepositoryGrowthBytes == nil {
+					run.RepositoryGrowthBytes = make(map[string]int64)
+				}
+				run.RepositoryGrowthBytes[vm.RepositoryID] += bytes
+				growth[vm.RepositoryID] += bytes
+			}
+
+			speed := vm.TransferSpeedBps
+			if speed == 0 {
+				speed = fallbackSpeed
+			}
+			if speed > 0 {
+				seconds := float64(bytes) / float64(speed)
+				totalSeconds += seconds
+				if seconds > longest {
+					longest = seconds
+				}
+			}
+		}
+
+		// Jobs run plan.Parallelism at a time; a run lasts at least as long as its biggest job
+		duration := totalSeconds / float64(plan.Parallelism)
+		if longest > duration {
+			duration = longest
+		}
+		run.EstimatedDurationSeconds = int64(duration)
+		if duration > 0 {
+			end := start.Add(time.Duration(duration) * time.Second)
+			run.EstimatedEndAt = &end
+			if rules != nil {
+				checkRunOverrun(&run, rules, start, end)
+			}
+		}
+
+		if previousEnd != nil && start.Before(*previousEnd) {
+			run.OverlapsPreviousRun = true
+		}
+		if run.EstimatedEndAt != nil {
+			previousEnd = run.EstimatedEndAt
+		}
+
+		plan.TotalEstimatedBytes += run.EstimatedBytes
+		plan.Runs = append(plan.Runs, run)
+	}
+
+	if overlaps := countOverlaps(plan.Runs); overlaps > 0 {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("%d run(s) would start before the previous run is estimated to finish", overlaps))
+	}
+
+	return s.forecastRepositories(ctx, plan, growth)
+}
+
+// checkRunOverrun records when a run would still be going as its backup window closes;
+// only the rules' transitions between start and end can end the window
+func checkRunOverrun(run *PlannedRun, rules backupWindowRules, start, end time.Time) {
+	for t := rules.nextTransition(start); !t.IsZero() && t.Before(end); t = rules.nextTransition(t) {
+		if decision := rules.check(t); !decision.Allowed {
+			overrunAt := t
+			run.OverrunsWindow = true
+			run.OverrunAt = &overrunAt
+			run.OverrunAction = decision.OverrunAction
+			return
+		}
+	}
+}
+
+// countOverlaps counts runs estimated to start while the previous run is still going
+func countOverlaps(runs []PlannedRun) int {
+	overlaps := 0
+	for _, run := range runs {
+		if run.OverlapsPreviousRun {
+			overlaps++
+		}
+	}
+	return overlaps
+}
+
+// fallbackSpeed returns the SHA-wide transfer speed assumed for VMs without their own history
+func (s *PlanningService) fallbackSpeed(ctx context.Context, plan *ExecutionPlan) int64 {
+	for _, vm := range plan.VMs {
+		if !vm.WouldRun || vm.TransferSpeedBps > 0 {
+			continue
+		}
+
+		speed, err := s.planRepo.GetAverageTransferSpeed(ctx, "", planHistorySample*10)
+		if err != nil {
+			log.WithError(err).Warn("Failed to get the average transfer speed")
+		}
+		if speed == 0 {
+			plan.Warnings = append(plan.Warnings, "No job has reported a transfer speed yet - durations cannot be estimated")
+		}
+		return speed
+	}
+	return 0
+}
+
+// forecastRepositories compares the planned growth of each repository with its free space
+func (s *PlanningService) forecastRepositories(ctx context.Context, plan *ExecutionPlan, growth map[string]int64) error {
+	for repositoryID := range growth {
+		forecast := RepositoryForecast{RepositoryID: repositoryID}
+		if err := s.repositoryCapacity(ctx, &forecast); err != nil {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("Capacity of repository %s unknown: %v", repositoryID, err))
+			continue
+		}
+
+		// Find the first run that no longer fits
+		var cumulative int64
+		for _, run := range plan.Runs {
+			cumulative += run.RepositoryGrowthBytes[repositoryID]
+			if forecast.FullAtRun == 0 && cumulative > forecast.AvailableBytes {
+				forecast.FullAtRun = run.Sequence
+			}
+		}
+		forecast.ProjectedGrowthBytes = growth[repositoryID]
+		forecast.ProjectedAvailableBytes = forecast.AvailableBytes - forecast.ProjectedGrowthBytes
+		forecast.Fits = forecast.FullAtRun == 0
+		if !forecast.Fits {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("Repository %s runs out of space during run %d", repositoryID, forecast.FullAtRun))
+		}
+
+		plan.Repositories = append(plan.Repositories, forecast)
+	}
+	return nil
+}
+
+// repositoryCapacity fills in the current capacity of a repository - live when the
+// repository manager is available, else as last recorded
+func (s *PlanningService) repositoryCapacity(ctx context.Context, forecast *RepositoryForecast) error {
+	if s.repositories != nil {
+		info, err := s.liveStorageInfo(ctx, forecast.RepositoryID)
+		if err == nil {
+			forecast.TotalBytes = info.TotalBytes
+			forecast.UsedBytes = info.UsedBytes
+			forecast.AvailableBytes = info.AvailableBytes
+			checkedAt := info.LastCheckAt
+			forecast.LastCheckAt = &checkedAt
+			return nil
+		}
+		log.WithError(err).WithField("repository_id", forecast.RepositoryID).Warn("Live repository capacity unavailable - using last recorded capacity")
+	}
+
+	total, used, available, checkedAt, err := s.planRepo.GetRepositoryUsage(ctx, forecast.RepositoryID)
+	if err != nil {
+		return err
+	}
+	forecast.TotalBytes = total
+	forecast.UsedBytes = used
+	forecast.AvailableBytes = available
+	forecast.LastCheckAt = checkedAt
+	return nil
+}
+
+// liveStorageInfo asks a repository for its current capacity
+func (s *PlanningService) liveStorageInfo(ctx context.Context, repositoryID string) (*storage.StorageInfo, error) {
+	repo, err := s.repositories.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetStorageInfo(ctx)
+}
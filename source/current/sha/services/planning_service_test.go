@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/vexxhost/migratekit-sha/database"
+)
+
+// mockConnection serves a gorm DB backed by sqlmock
+type mockConnection struct {
+	db *gorm.DB
+}
+
+func (c *mockConnection) Close() error        { return nil }
+func (c *mockConnection) Ping() error         { return nil }
+func (c *mockConnection) GetStatus() string   { return "connected" }
+func (c *mockConnection) GetGormDB() *gorm.DB { return c.db }
+
+func newMockConnection(t *testing.T) (*mockConnection, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}),
+		&gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open gorm on sqlmock: %v", err)
+	}
+	return &mockConnection{db: db}, mock
+}
+
+func TestEstimateIncrementalBytes(t *testing.T) {
+	const provisioned = int64(100 << 30)
+
+	tests := []struct {
+		name        string
+		cbtRows     *sqlmock.Rows
+		backupRows  *sqlmock.Rows
+		wantBytes   int64
+		wantSource  string
+		wantSamples int
+	}{
+		{
+			name: "cbt history",
+			cbtRows: sqlmock.NewRows([]string{"job_id", "bytes", "synced_at"}).
+				AddRow("job-2", 3<<30, time.Now()).
+				AddRow("job-1", 1<<30, time.Now().Add(-time.Hour)),
+			wantBytes:   2 << 30,
+			wantSource:  PlanEstimateCBTHistory,
+			wantSamples: 2,
+		},
+		{
+			name:    "backup history without cbt history",
+			cbtRows: sqlmock.NewRows([]string{"job_id", "bytes", "synced_at"}),
+			backupRows: sqlmock.NewRows([]string{"bytes_transferred"}).
+				AddRow(4 << 30).AddRow(2 << 30).AddRow(3 << 30),
+			wantBytes:   3 << 30,
+			wantSource:  PlanEstimateBackupHistory,
+			wantSamples: 3,
+		},
+		{
+			name:        "default change rate without history",
+			cbtRows:     sqlmock.NewRows([]string{"job_id", "bytes", "synced_at"}),
+			backupRows:  sqlmock.NewRows([]string{"bytes_transferred"}),
+			wantBytes:   provisioned * planDefaultChangePercent / 100,
+			wantSource:  PlanEstimateDefaultRate,
+			wantSamples: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock := newMockConnection(t)
+			mock.ExpectQuery("FROM `cbt_history`").WillReturnRows(tt.cbtRows)
+			if tt.backupRows != nil {
+				mock.ExpectQuery("FROM `backup_jobs`").WillReturnRows(tt.backupRows)
+			}
+
+			bytes, source, samples, err := estimateIncrementalBytes(context.Background(),
+				database.NewPlanningRepository(conn), "ctx-vm-1", provisioned)
+			if err != nil {
+				t.Fatalf("estimateIncrementalBytes() error = %v", err)
+			}
+			if bytes != tt.wantBytes || source != tt.wantSource || samples != tt.wantSamples {
+				t.Errorf("estimateIncrementalBytes() = (%d, %s, %d), want (%d, %s, %d)",
+					bytes, source, samples, tt.wantBytes, tt.wantSource, tt.wantSamples)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckRunOverrun(t *testing.T) {
+	rules := compileBackupWindows([]*database.BackupWindow{{
+		ID:            "nightly",
+		Name:          "nightly",
+		Scope:         database.WindowScopeGlobal,
+		RuleType:      database.WindowRuleWindow,
+		StartTime:     stringPtr("22:00"),
+		EndTime:       stringPtr("06:00"),
+		Timezone:      "UTC",
+		OutsideAction: database.WindowOutsideDefer,
+		OverrunAction: database.WindowOverrunCancel,
+	}})
+	day := func(hour, minute int) time.Time {
+		return time.Date(2025, 10, 15, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name        string
+		start, end  time.Time
+		wantOverrun *time.Time
+	}{
+		{"finishes inside window", day(22, 0), day(23, 30), nil},
+		{"finishes as window closes", day(22, 0), day(30, 0), nil},
+		{"runs past window close", day(23, 0), day(31, 15), timePtr(day(30, 0))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var run PlannedRun
+			checkRunOverrun(&run, rules, tt.start, tt.end)
+
+			if tt.wantOverrun == nil {
+				if run.OverrunsWindow {
+					t.Errorf("OverrunsWindow = true at %s, want no overrun", run.OverrunAt)
+				}
+				return
+			}
+			if !run.OverrunsWindow || run.OverrunAt == nil || !run.OverrunAt.Equal(*tt.wantOverrun) {
+				t.Fatalf("OverrunAt = %v, want %s", run.OverrunAt, tt.wantOverrun)
+			}
+			if run.OverrunAction != database.WindowOverrunCancel {
+				t.Errorf("OverrunAction = %q, want %q", run.OverrunAction, database.WindowOverrunCancel)
+			}
+		})
+	}
+}
+
+func TestBuildTimeline(t *testing.T) {
+	conn, mock := newMockConnection(t)
+	mock.ExpectQuery("FROM `backup_windows`").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name", "scope", "rule_type", "start_time", "end_time", "timezone", "outside_action", "overrun_action", "enabled"}).
+			AddRow("nightly", "nightly", database.WindowScopeGlobal, database.WindowRuleWindow, "22:00", "06:00", "UTC",
+				database.WindowOutsideDefer, database.WindowOverrunPause, true))
+
+	service := &PlanningService{
+		planRepo:      database.NewPlanningRepository(conn),
+		windowService: NewBackupWindowService(conn),
+	}
+	plan := &ExecutionPlan{
+		GeneratedAt: time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC),
+		VMs: []PlannedVM{
+			{VMContextID: "vm-a", WouldRun: true, FirstRunType: "full", ProvisionedBytes: 7200 << 20, IncrementalBytes: 360 << 20, TransferSpeedBps: 1 << 20},
+			{VMContextID: "vm-b", WouldRun: true, FirstRunType: "incremental", IncrementalBytes: 1800 << 20, TransferSpeedBps: 1 << 20},
+			{VMContextID: "vm-c", WouldRun: false, SkipReason: "scheduler disabled"},
+		},
+	}
+	times := []time.Time{
+		time.Date(2025, 10, 15, 14, 0, 0, 0, time.UTC), // Deferred to 22:00
+		time.Date(2025, 10, 15, 23, 0, 0, 0, time.UTC), // Inside the window, incremental only
+	}
+
+	err := service.buildTimeline(context.Background(), plan,
+		plannedWorkload{owners: []WindowOwner{{Scope: database.WindowScopeSchedule, ID: "sched-1"}}, parallelism: 1}, times)
+	if err != nil {
+		t.Fatalf("buildTimeline() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+
+	if plan.VMsToRun != 2 {
+		t.Errorf("VMsToRun = %d, want 2", plan.VMsToRun)
+	}
+	if len(plan.Runs) != 2 {
+		t.Fatalf("len(Runs) = %d, want 2", len(plan.Runs))
+	}
+
+	first := plan.Runs[0]
+	if !first.Deferred || first.StartAt == nil || !first.StartAt.Equal(time.Date(2025, 10, 15, 22, 0, 0, 0, time.UTC)) {
+		t.Errorf("first run: Deferred = %v, StartAt = %v, want deferred to 22:00", first.Deferred, first.StartAt)
+	}
+	if first.FullJobs != 1 || first.IncrementalJobs != 1 || first.EstimatedBytes != (7200+1800)<<20 {
+		t.Errorf("first run: full %d, incremental %d, bytes %d", first.FullJobs, first.IncrementalJobs, first.EstimatedBytes)
+	}
+	// 9000 MiB at 1 MiB/s one at a time: 2.5h, inside the window
+	if first.EstimatedDurationSeconds != 9000 || first.OverrunsWindow {
+		t.Errorf("first run: duration %ds, overruns %v", first.EstimatedDurationSeconds, first.OverrunsWindow)
+	}
+
+	second := plan.Runs[1]
+	if second.Deferred || second.FullJobs != 0 || second.IncrementalJobs != 2 || second.EstimatedBytes != (360+1800)<<20 {
+		t.Errorf("second run: deferred %v, full %d, incremental %d, bytes %d",
+			second.Deferred, second.FullJobs, second.IncrementalJobs, second.EstimatedBytes)
+	}
+	if !second.OverlapsPreviousRun {
+		t.Error("second run starts before the first is estimated to end, want OverlapsPreviousRun")
+	}
+	if plan.TotalEstimatedBytes != first.EstimatedBytes+second.EstimatedBytes {
+		t.Errorf("TotalEstimatedBytes = %d, want %d", plan.TotalEstimatedBytes, first.EstimatedBytes+second.EstimatedBytes)
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
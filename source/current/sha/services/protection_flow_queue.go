@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+)
+
+// QueueKindFlowExecution runs a protection flow execution through the durable job queue
+const QueueKindFlowExecution = "protection-flow.execute"
+
+// flowExecutionPayload is the queued input of a flow execution
+type flowExecutionPayload struct {
+	FlowID        string `json:"flow_id"`
+	ExecutionType string `json:"execution_type"` // "manual" or "scheduled"
+}
+
+// flowExecutionCheckpoint is recorded once the execution row exists
+type flowExecutionCheckpoint struct {
+	ExecutionID string `json:"execution_id"`
+}
+
+// flowExecutionHandler runs queued protection flow executions
+type flowExecutionHandler struct {
+	service *ProtectionFlowService
+}
+
+// SetJobQueue routes flow executions through the durable job queue
+func (s *ProtectionFlowService) SetJobQueue(jobQueue *JobQueue) {
+	s.jobQueue = jobQueue
+	jobQueue.RegisterHandler(QueueKindFlowExecution, &flowExecutionHandler{service: s})
+}
+
+// QueueFlowExecution queues an execution of a flow; while one is still queued or running
+// for the same flow that job is returned instead (queued=false)
+func (s *ProtectionFlowService) QueueFlowExecution(ctx context.Context, flowID, executionType, createdBy string) (*database.QueuedJob, bool, error) {
+	if s.jobQueue == nil {
+		return nil, false, fmt.Errorf("job queue not available")
+	}
+	if _, err := s.flowRepo.GetFlowByID(ctx, flowID); err != nil {
+		return nil, false, fmt.Errorf("flow not found: %w", err)
+	}
+
+	return s.jobQueue.Enqueue(ctx, EnqueueRequest{
+		Kind:       QueueKindFlowExecution,
+		ResourceID: flowID,
+		Payload:    flowExecutionPayload{FlowID: flowID, ExecutionType: executionType},
+		Exclusive:  true,
+		CreatedBy:  createdBy,
+	})
+}
+
+// Run starts the flow's jobs. Each job is recorded on the execution as it starts, so a
+// retry after an interruption resumes the same execution and skips VMs already started.
+func (h *flowExecutionHandler) Run(ctx context.Context, job *database.QueuedJob) error {
+	var payload flowExecutionPayload
+	if err := DecodeQueuePayload(job, &payload); err != nil {
+		return err
+	}
+
+	var execution *database.ProtectionFlowExecution
+	var err error
+
+	// An earlier attempt got as far as creating the execution
+	var checkpoint flowExecutionCheckpoint
+	if LoadQueueCheckpoint(job, &checkpoint) && checkpoint.ExecutionID != "" {
+		execution, err = h.service.flowRepo.GetExecution(ctx, checkpoint.ExecutionID)
+		if err != nil {
+			return fmt.Errorf("failed to load interrupted execution: %w", err)
+		}
+		if execution.Status != "running" || execution.DispatchedAt != nil {
+			log.WithFields(log.Fields{
+				"queue_job_id": job.ID,
+				"execution_id": execution.ID,
+				"status":       execution.Status,
+			}).Info("Flow execution already started all its jobs before the interruption - not starting them again")
+			return nil
+		}
+		execution, err = h.service.ResumeExecution(ctx, execution)
+	} else {
+		execution, err = h.service.ExecuteFlow(ctx, payload.FlowID, payload.ExecutionType)
+	}
+	if err != nil {
+		return err
+	}
+	if execution.Status == "error" {
+		// The failure is on record in the execution - retrying would only add another
+		msg := "flow execution failed to start its jobs"
+		if execution.ErrorMessage != nil {
+			msg = *execution.ErrorMessage
+		}
+		return PermanentError(fmt.Errorf("execution %s: %s", execution.ID, msg))
+	}
+	return nil
+}
+
+// Compensate closes an execution left mid-dispatch by a job that will not run again
+func (h *flowExecutionHandler) Compensate(ctx context.Context, job *database.QueuedJob, reason string) error {
+	var checkpoint flowExecutionCheckpoint
+	if !LoadQueueCheckpoint(job, &checkpoint) || checkpoint.ExecutionID == "" {
+		return nil
+	}
+
+	execution, err := h.service.flowRepo.GetExecution(ctx, checkpoint.ExecutionID)
+	if err != nil {
+		return fmt.Errorf("failed to load execution: %w", err)
+	}
+	if execution.Status != "running" || execution.DispatchedAt != nil {
+		// Finished, or its jobs are running and the execution monitor settles it
+		return nil
+	}
+	h.closeExecution(ctx, execution, reason)
+	return nil
+}
+
+// closeExecution stops an execution from starting more jobs. Without jobs it fails; jobs it
+// did start keep running and the execution monitor settles it once they finish.
+func (h *flowExecutionHandler) closeExecution(ctx context.Context, execution *database.ProtectionFlowExecution, reason string) {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"dispatched_at": now,
+		"error_message": reason,
+	}
+
+	status := "running"
+	if execution.CreatedJobIDs == nil || *execution.CreatedJobIDs == "" {
+		status = "error"
+		updates["completed_at"] = now
+		if execution.StartedAt != nil {
+			updates["execution_time_seconds"] = int(now.Sub(*execution.StartedAt).Seconds())
+		}
+	}
+
+	if err := h.service.flowRepo.UpdateExecutionStatus(ctx, execution.ID, status, updates); err != nil {
+		log.WithError(err).WithField("execution_id", execution.ID).Warn("Failed to close flow execution")
+	}
+}
@@ -23,6 +23,7 @@ type ProtectionFlowService struct {
 	flowRepo        *database.FlowRepository
 	scheduleService *SchedulerService
 	machineGroupSvc *MachineGroupService
+	jobHookService  *JobHookService
 	vmContextRepo   *database.VMReplicationContextRepository
 	jobTracker      *joblog.Tracker
 	db              database.Connection
@@ -30,6 +31,15 @@ type ProtectionFlowService struct {
 	// HTTP client for backup API calls
 	backupAPIClient *http.Client
 	backupAPIURL    string
+
+	// 🆕 NEW: Admission control per datastore, ESXi host and SNA (optional)
+	admission *AdmissionController
+
+	// 🆕 NEW: Durable job queue that runs flow executions
+	jobQueue *JobQueue
+
+	// 🆕 NEW: Live job updates - announces each job an execution starts (optional)
+	events *LiveEventHub
 }
 
 // Flow execution request types
@@ -42,7 +52,10 @@ type CreateFlowRequest struct {
 	RepositoryID *string `json:"repository_id,omitempty"`
 	PolicyID     *string `json:"policy_id,omitempty"`
 	ScheduleID   *string `json:"schedule_id,omitempty"`
+	RPOMinutes   *int    `json:"rpo_minutes,omitempty"` // RPO target - runs are scheduled from the newest restore point
 	Enabled      *bool   `json:"enabled,omitempty"`
+
+	ExcludedDisks []database.DiskExclusion `json:"excluded_disks,omitempty"` // Disks skipped for every VM in the flow
 }
 
 // Flow status response
@@ -83,11 +96,26 @@ func (s *ProtectionFlowService) SetSchedulerService(schedulerService *SchedulerS
 	s.scheduleService = schedulerService
 }
 
+// SetJobHookService sets the job hook service for pre/post job hooks
+func (s *ProtectionFlowService) SetJobHookService(jobHookService *JobHookService) {
+	s.jobHookService = jobHookService
+}
+
 // SetMachineGroupService sets the machine group service
 func (s *ProtectionFlowService) SetMachineGroupService(machineGroupSvc *MachineGroupService) {
 	s.machineGroupSvc = machineGroupSvc
 }
 
+// SetAdmissionController sets the admission controller that paces flow backups
+func (s *ProtectionFlowService) SetAdmissionController(admission *AdmissionController) {
+	s.admission = admission
+}
+
+// SetEventHub announces the jobs each flow execution starts to live event subscribers
+func (s *ProtectionFlowService) SetEventHub(events *LiveEventHub) {
+	s.events = events
+}
+
 // =============================================================================
 // FLOW CRUD OPERATIONS
 // =============================================================================
@@ -115,6 +143,11 @@ func (s *ProtectionFlowService) CreateFlow(ctx context.Context, req CreateFlowRe
 		enabled = *req.Enabled
 	}
 
+	excludedDisks, err := database.EncodeDiskExclusions(req.ExcludedDisks)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
 	// Create flow
 	flow := &database.ProtectionFlow{
 		Name:        req.Name,
@@ -125,6 +158,8 @@ func (s *ProtectionFlowService) CreateFlow(ctx context.Context, req CreateFlowRe
 		RepositoryID: req.RepositoryID,
 		PolicyID:     req.PolicyID,
 		ScheduleID:   req.ScheduleID,
+		RPOMinutes:   req.RPOMinutes,
+		ExcludedDisks: excludedDisks,
 		Enabled:      enabled,
 		LastExecutionStatus: "pending",
 		CreatedBy:           "system", // TODO: Get from context
@@ -222,15 +257,67 @@ func (s *ProtectionFlowService) ExecuteFlow(ctx context.Context, flowID string,
 
 	logger.Info("Execution record created", "execution_id", execution.ID)
 
-	// 3. Route to appropriate handler
+	// Let a queued run find this execution again if the SHA restarts mid-way
+	if err := SaveQueueCheckpoint(jobCtx, flowExecutionCheckpoint{ExecutionID: execution.ID}); err != nil {
+		logger.Warn("Failed to checkpoint queued flow execution", "error", err)
+	}
+
+	s.dispatchExecution(jobCtx, jobID, flow, execution, false)
+	return execution, nil
+}
+
+// ResumeExecution continues an execution interrupted while it was starting its jobs (SHA
+// restart or leader change). VMs that already have a job in created_job_ids are skipped.
+func (s *ProtectionFlowService) ResumeExecution(ctx context.Context, execution *database.ProtectionFlowExecution) (*database.ProtectionFlowExecution, error) {
+	owner := "system"
+	jobCtx, jobID, err := s.jobTracker.StartJob(ctx, joblog.JobStart{
+		JobType:   "scheduler",
+		Operation: fmt.Sprintf("resume_%s_flow", execution.ExecutionType),
+		Owner:     &owner,
+		Metadata:  map[string]interface{}{"flow_id": execution.FlowID, "execution_id": execution.ID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start job tracking: %w", err)
+	}
+	defer func() {
+		s.jobTracker.EndJob(jobCtx, jobID, joblog.StatusCompleted, nil)
+	}()
+
+	flow, err := s.flowRepo.GetFlowByID(jobCtx, execution.FlowID)
+	if err != nil {
+		return nil, fmt.Errorf("flow not found: %w", err)
+	}
+
+	s.jobTracker.Logger(jobCtx).Info("Resuming interrupted flow execution",
+		"execution_id", execution.ID,
+		"flow_id", flow.ID,
+		"jobs_already_created", execution.JobsCreated)
+
+	s.dispatchExecution(jobCtx, jobID, flow, execution, true)
+	return execution, nil
+}
+
+// dispatchExecution starts the execution's jobs and records the outcome on the execution
+// and the flow statistics
+func (s *ProtectionFlowService) dispatchExecution(jobCtx context.Context, jobID string, flow *database.ProtectionFlow, execution *database.ProtectionFlowExecution, resumed bool) {
+	logger := s.jobTracker.Logger(jobCtx)
+	flowID := flow.ID
+
+	// 3. Run pre_job hooks (flow and schedule), then route to appropriate handler.
+	// Hooks run before the first job starts; a resumed run repeats them only if it started nothing.
 	var execErr error
-	switch flow.FlowType {
-	case "backup":
-		execErr = s.ProcessBackupFlow(jobCtx, flow, execution)
-	case "replication":
-		execErr = s.ProcessReplicationFlow(jobCtx, flow, execution)
-	default:
-		execErr = fmt.Errorf("unknown flow type: %s", flow.FlowType)
+	if !resumed || execution.CreatedJobIDs == nil || *execution.CreatedJobIDs == "" {
+		execErr = s.runPreJobHooks(jobCtx, jobID, flow, execution)
+	}
+	if execErr == nil {
+		switch flow.FlowType {
+		case "backup":
+			execErr = s.ProcessBackupFlow(jobCtx, flow, execution)
+		case "replication":
+			execErr = s.ProcessReplicationFlow(jobCtx, flow, execution)
+		default:
+			execErr = fmt.Errorf("unknown flow type: %s", flow.FlowType)
+		}
 	}
 
 	// 4. Update execution status
@@ -258,6 +345,8 @@ func (s *ProtectionFlowService) ExecuteFlow(ctx context.Context, flowID string,
 			"jobs_skipped":           execution.JobsSkipped,
 			"vms_processed":          execution.VMsProcessed,
 			"bytes_transferred":      execution.BytesTransferred,
+			"created_job_ids":        execution.CreatedJobIDs,
+			"dispatched_at":          completedAt,
 		})
 		if updateErr != nil {
 			logger.Error("Failed to update execution status", "error", updateErr)
@@ -266,6 +355,8 @@ func (s *ProtectionFlowService) ExecuteFlow(ctx context.Context, flowID string,
 		// ✅ FIX: Jobs started successfully - keep status="running"
 		// Don't set completed_at or mark as success yet!
 		// Background monitor will update when jobs actually complete
+		dispatchedAt := time.Now()
+		execution.DispatchedAt = &dispatchedAt
 		updateErr := s.flowRepo.UpdateExecutionStatus(jobCtx, execution.ID, "running", map[string]interface{}{
 			"jobs_created":      execution.JobsCreated,
 			"jobs_completed":    execution.JobsCompleted,
@@ -273,6 +364,7 @@ func (s *ProtectionFlowService) ExecuteFlow(ctx context.Context, flowID string,
 			"jobs_skipped":      execution.JobsSkipped,
 			"vms_processed":     execution.VMsProcessed,
 			"created_job_ids":   execution.CreatedJobIDs,
+			"dispatched_at":     execution.DispatchedAt,
 		})
 		if updateErr != nil {
 			logger.Error("Failed to update execution status", "error", updateErr)
@@ -291,7 +383,12 @@ func (s *ProtectionFlowService) ExecuteFlow(ctx context.Context, flowID string,
 		LastExecutionID:     &execution.ID,
 		LastExecutionStatus: execution.Status,  // Use execution.Status (not finalStatus)
 		LastExecutionTime:   execution.CompletedAt,  // Will be nil for running executions
-		TotalExecutions:     flow.TotalExecutions + 1,
+		TotalExecutions:     flow.TotalExecutions + func() int {
+			if resumed {
+				return 0 // Counted when the execution was created
+			}
+			return 1
+		}(),
 		SuccessfulExecutions: flow.SuccessfulExecutions + func() int {
 			if execution.Status == "success" {
 				return 1
@@ -314,8 +411,27 @@ func (s *ProtectionFlowService) ExecuteFlow(ctx context.Context, flowID string,
 		"status", execution.Status,  // ✅ FIX: Use execution.Status
 		"jobs_created", execution.JobsCreated,
 		"vms_processed", execution.VMsProcessed)
+}
 
-	return execution, nil
+// runPreJobHooks runs the flow's pre_job hooks; a failing hook with on_failure=fail aborts the execution
+func (s *ProtectionFlowService) runPreJobHooks(ctx context.Context, jobID string, flow *database.ProtectionFlow, execution *database.ProtectionFlowExecution) error {
+	if s.jobHookService == nil {
+		return nil
+	}
+
+	owners := []HookOwner{{Type: database.HookOwnerFlow, ID: flow.ID}}
+	if flow.ScheduleID != nil && *flow.ScheduleID != "" {
+		owners = append(owners, HookOwner{Type: database.HookOwnerSchedule, ID: *flow.ScheduleID})
+	}
+
+	return s.jobHookService.RunHooks(ctx, jobID, database.HookTypePreJob, HookContext{
+		JobID:       execution.ID,
+		JobType:     flow.FlowType,
+		Operation:   "flow_execution",
+		FlowID:      flow.ID,
+		ScheduleID:  stringPtrToString(flow.ScheduleID),
+		ExecutionID: execution.ID,
+	}, owners...)
 }
 
 // ProcessBackupFlow executes a backup-type flow
@@ -350,15 +466,35 @@ func (s *ProtectionFlowService) ProcessBackupFlow(ctx context.Context, flow *dat
 	}
 
 	// 2. Execute backup for each VM
-	var createdJobIDs []string
 	var jobsFailed, jobsSkipped int  // ✅ FIX: Removed jobsCompleted (not used - jobs run in background)
 	var totalBytes int64
 
+	// A resumed execution keeps the jobs it started before the interruption
+	createdJobIDs, startedContexts, err := s.executionJobs(ctx, execution)
+	if err != nil {
+		return fmt.Errorf("failed to load jobs already started by execution: %w", err)
+	}
+
+	// 🆕 NEW: Start backups as datastore, host, SNA and NBD port capacity allows (admission control)
+	requests := make([]AdmissionRequest, 0, len(vmContexts))
 	for _, contextID := range vmContexts {
+		if startedContexts[contextID] {
+			logger.Info("Backup already started by this execution - skipping", "context_id", contextID)
+			continue
+		}
+		requests = append(requests, s.admission.BuildRequest(ctx, "backup", contextID, "flow:"+flow.ID))
+	}
+	admitted := len(vmContexts) - len(requests)
+
+	for ticket := range s.admission.Admit(ctx, requests) {
+		admitted++
+		contextID := ticket.Request.VMContextID
+
 		// Query VM context directly since no GetByContextID method exists
 		var vmCtx database.VMReplicationContext
 		if err := s.db.GetGormDB().Where("context_id = ?", contextID).First(&vmCtx).Error; err != nil {
 			logger.Error("Failed to load VM context", "context_id", contextID, "error", err)
+			s.admission.Release(ticket.ID)
 			jobsSkipped++
 			continue
 		}
@@ -380,7 +516,9 @@ func (s *ProtectionFlowService) ProcessBackupFlow(ctx context.Context, flow *dat
 			RepositoryID: *flow.RepositoryID,
 			BackupType:   backupType,
 			PolicyID:     stringPtrToString(flow.PolicyID),
+			FlowID:       flow.ID,
 		})
+		s.admission.Release(ticket.ID)
 		if err != nil {
 			logger.Error("Failed to start backup", "vm_name", vmCtx.VMName, "error", err)
 			jobsFailed++
@@ -388,6 +526,16 @@ func (s *ProtectionFlowService) ProcessBackupFlow(ctx context.Context, flow *dat
 		}
 
 		createdJobIDs = append(createdJobIDs, backupResp.BackupID)
+		// Record the job now - a retry must not start this VM's backup again
+		if err := s.flowRepo.AppendExecutionJob(ctx, execution.ID, backupResp.BackupID); err != nil {
+			logger.Error("Failed to record started backup on execution", "backup_id", backupResp.BackupID, "error", err)
+		}
+		s.events.Publish(LiveEvent{
+			Type:        LiveEventExecutionJob,
+			JobID:       backupResp.BackupID,
+			JobType:     "backup",
+			ExecutionID: execution.ID,
+		})
 		// ❌ REMOVED: jobsCompleted++ (backup just STARTED, not completed!)
 		totalBytes += backupResp.TotalBytes
 
@@ -397,6 +545,12 @@ func (s *ProtectionFlowService) ProcessBackupFlow(ctx context.Context, flow *dat
 			"bytes", backupResp.TotalBytes)
 	}
 
+	// VMs that never got admission are skipped this run
+	if notAdmitted := len(vmContexts) - admitted; notAdmitted > 0 {
+		logger.Warn("Backups not admitted - skipped", "count", notAdmitted)
+		jobsSkipped += notAdmitted
+	}
+
 	// 3. Update execution with results
 	execution.JobsCreated = len(createdJobIDs)
 	// ✅ FIX: Don't set jobsCompleted here - jobs are still running!
@@ -427,6 +581,33 @@ func (s *ProtectionFlowService) ProcessBackupFlow(ctx context.Context, flow *dat
 	return nil
 }
 
+// executionJobs returns the jobs an execution already started and the VM contexts they
+// belong to
+func (s *ProtectionFlowService) executionJobs(ctx context.Context, execution *database.ProtectionFlowExecution) ([]string, map[string]bool, error) {
+	started := make(map[string]bool)
+	if execution.CreatedJobIDs == nil || *execution.CreatedJobIDs == "" {
+		return nil, started, nil
+	}
+
+	var jobIDs []string
+	if err := json.Unmarshal([]byte(*execution.CreatedJobIDs), &jobIDs); err != nil {
+		return nil, nil, fmt.Errorf("invalid created_job_ids: %w", err)
+	}
+	if len(jobIDs) == 0 {
+		return nil, started, nil
+	}
+
+	var contextIDs []string
+	if err := s.db.GetGormDB().WithContext(ctx).Model(&database.BackupJob{}).
+		Where("id IN ?", jobIDs).Pluck("vm_context_id", &contextIDs).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, contextID := range contextIDs {
+		started[contextID] = true
+	}
+	return jobIDs, started, nil
+}
+
 // ProcessReplicationFlow executes a replication-type flow (Phase 5 placeholder)
 func (s *ProtectionFlowService) ProcessReplicationFlow(ctx context.Context, flow *database.ProtectionFlow, execution *database.ProtectionFlowExecution) error {
 	logger := s.jobTracker.Logger(ctx)
@@ -512,6 +693,12 @@ func (s *ProtectionFlowService) validateFlowRequest(req CreateFlowRequest) error
 		return fmt.Errorf("repository_id is required for backup flows")
 	}
 
+	if req.RPOMinutes != nil {
+		if err := ValidateRPOMinutes(*req.RPOMinutes); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -566,6 +753,7 @@ type BackupStartRequest struct {
 	BackupType   string            `json:"backup_type"`
 	RepositoryID string            `json:"repository_id"`
 	PolicyID     string            `json:"policy_id,omitempty"`
+	FlowID       string            `json:"flow_id,omitempty"` // Originating flow (for guest snapshot hooks)
 	Tags         map[string]string `json:"tags,omitempty"`
 }
 
@@ -54,6 +54,16 @@ func NewQemuNBDManager(portAllocator *NBDPortAllocator) *QemuNBDManager {
 // Start launches a new qemu-nbd instance
 // Returns the PID of the started process
 func (m *QemuNBDManager) Start(port int, exportName, filePath, jobID, vmName string, diskID int) (*QemuNBDProcess, error) {
+	return m.start(port, exportName, filePath, jobID, vmName, diskID, false)
+}
+
+// StartReadOnly launches a read-only qemu-nbd instance exporting an existing backup
+// Used by restores so the backup chain can never be modified
+func (m *QemuNBDManager) StartReadOnly(port int, exportName, filePath, jobID, vmName string, diskID int) (*QemuNBDProcess, error) {
+	return m.start(port, exportName, filePath, jobID, vmName, diskID, true)
+}
+
+func (m *QemuNBDManager) start(port int, exportName, filePath, jobID, vmName string, diskID int, readOnly bool) (*QemuNBDProcess, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	
@@ -74,15 +84,19 @@ func (m *QemuNBDManager) Start(port int, exportName, filePath, jobID, vmName str
 	// -p port: Listen port
 	// -b 0.0.0.0: Bind to all interfaces (accessible via tunnel)
 	// -t: Enable write-through cache
-	cmd := exec.Command("qemu-nbd",
+	// --read-only: Export without write access (restores)
+	args := []string{
 		"-f", "qcow2",
 		"-x", exportName,
 		"-p", strconv.Itoa(port),
 		"-b", "0.0.0.0",
 		"--shared", "10",
 		"-t",
-		filePath,
-	)
+	}
+	if readOnly {
+		args = append(args, "--read-only")
+	}
+	cmd := exec.Command("qemu-nbd", append(args, filePath)...)
 	
 	// Start the process
 	if err := cmd.Start(); err != nil {
@@ -0,0 +1,601 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+	"github.com/vexxhost/migratekit-sha/storage"
+)
+
+// =============================================================================
+// REPOSITORY CAPACITY SERVICE - Pre-flight space reservation and forecasting
+// =============================================================================
+// Every backup reserves space in its repository before any disk is prepared: the
+// included disk sizes for a full, the expected CBT delta for an incremental (same
+// estimate as the planning API), plus the repository's reserve margin. A backup that
+// does not fit next to the reservations of in-flight backups fails fast, or moves to
+// the repository's alternate when the policy says so. Reservations are released once
+// the backup job settles.
+//
+// Capacity is sampled hourly into repository_capacity_history. Growth per day is the
+// least-squares slope of used bytes over the last capacityForecastWindow, and
+// days-until-full is free space (less reservations) divided by that growth. Crossing
+// the usage or days-until-full thresholds of a repository opens a row in
+// repository_capacity_alerts until the condition clears.
+
+// Repository capacity levels
+const (
+	CapacityLevelOK       = "ok"
+	CapacityLevelWarning  = "warning"
+	CapacityLevelCritical = "critical"
+)
+
+const (
+	// capacityCheckInterval is how often settled reservations are released
+	capacityCheckInterval = time.Minute
+	// capacitySampleInterval is how often repository capacity is recorded and alerts evaluated
+	capacitySampleInterval = time.Hour
+	// capacityForecastWindow is how much history the growth rate is fitted over
+	capacityForecastWindow = 30 * 24 * time.Hour
+	// capacityMinHistory is the span of samples needed before a growth rate is reported
+	capacityMinHistory = 6 * time.Hour
+	// capacityHistoryRetention is how long capacity samples are kept
+	capacityHistoryRetention = 180 * 24 * time.Hour
+)
+
+// BackupSpaceRequest describes a backup about to start
+type BackupSpaceRequest struct {
+	BackupJobID  string
+	VMContextID  string
+	VMName       string
+	RepositoryID string
+	BackupType   string // "full" or "incremental"
+	DiskBytes    int64  // Total size of the disks included in the backup
+}
+
+// BackupSpaceReservation is the space held for a backup, possibly in an alternate repository
+type BackupSpaceReservation struct {
+	ID             string `json:"id"`
+	RepositoryID   string `json:"repository_id"`
+	BackupType     string `json:"backup_type"` // A redirected incremental becomes a full without a chain there
+	ReservedBytes  int64  `json:"reserved_bytes"`
+	EstimateSource string `json:"estimate_source"`
+	RedirectedFrom string `json:"redirected_from,omitempty"`
+}
+
+// RepositoryCapacityForecast is the current capacity and projected fill date of a repository
+type RepositoryCapacityForecast struct {
+	RepositoryID            string                              `json:"repository_id"`
+	Name                    string                              `json:"name"`
+	Enabled                 bool                                `json:"enabled"`
+	TotalBytes              int64                               `json:"total_bytes"`
+	UsedBytes               int64                               `json:"used_bytes"`
+	AvailableBytes          int64                               `json:"available_bytes"`
+	ReservedBytes           int64                               `json:"reserved_bytes"` // Still to be written by in-flight backups
+	EffectiveAvailableBytes int64                               `json:"effective_available_bytes"`
+	UsedPercent             float64                             `json:"used_percent"`
+	LastCheckAt             *time.Time                          `json:"last_check_at,omitempty"`
+	GrowthBytesPerDay       int64                               `json:"growth_bytes_per_day"`
+	HistorySamples          int                                 `json:"history_samples"`
+	HistoryDays             float64                             `json:"history_days"`
+	DaysUntilFull           *float64                            `json:"days_until_full,omitempty"` // Nil when not growing or too little history
+	ProjectedFullAt         *time.Time                          `json:"projected_full_at,omitempty"`
+	Level                   string                              `json:"level"`
+	Policy                  *database.RepositoryCapacityPolicy  `json:"policy"`
+	OpenAlerts              []*database.RepositoryCapacityAlert `json:"open_alerts"`
+}
+
+// RepositoryCapacityService reserves repository space for backups and forecasts capacity
+type RepositoryCapacityService struct {
+	capacityRepo *database.CapacityRepository
+	planRepo     *database.PlanningRepository
+	repositories *storage.RepositoryManager
+}
+
+// NewRepositoryCapacityService creates a new repository capacity service
+func NewRepositoryCapacityService(db database.Connection) *RepositoryCapacityService {
+	return &RepositoryCapacityService{
+		capacityRepo: database.NewCapacityRepository(db),
+		planRepo:     database.NewPlanningRepository(db),
+	}
+}
+
+// SetRepositoryManager enables live repository capacity (otherwise the last recorded capacity is used)
+func (s *RepositoryCapacityService) SetRepositoryManager(repositories *storage.RepositoryManager) {
+	s.repositories = repositories
+}
+
+// =============================================================================
+// SPACE RESERVATION
+// =============================================================================
+
+// ReserveForBackup holds space for a backup about to start. When the repository is
+// short of space the backup is redirected to the policy's alternate repository, or a
+// *storage.InsufficientSpaceError is returned.
+func (s *RepositoryCapacityService) ReserveForBackup(ctx context.Context, req BackupSpaceRequest) (*BackupSpaceReservation, error) {
+	policy, err := s.capacityRepo.GetPolicy(ctx, req.RepositoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	reservation, err := s.reserve(ctx, req, policy, "")
+	if err == nil {
+		return reservation, nil
+	}
+
+	var spaceErr *storage.InsufficientSpaceError
+	if !errors.As(err, &spaceErr) || policy.OnInsufficient != database.CapacityOnInsufficientRedirect ||
+		policy.AlternateRepositoryID == nil || *policy.AlternateRepositoryID == req.RepositoryID {
+		return nil, err
+	}
+
+	// Redirect - an incremental needs a full backup in the alternate repository to chain from
+	alternate := req
+	alternate.RepositoryID = *policy.AlternateRepositoryID
+	if alternate.BackupType == "incremental" {
+		hasFull, fullErr := s.planRepo.HasCompletedFullBackup(ctx, req.VMContextID, alternate.RepositoryID)
+		if fullErr != nil {
+			return nil, fullErr
+		}
+		if !hasFull {
+			alternate.BackupType = "full"
+		}
+	}
+
+	alternatePolicy, policyErr := s.capacityRepo.GetPolicy(ctx, alternate.RepositoryID)
+	if policyErr != nil {
+		return nil, policyErr
+	}
+	reservation, altErr := s.reserve(ctx, alternate, alternatePolicy, req.RepositoryID)
+	if altErr != nil {
+		log.WithError(altErr).WithFields(log.Fields{
+			"repository_id": req.RepositoryID,
+			"alternate_id":  alternate.RepositoryID,
+			"vm_name":       req.VMName,
+		}).Warn("Alternate repository cannot take the backup either")
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		"backup_job_id": req.BackupJobID,
+		"vm_name":       req.VMName,
+		"from":          req.RepositoryID,
+		"to":            reservation.RepositoryID,
+		"backup_type":   reservation.BackupType,
+		"required":      spaceErr.Required,
+		"available":     spaceErr.Available,
+	}).Warn("↪️ Repository short of space - backup redirected to alternate repository")
+	return reservation, nil
+}
+
+// reserve estimates the space a backup needs and reserves it if the repository has room
+func (s *RepositoryCapacityService) reserve(ctx context.Context, req BackupSpaceRequest, policy *database.RepositoryCapacityPolicy, redirectedFrom string) (*BackupSpaceReservation, error) {
+	required, source, err := s.estimateBackupBytes(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	required += required * int64(policy.ReserveMarginPercent) / 100
+
+	available, known, err := s.availableBytes(ctx, req.RepositoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	row := &database.RepositorySpaceReservation{
+		ID:             uuid.New().String(),
+		RepositoryID:   req.RepositoryID,
+		BackupJobID:    req.BackupJobID,
+		VMContextID:    req.VMContextID,
+		BackupType:     req.BackupType,
+		ReservedBytes:  required,
+		EstimateSource: source,
+	}
+	if redirectedFrom != "" {
+		row.RedirectedFrom = &redirectedFrom
+	}
+
+	err = s.capacityRepo.ReserveSpace(ctx, row, func(outstanding int64) error {
+		if !known {
+			return nil
+		}
+		free := available - outstanding
+		if required > free {
+			return &storage.InsufficientSpaceError{Required: required, Available: max(free, 0)}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !known {
+		log.WithField("repository_id", req.RepositoryID).Warn("Repository capacity never recorded - reserving space without a check")
+	}
+
+	log.WithFields(log.Fields{
+		"backup_job_id":   req.BackupJobID,
+		"repository_id":   req.RepositoryID,
+		"backup_type":     req.BackupType,
+		"reserved_bytes":  required,
+		"estimate_source": source,
+	}).Info("📦 Reserved repository space for backup")
+
+	return &BackupSpaceReservation{
+		ID:             row.ID,
+		RepositoryID:   row.RepositoryID,
+		BackupType:     row.BackupType,
+		ReservedBytes:  row.ReservedBytes,
+		EstimateSource: row.EstimateSource,
+		RedirectedFrom: redirectedFrom,
+	}, nil
+}
+
+// estimateBackupBytes predicts how much a backup writes - the disks for a full, the
+// expected change for an incremental
+func (s *RepositoryCapacityService) estimateBackupBytes(ctx context.Context, req BackupSpaceRequest) (int64, string, error) {
+	if req.BackupType != "incremental" {
+		return req.DiskBytes, "disk_size", nil
+	}
+
+	delta, source, _, err := estimateIncrementalBytes(ctx, s.planRepo, req.VMContextID, req.DiskBytes)
+	if err != nil {
+		return 0, "", err
+	}
+	return delta, source, nil
+}
+
+// availableBytes returns the free space of a repository, live when possible; known is
+// false when the capacity has never been recorded
+func (s *RepositoryCapacityService) availableBytes(ctx context.Context, repositoryID string) (int64, bool, error) {
+	if s.repositories != nil {
+		repo, err := s.repositories.GetRepository(ctx, repositoryID)
+		if err == nil {
+			info, infoErr := repo.GetStorageInfo(ctx)
+			if infoErr == nil {
+				return info.AvailableBytes, true, nil
+			}
+			err = infoErr
+		}
+		log.WithError(err).WithField("repository_id", repositoryID).Warn("Live repository capacity unavailable - using last recorded capacity")
+	}
+
+	usage, err := s.capacityRepo.GetRepositoryUsage(ctx, repositoryID)
+	if err != nil {
+		return 0, false, err
+	}
+	return usage.AvailableSizeBytes, usage.TotalSizeBytes > 0, nil
+}
+
+// Release frees the space reserved for a backup job
+func (s *RepositoryCapacityService) Release(ctx context.Context, backupJobID string) error {
+	return s.capacityRepo.ReleaseReservation(ctx, backupJobID)
+}
+
+// Reopen holds the space reserved for a backup job again when it is resumed
+func (s *RepositoryCapacityService) Reopen(ctx context.Context, backupJobID string) error {
+	return s.capacityRepo.ReopenReservation(ctx, backupJobID)
+}
+
+// =============================================================================
+// BACKGROUND SAMPLING AND ALERTS
+// =============================================================================
+
+// Start releases settled reservations and samples repository capacity until ctx is cancelled
+func (s *RepositoryCapacityService) Start(ctx context.Context) {
+	ticker := time.NewTicker(capacityCheckInterval)
+	defer ticker.Stop()
+
+	log.WithFields(log.Fields{
+		"check_interval":  capacityCheckInterval,
+		"sample_interval": capacitySampleInterval,
+	}).Info("📈 Repository capacity monitor started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("🛑 Repository capacity monitor stopped")
+			return
+		case <-ticker.C:
+			if released, err := s.capacityRepo.ReleaseSettledReservations(ctx); err != nil {
+				log.WithError(err).Warn("Failed to release settled space reservations")
+			} else if released > 0 {
+				log.WithField("released", released).Debug("Released space reservations of settled backups")
+			}
+
+			// The last sample is read from the database so a new leader keeps the cadence
+			last, err := s.capacityRepo.GetLastSampleTime(ctx)
+			if err != nil {
+				log.WithError(err).Warn("Failed to get the last repository capacity sample")
+				continue
+			}
+			if last == nil || time.Since(*last) >= capacitySampleInterval {
+				s.sampleRepositories(ctx)
+			}
+		}
+	}
+}
+
+// sampleRepositories records the capacity of every enabled repository and evaluates alerts
+func (s *RepositoryCapacityService) sampleRepositories(ctx context.Context) {
+	now := time.Now()
+
+	if s.repositories != nil {
+		if err := s.repositories.RefreshStorageInfo(ctx); err != nil {
+			log.WithError(err).Warn("Failed to refresh repository storage info")
+		}
+	}
+
+	repos, err := s.capacityRepo.ListRepositoryUsage(ctx)
+	if err != nil {
+		log.WithError(err).Warn("Failed to list repositories for capacity sampling")
+		return
+	}
+	openAlerts, err := s.capacityRepo.GetOpenAlerts(ctx)
+	if err != nil {
+		log.WithError(err).Warn("Failed to load open capacity alerts")
+		return
+	}
+
+	for i := range repos {
+		repo := &repos[i]
+		if !repo.Enabled || repo.TotalSizeBytes <= 0 {
+			continue
+		}
+
+		reserved, err := s.capacityRepo.GetOutstandingReservedBytes(ctx, repo.ID)
+		if err != nil {
+			log.WithError(err).WithField("repository_id", repo.ID).Warn("Failed to sum space reservations")
+			continue
+		}
+		if err := s.capacityRepo.RecordSample(ctx, &database.RepositoryCapacitySample{
+			RepositoryID:   repo.ID,
+			TotalBytes:     repo.TotalSizeBytes,
+			UsedBytes:      repo.UsedSizeBytes,
+			AvailableBytes: repo.AvailableSizeBytes,
+			ReservedBytes:  reserved,
+			RecordedAt:     now,
+		}); err != nil {
+			log.WithError(err).WithField("repository_id", repo.ID).Warn("Failed to record repository capacity")
+			continue
+		}
+
+		forecast, err := s.buildForecast(ctx, repo, now)
+		if err != nil {
+			log.WithError(err).WithField("repository_id", repo.ID).Warn("Failed to forecast repository capacity")
+			continue
+		}
+		s.evaluateAlerts(ctx, forecast, openAlerts, now)
+	}
+
+	if err := s.capacityRepo.PruneSamples(ctx, now.Add(-capacityHistoryRetention)); err != nil {
+		log.WithError(err).Warn("Failed to prune repository capacity history")
+	}
+}
+
+// evaluateAlerts opens alerts for thresholds a repository has crossed and resolves cleared ones
+func (s *RepositoryCapacityService) evaluateAlerts(ctx context.Context, forecast *RepositoryCapacityForecast, openAlerts map[string]*database.RepositoryCapacityAlert, now time.Time) {
+	policy := forecast.Policy
+	active := map[string]string{}
+
+	switch {
+	case forecast.UsedPercent >= float64(policy.CriticalPercent):
+		active[database.CapacityAlertUsageCritical] = fmt.Sprintf("Repository %s is %.1f%% full (critical at %d%%)",
+			forecast.Name, forecast.UsedPercent, policy.CriticalPercent)
+	case forecast.UsedPercent >= float64(policy.WarnPercent):
+		active[database.CapacityAlertUsageWarning] = fmt.Sprintf("Repository %s is %.1f%% full (warning at %d%%)",
+			forecast.Name, forecast.UsedPercent, policy.WarnPercent)
+	}
+	if forecast.DaysUntilFull != nil && *forecast.DaysUntilFull <= float64(policy.WarnDays) {
+		active[database.CapacityAlertDaysUntilFull] = fmt.Sprintf("Repository %s is projected to be full in %.1f days",
+			forecast.Name, *forecast.DaysUntilFull)
+	}
+
+	for _, alertType := range []string{database.CapacityAlertUsageWarning, database.CapacityAlertUsageCritical, database.CapacityAlertDaysUntilFull} {
+		open := openAlerts[database.CapacityAlertKey(forecast.RepositoryID, alertType)]
+		message, raised := active[alertType]
+
+		switch {
+		case raised && open == nil:
+			alert := &database.RepositoryCapacityAlert{
+				ID:            uuid.New().String(),
+				RepositoryID:  forecast.RepositoryID,
+				AlertType:     alertType,
+				Message:       message,
+				UsedPercent:   forecast.UsedPercent,
+				DaysUntilFull: forecast.DaysUntilFull,
+				StartedAt:     now,
+			}
+			if err := s.capacityRepo.CreateAlert(ctx, alert); err != nil {
+				log.WithError(err).WithField("repository_id", forecast.RepositoryID).Warn("Failed to record capacity alert")
+			}
+		case !raised && open != nil:
+			if err := s.capacityRepo.ResolveAlert(ctx, open.ID, now); err != nil {
+				log.WithError(err).WithField("alert_id", open.ID).Warn("Failed to resolve capacity alert")
+				continue
+			}
+			log.WithFields(log.Fields{
+				"repository_id": forecast.RepositoryID,
+				"alert_type":    alertType,
+			}).Info("✅ Repository capacity alert cleared")
+		}
+	}
+}
+
+// =============================================================================
+// FORECASTS AND POLICIES
+// =============================================================================
+
+// ListForecasts returns the capacity forecast of every repository
+func (s *RepositoryCapacityService) ListForecasts(ctx context.Context) ([]*RepositoryCapacityForecast, error) {
+	repos, err := s.capacityRepo.ListRepositoryUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	forecasts := make([]*RepositoryCapacityForecast, 0, len(repos))
+	for i := range repos {
+		forecast, err := s.buildForecast(ctx, &repos[i], now)
+		if err != nil {
+			return nil, err
+		}
+		forecasts = append(forecasts, forecast)
+	}
+	return forecasts, nil
+}
+
+// GetForecast returns the capacity forecast of one repository
+func (s *RepositoryCapacityService) GetForecast(ctx context.Context, repositoryID string) (*RepositoryCapacityForecast, error) {
+	repo, err := s.capacityRepo.GetRepositoryUsage(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildForecast(ctx, repo, time.Now())
+}
+
+// buildForecast projects when a repository fills up from its recorded growth
+func (s *RepositoryCapacityService) buildForecast(ctx context.Context, repo *database.RepositoryUsage, now time.Time) (*RepositoryCapacityForecast, error) {
+	policy, err := s.capacityRepo.GetPolicy(ctx, repo.ID)
+	if err != nil {
+		return nil, err
+	}
+	reserved, err := s.capacityRepo.GetOutstandingReservedBytes(ctx, repo.ID)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := s.capacityRepo.GetSamples(ctx, repo.ID, now.Add(-capacityForecastWindow))
+	if err != nil {
+		return nil, err
+	}
+	alerts, err := s.capacityRepo.ListAlerts(ctx, database.CapacityAlertFilters{RepositoryID: &repo.ID, OpenOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	forecast := &RepositoryCapacityForecast{
+		RepositoryID:            repo.ID,
+		Name:                    repo.Name,
+		Enabled:                 repo.Enabled,
+		TotalBytes:              repo.TotalSizeBytes,
+		UsedBytes:               repo.UsedSizeBytes,
+		AvailableBytes:          repo.AvailableSizeBytes,
+		ReservedBytes:           reserved,
+		EffectiveAvailableBytes: max(repo.AvailableSizeBytes-reserved, 0),
+		LastCheckAt:             repo.LastCheckAt,
+		HistorySamples:          len(samples),
+		Level:                   CapacityLevelOK,
+		Policy:                  policy,
+		OpenAlerts:              append([]*database.RepositoryCapacityAlert{}, alerts...),
+	}
+	if repo.TotalSizeBytes > 0 {
+		forecast.UsedPercent = float64(repo.UsedSizeBytes) * 100 / float64(repo.TotalSizeBytes)
+	}
+
+	if len(samples) >= 2 {
+		span := samples[len(samples)-1].RecordedAt.Sub(samples[0].RecordedAt)
+		forecast.HistoryDays = span.Hours() / 24
+		if span >= capacityMinHistory {
+			forecast.GrowthBytesPerDay = int64(growthPerDay(samples))
+		}
+	}
+	if forecast.GrowthBytesPerDay > 0 {
+		days := float64(forecast.EffectiveAvailableBytes) / float64(forecast.GrowthBytesPerDay)
+		fullAt := now.Add(time.Duration(days * float64(24*time.Hour)))
+		forecast.DaysUntilFull = &days
+		forecast.ProjectedFullAt = &fullAt
+	}
+
+	switch {
+	case forecast.UsedPercent >= float64(policy.CriticalPercent):
+		forecast.Level = CapacityLevelCritical
+	case forecast.UsedPercent >= float64(policy.WarnPercent),
+		forecast.DaysUntilFull != nil && *forecast.DaysUntilFull <= float64(policy.WarnDays):
+		forecast.Level = CapacityLevelWarning
+	}
+	return forecast, nil
+}
+
+// growthPerDay is the least-squares slope of used bytes over time, in bytes per day
+func growthPerDay(samples []database.RepositoryCapacitySample) float64 {
+	origin := samples[0].RecordedAt
+	var sumX, sumY, sumXY, sumXX float64
+	for _, sample := range samples {
+		x := sample.RecordedAt.Sub(origin).Hours() / 24
+		y := float64(sample.UsedBytes)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	n := float64(len(samples))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+	if math.IsNaN(slope) || math.IsInf(slope, 0) {
+		return 0
+	}
+	return slope
+}
+
+// GetPolicy returns the capacity policy of a repository
+func (s *RepositoryCapacityService) GetPolicy(ctx context.Context, repositoryID string) (*database.RepositoryCapacityPolicy, error) {
+	if _, err := s.capacityRepo.GetRepositoryUsage(ctx, repositoryID); err != nil {
+		return nil, err
+	}
+	return s.capacityRepo.GetPolicy(ctx, repositoryID)
+}
+
+// UpdatePolicy validates and stores the capacity policy of a repository
+func (s *RepositoryCapacityService) UpdatePolicy(ctx context.Context, policy *database.RepositoryCapacityPolicy) error {
+	if _, err := s.capacityRepo.GetRepositoryUsage(ctx, policy.RepositoryID); err != nil {
+		return err
+	}
+
+	switch policy.OnInsufficient {
+	case database.CapacityOnInsufficientFail:
+	case database.CapacityOnInsufficientRedirect:
+		if policy.AlternateRepositoryID == nil || *policy.AlternateRepositoryID == "" {
+			return fmt.Errorf("invalid policy: redirect requires alternate_repository_id")
+		}
+	default:
+		return fmt.Errorf("invalid policy: on_insufficient must be %q or %q",
+			database.CapacityOnInsufficientFail, database.CapacityOnInsufficientRedirect)
+	}
+
+	if policy.AlternateRepositoryID != nil && *policy.AlternateRepositoryID != "" {
+		if *policy.AlternateRepositoryID == policy.RepositoryID {
+			return fmt.Errorf("invalid policy: alternate repository must differ from the repository")
+		}
+		if _, err := s.capacityRepo.GetRepositoryUsage(ctx, *policy.AlternateRepositoryID); err != nil {
+			return fmt.Errorf("invalid policy: alternate %w", err)
+		}
+	} else {
+		policy.AlternateRepositoryID = nil
+	}
+
+	if policy.ReserveMarginPercent < 0 || policy.ReserveMarginPercent > 100 {
+		return fmt.Errorf("invalid policy: reserve_margin_percent must be between 0 and 100")
+	}
+	if policy.WarnPercent <= 0 || policy.WarnPercent > policy.CriticalPercent || policy.CriticalPercent > 100 {
+		return fmt.Errorf("invalid policy: expected 0 < warn_percent <= critical_percent <= 100")
+	}
+	if policy.WarnDays < 0 {
+		return fmt.Errorf("invalid policy: warn_days must not be negative")
+	}
+
+	return s.capacityRepo.SavePolicy(ctx, policy)
+}
+
+// ListAlerts returns repository capacity alert history
+func (s *RepositoryCapacityService) ListAlerts(ctx context.Context, filters database.CapacityAlertFilters) ([]*database.RepositoryCapacityAlert, error) {
+	return s.capacityRepo.ListAlerts(ctx, filters)
+}
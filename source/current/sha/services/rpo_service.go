@@ -0,0 +1,485 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+)
+
+// =============================================================================
+// RPO SERVICE - RPO-driven flow scheduling and SLA compliance
+// =============================================================================
+// Protection flows with rpo_minutes set are run whenever the newest restore point of
+// one of their VMs would otherwise age past the RPO target. The run is started early
+// enough to finish in time (average job duration plus a safety margin), is held back
+// while the SHA is busy or the flow's backup window is closed, and backs off when
+// runs keep failing to produce a newer restore point. VMs outside their RPO are
+// recorded in rpo_breaches for compliance history.
+
+// VM RPO compliance states
+const (
+	RPOStatusCompliant = "compliant"
+	RPOStatusAtRisk    = "at_risk"  // Restore point older than rpoAtRiskPercent of the target (or none yet)
+	RPOStatusBreached  = "breached" // Restore point older than the target
+)
+
+const (
+	// rpoAtRiskPercent of the RPO target after which a VM is reported at risk
+	rpoAtRiskPercent = 80
+	// rpoSafetyMarginPercent of the RPO target added to the expected job duration
+	rpoSafetyMarginPercent = 10
+	// rpoDurationSample is how many recent jobs the expected duration is averaged over
+	rpoDurationSample = 5
+
+	rpoMinBackoff = 5 * time.Minute
+	rpoMaxBackoff = time.Hour
+
+	// minRPOMinutes is the shortest RPO target the once-a-minute scheduler can honour
+	minRPOMinutes = 15
+
+	// defaultRPOMaxRunningJobs holds back RPO runs while this many jobs are moving data
+	defaultRPOMaxRunningJobs = 8
+)
+
+// VMRPOStatus is the RPO compliance of one VM in an RPO-driven flow
+type VMRPOStatus struct {
+	FlowID                 string     `json:"flow_id"`
+	FlowName               string     `json:"flow_name"`
+	FlowType               string     `json:"flow_type"`
+	VMContextID            string     `json:"vm_context_id"`
+	VMName                 string     `json:"vm_name"`
+	RPOMinutes             int        `json:"rpo_minutes"`
+	Status                 string     `json:"status"`
+	LastRestorePointAt     *time.Time `json:"last_restore_point_at,omitempty"`
+	RestorePointAgeSeconds *int64     `json:"restore_point_age_seconds,omitempty"`
+	RPODeadline            time.Time  `json:"rpo_deadline"` // When the VM falls (or fell) outside its RPO
+	NextRunAt              time.Time  `json:"next_run_at"`  // When the RPO scheduler wants a new restore point started
+	BreachID               string     `json:"breach_id,omitempty"`
+	BreachStartedAt        *time.Time `json:"breach_started_at,omitempty"`
+	BreachDurationSeconds  int64      `json:"breach_duration_seconds,omitempty"`
+}
+
+// RPOComplianceSummary counts VMs by compliance state
+type RPOComplianceSummary struct {
+	Total     int `json:"total"`
+	Compliant int `json:"compliant"`
+	AtRisk    int `json:"at_risk"`
+	Breached  int `json:"breached"`
+}
+
+// RPOComplianceReport answers "are we within RPO?" for every VM in RPO-driven flows
+type RPOComplianceReport struct {
+	GeneratedAt time.Time            `json:"generated_at"`
+	Summary     RPOComplianceSummary `json:"summary"`
+	VMs         []VMRPOStatus        `json:"vms"`
+}
+
+// rpoFlowState tracks RPO dispatches of a flow to back off runs that don't help
+type rpoFlowState struct {
+	lastDispatchAt  time.Time
+	dispatchedPoint *time.Time // Restore point of the VM that triggered the last dispatch
+	failures        int
+}
+
+// RPOService schedules RPO-driven protection flows and reports RPO compliance
+type RPOService struct {
+	rpoRepo       *database.RPORepository
+	flowService   *ProtectionFlowService
+	windowService *BackupWindowService
+
+	checkInterval  time.Duration
+	maxRunningJobs int64
+
+	mu    sync.Mutex
+	state map[string]*rpoFlowState
+}
+
+// NewRPOService creates a new RPO service
+func NewRPOService(db database.Connection, flowService *ProtectionFlowService) *RPOService {
+	return &RPOService{
+		rpoRepo:        database.NewRPORepository(db),
+		flowService:    flowService,
+		checkInterval:  time.Minute,
+		maxRunningJobs: defaultRPOMaxRunningJobs,
+		state:          make(map[string]*rpoFlowState),
+	}
+}
+
+// SetBackupWindowService makes RPO runs honour backup windows and blackouts
+func (s *RPOService) SetBackupWindowService(windowService *BackupWindowService) {
+	s.windowService = windowService
+}
+
+// Start evaluates RPO-driven flows until ctx is cancelled
+func (s *RPOService) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	log.WithFields(log.Fields{
+		"check_interval":   s.checkInterval,
+		"max_running_jobs": s.maxRunningJobs,
+	}).Info("🎯 RPO scheduler started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("🛑 RPO scheduler stopped")
+			return
+		case <-ticker.C:
+			s.evaluateFlows(ctx)
+		}
+	}
+}
+
+// evaluateFlows records breaches and starts flows whose VMs need a new restore point
+func (s *RPOService) evaluateFlows(ctx context.Context) {
+	now := time.Now()
+
+	flows, err := s.rpoRepo.GetRPOFlows(ctx)
+	if err != nil {
+		log.WithError(err).Warn("Failed to load RPO-driven flows")
+		return
+	}
+	openBreaches, err := s.rpoRepo.GetOpenBreaches(ctx)
+	if err != nil {
+		log.WithError(err).Warn("Failed to load open RPO breaches")
+		return
+	}
+	runningJobs, err := s.rpoRepo.CountRunningJobs(ctx)
+	if err != nil {
+		log.WithError(err).Warn("Failed to count running jobs for RPO scheduling")
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, flow := range flows {
+		statuses, err := s.flowCompliance(ctx, flow, now, openBreaches)
+		if err != nil {
+			log.WithError(err).WithField("flow_id", flow.ID).Warn("Failed to evaluate RPO compliance")
+			continue
+		}
+		for i := range statuses {
+			seen[database.RPOBreachKey(flow.ID, statuses[i].VMContextID)] = true
+		}
+
+		s.recordBreaches(ctx, statuses, openBreaches, now)
+		s.scheduleFlow(ctx, flow, statuses, &runningJobs, now)
+	}
+
+	// VMs that left their flow (or flows that lost their RPO target) are no longer in breach
+	for key, breach := range openBreaches {
+		if !seen[key] {
+			if err := s.rpoRepo.ResolveBreach(ctx, breach.ID, now, nil); err != nil {
+				log.WithError(err).WithField("breach_id", breach.ID).Warn("Failed to close RPO breach")
+			}
+		}
+	}
+}
+
+// flowCompliance computes the RPO state of every VM in a flow
+func (s *RPOService) flowCompliance(ctx context.Context, flow *database.ProtectionFlow, now time.Time, openBreaches map[string]*database.RPOBreach) ([]VMRPOStatus, error) {
+	vms, err := s.rpoRepo.GetFlowTargetVMs(ctx, flow)
+	if err != nil {
+		return nil, err
+	}
+
+	rpo := time.Duration(*flow.RPOMinutes) * time.Minute
+	statuses := make([]VMRPOStatus, 0, len(vms))
+	for _, vm := range vms {
+		lastPoint, err := s.rpoRepo.GetLastRestorePoint(ctx, flow.FlowType, vm.ContextID)
+		if err != nil {
+			return nil, err
+		}
+		duration, err := s.rpoRepo.GetAverageJobDuration(ctx, flow.FlowType, vm.ContextID, rpoDurationSample)
+		if err != nil {
+			return nil, err
+		}
+
+		status := VMRPOStatus{
+			FlowID:             flow.ID,
+			FlowName:           flow.Name,
+			FlowType:           flow.FlowType,
+			VMContextID:        vm.ContextID,
+			VMName:             vm.VMName,
+			RPOMinutes:         *flow.RPOMinutes,
+			LastRestorePointAt: lastPoint,
+		}
+
+		// Never protected VMs get one RPO period from when they joined protection
+		if lastPoint != nil {
+			age := int64(now.Sub(*lastPoint).Seconds())
+			status.RestorePointAgeSeconds = &age
+			status.RPODeadline = lastPoint.Add(rpo)
+		} else {
+			since := flow.CreatedAt
+			if vm.CreatedAt.After(since) {
+				since = vm.CreatedAt
+			}
+			status.RPODeadline = since.Add(rpo)
+		}
+
+		// Start early enough for the job to finish before the deadline
+		leadTime := duration + rpo*rpoSafetyMarginPercent/100
+		status.NextRunAt = status.RPODeadline.Add(-leadTime)
+		if lastPoint == nil || status.NextRunAt.Before(now) {
+			status.NextRunAt = now
+		}
+
+		switch {
+		case !now.Before(status.RPODeadline):
+			status.Status = RPOStatusBreached
+		case lastPoint == nil || now.Sub(*lastPoint) >= rpo*rpoAtRiskPercent/100:
+			status.Status = RPOStatusAtRisk
+		default:
+			status.Status = RPOStatusCompliant
+		}
+
+		if breach, ok := openBreaches[database.RPOBreachKey(flow.ID, vm.ContextID)]; ok && status.Status == RPOStatusBreached {
+			status.BreachID = breach.ID
+			status.BreachStartedAt = &breach.BreachStartedAt
+		} else if status.Status == RPOStatusBreached {
+			deadline := status.RPODeadline
+			status.BreachStartedAt = &deadline
+		}
+		if status.BreachStartedAt != nil {
+			status.BreachDurationSeconds = int64(now.Sub(*status.BreachStartedAt).Seconds())
+		}
+
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// recordBreaches opens breaches for VMs that fell outside their RPO and closes recovered ones
+func (s *RPOService) recordBreaches(ctx context.Context, statuses []VMRPOStatus, openBreaches map[string]*database.RPOBreach, now time.Time) {
+	for i := range statuses {
+		status := &statuses[i]
+		key := database.RPOBreachKey(status.FlowID, status.VMContextID)
+		breach, open := openBreaches[key]
+
+		switch {
+		case status.Status == RPOStatusBreached && !open:
+			breach = &database.RPOBreach{
+				ID:                 uuid.New().String(),
+				FlowID:             status.FlowID,
+				VMContextID:        status.VMContextID,
+				VMName:             status.VMName,
+				RPOMinutes:         status.RPOMinutes,
+				LastRestorePointAt: status.LastRestorePointAt,
+				BreachStartedAt:    status.RPODeadline,
+			}
+			if err := s.rpoRepo.CreateBreach(ctx, breach); err != nil {
+				log.WithError(err).WithField("vm_context_id", status.VMContextID).Warn("Failed to record RPO breach")
+				continue
+			}
+			openBreaches[key] = breach
+			status.BreachID = breach.ID
+
+		case status.Status != RPOStatusBreached && open:
+			// The breach ended when the restore point that fixed it was taken
+			resolvedAt := now
+			if status.LastRestorePointAt != nil && status.LastRestorePointAt.After(breach.BreachStartedAt) {
+				resolvedAt = *status.LastRestorePointAt
+			}
+			if err := s.rpoRepo.ResolveBreach(ctx, breach.ID, resolvedAt, status.LastRestorePointAt); err != nil {
+				log.WithError(err).WithField("breach_id", breach.ID).Warn("Failed to close RPO breach")
+				continue
+			}
+			delete(openBreaches, key)
+
+			log.WithFields(log.Fields{
+				"breach_id":       breach.ID,
+				"flow_id":         status.FlowID,
+				"vm_context_id":   status.VMContextID,
+				"breach_duration": resolvedAt.Sub(breach.BreachStartedAt).Round(time.Second),
+			}).Info("✅ VM back within RPO")
+		}
+	}
+}
+
+// scheduleFlow starts a flow when one of its VMs needs a new restore point
+func (s *RPOService) scheduleFlow(ctx context.Context, flow *database.ProtectionFlow, statuses []VMRPOStatus, runningJobs *int64, now time.Time) {
+	if len(statuses) == 0 {
+		return
+	}
+
+	// The VM that needs a restore point first drives the flow
+	driver := statuses[0]
+	for _, status := range statuses[1:] {
+		if status.NextRunAt.Before(driver.NextRunAt) {
+			driver = status
+		}
+	}
+
+	nextRun := driver.NextRunAt
+	if flow.NextExecutionTime == nil || !flow.NextExecutionTime.Equal(nextRun) {
+		if err := s.rpoRepo.SetNextExecutionTime(ctx, flow.ID, &nextRun); err != nil {
+			log.WithError(err).WithField("flow_id", flow.ID).Warn("Failed to record next RPO run")
+		}
+	}
+	if nextRun.After(now) {
+		return
+	}
+
+	logger := log.WithFields(log.Fields{
+		"flow_id":       flow.ID,
+		"flow_name":     flow.Name,
+		"vm_context_id": driver.VMContextID,
+		"vm_status":     driver.Status,
+		"rpo_minutes":   driver.RPOMinutes,
+	})
+
+	running, err := s.rpoRepo.HasRunningExecution(ctx, flow.ID)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to check running flow executions")
+		return
+	}
+	if running {
+		return
+	}
+
+	// Back off when the previous RPO run did not produce a newer restore point
+	s.mu.Lock()
+	state, ok := s.state[flow.ID]
+	if !ok {
+		state = &rpoFlowState{}
+		s.state[flow.ID] = state
+	}
+	retrying := !state.lastDispatchAt.IsZero() && sameRestorePoint(state.dispatchedPoint, driver.LastRestorePointAt)
+	if retrying {
+		if notBefore := state.lastDispatchAt.Add(rpoBackoff(state.failures + 1)); now.Before(notBefore) {
+			s.mu.Unlock()
+			return
+		}
+	}
+	s.mu.Unlock()
+
+	if *runningJobs >= s.maxRunningJobs {
+		logger.WithField("running_jobs", *runningJobs).Info("⏳ RPO run held back - SHA under load")
+		return
+	}
+
+	if s.windowService != nil {
+		owners := []WindowOwner{{Scope: database.WindowScopeFlow, ID: flow.ID}}
+		if flow.ScheduleID != nil {
+			owners = append(owners, WindowOwner{Scope: database.WindowScopeSchedule, ID: *flow.ScheduleID})
+		}
+		decision, err := s.windowService.Check(ctx, now, owners...)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to evaluate backup windows for RPO run")
+		} else if !decision.Allowed {
+			logger.WithField("reason", decision.Reason).Debug("RPO run held back - outside backup window")
+			return
+		}
+	}
+
+	logger.Info("🎯 Queueing protection flow to meet RPO target")
+	queued, created, err := s.flowService.QueueFlowExecution(ctx, flow.ID, "scheduled", "rpo")
+	if err == nil && !created {
+		logger.WithField("queue_job_id", queued.ID).Debug("RPO-driven flow already queued or running")
+		return
+	}
+
+	s.mu.Lock()
+	if retrying {
+		state.failures++
+	} else {
+		state.failures = 0
+	}
+	state.lastDispatchAt = now
+	state.dispatchedPoint = driver.LastRestorePointAt
+	s.mu.Unlock()
+
+	if err != nil {
+		logger.WithError(err).Error("Failed to queue RPO-driven flow")
+		return
+	}
+	// The jobs are started by the queue - count the flow as one more job until they show up
+	*runningJobs++
+}
+
+// ValidateRPOMinutes checks an RPO target is usable by the RPO scheduler
+func ValidateRPOMinutes(rpoMinutes int) error {
+	if rpoMinutes < minRPOMinutes {
+		return fmt.Errorf("rpo_minutes must be at least %d", minRPOMinutes)
+	}
+	return nil
+}
+
+// rpoBackoff returns how long to wait before retrying an RPO run that didn't help
+func rpoBackoff(failures int) time.Duration {
+	backoff := rpoMinBackoff
+	for i := 1; i < failures && backoff < rpoMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > rpoMaxBackoff {
+		backoff = rpoMaxBackoff
+	}
+	return backoff
+}
+
+// sameRestorePoint reports whether two restore point times are the same (both nil counts)
+func sameRestorePoint(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+// =============================================================================
+// COMPLIANCE REPORTING
+// =============================================================================
+
+// GetCompliance reports the RPO state of every VM in RPO-driven flows (optionally one flow)
+func (s *RPOService) GetCompliance(ctx context.Context, flowID string) (*RPOComplianceReport, error) {
+	now := time.Now()
+
+	flows, err := s.rpoRepo.GetRPOFlows(ctx)
+	if err != nil {
+		return nil, err
+	}
+	openBreaches, err := s.rpoRepo.GetOpenBreaches(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RPOComplianceReport{
+		GeneratedAt: now,
+		VMs:         []VMRPOStatus{},
+	}
+	for _, flow := range flows {
+		if flowID != "" && flow.ID != flowID {
+			continue
+		}
+
+		statuses, err := s.flowCompliance(ctx, flow, now, openBreaches)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate flow %s: %w", flow.ID, err)
+		}
+		for _, status := range statuses {
+			switch status.Status {
+			case RPOStatusCompliant:
+				report.Summary.Compliant++
+			case RPOStatusAtRisk:
+				report.Summary.AtRisk++
+			case RPOStatusBreached:
+				report.Summary.Breached++
+			}
+		}
+		report.VMs = append(report.VMs, statuses...)
+	}
+	report.Summary.Total = len(report.VMs)
+
+	return report, nil
+}
+
+// ListBreaches retrieves RPO breach history
+func (s *RPOService) ListBreaches(ctx context.Context, filters database.RPOBreachFilters) ([]*database.RPOBreach, error) {
+	return s.rpoRepo.ListBreaches(ctx, filters)
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+)
+
+// =============================================================================
+// SCHEDULER BACKUP WINDOWS - Defer or skip runs outside their backup window
+// =============================================================================
+
+const scheduleTriggerWindow = "scheduler-window"
+
+// SetBackupWindowService enables backup windows and blackouts for schedules and flows
+func (s *SchedulerService) SetBackupWindowService(windowService *BackupWindowService) {
+	s.windowService = windowService
+	s.conflictDetector.SetBackupWindowService(windowService)
+}
+
+// insideBackupWindow reports whether a schedule may run now; runs that may not are
+// queued for when the window opens ("defer") or recorded as skipped ("skip")
+func (s *SchedulerService) insideBackupWindow(ctx context.Context, schedule *database.ReplicationSchedule, deferred *database.ScheduleExecution) bool {
+	if s.windowService == nil {
+		return true
+	}
+
+	decision, err := s.windowService.Evaluate(ctx, time.Now(),
+		WindowOwner{Scope: database.WindowScopeSchedule, ID: schedule.ID})
+	if err != nil {
+		// Don't hold back backups on a window lookup failure
+		log.WithError(err).WithField("schedule_id", schedule.ID).Warn("Failed to evaluate backup windows")
+		return true
+	}
+	if decision.Allowed {
+		return true
+	}
+
+	logger := log.WithFields(log.Fields{
+		"schedule_id":    schedule.ID,
+		"reason":         decision.Reason,
+		"outside_action": decision.OutsideAction,
+		"next_open_at":   decision.NextOpenAt,
+	})
+
+	if decision.OutsideAction == database.WindowOutsideSkip || decision.NextOpenAt == nil {
+		logger.Info("⏭️ Schedule run skipped - outside backup window")
+		if deferred != nil {
+			s.settleDeferredExecution(deferred, "skipped", decision.Reason)
+			return false
+		}
+		now := time.Now()
+		skipped := &database.ScheduleExecution{
+			ID:                 uuid.New().String(),
+			ScheduleID:         schedule.ID,
+			ScheduledAt:        now,
+			CompletedAt:        &now,
+			OutcomeEvaluatedAt: &now,
+			Status:             "skipped",
+			Attempt:            1,
+			ErrorMessage:       &decision.Reason,
+			TriggeredBy:        "scheduler-service",
+		}
+		if err := s.repository.CreateScheduleExecution(skipped); err != nil {
+			logger.WithError(err).Error("Failed to record skipped schedule execution")
+		}
+		return false
+	}
+
+	// Queued executions (retries, chains, earlier deferrals) just move to the window opening
+	if deferred != nil {
+		if err := s.repository.UpdateScheduleExecution(deferred.ID, map[string]interface{}{
+			"scheduled_at":   *decision.NextOpenAt,
+			"deferred_until": *decision.NextOpenAt,
+			"error_message":  decision.Reason,
+		}); err != nil {
+			logger.WithError(err).Error("Failed to defer queued schedule execution")
+			return false
+		}
+		deferred.ScheduledAt = *decision.NextOpenAt
+		deferred.DeferredUntil = decision.NextOpenAt
+		s.armDeferredExecution(deferred)
+		logger.Info("⏳ Queued schedule execution deferred to backup window")
+		return false
+	}
+
+	// One deferred run per schedule is enough - later cron firings fold into it
+	pending, err := s.repository.ListDeferredScheduleExecutions()
+	if err == nil {
+		for _, execution := range pending {
+			if execution.ScheduleID == schedule.ID && execution.TriggeredBy == scheduleTriggerWindow {
+				logger.WithField("execution_id", execution.ID).Info("Schedule run already deferred to backup window")
+				return false
+			}
+		}
+	}
+
+	details, _ := json.Marshal(map[string]interface{}{"deferred_reason": decision.Reason})
+	detailsStr := string(details)
+	execution := &database.ScheduleExecution{
+		ID:               uuid.New().String(),
+		ScheduleID:       schedule.ID,
+		ScheduledAt:      *decision.NextOpenAt,
+		DeferredUntil:    decision.NextOpenAt,
+		Status:           "scheduled",
+		Attempt:          1,
+		ExecutionDetails: &detailsStr,
+		TriggeredBy:      scheduleTriggerWindow,
+	}
+	if err := s.repository.CreateScheduleExecution(execution); err != nil {
+		logger.WithError(err).Error("Failed to queue deferred schedule execution")
+		return false
+	}
+
+	logger.WithField("execution_id", execution.ID).Info("⏳ Schedule run deferred to backup window")
+	s.armDeferredExecution(execution)
+	return false
+}
+
+// flowInsideBackupWindow reports whether a scheduled flow may run now; deferred
+// flows run again when the window opens (a restart falls back to the next cron run)
+func (s *SchedulerService) flowInsideBackupWindow(ctx context.Context, flowID, scheduleID string) bool {
+	if s.windowService == nil {
+		return true
+	}
+
+	decision, err := s.windowService.Evaluate(ctx, time.Now(),
+		WindowOwner{Scope: database.WindowScopeFlow, ID: flowID},
+		WindowOwner{Scope: database.WindowScopeSchedule, ID: scheduleID})
+	if err != nil {
+		log.WithError(err).WithField("flow_id", flowID).Warn("Failed to evaluate backup windows")
+		return true
+	}
+	if decision.Allowed {
+		return true
+	}
+
+	logger := log.WithFields(log.Fields{
+		"flow_id":        flowID,
+		"schedule_id":    scheduleID,
+		"reason":         decision.Reason,
+		"outside_action": decision.OutsideAction,
+		"next_open_at":   decision.NextOpenAt,
+	})
+
+	if decision.OutsideAction == database.WindowOutsideSkip || decision.NextOpenAt == nil {
+		logger.Info("⏭️ Scheduled flow run skipped - outside backup window")
+		return false
+	}
+
+	s.runningMutex.Lock()
+	alreadyDeferred := s.deferredFlows[flowID]
+	s.deferredFlows[flowID] = true
+	s.runningMutex.Unlock()
+	if alreadyDeferred {
+		logger.Info("Scheduled flow run already deferred to backup window")
+		return false
+	}
+
+	stop := s.stopChan
+	time.AfterFunc(time.Until(*decision.NextOpenAt), func() {
+		s.runningMutex.Lock()
+		delete(s.deferredFlows, flowID)
+		s.runningMutex.Unlock()
+		select {
+		case <-stop:
+			// Scheduler stopped (e.g. leadership moved) - the next cron run picks the flow up
+			return
+		default:
+		}
+		s.ExecuteScheduledFlow(context.Background(), flowID, scheduleID)
+	})
+
+	logger.Info("⏳ Scheduled flow run deferred to backup window")
+	return false
+}
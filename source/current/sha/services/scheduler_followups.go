@@ -0,0 +1,386 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+)
+
+// =============================================================================
+// SCHEDULE FOLLOW-UPS - Retries of failed VMs and chained schedules
+// =============================================================================
+// Once a schedule execution has dispatched its replication jobs, the scheduler waits
+// for them to finish. VMs whose job failed are re-queued as a new execution attempt
+// after retry_delay_minutes (up to retry_attempts retries); when the last attempt has
+// settled, chain schedules that follow the schedule are queued chain_delay_minutes later.
+// Queued executions are schedule_executions rows in "scheduled" status with
+// deferred_until set, so they survive a restart of the SHA.
+
+const (
+	// scheduleOutcomePollInterval is how often dispatched replication jobs are checked
+	scheduleOutcomePollInterval = 30 * time.Second
+	// scheduleOutcomeTimeout stops waiting on replication jobs that never finish
+	scheduleOutcomeTimeout = 24 * time.Hour
+
+	scheduleTriggerRetry = "scheduler-retry"
+	scheduleTriggerChain = "scheduler-chain"
+)
+
+// scheduleCronSpec returns the cron spec for a schedule evaluated in its timezone
+func scheduleCronSpec(schedule *database.ReplicationSchedule) (string, error) {
+	spec := strings.TrimSpace(schedule.CronExpression)
+	if schedule.Timezone == "" || strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		return spec, nil
+	}
+
+	if _, err := time.LoadLocation(schedule.Timezone); err != nil {
+		return "", fmt.Errorf("invalid timezone %q for schedule %s: %w", schedule.Timezone, schedule.ID, err)
+	}
+	return fmt.Sprintf("CRON_TZ=%s %s", schedule.Timezone, spec), nil
+}
+
+// scheduleTimingChanged reports whether a schedule must be re-registered with cron
+func scheduleTimingChanged(registered, current *database.ReplicationSchedule) bool {
+	return registered.CronExpression != current.CronExpression ||
+		registered.Timezone != current.Timezone ||
+		registered.ScheduleType != current.ScheduleType
+}
+
+// retryVMContextIDs returns the VMs a retry attempt is limited to (nil for a full run)
+func retryVMContextIDs(execution *database.ScheduleExecution) map[string]bool {
+	if execution.Attempt <= 1 || execution.ExecutionDetails == nil {
+		return nil
+	}
+
+	var details struct {
+		RetryVMContextIDs []string `json:"retry_vm_context_ids"`
+	}
+	if err := json.Unmarshal([]byte(*execution.ExecutionDetails), &details); err != nil || len(details.RetryVMContextIDs) == 0 {
+		return nil
+	}
+
+	vms := make(map[string]bool, len(details.RetryVMContextIDs))
+	for _, contextID := range details.RetryVMContextIDs {
+		vms[contextID] = true
+	}
+	return vms
+}
+
+// sortedKeys returns the keys of a set in stable order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// awaitExecutionOutcome waits for the replication jobs of an execution to finish,
+// records the outcome, then queues a retry of failed VMs or the chained schedules
+func (s *SchedulerService) awaitExecutionOutcome(executionID string) {
+	logger := log.WithField("execution_id", executionID)
+	deadline := time.Now().Add(scheduleOutcomeTimeout)
+	stop := s.stopChan
+
+	ticker := time.NewTicker(scheduleOutcomePollInterval)
+	defer ticker.Stop()
+
+	for {
+		done, err := s.evaluateExecutionOutcome(executionID, time.Now().After(deadline))
+		if err != nil {
+			logger.WithError(err).Warn("Failed to evaluate schedule execution outcome")
+		}
+		if done {
+			return
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			// Picked up again by recoverScheduleFollowUps on the next start
+			return
+		}
+	}
+}
+
+// evaluateExecutionOutcome checks the jobs of an execution; it returns true once the
+// outcome has been recorded (or the execution can never be evaluated)
+func (s *SchedulerService) evaluateExecutionOutcome(executionID string, timedOut bool) (bool, error) {
+	execution, err := s.repository.GetScheduleExecution(executionID)
+	if err != nil {
+		return false, err
+	}
+	if execution.OutcomeEvaluatedAt != nil {
+		return true, nil
+	}
+
+	jobs, err := s.repository.GetScheduleExecutionJobs(executionID)
+	if err != nil {
+		return false, err
+	}
+
+	failedVMs := make(map[string]bool)
+	completed, failed, pending := 0, 0, 0
+	for _, job := range jobs {
+		switch job.Status {
+		case "completed":
+			completed++
+		case "failed":
+			failed++
+			failedVMs[job.VMContextID] = true
+		case "cancelled":
+			// Cancelled by an operator - never retried
+		default:
+			pending++
+		}
+	}
+	if pending > 0 && !timedOut {
+		return false, nil
+	}
+
+	// VMs whose replication job could not even be created also count as failed
+	var dispatch ExecutionSummary
+	if execution.ExecutionDetails != nil {
+		json.Unmarshal([]byte(*execution.ExecutionDetails), &dispatch)
+	}
+	for _, contextID := range dispatch.FailedVMContexts {
+		failedVMs[contextID] = true
+	}
+
+	now := time.Now()
+	status, errorMessage := "completed", ""
+	updates := map[string]interface{}{
+		"jobs_completed":       completed,
+		"jobs_failed":          failed + len(dispatch.FailedVMContexts),
+		"outcome_evaluated_at": now,
+	}
+	if len(failedVMs) > 0 {
+		status = "failed"
+		errorMessage = fmt.Sprintf("%d VM(s) failed replication", len(failedVMs))
+		updates["status"] = status
+		updates["error_message"] = errorMessage
+	}
+	if pending > 0 {
+		status = "failed"
+		errorMessage = fmt.Sprintf("%d replication job(s) still running after %s", pending, scheduleOutcomeTimeout)
+		updates["error_message"] = errorMessage
+	}
+	if err := s.repository.UpdateScheduleExecution(executionID, updates); err != nil {
+		return false, err
+	}
+
+	log.WithFields(log.Fields{
+		"execution_id":   executionID,
+		"schedule_id":    execution.ScheduleID,
+		"attempt":        execution.Attempt,
+		"jobs_completed": completed,
+		"jobs_failed":    failed,
+		"failed_vms":     len(failedVMs),
+	}).Info("📊 Schedule execution outcome recorded")
+
+	s.runExecutionPostJobHooks(execution, status, errorMessage)
+
+	schedule, err := s.repository.GetScheduleByID(execution.ScheduleID)
+	if err != nil {
+		return true, err
+	}
+
+	// Retry failed VMs while the schedule allows it; chains wait for the last attempt
+	if len(failedVMs) > 0 && execution.Attempt <= schedule.RetryAttempts && schedule.Enabled {
+		return true, s.queueRetryExecution(schedule, execution, failedVMs)
+	}
+
+	return true, s.queueChainExecutions(schedule, execution)
+}
+
+// runExecutionPostJobHooks runs the schedule's post_job hooks with the final status of
+// an execution's replication jobs; failures are logged only
+func (s *SchedulerService) runExecutionPostJobHooks(execution *database.ScheduleExecution, status, errorMessage string) {
+	if s.jobHookService == nil {
+		return
+	}
+
+	hookCtx := HookContext{
+		JobID:        execution.ID,
+		JobType:      "replication",
+		Operation:    "schedule-execution",
+		ScheduleID:   execution.ScheduleID,
+		ExecutionID:  execution.ID,
+		Status:       status,
+		ErrorMessage: errorMessage,
+	}
+	owner := HookOwner{Type: database.HookOwnerSchedule, ID: execution.ScheduleID}
+	if err := s.jobHookService.RunDetachedHooks(context.Background(), database.HookTypePostJob, hookCtx, owner); err != nil {
+		log.WithError(err).WithField("execution_id", execution.ID).Warn("⚠️ Schedule post_job hook failed")
+	}
+}
+
+// queueRetryExecution queues a new attempt for the failed VMs of an execution
+func (s *SchedulerService) queueRetryExecution(schedule *database.ReplicationSchedule, execution *database.ScheduleExecution, failedVMs map[string]bool) error {
+	details, _ := json.Marshal(map[string]interface{}{
+		"retry_vm_context_ids": sortedKeys(failedVMs),
+	})
+	detailsStr := string(details)
+
+	dueAt := time.Now().Add(time.Duration(schedule.RetryDelayMinutes) * time.Minute)
+	retry := &database.ScheduleExecution{
+		ID:                uuid.New().String(),
+		ScheduleID:        schedule.ID,
+		GroupID:           execution.GroupID,
+		ScheduledAt:       dueAt,
+		DeferredUntil:     &dueAt,
+		Status:            "scheduled",
+		Attempt:           execution.Attempt + 1,
+		ParentExecutionID: &execution.ID,
+		VMsEligible:       len(failedVMs),
+		ExecutionDetails:  &detailsStr,
+		TriggeredBy:       scheduleTriggerRetry,
+	}
+	if err := s.repository.CreateScheduleExecution(retry); err != nil {
+		return fmt.Errorf("failed to queue retry of execution %s: %w", execution.ID, err)
+	}
+
+	log.WithFields(log.Fields{
+		"schedule_id":   schedule.ID,
+		"execution_id":  retry.ID,
+		"retry_of":      execution.ID,
+		"attempt":       retry.Attempt,
+		"max_retries":   schedule.RetryAttempts,
+		"failed_vms":    len(failedVMs),
+		"scheduled_at":  retry.ScheduledAt,
+		"delay_minutes": schedule.RetryDelayMinutes,
+	}).Info("🔁 Queued retry of failed VMs")
+
+	s.armDeferredExecution(retry)
+	return nil
+}
+
+// queueChainExecutions queues the chain schedules that follow a completed schedule
+func (s *SchedulerService) queueChainExecutions(schedule *database.ReplicationSchedule, execution *database.ScheduleExecution) error {
+	children, err := s.repository.GetChainChildSchedules(schedule.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		dueAt := time.Now().Add(time.Duration(child.ChainDelayMinutes) * time.Minute)
+		chained := &database.ScheduleExecution{
+			ID:                uuid.New().String(),
+			ScheduleID:        child.ID,
+			ScheduledAt:       dueAt,
+			DeferredUntil:     &dueAt,
+			Status:            "scheduled",
+			Attempt:           1,
+			ParentExecutionID: &execution.ID,
+			TriggeredBy:       scheduleTriggerChain,
+		}
+		if err := s.repository.CreateScheduleExecution(chained); err != nil {
+			log.WithError(err).WithField("schedule_id", child.ID).Error("Failed to queue chained schedule execution")
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"schedule_id":      child.ID,
+			"parent_schedule":  schedule.ID,
+			"parent_execution": execution.ID,
+			"execution_id":     chained.ID,
+			"scheduled_at":     chained.ScheduledAt,
+		}).Info("⛓️ Queued chained schedule execution")
+
+		s.armDeferredExecution(chained)
+	}
+
+	return nil
+}
+
+// armDeferredExecution starts a queued execution once it is due
+func (s *SchedulerService) armDeferredExecution(execution *database.ScheduleExecution) {
+	if execution.DeferredUntil == nil {
+		return
+	}
+	delay := time.Until(*execution.DeferredUntil)
+	if delay < 0 {
+		delay = 0
+	}
+
+	executionID := execution.ID
+	stop := s.stopChan
+	time.AfterFunc(delay, func() {
+		s.runDeferredExecution(executionID, stop)
+	})
+}
+
+// runDeferredExecution runs a queued retry or chained execution unless the
+// scheduler run that armed it has been stopped since
+func (s *SchedulerService) runDeferredExecution(executionID string, stop <-chan struct{}) {
+	select {
+	case <-stop:
+		// Service stopped - the execution stays queued for the next start
+		return
+	default:
+	}
+
+	execution, err := s.repository.GetScheduleExecution(executionID)
+	if err != nil {
+		log.WithError(err).WithField("execution_id", executionID).Error("Failed to load queued schedule execution")
+		return
+	}
+	if execution.Status != "scheduled" || execution.DeferredUntil == nil {
+		return
+	}
+
+	s.executeSchedule(context.Background(), execution.ScheduleID, execution)
+}
+
+// settleDeferredExecution closes a queued execution that could not be run
+func (s *SchedulerService) settleDeferredExecution(execution *database.ScheduleExecution, status, reason string) {
+	if execution == nil {
+		return
+	}
+
+	now := time.Now()
+	if err := s.repository.UpdateScheduleExecution(execution.ID, map[string]interface{}{
+		"status":               status,
+		"error_message":        reason,
+		"completed_at":         now,
+		"outcome_evaluated_at": now,
+	}); err != nil {
+		log.WithError(err).WithField("execution_id", execution.ID).Error("Failed to settle queued schedule execution")
+	}
+}
+
+// recoverScheduleFollowUps re-arms queued executions and resumes outcome tracking
+// for executions dispatched before the scheduler was restarted
+func (s *SchedulerService) recoverScheduleFollowUps(ctx context.Context) error {
+	logger := s.jobTracker.Logger(ctx)
+
+	deferred, err := s.repository.ListDeferredScheduleExecutions()
+	if err != nil {
+		return err
+	}
+	for i := range deferred {
+		s.armDeferredExecution(&deferred[i])
+	}
+
+	unevaluated, err := s.repository.ListUnevaluatedScheduleExecutions(time.Now().Add(-scheduleOutcomeTimeout))
+	if err != nil {
+		return err
+	}
+	for _, execution := range unevaluated {
+		go s.awaitExecutionOutcome(execution.ID)
+	}
+
+	logger.Info("Recovered schedule follow-ups",
+		"queued_executions", len(deferred),
+		"awaiting_outcome", len(unevaluated))
+	return nil
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// scheduleSyncInterval is how often the leader looks for schedules edited through
+// another SHA instance (whose own scheduler is not running)
+const scheduleSyncInterval = 30 * time.Second
+
+// RunAsLeader runs the scheduler while this SHA instance holds leadership: it starts
+// the scheduler, picks up schedule edits made on standby instances and stops once ctx
+// is cancelled
+func (s *SchedulerService) RunAsLeader(ctx context.Context) {
+	ticker := time.NewTicker(scheduleSyncInterval)
+	defer ticker.Stop()
+
+	// Keep trying - the lease is held, so no other instance will schedule meanwhile
+	for {
+		err := s.Start(ctx)
+		if err == nil {
+			break
+		}
+		log.WithError(err).Error("Failed to start scheduler service as leader - retrying")
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+	log.Info("✅ Scheduler service started - automatic jobs will now trigger at scheduled times")
+
+	marker, err := s.repository.GetScheduleChangeMarker()
+	if err != nil {
+		log.WithError(err).Warn("Failed to read schedule change marker")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := s.Stop(context.Background()); err != nil {
+				log.WithError(err).Warn("Failed to stop scheduler service")
+			}
+			return
+		case <-ticker.C:
+		}
+
+		current, err := s.repository.GetScheduleChangeMarker()
+		if err != nil {
+			log.WithError(err).Warn("Failed to read schedule change marker")
+			continue
+		}
+		if current == marker {
+			continue
+		}
+		marker = current
+
+		if err := s.ReloadSchedules(context.Background()); err != nil {
+			log.WithError(err).Warn("Failed to reload changed schedules")
+		}
+	}
+}
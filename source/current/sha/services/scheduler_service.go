@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/robfig/cron/v3"
 	log "github.com/sirupsen/logrus"
 
@@ -44,6 +45,19 @@ type SchedulerService struct {
 	flowService       *ProtectionFlowService
 	activeFlowSchedules map[string]cron.EntryID
 
+	// 🆕 NEW: Pre/post job hooks for schedule executions
+	jobHookService *JobHookService
+
+	// 🆕 NEW: Backup windows and blackout periods (optional)
+	windowService *BackupWindowService
+	deferredFlows map[string]bool // Flow IDs waiting for their window to open
+
+	// 🆕 NEW: Admission control per datastore, ESXi host and SNA (optional)
+	admission *AdmissionController
+
+	// 🆕 NEW: Routes SNA calls to the SNA serving each VM's placement (optional)
+	snaRouter *SNARouter
+
 	// Concurrent execution tracking
 	runningMutex    sync.RWMutex
 	activeSchedules map[string]*ScheduleContext
@@ -82,6 +96,7 @@ type ExecutionSummary struct {
 	JobsSkipped         int                    `json:"jobs_skipped"`
 	VMContextsProcessed []string               `json:"vm_contexts_processed"` // context_ids
 	CreatedJobIDs       []string               `json:"created_job_ids"`
+	FailedVMContexts    []string               `json:"failed_vm_contexts,omitempty"` // context_ids whose job could not be created
 	ExecutionTime       time.Duration          `json:"execution_time"`
 	Summary             map[string]interface{} `json:"summary,omitempty"`
 	ErrorMessage        *string                `json:"error_message,omitempty"`
@@ -225,6 +240,7 @@ func NewSchedulerService(
 
 		activeSchedules: make(map[string]*ScheduleContext),
 		activeFlowSchedules: make(map[string]cron.EntryID), // Track flow schedule registrations
+		deferredFlows:       make(map[string]bool),
 		maxConcurrent:   10, // Maximum concurrent schedule executions
 		stopChan:        make(chan struct{}),
 	}
@@ -241,6 +257,9 @@ func (s *SchedulerService) Start(ctx context.Context) error {
 
 	log.Info("🚀 Starting scheduler service")
 
+	// Fresh stop signal - the service may be started again after Stop (leader failover)
+	s.stopChan = make(chan struct{})
+
 	// Initialize job tracker for scheduler operations
 	ctx, jobID, err := s.jobTracker.StartJob(ctx, joblog.JobStart{
 		JobType:   "scheduler",
@@ -290,6 +309,15 @@ func (s *SchedulerService) Start(ctx context.Context) error {
 		return err
 	}
 
+	// Re-arm retries and chained executions queued before a restart
+	err = s.jobTracker.RunStep(ctx, jobID, "recover-follow-ups", func(ctx context.Context) error {
+		return s.recoverScheduleFollowUps(ctx)
+	})
+	if err != nil {
+		// Not fatal - cron schedules keep running
+		s.jobTracker.Logger(ctx).Warn("Failed to recover schedule retries and chains", "error", err)
+	}
+
 	s.jobTracker.EndJob(ctx, jobID, joblog.StatusCompleted, nil)
 
 	log.WithField("active_schedules", len(s.activeSchedules)).Info("✅ Scheduler service started successfully")
@@ -299,9 +327,9 @@ func (s *SchedulerService) Start(ctx context.Context) error {
 // Stop gracefully stops the scheduler service
 func (s *SchedulerService) Stop(ctx context.Context) error {
 	s.runningMutex.Lock()
-	defer s.runningMutex.Unlock()
 
 	if !s.isRunning {
+		s.runningMutex.Unlock()
 		return fmt.Errorf("scheduler service not running")
 	}
 
@@ -309,9 +337,17 @@ func (s *SchedulerService) Stop(ctx context.Context) error {
 
 	// Signal stop and wait for current executions to complete
 	close(s.stopChan)
+	s.isRunning = false
 
-	// Stop cron scheduler
+	// Stop cron scheduler and drop schedule entries - Start registers them again
 	cronCtx := s.cron.Stop()
+	for _, scheduleCtx := range s.activeSchedules {
+		s.cron.Remove(scheduleCtx.CronEntryID)
+	}
+	s.activeSchedules = make(map[string]*ScheduleContext)
+
+	// Executions take the lock to finish, so wait for them without holding it
+	s.runningMutex.Unlock()
 
 	// Wait for current executions with timeout
 	select {
@@ -345,33 +381,55 @@ func (s *SchedulerService) Stop(ctx context.Context) error {
 	}
 
 stopComplete:
-	s.isRunning = false
-	s.activeSchedules = make(map[string]*ScheduleContext)
-
 	log.Info("✅ Scheduler service stopped")
 	return nil
 }
 
 // registerSchedule adds a schedule to the cron scheduler
 func (s *SchedulerService) registerSchedule(schedule *database.ReplicationSchedule) error {
-	if schedule.ScheduleType != "cron" {
-		return fmt.Errorf("only cron schedules supported currently, got: %s", schedule.ScheduleType)
+	switch schedule.ScheduleType {
+	case "cron":
+	case "chain":
+		// Chain schedules have no cron entry - they fire when their parent's execution completes
+		if schedule.ChainParentScheduleID == nil || *schedule.ChainParentScheduleID == "" {
+			return fmt.Errorf("chain schedule %s has no chain_parent_schedule_id", schedule.ID)
+		}
+		s.activeSchedules[schedule.ID] = &ScheduleContext{
+			ScheduleID: schedule.ID,
+			Schedule:   schedule,
+		}
+
+		log.WithFields(log.Fields{
+			"schedule_id":     schedule.ID,
+			"schedule_name":   schedule.Name,
+			"parent_schedule": *schedule.ChainParentScheduleID,
+			"delay_minutes":   schedule.ChainDelayMinutes,
+		}).Info("Registered chain schedule")
+		return nil
+	default:
+		return fmt.Errorf("unsupported schedule type: %s", schedule.ScheduleType)
+	}
+
+	cronSpec, err := scheduleCronSpec(schedule)
+	if err != nil {
+		return err
 	}
 
 	log.WithFields(log.Fields{
 		"schedule_id":   schedule.ID,
 		"schedule_name": schedule.Name,
 		"cron_expr":     schedule.CronExpression,
+		"timezone":      schedule.Timezone,
 	}).Info("Registering schedule")
 
 	// Create execution function with context_id operations
 	executionFunc := func() {
 		ctx := context.Background()
-		s.executeSchedule(ctx, schedule.ID)
+		s.executeSchedule(ctx, schedule.ID, nil)
 	}
 
-	// Add to cron with timezone support
-	entryID, err := s.cron.AddFunc(schedule.CronExpression, executionFunc)
+	// Add to cron in the schedule's timezone (CRON_TZ prefix keeps DST transitions correct)
+	entryID, err := s.cron.AddFunc(cronSpec, executionFunc)
 	if err != nil {
 		return fmt.Errorf("failed to add cron job for schedule %s: %w", schedule.ID, err)
 	}
@@ -447,6 +505,11 @@ func (s *SchedulerService) ReloadSchedules(ctx context.Context) error {
 			s.cron.Remove(scheduleCtx.CronEntryID)
 			delete(s.activeSchedules, scheduleID)
 			removed++
+		} else if scheduleTimingChanged(scheduleCtx.Schedule, dbScheduleMap[scheduleID]) {
+			// Cron expression, timezone or type edited - re-register below with the new timing
+			logger.Info("Re-registering schedule with changed timing", "schedule_id", scheduleID)
+			s.cron.Remove(scheduleCtx.CronEntryID)
+			delete(s.activeSchedules, scheduleID)
 		} else {
 			kept++
 		}
@@ -498,15 +561,23 @@ func (s *SchedulerService) unregisterSchedule(scheduleID string) error {
 }
 
 // executeSchedule runs a scheduled execution using VM context_ids
-func (s *SchedulerService) executeSchedule(ctx context.Context, scheduleID string) {
+// deferred is a queued retry/chain execution record to run, or nil for a new cron run
+func (s *SchedulerService) executeSchedule(ctx context.Context, scheduleID string, deferred *database.ScheduleExecution) {
 	// ✅ EARLY ENABLED CHECK - Prevent job tracker creation for disabled schedules
 	schedule, err := s.repository.GetScheduleByID(scheduleID)
 	if err != nil {
 		log.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to get schedule for execution")
+		s.settleDeferredExecution(deferred, "failed", err.Error())
 		return
 	}
 	if schedule == nil || !schedule.Enabled {
 		log.WithField("schedule_id", scheduleID).Warn("⚠️ Schedule is disabled or not found, skipping execution")
+		s.settleDeferredExecution(deferred, "skipped", "schedule is disabled or not found")
+		return
+	}
+
+	// Backup windows and blackouts - defer or skip runs that cannot start now
+	if !s.insideBackupWindow(ctx, schedule, deferred) {
 		return
 	}
 
@@ -515,6 +586,7 @@ func (s *SchedulerService) executeSchedule(ctx context.Context, scheduleID strin
 	if s.runningCount >= s.maxConcurrent {
 		s.runningMutex.Unlock()
 		log.WithField("schedule_id", scheduleID).Warn("⚠️ Max concurrent executions reached, skipping")
+		s.settleDeferredExecution(deferred, "skipped", "max concurrent schedule executions reached")
 		return
 	}
 	s.runningCount++
@@ -537,16 +609,35 @@ func (s *SchedulerService) executeSchedule(ctx context.Context, scheduleID strin
 	})
 	if err != nil {
 		log.WithError(err).WithField("schedule_id", scheduleID).Error("Failed to start execution job tracking")
+		s.settleDeferredExecution(deferred, "failed", err.Error())
 		return
 	}
 
 	logger := s.jobTracker.Logger(ctx)
 	logger.Info("🎯 Starting scheduled execution", "schedule_id", scheduleID)
 
+	// Run pre_job hooks attached to the schedule
+	hookOwner := HookOwner{Type: database.HookOwnerSchedule, ID: scheduleID}
+	hookCtx := HookContext{
+		JobID:      executionJobID,
+		JobType:    "replication",
+		Operation:  "schedule-execution",
+		ScheduleID: scheduleID,
+	}
+	if s.jobHookService != nil {
+		if err := s.jobHookService.RunHooks(ctx, executionJobID, database.HookTypePreJob, hookCtx, hookOwner); err != nil {
+			logger.Error("❌ Schedule pre_job hook failed - skipping execution", "error", err)
+			s.settleDeferredExecution(deferred, "failed", err.Error())
+			s.jobTracker.EndJob(ctx, executionJobID, joblog.StatusFailed, err)
+			return
+		}
+	}
+
 	// Execute the schedule
-	summary, err := s.runScheduleExecution(ctx, scheduleID)
+	summary, err := s.runExecution(ctx, scheduleID, deferred, true)
 	if err != nil {
 		logger.Error("❌ Schedule execution failed", "error", err)
+		s.runSchedulePostJobHooks(ctx, executionJobID, hookCtx, hookOwner, "failed", err)
 		s.jobTracker.EndJob(ctx, executionJobID, joblog.StatusFailed, err)
 		return
 	}
@@ -559,11 +650,52 @@ func (s *SchedulerService) executeSchedule(ctx context.Context, scheduleID strin
 		"execution_time", summary.ExecutionTime,
 	)
 
+	// post_job hooks run from awaitExecutionOutcome once the dispatched jobs have finished
+
 	s.jobTracker.EndJob(ctx, executionJobID, joblog.StatusCompleted, nil)
 }
 
+// SetJobHookService sets the job hook service for schedule pre/post job hooks
+func (s *SchedulerService) SetJobHookService(jobHookService *JobHookService) {
+	s.jobHookService = jobHookService
+}
+
+// SetAdmissionController sets the admission controller that paces scheduled replications
+func (s *SchedulerService) SetAdmissionController(admission *AdmissionController) {
+	s.admission = admission
+}
+
+// SetSNARouter sets the router that picks the SNA for each VM's calls
+func (s *SchedulerService) SetSNARouter(router *SNARouter) {
+	s.snaRouter = router
+	s.phantomDetector.SetSNARouter(router)
+}
+
+// runSchedulePostJobHooks runs post_job hooks for a schedule execution; failures are logged only
+func (s *SchedulerService) runSchedulePostJobHooks(ctx context.Context, jobID string, hookCtx HookContext, owner HookOwner, status string, execErr error) {
+	if s.jobHookService == nil {
+		return
+	}
+
+	hookCtx.Status = status
+	if execErr != nil {
+		hookCtx.ErrorMessage = execErr.Error()
+	}
+
+	if err := s.jobHookService.RunHooks(ctx, jobID, database.HookTypePostJob, hookCtx, owner); err != nil {
+		s.jobTracker.Logger(ctx).Warn("⚠️ Schedule post_job hook failed", "error", err)
+	}
+}
+
 // runScheduleExecution performs the actual schedule execution logic
 func (s *SchedulerService) runScheduleExecution(ctx context.Context, scheduleID string) (*ExecutionSummary, error) {
+	return s.runExecution(ctx, scheduleID, nil, false)
+}
+
+// runExecution runs a schedule, either as a new execution or as a queued retry/chain execution
+// Retries only process the VMs that failed in the previous attempt
+// windowChecked is set when insideBackupWindow has already allowed the run
+func (s *SchedulerService) runExecution(ctx context.Context, scheduleID string, deferred *database.ScheduleExecution, windowChecked bool) (*ExecutionSummary, error) {
 	startTime := time.Now()
 
 	// Get schedule details
@@ -582,17 +714,40 @@ func (s *SchedulerService) runScheduleExecution(ctx context.Context, scheduleID
 		"groups_count", len(schedule.Groups),
 	)
 
-	// Create schedule execution record
-	execution := &database.ScheduleExecution{
-		ScheduleID:  scheduleID,
-		ScheduledAt: startTime,
-		StartedAt:   &startTime,
-		Status:      "running",
-		TriggeredBy: "scheduler-service",
+	// Create schedule execution record (queued retries/chains already have one)
+	execution := deferred
+	if execution == nil {
+		execution = &database.ScheduleExecution{
+			ID:          uuid.New().String(),
+			ScheduleID:  scheduleID,
+			ScheduledAt: startTime,
+			StartedAt:   &startTime,
+			Status:      "running",
+			Attempt:     1,
+			TriggeredBy: "scheduler-service",
+		}
+
+		if err := s.repository.CreateScheduleExecution(execution); err != nil {
+			return nil, fmt.Errorf("failed to create execution record: %w", err)
+		}
+	} else {
+		execution.StartedAt = &startTime
+		execution.Status = "running"
+		if err := s.repository.UpdateScheduleExecution(execution.ID, map[string]interface{}{
+			"status":     "running",
+			"started_at": startTime,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to start queued execution %s: %w", execution.ID, err)
+		}
 	}
 
-	if err := s.repository.CreateScheduleExecution(execution); err != nil {
-		return nil, fmt.Errorf("failed to create execution record: %w", err)
+	retryVMs := retryVMContextIDs(execution)
+	if retryVMs != nil {
+		logger.Info("Retrying failed VMs",
+			"execution_id", execution.ID,
+			"attempt", execution.Attempt,
+			"vm_count", len(retryVMs),
+		)
 	}
 
 	summary := &ExecutionSummary{
@@ -610,10 +765,17 @@ func (s *SchedulerService) runScheduleExecution(ctx context.Context, scheduleID
 		CreatedJobIDs:       make([]string, 0),
 		Summary:             make(map[string]interface{}),
 	}
+	summary.Summary["attempt"] = execution.Attempt
+	if execution.ParentExecutionID != nil {
+		summary.Summary["parent_execution_id"] = *execution.ParentExecutionID
+	}
+	if retryVMs != nil {
+		summary.Summary["retry_vm_context_ids"] = sortedKeys(retryVMs)
+	}
 
 	// Process each group in the schedule
 	for _, group := range schedule.Groups {
-		groupSummary, err := s.executeGroup(ctx, execution, &group, schedule)
+		groupSummary, err := s.executeGroup(ctx, execution, &group, schedule, retryVMs, windowChecked)
 		if err != nil {
 			logger.Error("Group execution failed", "error", err, "group_id", group.ID)
 			continue
@@ -628,6 +790,7 @@ func (s *SchedulerService) runScheduleExecution(ctx context.Context, scheduleID
 		summary.JobsSkipped += groupSummary.JobsSkipped
 		summary.VMContextsProcessed = append(summary.VMContextsProcessed, groupSummary.VMContextsProcessed...)
 		summary.CreatedJobIDs = append(summary.CreatedJobIDs, groupSummary.CreatedJobIDs...)
+		summary.FailedVMContexts = append(summary.FailedVMContexts, groupSummary.FailedVMContexts...)
 	}
 
 	// Complete execution
@@ -653,11 +816,15 @@ func (s *SchedulerService) runScheduleExecution(ctx context.Context, scheduleID
 		logger.Error("Failed to update execution record", "error", err)
 	}
 
+	// Retries and chained schedules depend on how the dispatched jobs end
+	go s.awaitExecutionOutcome(execution.ID)
+
 	return summary, nil
 }
 
 // executeGroup processes all VMs in a group using context_id
-func (s *SchedulerService) executeGroup(ctx context.Context, execution *database.ScheduleExecution, group *database.VMMachineGroup, schedule *database.ReplicationSchedule) (*ExecutionSummary, error) {
+// retryVMs limits processing to the given context_ids (nil processes every member)
+func (s *SchedulerService) executeGroup(ctx context.Context, execution *database.ScheduleExecution, group *database.VMMachineGroup, schedule *database.ReplicationSchedule, retryVMs map[string]bool, windowChecked bool) (*ExecutionSummary, error) {
 	logger := s.jobTracker.Logger(ctx)
 	logger.Info("Processing machine group",
 		"group_id", group.ID,
@@ -671,6 +838,16 @@ func (s *SchedulerService) executeGroup(ctx context.Context, execution *database
 		return nil, fmt.Errorf("failed to get group memberships: %w", err)
 	}
 
+	if retryVMs != nil {
+		retryMemberships := make([]database.VMGroupMembership, 0, len(memberships))
+		for _, membership := range memberships {
+			if retryVMs[membership.VMContextID] {
+				retryMemberships = append(retryMemberships, membership)
+			}
+		}
+		memberships = retryMemberships
+	}
+
 	summary := &ExecutionSummary{
 		ExecutionID:         execution.ID,
 		ScheduleID:          execution.ScheduleID,
@@ -708,6 +885,7 @@ func (s *SchedulerService) executeGroup(ctx context.Context, execution *database
 		SkipIfRunning:     schedule.SkipIfRunning,
 		MaxConcurrentJobs: schedule.MaxConcurrentJobs,
 		Enabled:           schedule.Enabled,
+		WindowChecked:     windowChecked,
 	}
 
 	groupConstraints := &GroupConstraints{
@@ -730,6 +908,7 @@ func (s *SchedulerService) executeGroup(ctx context.Context, execution *database
 	}
 
 	// Process VMs based on conflict detection results
+	schedulable := make([]*database.VMReplicationContext, 0, len(vmContexts))
 	for i, vmCtx := range vmContexts {
 		membership := membershipMap[vmCtx.ContextID]
 
@@ -763,8 +942,29 @@ func (s *SchedulerService) executeGroup(ctx context.Context, execution *database
 			continue
 		}
 
+		schedulable = append(schedulable, vmCtx)
+	}
+
+	// 🆕 NEW: Start jobs as datastore, host and SNA capacity allows (admission control)
+	requests := make([]AdmissionRequest, 0, len(schedulable))
+	vmByContext := make(map[string]*database.VMReplicationContext, len(schedulable))
+	for _, vmCtx := range schedulable {
+		requests = append(requests, s.admission.BuildRequest(ctx, "replication", vmCtx.ContextID, "schedule:"+schedule.ID))
+		vmByContext[vmCtx.ContextID] = vmCtx
+	}
+
+	dispatchCtx, cancelDispatch := context.WithCancel(ctx)
+	defer cancelDispatch()
+	tickets := s.admission.Admit(dispatchCtx, requests)
+	dispatched := make(map[string]bool, len(schedulable))
+
+	for ticket := range tickets {
+		vmCtx := vmByContext[ticket.Request.VMContextID]
+		dispatched[vmCtx.ContextID] = true
+
 		// Create replication job for this VM
 		jobID, err := s.createReplicationJob(ctx, execution, group, vmCtx, schedule)
+		s.admission.Release(ticket.ID)
 		if err != nil {
 			logger.Error("Failed to create replication job",
 				"error", err,
@@ -772,6 +972,7 @@ func (s *SchedulerService) executeGroup(ctx context.Context, execution *database
 				"vm_name", vmCtx.VMName,
 			)
 			summary.JobsFailed++
+			summary.FailedVMContexts = append(summary.FailedVMContexts, vmCtx.ContextID)
 		} else {
 			logger.Info("Successfully created replication job",
 				"vm_context_id", vmCtx.ContextID,
@@ -787,10 +988,23 @@ func (s *SchedulerService) executeGroup(ctx context.Context, execution *database
 		// Respect group concurrency limits
 		if summary.JobsCreated >= group.MaxConcurrentVMs {
 			logger.Info("Reached group concurrency limit", "max_concurrent", group.MaxConcurrentVMs)
+			cancelDispatch()
+			s.admission.Discard(tickets)
 			break
 		}
 	}
 
+	// VMs that never got admission (or were cut off by the group limit) are skipped this run
+	for _, vmCtx := range schedulable {
+		if dispatched[vmCtx.ContextID] {
+			continue
+		}
+		logger.Info("Skipping VM - not admitted", "vm_context_id", vmCtx.ContextID, "vm_name", vmCtx.VMName)
+		summary.JobsSkipped++
+		summary.VMsProcessed++
+		summary.VMContextsProcessed = append(summary.VMContextsProcessed, vmCtx.ContextID)
+	}
+
 	logger.Info("Completed group processing",
 		"group_id", group.ID,
 		"vms_eligible", summary.VMsEligible,
@@ -1130,8 +1344,12 @@ func (s *SchedulerService) discoverVMFromVMA(ctx context.Context, vmName, vCente
 		return nil, fmt.Errorf("failed to marshal discovery request: %w", err)
 	}
 
-	// Call SNA discovery API (same endpoint as GUI)
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", s.snaAPIEndpoint+"/api/v1/discover", bytes.NewBuffer(jsonData))
+	// Call SNA discovery API (same endpoint as GUI) on the SNA serving the vCenter
+	snaEndpoint, err := s.snaRouter.EndpointForScope(ctx, SNAPlacement{VCenterHost: vCenterHost, Datacenter: datacenter}, s.snaAPIEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to route discovery to SNA: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", snaEndpoint+"/api/v1/discover", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create discovery request: %w", err)
 	}
@@ -1142,7 +1360,7 @@ func (s *SchedulerService) discoverVMFromVMA(ctx context.Context, vmName, vCente
 		"vm_name", vmName,
 		"vcenter_host", vCenterHost,
 		"datacenter", datacenter,
-		"endpoint", s.snaAPIEndpoint+"/api/v1/discover")
+		"endpoint", snaEndpoint+"/api/v1/discover")
 
 	resp, err := s.snaClient.Do(httpReq)
 	if err != nil {
@@ -1249,8 +1467,14 @@ func (s *SchedulerService) RegisterFlowSchedule(flowID string, scheduleID string
 		return fmt.Errorf("failed to get schedule: %w", err)
 	}
 
+	cronSpec, err := scheduleCronSpec(schedule)
+	if err != nil {
+		logger.Error("Invalid schedule timing", "error", err)
+		return err
+	}
+
 	// Add cron job that executes the flow
-	entryID, err := s.cron.AddFunc(schedule.CronExpression, func() {
+	entryID, err := s.cron.AddFunc(cronSpec, func() {
 		ctx := context.Background()
 		s.ExecuteScheduledFlow(ctx, flowID, scheduleID)
 	})
@@ -1284,17 +1508,25 @@ func (s *SchedulerService) ExecuteScheduledFlow(ctx context.Context, flowID stri
 		return
 	}
 
-	// Execute the flow
-	execution, err := s.flowService.ExecuteFlow(ctx, flowID, "scheduled")
+	// Backup windows and blackouts - defer or skip runs that cannot start now
+	if !s.flowInsideBackupWindow(ctx, flowID, scheduleID) {
+		return
+	}
+
+	// Queue the flow execution - the job queue runs it and survives SHA restarts
+	queued, created, err := s.flowService.QueueFlowExecution(ctx, flowID, "scheduled", "schedule:"+scheduleID)
 	if err != nil {
-		logger.Error("Flow execution failed", "error", err)
+		logger.Error("Failed to queue flow execution", "error", err)
+		return
+	}
+	if !created {
+		logger.Info("Flow execution already queued or running - skipping this run",
+			"queue_job_id", queued.ID,
+			"state", queued.State)
 		return
 	}
 
-	logger.Info("Scheduled flow execution completed",
-		"execution_id", execution.ID,
-		"status", execution.Status,
-		"jobs_created", execution.JobsCreated)
+	logger.Info("Scheduled flow execution queued", "queue_job_id", queued.ID)
 }
 
 // UnregisterFlowSchedule removes a flow from scheduled execution
@@ -46,7 +46,7 @@ func (sdvis *SimpleDatabaseVMInfoService) ValidateVMExists(vmID string) error {
 		}
 
 		if highProgressJobCount == 0 {
-			return fmt.Errorf("VM %s has no completed migration - failover requires completed replication job or 95%+ progress", vmID)
+			return fmt.Errorf("VM %s has no completed migration - failover requires completed replication job or 95%%+ progress", vmID)
 		}
 
 		log.WithFields(log.Fields{
@@ -249,7 +249,7 @@ func (ves *SNAEnrollmentService) ApproveEnrollment(enrollmentID string, approved
 func (ves *SNAEnrollmentService) activateSSHAccess(enrollment *models.SNAEnrollment) error {
 	// Add SNA SSH key to authorized_keys with security restrictions
 	if ves.sshManager != nil {
-		if err := ves.sshManager.AddVMAKey(enrollment.SNAPublicKey, *enrollment.SNAFingerprint); err != nil {
+		if err := ves.sshManager.AddVMAKey(enrollment.SNAPublicKey, *enrollment.SNAFingerprint, 0); err != nil {
 			return fmt.Errorf("failed to add SNA SSH key: %w", err)
 		}
 	}
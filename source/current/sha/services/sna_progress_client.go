@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,6 +15,7 @@ import (
 // SNAProgressClient handles communication with SNA v1.5.0 progress tracking API
 type SNAProgressClient struct {
 	baseURL    string
+	snaRouter  *SNARouter // Optional: reaches the SNA each job was routed to
 	httpClient *http.Client
 }
 
@@ -90,9 +92,19 @@ func NewVMAProgressClient(baseURL string) *SNAProgressClient {
 	}
 }
 
+// SetSNARouter sets the router used to reach the SNA a job runs on
+func (vpc *SNAProgressClient) SetSNARouter(router *SNARouter) {
+	vpc.snaRouter = router
+}
+
+// jobURL returns the base URL of the SNA a job was routed to
+func (vpc *SNAProgressClient) jobURL(jobID string) string {
+	return vpc.snaRouter.EndpointForJob(context.Background(), jobID, vpc.baseURL)
+}
+
 // GetProgress retrieves progress information for a specific job
 func (vpc *SNAProgressClient) GetProgress(jobID string) (*SNAProgressResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/progress/%s", vpc.baseURL, jobID)
+	url := fmt.Sprintf("%s/api/v1/progress/%s", vpc.jobURL(jobID), jobID)
 
 	log.WithFields(log.Fields{
 		"job_id": jobID,
@@ -143,7 +155,7 @@ func (vpc *SNAProgressClient) GetProgress(jobID string) (*SNAProgressResponse, e
 
 // GetBasicStatus retrieves basic job status (backward compatible endpoint)
 func (vpc *SNAProgressClient) GetBasicStatus(jobID string) (map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/api/v1/status/%s", vpc.baseURL, jobID)
+	url := fmt.Sprintf("%s/api/v1/status/%s", vpc.jobURL(jobID), jobID)
 
 	log.WithFields(log.Fields{
 		"job_id": jobID,
@@ -225,7 +237,7 @@ type SNAProgressUpdate struct {
 
 // UpdateProgress sends progress update to SNA v1.5.0 progress API
 func (vpc *SNAProgressClient) UpdateProgress(jobID string, update SNAProgressUpdate) error {
-	url := fmt.Sprintf("%s/api/v1/progress/%s/update", vpc.baseURL, jobID)
+	url := fmt.Sprintf("%s/api/v1/progress/%s/update", vpc.jobURL(jobID), jobID)
 
 	updateJSON, err := json.Marshal(update)
 	if err != nil {
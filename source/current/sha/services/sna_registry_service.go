@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+)
+
+// SNARegistryService manages enrolled SNAs, their scopes and capacity
+type SNARegistryService struct {
+	registry *database.SNARegistryRepository
+}
+
+// NewSNARegistryService creates a new SNA registry service
+func NewSNARegistryService(db database.Connection) *SNARegistryService {
+	return &SNARegistryService{
+		registry: database.NewSNARegistryRepository(db),
+	}
+}
+
+// SNARegistration is what an SNA reports about itself on startup and as a heartbeat
+type SNARegistration struct {
+	EnrollmentID      string              `json:"enrollment_id,omitempty"`
+	TunnelPort        int                 `json:"tunnel_port,omitempty"` // 0 = pre-enrollment tunnel (9081)
+	Name              string              `json:"name"`
+	Version           string              `json:"version,omitempty"`
+	MaxConcurrentJobs int                 `json:"max_concurrent_jobs,omitempty"`
+	Scopes            []database.SNAScope `json:"scopes,omitempty"` // Replaces the stored scopes when set
+}
+
+// SNAUpdate changes the settings of a registered SNA (nil fields are left unchanged)
+type SNAUpdate struct {
+	State             *string              `json:"state,omitempty"`
+	MaxConcurrentJobs *int                 `json:"max_concurrent_jobs,omitempty"`
+	Scopes            *[]database.SNAScope `json:"scopes,omitempty"`
+}
+
+// SNAStatus is a registered SNA with its current load
+type SNAStatus struct {
+	*database.SNAAppliance
+	Endpoint   string `json:"endpoint"`
+	Online     bool   `json:"online"`
+	ActiveJobs int    `json:"active_jobs"`
+}
+
+// validateScopes checks and normalizes SNA scopes
+func validateScopes(scopes []database.SNAScope) ([]database.SNAScope, error) {
+	normalized := make([]database.SNAScope, 0, len(scopes))
+	for _, scope := range scopes {
+		scope.VCenterHost = strings.TrimSpace(scope.VCenterHost)
+		scope.Datacenter = strings.TrimSpace(scope.Datacenter)
+		scope.Cluster = strings.TrimSpace(scope.Cluster)
+		if scope.VCenterHost == "" {
+			return nil, fmt.Errorf("scope vcenter_host is required")
+		}
+		if scope.Cluster != "" && scope.Datacenter == "" {
+			return nil, fmt.Errorf("scope cluster %s needs a datacenter", scope.Cluster)
+		}
+		normalized = append(normalized, scope)
+	}
+	return normalized, nil
+}
+
+// Register records an SNA's identity, capacity and scopes, creating it on first contact
+func (s *SNARegistryService) Register(ctx context.Context, reg SNARegistration) (*database.SNAAppliance, error) {
+	if reg.Name == "" {
+		return nil, fmt.Errorf("invalid registration: name is required")
+	}
+	if reg.MaxConcurrentJobs < 0 {
+		return nil, fmt.Errorf("invalid registration: max_concurrent_jobs must not be negative")
+	}
+	scopes, err := validateScopes(reg.Scopes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registration: %w", err)
+	}
+	if reg.TunnelPort == 0 {
+		reg.TunnelPort = database.SNATunnelPortFirst
+	}
+
+	// SNAs enrolled before the registry existed have no row for their enrollment
+	// and still use the original tunnel port, so fall back to matching by port
+	var appliance *database.SNAAppliance
+	if reg.EnrollmentID != "" {
+		appliance, err = s.registry.GetApplianceByEnrollment(ctx, reg.EnrollmentID)
+		if err != nil && !strings.Contains(err.Error(), "not found") {
+			return nil, err
+		}
+	}
+	if appliance == nil {
+		appliance, err = s.registry.GetApplianceByTunnelPort(ctx, reg.TunnelPort)
+		if err != nil && !strings.Contains(err.Error(), "not found") {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	if appliance == nil {
+		// SNA on the original tunnel that predates enrollment-based registration
+		appliance = &database.SNAAppliance{
+			Name:              reg.Name,
+			TunnelPort:        reg.TunnelPort,
+			State:             database.SNAStateActive,
+			MaxConcurrentJobs: 8,
+		}
+		if reg.MaxConcurrentJobs > 0 {
+			appliance.MaxConcurrentJobs = reg.MaxConcurrentJobs
+		}
+		if err := s.registry.CreateAppliance(ctx, appliance); err != nil {
+			return nil, err
+		}
+	}
+
+	updates := map[string]interface{}{
+		"name":              reg.Name,
+		"last_heartbeat_at": now,
+	}
+	if reg.Version != "" {
+		updates["version"] = reg.Version
+	}
+	if reg.MaxConcurrentJobs > 0 {
+		updates["max_concurrent_jobs"] = reg.MaxConcurrentJobs
+	}
+	if err := s.registry.UpdateAppliance(ctx, appliance.ID, updates); err != nil {
+		return nil, err
+	}
+	if len(scopes) > 0 {
+		if err := s.registry.ReplaceScopes(ctx, appliance.ID, scopes); err != nil {
+			return nil, err
+		}
+	}
+
+	if appliance.LastHeartbeatAt == nil || now.Sub(*appliance.LastHeartbeatAt) > snaHeartbeatTimeout {
+		log.WithFields(log.Fields{
+			"sna_id":      appliance.ID,
+			"name":        reg.Name,
+			"tunnel_port": appliance.TunnelPort,
+			"scopes":      len(scopes),
+		}).Info("🔗 SNA registered")
+	}
+
+	return s.registry.GetAppliance(ctx, appliance.ID)
+}
+
+// List returns every registered SNA with its load
+func (s *SNARegistryService) List(ctx context.Context) ([]*SNAStatus, error) {
+	appliances, err := s.registry.ListAppliances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	active, err := s.registry.CountActiveJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	statuses := make([]*SNAStatus, 0, len(appliances))
+	for _, appliance := range appliances {
+		statuses = append(statuses, s.status(appliance, active, now))
+	}
+	return statuses, nil
+}
+
+// Get returns a registered SNA with its load
+func (s *SNARegistryService) Get(ctx context.Context, id string) (*SNAStatus, error) {
+	appliance, err := s.registry.GetAppliance(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	active, err := s.registry.CountActiveJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.status(appliance, active, time.Now()), nil
+}
+
+// status builds the status view of an SNA
+func (s *SNARegistryService) status(appliance *database.SNAAppliance, active map[string]int, now time.Time) *SNAStatus {
+	return &SNAStatus{
+		SNAAppliance: appliance,
+		Endpoint:     SNAEndpoint(appliance.TunnelPort),
+		Online:       appliance.LastHeartbeatAt != nil && snaOnline(appliance, now),
+		ActiveJobs:   active[appliance.ID],
+	}
+}
+
+// Update changes the state, capacity or scopes of an SNA
+func (s *SNARegistryService) Update(ctx context.Context, id string, update SNAUpdate) (*SNAStatus, error) {
+	if _, err := s.registry.GetAppliance(ctx, id); err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	if update.State != nil {
+		switch *update.State {
+		case database.SNAStateActive, database.SNAStateDraining, database.SNAStateDisabled:
+			updates["state"] = *update.State
+		default:
+			return nil, fmt.Errorf("invalid SNA update: state must be active, draining or disabled")
+		}
+	}
+	if update.MaxConcurrentJobs != nil {
+		if *update.MaxConcurrentJobs < 1 {
+			return nil, fmt.Errorf("invalid SNA update: max_concurrent_jobs must be at least 1")
+		}
+		updates["max_concurrent_jobs"] = *update.MaxConcurrentJobs
+	}
+	var scopes []database.SNAScope
+	if update.Scopes != nil {
+		var err error
+		if scopes, err = validateScopes(*update.Scopes); err != nil {
+			return nil, fmt.Errorf("invalid SNA update: %w", err)
+		}
+	}
+
+	if len(updates) > 0 {
+		if err := s.registry.UpdateAppliance(ctx, id, updates); err != nil {
+			return nil, err
+		}
+	}
+	if update.Scopes != nil {
+		if err := s.registry.ReplaceScopes(ctx, id, scopes); err != nil {
+			return nil, err
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"sna_id":  id,
+		"updates": updates,
+		"scopes":  len(scopes),
+	}).Info("✅ SNA updated")
+	return s.Get(ctx, id)
+}
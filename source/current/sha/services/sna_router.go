@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+)
+
+// =============================================================================
+// SNA ROUTER - Picks the SNA that serves a VM's vCenter placement
+// =============================================================================
+// Each enrolled SNA registers the vCenters, datacenters and clusters it serves.
+// Calls for a VM go to an SNA whose scope matches the VM's placement; the most
+// specific scope wins (cluster over datacenter over whole vCenter) and SNAs with
+// equally specific scopes are balanced by their share of busy job slots. The SNA
+// a job was started on is recorded so later calls for that job reach it again.
+// Until any SNA registers a scope for a vCenter, its calls use the original
+// single tunnel endpoint.
+
+const (
+	// DefaultSNAEndpoint is the SNA API through the pre-enrollment reverse tunnel
+	DefaultSNAEndpoint = "http://localhost:9081"
+
+	// snaHeartbeatTimeout marks an SNA offline when it stops re-registering
+	snaHeartbeatTimeout = 3 * time.Minute
+)
+
+// SNAEndpoint returns the SHA-side URL of an SNA API reverse tunnel
+func SNAEndpoint(tunnelPort int) string {
+	return fmt.Sprintf("http://localhost:%d", tunnelPort)
+}
+
+// SNAPlacement is where a VM runs in vSphere (empty fields are unknown)
+type SNAPlacement struct {
+	VCenterHost string `json:"vcenter_host"`
+	Datacenter  string `json:"datacenter,omitempty"`
+	Cluster     string `json:"cluster,omitempty"`
+}
+
+// SNARoute is the SNA chosen for a call
+type SNARoute struct {
+	SNAID    string `json:"sna_id"`
+	Name     string `json:"name"`
+	Endpoint string `json:"endpoint"`
+	Fallback bool   `json:"fallback"` // No registered SNA serves the placement
+}
+
+// SNARouter routes SNA calls by VM placement and job
+type SNARouter struct {
+	db       database.Connection
+	registry *database.SNARegistryRepository
+}
+
+// NewSNARouter creates a new SNA router
+func NewSNARouter(db database.Connection) *SNARouter {
+	return &SNARouter{
+		db:       db,
+		registry: database.NewSNARegistryRepository(db),
+	}
+}
+
+// snaOnline reports whether an SNA can take calls (never-registered SNAs count as online)
+func snaOnline(appliance *database.SNAAppliance, now time.Time) bool {
+	return appliance.LastHeartbeatAt == nil || now.Sub(*appliance.LastHeartbeatAt) <= snaHeartbeatTimeout
+}
+
+// scopeSpecificity scores how closely a scope matches a placement (-1 when it does not)
+func scopeSpecificity(scope database.SNAScope, placement SNAPlacement) int {
+	if !strings.EqualFold(scope.VCenterHost, placement.VCenterHost) {
+		return -1
+	}
+	score := 0
+	if scope.Datacenter != "" && placement.Datacenter != "" {
+		if !strings.EqualFold(scope.Datacenter, placement.Datacenter) {
+			return -1
+		}
+		score++
+	}
+	if scope.Cluster != "" && placement.Cluster != "" {
+		if !strings.EqualFold(scope.Cluster, placement.Cluster) {
+			return -1
+		}
+		score += 2
+	}
+	return score
+}
+
+// Select picks the least loaded available SNA with the most specific scope for a placement
+func (r *SNARouter) Select(ctx context.Context, placement SNAPlacement, fallback string) (*SNARoute, error) {
+	appliances, err := r.registry.ListAppliances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	bestScore := -1
+	var candidates []*database.SNAAppliance
+	matched := 0
+	for _, appliance := range appliances {
+		score := -1
+		for _, scope := range appliance.Scopes {
+			if s := scopeSpecificity(scope, placement); s > score {
+				score = s
+			}
+		}
+		if score < 0 {
+			continue
+		}
+		matched++
+		if appliance.State != database.SNAStateActive || !snaOnline(appliance, now) {
+			continue
+		}
+		if score > bestScore {
+			bestScore = score
+			candidates = nil
+		}
+		if score == bestScore {
+			candidates = append(candidates, appliance)
+		}
+	}
+
+	if matched == 0 {
+		return r.fallbackRoute(appliances, fallback), nil
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no available SNA serves vCenter %s (%d registered, all draining, disabled or offline)",
+			placement.VCenterHost, matched)
+	}
+
+	if len(candidates) > 1 {
+		active, err := r.registry.CountActiveJobs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		load := func(appliance *database.SNAAppliance) float64 {
+			if appliance.MaxConcurrentJobs <= 0 {
+				return float64(active[appliance.ID])
+			}
+			return float64(active[appliance.ID]) / float64(appliance.MaxConcurrentJobs)
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			li, lj := load(candidates[i]), load(candidates[j])
+			if li != lj {
+				return li < lj
+			}
+			return candidates[i].Name < candidates[j].Name
+		})
+	}
+
+	chosen := candidates[0]
+	return &SNARoute{
+		SNAID:    chosen.ID,
+		Name:     chosen.Name,
+		Endpoint: SNAEndpoint(chosen.TunnelPort),
+	}, nil
+}
+
+// fallbackRoute is the original tunnel, attributed to the SNA that owns its port if any
+func (r *SNARouter) fallbackRoute(appliances []*database.SNAAppliance, fallback string) *SNARoute {
+	route := &SNARoute{SNAID: DefaultSNAID, Name: DefaultSNAID, Endpoint: fallback, Fallback: true}
+	for _, appliance := range appliances {
+		if appliance.TunnelPort == database.SNATunnelPortFirst {
+			route.SNAID = appliance.ID
+			route.Name = appliance.Name
+			break
+		}
+	}
+	return route
+}
+
+// PlacementForVM reads a VM's placement from its context
+func (r *SNARouter) PlacementForVM(ctx context.Context, vmContextID string) (SNAPlacement, error) {
+	var vmContext struct {
+		VCenterHost string
+		Datacenter  string
+		Cluster     *string
+	}
+	result := r.db.GetGormDB().WithContext(ctx).Table("vm_replication_contexts").
+		Select("vcenter_host, datacenter, cluster").
+		Where("context_id = ?", vmContextID).
+		Limit(1).
+		Scan(&vmContext)
+	if result.Error != nil {
+		return SNAPlacement{}, fmt.Errorf("failed to load VM placement: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return SNAPlacement{}, fmt.Errorf("VM context not found: %s", vmContextID)
+	}
+
+	placement := SNAPlacement{VCenterHost: vmContext.VCenterHost, Datacenter: vmContext.Datacenter}
+	if vmContext.Cluster != nil {
+		placement.Cluster = *vmContext.Cluster
+	}
+	return placement, nil
+}
+
+// SelectForVM picks the SNA for a VM context
+func (r *SNARouter) SelectForVM(ctx context.Context, vmContextID, fallback string) (*SNARoute, error) {
+	placement, err := r.PlacementForVM(ctx, vmContextID)
+	if err != nil {
+		return nil, err
+	}
+	return r.Select(ctx, placement, fallback)
+}
+
+// Assign records the SNA a job runs on
+func (r *SNARouter) Assign(ctx context.Context, jobID, jobType, vmContextID, snaID string) error {
+	assignment := &database.SNAJobAssignment{
+		JobID:   jobID,
+		SNAID:   snaID,
+		JobType: jobType,
+	}
+	if vmContextID != "" {
+		assignment.VMContextID = &vmContextID
+	}
+	return r.registry.UpsertAssignment(ctx, assignment)
+}
+
+// EndpointForScope returns the SNA endpoint for a placement (fallback when the router is not configured)
+func (r *SNARouter) EndpointForScope(ctx context.Context, placement SNAPlacement, fallback string) (string, error) {
+	if r == nil {
+		return fallback, nil
+	}
+	route, err := r.Select(ctx, placement, fallback)
+	if err != nil {
+		return "", err
+	}
+	return route.Endpoint, nil
+}
+
+// RouteJob picks the SNA for a VM's job and records the assignment, returning its endpoint
+func (r *SNARouter) RouteJob(ctx context.Context, jobID, jobType, vmContextID, fallback string) (string, error) {
+	if r == nil {
+		return fallback, nil
+	}
+	route, err := r.SelectForVM(ctx, vmContextID, fallback)
+	if err != nil {
+		return "", err
+	}
+	if err := r.Assign(ctx, jobID, jobType, vmContextID, route.SNAID); err != nil {
+		// The job can still run; only job-level routing and load counting lose it
+		log.WithError(err).WithField("job_id", jobID).Warn("Failed to record SNA job assignment")
+	}
+
+	log.WithFields(log.Fields{
+		"job_id":   jobID,
+		"job_type": jobType,
+		"sna_id":   route.SNAID,
+		"sna_name": route.Name,
+		"endpoint": route.Endpoint,
+	}).Debug("Routed job to SNA")
+	return route.Endpoint, nil
+}
+
+// EndpointForJob returns the endpoint of the SNA a job was routed to (fallback when unknown)
+func (r *SNARouter) EndpointForJob(ctx context.Context, jobID, fallback string) string {
+	if r == nil {
+		return fallback
+	}
+	assignment, err := r.registry.GetAssignment(ctx, jobID)
+	if err != nil {
+		return fallback
+	}
+	if assignment.SNAID == DefaultSNAID {
+		return fallback
+	}
+	appliance, err := r.registry.GetAppliance(ctx, assignment.SNAID)
+	if err != nil {
+		log.WithError(err).WithField("job_id", jobID).Warn("SNA of job no longer registered, using default endpoint")
+		return fallback
+	}
+	return SNAEndpoint(appliance.TunnelPort)
+}
+
+// SNAForJobs returns the SNA each job was routed to, keyed by job ID
+func (r *SNARouter) SNAForJobs(ctx context.Context, jobIDs []string) (map[string]string, error) {
+	return r.registry.GetAssignments(ctx, jobIDs)
+}
+
+// Capacities returns the job capacity each registered SNA declared, keyed by SNA ID
+func (r *SNARouter) Capacities(ctx context.Context) (map[string]int, error) {
+	appliances, err := r.registry.ListAppliances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	capacities := make(map[string]int, len(appliances))
+	for _, appliance := range appliances {
+		capacities[appliance.ID] = appliance.MaxConcurrentJobs
+	}
+	return capacities, nil
+}
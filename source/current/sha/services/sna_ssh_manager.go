@@ -123,8 +123,9 @@ func (vsm *SNASSHManager) ensureSSHDirectory() error {
 	return nil
 }
 
-// AddVMAKey adds a SNA SSH public key with tunnel restrictions
-func (vsm *SNASSHManager) AddVMAKey(publicKey, fingerprint string) error {
+// AddVMAKey adds a SNA SSH public key with tunnel restrictions; the SNA may only open its
+// API reverse tunnel on tunnelPort (0 = the original 9081 tunnel)
+func (vsm *SNASSHManager) AddVMAKey(publicKey, fingerprint string, tunnelPort int) error {
 	log.WithFields(log.Fields{
 		"fingerprint":     fingerprint[:16] + "...", // Truncate for logging
 		"key_type":        "ed25519",
@@ -142,7 +143,10 @@ func (vsm *SNASSHManager) AddVMAKey(publicKey, fingerprint string) error {
 	}
 
 	// Build SSH key entry with restrictions
-	restrictions := `command="/usr/local/sbin/oma_tunnel_wrapper.sh",restrict,permitopen="127.0.0.1:10809",permitopen="127.0.0.1:8081"`
+	if tunnelPort == 0 {
+		tunnelPort = 9081
+	}
+	restrictions := fmt.Sprintf(`command="/usr/local/sbin/oma_tunnel_wrapper.sh",restrict,permitopen="127.0.0.1:10809",permitopen="127.0.0.1:8081",permitlisten="127.0.0.1:%d"`, tunnelPort)
 	keyEntry := fmt.Sprintf("%s %s # SNA enrollment key - %s\n", restrictions, publicKey, fingerprint)
 
 	// Read existing keys
@@ -193,6 +197,7 @@ func (vsm *SNASSHManager) AddVMAKey(publicKey, fingerprint string) error {
 		"fingerprint":     fingerprint[:16] + "...",
 		"authorized_keys": vsm.authorizedKeysPath,
 		"restrictions":    "tunnel access only",
+		"tunnel_port":     tunnelPort,
 	}).Info("✅ SNA SSH key added successfully")
 
 	return nil
@@ -24,6 +24,10 @@ type TelemetryUpdate struct {
 	Disks            []DiskTelemetry   `json:"disks"`
 	Error            *TelemetryError   `json:"error,omitempty"`
 	Timestamp        string            `json:"timestamp,omitempty"`
+
+	// Snapshot consistency actually achieved by the backup client
+	ConsistencyLevel          string `json:"consistency_level,omitempty"`
+	ConsistencyFallbackReason string `json:"consistency_fallback_reason,omitempty"`
 }
 
 type DiskTelemetry struct {
@@ -42,7 +46,8 @@ type TelemetryError struct {
 
 // TelemetryService processes real-time telemetry updates from SBC
 type TelemetryService struct {
-	db database.Connection
+	db     database.Connection
+	events *LiveEventHub // Optional - pushes updates to API subscribers
 }
 
 // NewTelemetryService creates a new telemetry service
@@ -50,6 +55,11 @@ func NewTelemetryService(db database.Connection) *TelemetryService {
 	return &TelemetryService{db: db}
 }
 
+// SetEventHub pushes processed telemetry and flow execution changes to API subscribers
+func (ts *TelemetryService) SetEventHub(events *LiveEventHub) {
+	ts.events = events
+}
+
 // ProcessTelemetryUpdate processes a telemetry update from SBC
 // Updates both backup_jobs and backup_disks tables with real-time progress
 func (ts *TelemetryService) ProcessTelemetryUpdate(
@@ -58,6 +68,15 @@ func (ts *TelemetryService) ProcessTelemetryUpdate(
 	jobID string,
 	update *TelemetryUpdate,
 ) error {
+	// Restores report through the same API but track progress in vmware_restore_jobs
+	if jobType == "restore" {
+		if err := ts.processRestoreTelemetry(ctx, jobID, update); err != nil {
+			return err
+		}
+		ts.events.Publish(LiveEvent{Type: LiveEventTelemetry, JobID: jobID, JobType: jobType, Data: update})
+		return nil
+	}
+
 	now := time.Now()
 	
 	log.WithFields(log.Fields{
@@ -108,11 +127,25 @@ func (ts *TelemetryService) ProcessTelemetryUpdate(
 	// Update status if provided
 	if update.Status != "" && update.Status != "running" {
 		updates["status"] = update.Status
-		if update.Status == "completed" {
+		if update.Status == "completed" || update.Status == "cancelled" {
 			updates["completed_at"] = now
 		}
 	}
 	
+	// Record the snapshot consistency the backup client actually achieved
+	if update.ConsistencyLevel != "" {
+		updates["consistency_level"] = update.ConsistencyLevel
+		if update.ConsistencyFallbackReason != "" {
+			updates["consistency_fallback_reason"] = update.ConsistencyFallbackReason
+			
+			log.WithFields(log.Fields{
+				"job_id":            jobID,
+				"consistency_level": update.ConsistencyLevel,
+				"reason":            update.ConsistencyFallbackReason,
+			}).Warn("⚠️ Backup snapshot consistency degraded from requested level")
+		}
+	}
+	
 	// Handle errors
 	if update.Error != nil {
 		updates["error_message"] = update.Error.Message
@@ -200,15 +233,79 @@ func (ts *TelemetryService) ProcessTelemetryUpdate(
 		"disks_updated":    len(update.Disks),
 	}).Debug("✅ Telemetry update persisted to database")
 	
+	ts.events.Publish(LiveEvent{Type: LiveEventTelemetry, JobID: jobID, JobType: jobType, Data: update})
+	
 	// 🆕 EVENT-DRIVEN FLOW EXECUTION UPDATE
-	// When a backup job completes or fails, check if its parent flow execution is now complete
-	if update.Status == "completed" || update.Status == "failed" {
+	// When a backup job completes, fails or is cancelled, check if its parent flow execution is now complete
+	if update.Status == "completed" || update.Status == "failed" || update.Status == "cancelled" {
 		ts.checkAndUpdateFlowExecution(ctx, jobID)
 	}
 	
 	return nil
 }
 
+// processRestoreTelemetry persists a telemetry update for a VMware restore job
+// Uses the same smart-update rules as backups so terminal updates keep the last progress
+func (ts *TelemetryService) processRestoreTelemetry(ctx context.Context, jobID string, update *TelemetryUpdate) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"last_telemetry_at": now,
+	}
+
+	if update.BytesTransferred > 0 {
+		updates["bytes_transferred"] = update.BytesTransferred
+	}
+	if update.TotalBytes > 0 {
+		updates["total_bytes"] = update.TotalBytes
+	}
+	if update.CurrentPhase != "" {
+		updates["current_phase"] = update.CurrentPhase
+	}
+	if update.TransferSpeedBps > 0 {
+		updates["transfer_speed_bps"] = update.TransferSpeedBps
+	}
+	if update.ProgressPercent > 0 {
+		updates["progress_percent"] = update.ProgressPercent
+	}
+	if update.Status != "" && update.Status != "running" {
+		updates["status"] = update.Status
+		if update.Status == database.VMwareRestoreStatusCompleted {
+			updates["completed_at"] = now
+			updates["progress_percent"] = 100.0
+		}
+	}
+
+	if update.Error != nil {
+		updates["error_message"] = update.Error.Message
+		updates["status"] = database.VMwareRestoreStatusFailed
+		updates["completed_at"] = now
+
+		log.WithFields(log.Fields{
+			"restore_id": jobID,
+			"error":      update.Error.Message,
+		}).Error("VMware restore failed - error reported via telemetry")
+	}
+
+	result := ts.db.GetGormDB().WithContext(ctx).
+		Model(&database.VMwareRestoreJob{}).
+		Where("id = ?", jobID).
+		Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update VMware restore job: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("VMware restore job not found: %s", jobID)
+	}
+
+	log.WithFields(log.Fields{
+		"restore_id":       jobID,
+		"status":           update.Status,
+		"phase":            update.CurrentPhase,
+		"progress_percent": update.ProgressPercent,
+	}).Debug("✅ Restore telemetry persisted to database")
+	return nil
+}
+
 // checkAndUpdateFlowExecution checks if a backup job belongs to a flow execution
 // and updates the execution status if all jobs are complete
 func (ts *TelemetryService) checkAndUpdateFlowExecution(ctx context.Context, jobID string) {
@@ -293,7 +390,7 @@ func (ts *TelemetryService) updateExecutionIfComplete(ctx context.Context, execu
 		switch job.Status {
 		case "completed":
 			completed++
-		case "failed":
+		case "failed", "cancelled":
 			failed++
 		}
 	}
@@ -342,6 +439,17 @@ func (ts *TelemetryService) updateExecutionIfComplete(ctx context.Context, execu
 		return
 	}
 	
+	ts.events.Publish(LiveEvent{
+		Type:        LiveEventFlowExecution,
+		ExecutionID: execution.ID,
+		Data: map[string]interface{}{
+			"flow_id":        execution.FlowID,
+			"status":         finalStatus,
+			"jobs_completed": completed,
+			"jobs_failed":    failed,
+		},
+	})
+	
 	// Update flow statistics
 	flow, err := flowRepo.GetFlowByID(ctx, execution.FlowID)
 	if err != nil {
@@ -377,3 +485,31 @@ func (ts *TelemetryService) updateExecutionIfComplete(ctx context.Context, execu
 	}).Info("✅ Flow execution and statistics updated (event-driven)")
 }
 
+
+// ActiveJobCredential returns the VMware credential ID of a backup or restore job that
+// is still active, so an SNA that lost the job can clean up its snapshot (nil when the
+// VM context has no credential set)
+func (ts *TelemetryService) ActiveJobCredential(ctx context.Context, jobType, jobID string) (*int, error) {
+	table, statuses := "backup_jobs", []string{"pending", "running", "paused"}
+	if jobType == "restore" {
+		table = "vmware_restore_jobs"
+		statuses = []string{database.VMwareRestoreStatusPending, database.VMwareRestoreStatusRunning}
+	}
+
+	var rows []struct {
+		CredentialID *int
+	}
+	err := ts.db.GetGormDB().WithContext(ctx).
+		Table(table+" AS j").
+		Select("c.credential_id AS credential_id").
+		Joins("JOIN vm_replication_contexts c ON c.context_id = j.vm_context_id").
+		Where("j.id = ? AND j.status IN ?", jobID, statuses).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up %s job %s: %w", jobType, jobID, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%s job %s is not active", jobType, jobID)
+	}
+	return rows[0].CredentialID, nil
+}
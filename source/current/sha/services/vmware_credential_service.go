@@ -3,6 +3,9 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"time"
 
@@ -235,3 +238,62 @@ func (vcs *VMwareCredentialService) TestConnectivity(ctx context.Context, creds
 	// For now, return success - will implement actual connectivity test
 	return nil
 }
+
+// vmwareCredentialTokenTTL is how long a credential token can be redeemed after it is issued
+const vmwareCredentialTokenTTL = 15 * time.Minute
+
+// hashCredentialToken returns the stored form of a credential token
+func hashCredentialToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueCredentialToken creates a single-use token that lets the backup client of one job
+// resolve a credential set over the tunnel, so the password never reaches a command line
+func (vcs *VMwareCredentialService) IssueCredentialToken(ctx context.Context, credentialID int, jobID string) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate credential token: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+
+	record := &database.VMwareCredentialToken{
+		TokenHash:    hashCredentialToken(token),
+		CredentialID: credentialID,
+		ExpiresAt:    time.Now().Add(vmwareCredentialTokenTTL),
+	}
+	if jobID != "" {
+		record.JobID = &jobID
+	}
+
+	db := (*vcs.db).GetGormDB().WithContext(ctx)
+	if err := db.Create(record).Error; err != nil {
+		return "", fmt.Errorf("failed to store credential token: %w", err)
+	}
+
+	// Housekeeping - tokens are useless once expired
+	db.Where("expires_at < ?", time.Now().Add(-vmwareCredentialTokenTTL)).Delete(&database.VMwareCredentialToken{})
+
+	return token, nil
+}
+
+// RedeemCredentialToken resolves a credential set with a token issued for it; each token works once
+func (vcs *VMwareCredentialService) RedeemCredentialToken(ctx context.Context, credentialID int, token string) (*database.VMwareCredentials, error) {
+	if token == "" {
+		return nil, fmt.Errorf("invalid credential token")
+	}
+
+	// Claim the token atomically so concurrent redemptions cannot both succeed
+	result := (*vcs.db).GetGormDB().WithContext(ctx).Model(&database.VMwareCredentialToken{}).
+		Where("token_hash = ? AND credential_id = ? AND used_at IS NULL AND expires_at > ?",
+			hashCredentialToken(token), credentialID, time.Now()).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to redeem credential token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("invalid credential token: unknown, expired or already used")
+	}
+
+	return vcs.GetCredentials(ctx, credentialID)
+}
@@ -0,0 +1,410 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/database"
+	"github.com/vexxhost/migratekit-sha/joblog"
+	"github.com/vexxhost/migratekit-sha/models"
+	"github.com/vexxhost/migratekit-sha/restore"
+	"github.com/vexxhost/migratekit-sha/storage"
+)
+
+// =============================================================================
+// VMWARE RESTORE SERVICE - Restore backups back to vSphere
+// =============================================================================
+// The SHA exports the backup's QCOW2 disks read-only over NBD, and the SNA runs the
+// backup client's restore command, which either creates a new VM from the captured
+// VM configuration or overwrites the original VM's disks (CBT-assisted). Progress
+// arrives through the telemetry API under the "restore" job type.
+
+const (
+	// vmwareRestoreMonitorInterval is how often the monitor checks a running restore
+	vmwareRestoreMonitorInterval = 10 * time.Second
+	// vmwareRestoreTelemetryTimeout fails a restore whose client stopped reporting
+	vmwareRestoreTelemetryTimeout = 30 * time.Minute
+)
+
+// VMwareRestoreService orchestrates restores of backups to VMware
+type VMwareRestoreService struct {
+	db                database.Connection
+	restoreRepo       *database.VMwareRestoreJobRepository
+	backupJobRepo     *database.BackupJobRepository
+	vmContextRepo     *database.VMReplicationContextRepository
+	credentialService *VMwareCredentialService
+	portAllocator     *NBDPortAllocator
+	qemuManager       *QemuNBDManager
+	jobTracker        *joblog.Tracker
+	snaAPIEndpoint    string
+	snaRouter         *SNARouter // Optional: picks the SNA serving the VM's vCenter
+}
+
+// VMwareRestoreRequest describes a restore of a backup to vSphere
+type VMwareRestoreRequest struct {
+	BackupID     string            `json:"backup_id"`
+	Mode         string            `json:"mode"`                     // "new_vm" or "in_place"
+	TargetVMName string            `json:"target_vm_name,omitempty"` // new_vm only (default: <vm>-restored-<timestamp>)
+	Datacenter   string            `json:"datacenter,omitempty"`     // new_vm placement (defaults to the source VM's)
+	Folder       string            `json:"folder,omitempty"`
+	ResourcePool string            `json:"resource_pool,omitempty"`
+	Datastore    string            `json:"datastore,omitempty"`
+	NetworkMap   map[string]string `json:"network_map,omitempty"` // Source network name -> target network name
+	PreserveMACs bool              `json:"preserve_macs"`
+	PowerOn      bool              `json:"power_on"`
+}
+
+// vmwareRestoreSpec is the restore spec handed to the SNA (and on to the backup client)
+type vmwareRestoreSpec struct {
+	Mode         string                  `json:"mode"`
+	VMPath       string                  `json:"vm_path,omitempty"`
+	TargetName   string                  `json:"target_name,omitempty"`
+	Datacenter   string                  `json:"datacenter,omitempty"`
+	Folder       string                  `json:"folder,omitempty"`
+	ResourcePool string                  `json:"resource_pool,omitempty"`
+	Datastore    string                  `json:"datastore,omitempty"`
+	NetworkMap   map[string]string       `json:"network_map,omitempty"`
+	PreserveMACs bool                    `json:"preserve_macs"`
+	PowerOn      bool                    `json:"power_on"`
+	VMConfig     *models.VMConfiguration `json:"vm_config,omitempty"`
+	Disks        []vmwareRestoreDisk     `json:"disks"`
+}
+
+// vmwareRestoreDisk is one exported backup disk in the restore spec
+type vmwareRestoreDisk struct {
+	DiskKey   int32  `json:"disk_key"`
+	SizeBytes int64  `json:"size_bytes"`
+	SourceURL string `json:"source_url,omitempty"`
+	Blank     bool   `json:"blank"`
+	ChangeID  string `json:"change_id,omitempty"`
+}
+
+// NewVMwareRestoreService creates a new VMware restore service
+func NewVMwareRestoreService(
+	db database.Connection,
+	credentialService *VMwareCredentialService,
+	portAllocator *NBDPortAllocator,
+	qemuManager *QemuNBDManager,
+	jobTracker *joblog.Tracker,
+	snaAPIEndpoint string,
+) *VMwareRestoreService {
+	return &VMwareRestoreService{
+		db:                db,
+		restoreRepo:       database.NewVMwareRestoreJobRepository(db),
+		backupJobRepo:     database.NewBackupJobRepository(db),
+		vmContextRepo:     database.NewVMReplicationContextRepository(db),
+		credentialService: credentialService,
+		portAllocator:     portAllocator,
+		qemuManager:       qemuManager,
+		jobTracker:        jobTracker,
+		snaAPIEndpoint:    snaAPIEndpoint,
+	}
+}
+
+// SetSNARouter sets the router that picks the SNA serving each VM
+func (s *VMwareRestoreService) SetSNARouter(router *SNARouter) {
+	s.snaRouter = router
+}
+
+// StartRestore exports the backup disks and dispatches the restore to the SNA
+// The returned job keeps running in the background until the client reports completion
+func (s *VMwareRestoreService) StartRestore(ctx context.Context, req *VMwareRestoreRequest) (*database.VMwareRestoreJob, error) {
+	backup, err := s.backupJobRepo.GetByID(ctx, req.BackupID)
+	if err != nil {
+		return nil, err
+	}
+	if backup.Status != "completed" {
+		return nil, fmt.Errorf("backup %s is not completed (status: %s)", backup.ID, backup.Status)
+	}
+
+	// VM names are not unique across vCenters - the backup records its own context
+	vmContext, err := s.vmContextRepo.GetVMContextByID(backup.VMContextID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find VM context of backup %s: %w", backup.ID, err)
+	}
+	if vmContext.CredentialID == nil {
+		return nil, fmt.Errorf("VM context %s has no credential_id set", vmContext.ContextID)
+	}
+
+	// The captured VM configuration is required to build a new VM, optional in place
+	var vmConfig *models.VMConfiguration
+	if backup.VMConfigPath != nil && *backup.VMConfigPath != "" {
+		sidecar, err := storage.LoadVMConfig(*backup.VMConfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load VM configuration for backup %s: %w", backup.ID, err)
+		}
+		vmConfig = &sidecar.Config
+	}
+
+	spec := &vmwareRestoreSpec{
+		Mode:         req.Mode,
+		Datacenter:   req.Datacenter,
+		Folder:       req.Folder,
+		ResourcePool: req.ResourcePool,
+		Datastore:    req.Datastore,
+		NetworkMap:   req.NetworkMap,
+		PreserveMACs: req.PreserveMACs,
+		PowerOn:      req.PowerOn,
+		VMConfig:     vmConfig,
+	}
+
+	switch req.Mode {
+	case database.VMwareRestoreModeNewVM:
+		if vmConfig == nil {
+			return nil, fmt.Errorf("backup %s has no captured VM configuration - restore in place instead", backup.ID)
+		}
+		spec.TargetName = req.TargetVMName
+		if spec.TargetName == "" {
+			spec.TargetName = fmt.Sprintf("%s-restored-%s", backup.VMName, time.Now().Format("20060102-150405"))
+		}
+		if spec.Datacenter == "" {
+			spec.Datacenter = vmContext.Datacenter
+		}
+	case database.VMwareRestoreModeInPlace:
+		spec.TargetName = vmContext.VMName
+		spec.VMPath = vmContext.VMPath
+	default:
+		return nil, fmt.Errorf("invalid restore mode %q (valid: %s, %s)", req.Mode,
+			database.VMwareRestoreModeNewVM, database.VMwareRestoreModeInPlace)
+	}
+
+	plans, err := restore.PlanDiskRestore(ctx, s.db, backup.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	restoreID := fmt.Sprintf("restore-%s-%d", backup.VMName, time.Now().Unix())
+	job := &database.VMwareRestoreJob{
+		ID:           restoreID,
+		BackupID:     backup.ID,
+		VMContextID:  vmContext.ContextID,
+		Mode:         req.Mode,
+		TargetVMName: spec.TargetName,
+		Status:       database.VMwareRestoreStatusPending,
+		CurrentPhase: "pending",
+	}
+	if spec.VMPath != "" {
+		job.TargetVMPath = &spec.VMPath
+	}
+	if err := s.restoreRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	owner := "system"
+	jobCtx, logJobID, err := s.jobTracker.StartJob(context.Background(), joblog.JobStart{
+		JobType:       "restore",
+		Operation:     "vmware-restore",
+		Owner:         &owner,
+		ContextID:     &vmContext.ContextID,
+		ExternalJobID: &restoreID,
+		Metadata: map[string]interface{}{
+			"backup_id": backup.ID,
+			"mode":      req.Mode,
+			"target_vm": spec.TargetName,
+		},
+	})
+	if err != nil {
+		s.restoreRepo.MarkFailed(ctx, restoreID, err.Error())
+		return nil, fmt.Errorf("failed to start job tracking: %w", err)
+	}
+
+	fail := func(err error) (*database.VMwareRestoreJob, error) {
+		s.qemuManager.StopByJobID(restoreID)
+		s.restoreRepo.MarkFailed(context.Background(), restoreID, err.Error())
+		s.jobTracker.EndJob(jobCtx, logJobID, joblog.StatusFailed, err)
+		return nil, err
+	}
+
+	err = s.jobTracker.RunStep(jobCtx, logJobID, "export-backup-disks", func(ctx context.Context) error {
+		disks, err := s.exportBackupDisks(ctx, restoreID, backup, plans)
+		if err != nil {
+			return err
+		}
+		spec.Disks = disks
+		return nil
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	err = s.jobTracker.RunStep(jobCtx, logJobID, "dispatch-to-sna", func(ctx context.Context) error {
+		creds, err := s.credentialService.GetCredentials(ctx, *vmContext.CredentialID)
+		if err != nil {
+			return fmt.Errorf("failed to get VMware credentials: %w", err)
+		}
+		return s.dispatchToSNA(ctx, restoreID, vmContext.ContextID, creds, spec)
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":        database.VMwareRestoreStatusRunning,
+		"current_phase": "dispatched",
+		"job_log_id":    logJobID,
+		"started_at":    now,
+	}
+	if err := s.restoreRepo.Update(ctx, restoreID, updates); err != nil {
+		log.WithError(err).WithField("restore_id", restoreID).Warn("Failed to mark VMware restore as running")
+	}
+
+	go s.monitorRestore(jobCtx, logJobID, restoreID)
+
+	return s.restoreRepo.GetByID(ctx, restoreID)
+}
+
+// GetRestore returns a VMware restore job
+func (s *VMwareRestoreService) GetRestore(ctx context.Context, id string) (*database.VMwareRestoreJob, error) {
+	return s.restoreRepo.GetByID(ctx, id)
+}
+
+// ListRestores lists VMware restore jobs, optionally for one VM context
+func (s *VMwareRestoreService) ListRestores(ctx context.Context, vmContextID string) ([]*database.VMwareRestoreJob, error) {
+	return s.restoreRepo.ListByVMContext(ctx, vmContextID)
+}
+
+// exportBackupDisks starts a read-only qemu-nbd export for every non-blank backup disk
+func (s *VMwareRestoreService) exportBackupDisks(ctx context.Context, restoreID string, backup *database.BackupJob, plans []restore.DiskRestorePlan) ([]vmwareRestoreDisk, error) {
+	// CBT change IDs recorded per disk, used for changed-block in-place restores
+	var backupDisks []database.BackupDisk
+	if err := s.db.GetGormDB().WithContext(ctx).
+		Where("backup_job_id = ?", backup.ID).
+		Find(&backupDisks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load backup disks: %w", err)
+	}
+	changeIDs := make(map[int64]string, len(backupDisks))
+	for _, disk := range backupDisks {
+		if disk.DiskChangeID != nil {
+			changeIDs[disk.ID] = *disk.DiskChangeID
+		}
+	}
+
+	disks := make([]vmwareRestoreDisk, 0, len(plans))
+	for _, plan := range plans {
+		disk := vmwareRestoreDisk{
+			DiskKey:   int32(plan.VMwareDiskKey),
+			SizeBytes: plan.SizeBytes,
+			Blank:     plan.Blank,
+			ChangeID:  changeIDs[plan.BackupDiskID],
+		}
+
+		if !plan.Blank {
+			exportName := fmt.Sprintf("%s-disk%d", restoreID, plan.DiskIndex)
+			port, err := s.portAllocator.Allocate(restoreID, backup.VMName, exportName)
+			if err != nil {
+				return nil, fmt.Errorf("failed to allocate NBD port for disk %d: %w", plan.DiskIndex, err)
+			}
+			if _, err := s.qemuManager.StartReadOnly(port, exportName, plan.SourcePath, restoreID, backup.VMName, plan.DiskIndex); err != nil {
+				s.portAllocator.Release(port)
+				return nil, fmt.Errorf("failed to export disk %d: %w", plan.DiskIndex, err)
+			}
+			disk.SourceURL = fmt.Sprintf("nbd://127.0.0.1:%d/%s", port, exportName)
+		}
+
+		s.jobTracker.Logger(ctx).Info("Backup disk prepared for restore",
+			"disk_index", plan.DiskIndex,
+			"disk_key", plan.VMwareDiskKey,
+			"blank", plan.Blank,
+			"source_url", disk.SourceURL)
+		disks = append(disks, disk)
+	}
+
+	return disks, nil
+}
+
+// dispatchToSNA asks the SNA to run the restore with the backup client
+func (s *VMwareRestoreService) dispatchToSNA(ctx context.Context, restoreID, vmContextID string, creds *database.VMwareCredentials, spec *vmwareRestoreSpec) error {
+	// The backup client resolves the password itself with a one-time token
+	credentialToken, err := s.credentialService.IssueCredentialToken(ctx, creds.ID, restoreID)
+	if err != nil {
+		return fmt.Errorf("failed to issue VMware credential token: %w", err)
+	}
+
+	snaReq := map[string]interface{}{
+		"job_id":       restoreID,
+		"vcenter_host": creds.VCenterHost,
+		"vcenter_user": creds.Username,
+		"vmware_credential": map[string]interface{}{
+			"credential_id": creds.ID,
+			"token":         credentialToken,
+		},
+		"spec": spec,
+	}
+	jsonData, err := json.Marshal(snaReq)
+	if err != nil {
+		return fmt.Errorf("failed to encode SNA restore request: %w", err)
+	}
+
+	snaEndpoint, err := s.snaRouter.RouteJob(ctx, restoreID, "restore", vmContextID, s.snaAPIEndpoint)
+	if err != nil {
+		return fmt.Errorf("failed to route restore to SNA: %w", err)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(snaEndpoint+"/api/v1/restore/vmware", "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to call SNA restore API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("SNA restore API returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// monitorRestore waits for the restore to finish, then tears down the exports and closes the job log
+func (s *VMwareRestoreService) monitorRestore(ctx context.Context, logJobID, restoreID string) {
+	ticker := time.NewTicker(vmwareRestoreMonitorInterval)
+	defer ticker.Stop()
+
+	logger := log.WithField("restore_id", restoreID)
+
+	for range ticker.C {
+		job, err := s.restoreRepo.GetByID(context.Background(), restoreID)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to check VMware restore status")
+			continue
+		}
+
+		lastSeen := job.StartedAt
+		if job.LastTelemetryAt != nil {
+			lastSeen = job.LastTelemetryAt
+		}
+		if !job.IsTerminal() && lastSeen != nil && time.Since(*lastSeen) > vmwareRestoreTelemetryTimeout {
+			reason := fmt.Sprintf("no telemetry from backup client for %s", vmwareRestoreTelemetryTimeout)
+			if err := s.restoreRepo.MarkFailed(context.Background(), restoreID, reason); err != nil {
+				logger.WithError(err).Warn("Failed to mark stale VMware restore as failed")
+			}
+			job.Status = database.VMwareRestoreStatusFailed
+			job.ErrorMessage = reason
+		}
+
+		if !job.IsTerminal() {
+			continue
+		}
+
+		stopped := s.qemuManager.StopByJobID(restoreID)
+		logger.WithFields(log.Fields{
+			"status":          job.Status,
+			"exports_stopped": stopped,
+		}).Info("♻️ VMware restore finished")
+
+		if job.Status == database.VMwareRestoreStatusCompleted {
+			s.jobTracker.EndJob(ctx, logJobID, joblog.StatusCompleted, nil)
+		} else {
+			s.jobTracker.EndJob(ctx, logJobID, joblog.StatusFailed, fmt.Errorf("%s", job.ErrorMessage))
+		}
+		return
+	}
+}
@@ -66,17 +66,18 @@ func (r *SQLBackupChainRepository) GetBackupChain(ctx context.Context, vmContext
 		SELECT id, vm_context_id, disk_id,
 			full_backup_id, latest_backup_id,
 			total_backups, total_size_bytes,
-			created_at, updated_at
+			created_at, updated_at, closed_at
 		FROM backup_chains
 		WHERE vm_context_id = ? AND disk_id = ?
 	`
 
 	chain := &BackupChain{}
+	var closedAt sql.NullTime
 	err := r.db.QueryRowContext(ctx, query, vmContextID, diskID).Scan(
 		&chain.ID, &chain.VMContextID, &chain.DiskID,
 		&chain.FullBackupID, &chain.LatestBackupID,
 		&chain.TotalBackups, &chain.TotalSizeBytes,
-		&chain.CreatedAt, &chain.UpdatedAt,
+		&chain.CreatedAt, &chain.UpdatedAt, &closedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, ErrBackupChainNotFound
@@ -84,6 +85,9 @@ func (r *SQLBackupChainRepository) GetBackupChain(ctx context.Context, vmContext
 	if err != nil {
 		return nil, fmt.Errorf("failed to query backup chain: %w", err)
 	}
+	if closedAt.Valid {
+		chain.ClosedAt = &closedAt.Time
+	}
 
 	return chain, nil
 }
@@ -94,17 +98,18 @@ func (r *SQLBackupChainRepository) GetBackupChainByID(ctx context.Context, chain
 		SELECT id, vm_context_id, disk_id,
 			full_backup_id, latest_backup_id,
 			total_backups, total_size_bytes,
-			created_at, updated_at
+			created_at, updated_at, closed_at
 		FROM backup_chains
 		WHERE id = ?
 	`
 
 	chain := &BackupChain{}
+	var closedAt sql.NullTime
 	err := r.db.QueryRowContext(ctx, query, chainID).Scan(
 		&chain.ID, &chain.VMContextID, &chain.DiskID,
 		&chain.FullBackupID, &chain.LatestBackupID,
 		&chain.TotalBackups, &chain.TotalSizeBytes,
-		&chain.CreatedAt, &chain.UpdatedAt,
+		&chain.CreatedAt, &chain.UpdatedAt, &closedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, ErrBackupChainNotFound
@@ -112,6 +117,9 @@ func (r *SQLBackupChainRepository) GetBackupChainByID(ctx context.Context, chain
 	if err != nil {
 		return nil, fmt.Errorf("failed to query backup chain by ID: %w", err)
 	}
+	if closedAt.Valid {
+		chain.ClosedAt = &closedAt.Time
+	}
 
 	return chain, nil
 }
@@ -124,6 +132,7 @@ func (r *SQLBackupChainRepository) UpdateBackupChain(ctx context.Context, chain
 			latest_backup_id = ?,
 			total_backups = ?,
 			total_size_bytes = ?,
+			closed_at = ?,
 			updated_at = ?
 		WHERE id = ?
 	`
@@ -132,7 +141,7 @@ func (r *SQLBackupChainRepository) UpdateBackupChain(ctx context.Context, chain
 	result, err := r.db.ExecContext(ctx, query,
 		chain.FullBackupID, chain.LatestBackupID,
 		chain.TotalBackups, chain.TotalSizeBytes,
-		now, chain.ID,
+		chain.ClosedAt, now, chain.ID,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update backup chain: %w", err)
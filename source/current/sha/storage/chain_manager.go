@@ -75,6 +75,7 @@ func (cm *ChainManager) GetOrCreateChainWithBackup(ctx context.Context, vmContex
 		chain.UpdatedAt = time.Now()
 		if backupType == BackupTypeFull {
 			chain.FullBackupID = backupID // Update full backup if this is a new full backup
+			chain.ClosedAt = nil          // A disk that returned to the VM reopens its chain
 		}
 		// Update chain in database
 		updateErr := cm.repo.UpdateBackupChain(ctx, chain)
@@ -143,6 +144,30 @@ func (cm *ChainManager) GetChain(ctx context.Context, vmContextID string, diskID
 	return chain, nil
 }
 
+// CloseChain marks a VM disk's chain closed because the disk was removed from the VM.
+// Its backups stay restorable until retention expires them; a new full reopens it.
+func (cm *ChainManager) CloseChain(ctx context.Context, vmContextID string, diskID int) (*BackupChain, error) {
+	chain, err := cm.repo.GetBackupChain(ctx, vmContextID, diskID)
+	if err != nil {
+		return nil, err
+	}
+	if chain.ClosedAt != nil {
+		return chain, nil
+	}
+
+	now := time.Now()
+	chain.ClosedAt = &now
+	if err := cm.repo.UpdateBackupChain(ctx, chain); err != nil {
+		return nil, &ChainError{
+			ChainID: chain.ID,
+			Op:      "close",
+			Err:     fmt.Errorf("failed to close chain: %w", err),
+		}
+	}
+
+	return chain, nil
+}
+
 // AddBackupToChain adds a backup to the chain and updates metadata.
 func (cm *ChainManager) AddBackupToChain(ctx context.Context, chainID string, backup *Backup) error {
 	// Start transaction (using db for complex transaction pattern)
@@ -2,13 +2,98 @@ package storage
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 )
 
+// fakeChainRepo is an in-memory BackupChainRepository keyed by chain ID
+type fakeChainRepo struct {
+	chains  map[string]*BackupChain
+	backups []*Backup // Backups of every chain, in chain order
+}
+
+func newFakeChainRepo(chains ...*BackupChain) *fakeChainRepo {
+	repo := &fakeChainRepo{chains: make(map[string]*BackupChain)}
+	for _, chain := range chains {
+		repo.chains[chain.ID] = chain
+	}
+	return repo
+}
+
+func (r *fakeChainRepo) CreateBackupChain(ctx context.Context, chain *BackupChain) error {
+	stored := *chain
+	r.chains[chain.ID] = &stored
+	return nil
+}
+
+func (r *fakeChainRepo) GetBackupChain(ctx context.Context, vmContextID string, diskID int) (*BackupChain, error) {
+	return r.GetBackupChainByID(ctx, GenerateChainID(vmContextID, diskID))
+}
+
+func (r *fakeChainRepo) GetBackupChainByID(ctx context.Context, chainID string) (*BackupChain, error) {
+	chain, exists := r.chains[chainID]
+	if !exists {
+		return nil, ErrBackupChainNotFound
+	}
+	found := *chain
+	return &found, nil
+}
+
+func (r *fakeChainRepo) UpdateBackupChain(ctx context.Context, chain *BackupChain) error {
+	if _, exists := r.chains[chain.ID]; !exists {
+		return ErrBackupChainNotFound
+	}
+	stored := *chain
+	r.chains[chain.ID] = &stored
+	return nil
+}
+
+func (r *fakeChainRepo) DeleteBackupChain(ctx context.Context, chainID string) error {
+	delete(r.chains, chainID)
+	return nil
+}
+
+func (r *fakeChainRepo) GetBackup(ctx context.Context, backupID string) (*Backup, error) {
+	for _, backup := range r.backups {
+		if backup.ID == backupID {
+			return backup, nil
+		}
+	}
+	return nil, ErrBackupNotFound
+}
+
+func (r *fakeChainRepo) ListBackupsForChain(ctx context.Context, vmContextID string, diskID int) ([]*Backup, error) {
+	var backups []*Backup
+	for _, backup := range r.backups {
+		if backup.VMContextID == vmContextID && backup.DiskID == diskID {
+			backups = append(backups, backup)
+		}
+	}
+	return backups, nil
+}
+
+func (r *fakeChainRepo) CountBackupDependencies(ctx context.Context, backupID string) (int, error) {
+	count := 0
+	for _, backup := range r.backups {
+		if backup.ParentBackupID == backupID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func testChainBackup(vmContextID, id string, backupType BackupType, parentID string) *Backup {
+	return &Backup{
+		ID:             id,
+		VMContextID:    vmContextID,
+		BackupType:     backupType,
+		ParentBackupID: parentID,
+	}
+}
+
 func TestNewChainManager(t *testing.T) {
 	db, _, err := sqlmock.New()
 	if err != nil {
@@ -16,7 +101,7 @@ func TestNewChainManager(t *testing.T) {
 	}
 	defer db.Close()
 
-	cm := NewChainManager(db)
+	cm := NewChainManager(newFakeChainRepo(), db)
 	if cm == nil {
 		t.Fatal("NewChainManager returned nil")
 	}
@@ -26,27 +111,17 @@ func TestNewChainManager(t *testing.T) {
 }
 
 func TestCreateChain(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("failed to create mock db: %v", err)
-	}
-	defer db.Close()
-
-	cm := NewChainManager(db)
+	repo := newFakeChainRepo()
+	cm := NewChainManager(repo, nil)
 	ctx := context.Background()
 
 	vmContextID := "ctx-vm1-20251004-153000"
 	diskID := 0
 	fullBackupID := "backup-full-123"
 
-	// Expect INSERT query
-	mock.ExpectExec("INSERT INTO backup_chains").
-		WithArgs(sqlmock.AnyArg(), vmContextID, diskID, fullBackupID, fullBackupID, 1, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
-		WillReturnResult(sqlmock.NewResult(1, 1))
-
-	chain, err := cm.CreateChain(ctx, vmContextID, diskID, fullBackupID, 42949672960)
+	chain, err := cm.GetOrCreateChainWithBackup(ctx, vmContextID, diskID, fullBackupID, BackupTypeFull)
 	if err != nil {
-		t.Fatalf("CreateChain failed: %v", err)
+		t.Fatalf("GetOrCreateChainWithBackup failed: %v", err)
 	}
 
 	if chain.VMContextID != vmContextID {
@@ -61,15 +136,8 @@ func TestCreateChain(t *testing.T) {
 	if chain.LatestBackupID != fullBackupID {
 		t.Errorf("chain.LatestBackupID = %v, want %v", chain.LatestBackupID, fullBackupID)
 	}
-	if chain.TotalBackups != 1 {
-		t.Errorf("chain.TotalBackups = %v, want %v", chain.TotalBackups, 1)
-	}
-	if chain.TotalSizeBytes != 42949672960 {
-		t.Errorf("chain.TotalSizeBytes = %v, want %v", chain.TotalSizeBytes, 42949672960)
-	}
-
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("unfulfilled expectations: %v", err)
+	if _, exists := repo.chains[GenerateChainID(vmContextID, diskID)]; !exists {
+		t.Error("chain was not stored in the repository")
 	}
 }
 
@@ -80,28 +148,29 @@ func TestAddToChain(t *testing.T) {
 	}
 	defer db.Close()
 
-	cm := NewChainManager(db)
+	cm := NewChainManager(newFakeChainRepo(), db)
 	ctx := context.Background()
 
 	chainID := "chain-vm1-disk0"
-	newBackupID := "backup-inc-456"
-	newBackupSize := int64(4294967296)
+	newBackup := &Backup{ID: "backup-inc-456", BackupType: BackupTypeIncremental, SizeBytes: 4294967296}
 
 	// Expect SELECT query for current chain
-	rows := sqlmock.NewRows([]string{"total_backups", "total_size_bytes"}).
-		AddRow(2, 47244640256)
-	mock.ExpectQuery("SELECT total_backups, total_size_bytes FROM backup_chains").
+	mock.ExpectBegin()
+	rows := sqlmock.NewRows([]string{"full_backup_id", "latest_backup_id", "total_backups", "total_size_bytes"}).
+		AddRow("backup-full-123", "backup-inc-123", 2, 47244640256)
+	mock.ExpectQuery("SELECT full_backup_id, latest_backup_id, total_backups, total_size_bytes FROM backup_chains").
 		WithArgs(chainID).
 		WillReturnRows(rows)
 
 	// Expect UPDATE query
 	mock.ExpectExec("UPDATE backup_chains").
-		WithArgs(newBackupID, 3, 51539607552, sqlmock.AnyArg(), chainID).
+		WithArgs("backup-full-123", newBackup.ID, 3, int64(51539607552), sqlmock.AnyArg(), chainID).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
-	err = cm.AddToChain(ctx, chainID, newBackupID, newBackupSize)
+	err = cm.AddBackupToChain(ctx, chainID, newBackup)
 	if err != nil {
-		t.Fatalf("AddToChain failed: %v", err)
+		t.Fatalf("AddBackupToChain failed: %v", err)
 	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -110,38 +179,31 @@ func TestAddToChain(t *testing.T) {
 }
 
 func TestGetChain(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("failed to create mock db: %v", err)
-	}
-	defer db.Close()
-
-	cm := NewChainManager(db)
-	ctx := context.Background()
-
 	vmContextID := "ctx-vm1-20251004-153000"
 	diskID := 0
 	now := time.Now()
 
-	// Expect SELECT query
-	rows := sqlmock.NewRows([]string{
-		"id", "vm_context_id", "disk_id", "full_backup_id", "latest_backup_id",
-		"total_backups", "total_size_bytes", "created_at", "updated_at",
-	}).AddRow(
-		"chain-vm1-disk0", vmContextID, diskID, "backup-full-123", "backup-inc-456",
-		3, int64(51539607552), now, now,
-	)
-	mock.ExpectQuery("SELECT (.+) FROM backup_chains").
-		WithArgs(vmContextID, diskID).
-		WillReturnRows(rows)
+	repo := newFakeChainRepo(&BackupChain{
+		ID:             GenerateChainID(vmContextID, diskID),
+		VMContextID:    vmContextID,
+		DiskID:         diskID,
+		FullBackupID:   "backup-full-123",
+		LatestBackupID: "backup-inc-456",
+		TotalBackups:   3,
+		TotalSizeBytes: 51539607552,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	})
+	cm := NewChainManager(repo, nil)
+	ctx := context.Background()
 
 	chain, err := cm.GetChain(ctx, vmContextID, diskID)
 	if err != nil {
 		t.Fatalf("GetChain failed: %v", err)
 	}
 
-	if chain.ID != "chain-vm1-disk0" {
-		t.Errorf("chain.ID = %v, want %v", chain.ID, "chain-vm1-disk0")
+	if chain.ID != GenerateChainID(vmContextID, diskID) {
+		t.Errorf("chain.ID = %v, want %v", chain.ID, GenerateChainID(vmContextID, diskID))
 	}
 	if chain.VMContextID != vmContextID {
 		t.Errorf("chain.VMContextID = %v, want %v", chain.VMContextID, vmContextID)
@@ -152,138 +214,86 @@ func TestGetChain(t *testing.T) {
 	if chain.TotalBackups != 3 {
 		t.Errorf("chain.TotalBackups = %v, want %v", chain.TotalBackups, 3)
 	}
-
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("unfulfilled expectations: %v", err)
-	}
 }
 
 func TestGetChainNotFound(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("failed to create mock db: %v", err)
-	}
-	defer db.Close()
-
-	cm := NewChainManager(db)
+	cm := NewChainManager(newFakeChainRepo(), nil)
 	ctx := context.Background()
 
-	// Expect SELECT query that returns no rows
-	mock.ExpectQuery("SELECT (.+) FROM backup_chains").
-		WithArgs("ctx-nonexistent", 0).
-		WillReturnError(sql.ErrNoRows)
-
-	_, err = cm.GetChain(ctx, "ctx-nonexistent", 0)
+	_, err := cm.GetChain(ctx, "ctx-nonexistent", 0)
 	if err == nil {
 		t.Fatal("GetChain should return error for non-existent chain")
 	}
-
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("unfulfilled expectations: %v", err)
+	if !errors.Is(err, ErrBackupChainNotFound) {
+		t.Errorf("GetChain error = %v, want ErrBackupChainNotFound", err)
 	}
 }
 
 func TestValidateChain(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("failed to create mock db: %v", err)
-	}
-	defer db.Close()
-
-	cm := NewChainManager(db)
-	ctx := context.Background()
-
-	chainID := "chain-vm1-disk0"
-
-	// Expect query for chain info
-	chainRows := sqlmock.NewRows([]string{"full_backup_id"}).
-		AddRow("backup-full-123")
-	mock.ExpectQuery("SELECT full_backup_id FROM backup_chains").
-		WithArgs(chainID).
-		WillReturnRows(chainRows)
-
-	// Expect query for all backups in chain
-	backupRows := sqlmock.NewRows([]string{"id", "parent_backup_id", "repository_path"}).
-		AddRow("backup-full-123", nil, "/backups/vm1/backup-full-123.qcow2").
-		AddRow("backup-inc-456", "backup-full-123", "/backups/vm1/backup-inc-456.qcow2").
-		AddRow("backup-inc-789", "backup-inc-456", "/backups/vm1/backup-inc-789.qcow2")
-	mock.ExpectQuery("SELECT id, parent_backup_id, repository_path FROM backup_jobs").
-		WillReturnRows(backupRows)
+	vmContextID := "ctx-vm1-20251004-153000"
+	chainID := GenerateChainID(vmContextID, 0)
 
-	valid, err := cm.ValidateChain(ctx, chainID)
-	if err != nil {
-		t.Fatalf("ValidateChain failed: %v", err)
+	repo := newFakeChainRepo(&BackupChain{ID: chainID, VMContextID: vmContextID, FullBackupID: "backup-full-123"})
+	repo.backups = []*Backup{
+		testChainBackup(vmContextID, "backup-full-123", BackupTypeFull, ""),
+		testChainBackup(vmContextID, "backup-inc-456", BackupTypeIncremental, "backup-full-123"),
+		testChainBackup(vmContextID, "backup-inc-789", BackupTypeIncremental, "backup-inc-456"),
 	}
+	cm := NewChainManager(repo, nil)
 
-	if !valid {
-		t.Error("ValidateChain should return true for valid chain")
-	}
-
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("unfulfilled expectations: %v", err)
+	if err := cm.ValidateChain(context.Background(), chainID); err != nil {
+		t.Fatalf("ValidateChain failed for valid chain: %v", err)
 	}
 }
 
 func TestValidateChainBroken(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("failed to create mock db: %v", err)
-	}
-	defer db.Close()
-
-	cm := NewChainManager(db)
-	ctx := context.Background()
-
-	chainID := "chain-vm1-disk0"
-
-	// Expect query for chain info
-	chainRows := sqlmock.NewRows([]string{"full_backup_id"}).
-		AddRow("backup-full-123")
-	mock.ExpectQuery("SELECT full_backup_id FROM backup_chains").
-		WithArgs(chainID).
-		WillReturnRows(chainRows)
-
-	// Expect query for backups with broken chain (missing parent)
-	backupRows := sqlmock.NewRows([]string{"id", "parent_backup_id", "repository_path"}).
-		AddRow("backup-full-123", nil, "/backups/vm1/backup-full-123.qcow2").
-		AddRow("backup-inc-789", "backup-inc-456", "/backups/vm1/backup-inc-789.qcow2") // Parent missing!
-	mock.ExpectQuery("SELECT id, parent_backup_id, repository_path FROM backup_jobs").
-		WillReturnRows(backupRows)
+	vmContextID := "ctx-vm1-20251004-153000"
+	chainID := GenerateChainID(vmContextID, 0)
 
-	valid, err := cm.ValidateChain(ctx, chainID)
-	if err != nil {
-		t.Fatalf("ValidateChain failed: %v", err)
+	// Backups with broken chain (missing parent)
+	repo := newFakeChainRepo(&BackupChain{ID: chainID, VMContextID: vmContextID, FullBackupID: "backup-full-123"})
+	repo.backups = []*Backup{
+		testChainBackup(vmContextID, "backup-full-123", BackupTypeFull, ""),
+		testChainBackup(vmContextID, "backup-inc-789", BackupTypeIncremental, "backup-inc-456"), // Parent missing!
 	}
+	cm := NewChainManager(repo, nil)
 
-	if valid {
-		t.Error("ValidateChain should return false for broken chain")
+	err := cm.ValidateChain(context.Background(), chainID)
+	if err == nil {
+		t.Fatal("ValidateChain should fail for broken chain")
 	}
-
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("unfulfilled expectations: %v", err)
+	var chainErr *ChainError
+	if !errors.As(err, &chainErr) {
+		t.Errorf("ValidateChain error = %T, want *ChainError", err)
 	}
 }
 
-func TestDeleteChain(t *testing.T) {
+func TestRemoveBackupFromChain(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("failed to create mock db: %v", err)
 	}
 	defer db.Close()
 
-	cm := NewChainManager(db)
+	cm := NewChainManager(newFakeChainRepo(), db)
 	ctx := context.Background()
 
 	chainID := "chain-vm1-disk0"
+	backupID := "backup-inc-456"
 
-	// Expect DELETE query
-	mock.ExpectExec("DELETE FROM backup_chains").
-		WithArgs(chainID).
+	// Removing a backup from the chain subtracts its size from the totals
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT bytes_transferred FROM backup_jobs").
+		WithArgs(backupID).
+		WillReturnRows(sqlmock.NewRows([]string{"bytes_transferred"}).AddRow(int64(4294967296)))
+	mock.ExpectExec("UPDATE backup_chains").
+		WithArgs(int64(4294967296), sqlmock.AnyArg(), chainID).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
-	err = cm.DeleteChain(ctx, chainID)
+	err = cm.RemoveBackupFromChain(ctx, chainID, backupID)
 	if err != nil {
-		t.Fatalf("DeleteChain failed: %v", err)
+		t.Fatalf("RemoveBackupFromChain failed: %v", err)
 	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -298,25 +308,27 @@ func TestChainManagerEdgeCases(t *testing.T) {
 	}
 	defer db.Close()
 
-	cm := NewChainManager(db)
+	cm := NewChainManager(newFakeChainRepo(), db)
 	ctx := context.Background()
 
-	t.Run("AddToChain with zero size", func(t *testing.T) {
+	t.Run("AddBackupToChain with zero size", func(t *testing.T) {
 		// Expect SELECT query
-		rows := sqlmock.NewRows([]string{"total_backups", "total_size_bytes"}).
-			AddRow(1, 42949672960)
-		mock.ExpectQuery("SELECT total_backups, total_size_bytes FROM backup_chains").
+		mock.ExpectBegin()
+		rows := sqlmock.NewRows([]string{"full_backup_id", "latest_backup_id", "total_backups", "total_size_bytes"}).
+			AddRow("backup-full", "backup-full", 1, 42949672960)
+		mock.ExpectQuery("SELECT full_backup_id, latest_backup_id, total_backups, total_size_bytes FROM backup_chains").
 			WithArgs("chain-test").
 			WillReturnRows(rows)
 
 		// Expect UPDATE query
 		mock.ExpectExec("UPDATE backup_chains").
-			WithArgs("backup-new", 2, int64(42949672960), sqlmock.AnyArg(), "chain-test").
+			WithArgs("backup-full", "backup-new", 2, int64(42949672960), sqlmock.AnyArg(), "chain-test").
 			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
 
-		err := cm.AddToChain(ctx, "chain-test", "backup-new", 0)
+		err := cm.AddBackupToChain(ctx, "chain-test", &Backup{ID: "backup-new", BackupType: BackupTypeIncremental})
 		if err != nil {
-			t.Errorf("AddToChain with zero size failed: %v", err)
+			t.Errorf("AddBackupToChain with zero size failed: %v", err)
 		}
 	})
 
@@ -324,4 +336,3 @@ func TestChainManagerEdgeCases(t *testing.T) {
 		t.Errorf("unfulfilled expectations: %v", err)
 	}
 }
-
@@ -28,7 +28,7 @@ func TestRepositoryError(t *testing.T) {
 				Op:  "list",
 				Err: errors.New("database connection failed"),
 			},
-			expectedMsg:  "repository : list: database connection failed",
+			expectedMsg:  "list: database connection failed",
 			expectedRepo: "",
 		},
 	}
@@ -60,34 +60,29 @@ func TestRepositoryErrorUnwrap(t *testing.T) {
 
 func TestBackupError(t *testing.T) {
 	tests := []struct {
-		name         string
-		err          *BackupError
-		expectedMsg  string
-		expectedID   string
-		expectedRepo string
+		name        string
+		err         *BackupError
+		expectedMsg string
+		expectedID  string
 	}{
 		{
 			name: "complete error",
 			err: &BackupError{
-				BackupID:     "backup-456",
-				RepositoryID: "repo-123",
-				Op:           "verify",
-				Err:          errors.New("checksum mismatch"),
+				BackupID: "backup-456",
+				Op:       "verify",
+				Err:      errors.New("checksum mismatch"),
 			},
-			expectedMsg:  "backup backup-456 in repository repo-123: verify: checksum mismatch",
-			expectedID:   "backup-456",
-			expectedRepo: "repo-123",
+			expectedMsg: "backup backup-456: verify: checksum mismatch",
+			expectedID:  "backup-456",
 		},
 		{
 			name: "error without backup ID",
 			err: &BackupError{
-				RepositoryID: "repo-123",
-				Op:           "create",
-				Err:          errors.New("insufficient space"),
+				Op:  "create",
+				Err: errors.New("insufficient space"),
 			},
-			expectedMsg:  "backup  in repository repo-123: create: insufficient space",
-			expectedID:   "",
-			expectedRepo: "repo-123",
+			expectedMsg: "create: insufficient space",
+			expectedID:  "",
 		},
 	}
 
@@ -99,9 +94,6 @@ func TestBackupError(t *testing.T) {
 			if got := tt.err.BackupID; got != tt.expectedID {
 				t.Errorf("BackupError.BackupID = %v, want %v", got, tt.expectedID)
 			}
-			if got := tt.err.RepositoryID; got != tt.expectedRepo {
-				t.Errorf("BackupError.RepositoryID = %v, want %v", got, tt.expectedRepo)
-			}
 		})
 	}
 }
@@ -109,10 +101,9 @@ func TestBackupError(t *testing.T) {
 func TestBackupErrorUnwrap(t *testing.T) {
 	innerErr := errors.New("inner error")
 	backupErr := &BackupError{
-		BackupID:     "backup-456",
-		RepositoryID: "repo-123",
-		Op:           "restore",
-		Err:          innerErr,
+		BackupID: "backup-456",
+		Op:       "restore",
+		Err:      innerErr,
 	}
 
 	if unwrapped := backupErr.Unwrap(); unwrapped != innerErr {
@@ -128,8 +119,8 @@ func TestPredefinedErrors(t *testing.T) {
 	}{
 		{"ErrRepositoryNotFound", ErrRepositoryNotFound, "repository not found"},
 		{"ErrBackupNotFound", ErrBackupNotFound, "backup not found"},
-		{"ErrInsufficientSpace", ErrInsufficientSpace, "insufficient storage space"},
-		{"ErrCorruptChain", ErrCorruptChain, "backup chain is corrupted"},
+		{"ErrRepositoryFull", ErrRepositoryFull, "repository has insufficient space"},
+		{"ErrBackupChainCorrupt", ErrBackupChainCorrupt, "backup chain is corrupt"},
 		{"ErrInvalidBackupType", ErrInvalidBackupType, "invalid backup type"},
 		{"ErrBackupInProgress", ErrBackupInProgress, "backup operation in progress"},
 	}
@@ -148,22 +139,21 @@ func TestErrorsIsComparison(t *testing.T) {
 	repoErr := &RepositoryError{
 		RepositoryID: "repo-123",
 		Op:           "create",
-		Err:          ErrInsufficientSpace,
+		Err:          ErrRepositoryFull,
 	}
 
-	if !errors.Is(repoErr, ErrInsufficientSpace) {
-		t.Error("errors.Is should identify wrapped ErrInsufficientSpace")
+	if !errors.Is(repoErr, ErrRepositoryFull) {
+		t.Error("errors.Is should identify wrapped ErrRepositoryFull")
 	}
 
 	backupErr := &BackupError{
-		BackupID:     "backup-456",
-		RepositoryID: "repo-123",
-		Op:           "verify",
-		Err:          ErrCorruptChain,
+		BackupID: "backup-456",
+		Op:       "verify",
+		Err:      ErrBackupChainCorrupt,
 	}
 
-	if !errors.Is(backupErr, ErrCorruptChain) {
-		t.Error("errors.Is should identify wrapped ErrCorruptChain")
+	if !errors.Is(backupErr, ErrBackupChainCorrupt) {
+		t.Error("errors.Is should identify wrapped ErrBackupChainCorrupt")
 	}
 }
 
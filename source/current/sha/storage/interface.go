@@ -42,7 +42,8 @@ type BackupRequest struct {
 	BackupType        BackupType     `json:"backup_type"`
 	ParentBackupID    string         `json:"parent_backup_id,omitempty"` // For incrementals (QCOW2 backing file)
 	TotalBytes        int64          `json:"total_bytes"`
-	ChangeID          string         `json:"change_id,omitempty"` // VMware CBT change ID
+	VMwareDiskKey     int            `json:"vmware_disk_key,omitempty"` // VMware disk key (2000, 2001...); 0 = DiskID + 2000
+	ChangeID          string         `json:"change_id,omitempty"`       // VMware CBT change ID
 	Metadata          BackupMetadata `json:"metadata"`
 }
 
@@ -66,16 +67,17 @@ type Backup struct {
 
 // BackupChain represents a full backup plus its incrementals.
 type BackupChain struct {
-	ID              string    `json:"id"`
-	VMContextID     string    `json:"vm_context_id"`
-	DiskID          int       `json:"disk_id"`
-	FullBackupID    string    `json:"full_backup_id"`
-	LatestBackupID  string    `json:"latest_backup_id"`
-	Backups         []*Backup `json:"backups"` // Ordered: full first, then incrementals
-	TotalBackups    int       `json:"total_backups"`
-	TotalSizeBytes  int64     `json:"total_size_bytes"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID             string     `json:"id"`
+	VMContextID    string     `json:"vm_context_id"`
+	DiskID         int        `json:"disk_id"`
+	FullBackupID   string     `json:"full_backup_id"`
+	LatestBackupID string     `json:"latest_backup_id"`
+	Backups        []*Backup  `json:"backups"` // Ordered: full first, then incrementals
+	TotalBackups   int        `json:"total_backups"`
+	TotalSizeBytes int64      `json:"total_size_bytes"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	ClosedAt       *time.Time `json:"closed_at,omitempty"` // Set when the disk left the VM; kept until retention expires it
 }
 
 // StorageInfo provides repository capacity information.
@@ -115,6 +117,7 @@ type BackupMetadata struct {
 	CloudStackInfo  *CloudStackMetadata  `json:"cloudstack_info,omitempty"`
 	HyperVInfo      *HyperVMetadata      `json:"hyperv_info,omitempty"`
 	AWSInfo         *AWSMetadata         `json:"aws_info,omitempty"`
+	KVMInfo         *KVMMetadata         `json:"kvm_info,omitempty"`
 	CustomMetadata  map[string]string    `json:"custom_metadata,omitempty"`
 }
 
@@ -146,6 +149,16 @@ type HyperVMetadata struct {
 	CheckpointID string `json:"checkpoint_id,omitempty"` // RCT checkpoint
 }
 
+// KVMMetadata contains KVM/libvirt-specific information. The libvirt checkpoint
+// (QEMU dirty bitmap) is part of the disk's change ID.
+type KVMMetadata struct {
+	LibvirtURI string `json:"libvirt_uri,omitempty"`
+	DomainUUID string `json:"domain_uuid"`
+	DomainName string `json:"domain_name"`
+	DiskTarget string `json:"disk_target"`           // Guest device name (vda, sdb...)
+	DiskSource string `json:"disk_source,omitempty"` // Image file or block device
+}
+
 // AWSMetadata contains AWS EC2-specific information.
 type AWSMetadata struct {
 	Region          string `json:"region"`
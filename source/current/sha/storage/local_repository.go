@@ -110,6 +110,16 @@ func (lr *LocalRepository) CreateBackup(ctx context.Context, req BackupRequest)
 				Err:      err,
 			}
 		}
+
+		// The overlay inherits the parent's size - grow it when the source disk was extended
+		if err := lr.growIncremental(ctx, backupPath, req.TotalBytes); err != nil {
+			os.Remove(backupPath)
+			return nil, &BackupError{
+				BackupID: backupID,
+				Op:       "resize_incremental",
+				Err:      err,
+			}
+		}
 	} else {
 		return nil, &BackupError{
 			BackupID: backupID,
@@ -175,8 +185,11 @@ func (lr *LocalRepository) CreateBackup(ctx context.Context, req BackupRequest)
 		// Calculate size in GB (round up)
 		sizeGB := (req.TotalBytes + 1073741823) / 1073741824
 		
-		// vmware_disk_key = disk_index + 2000 (standard VMware calculation)
-		vmwareDiskKey := req.DiskID + 2000
+		// vmware_disk_key from discovery, else disk_index + 2000 (standard VMware calculation)
+		vmwareDiskKey := req.VMwareDiskKey
+		if vmwareDiskKey == 0 {
+			vmwareDiskKey = req.DiskID + 2000
+		}
 		
 		// CRITICAL FIX: Use ParentJobID (not backup.ID) so completion API can find records!
 		_, diskErr := lr.db.ExecContext(ctx, diskQuery,
@@ -220,6 +233,31 @@ func (lr *LocalRepository) CreateBackup(ctx context.Context, req BackupRequest)
 	return backup, nil
 }
 
+// growIncremental resizes a new incremental overlay to the source disk's current size
+// when the disk grew since its parent backup. Disks never shrink in place.
+func (lr *LocalRepository) growIncremental(ctx context.Context, path string, totalBytes int64) error {
+	if totalBytes <= 0 {
+		return nil
+	}
+	virtualSize, err := lr.qcowManager.GetVirtualSize(ctx, path)
+	if err != nil {
+		return err
+	}
+	if totalBytes <= virtualSize {
+		return nil
+	}
+
+	if err := lr.qcowManager.Resize(ctx, path, totalBytes); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		"path":     path,
+		"old_size": virtualSize,
+		"new_size": totalBytes,
+	}).Info("📐 Grew incremental backup for resized disk")
+	return nil
+}
+
 // GetBackup retrieves backup metadata by ID.
 func (lr *LocalRepository) GetBackup(ctx context.Context, backupID string) (*Backup, error) {
 	var backup Backup
@@ -73,22 +73,20 @@ func TestBackupJSONSerialization(t *testing.T) {
 	completed := now.Add(1 * time.Hour)
 
 	backup := &Backup{
-		ID:                "backup-123",
-		VMContextID:       "ctx-vm1-20251004-153000",
-		VMName:            "test-vm",
-		RepositoryID:      "repo-456",
-		BackupType:        BackupTypeFull,
-		Status:            BackupStatusCompleted,
-		RepositoryPath:    "/backups/test-vm/backup-123.qcow2",
-		ParentBackupID:    "",
-		ChangeID:          "52 3c ec 11 9e 2c 4c 3d-87 4a c3 4e 85 f2 ea 95/446",
-		BytesTransferred:  42949672960,
-		TotalBytes:        42949672960,
-		CompressionEnabled: true,
-		ErrorMessage:      "",
-		CreatedAt:         now,
-		StartedAt:         &now,
-		CompletedAt:       &completed,
+		ID:             "backup-123",
+		VMContextID:    "ctx-vm1-20251004-153000",
+		VMName:         "test-vm",
+		DiskID:         1,
+		BackupType:     BackupTypeFull,
+		Status:         BackupStatusCompleted,
+		FilePath:       "/backups/test-vm/backup-123.qcow2",
+		ParentBackupID: "",
+		ChangeID:       "52 3c ec 11 9e 2c 4c 3d-87 4a c3 4e 85 f2 ea 95/446",
+		SizeBytes:      21474836480,
+		TotalBytes:     42949672960,
+		ErrorMessage:   "",
+		CreatedAt:      now,
+		CompletedAt:    &completed,
 	}
 
 	// Test serialization
@@ -116,11 +114,14 @@ func TestBackupJSONSerialization(t *testing.T) {
 	if decoded.Status != backup.Status {
 		t.Errorf("Status mismatch: got %v, want %v", decoded.Status, backup.Status)
 	}
-	if decoded.BytesTransferred != backup.BytesTransferred {
-		t.Errorf("BytesTransferred mismatch: got %v, want %v", decoded.BytesTransferred, backup.BytesTransferred)
+	if decoded.DiskID != backup.DiskID {
+		t.Errorf("DiskID mismatch: got %v, want %v", decoded.DiskID, backup.DiskID)
 	}
-	if decoded.CompressionEnabled != backup.CompressionEnabled {
-		t.Errorf("CompressionEnabled mismatch: got %v, want %v", decoded.CompressionEnabled, backup.CompressionEnabled)
+	if decoded.SizeBytes != backup.SizeBytes {
+		t.Errorf("SizeBytes mismatch: got %v, want %v", decoded.SizeBytes, backup.SizeBytes)
+	}
+	if decoded.CompletedAt == nil || !decoded.CompletedAt.Equal(completed) {
+		t.Errorf("CompletedAt mismatch: got %v, want %v", decoded.CompletedAt, completed)
 	}
 }
 
@@ -175,11 +176,10 @@ func TestBackupRequestValidation(t *testing.T) {
 		{
 			name: "valid full backup",
 			req: BackupRequest{
-				VMContextID:  "ctx-vm1-20251004-153000",
-				VMName:       "test-vm",
-				RepositoryID: "repo-456",
-				BackupType:   BackupTypeFull,
-				DiskID:       0,
+				VMContextID: "ctx-vm1-20251004-153000",
+				VMName:      "test-vm",
+				BackupType:  BackupTypeFull,
+				DiskID:      0,
 			},
 			wantErr: false,
 		},
@@ -188,7 +188,6 @@ func TestBackupRequestValidation(t *testing.T) {
 			req: BackupRequest{
 				VMContextID:    "ctx-vm1-20251004-153000",
 				VMName:         "test-vm",
-				RepositoryID:   "repo-456",
 				BackupType:     BackupTypeIncremental,
 				ParentBackupID: "backup-full-123",
 				DiskID:         0,
@@ -198,18 +197,16 @@ func TestBackupRequestValidation(t *testing.T) {
 		{
 			name: "missing vm_context_id",
 			req: BackupRequest{
-				VMName:       "test-vm",
-				RepositoryID: "repo-456",
-				BackupType:   BackupTypeFull,
+				VMName:     "test-vm",
+				BackupType: BackupTypeFull,
 			},
 			wantErr: true,
 		},
 		{
-			name: "missing repository_id",
+			name: "missing backup_type",
 			req: BackupRequest{
 				VMContextID: "ctx-vm1-20251004-153000",
 				VMName:      "test-vm",
-				BackupType:  BackupTypeFull,
 			},
 			wantErr: true,
 		},
@@ -217,7 +214,7 @@ func TestBackupRequestValidation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			hasError := (tt.req.VMContextID == "" || tt.req.RepositoryID == "")
+			hasError := (tt.req.VMContextID == "" || tt.req.BackupType == "")
 			if hasError != tt.wantErr {
 				t.Errorf("validation error expectation mismatch: got %v, want %v", hasError, tt.wantErr)
 			}
@@ -227,17 +224,13 @@ func TestBackupRequestValidation(t *testing.T) {
 
 func TestVMwareMetadataJSONSerialization(t *testing.T) {
 	metadata := &VMwareMetadata{
-		VMwareVMID:   "vm-123",
-		VCenterHost:  "vcenter.example.com",
-		Datacenter:   "DC1",
-		Datastore:    "datastore1",
-		VMXPath:      "[datastore1] test-vm/test-vm.vmx",
-		DiskPath:     "[datastore1] test-vm/test-vm.vmdk",
-		DiskUUID:     "6000C29a-1234-5678-9abc-def012345678",
-		ChangeID:     "52 3c ec 11 9e 2c 4c 3d-87 4a c3 4e 85 f2 ea 95/446",
-		CBTEnabled:   true,
-		DiskSizeGB:   100,
-		ProvisioningType: "thin",
+		VCenterUUID:   "4c4c4544-0051-3010-8057-b4c04f4d4e31",
+		VMwareVMID:    "vm-123",
+		VMwareDiskID:  "6000C29a-1234-5678-9abc-def012345678",
+		DiskPath:      "[datastore1] test-vm/test-vm.vmdk",
+		DatastoreName: "datastore1",
+		ChangeID:      "52 3c ec 11 9e 2c 4c 3d-87 4a c3 4e 85 f2 ea 95/446",
+		CBTEnabled:    true,
 	}
 
 	// Test serialization
@@ -262,8 +255,8 @@ func TestVMwareMetadataJSONSerialization(t *testing.T) {
 	if decoded.CBTEnabled != metadata.CBTEnabled {
 		t.Errorf("CBTEnabled mismatch: got %v, want %v", decoded.CBTEnabled, metadata.CBTEnabled)
 	}
-	if decoded.DiskSizeGB != metadata.DiskSizeGB {
-		t.Errorf("DiskSizeGB mismatch: got %v, want %v", decoded.DiskSizeGB, metadata.DiskSizeGB)
+	if decoded.VMwareDiskID != metadata.VMwareDiskID {
+		t.Errorf("VMwareDiskID mismatch: got %v, want %v", decoded.VMwareDiskID, metadata.VMwareDiskID)
 	}
 }
 
@@ -303,11 +296,11 @@ func TestStorageInfoJSONSerialization(t *testing.T) {
 
 func TestBackupMetadataFields(t *testing.T) {
 	backup := &Backup{
-		ID:           "backup-123",
-		VMContextID:  "ctx-vm1-20251004-153000",
-		VMName:       "test-vm",
-		RepositoryID: "repo-456",
-		BackupType:   BackupTypeFull,
+		ID:          "backup-123",
+		VMContextID: "ctx-vm1-20251004-153000",
+		VMName:      "test-vm",
+		FilePath:    "/backups/test-vm/backup-123.qcow2",
+		BackupType:  BackupTypeFull,
 	}
 
 	// Test that all expected fields are accessible
@@ -320,8 +313,8 @@ func TestBackupMetadataFields(t *testing.T) {
 	if backup.VMName == "" {
 		t.Error("VMName should not be empty")
 	}
-	if backup.RepositoryID == "" {
-		t.Error("RepositoryID should not be empty")
+	if backup.FilePath == "" {
+		t.Error("FilePath should not be empty")
 	}
 	if backup.BackupType != BackupTypeFull {
 		t.Errorf("BackupType = %v, want %v", backup.BackupType, BackupTypeFull)
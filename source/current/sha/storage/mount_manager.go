@@ -304,8 +304,7 @@ func (m *MountManager) GetMountInfo(mountPoint string) (*MountInfo, error) {
 	}
 
 	// Return a copy to prevent external modification
-	infoCopy := *info
-	return &infoCopy, nil
+	return copyMountInfo(info), nil
 }
 
 // GetMountsByRepository returns all mount points for a repository
@@ -331,13 +330,19 @@ func (m *MountManager) ListMounted() map[string]*MountInfo {
 	// Return copies to prevent external modification
 	mounts := make(map[string]*MountInfo, len(m.mountedPaths))
 	for path, info := range m.mountedPaths {
-		infoCopy := *info
-		mounts[path] = &infoCopy
+		mounts[path] = copyMountInfo(info)
 	}
 
 	return mounts
 }
 
+// copyMountInfo copies mount info including its mount options
+func copyMountInfo(info *MountInfo) *MountInfo {
+	infoCopy := *info
+	infoCopy.MountOptions = append([]string(nil), info.MountOptions...)
+	return &infoCopy
+}
+
 // isPathMounted checks if a path is mounted in the system (reads /proc/mounts)
 // This is a low-level check that doesn't rely on our internal state
 func (m *MountManager) isPathMounted(path string) (bool, error) {
@@ -8,9 +8,9 @@ import (
 )
 
 func TestNewQCOW2Manager(t *testing.T) {
-	qm := NewQCOW2Manager()
-	if qm == nil {
-		t.Fatal("NewQCOW2Manager returned nil")
+	qm := newTestQCOW2Manager(t)
+	if qm.qemuImgPath == "" {
+		t.Fatal("NewQCOW2Manager did not resolve qemu-img")
 	}
 }
 
@@ -22,21 +22,15 @@ func TestCreateFullBackup(t *testing.T) {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	qm := NewQCOW2Manager()
+	qm := newTestQCOW2Manager(t)
 	ctx := context.Background()
 
 	outputPath := filepath.Join(tmpDir, "test-backup.qcow2")
 	sizeBytes := int64(10737418240) // 10 GB
 
-	// Note: This test requires qemu-img to be installed
-	// In a real environment, we would skip this test if qemu-img is not available
-	if !isQemuImgAvailable() {
-		t.Skip("qemu-img not available, skipping test")
-	}
-
-	err = qm.CreateFullBackup(ctx, outputPath, sizeBytes, true)
+	err = qm.CreateFull(ctx, outputPath, sizeBytes)
 	if err != nil {
-		t.Fatalf("CreateFullBackup failed: %v", err)
+		t.Fatalf("CreateFull failed: %v", err)
 	}
 
 	// Verify file was created
@@ -52,25 +46,21 @@ func TestCreateIncrementalBackup(t *testing.T) {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	qm := NewQCOW2Manager()
+	qm := newTestQCOW2Manager(t)
 	ctx := context.Background()
 
-	if !isQemuImgAvailable() {
-		t.Skip("qemu-img not available, skipping test")
-	}
-
 	// Create base backup first
 	basePath := filepath.Join(tmpDir, "base.qcow2")
-	err = qm.CreateFullBackup(ctx, basePath, 10737418240, true)
+	err = qm.CreateFull(ctx, basePath, 10737418240)
 	if err != nil {
-		t.Fatalf("CreateFullBackup failed: %v", err)
+		t.Fatalf("CreateFull failed: %v", err)
 	}
 
 	// Create incremental backup
 	incrPath := filepath.Join(tmpDir, "incremental.qcow2")
-	err = qm.CreateIncrementalBackup(ctx, incrPath, basePath, true)
+	err = qm.CreateIncremental(ctx, incrPath, basePath)
 	if err != nil {
-		t.Fatalf("CreateIncrementalBackup failed: %v", err)
+		t.Fatalf("CreateIncremental failed: %v", err)
 	}
 
 	// Verify incremental file was created
@@ -96,19 +86,15 @@ func TestGetInfo(t *testing.T) {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	qm := NewQCOW2Manager()
+	qm := newTestQCOW2Manager(t)
 	ctx := context.Background()
 
-	if !isQemuImgAvailable() {
-		t.Skip("qemu-img not available, skipping test")
-	}
-
 	// Create a test backup
 	backupPath := filepath.Join(tmpDir, "test.qcow2")
 	sizeBytes := int64(10737418240)
-	err = qm.CreateFullBackup(ctx, backupPath, sizeBytes, true)
+	err = qm.CreateFull(ctx, backupPath, sizeBytes)
 	if err != nil {
-		t.Fatalf("CreateFullBackup failed: %v", err)
+		t.Fatalf("CreateFull failed: %v", err)
 	}
 
 	// Get info
@@ -126,13 +112,9 @@ func TestGetInfo(t *testing.T) {
 }
 
 func TestGetInfoNonExistent(t *testing.T) {
-	qm := NewQCOW2Manager()
+	qm := newTestQCOW2Manager(t)
 	ctx := context.Background()
 
-	if !isQemuImgAvailable() {
-		t.Skip("qemu-img not available, skipping test")
-	}
-
 	_, err := qm.GetInfo(ctx, "/nonexistent/path/backup.qcow2")
 	if err == nil {
 		t.Error("GetInfo should fail for non-existent file")
@@ -146,24 +128,20 @@ func TestVerifyBackup(t *testing.T) {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	qm := NewQCOW2Manager()
+	qm := newTestQCOW2Manager(t)
 	ctx := context.Background()
 
-	if !isQemuImgAvailable() {
-		t.Skip("qemu-img not available, skipping test")
-	}
-
 	// Create a test backup
 	backupPath := filepath.Join(tmpDir, "test.qcow2")
-	err = qm.CreateFullBackup(ctx, backupPath, 10737418240, true)
+	err = qm.CreateFull(ctx, backupPath, 10737418240)
 	if err != nil {
-		t.Fatalf("CreateFullBackup failed: %v", err)
+		t.Fatalf("CreateFull failed: %v", err)
 	}
 
 	// Verify it
-	err = qm.VerifyBackup(ctx, backupPath)
+	err = qm.Verify(ctx, backupPath)
 	if err != nil {
-		t.Fatalf("VerifyBackup failed: %v", err)
+		t.Fatalf("Verify failed: %v", err)
 	}
 }
 
@@ -174,13 +152,9 @@ func TestVerifyCorruptedBackup(t *testing.T) {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	qm := NewQCOW2Manager()
+	qm := newTestQCOW2Manager(t)
 	ctx := context.Background()
 
-	if !isQemuImgAvailable() {
-		t.Skip("qemu-img not available, skipping test")
-	}
-
 	// Create a corrupted file (not a valid QCOW2)
 	corruptPath := filepath.Join(tmpDir, "corrupt.qcow2")
 	err = os.WriteFile(corruptPath, []byte("not a qcow2 file"), 0644)
@@ -189,25 +163,31 @@ func TestVerifyCorruptedBackup(t *testing.T) {
 	}
 
 	// Verify should fail
-	err = qm.VerifyBackup(ctx, corruptPath)
+	err = qm.Verify(ctx, corruptPath)
 	if err == nil {
-		t.Error("VerifyBackup should fail for corrupted file")
+		t.Error("Verify should fail for corrupted file")
 	}
 }
 
 func TestCreateBackupWithInvalidPath(t *testing.T) {
-	qm := NewQCOW2Manager()
+	tmpDir, err := os.MkdirTemp("", "sendense-qcow2-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	qm := newTestQCOW2Manager(t)
 	ctx := context.Background()
 
-	if !isQemuImgAvailable() {
-		t.Skip("qemu-img not available, skipping test")
+	// CreateFull creates missing directories, so put the backup under a regular file
+	parentFile := filepath.Join(tmpDir, "not-a-directory")
+	if err := os.WriteFile(parentFile, nil, 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
 	}
-
-	// Try to create backup in non-existent directory
-	invalidPath := "/nonexistent/directory/backup.qcow2"
-	err := qm.CreateFullBackup(ctx, invalidPath, 10737418240, true)
+	invalidPath := filepath.Join(parentFile, "backup.qcow2")
+	err = qm.CreateFull(ctx, invalidPath, 10737418240)
 	if err == nil {
-		t.Error("CreateFullBackup should fail for invalid path")
+		t.Error("CreateFull should fail for invalid path")
 	}
 }
 
@@ -218,44 +198,22 @@ func TestCreateIncrementalWithMissingBase(t *testing.T) {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	qm := NewQCOW2Manager()
+	qm := newTestQCOW2Manager(t)
 	ctx := context.Background()
 
-	if !isQemuImgAvailable() {
-		t.Skip("qemu-img not available, skipping test")
-	}
-
 	incrPath := filepath.Join(tmpDir, "incremental.qcow2")
 	missingBase := filepath.Join(tmpDir, "nonexistent-base.qcow2")
 
-	err = qm.CreateIncrementalBackup(ctx, incrPath, missingBase, true)
+	err = qm.CreateIncremental(ctx, incrPath, missingBase)
 	if err == nil {
-		t.Error("CreateIncrementalBackup should fail when base file is missing")
+		t.Error("CreateIncremental should fail when base file is missing")
 	}
 }
 
 func TestQCOW2EdgeCases(t *testing.T) {
-	qm := NewQCOW2Manager()
+	qm := newTestQCOW2Manager(t)
 	ctx := context.Background()
 
-	if !isQemuImgAvailable() {
-		t.Skip("qemu-img not available, skipping test")
-	}
-
-	t.Run("zero size backup", func(t *testing.T) {
-		tmpDir, err := os.MkdirTemp("", "sendense-qcow2-test-*")
-		if err != nil {
-			t.Fatalf("failed to create temp dir: %v", err)
-		}
-		defer os.RemoveAll(tmpDir)
-
-		backupPath := filepath.Join(tmpDir, "zero.qcow2")
-		err = qm.CreateFullBackup(ctx, backupPath, 0, true)
-		if err == nil {
-			t.Error("CreateFullBackup should fail for zero size")
-		}
-	})
-
 	t.Run("negative size backup", func(t *testing.T) {
 		tmpDir, err := os.MkdirTemp("", "sendense-qcow2-test-*")
 		if err != nil {
@@ -264,22 +222,21 @@ func TestQCOW2EdgeCases(t *testing.T) {
 		defer os.RemoveAll(tmpDir)
 
 		backupPath := filepath.Join(tmpDir, "negative.qcow2")
-		err = qm.CreateFullBackup(ctx, backupPath, -1000, true)
+		err = qm.CreateFull(ctx, backupPath, -1000)
 		if err == nil {
-			t.Error("CreateFullBackup should fail for negative size")
+			t.Error("CreateFull should fail for negative size")
 		}
 	})
 }
 
-// Helper function to check if qemu-img is available
-func isQemuImgAvailable() bool {
-	_, err := os.Stat("/usr/bin/qemu-img")
-	if err == nil {
-		return true
+// newTestQCOW2Manager returns a QCOW2Manager, skipping the test when qemu-img is not installed
+func newTestQCOW2Manager(t *testing.T) *QCOW2Manager {
+	t.Helper()
+	qm, err := NewQCOW2Manager()
+	if err != nil {
+		t.Skipf("qemu-img not available, skipping test: %v", err)
 	}
-	// Try alternative path
-	_, err = os.Stat("/usr/local/bin/qemu-img")
-	return err == nil
+	return qm
 }
 
 func TestQCOW2InfoParsing(t *testing.T) {
@@ -82,9 +82,9 @@ func TestNFSRepositoryConfig(t *testing.T) {
 		{
 			name: "valid config",
 			config: NFSRepositoryConfig{
-				Server:     "nfs.example.com",
-				ExportPath: "/exports/backups",
-				MountOptions: []string{"ro", "nolock"},
+				Server:       "nfs.example.com",
+				ExportPath:   "/exports/backups",
+				MountOptions: "ro,nolock",
 			},
 			wantErr: false,
 		},
@@ -125,11 +125,11 @@ func TestCIFSRepositoryConfig(t *testing.T) {
 		{
 			name: "valid config",
 			config: CIFSRepositoryConfig{
-				Server:    "10.0.100.50",
-				ShareName: "backups",
-				Domain:    "EXAMPLE",
-				Username:  "backup_user",
-				Password:  "secure_password",
+				Server:         "10.0.100.50",
+				ShareName:      "backups",
+				Domain:         "EXAMPLE",
+				Username:       "backup_user",
+				PasswordSecret: "secret-ref-123",
 			},
 			wantErr: false,
 		},
@@ -168,29 +168,27 @@ func TestImmutableConfig(t *testing.T) {
 		valid  bool
 	}{
 		{
-			name: "linux chattr method",
+			name: "linux chattr type",
 			config: ImmutableConfig{
-				Method:              ImmutableMethodLinuxChattr,
-				RetentionPeriodDays: 30,
-				Locked:              true,
+				Type:             ImmutableTypeLinuxChattr,
+				MinRetentionDays: 30,
+				Config:           LinuxImmutableConfig{GracePeriodDays: 1},
 			},
 			valid: true,
 		},
 		{
-			name: "s3 object lock method",
+			name: "s3 object lock type",
 			config: ImmutableConfig{
-				Method:              ImmutableMethodS3ObjectLock,
-				RetentionPeriodDays: 60,
-				Locked:              true,
+				Type:             ImmutableTypeS3Lock,
+				MinRetentionDays: 60,
 			},
 			valid: true,
 		},
 		{
-			name: "azure worm method",
+			name: "azure worm type",
 			config: ImmutableConfig{
-				Method:              ImmutableMethodAzureWORM,
-				RetentionPeriodDays: 90,
-				Locked:              true,
+				Type:             ImmutableTypeAzureWORM,
+				MinRetentionDays: 90,
 			},
 			valid: true,
 		},
@@ -198,19 +196,19 @@ func TestImmutableConfig(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			validMethods := map[ImmutableMethod]bool{
-				ImmutableMethodLinuxChattr:  true,
-				ImmutableMethodS3ObjectLock: true,
-				ImmutableMethodAzureWORM:    true,
+			validTypes := map[ImmutableType]bool{
+				ImmutableTypeLinuxChattr: true,
+				ImmutableTypeS3Lock:      true,
+				ImmutableTypeAzureWORM:   true,
 			}
 
-			isValid := validMethods[tt.config.Method]
+			isValid := validTypes[tt.config.Type]
 			if isValid != tt.valid {
-				t.Errorf("ImmutableConfig method %q validity = %v, want %v", tt.config.Method, isValid, tt.valid)
+				t.Errorf("ImmutableConfig type %q validity = %v, want %v", tt.config.Type, isValid, tt.valid)
 			}
 
-			if tt.config.RetentionPeriodDays <= 0 {
-				t.Error("RetentionPeriodDays should be positive")
+			if tt.config.MinRetentionDays <= 0 {
+				t.Error("MinRetentionDays should be positive")
 			}
 		})
 	}
@@ -229,9 +227,8 @@ func TestRepositoryConfigComplete(t *testing.T) {
 		},
 		IsImmutable: true,
 		ImmutableConfig: &ImmutableConfig{
-			Method:              ImmutableMethodLinuxChattr,
-			RetentionPeriodDays: 30,
-			Locked:              true,
+			Type:             ImmutableTypeLinuxChattr,
+			MinRetentionDays: 30,
 		},
 		MinRetentionDays: 30,
 		TotalBytes:       10737418240000,
@@ -289,9 +286,9 @@ func TestValidatePath(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name:    "non-existent directory",
+			name:    "non-existent directory is created",
 			path:    filepath.Join(tmpDir, "nonexistent"),
-			wantErr: true,
+			wantErr: false,
 		},
 		{
 			name: "file instead of directory",
@@ -319,23 +316,6 @@ func TestValidatePath(t *testing.T) {
 	}
 }
 
-func TestGetMountPoint(t *testing.T) {
-	config := LocalRepositoryConfig{
-		Path: "/mnt/backups/primary",
-	}
-
-	mountPoint := getMountPoint(config.Path)
-	
-	// The mount point should be a parent or the path itself
-	if mountPoint == "" {
-		t.Error("getMountPoint should not return empty string")
-	}
-
-	// For this test, we just verify it returns something
-	// In a real scenario, it would detect the actual mount point
-	t.Logf("Mount point detected: %s", mountPoint)
-}
-
 func TestRepositoryConfigTypeAssertion(t *testing.T) {
 	// Test that we can properly type assert Config interface
 	localConfig := LocalRepositoryConfig{Path: "/mnt/backups"}
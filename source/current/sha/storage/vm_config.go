@@ -0,0 +1,233 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/vexxhost/migratekit-sha/models"
+)
+
+// VMConfigSchemaVersion is the current VM configuration sidecar format.
+// Bump it when VMConfiguration changes incompatibly.
+const VMConfigSchemaVersion = 1
+
+// VM configuration change types.
+const (
+	VMConfigChangeModified = "modified"
+	VMConfigChangeAdded    = "added"
+	VMConfigChangeRemoved  = "removed"
+)
+
+// VMConfigSidecar is the VM configuration captured for a backup, stored as
+// config/<backup_id>.vmconfig.json under the VM's repository directory.
+type VMConfigSidecar struct {
+	SchemaVersion int                    `json:"schema_version"`
+	BackupID      string                 `json:"backup_id"`
+	VMContextID   string                 `json:"vm_context_id"`
+	VMName        string                 `json:"vm_name"`
+	CapturedAt    time.Time              `json:"captured_at"`
+	Config        models.VMConfiguration `json:"config"`
+}
+
+// VMConfigChange describes one difference between two VM configurations.
+type VMConfigChange struct {
+	Field      string      `json:"field"`       // e.g. "memory_mb", "nics[4000].mac_address"
+	ChangeType string      `json:"change_type"` // modified, added, removed
+	OldValue   interface{} `json:"old_value,omitempty"`
+	NewValue   interface{} `json:"new_value,omitempty"`
+}
+
+// GetVMConfigPath returns the sidecar path for a backup's VM configuration.
+func GetVMConfigPath(basePath, vmContextID, backupID string) string {
+	return filepath.Join(basePath, vmContextID, "config", backupID+".vmconfig.json")
+}
+
+// GetVMConfigPathForDisk returns the VM configuration sidecar path for a backup,
+// derived from one of its disk files (basePath/vmContextID/disk-N/file.qcow2).
+func GetVMConfigPathForDisk(diskFilePath, backupID string) string {
+	vmDir := filepath.Dir(filepath.Dir(diskFilePath))
+	return filepath.Join(vmDir, "config", backupID+".vmconfig.json")
+}
+
+// SaveVMConfig writes a VM configuration sidecar.
+func SaveVMConfig(path string, sidecar *VMConfigSidecar) error {
+	if sidecar.SchemaVersion == 0 {
+		sidecar.SchemaVersion = VMConfigSchemaVersion
+	}
+	return saveJSONFile(path, sidecar)
+}
+
+// LoadVMConfig reads a VM configuration sidecar.
+func LoadVMConfig(path string) (*VMConfigSidecar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read VM config: %w", err)
+	}
+
+	var sidecar VMConfigSidecar
+	if err := json.Unmarshal(data, &sidecar); err != nil {
+		return nil, fmt.Errorf("failed to parse VM config: %w", err)
+	}
+
+	if sidecar.SchemaVersion > VMConfigSchemaVersion {
+		return nil, fmt.Errorf("unsupported VM config schema version %d (max %d)",
+			sidecar.SchemaVersion, VMConfigSchemaVersion)
+	}
+
+	return &sidecar, nil
+}
+
+// DiffVMConfig lists the configuration changes from old to new.
+// Devices are matched by their VMware device key, so reordering is not a change.
+func DiffVMConfig(old, new *models.VMConfiguration) []VMConfigChange {
+	var changes []VMConfigChange
+
+	field := func(name string, o, n interface{}) {
+		if o != n {
+			changes = append(changes, VMConfigChange{Field: name, ChangeType: VMConfigChangeModified, OldValue: o, NewValue: n})
+		}
+	}
+
+	field("name", old.Name, new.Name)
+	field("guest_id", old.GuestID, new.GuestID)
+	field("hardware_version", old.HardwareVersion, new.HardwareVersion)
+	field("annotation", old.Annotation, new.Annotation)
+	field("folder_path", old.FolderPath, new.FolderPath)
+	field("num_cpus", old.NumCPUs, new.NumCPUs)
+	field("cores_per_socket", old.CoresPerSocket, new.CoresPerSocket)
+	field("memory_mb", old.MemoryMB, new.MemoryMB)
+	field("cpu_hot_add_enabled", old.CPUHotAddEnabled, new.CPUHotAddEnabled)
+	field("memory_hot_add_enabled", old.MemHotAddEnabled, new.MemHotAddEnabled)
+	field("cpu_reservation_mhz", old.CPUReservationMHz, new.CPUReservationMHz)
+	field("memory_reservation_mb", old.MemReservationMB, new.MemReservationMB)
+	field("firmware", old.Firmware, new.Firmware)
+	field("secure_boot", old.SecureBoot, new.SecureBoot)
+	field("vtpm", old.VTPM, new.VTPM)
+
+	// NICs
+	oldNICs := make(map[int32]models.VMConfigNIC, len(old.NICs))
+	for _, nic := range old.NICs {
+		oldNICs[nic.Key] = nic
+	}
+	for _, nic := range new.NICs {
+		prefix := fmt.Sprintf("nics[%d]", nic.Key)
+		prev, ok := oldNICs[nic.Key]
+		if !ok {
+			changes = append(changes, VMConfigChange{Field: prefix, ChangeType: VMConfigChangeAdded, NewValue: nic})
+			continue
+		}
+		delete(oldNICs, nic.Key)
+		field(prefix+".adapter_type", prev.AdapterType, nic.AdapterType)
+		field(prefix+".mac_address", prev.MACAddress, nic.MACAddress)
+		field(prefix+".network_name", prev.NetworkName, nic.NetworkName)
+		field(prefix+".connected", prev.Connected, nic.Connected)
+		field(prefix+".start_connected", prev.StartConnected, nic.StartConnected)
+	}
+	for _, key := range sortedKeys(oldNICs) {
+		changes = append(changes, VMConfigChange{Field: fmt.Sprintf("nics[%d]", key), ChangeType: VMConfigChangeRemoved, OldValue: oldNICs[key]})
+	}
+
+	// Controllers
+	oldControllers := make(map[int32]models.VMConfigController, len(old.Controllers))
+	for _, c := range old.Controllers {
+		oldControllers[c.Key] = c
+	}
+	for _, c := range new.Controllers {
+		prefix := fmt.Sprintf("controllers[%d]", c.Key)
+		prev, ok := oldControllers[c.Key]
+		if !ok {
+			changes = append(changes, VMConfigChange{Field: prefix, ChangeType: VMConfigChangeAdded, NewValue: c})
+			continue
+		}
+		delete(oldControllers, c.Key)
+		field(prefix+".type", prev.Type, c.Type)
+		field(prefix+".bus_number", prev.BusNumber, c.BusNumber)
+		field(prefix+".shared_bus", prev.SharedBus, c.SharedBus)
+	}
+	for _, key := range sortedKeys(oldControllers) {
+		changes = append(changes, VMConfigChange{Field: fmt.Sprintf("controllers[%d]", key), ChangeType: VMConfigChangeRemoved, OldValue: oldControllers[key]})
+	}
+
+	// Disks
+	oldDisks := make(map[int32]models.VMConfigDisk, len(old.Disks))
+	for _, d := range old.Disks {
+		oldDisks[d.Key] = d
+	}
+	for _, d := range new.Disks {
+		prefix := fmt.Sprintf("disks[%d]", d.Key)
+		prev, ok := oldDisks[d.Key]
+		if !ok {
+			changes = append(changes, VMConfigChange{Field: prefix, ChangeType: VMConfigChangeAdded, NewValue: d})
+			continue
+		}
+		delete(oldDisks, d.Key)
+		field(prefix+".controller_key", prev.ControllerKey, d.ControllerKey)
+		field(prefix+".unit_number", prev.UnitNumber, d.UnitNumber)
+		field(prefix+".capacity_bytes", prev.CapacityBytes, d.CapacityBytes)
+		field(prefix+".disk_mode", prev.DiskMode, d.DiskMode)
+	}
+	for _, key := range sortedKeys(oldDisks) {
+		changes = append(changes, VMConfigChange{Field: fmt.Sprintf("disks[%d]", key), ChangeType: VMConfigChangeRemoved, OldValue: oldDisks[key]})
+	}
+
+	// Custom attributes
+	attrNames := make(map[string]bool)
+	for name := range old.CustomAttributes {
+		attrNames[name] = true
+	}
+	for name := range new.CustomAttributes {
+		attrNames[name] = true
+	}
+	names := make([]string, 0, len(attrNames))
+	for name := range attrNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		o, hadOld := old.CustomAttributes[name]
+		n, hasNew := new.CustomAttributes[name]
+		prefix := fmt.Sprintf("custom_attributes[%s]", name)
+		switch {
+		case !hadOld:
+			changes = append(changes, VMConfigChange{Field: prefix, ChangeType: VMConfigChangeAdded, NewValue: n})
+		case !hasNew:
+			changes = append(changes, VMConfigChange{Field: prefix, ChangeType: VMConfigChangeRemoved, OldValue: o})
+		default:
+			field(prefix, o, n)
+		}
+	}
+
+	// Tags
+	oldTags := make(map[models.VMConfigTag]bool, len(old.Tags))
+	for _, tag := range old.Tags {
+		oldTags[tag] = true
+	}
+	newTags := make(map[models.VMConfigTag]bool, len(new.Tags))
+	for _, tag := range new.Tags {
+		newTags[tag] = true
+		if !oldTags[tag] {
+			changes = append(changes, VMConfigChange{Field: "tags", ChangeType: VMConfigChangeAdded, NewValue: tag})
+		}
+	}
+	for _, tag := range old.Tags {
+		if !newTags[tag] {
+			changes = append(changes, VMConfigChange{Field: "tags", ChangeType: VMConfigChangeRemoved, OldValue: tag})
+		}
+	}
+
+	return changes
+}
+
+// sortedKeys returns device keys in ascending order for deterministic diffs.
+func sortedKeys[T any](m map[int32]T) []int32 {
+	keys := make([]int32, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vexxhost/migratekit-sha/models"
+)
+
+func testVMConfig() models.VMConfiguration {
+	return models.VMConfiguration{
+		UUID:            "4213a1b2-0000-0000-0000-000000000001",
+		Name:            "web-01",
+		GuestID:         "ubuntu64Guest",
+		HardwareVersion: "vmx-19",
+		NumCPUs:         4,
+		CoresPerSocket:  2,
+		MemoryMB:        8192,
+		Firmware:        "efi",
+		SecureBoot:      true,
+		NICs: []models.VMConfigNIC{
+			{Key: 4000, AdapterType: "vmxnet3", MACAddress: "00:50:56:aa:bb:01", NetworkName: "VM Network", Connected: true},
+		},
+		Controllers: []models.VMConfigController{
+			{Key: 1000, Type: "pvscsi", BusNumber: 0},
+		},
+		Disks: []models.VMConfigDisk{
+			{Key: 2000, ControllerKey: 1000, UnitNumber: 0, CapacityBytes: 40 << 30},
+		},
+		CustomAttributes: map[string]string{"owner": "platform"},
+		Tags:             []models.VMConfigTag{{Category: "tier", Name: "gold"}},
+	}
+}
+
+func TestVMConfigSidecarRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := GetVMConfigPath(dir, "ctx-web-01", "backup-web-01-1700000000")
+
+	if want := filepath.Join(dir, "ctx-web-01", "config", "backup-web-01-1700000000.vmconfig.json"); path != want {
+		t.Fatalf("GetVMConfigPath() = %q, want %q", path, want)
+	}
+
+	sidecar := &VMConfigSidecar{
+		BackupID:    "backup-web-01-1700000000",
+		VMContextID: "ctx-web-01",
+		VMName:      "web-01",
+		CapturedAt:  time.Now().UTC().Truncate(time.Second),
+		Config:      testVMConfig(),
+	}
+	if err := SaveVMConfig(path, sidecar); err != nil {
+		t.Fatalf("SaveVMConfig() error = %v", err)
+	}
+
+	loaded, err := LoadVMConfig(path)
+	if err != nil {
+		t.Fatalf("LoadVMConfig() error = %v", err)
+	}
+	if loaded.SchemaVersion != VMConfigSchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", loaded.SchemaVersion, VMConfigSchemaVersion)
+	}
+	if loaded.Config.Firmware != "efi" || !loaded.Config.SecureBoot {
+		t.Errorf("firmware not preserved: %+v", loaded.Config)
+	}
+	if len(loaded.Config.NICs) != 1 || loaded.Config.NICs[0].MACAddress != "00:50:56:aa:bb:01" {
+		t.Errorf("NICs not preserved: %+v", loaded.Config.NICs)
+	}
+	if changes := DiffVMConfig(&sidecar.Config, &loaded.Config); len(changes) != 0 {
+		t.Errorf("round trip produced changes: %+v", changes)
+	}
+}
+
+func TestGetVMConfigPathForDisk(t *testing.T) {
+	diskPath := GetBackupFilePath("/backups", "ctx-web-01", 1, "backup-web-01-disk1-20250101-000000")
+	got := GetVMConfigPathForDisk(diskPath, "backup-web-01-1700000000")
+
+	if want := GetVMConfigPath("/backups", "ctx-web-01", "backup-web-01-1700000000"); got != want {
+		t.Errorf("GetVMConfigPathForDisk() = %q, want %q", got, want)
+	}
+}
+
+func TestDiffVMConfig(t *testing.T) {
+	old := testVMConfig()
+	updated := testVMConfig()
+	updated.MemoryMB = 16384
+	updated.NICs = []models.VMConfigNIC{
+		{Key: 4000, AdapterType: "vmxnet3", MACAddress: "00:50:56:aa:bb:02", NetworkName: "VM Network", Connected: true},
+		{Key: 4001, AdapterType: "vmxnet3", MACAddress: "00:50:56:aa:bb:03", NetworkName: "Backup"},
+	}
+	updated.Disks = nil
+	updated.CustomAttributes = map[string]string{"owner": "dba"}
+	updated.Tags = []models.VMConfigTag{{Category: "tier", Name: "silver"}}
+
+	changes := DiffVMConfig(&old, &updated)
+
+	want := map[string]string{
+		"memory_mb":                VMConfigChangeModified,
+		"nics[4000].mac_address":   VMConfigChangeModified,
+		"nics[4001]":               VMConfigChangeAdded,
+		"disks[2000]":              VMConfigChangeRemoved,
+		"custom_attributes[owner]": VMConfigChangeModified,
+	}
+	got := make(map[string]string)
+	tagChanges := 0
+	for _, c := range changes {
+		if c.Field == "tags" {
+			tagChanges++
+			continue
+		}
+		got[c.Field] = c.ChangeType
+	}
+
+	for field, changeType := range want {
+		if got[field] != changeType {
+			t.Errorf("change for %s = %q, want %q", field, got[field], changeType)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d field changes, want %d: %+v", len(got), len(want), changes)
+	}
+	if tagChanges != 2 {
+		t.Errorf("got %d tag changes, want 2 (one removed, one added)", tagChanges)
+	}
+}
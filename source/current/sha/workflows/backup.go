@@ -6,12 +6,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 
 	"github.com/vexxhost/migratekit-sha/database"
 	"github.com/vexxhost/migratekit-sha/services"
@@ -36,6 +39,7 @@ type BackupEngine struct {
 
 	// SNA client for triggering replications
 	snaAPIEndpoint string
+	snaRouter      *services.SNARouter // Optional: routes each backup to the SNA serving the VM
 	snaClient      *http.Client
 }
 
@@ -66,14 +70,20 @@ func NewBackupEngine(
 	}
 }
 
+// SetSNARouter routes backups to the SNA serving each VM's placement
+func (be *BackupEngine) SetSNARouter(router *services.SNARouter) {
+	be.snaRouter = router
+}
+
 // BackupRequest represents a request to create a VM backup
 type BackupRequest struct {
 	// VM identification
-	VMContextID       string `json:"vm_context_id"`        // Required: VM replication context identifier (legacy)
-	VMBackupContextID string `json:"vm_backup_context_id"` // Required: VM backup context identifier (NEW ARCHITECTURE!)
-	ParentJobID       string `json:"parent_job_id"`        // Required: Parent backup job ID (for backup_disks FK)
-	VMName            string `json:"vm_name"`              // Required: VM name
-	DiskID            int    `json:"disk_id"`              // Required: Disk number (0, 1, 2...)
+	VMContextID       string `json:"vm_context_id"`             // Required: VM replication context identifier (legacy)
+	VMBackupContextID string `json:"vm_backup_context_id"`      // Required: VM backup context identifier (NEW ARCHITECTURE!)
+	ParentJobID       string `json:"parent_job_id"`             // Required: Parent backup job ID (for backup_disks FK)
+	VMName            string `json:"vm_name"`                   // Required: VM name
+	DiskID            int    `json:"disk_id"`                   // Required: Disk number (0, 1, 2...)
+	VMwareDiskKey     int    `json:"vmware_disk_key,omitempty"` // Optional: VMware device key, kept stable across disk changes
 
 	// Backup configuration
 	RepositoryID string             `json:"repository_id"` // Required: Target repository
@@ -85,9 +95,9 @@ type BackupRequest struct {
 	PreviousChangeID string `json:"previous_change_id,omitempty"` // For incremental backups
 
 	// Metadata
-	TotalBytes int64                  `json:"total_bytes"`           // VM disk total size
-	Metadata   storage.BackupMetadata `json:"metadata,omitempty"`    // Platform-specific metadata
-	Tags       map[string]string      `json:"tags,omitempty"`        // Custom tags
+	TotalBytes int64                  `json:"total_bytes"`        // VM disk total size
+	Metadata   storage.BackupMetadata `json:"metadata,omitempty"` // Platform-specific metadata
+	Tags       map[string]string      `json:"tags,omitempty"`     // Custom tags
 }
 
 // BackupResult represents the result of a backup operation
@@ -97,8 +107,8 @@ type BackupResult struct {
 	BackupType       storage.BackupType   `json:"backup_type"`
 	FilePath         string               `json:"file_path"`
 	NBDExportName    string               `json:"nbd_export_name,omitempty"`
-	NBDPort          int                  `json:"nbd_port,omitempty"`          // qemu-nbd port
-	QemuNBDPID       int                  `json:"qemu_nbd_pid,omitempty"`      // qemu-nbd process ID
+	NBDPort          int                  `json:"nbd_port,omitempty"`     // qemu-nbd port
+	QemuNBDPID       int                  `json:"qemu_nbd_pid,omitempty"` // qemu-nbd process ID
 	BytesTransferred int64                `json:"bytes_transferred"`
 	TotalBytes       int64                `json:"total_bytes"`
 	ChangeID         string               `json:"change_id,omitempty"`
@@ -140,6 +150,7 @@ func (be *BackupEngine) ExecuteBackup(ctx context.Context, req *BackupRequest) (
 		ParentBackupID:    "", // Will be set for incrementals (QCOW2 backing file)
 		TotalBytes:        req.TotalBytes,
 		ChangeID:          req.ChangeID,
+		VMwareDiskKey:     req.VMwareDiskKey,
 		Metadata:          req.Metadata,
 	}
 
@@ -176,7 +187,7 @@ func (be *BackupEngine) ExecuteBackup(ctx context.Context, req *BackupRequest) (
 	// Allocate NBD port
 	exportName := fmt.Sprintf("%s-disk%d", req.VMName, req.DiskID)
 	diskJobID := fmt.Sprintf("%s-disk%d", backup.ID, req.DiskID)
-	
+
 	nbdPort, err := be.portAllocator.Allocate(diskJobID, req.VMName, exportName)
 	if err != nil {
 		// Cleanup: Delete backup file if port allocation fails
@@ -233,16 +244,16 @@ func (be *BackupEngine) ExecuteBackup(ctx context.Context, req *BackupRequest) (
 
 	// Build result
 	result := &BackupResult{
-		BackupID:       backup.ID,
-		Status:         backup.Status,
-		BackupType:     backup.BackupType,
-		FilePath:       backup.FilePath,
-		NBDExportName:  exportName,
-		NBDPort:        nbdPort,
-		QemuNBDPID:     qemuProcess.PID,
-		TotalBytes:     backup.TotalBytes,
-		ChangeID:       backup.ChangeID,
-		CreatedAt:      backup.CreatedAt,
+		BackupID:      backup.ID,
+		Status:        backup.Status,
+		BackupType:    backup.BackupType,
+		FilePath:      backup.FilePath,
+		NBDExportName: exportName,
+		NBDPort:       nbdPort,
+		QemuNBDPID:    qemuProcess.PID,
+		TotalBytes:    backup.TotalBytes,
+		ChangeID:      backup.ChangeID,
+		CreatedAt:     backup.CreatedAt,
 	}
 
 	log.WithFields(log.Fields{
@@ -287,6 +298,7 @@ func (be *BackupEngine) PrepareBackupDisk(ctx context.Context, req *BackupReques
 		ParentBackupID:    "", // Will be set for incrementals (QCOW2 backing file)
 		TotalBytes:        req.TotalBytes,
 		ChangeID:          req.ChangeID,
+		VMwareDiskKey:     req.VMwareDiskKey,
 		Metadata:          req.Metadata,
 	}
 
@@ -323,7 +335,7 @@ func (be *BackupEngine) PrepareBackupDisk(ctx context.Context, req *BackupReques
 	// Allocate NBD port
 	exportName := fmt.Sprintf("%s-disk%d", req.VMName, req.DiskID)
 	diskJobID := fmt.Sprintf("%s-disk%d", backup.ID, req.DiskID)
-	
+
 	nbdPort, err := be.portAllocator.Allocate(diskJobID, req.VMName, exportName)
 	if err != nil {
 		// Cleanup: Delete backup file if port allocation fails
@@ -360,16 +372,16 @@ func (be *BackupEngine) PrepareBackupDisk(ctx context.Context, req *BackupReques
 
 	// Build result (WITHOUT triggering SNA)
 	result := &BackupResult{
-		BackupID:       backup.ID,
-		Status:         backup.Status,
-		BackupType:     backup.BackupType,
-		FilePath:       backup.FilePath,
-		NBDExportName:  exportName,
-		NBDPort:        nbdPort,
-		QemuNBDPID:     qemuProcess.PID,
-		TotalBytes:     backup.TotalBytes,
-		ChangeID:       backup.ChangeID,
-		CreatedAt:      backup.CreatedAt,
+		BackupID:      backup.ID,
+		Status:        backup.Status,
+		BackupType:    backup.BackupType,
+		FilePath:      backup.FilePath,
+		NBDExportName: exportName,
+		NBDPort:       nbdPort,
+		QemuNBDPID:    qemuProcess.PID,
+		TotalBytes:    backup.TotalBytes,
+		ChangeID:      backup.ChangeID,
+		CreatedAt:     backup.CreatedAt,
 	}
 
 	log.WithFields(log.Fields{
@@ -478,8 +490,12 @@ func (be *BackupEngine) triggerSNAReplication(
 		return "", fmt.Errorf("failed to marshal SNA request: %w", err)
 	}
 
-	// Call SNA API to start replication
-	snaURL := fmt.Sprintf("%s/api/v1/replicate", be.snaAPIEndpoint)
+	// Call SNA API to start replication on the SNA serving the VM
+	snaEndpoint, err := be.snaRouter.RouteJob(ctx, backup.ID, "backup", vmContext.ContextID, be.snaAPIEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("failed to route backup to SNA: %w", err)
+	}
+	snaURL := fmt.Sprintf("%s/api/v1/replicate", snaEndpoint)
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", snaURL, bytes.NewReader(requestBody))
 	if err != nil {
 		return "", fmt.Errorf("failed to create SNA request: %w", err)
@@ -521,7 +537,7 @@ func (be *BackupEngine) updateBackupJobStatus(
 	status string,
 ) error {
 	now := time.Now()
-	
+
 	// Update existing backup_job record (created by storage layer)
 	err := be.db.GetGormDB().Model(&database.BackupJob{}).
 		Where("id = ?", backupID).
@@ -529,7 +545,7 @@ func (be *BackupEngine) updateBackupJobStatus(
 			"status":     status,
 			"started_at": now,
 		}).Error
-	
+
 	if err != nil {
 		return fmt.Errorf("failed to update backup job status: %w", err)
 	}
@@ -578,7 +594,7 @@ func (be *BackupEngine) CompleteBackup(ctx context.Context, backupID string, dis
 	}).Info("📝 Completing backup disk")
 
 	now := time.Now()
-	
+
 	// NEW ARCHITECTURE: Update backup_disks table directly (no time-window hack!)
 	result := be.db.GetGormDB().
 		Model(&database.BackupDisk{}).
@@ -589,15 +605,15 @@ func (be *BackupEngine) CompleteBackup(ctx context.Context, backupID string, dis
 			"bytes_transferred": bytesTransferred,
 			"completed_at":      now,
 		})
-	
+
 	if result.Error != nil {
 		return fmt.Errorf("failed to update backup disk: %w", result.Error)
 	}
-	
+
 	if result.RowsAffected == 0 {
 		return fmt.Errorf("backup disk not found: job_id=%s disk_index=%d", backupID, diskID)
 	}
-	
+
 	log.WithFields(log.Fields{
 		"backup_id": backupID,
 		"disk_id":   diskID,
@@ -611,27 +627,27 @@ func (be *BackupEngine) CompleteBackup(ctx context.Context, backupID string, dis
 	be.db.GetGormDB().Model(&database.BackupDisk{}).
 		Where("backup_job_id = ? AND status = ?", backupID, "completed").
 		Count(&completedDisks)
-	
+
 	log.WithFields(log.Fields{
 		"backup_id":       backupID,
 		"total_disks":     totalDisks,
 		"completed_disks": completedDisks,
 	}).Debug("Checking backup completion status")
-	
+
 	// If all disks completed, mark parent backup_jobs record as completed
 	if totalDisks > 0 && totalDisks == completedDisks {
 		// Get current job to check if telemetry already set bytes_transferred
 		var currentJob database.BackupJob
 		be.db.GetGormDB().Where("id = ?", backupID).First(&currentJob)
-		
+
 		// Preserve telemetry data if present, otherwise aggregate from disks
 		var finalBytesTransferred int64
 		if currentJob.BytesTransferred > 0 {
 			// Telemetry already set this - keep it!
 			finalBytesTransferred = currentJob.BytesTransferred
 			log.WithFields(log.Fields{
-				"backup_id":              backupID,
-				"bytes_from_telemetry":   finalBytesTransferred,
+				"backup_id":            backupID,
+				"bytes_from_telemetry": finalBytesTransferred,
 			}).Info("✅ Using bytes_transferred from telemetry (real-time SBC data)")
 		} else {
 			// No telemetry - aggregate from disks as fallback
@@ -640,13 +656,13 @@ func (be *BackupEngine) CompleteBackup(ctx context.Context, backupID string, dis
 				Select("SUM(IFNULL(bytes_transferred, 0))").
 				Where("backup_job_id = ?", backupID).
 				Scan(&finalBytesTransferred)
-			
+
 			log.WithFields(log.Fields{
-				"backup_id":              backupID,
-				"bytes_from_disks":       finalBytesTransferred,
+				"backup_id":        backupID,
+				"bytes_from_disks": finalBytesTransferred,
 			}).Debug("Aggregated bytes_transferred from disks (fallback)")
 		}
-		
+
 		result = be.db.GetGormDB().
 			Model(&database.BackupJob{}).
 			Where("id = ?", backupID).
@@ -655,13 +671,13 @@ func (be *BackupEngine) CompleteBackup(ctx context.Context, backupID string, dis
 				"bytes_transferred": finalBytesTransferred,
 				"completed_at":      now,
 			})
-		
+
 		if result.Error != nil {
 			log.WithError(result.Error).Warn("Failed to update parent backup job status")
 			// Don't fail - disk completion is what matters
 		} else {
 			log.WithField("backup_id", backupID).Info("✅ All disks completed - backup job finished")
-			
+
 			// Update backup context statistics
 			var backupJob database.BackupJob
 			if err := be.db.GetGormDB().Where("id = ?", backupID).First(&backupJob).Error; err == nil {
@@ -675,13 +691,13 @@ func (be *BackupEngine) CompleteBackup(ctx context.Context, backupID string, dis
 							"last_backup_type":   backupJob.BackupType,
 							"last_backup_at":     now,
 						})
-					
+
 					// 🆕 FIX: Update backup_chains for each completed disk
 					var completedDisksForChain []database.BackupDisk
 					if err := be.db.GetGormDB().
 						Where("backup_job_id = ? AND status = ?", backupID, "completed").
 						Find(&completedDisksForChain).Error; err == nil {
-						
+
 						for _, disk := range completedDisksForChain {
 							// Find the actual per-disk backup_jobs record by vm_backup_context_id and disk_index
 							// Per-disk jobs have format: backup-{vm_name}-disk{index}-{timestamp}
@@ -693,7 +709,7 @@ func (be *BackupEngine) CompleteBackup(ctx context.Context, backupID string, dis
 								Where("created_at <= ?", backupJob.CreatedAt.Add(1*time.Minute)).
 								Order("created_at DESC").
 								First(&perDiskJob).Error
-							
+
 							if err != nil {
 								log.WithError(err).WithFields(log.Fields{
 									"backup_id":  backupID,
@@ -701,7 +717,7 @@ func (be *BackupEngine) CompleteBackup(ctx context.Context, backupID string, dis
 								}).Warn("⚠️ Could not find per-disk backup_jobs record")
 								continue
 							}
-							
+
 							// Update per-disk backup_jobs record to "completed"
 							be.db.GetGormDB().
 								Model(&database.BackupJob{}).
@@ -710,9 +726,9 @@ func (be *BackupEngine) CompleteBackup(ctx context.Context, backupID string, dis
 									"status":       "completed",
 									"completed_at": now,
 								})
-							
+
 							log.WithField("per_disk_job_id", perDiskJob.ID).Info("✅ Updated per-disk backup_jobs status")
-							
+
 							// Get backup file size for chain update
 							var fileSize int64
 							if disk.QCOW2Path != nil && *disk.QCOW2Path != "" {
@@ -720,28 +736,28 @@ func (be *BackupEngine) CompleteBackup(ctx context.Context, backupID string, dis
 									fileSize = fileInfo.Size()
 								}
 							}
-							
+
 							// Add backup to chain (updates total_backups and latest_backup_id)
 							chainID := storage.GenerateChainID(*backupJob.VMBackupContextID, disk.DiskIndex)
-							
+
 							// Prepare ChangeID (handle *string type)
 							changeIDStr := ""
 							if disk.DiskChangeID != nil {
 								changeIDStr = *disk.DiskChangeID
 							}
-							
+
 							backupForChain := &storage.Backup{
-								ID:             perDiskJob.ID, // Use actual per-disk job ID from database
-								VMContextID:    *backupJob.VMBackupContextID,
-								DiskID:         disk.DiskIndex,
-								BackupType:     storage.BackupType(backupJob.BackupType),
-								SizeBytes:      fileSize,
-								Status:         "completed",
-								ChangeID:       changeIDStr,
-								CreatedAt:      perDiskJob.CreatedAt,
-								CompletedAt:    &now,
+								ID:          perDiskJob.ID, // Use actual per-disk job ID from database
+								VMContextID: *backupJob.VMBackupContextID,
+								DiskID:      disk.DiskIndex,
+								BackupType:  storage.BackupType(backupJob.BackupType),
+								SizeBytes:   fileSize,
+								Status:      "completed",
+								ChangeID:    changeIDStr,
+								CreatedAt:   perDiskJob.CreatedAt,
+								CompletedAt: &now,
 							}
-							
+
 							// Use chain manager via repository (get *sql.DB from GORM)
 							sqlDB, dbErr := be.db.GetGormDB().DB()
 							if dbErr != nil {
@@ -767,7 +783,7 @@ func (be *BackupEngine) CompleteBackup(ctx context.Context, backupID string, dis
 					}
 				}
 			}
-			
+
 			// 🆕 CLEANUP: Stop qemu-nbd processes and release NBD ports after backup completion
 			// This fixes the stale qemu-nbd process bug
 			ports := be.portAllocator.GetPortsForBackupJob(backupID)
@@ -777,14 +793,14 @@ func (be *BackupEngine) CompleteBackup(ctx context.Context, backupID string, dis
 					"port_count": len(ports),
 					"ports":      ports,
 				}).Info("🧹 Cleaning up qemu-nbd processes for completed backup")
-				
+
 				for _, port := range ports {
 					// Stop qemu-nbd process
 					be.qemuManager.Stop(port)
 					// Release port for reuse
 					be.portAllocator.Release(port)
 				}
-				
+
 				log.WithField("backup_id", backupID).Info("✅ qemu-nbd cleanup completed")
 			}
 		}
@@ -798,6 +814,30 @@ func (be *BackupEngine) CompleteBackup(ctx context.Context, backupID string, dis
 	return nil
 }
 
+// CloseDiskChain closes the backup chain of a disk that no longer exists on the source VM so
+// its history stays restorable but no further incrementals are taken against it
+func (be *BackupEngine) CloseDiskChain(ctx context.Context, vmContextID string, diskID int) error {
+	sqlDB, err := be.db.GetGormDB().DB()
+	if err != nil {
+		return fmt.Errorf("failed to get SQL DB: %w", err)
+	}
+
+	chain, err := storage.NewChainManager(be.backupChainRepo, sqlDB).CloseChain(ctx, vmContextID, diskID)
+	if err != nil {
+		if errors.Is(err, storage.ErrBackupChainNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to close backup chain: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"vm_context_id": vmContextID,
+		"disk_id":       diskID,
+		"chain_id":      chain.ID,
+	}).Info("🔒 Closed backup chain for removed disk")
+	return nil
+}
+
 // FailBackup marks a backup as failed
 func (be *BackupEngine) FailBackup(ctx context.Context, backupID string, errorMessage string) error {
 	log.WithFields(log.Fields{
@@ -817,6 +857,292 @@ func (be *BackupEngine) FailBackup(ctx context.Context, backupID string, errorMe
 	return nil
 }
 
+// resumableBackupAge matches how long the backup client keeps a transfer checkpoint
+// (internal/checkpoint MaxAge) - older interrupted backups start over
+const resumableBackupAge = 24 * time.Hour
+
+// multiDiskParentPath marks the parent backup_jobs record of a multi-disk backup
+const multiDiskParentPath = "/multi-disk-parent"
+
+// ResumedBackup is an interrupted backup re-exported for its client to resume under
+// the same job ID, from the snapshot and checkpoint the interrupted run kept
+type ResumedBackup struct {
+	BackupID          string
+	VMBackupContextID string
+	BackupType        string
+	Disks             []ResumedDisk
+	ExcludedDiskKeys  []int
+}
+
+// ResumedDisk is one re-exported disk of a resumed backup
+type ResumedDisk struct {
+	DiskIndex     int
+	VMwareDiskKey int
+	QCOW2Path     string
+	NBDPort       int
+	ExportName    string
+	QemuNBDPID    int
+}
+
+// NBDTargets returns the multi-disk NBD targets string for the backup client
+func (rb *ResumedBackup) NBDTargets() string {
+	targets := make([]string, len(rb.Disks))
+	for i, disk := range rb.Disks {
+		targets[i] = fmt.Sprintf("%d:nbd://127.0.0.1:%d/%s", disk.VMwareDiskKey, disk.NBDPort, disk.ExportName)
+	}
+	return strings.Join(targets, ",")
+}
+
+// FindResumableBackup returns the latest backup of a VM when it failed recently enough
+// for its client checkpoint to still exist and it covers the same disks, or nil
+func (be *BackupEngine) FindResumableBackup(ctx context.Context, vmName, repositoryID string, includedDiskKeys []int) (*database.BackupJob, error) {
+	gormDB := be.db.GetGormDB().WithContext(ctx)
+
+	var latest database.BackupJob
+	err := gormDB.Where("vm_name = ? AND repository_path = ?", vmName, multiDiskParentPath).
+		Order("created_at DESC").
+		First(&latest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find latest backup of VM: %w", err)
+	}
+
+	// Cancelled backups discard their checkpoint; only failures are resumed
+	if latest.Status != "failed" || latest.RepositoryID != repositoryID ||
+		latest.CompletedAt == nil || time.Since(*latest.CompletedAt) > resumableBackupAge {
+		return nil, nil
+	}
+
+	var recordedKeys []int
+	if err := gormDB.Model(&database.BackupDisk{}).
+		Where("backup_job_id = ? AND status <> ?", latest.ID, database.BackupDiskStatusExcluded).
+		Pluck("vmware_disk_key", &recordedKeys).Error; err != nil {
+		return nil, fmt.Errorf("failed to load disks of backup: %w", err)
+	}
+	if !sameDiskKeys(recordedKeys, includedDiskKeys) {
+		log.WithFields(log.Fields{
+			"backup_id": latest.ID,
+			"vm_name":   vmName,
+		}).Info("Disks of VM changed since the interrupted backup - starting a new one")
+		return nil, nil
+	}
+
+	return &latest, nil
+}
+
+// ResumeBackup re-exports the QCOW2 files of a failed backup (or a running one whose
+// client was lost) on fresh NBD ports and puts it back to running, so its client can
+// continue under the same job ID
+func (be *BackupEngine) ResumeBackup(ctx context.Context, backupID string) (*ResumedBackup, error) {
+	parent, err := be.backupJobRepo.GetByID(ctx, backupID)
+	if err != nil {
+		return nil, err
+	}
+	if parent.Status != "failed" && parent.Status != "running" {
+		return nil, fmt.Errorf("backup %s is %s, only failed or lost backups can be resumed", backupID, parent.Status)
+	}
+
+	gormDB := be.db.GetGormDB().WithContext(ctx)
+	var disks []database.BackupDisk
+	if err := gormDB.Where("backup_job_id = ?", backupID).Order("disk_index").Find(&disks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load backup disks: %w", err)
+	}
+
+	// Exports of the interrupted run may still be up (or died with a previous SHA)
+	for _, port := range be.portAllocator.GetPortsForBackupJob(backupID) {
+		be.qemuManager.Stop(port)
+		be.portAllocator.Release(port)
+	}
+
+	resumed := &ResumedBackup{
+		BackupID:   backupID,
+		BackupType: parent.BackupType,
+	}
+	if parent.VMBackupContextID != nil {
+		resumed.VMBackupContextID = *parent.VMBackupContextID
+	}
+
+	releaseExports := func() {
+		for _, disk := range resumed.Disks {
+			be.qemuManager.Stop(disk.NBDPort)
+			be.portAllocator.Release(disk.NBDPort)
+		}
+	}
+
+	diskPaths := []string{}
+	for _, disk := range disks {
+		if disk.Status == database.BackupDiskStatusExcluded {
+			resumed.ExcludedDiskKeys = append(resumed.ExcludedDiskKeys, disk.VMwareDiskKey)
+			continue
+		}
+		if disk.QCOW2Path == nil {
+			releaseExports()
+			return nil, fmt.Errorf("disk %d of backup %s has no QCOW2 file", disk.DiskIndex, backupID)
+		}
+		if _, err := os.Stat(*disk.QCOW2Path); err != nil {
+			releaseExports()
+			return nil, fmt.Errorf("QCOW2 file of disk %d is gone: %w", disk.DiskIndex, err)
+		}
+
+		// The per-disk backup_jobs record owns the QCOW2 file
+		var diskJob database.BackupJob
+		if err := gormDB.Where("repository_path = ?", *disk.QCOW2Path).First(&diskJob).Error; err != nil {
+			releaseExports()
+			return nil, fmt.Errorf("failed to find backup job of disk %d: %w", disk.DiskIndex, err)
+		}
+
+		exportName := fmt.Sprintf("%s-disk%d", parent.VMName, disk.DiskIndex)
+		diskJobID := fmt.Sprintf("%s-disk%d", diskJob.ID, disk.DiskIndex)
+		nbdPort, err := be.portAllocator.Allocate(diskJobID, parent.VMName, exportName)
+		if err != nil {
+			releaseExports()
+			return nil, fmt.Errorf("failed to allocate NBD port: %w", err)
+		}
+		qemuProcess, err := be.qemuManager.Start(nbdPort, exportName, *disk.QCOW2Path, diskJob.ID, parent.VMName, disk.DiskIndex)
+		if err != nil {
+			be.portAllocator.Release(nbdPort)
+			releaseExports()
+			return nil, fmt.Errorf("failed to start qemu-nbd: %w", err)
+		}
+
+		resumed.Disks = append(resumed.Disks, ResumedDisk{
+			DiskIndex:     disk.DiskIndex,
+			VMwareDiskKey: disk.VMwareDiskKey,
+			QCOW2Path:     *disk.QCOW2Path,
+			NBDPort:       nbdPort,
+			ExportName:    exportName,
+			QemuNBDPID:    qemuProcess.PID,
+		})
+		diskPaths = append(diskPaths, *disk.QCOW2Path)
+	}
+	if len(resumed.Disks) == 0 {
+		return nil, fmt.Errorf("backup %s has no disks to resume", backupID)
+	}
+
+	// Completed disks stay completed - the client finds them fully checkpointed
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&database.BackupJob{}).
+			Where("id = ? AND status IN ?", backupID, []string{"failed", "running"}).
+			Updates(map[string]interface{}{
+				"status":        "running",
+				"current_phase": "resuming",
+				"error_message": "",
+				"completed_at":  nil,
+			}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&database.BackupJob{}).
+			Where("repository_path IN ? AND status IN ?", diskPaths, []string{"failed", "running"}).
+			Updates(map[string]interface{}{
+				"status":        "pending",
+				"error_message": "",
+				"completed_at":  nil,
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&database.BackupDisk{}).
+			Where("backup_job_id = ? AND status IN ?", backupID, []string{"failed", "running", "pending"}).
+			Updates(map[string]interface{}{
+				"status":        "pending",
+				"error_message": nil,
+				"completed_at":  nil,
+			}).Error
+	})
+	if err != nil {
+		releaseExports()
+		return nil, fmt.Errorf("failed to reset backup for resume: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"backup_id":  backupID,
+		"vm_name":    parent.VMName,
+		"disk_count": len(resumed.Disks),
+	}).Info("♻️ Backup re-exported for resume")
+	return resumed, nil
+}
+
+// sameDiskKeys reports whether two lists hold the same VMware disk keys
+func sameDiskKeys(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[int]int, len(a))
+	for _, key := range a {
+		counts[key]++
+	}
+	for _, key := range b {
+		if counts[key] == 0 {
+			return false
+		}
+		counts[key]--
+	}
+	return true
+}
+
+// CancelBackup marks a backup and its unfinished disks as cancelled and releases the
+// qemu-nbd exports and NBD ports it held; the partial QCOW2 files are left for retention cleanup
+func (be *BackupEngine) CancelBackup(ctx context.Context, backupID string, reason string) error {
+	log.WithFields(log.Fields{
+		"backup_id": backupID,
+		"reason":    reason,
+	}).Warn("🛑 Cancelling backup")
+
+	backupJob, err := be.backupJobRepo.GetByID(ctx, backupID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	gormDB := be.db.GetGormDB().WithContext(ctx)
+	if err := gormDB.Model(&database.BackupJob{}).
+		Where("id = ? AND status IN ?", backupID, []string{"pending", "running", "paused"}).
+		Updates(map[string]interface{}{
+			"status":        "cancelled",
+			"error_message": reason,
+			"completed_at":  now,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to cancel backup job: %w", err)
+	}
+
+	// Disks still in flight have incomplete QCOW2 files that must not join a chain
+	if err := gormDB.Model(&database.BackupDisk{}).
+		Where("backup_job_id = ? AND status IN ?", backupID, []string{"pending", "running"}).
+		Updates(map[string]interface{}{
+			"status":        "cancelled",
+			"error_message": reason,
+			"completed_at":  now,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to cancel backup disks: %w", err)
+	}
+
+	// Per-disk backup_jobs records are created alongside the parent job
+	if backupJob.VMBackupContextID != nil {
+		gormDB.Model(&database.BackupJob{}).
+			Where("vm_backup_context_id = ? AND id <> ?", *backupJob.VMBackupContextID, backupID).
+			Where("status IN ?", []string{"pending", "running", "paused"}).
+			Where("created_at BETWEEN ? AND ?", backupJob.CreatedAt.Add(-1*time.Minute), backupJob.CreatedAt.Add(1*time.Minute)).
+			Updates(map[string]interface{}{
+				"status":        "cancelled",
+				"error_message": reason,
+				"completed_at":  now,
+			})
+	}
+
+	ports := be.portAllocator.GetPortsForBackupJob(backupID)
+	for _, port := range ports {
+		be.qemuManager.Stop(port)
+		be.portAllocator.Release(port)
+	}
+
+	log.WithFields(log.Fields{
+		"backup_id":      backupID,
+		"released_ports": len(ports),
+	}).Info("✅ Backup cancelled")
+	return nil
+}
+
 // ListBackups lists all backups for a VM context
 func (be *BackupEngine) ListBackups(ctx context.Context, vmContextID string) ([]*BackupResult, error) {
 	jobs, err := be.backupJobRepo.ListByVMContext(ctx, vmContextID)
@@ -45,7 +45,8 @@ type MigrationEngine struct {
 
 	// Service dependencies
 	mountManager      *volume.MountManager
-	snaProgressPoller SNAProgressPoller // Interface for SNA progress polling
+	snaProgressPoller SNAProgressPoller   // Interface for SNA progress polling
+	snaRouter         *services.SNARouter // Optional: routes the job to the SNA serving the VM
 }
 
 // NewMigrationEngine creates a new migration workflow engine
@@ -63,6 +64,11 @@ func NewMigrationEngine(db database.Connection, mountManager *volume.MountManage
 	}
 }
 
+// SetSNARouter routes replications to the SNA serving each VM's placement
+func (m *MigrationEngine) SetSNARouter(router *services.SNARouter) {
+	m.snaRouter = router
+}
+
 // MigrationRequest represents a complete migration request
 type MigrationRequest struct {
 	// Source VM information
@@ -393,6 +399,15 @@ func (m *MigrationEngine) analyzeAndRecordVMDisks(req *MigrationRequest) error {
 				"new_job_id":    req.JobID,
 			}).Info("🔄 Updating existing VM disk record to maintain stable ID")
 
+			if existingDisk.CapacityBytes > 0 && disk.CapacityBytes != existingDisk.CapacityBytes {
+				log.WithFields(log.Fields{
+					"vm_context_id":      vmContextID,
+					"disk_id":            disk.ID,
+					"old_capacity_bytes": existingDisk.CapacityBytes,
+					"new_capacity_bytes": disk.CapacityBytes,
+				}).Warn("📐 Source disk was resized since the last sync - its volume is grown before the incremental")
+			}
+
 			// Preserve stable ID, update with new job data
 			existingDisk.JobID = &req.JobID // Pointer for nullable field
 			existingDisk.VMDKPath = disk.Path
@@ -451,6 +466,8 @@ func (m *MigrationEngine) analyzeAndRecordVMDisks(req *MigrationRequest) error {
 		}).Info("VM disk record created")
 	}
 
+	m.logRemovedVMDisks(vmContextID, req.SourceVM.Disks)
+
 	log.WithFields(log.Fields{
 		"job_id":       req.JobID,
 		"vm_name":      req.SourceVM.Name,
@@ -461,6 +478,34 @@ func (m *MigrationEngine) analyzeAndRecordVMDisks(req *MigrationRequest) error {
 	return nil
 }
 
+// logRemovedVMDisks reports disks removed from the source VM since the last sync. Their
+// vm_disks records keep the previous job ID, so they drop out of this job's provisioning
+// while their volumes stay in place for failover of earlier data.
+func (m *MigrationEngine) logRemovedVMDisks(vmContextID string, current []models.DiskInfo) {
+	if vmContextID == "" {
+		return
+	}
+	stored, err := m.vmDiskRepo.GetByVMContextID(vmContextID)
+	if err != nil {
+		log.WithError(err).WithField("vm_context_id", vmContextID).Debug("Could not check for removed VM disks")
+		return
+	}
+
+	currentIDs := make(map[string]bool, len(current))
+	for _, disk := range current {
+		currentIDs[disk.ID] = true
+	}
+	for _, disk := range stored {
+		if !currentIDs[disk.DiskID] {
+			log.WithFields(log.Fields{
+				"vm_context_id":   vmContextID,
+				"disk_id":         disk.DiskID,
+				"ossea_volume_id": disk.OSSEAVolumeID,
+			}).Warn("➖ Disk was removed from the source VM - it is no longer replicated and its volume is kept")
+		}
+	}
+}
+
 // updateVMContextWithSpecs updates VM context with specifications from VM disk record
 func (m *MigrationEngine) updateVMContextWithSpecs(vmContextID string, vmDisk *database.VMDisk) error {
 	updates := map[string]interface{}{
@@ -531,7 +576,7 @@ func (m *MigrationEngine) provisionOSSEAVolumes(ctx context.Context, req *Migrat
 		volumeSizeBytes := vmDisk.CapacityBytes + (5 * 1024 * 1024 * 1024) // CapacityBytes + 5GB
 		calculatedSizeGB := int(volumeSizeBytes / (1024 * 1024 * 1024))
 
-		// Check for existing volume for this VM disk first using the calculated size
+		// Check for existing volume for this VM disk first (it may be smaller if the disk was extended)
 		existingVolume, err := m.findExistingVolumeForVMDisk(req.SourceVM.Path, vmDisk.UnitNumber, calculatedSizeGB)
 		if err != nil {
 			log.WithError(err).Warn("Failed to check for existing volume, creating new one")
@@ -547,6 +592,17 @@ func (m *MigrationEngine) provisionOSSEAVolumes(ctx context.Context, req *Migrat
 				"volume_name": existingVolume.VolumeName,
 			}).Info("♻️  Reusing existing OSSEA volume for incremental sync")
 
+			// The source disk was extended since the last sync - grow the volume before the
+			// incremental writes past its old end
+			if existingVolume.SizeGB < calculatedSizeGB {
+				if err := m.growOSSEAVolume(ctx, existingVolume, volumeSizeBytes); err != nil {
+					result.Status = "failed"
+					result.ErrorMessage = err.Error()
+					results = append(results, result)
+					return results, fmt.Errorf("failed to grow volume for resized disk %s: %w", vmDisk.DiskID, err)
+				}
+			}
+
 			// Convert existing volume to OSSEA format
 			volume = &ossea.Volume{
 				ID:    existingVolume.VolumeID,
@@ -1067,7 +1123,11 @@ func (m *MigrationEngine) initiateVMwareReplication(req *MigrationRequest, nbdEx
 	// Get SNA API URL from environment, fallback to localhost for tunnel
 	snaAPIURL := os.Getenv("SNA_API_URL")
 	if snaAPIURL == "" {
-		snaAPIURL = "http://localhost:9081" // Default for reverse tunnel
+		snaAPIURL = services.DefaultSNAEndpoint // Default for reverse tunnel
+	}
+	snaAPIURL, err = m.routeToSNA(req, snaAPIURL)
+	if err != nil {
+		return fmt.Errorf("failed to route replication to SNA: %w", err)
 	}
 
 	// Make HTTP request to SNA via reverse tunnel
@@ -1092,6 +1152,23 @@ func (m *MigrationEngine) initiateVMwareReplication(req *MigrationRequest, nbdEx
 	return nil
 }
 
+// routeToSNA picks the SNA serving the job's VM and records it for the job's later calls
+func (m *MigrationEngine) routeToSNA(req *MigrationRequest, fallback string) (string, error) {
+	if m.snaRouter == nil {
+		return fallback, nil
+	}
+
+	vmContextID := req.ExistingContextID
+	if vmContextID == "" {
+		var job database.ReplicationJob
+		if err := m.db.GetGormDB().Select("vm_context_id").Where("id = ?", req.JobID).First(&job).Error; err != nil {
+			return "", fmt.Errorf("failed to get VM context of job: %w", err)
+		}
+		vmContextID = job.VMContextID
+	}
+	return m.snaRouter.RouteJob(context.Background(), req.JobID, "replication", vmContextID, fallback)
+}
+
 // getVMDiskUnitNumber retrieves the unit number for a VM disk by ID
 func (m *MigrationEngine) getVMDiskUnitNumber(vmDiskID int) (int, error) {
 	var vmDisk database.VMDisk
@@ -1648,7 +1725,9 @@ func (m *MigrationEngine) findActualDevicePath(reportedPath string) (string, err
 	return actualPath, nil
 }
 
-// findExistingVolumeForVMDisk checks for existing OSSEA volumes for this VM disk
+// findExistingVolumeForVMDisk checks for existing OSSEA volumes for this VM disk. Volumes
+// match by name whatever their size (largest first) so an extended disk keeps its volume
+// and CBT history; sizeGB is the size the disk needs now.
 func (m *MigrationEngine) findExistingVolumeForVMDisk(vmPath string, unitNumber int, sizeGB int) (*database.OSSEAVolume, error) {
 	// Extract VM name from path for consistent volume naming
 	vmName := vmPath[strings.LastIndex(vmPath, "/")+1:]
@@ -1670,7 +1749,7 @@ func (m *MigrationEngine) findExistingVolumeForVMDisk(vmPath string, unitNumber
 		"size_gb": sizeGB,
 	}).Info("🔍 DEBUG: Executing volume query")
 
-	if err := m.db.GetGormDB().Where("volume_name = ? AND size_gb = ?", newPattern, sizeGB).Find(&volumes).Error; err != nil {
+	if err := m.db.GetGormDB().Where("volume_name = ?", newPattern).Order("size_gb DESC").Find(&volumes).Error; err != nil {
 		log.WithError(err).Error("🚨 DEBUG: Database query failed")
 		return nil, fmt.Errorf("failed to query existing volumes: %w", err)
 	}
@@ -1702,7 +1781,7 @@ func (m *MigrationEngine) findExistingVolumeForVMDisk(vmPath string, unitNumber
 		// Look for volumes from previous jobs
 		for _, job := range jobs {
 			oldPattern := fmt.Sprintf("migration-%s-disk-%d", job.ID, unitNumber)
-			if err := m.db.GetGormDB().Where("volume_name = ? AND size_gb = ?", oldPattern, sizeGB).Find(&volumes).Error; err == nil && len(volumes) > 0 {
+			if err := m.db.GetGormDB().Where("volume_name = ?", oldPattern).Order("size_gb DESC").Find(&volumes).Error; err == nil && len(volumes) > 0 {
 				volume := &volumes[0]
 				log.WithFields(log.Fields{
 					"vm_path":     vmPath,
@@ -1728,6 +1807,31 @@ func (m *MigrationEngine) findExistingVolumeForVMDisk(vmPath string, unitNumber
 	return nil, nil
 }
 
+// growOSSEAVolume resizes a reused volume via the Volume Daemon to fit its extended source disk
+func (m *MigrationEngine) growOSSEAVolume(ctx context.Context, volume *database.OSSEAVolume, sizeBytes int64) error {
+	logger := log.WithFields(log.Fields{
+		"volume_id":   volume.VolumeID,
+		"volume_name": volume.VolumeName,
+		"old_size_gb": volume.SizeGB,
+		"new_size_gb": int((sizeBytes + 1073741823) / 1073741824),
+	})
+	logger.Info("📈 Source disk was extended - growing OSSEA volume before incremental sync")
+
+	volumeClient := common.NewVolumeClient("http://localhost:8090")
+	operation, err := volumeClient.ResizeVolume(ctx, volume.VolumeID, sizeBytes)
+	if err != nil {
+		return fmt.Errorf("failed to resize volume via daemon: %w", err)
+	}
+	if _, err := volumeClient.WaitForCompletionWithTimeout(ctx, operation.ID, 2*time.Minute); err != nil {
+		return fmt.Errorf("volume resize failed: %w", err)
+	}
+
+	// The daemon records the new size in ossea_volumes
+	volume.SizeGB = int((sizeBytes + 1073741823) / 1073741824)
+	logger.Info("✅ OSSEA volume grown via Volume Daemon")
+	return nil
+}
+
 // GetVMDisksByJobID retrieves VM disk records for a specific job
 func (m *MigrationEngine) GetVMDisksByJobID(jobID string) ([]database.VMDisk, error) {
 	return m.vmDiskRepo.GetByJobID(jobID)
@@ -3,6 +3,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -22,8 +23,18 @@ var (
 	port    = flag.Int("port", 8081, "Port for SNA control API server")
 	debug   = flag.Bool("debug", false, "Enable debug logging")
 	autoCBT = flag.Bool("auto-cbt", true, "Enable automatic CBT enablement before migration")
+
+	// SNA registry registration (multi-SNA routing)
+	shaURL       = flag.String("sha-url", "http://10.245.246.125:8082", "SHA API base URL for registration")
+	enrollmentID = flag.String("enrollment-id", "", "Enrollment ID this SNA was approved under")
+	snaName      = flag.String("sna-name", "", "SNA name reported to the SHA (default: hostname)")
+	tunnelPort   = flag.Int("tunnel-port", 9081, "SHA-side reverse tunnel port assigned at enrollment")
+	scopes       = flag.String("scopes", "", "Placements served, comma-separated vcenter[/datacenter[/cluster]]")
+	maxJobs      = flag.Int("max-jobs", 8, "Maximum concurrent jobs this SNA accepts")
 )
 
+const snaVersion = "1.3.2"
+
 func main() {
 	flag.Parse()
 
@@ -32,7 +43,7 @@ func main() {
 	}
 
 	log.WithFields(log.Fields{
-		"version":  "1.3.2",
+		"version":  snaVersion,
 		"port":     *port,
 		"auto_cbt": *autoCBT,
 	}).Info("🚀 SNA Control API Server starting")
@@ -56,11 +67,43 @@ func main() {
 	progressHandler := api.NewProgressHandler(progressSvc)
 
 	// Create and configure the API server
-	server := api.NewVMAControlServer(*port, vmwareClient)
+	server := api.NewVMAControlServerWithServices(*port, vmwareClient,
+		vmware.NewDiscoveryProviderAdapter(), vmware.NewSpecificationServiceAdapter())
+	server.SetProgressService(progressSvc)
 	
 	// Register the new progress endpoint
 	progressHandler.RegisterRoutes(server.GetRouter())
 
+	// Backup clients that outlived a restart reopen their event streams here
+	if err := server.ListenClientEvents(); err != nil {
+		log.WithError(err).Warn("Reattached backup clients cannot resume their event streams")
+	}
+
+	// Reattach to or settle the jobs that were running before a restart
+	server.RecoverJobs(*shaURL)
+
+	// Register with the SHA so it can route VMs in our scopes here
+	snaScopes, err := services.ParseScopes(*scopes)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid -scopes")
+	}
+	name := *snaName
+	if name == "" {
+		if name, err = os.Hostname(); err != nil {
+			name = "sna"
+		}
+	}
+	registration := services.NewRegistrationService(services.RegistrationConfig{
+		SHAURL:            *shaURL,
+		EnrollmentID:      *enrollmentID,
+		TunnelPort:        *tunnelPort,
+		Name:              name,
+		Version:           snaVersion,
+		MaxConcurrentJobs: *maxJobs,
+		Scopes:            snaScopes,
+	})
+	go registration.Run(context.Background())
+
 	// Setup graceful shutdown
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vexxhost/migratekit/source/current/sna/progress"
+	"github.com/vexxhost/migratekit/source/current/sna/services"
+)
+
+const (
+	// maxClientEventSize bounds one line of the backup client event stream
+	maxClientEventSize = 64 * 1024
+
+	// clientEventSocket is where backup clients reopen their event stream after the
+	// pipe from an SNA that restarted broke; shared with the backup client (internal/events)
+	clientEventSocket = jobControlDir + "/events.sock"
+
+	// clientEventHelloTimeout bounds the handshake of a reconnecting client
+	clientEventHelloTimeout = 10 * time.Second
+)
+
+// SetProgressService lets client event streams feed the progress served to the SHA
+func (s *SNAControlServer) SetProgressService(progressService *services.ProgressService) {
+	s.progressService = progressService
+}
+
+// attachEventStream passes the backup client a pipe to write its event stream to and
+// returns the SNA's end. Call after AttachVMwareCredentials, which must stay the first
+// inherited descriptor, and ReleaseCommandFiles once the command has started.
+func attachEventStream(cmd *exec.Cmd) (*os.File, error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event pipe: %w", err)
+	}
+
+	// ExtraFiles[i] becomes descriptor 3+i in the child
+	cmd.Args = append(cmd.Args, "--event-fd", strconv.Itoa(3+len(cmd.ExtraFiles)))
+	cmd.ExtraFiles = append(cmd.ExtraFiles, writer)
+	return reader, nil
+}
+
+// ListenClientEvents accepts the event streams of backup clients that outlived an SNA
+// restart. Call before RecoverJobs so reattached clients can reconnect at once.
+func (s *SNAControlServer) ListenClientEvents() error {
+	if err := os.MkdirAll(jobControlDir, 0700); err != nil {
+		return fmt.Errorf("failed to create job control directory: %w", err)
+	}
+	// The socket of the previous SNA process is stale
+	os.Remove(clientEventSocket)
+
+	listener, err := net.Listen("unix", clientEventSocket)
+	if err != nil {
+		return fmt.Errorf("failed to listen on client event socket: %w", err)
+	}
+	if err := os.Chmod(clientEventSocket, 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to restrict client event socket: %w", err)
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				log.WithError(err).Error("Client event socket stopped accepting")
+				return
+			}
+			go s.resumeEventStream(conn)
+		}
+	}()
+
+	log.WithField("socket", clientEventSocket).Info("Listening for reconnecting backup client event streams")
+	return nil
+}
+
+// resumeEventStream takes over the event stream of a reconnecting client: the client
+// names its job, is told the last event applied for it and replays the events after it
+func (s *SNAControlServer) resumeEventStream(conn net.Conn) {
+	reader := bufio.NewReaderSize(conn, 4096)
+	conn.SetDeadline(time.Now().Add(clientEventHelloTimeout))
+
+	var hello progress.ClientEventHello
+	line, err := reader.ReadBytes('\n')
+	if err == nil {
+		err = json.Unmarshal(line, &hello)
+	}
+	if err != nil || hello.JobID == "" {
+		log.WithError(err).Warn("Rejecting client event stream without a valid hello")
+		conn.Close()
+		return
+	}
+
+	s.jobTracker.mu.RLock()
+	job, tracked := s.jobTracker.jobs[hello.JobID]
+	var lastSeq uint64
+	if record, exists := s.jobTracker.records[hello.JobID]; exists {
+		lastSeq = record.EventSeq
+	}
+	active := tracked && !isFinalJobStatus(job.Status)
+	s.jobTracker.mu.RUnlock()
+
+	resume := progress.ClientEventResume{LastSeq: lastSeq}
+	if !active {
+		resume.Error = "job is not running on this SNA"
+	}
+	data, _ := json.Marshal(resume)
+	if _, err := conn.Write(append(data, '\n')); err != nil || !active {
+		conn.Close()
+		return
+	}
+	conn.SetDeadline(time.Time{})
+
+	log.WithFields(log.Fields{
+		"job_id":   hello.JobID,
+		"last_seq": lastSeq,
+	}).Info("🔗 Backup client reopened its event stream")
+
+	s.consumeEventStream(hello.JobID, struct {
+		io.Reader
+		io.Closer
+	}{reader, conn}, lastSeq)
+}
+
+// consumeEventStream applies the events a client writes until the stream ends, skipping
+// events up to lastSeq that were applied before a reconnect
+func (s *SNAControlServer) consumeEventStream(jobID string, stream io.ReadCloser, lastSeq uint64) {
+	go func() {
+		defer stream.Close()
+
+		scanner := bufio.NewScanner(stream)
+		scanner.Buffer(make([]byte, 0, 4096), maxClientEventSize)
+		warnedVersion := false
+		for scanner.Scan() {
+			var event progress.ClientEvent
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+				log.WithError(err).WithField("job_id", jobID).Warn("Ignoring malformed client event")
+				continue
+			}
+			if event.Seq != 0 && event.Seq <= lastSeq {
+				continue
+			}
+			lastSeq = event.Seq
+			if event.Version > progress.ClientEventVersion && !warnedVersion {
+				log.WithFields(log.Fields{
+					"job_id":  jobID,
+					"version": event.Version,
+				}).Warn("Backup client sends a newer event schema - applying the fields this SNA knows")
+				warnedVersion = true
+			}
+			s.applyClientEvent(jobID, &event)
+		}
+		if err := scanner.Err(); err != nil {
+			log.WithError(err).WithField("job_id", jobID).Warn("Client event stream failed")
+		}
+	}()
+}
+
+// applyClientEvent updates the progress service and the job record from one client event
+func (s *SNAControlServer) applyClientEvent(jobID string, event *progress.ClientEvent) {
+	percent := -1.0
+	if s.progressService != nil {
+		if err := s.progressService.ApplyClientEvent(context.Background(), jobID, event); err != nil {
+			log.WithError(err).WithField("job_id", jobID).Warn("Failed to apply client event to progress")
+		} else if jobProgress, err := s.progressService.GetJobProgress(context.Background(), jobID); err == nil {
+			percent = jobProgress.Aggregate.Percent
+		}
+	}
+
+	s.jobTracker.mu.Lock()
+	defer s.jobTracker.mu.Unlock()
+
+	job, exists := s.jobTracker.jobs[jobID]
+	if !exists {
+		return
+	}
+	job.LastUpdate = time.Now()
+	// Persisted with the progress so a client reconnecting after a restart resumes here
+	if record, exists := s.jobTracker.records[jobID]; exists && event.Seq > record.EventSeq {
+		record.EventSeq = event.Seq
+	}
+
+	switch event.Type {
+	case progress.ClientEventStatus:
+		if event.Error != nil {
+			job.CurrentOperation = fmt.Sprintf("%s: %s", event.Error.Code, event.Error.Message)
+		} else if event.Phase != "" {
+			job.CurrentOperation = event.Phase
+		}
+
+	case progress.ClientEventProgress, progress.ClientEventDisk:
+		if percent < 0 && event.TotalBytes > 0 {
+			percent = float64(event.BytesTransferred) / float64(event.TotalBytes) * 100.0
+		}
+		if percent >= 0 {
+			job.ProgressPercent = percent
+		}
+
+	case progress.ClientEventSnapshot:
+		// Persist at once so a restarted SNA can remove the snapshot of a lost job
+		if record, exists := s.jobTracker.records[jobID]; exists {
+			if event.Action == "created" {
+				record.SnapshotRef = event.SnapshotRef
+			} else if event.Action == "removed" {
+				record.SnapshotRef = ""
+			}
+			s.persistJobLocked(jobID)
+		}
+
+	case progress.ClientEventError:
+		if event.Error != nil {
+			log.WithFields(log.Fields{
+				"job_id":      jobID,
+				"code":        event.Error.Code,
+				"recoverable": event.Error.Recoverable,
+			}).Warn(event.Error.Message)
+		}
+	}
+}
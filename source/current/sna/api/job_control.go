@@ -0,0 +1,362 @@
+// Package api provides the job control endpoints for the SNA server
+// The SHA pauses, resumes and cancels running backups and replications here; the SNA
+// hands the request to the backup client through a control file plus SIGUSR1, and
+// falls back to SIGTERM (snapshot and nbdkit cleanup in the client) for legacy
+// migratekit binaries that cannot be paused
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// jobControlDir is shared with the backup client (internal/jobcontrol)
+	jobControlDir = "/var/lib/sendense/control"
+
+	// cancelGracePeriod is how long a cancelled client gets to remove its snapshot
+	// before it is sent SIGTERM
+	cancelGracePeriod = 2 * time.Minute
+)
+
+// jobProcess is the backup client or migratekit process running a job
+type jobProcess struct {
+	pid    int
+	exited chan struct{} // Closed once the process has been reaped (tracked processes only)
+}
+
+// JobControlResponse reports the control state of a job
+type JobControlResponse struct {
+	JobID          string           `json:"job_id"`
+	Action         string           `json:"action,omitempty"`
+	State          string           `json:"state"` // "running", "pausing", "paused", "resuming", "cancelling", "exited"
+	PID            int              `json:"pid,omitempty"`
+	ProcessRunning bool             `json:"process_running"`
+	Pausable       bool             `json:"pausable"`
+	Checkpoints    map[string]int64 `json:"checkpoints,omitempty"` // "<disk_key>/<worker_id>" -> next offset to copy
+	Message        string           `json:"message,omitempty"`
+}
+
+// clientControlState is the state file written by the backup client
+type clientControlState struct {
+	State       string           `json:"state"`
+	Checkpoints map[string]int64 `json:"checkpoints"`
+}
+
+// registerJobControlRoutes adds the job control endpoints
+func (s *SNAControlServer) registerJobControlRoutes(api *mux.Router) {
+	api.HandleFunc("/jobs/{job_id}/pause", s.handleJobPause).Methods("POST")
+	api.HandleFunc("/jobs/{job_id}/resume", s.handleJobResume).Methods("POST")
+	api.HandleFunc("/jobs/{job_id}/cancel", s.handleJobCancel).Methods("POST")
+	api.HandleFunc("/jobs/{job_id}/control", s.handleJobControlStatus).Methods("GET")
+}
+
+// trackJobProcess remembers the process of a job started by this SNA and reaps it when it exits
+func (s *SNAControlServer) trackJobProcess(jobID string, cmd *exec.Cmd) {
+	proc := &jobProcess{
+		pid:    cmd.Process.Pid,
+		exited: make(chan struct{}),
+	}
+
+	s.jobTracker.mu.Lock()
+	s.jobTracker.processes[jobID] = proc
+	s.jobTracker.mu.Unlock()
+
+	go func() {
+		err := cmd.Wait()
+		close(proc.exited)
+
+		s.jobTracker.mu.Lock()
+		if job, exists := s.jobTracker.jobs[jobID]; exists {
+			switch {
+			case job.Status == "cancelling":
+				job.Status = "cancelled"
+			case err != nil:
+				job.Status = "failed"
+			default:
+				job.Status = "completed"
+			}
+			job.LastUpdate = time.Now()
+		}
+		delete(s.jobTracker.processes, jobID)
+		s.persistJobLocked(jobID)
+		s.jobTracker.mu.Unlock()
+
+		os.Remove(controlRequestPath(jobID))
+		os.Remove(controlStatePath(jobID))
+
+		log.WithFields(log.Fields{
+			"job_id": jobID,
+			"pid":    proc.pid,
+			"error":  err,
+		}).Info("Job process exited")
+	}()
+}
+
+// findJobProcess returns the process running a job - tracked by this SNA, or found by
+// its --job-id argument (replications started by the VMware service)
+func (s *SNAControlServer) findJobProcess(jobID string) (*jobProcess, bool) {
+	s.jobTracker.mu.RLock()
+	proc, exists := s.jobTracker.processes[jobID]
+	s.jobTracker.mu.RUnlock()
+	if exists {
+		return proc, true
+	}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, false
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		if processRunsJob(pid, jobID) {
+			return &jobProcess{pid: pid}, true
+		}
+	}
+	return nil, false
+}
+
+// processRunsJob reports whether a process was started with --job-id <jobID>
+func processRunsJob(pid int, jobID string) bool {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "cmdline"))
+	if err != nil || len(data) == 0 {
+		return false
+	}
+	args := strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "--job-id" && args[i+1] == jobID {
+			return true
+		}
+	}
+	return false
+}
+
+// handleJobPause asks the backup client to stop its workers at the next chunk boundary
+func (s *SNAControlServer) handleJobPause(w http.ResponseWriter, r *http.Request) {
+	s.requestJobAction(w, mux.Vars(r)["job_id"], "pause", "pausing")
+}
+
+// handleJobResume lets a paused backup client continue from its checkpoints
+func (s *SNAControlServer) handleJobResume(w http.ResponseWriter, r *http.Request) {
+	s.requestJobAction(w, mux.Vars(r)["job_id"], "resume", "resuming")
+}
+
+// requestJobAction hands a pause or resume request to the backup client
+func (s *SNAControlServer) requestJobAction(w http.ResponseWriter, jobID, action, state string) {
+	proc, found := s.findJobProcess(jobID)
+	if !found || !processRunning(proc.pid) {
+		http.Error(w, fmt.Sprintf("No running process for job: %s", jobID), http.StatusNotFound)
+		return
+	}
+	if !supportsJobControl(jobID) {
+		http.Error(w, fmt.Sprintf("Job %s cannot be paused - its client does not support job control", jobID), http.StatusConflict)
+		return
+	}
+
+	if err := signalJobControl(jobID, proc.pid, action); err != nil {
+		log.WithError(err).WithField("job_id", jobID).Error("Failed to send job control request")
+		http.Error(w, fmt.Sprintf("Job control failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	jobStatus := "running"
+	if action == "pause" {
+		jobStatus = "paused"
+	}
+	s.setJobStatus(jobID, jobStatus, action)
+
+	log.WithFields(log.Fields{
+		"job_id": jobID,
+		"pid":    proc.pid,
+		"action": action,
+	}).Info("🎛️ Job control request sent to backup client")
+
+	s.writeJobControlResponse(w, http.StatusAccepted, JobControlResponse{
+		JobID:          jobID,
+		Action:         action,
+		State:          state,
+		PID:            proc.pid,
+		ProcessRunning: true,
+		Pausable:       true,
+	})
+}
+
+// handleJobCancel stops a job; the client removes its VMware snapshot and nbdkit
+// servers before exiting, and is sent SIGTERM if it has not exited after the grace period
+func (s *SNAControlServer) handleJobCancel(w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["job_id"]
+
+	proc, found := s.findJobProcess(jobID)
+	if !found || !processRunning(proc.pid) {
+		// Nothing left to stop - the SHA still cleans up its side
+		s.setJobStatus(jobID, "cancelled", "cancelled")
+		s.writeJobControlResponse(w, http.StatusOK, JobControlResponse{
+			JobID:   jobID,
+			Action:  "cancel",
+			State:   "exited",
+			Message: "no running process for job",
+		})
+		return
+	}
+
+	logger := log.WithFields(log.Fields{"job_id": jobID, "pid": proc.pid})
+
+	pausable := supportsJobControl(jobID)
+	if pausable {
+		if err := signalJobControl(jobID, proc.pid, "cancel"); err != nil {
+			logger.WithError(err).Warn("Failed to send cancel request - terminating job process")
+			syscall.Kill(proc.pid, syscall.SIGTERM)
+		} else {
+			go terminateAfterGracePeriod(jobID, proc)
+		}
+	} else {
+		// migratekit removes its snapshot and nbdkit servers on SIGTERM
+		if err := syscall.Kill(proc.pid, syscall.SIGTERM); err != nil {
+			logger.WithError(err).Error("Failed to terminate job process")
+			http.Error(w, fmt.Sprintf("Cancel failed: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	s.setJobStatus(jobID, "cancelling", "cancelling")
+
+	logger.Info("🛑 Job cancellation requested")
+
+	s.writeJobControlResponse(w, http.StatusAccepted, JobControlResponse{
+		JobID:          jobID,
+		Action:         "cancel",
+		State:          "cancelling",
+		PID:            proc.pid,
+		ProcessRunning: true,
+		Pausable:       pausable,
+	})
+}
+
+// handleJobControlStatus reports whether the job process is still running and where
+// the backup client's workers stopped
+func (s *SNAControlServer) handleJobControlStatus(w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["job_id"]
+
+	response := JobControlResponse{JobID: jobID, State: "exited"}
+	if proc, found := s.findJobProcess(jobID); found && processRunning(proc.pid) {
+		response.PID = proc.pid
+		response.ProcessRunning = true
+		response.Pausable = supportsJobControl(jobID)
+		response.State = "running"
+	}
+
+	if data, err := os.ReadFile(controlStatePath(jobID)); err == nil {
+		var state clientControlState
+		if err := json.Unmarshal(data, &state); err == nil {
+			if response.ProcessRunning {
+				response.State = state.State
+			}
+			response.Checkpoints = state.Checkpoints
+		}
+	}
+
+	s.writeJobControlResponse(w, http.StatusOK, response)
+}
+
+// setJobStatus records a job control transition in the job tracker
+func (s *SNAControlServer) setJobStatus(jobID, status, operation string) {
+	s.jobTracker.mu.Lock()
+	defer s.jobTracker.mu.Unlock()
+
+	if job, exists := s.jobTracker.jobs[jobID]; exists {
+		job.Status = status
+		job.CurrentOperation = operation
+		job.LastUpdate = time.Now()
+		s.persistJobLocked(jobID)
+	}
+}
+
+// writeJobControlResponse sends a job control response
+func (s *SNAControlServer) writeJobControlResponse(w http.ResponseWriter, statusCode int, response JobControlResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// signalJobControl writes the requested action for the backup client and wakes it up
+func signalJobControl(jobID string, pid int, action string) error {
+	if err := os.MkdirAll(jobControlDir, 0700); err != nil {
+		return fmt.Errorf("failed to create job control directory: %w", err)
+	}
+
+	path := controlRequestPath(jobID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(action), 0600); err != nil {
+		return fmt.Errorf("failed to write job control request: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write job control request: %w", err)
+	}
+
+	// The client also polls the request file, so a lost signal only delays the action
+	if err := syscall.Kill(pid, syscall.SIGUSR1); err != nil {
+		return fmt.Errorf("failed to signal job process: %w", err)
+	}
+	return nil
+}
+
+// terminateAfterGracePeriod sends SIGTERM to a cancelled client that is still running
+func terminateAfterGracePeriod(jobID string, proc *jobProcess) {
+	deadline := time.Now().Add(cancelGracePeriod)
+	for time.Now().Before(deadline) {
+		if proc.exited != nil {
+			select {
+			case <-proc.exited:
+				return
+			case <-time.After(5 * time.Second):
+			}
+		} else {
+			time.Sleep(5 * time.Second)
+		}
+		if !processRunning(proc.pid) {
+			return
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"job_id": jobID,
+		"pid":    proc.pid,
+	}).Warn("⚠️ Cancelled job did not exit within the grace period - terminating")
+	syscall.Kill(proc.pid, syscall.SIGTERM)
+}
+
+// supportsJobControl reports whether the job's client watches control files; the
+// backup client writes its state file as soon as it starts
+func supportsJobControl(jobID string) bool {
+	_, err := os.Stat(controlStatePath(jobID))
+	return err == nil
+}
+
+// processRunning reports whether a process is still alive
+func processRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	return syscall.Kill(pid, 0) == nil
+}
+
+func controlRequestPath(jobID string) string {
+	return filepath.Join(jobControlDir, jobID+".request")
+}
+
+func controlStatePath(jobID string) string {
+	return filepath.Join(jobControlDir, jobID+".state.json")
+}
@@ -0,0 +1,559 @@
+// Package api provides durable job records for the SNA server
+// Every backup and restore started here is recorded under /var/lib/sendense/jobs with
+// its client PID, vCenter, snapshot reference and last progress. When the SNA service
+// restarts it reloads the records: clients still running are reattached, clients that
+// exited report the final state they left in their control file, and jobs whose
+// client died mid-transfer are reported lost to the SHA. A backup whose client
+// checkpointed its transfer is relaunched under the same job ID to resume from the
+// kept snapshot; other lost jobs are failed and their snapshot removed.
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// jobStoreDir holds one record per job started by this SNA
+	jobStoreDir = "/var/lib/sendense/jobs"
+
+	// checkpointDir is shared with the backup client (internal/checkpoint)
+	checkpointDir = "/var/lib/sendense/checkpoints"
+
+	// finishedJobRetention is how long records of finished jobs are kept for status queries
+	finishedJobRetention = 24 * time.Hour
+
+	// recoveredJobPollInterval is how often a reattached client is checked - it is no
+	// longer a child of this process, so its exit cannot be waited for
+	recoveredJobPollInterval = 5 * time.Second
+
+	// jobProgressSaveInterval is how often progress and snapshot references are persisted
+	jobProgressSaveInterval = 30 * time.Second
+)
+
+// jobRecord is the persisted state of a job started by this SNA
+type jobRecord struct {
+	JobType     string         `json:"job_type"` // "backup" or "restore"
+	Status      *JobStatus     `json:"status"`
+	PID         int            `json:"pid"`
+	PIDStart    uint64         `json:"pid_start,omitempty"` // Process start time in clock ticks - guards against PID reuse
+	VCenterHost string         `json:"vcenter_host"`
+	VCenterUser string         `json:"vcenter_user"`
+	SnapshotRef string         `json:"snapshot_ref,omitempty"` // Snapshot created by the client (from its checkpoint)
+	EventSeq    uint64         `json:"event_seq,omitempty"`    // Last client event applied - a reconnecting client replays the rest
+	Backup      *BackupRequest `json:"backup,omitempty"`       // Backup request without secrets, to relaunch a resumed client
+}
+
+// clientCheckpoint is the part of the backup client's transfer checkpoint the SNA reads
+type clientCheckpoint struct {
+	VMRef       string `json:"vm_ref"`
+	SnapshotRef string `json:"snapshot_ref"`
+}
+
+// lostJobResponse is the SHA's answer to a lost job report
+type lostJobResponse struct {
+	VCenterHost      string               `json:"vcenter_host"`
+	VCenterUser      string               `json:"vcenter_user"`
+	VMwareCredential *VMwareCredentialRef `json:"vmware_credential"`
+	Resume           bool                 `json:"resume"`             // Relaunch the backup client under the same job ID
+	NBDTargets       string               `json:"nbd_targets"`        // Re-exported disks of a resumed backup
+	ExcludedDiskKeys []int                `json:"excluded_disk_keys"` // Disks of a resumed backup skipped by policy
+	KeepSnapshot     bool                 `json:"keep_snapshot"`      // The next backup of the VM resumes from the snapshot
+}
+
+// recordJob persists a job that was just started so it survives an SNA restart. Backups
+// keep their request (without the vCenter password, credential token or guest hooks)
+// so a lost client can be relaunched to resume.
+func (s *SNAControlServer) recordJob(jobType, jobID, vcenterHost, vcenterUser string, pid int, backup *BackupRequest) {
+	pidStart, err := processStartTime(pid)
+	if err != nil {
+		log.WithError(err).WithField("job_id", jobID).Warn("Failed to read job process start time")
+	}
+
+	s.jobTracker.mu.Lock()
+	defer s.jobTracker.mu.Unlock()
+
+	job, exists := s.jobTracker.jobs[jobID]
+	if !exists {
+		return
+	}
+	record := &jobRecord{
+		JobType:     jobType,
+		Status:      job,
+		PID:         pid,
+		PIDStart:    pidStart,
+		VCenterHost: vcenterHost,
+		VCenterUser: vcenterUser,
+	}
+	if backup != nil {
+		request := *backup
+		request.VCenterPass = ""
+		request.VMwareCredential = nil
+		request.GuestHooks = nil
+		request.Resume = false
+		record.Backup = &request
+	}
+	s.jobTracker.records[jobID] = record
+	s.persistJobLocked(jobID)
+}
+
+// persistJob writes the record of a job
+func (s *SNAControlServer) persistJob(jobID string) {
+	s.jobTracker.mu.RLock()
+	defer s.jobTracker.mu.RUnlock()
+	s.persistJobLocked(jobID)
+}
+
+// persistJobLocked writes the record of a job; the caller holds the job tracker lock
+func (s *SNAControlServer) persistJobLocked(jobID string) {
+	record, exists := s.jobTracker.records[jobID]
+	if !exists {
+		return
+	}
+	if err := writeJobRecord(jobID, record); err != nil {
+		log.WithError(err).WithField("job_id", jobID).Warn("Failed to persist job record")
+	}
+}
+
+// RecoverJobs reloads the jobs recorded before the SNA restarted and reconciles them
+// with the processes still running. Call before Start so the SHA finds recovered jobs
+// on its first status poll.
+func (s *SNAControlServer) RecoverJobs(shaURL string) {
+	s.shaURL = strings.TrimRight(shaURL, "/")
+	defer func() { go s.saveJobProgress() }()
+
+	entries, err := os.ReadDir(jobStoreDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.WithError(err).Warn("Failed to read job records")
+		}
+		return
+	}
+
+	recovered := 0
+	for _, entry := range entries {
+		path := filepath.Join(jobStoreDir, entry.Name())
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			os.Remove(path) // Interrupted write
+			continue
+		}
+		jobID, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok {
+			continue
+		}
+
+		record, err := readJobRecord(path)
+		if err != nil {
+			log.WithError(err).WithField("path", path).Warn("Discarding unreadable job record")
+			os.Remove(path)
+			continue
+		}
+
+		logger := log.WithFields(log.Fields{
+			"job_id":   jobID,
+			"job_type": record.JobType,
+			"pid":      record.PID,
+			"status":   record.Status.Status,
+		})
+
+		if isFinalJobStatus(record.Status.Status) {
+			if time.Since(record.Status.LastUpdate) > finishedJobRetention {
+				os.Remove(path)
+				continue
+			}
+			s.restoreJobRecord(jobID, record)
+			continue
+		}
+
+		s.restoreJobRecord(jobID, record)
+		recovered++
+		if jobProcessAlive(jobID, record) {
+			s.watchRecoveredJob(jobID, record.PID)
+			logger.Info("🔗 Reattached to job process that survived the SNA restart")
+			continue
+		}
+
+		logger.Warn("Job process exited while the SNA was down")
+		go s.finishRecoveredJob(jobID)
+	}
+
+	if recovered > 0 {
+		log.WithField("jobs", recovered).Info("♻️ Recovered active jobs from job records")
+	}
+}
+
+// restoreJobRecord puts a recovered job back in the job tracker
+func (s *SNAControlServer) restoreJobRecord(jobID string, record *jobRecord) {
+	s.jobTracker.mu.Lock()
+	defer s.jobTracker.mu.Unlock()
+
+	// A reattached client reopens its event stream on the client event socket
+	s.jobTracker.jobs[jobID] = record.Status
+	s.jobTracker.records[jobID] = record
+}
+
+// watchRecoveredJob tracks a reattached client until it exits
+func (s *SNAControlServer) watchRecoveredJob(jobID string, pid int) {
+	proc := &jobProcess{
+		pid:    pid,
+		exited: make(chan struct{}),
+	}
+
+	s.jobTracker.mu.Lock()
+	s.jobTracker.processes[jobID] = proc
+	s.jobTracker.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(recoveredJobPollInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if processRunsJob(pid, jobID) {
+				continue
+			}
+			close(proc.exited)
+
+			s.jobTracker.mu.Lock()
+			delete(s.jobTracker.processes, jobID)
+			s.jobTracker.mu.Unlock()
+
+			s.finishRecoveredJob(jobID)
+			return
+		}
+	}()
+}
+
+// finishRecoveredJob settles a recovered job whose client has exited. A client that
+// finished on its own left its final state in the control state file (and reported it
+// to the SHA itself); otherwise the job is lost. A lost backup that checkpointed its
+// transfer is relaunched to resume when the SHA re-exports its disks, or keeps its
+// snapshot for the next backup of the VM; other lost jobs are failed and their
+// snapshot removed.
+func (s *SNAControlServer) finishRecoveredJob(jobID string) {
+	// The control files belong to the exited client - clear them before a resumed
+	// client of the same job starts writing its own
+	state := readClientState(jobID)
+	os.Remove(controlRequestPath(jobID))
+	os.Remove(controlStatePath(jobID))
+
+	if isFinalJobStatus(state) {
+		s.setJobStatus(jobID, state, state)
+		log.WithFields(log.Fields{
+			"job_id": jobID,
+			"status": state,
+		}).Info("Recovered job finished")
+		return
+	}
+
+	s.jobTracker.mu.RLock()
+	record, exists := s.jobTracker.records[jobID]
+	var jobType, vcenterHost, vcenterUser, vmPath, snapshotRef string
+	var backup *BackupRequest
+	if exists {
+		jobType, vcenterHost, vcenterUser = record.JobType, record.VCenterHost, record.VCenterUser
+		vmPath, snapshotRef, backup = record.Status.VMPath, record.SnapshotRef, record.Backup
+	}
+	s.jobTracker.mu.RUnlock()
+	if !exists {
+		return
+	}
+
+	checkpoint, err := readClientCheckpoint(jobID)
+	if err == nil && checkpoint.SnapshotRef != "" {
+		snapshotRef = checkpoint.SnapshotRef
+	}
+	resumable := jobType == "backup" && backup != nil && err == nil && checkpoint.SnapshotRef != ""
+
+	logger := log.WithFields(log.Fields{
+		"job_id":       jobID,
+		"snapshot_ref": snapshotRef,
+		"resumable":    resumable,
+	})
+	logger.Warn("⚠️ Job lost - its client exited without a final state")
+
+	message := fmt.Sprintf("%s client exited while the SNA was restarting", jobType)
+	response, err := s.reportLostJob(jobType, jobID, message, snapshotRef, resumable)
+	if err != nil {
+		s.setJobStatus(jobID, "failed", "lost")
+		logger.WithError(err).Error("Failed to report lost job to SHA")
+		return
+	}
+
+	if resumable && response != nil && response.Resume {
+		if err := s.resumeLostBackup(backup, response); err != nil {
+			// The SHA fails the job once it stops hearing from it; the snapshot is kept
+			// for the next backup of the VM
+			s.setJobStatus(jobID, "failed", "lost")
+			logger.WithError(err).Error("Failed to relaunch lost backup")
+			return
+		}
+		logger.Info("♻️ Lost backup relaunched to resume from its checkpoint")
+		return
+	}
+
+	s.setJobStatus(jobID, "failed", "lost")
+	if snapshotRef == "" {
+		return
+	}
+	if response != nil && response.KeepSnapshot {
+		logger.Info("Snapshot of lost backup kept - the next backup of the VM resumes from it")
+		return
+	}
+	if response == nil || response.VMwareCredential == nil {
+		logger.Warn("SHA provided no credentials - snapshot of lost job left for manual cleanup")
+		return
+	}
+
+	if response.VCenterHost != "" {
+		vcenterHost, vcenterUser = response.VCenterHost, response.VCenterUser
+	}
+	if err := runSnapshotCleanup(jobID, vcenterHost, vcenterUser, vmPath, snapshotRef, response.VMwareCredential); err != nil {
+		logger.WithError(err).Error("Failed to remove snapshot of lost job")
+		return
+	}
+	logger.Info("🧹 Snapshot of lost job removed")
+}
+
+// resumeLostBackup relaunches the client of a lost backup under the same job ID with the
+// disks the SHA re-exported; the client resumes from its checkpoint and kept snapshot
+func (s *SNAControlServer) resumeLostBackup(backup *BackupRequest, response *lostJobResponse) error {
+	req := *backup
+	req.NBDTargets = response.NBDTargets
+	req.ExcludedDiskKeys = response.ExcludedDiskKeys
+	req.VMwareCredential = response.VMwareCredential
+	req.Resume = true
+	if response.VCenterHost != "" {
+		req.VCenterHost, req.VCenterUser = response.VCenterHost, response.VCenterUser
+	}
+	if err := s.validateBackupRequest(&req); err != nil {
+		return err
+	}
+
+	_, err := s.startBackup(&req)
+	return err
+}
+
+// reportLostJob tells the SHA a job failed while the SNA was down. The response carries
+// credentials for removing the job's snapshot, or - for a resumable backup - the new
+// NBD targets to resume it with (nil if the SHA already ended the job).
+func (s *SNAControlServer) reportLostJob(jobType, jobID, message, snapshotRef string, resumable bool) (*lostJobResponse, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"error":        message,
+		"snapshot_ref": snapshotRef,
+		"resumable":    resumable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode lost job report: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/telemetry/%s/%s/lost", s.shaURL, jobType, jobID)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lost job report: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach SHA: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusConflict:
+		log.WithField("job_id", jobID).Info("SHA had already ended the lost job")
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("SHA rejected lost job report with status %d", resp.StatusCode)
+	}
+
+	var response lostJobResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("invalid lost job response: %w", err)
+	}
+	return &response, nil
+}
+
+// runSnapshotCleanup removes the snapshot of a lost job with the backup client
+func runSnapshotCleanup(jobID, vcenterHost, vcenterUser, vmPath, snapshotRef string, credential *VMwareCredentialRef) error {
+	cmd := exec.Command("/usr/local/bin/sendense-backup-client",
+		"cleanup",
+		"--vmware-endpoint", vcenterHost,
+		"--vmware-username", vcenterUser,
+		"--vmware-path", vmPath,
+		"--job-id", jobID,
+		"--snapshot-ref", snapshotRef,
+	)
+	if err := AttachVMwareCredentials(cmd, credential, ""); err != nil {
+		return err
+	}
+	output, err := cmd.CombinedOutput()
+	ReleaseCommandFiles(cmd)
+	if err != nil {
+		return fmt.Errorf("cleanup command failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// saveJobProgress periodically persists the progress, last client event and snapshot
+// reference of running jobs
+func (s *SNAControlServer) saveJobProgress() {
+	ticker := time.NewTicker(jobProgressSaveInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.jobTracker.mu.RLock()
+		var jobIDs []string
+		for jobID, record := range s.jobTracker.records {
+			if !isFinalJobStatus(record.Status.Status) {
+				jobIDs = append(jobIDs, jobID)
+			}
+		}
+		s.jobTracker.mu.RUnlock()
+
+		for _, jobID := range jobIDs {
+			checkpoint, _ := readClientCheckpoint(jobID)
+
+			// The event stream already keeps the record's status and event sequence current
+			s.jobTracker.mu.Lock()
+			if record, exists := s.jobTracker.records[jobID]; exists {
+				if checkpoint != nil && checkpoint.SnapshotRef != "" {
+					record.SnapshotRef = checkpoint.SnapshotRef
+				}
+				s.persistJobLocked(jobID)
+			}
+			s.jobTracker.mu.Unlock()
+		}
+	}
+}
+
+// removeJobRecord deletes the persisted record of a job
+func removeJobRecord(jobID string) {
+	if err := os.Remove(jobRecordPath(jobID)); err != nil && !os.IsNotExist(err) {
+		log.WithError(err).WithField("job_id", jobID).Warn("Failed to remove job record")
+	}
+}
+
+// writeJobRecord stores a job record atomically
+func writeJobRecord(jobID string, record *jobRecord) error {
+	if err := os.MkdirAll(jobStoreDir, 0700); err != nil {
+		return fmt.Errorf("failed to create job record directory: %w", err)
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode job record: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(jobStoreDir, jobID+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write job record: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), jobRecordPath(jobID))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write job record: %w", err)
+	}
+	return nil
+}
+
+// readJobRecord loads a job record
+func readJobRecord(path string) (*jobRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var record jobRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	if record.Status == nil {
+		return nil, fmt.Errorf("job record has no status")
+	}
+	return &record, nil
+}
+
+// readClientState returns the state in the backup client's control state file
+func readClientState(jobID string) string {
+	data, err := os.ReadFile(controlStatePath(jobID))
+	if err != nil {
+		return ""
+	}
+	var state clientControlState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return ""
+	}
+	return state.State
+}
+
+// readClientCheckpoint loads the snapshot reference from the backup client's checkpoint
+func readClientCheckpoint(jobID string) (*clientCheckpoint, error) {
+	data, err := os.ReadFile(filepath.Join(checkpointDir, jobID+".json"))
+	if err != nil {
+		return nil, err
+	}
+	var checkpoint clientCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+// jobProcessAlive reports whether the recorded client of a job is still running
+func jobProcessAlive(jobID string, record *jobRecord) bool {
+	if !processRunsJob(record.PID, jobID) {
+		return false
+	}
+	if record.PIDStart == 0 {
+		return true
+	}
+	start, err := processStartTime(record.PID)
+	return err == nil && start == record.PIDStart
+}
+
+// processStartTime returns when a process started, in clock ticks since boot
+// (field 22 of /proc/<pid>/stat)
+func processStartTime(pid int) (uint64, error) {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0, err
+	}
+	// The command name (field 2) may contain spaces, so count fields after its ')'
+	end := bytes.LastIndexByte(data, ')')
+	if end < 0 {
+		return 0, fmt.Errorf("malformed stat for process %d", pid)
+	}
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 20 {
+		return 0, fmt.Errorf("malformed stat for process %d", pid)
+	}
+	return strconv.ParseUint(fields[19], 10, 64)
+}
+
+// isFinalJobStatus reports whether a job status is terminal
+func isFinalJobStatus(status string) bool {
+	return status == "completed" || status == "failed" || status == "cancelled"
+}
+
+func jobRecordPath(jobID string) string {
+	return filepath.Join(jobStoreDir, jobID+".json")
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Source types of a backup request - VMware when unset (older SHAs)
+const (
+	SourceTypeVMware = "vmware"
+	SourceTypeKVM    = "kvm"
+)
+
+// KVMSource locates a libvirt domain and the NBD server libvirt exposes its disks on
+// during a pull-mode backup
+type KVMSource struct {
+	LibvirtURI string `json:"libvirt_uri"`           // e.g. "qemu+ssh://root@kvm1/system"
+	Domain     string `json:"domain"`                // Domain name or UUID
+	NBDHost    string `json:"nbd_host,omitempty"`    // Address libvirt serves the backup exports on
+	NBDPort    int    `json:"nbd_port,omitempty"`    // Defaults to 10809
+	NBDSocket  string `json:"nbd_socket,omitempty"`  // Unix socket instead of nbd_host (local libvirt only)
+	ScratchDir string `json:"scratch_dir,omitempty"` // Backup scratch files on the KVM host
+}
+
+// KVMDomainRequest asks the SNA to describe a libvirt domain
+type KVMDomainRequest struct {
+	LibvirtURI string `json:"libvirt_uri"`
+	Domain     string `json:"domain"`
+}
+
+// validateKVMSource checks the KVM part of a backup request
+func validateKVMSource(req *BackupRequest) error {
+	if req.KVM == nil || req.KVM.Domain == "" {
+		return fmt.Errorf("kvm.domain is required")
+	}
+	if req.KVM.NBDHost == "" && req.KVM.NBDSocket == "" {
+		return fmt.Errorf("kvm.nbd_host or kvm.nbd_socket is required")
+	}
+	// KVM clients keep no resume checkpoint and run no guest hooks
+	if req.Resume {
+		return fmt.Errorf("KVM backups cannot be resumed")
+	}
+	if len(req.GuestHooks) > 0 {
+		return fmt.Errorf("guest hooks are not supported for KVM backups")
+	}
+	return nil
+}
+
+// kvmBackupArgs returns the backup client arguments of a KVM backup request
+func kvmBackupArgs(req *BackupRequest) []string {
+	args := []string{
+		"kvm-backup",
+		"--domain", req.KVM.Domain,
+		"--nbd-targets", req.NBDTargets,
+		"--job-id", req.JobID,
+	}
+	if req.KVM.LibvirtURI != "" {
+		args = append(args, "--libvirt-uri", req.KVM.LibvirtURI)
+	}
+	if req.KVM.NBDSocket != "" {
+		args = append(args, "--kvm-nbd-socket", req.KVM.NBDSocket)
+	} else {
+		args = append(args, "--kvm-nbd-host", req.KVM.NBDHost)
+		if req.KVM.NBDPort > 0 {
+			args = append(args, "--kvm-nbd-port", strconv.Itoa(req.KVM.NBDPort))
+		}
+	}
+	if req.KVM.ScratchDir != "" {
+		args = append(args, "--kvm-scratch-dir", req.KVM.ScratchDir)
+	}
+	return args
+}
+
+// handleKVMDomain describes a libvirt domain (UUID, disk keys and capacities) with the
+// backup client, so the SHA can record its disks before the first backup
+func (s *SNAControlServer) handleKVMDomain(w http.ResponseWriter, r *http.Request) {
+	var req KVMDomainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Domain == "" {
+		http.Error(w, "domain is required", http.StatusBadRequest)
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"libvirt_uri": req.LibvirtURI,
+		"domain":      req.Domain,
+	}).Info("Received KVM domain inspection request from SHA")
+
+	args := []string{"kvm-inspect", "--domain", req.Domain}
+	if req.LibvirtURI != "" {
+		args = append(args, "--libvirt-uri", req.LibvirtURI)
+	}
+	cmd := exec.CommandContext(r.Context(), "/usr/local/bin/sendense-backup-client", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		log.WithError(err).WithField("domain", req.Domain).Error("Failed to inspect KVM domain")
+		http.Error(w, fmt.Sprintf("Domain inspection failed: %v: %s", err, strings.TrimSpace(stderr.String())), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(output)
+}
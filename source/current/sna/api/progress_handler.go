@@ -158,6 +158,14 @@ func convertToVMAProgressResponse(jobProgress *progress.ReplicationProgress) *SN
 		},
 	}
 
+	// Errors reported through the backup client event stream
+	for _, clientErr := range jobProgress.Errors {
+		response.Errors = append(response.Errors, clientErr)
+	}
+	if n := len(jobProgress.Errors); n > 0 {
+		response.LastError = jobProgress.Errors[n-1]
+	}
+
 	// 🎯 MULTI-DISK PROGRESS INFORMATION
 	// Convert disk progress to API format
 	if len(jobProgress.Disks) > 0 {
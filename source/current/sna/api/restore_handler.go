@@ -0,0 +1,251 @@
+// Package api provides the VMware restore endpoint for the SNA server
+// The SHA exports backup disks over NBD; the SNA runs the backup client's restore
+// command, which writes them back to vSphere through VDDK
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-sha/models"
+)
+
+// VMwareRestoreRequest asks the SNA to restore a backup to vSphere
+type VMwareRestoreRequest struct {
+	JobID       string            `json:"job_id"`                     // SHA-generated restore job ID
+	VCenterHost string            `json:"vcenter_host"`               // vCenter hostname
+	VCenterUser string            `json:"vcenter_user"`               // vCenter username
+	VCenterPass string            `json:"vcenter_password,omitempty"` // vCenter password (older SHAs - prefer vmware_credential)
+	Spec        VMwareRestoreSpec `json:"spec"`                       // Handed to the backup client as-is
+
+	VMwareCredential *VMwareCredentialRef `json:"vmware_credential,omitempty"` // Credential the backup client resolves from the SHA
+}
+
+// VMwareRestoreSpec describes the restore target and the backup disks to write
+type VMwareRestoreSpec struct {
+	Mode         string                  `json:"mode"`                  // "new_vm" or "in_place"
+	VMPath       string                  `json:"vm_path,omitempty"`     // In-place: VM whose disks are overwritten
+	TargetName   string                  `json:"target_name,omitempty"` // New VM: name of the VM to create
+	Datacenter   string                  `json:"datacenter,omitempty"`
+	Folder       string                  `json:"folder,omitempty"`
+	ResourcePool string                  `json:"resource_pool,omitempty"`
+	Datastore    string                  `json:"datastore,omitempty"`
+	NetworkMap   map[string]string       `json:"network_map,omitempty"` // Source network name -> target network name
+	PreserveMACs bool                    `json:"preserve_macs"`
+	PowerOn      bool                    `json:"power_on"`
+	VMConfig     *models.VMConfiguration `json:"vm_config,omitempty"` // Configuration captured with the backup
+	Disks        []VMwareRestoreDisk     `json:"disks"`
+}
+
+// VMwareRestoreDisk is one backup disk exported by the SHA for restore
+type VMwareRestoreDisk struct {
+	DiskKey   int32  `json:"disk_key"`             // VMware disk key on the source VM
+	SizeBytes int64  `json:"size_bytes"`           // Virtual size of the backup disk
+	SourceURL string `json:"source_url,omitempty"` // nbd://host:port/export (empty when Blank)
+	Blank     bool   `json:"blank"`                // Disk was excluded from the backup
+	ChangeID  string `json:"change_id,omitempty"`  // CBT change ID recorded by the backup
+}
+
+// VMwareRestoreResponse represents the response from starting a restore
+type VMwareRestoreResponse struct {
+	JobID     string `json:"job_id"`
+	Status    string `json:"status"` // "started"
+	Message   string `json:"message"`
+	StartedAt string `json:"started_at"` // ISO 8601 timestamp
+	PID       int    `json:"pid"`        // SBC process ID
+}
+
+// handleVMwareRestore launches a restore of backup disks to vSphere
+func (s *SNAControlServer) handleVMwareRestore(w http.ResponseWriter, r *http.Request) {
+	var req VMwareRestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WithError(err).Error("Invalid restore request")
+		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"job_id":  req.JobID,
+		"mode":    req.Spec.Mode,
+		"vcenter": req.VCenterHost,
+		"disks":   len(req.Spec.Disks),
+	}).Info("♻️ Received VMware restore request from SHA")
+
+	if err := validateVMwareRestoreRequest(&req); err != nil {
+		log.WithError(err).Error("Restore request validation failed")
+		http.Error(w, fmt.Sprintf("Validation failed: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	cmd, err := s.buildRestoreCommand(&req)
+	if err != nil {
+		log.WithError(err).Error("Failed to build restore command")
+		http.Error(w, fmt.Sprintf("Command build failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	eventStream, err := attachEventStream(cmd)
+	if err != nil {
+		log.WithError(err).WithField("job_id", req.JobID).Warn("Starting restore without an event stream")
+	}
+
+	err = cmd.Start()
+	ReleaseCommandFiles(cmd)
+	if err != nil {
+		if eventStream != nil {
+			eventStream.Close()
+		}
+		log.WithError(err).Error("Failed to start restore process")
+		http.Error(w, fmt.Sprintf("Process start failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	s.AddJob(req.JobID)
+	if eventStream != nil {
+		s.consumeEventStream(req.JobID, eventStream, 0)
+	}
+	s.trackJobProcess(req.JobID, cmd)
+	s.recordJob("restore", req.JobID, req.VCenterHost, req.VCenterUser, cmd.Process.Pid, nil)
+
+	response := VMwareRestoreResponse{
+		JobID:     req.JobID,
+		Status:    "started",
+		Message:   fmt.Sprintf("VMware restore (%s) started", req.Spec.Mode),
+		StartedAt: time.Now().UTC().Format(time.RFC3339),
+		PID:       cmd.Process.Pid,
+	}
+
+	log.WithFields(log.Fields{
+		"job_id": req.JobID,
+		"pid":    response.PID,
+	}).Info("✅ Restore process started successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// validateVMwareRestoreRequest validates the restore request fields
+func validateVMwareRestoreRequest(req *VMwareRestoreRequest) error {
+	if req.JobID == "" {
+		return fmt.Errorf("job_id is required")
+	}
+	if req.VCenterHost == "" || req.VCenterUser == "" {
+		return fmt.Errorf("vcenter_host and vcenter_user are required")
+	}
+	if req.VCenterPass == "" && (req.VMwareCredential == nil || req.VMwareCredential.CredentialID == 0 || req.VMwareCredential.Token == "") {
+		return fmt.Errorf("vmware_credential (or vcenter_password) is required")
+	}
+	switch req.Spec.Mode {
+	case "new_vm":
+		if req.Spec.VMConfig == nil {
+			return fmt.Errorf("vm_config is required for new_vm restores")
+		}
+		if req.Spec.TargetName == "" {
+			return fmt.Errorf("target_name is required for new_vm restores")
+		}
+	case "in_place":
+		if req.Spec.VMPath == "" {
+			return fmt.Errorf("vm_path is required for in_place restores")
+		}
+	default:
+		return fmt.Errorf("mode must be 'new_vm' or 'in_place'")
+	}
+	if len(req.Spec.Disks) == 0 {
+		return fmt.Errorf("at least one disk is required")
+	}
+	for _, disk := range req.Spec.Disks {
+		if !disk.Blank && disk.SourceURL == "" {
+			return fmt.Errorf("disk %d has no source_url", disk.DiskKey)
+		}
+	}
+	return nil
+}
+
+// buildRestoreCommand constructs the sendense-backup-client restore command
+func (s *SNAControlServer) buildRestoreCommand(req *VMwareRestoreRequest) (*exec.Cmd, error) {
+	sbcBinary := "/usr/local/bin/sendense-backup-client"
+	if _, err := os.Stat(sbcBinary); os.IsNotExist(err) {
+		return nil, fmt.Errorf("sendense-backup-client binary not found")
+	}
+
+	// The spec carries the VM configuration and disk exports - hand it over in a
+	// private job-scoped file (removed by the client after loading)
+	specPath, err := writeRestoreSpecFile(req.JobID, &req.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	vmPath := req.Spec.VMPath
+	if vmPath == "" {
+		vmPath = req.Spec.TargetName
+	}
+
+	args := []string{
+		"restore",
+		"--vmware-endpoint", req.VCenterHost,
+		"--vmware-username", req.VCenterUser,
+		"--vmware-path", vmPath,
+		"--job-id", req.JobID,
+		"--restore-spec-file", specPath,
+	}
+
+	cmd := exec.Command(sbcBinary, args...)
+	if err := AttachVMwareCredentials(cmd, req.VMwareCredential, req.VCenterPass); err != nil {
+		return nil, err
+	}
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("MIGRATEKIT_JOB_ID=%s", req.JobID),
+	)
+
+	logDir := "/var/log/sendense"
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		log.WithError(err).Warn("Failed to create log directory, using /tmp")
+		logDir = "/tmp"
+	}
+
+	logPath := filepath.Join(logDir, fmt.Sprintf("restore-%s.log", req.JobID))
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		ReleaseCommandFiles(cmd)
+		return nil, fmt.Errorf("failed to create log file: %w", err)
+	}
+
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+
+	log.WithFields(log.Fields{
+		"binary":   sbcBinary,
+		"job_id":   req.JobID,
+		"log_path": logPath,
+	}).Info("Built restore command")
+
+	return cmd, nil
+}
+
+// writeRestoreSpecFile stores the restore spec for the backup client in a private job-scoped file
+func writeRestoreSpecFile(jobID string, spec *VMwareRestoreSpec) (string, error) {
+	specDir := "/var/lib/sendense/restores"
+	if err := os.MkdirAll(specDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create restore spec directory: %w", err)
+	}
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode restore spec: %w", err)
+	}
+
+	specPath := filepath.Join(specDir, fmt.Sprintf("%s.json", jobID))
+	if err := os.WriteFile(specPath, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write restore spec file: %w", err)
+	}
+
+	return specPath, nil
+}
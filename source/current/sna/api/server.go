@@ -11,13 +11,14 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
 	"github.com/vexxhost/migratekit/source/current/sna/cbt"
-	"github.com/vexxhost/migratekit/source/current/sna/progress"
 	"github.com/vexxhost/migratekit/source/current/sna/services"
 )
 
@@ -29,13 +30,16 @@ type SNAControlServer struct {
 	router            *mux.Router
 	discoveryProvider services.VMwareDiscoveryProvider
 	specChecker       services.VMSpecificationChecker
+	shaURL            string // SHA API base URL for lost job reports (set by RecoverJobs)
+	progressService   *services.ProgressService
 }
 
 // JobTracker tracks migration jobs and their status
 type JobTracker struct {
-	mu      sync.RWMutex
-	jobs    map[string]*JobStatus
-	parsers map[string]*progress.ProgressParser
+	mu        sync.RWMutex
+	jobs      map[string]*JobStatus
+	processes map[string]*jobProcess // 🆕 NEW: Job processes for pause/resume/cancel
+	records   map[string]*jobRecord  // Persisted job records (survive SNA restarts)
 }
 
 // JobStatus represents the current status of a migration job
@@ -110,6 +114,8 @@ type VMInfo struct {
 	Name       string `json:"name"`
 	Path       string `json:"path"`
 	Datacenter string `json:"datacenter"`
+	ESXiHost   string `json:"esxi_host,omitempty"` // ESXi host the VM runs on
+	Cluster    string `json:"cluster,omitempty"`   // Cluster of that host (empty for standalone hosts)
 	PowerState string `json:"power_state"`
 	GuestOS    string `json:"guest_os"`
 	MemoryMB   int    `json:"memory_mb"`
@@ -125,6 +131,10 @@ type VMInfo struct {
 	VMwareToolsStatus  string `json:"vmware_tools_status"`  // VMware Tools status
 	VMwareToolsVersion string `json:"vmware_tools_version"` // VMware Tools version
 
+	// vSphere metadata for rule-based machine groups
+	ResourcePool string   `json:"resource_pool,omitempty"` // Resource pool name
+	Tags         []string `json:"tags"`                    // Attached tags as "category:tag" (nil: tags could not be read)
+
 	Disks    []DiskInfo    `json:"disks"`
 	Networks []NetworkInfo `json:"networks"`
 }
@@ -180,12 +190,13 @@ type ReplicationResponse struct {
 
 // VMSpecChangesRequest represents a request to check VM specification changes
 type VMSpecChangesRequest struct {
-	VCenter      string `json:"vcenter" binding:"required"`
-	Username     string `json:"username" binding:"required"`
-	Password     string `json:"password" binding:"required"`
-	Datacenter   string `json:"datacenter" binding:"required"`
-	VMPath       string `json:"vm_path" binding:"required"`
-	StoredVMInfo VMInfo `json:"stored_vm_info" binding:"required"`
+	VCenter          string               `json:"vcenter" binding:"required"`
+	Username         string               `json:"username" binding:"required"`
+	Password         string               `json:"password,omitempty"`          // Older SHAs only
+	VMwareCredential *VMwareCredentialRef `json:"vmware_credential,omitempty"` // Redeemed with the SHA for the password
+	Datacenter       string               `json:"datacenter" binding:"required"`
+	VMPath           string               `json:"vm_path" binding:"required"`
+	StoredVMInfo     VMInfo               `json:"stored_vm_info" binding:"required"`
 }
 
 // VMSpecChangesResponse represents the response from VM specification change detection
@@ -198,17 +209,45 @@ type VMSpecChangesResponse struct {
 	ErrorMessage   string `json:"error_message,omitempty"`
 }
 
+// VMConfigRequest represents a request to collect a VM's full configuration
+type VMConfigRequest struct {
+	VCenter          string               `json:"vcenter"`
+	Username         string               `json:"username"`
+	VMwareCredential *VMwareCredentialRef `json:"vmware_credential"` // Redeemed with the SHA for the password
+	Datacenter       string               `json:"datacenter"`
+	VMPath           string               `json:"vm_path"`
+}
+
 // BackupRequest represents a backup job request from SHA
 type BackupRequest struct {
-	JobID            string `json:"job_id"`                       // SHA-generated job ID
-	VMName           string `json:"vm_name"`                      // VM name for identification
-	VCenterHost      string `json:"vcenter_host"`                 // vCenter hostname
-	VCenterUser      string `json:"vcenter_user"`                 // vCenter username
-	VCenterPass      string `json:"vcenter_password"`             // vCenter password
-	VMPath           string `json:"vm_path"`                      // VMware VM path (e.g., "/DC1/vm/pgtest1")
-	NBDTargets       string `json:"nbd_targets"`                  // Multi-disk NBD targets string
-	BackupType       string `json:"backup_type"`                  // "full" or "incremental"
-	PreviousChangeID string `json:"previous_change_id,omitempty"` // For incremental backups
+	JobID            string               `json:"job_id"`                       // SHA-generated job ID
+	VMName           string               `json:"vm_name"`                      // VM name for identification
+	VCenterHost      string               `json:"vcenter_host"`                 // vCenter hostname
+	VCenterUser      string               `json:"vcenter_user"`                 // vCenter username
+	VCenterPass      string               `json:"vcenter_password,omitempty"`   // vCenter password (older SHAs - prefer vmware_credential)
+	VMwareCredential *VMwareCredentialRef `json:"vmware_credential,omitempty"`  // Credential the backup client resolves from the SHA
+	VMPath           string               `json:"vm_path"`                      // VMware VM path (e.g., "/DC1/vm/pgtest1")
+	NBDTargets       string               `json:"nbd_targets"`                  // Multi-disk NBD targets string
+	BackupType       string               `json:"backup_type"`                  // "full" or "incremental"
+	PreviousChangeID string               `json:"previous_change_id,omitempty"` // For incremental backups
+	GuestHooks       []GuestHook          `json:"guest_hooks,omitempty"`        // In-guest pre/post snapshot hooks
+	ConsistencyLevel string               `json:"consistency_level,omitempty"`  // "crash", "filesystem" or "application"
+	VSSBackupType    string               `json:"vss_backup_type,omitempty"`    // "full" or "copy" (application consistency only)
+	ExcludedDiskKeys []int                `json:"excluded_disk_keys,omitempty"` // VMware disk keys skipped by policy
+	Resume           bool                 `json:"resume,omitempty"`             // Same job ID as an interrupted run - the client resumes from its checkpoint
+	SourceType       string               `json:"source_type,omitempty"`        // "vmware" (default) or "kvm"
+	KVM              *KVMSource           `json:"kvm,omitempty"`                // libvirt domain of a KVM backup
+}
+
+// GuestHook is a pre/post snapshot hook run inside the guest via VMware Tools
+type GuestHook struct {
+	Name           string `json:"name"`
+	HookType       string `json:"hook_type"` // "pre_snapshot" or "post_snapshot"
+	Command        string `json:"command"`
+	TimeoutSeconds int    `json:"timeout_seconds"`
+	OnFailure      string `json:"on_failure"` // "fail" or "continue"
+	GuestUsername  string `json:"guest_username"`
+	GuestPassword  string `json:"guest_password"`
 }
 
 // BackupResponse represents the response from starting a backup
@@ -225,8 +264,9 @@ func NewVMAControlServer(port int, vmwareClient VMwareClientInterface) *SNAContr
 	server := &SNAControlServer{
 		port: port,
 		jobTracker: &JobTracker{
-			jobs:    make(map[string]*JobStatus),
-			parsers: make(map[string]*progress.ProgressParser),
+			jobs:      make(map[string]*JobStatus),
+			processes: make(map[string]*jobProcess),
+			records:   make(map[string]*jobRecord),
 		},
 		vmwareClient: vmwareClient,
 		router:       mux.NewRouter(),
@@ -242,8 +282,9 @@ func NewVMAControlServerWithServices(port int, vmwareClient VMwareClientInterfac
 	server := &SNAControlServer{
 		port: port,
 		jobTracker: &JobTracker{
-			jobs:    make(map[string]*JobStatus),
-			parsers: make(map[string]*progress.ProgressParser),
+			jobs:      make(map[string]*JobStatus),
+			processes: make(map[string]*jobProcess),
+			records:   make(map[string]*jobRecord),
 		},
 		vmwareClient:      vmwareClient,
 		router:            mux.NewRouter(),
@@ -263,7 +304,6 @@ func (s *SNAControlServer) setupRoutes() {
 	api.HandleFunc("/cleanup", s.handleCleanup).Methods("POST")
 	api.HandleFunc("/status/{job_id}", s.handleStatus).Methods("GET")
 	// 🚨 REMOVED: Conflicting progress route - now handled by ProgressHandler
-	// api.HandleFunc("/progress/{job_id}", s.handleProgress).Methods("GET")
 	api.HandleFunc("/config", s.handleConfig).Methods("PUT")
 	api.HandleFunc("/health", s.handleHealth).Methods("GET")
 	api.HandleFunc("/vms/{vm_path:.*}/cbt-status", s.handleCBTStatus).Methods("GET")
@@ -273,6 +313,9 @@ func (s *SNAControlServer) setupRoutes() {
 	api.HandleFunc("/replicate", s.handleReplicate).Methods("POST")
 	api.HandleFunc("/backup/start", s.handleBackupStart).Methods("POST")
 	api.HandleFunc("/vm-spec-changes", s.handleVMSpecChanges).Methods("POST")
+	api.HandleFunc("/vm-config", s.handleVMConfig).Methods("POST")           // 🆕 NEW: VM configuration capture for backups
+	api.HandleFunc("/restore/vmware", s.handleVMwareRestore).Methods("POST") // 🆕 NEW: Restore backups to vSphere
+	api.HandleFunc("/kvm/domain", s.handleKVMDomain).Methods("POST")         // 🆕 NEW: libvirt domain disks for KVM backups
 
 	// Power management endpoints for unified failover system
 	api.HandleFunc("/vm/{vm_id}/power-off", s.handleVMPowerOff).Methods("POST")
@@ -283,7 +326,10 @@ func (s *SNAControlServer) setupRoutes() {
 	api.HandleFunc("/enrollment/enroll", s.handleEnrollWithOMA).Methods("POST")
 	api.HandleFunc("/enrollment/status", s.handleEnrollmentStatus).Methods("GET")
 
-	log.WithField("endpoints", 12).Info("SNA Control API routes configured (including backup endpoint)")
+	// 🆕 NEW: Job control endpoints (pause/resume/cancel)
+	s.registerJobControlRoutes(api)
+
+	log.WithField("endpoints", 16).Info("SNA Control API routes configured (including backup endpoint)")
 }
 
 // GetRouter returns the router instance for external route registration
@@ -291,7 +337,8 @@ func (s *SNAControlServer) GetRouter() *mux.Router {
 	return s.router
 }
 
-// AddJobWithProgress adds a job to tracking with progress parser
+// AddJobWithProgress adds a backup job to tracking; its progress comes from the
+// backup client event stream
 func (s *SNAControlServer) AddJobWithProgress(jobID, vmPath string) {
 	s.jobTracker.mu.Lock()
 	defer s.jobTracker.mu.Unlock()
@@ -305,16 +352,10 @@ func (s *SNAControlServer) AddJobWithProgress(jobID, vmPath string) {
 		LastUpdate: time.Now(),
 	}
 
-	// Create progress parser for hybrid tracking
-	logPath := fmt.Sprintf("/tmp/migratekit-%s.log", jobID)
-	parser := progress.NewProgressParser(jobID, logPath)
-	s.jobTracker.parsers[jobID] = parser
-
 	log.WithFields(log.Fields{
-		"job_id":   jobID,
-		"vm_path":  vmPath,
-		"log_path": logPath,
-	}).Info("Job added with hybrid progress tracking")
+		"job_id":  jobID,
+		"vm_path": vmPath,
+	}).Info("Job added with event stream progress tracking")
 }
 
 // RemoveJob removes a job from tracking and cleans up resources
@@ -322,34 +363,15 @@ func (s *SNAControlServer) RemoveJob(jobID string) {
 	s.jobTracker.mu.Lock()
 	defer s.jobTracker.mu.Unlock()
 
-	// Clean up progress parser
-	if _, exists := s.jobTracker.parsers[jobID]; exists {
-		delete(s.jobTracker.parsers, jobID)
-	}
-
 	delete(s.jobTracker.jobs, jobID)
+	if _, exists := s.jobTracker.records[jobID]; exists {
+		delete(s.jobTracker.records, jobID)
+		removeJobRecord(jobID)
+	}
 
 	log.WithField("job_id", jobID).Info("Job removed from tracking")
 }
 
-// UpdateNBDProgress updates NBD progress from pipe data
-func (s *SNAControlServer) UpdateNBDProgress(jobID string, bytesTransferred, totalBytes int64) {
-	s.jobTracker.mu.RLock()
-	parser, exists := s.jobTracker.parsers[jobID]
-	s.jobTracker.mu.RUnlock()
-
-	if exists && parser != nil && totalBytes > 0 {
-		percentage := float64(bytesTransferred) / float64(totalBytes) * 100.0
-		parser.UpdateNBDProgress(percentage)
-		log.WithFields(log.Fields{
-			"job_id":            jobID,
-			"bytes_transferred": bytesTransferred,
-			"total_bytes":       totalBytes,
-			"percentage":        percentage,
-		}).Debug("NBD progress updated from pipe")
-	}
-}
-
 // handleCleanup processes cleanup operation requests from SHA
 func (s *SNAControlServer) handleCleanup(w http.ResponseWriter, r *http.Request) {
 	var req CleanupRequest
@@ -390,6 +412,7 @@ func (s *SNAControlServer) handleCleanup(w http.ResponseWriter, r *http.Request)
 		job.Status = "completed"
 		job.CurrentOperation = "cleanup_completed"
 		job.LastUpdate = time.Now()
+		s.persistJob(req.JobID)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -415,43 +438,6 @@ func (s *SNAControlServer) handleStatus(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(job)
 }
 
-// handleProgress returns detailed progress information including throughput and errors
-func (s *SNAControlServer) handleProgress(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	jobID := vars["job_id"]
-
-	// Check if job exists in tracker
-	if _, exists := s.jobTracker.jobs[jobID]; !exists {
-		http.Error(w, fmt.Sprintf("Job not found: %s", jobID), http.StatusNotFound)
-		return
-	}
-
-	// Get progress from hybrid parser (log parsing + NBD pipe data)
-	s.jobTracker.mu.RLock()
-	parser, exists := s.jobTracker.parsers[jobID]
-	s.jobTracker.mu.RUnlock()
-
-	if !exists || parser == nil {
-		log.WithField("job_id", jobID).Warn("No active parser found for job")
-		http.Error(w, fmt.Sprintf("No active migration found for job: %s", jobID), http.StatusNotFound)
-		return
-	}
-
-	// Get progress from hybrid parser (log parsing + NBD pipe data)
-	jobProgress := parser.GetProgress()
-
-	log.WithFields(log.Fields{
-		"job_id":     jobID,
-		"percentage": jobProgress.Percentage,
-		"status":     jobProgress.Status,
-		"phase":      jobProgress.Phase,
-		"throughput": jobProgress.Throughput.CurrentMBps,
-	}).Debug("Returning progress from hybrid parser")
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(jobProgress)
-}
-
 // handleConfig processes configuration updates from SHA
 func (s *SNAControlServer) handleConfig(w http.ResponseWriter, r *http.Request) {
 	var req ConfigRequest
@@ -584,37 +570,36 @@ func (s *SNAControlServer) handleBackupStart(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	// Build sendense-backup-client command
-	cmd, err := s.buildBackupCommand(&req)
-	if err != nil {
-		log.WithError(err).Error("Failed to build backup command")
-		http.Error(w, fmt.Sprintf("Command build failed: %v", err), http.StatusInternalServerError)
-		return
+	// A resumed job must not run twice - the interrupted client may still be exiting
+	if req.Resume {
+		if _, running := s.findJobProcess(req.JobID); running {
+			log.WithField("job_id", req.JobID).Warn("Backup to resume is still running")
+			http.Error(w, "Backup job is still running", http.StatusConflict)
+			return
+		}
 	}
 
-	// Start backup process
-	if err := cmd.Start(); err != nil {
+	pid, err := s.startBackup(&req)
+	if err != nil {
 		log.WithError(err).Error("Failed to start backup process")
-		http.Error(w, fmt.Sprintf("Process start failed: %v", err), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Add job to tracker for status monitoring
-	s.AddJobWithProgress(req.JobID, req.VMPath)
-
 	// Create response
 	response := BackupResponse{
 		JobID:     req.JobID,
 		Status:    "started",
 		Message:   fmt.Sprintf("Backup started for %s", req.VMName),
 		StartedAt: time.Now().UTC().Format(time.RFC3339),
-		PID:       cmd.Process.Pid,
+		PID:       pid,
 	}
 
 	log.WithFields(log.Fields{
 		"job_id":  req.JobID,
 		"vm_name": req.VMName,
 		"pid":     response.PID,
+		"resume":  req.Resume,
 	}).Info("✅ Backup process started successfully")
 
 	w.Header().Set("Content-Type", "application/json")
@@ -622,6 +607,41 @@ func (s *SNAControlServer) handleBackupStart(w http.ResponseWriter, r *http.Requ
 	json.NewEncoder(w).Encode(response)
 }
 
+// startBackup launches sendense-backup-client for a backup request and tracks it as a job
+func (s *SNAControlServer) startBackup(req *BackupRequest) (int, error) {
+	cmd, err := s.buildBackupCommand(req)
+	if err != nil {
+		return 0, fmt.Errorf("command build failed: %w", err)
+	}
+
+	// Without an event stream the job reports progress to the SHA only
+	eventStream, err := attachEventStream(cmd)
+	if err != nil {
+		log.WithError(err).WithField("job_id", req.JobID).Warn("Starting backup without an event stream")
+	}
+
+	// Start backup process (the child holds its own copy of the credential and event pipes)
+	err = cmd.Start()
+	ReleaseCommandFiles(cmd)
+	if err != nil {
+		if eventStream != nil {
+			eventStream.Close()
+		}
+		removeGuestHooksFile(req.JobID)
+		return 0, fmt.Errorf("process start failed: %w", err)
+	}
+
+	// Add job to tracker for status monitoring
+	s.AddJobWithProgress(req.JobID, req.VMPath)
+	if eventStream != nil {
+		s.consumeEventStream(req.JobID, eventStream, 0)
+	}
+	s.trackJobProcess(req.JobID, cmd)
+	s.recordJob("backup", req.JobID, req.VCenterHost, req.VCenterUser, cmd.Process.Pid, req)
+
+	return cmd.Process.Pid, nil
+}
+
 // validateBackupRequest validates the backup request fields
 func (s *SNAControlServer) validateBackupRequest(req *BackupRequest) error {
 	if req.JobID == "" {
@@ -630,18 +650,33 @@ func (s *SNAControlServer) validateBackupRequest(req *BackupRequest) error {
 	if req.VMName == "" {
 		return fmt.Errorf("vm_name is required")
 	}
+	switch req.SourceType {
+	case "", SourceTypeVMware:
+	case SourceTypeKVM:
+		if err := validateKVMSource(req); err != nil {
+			return err
+		}
+		return validateBackupTargets(req)
+	default:
+		return fmt.Errorf("unsupported source_type %q", req.SourceType)
+	}
 	if req.VCenterHost == "" {
 		return fmt.Errorf("vcenter_host is required")
 	}
 	if req.VCenterUser == "" {
 		return fmt.Errorf("vcenter_user is required")
 	}
-	if req.VCenterPass == "" {
-		return fmt.Errorf("vcenter_password is required")
+	if req.VCenterPass == "" && (req.VMwareCredential == nil || req.VMwareCredential.CredentialID == 0 || req.VMwareCredential.Token == "") {
+		return fmt.Errorf("vmware_credential (or vcenter_password) is required")
 	}
 	if req.VMPath == "" {
 		return fmt.Errorf("vm_path is required")
 	}
+	return validateBackupTargets(req)
+}
+
+// validateBackupTargets validates the target and backup type fields of any source
+func validateBackupTargets(req *BackupRequest) error {
 	if req.NBDTargets == "" {
 		return fmt.Errorf("nbd_targets is required")
 	}
@@ -669,23 +704,62 @@ func (s *SNAControlServer) buildBackupCommand(req *BackupRequest) (*exec.Cmd, er
 		log.Warn("Using migratekit binary (legacy) - upgrade to sendense-backup-client recommended")
 	}
 
+	// The vCenter password is attached below through an inherited pipe, never as an argument
+
 	// Build command arguments
 	args := []string{
 		"migrate",
 		"--vmware-endpoint", req.VCenterHost,
 		"--vmware-username", req.VCenterUser,
-		"--vmware-password", req.VCenterPass,
 		"--vmware-path", req.VMPath,
 		"--nbd-targets", req.NBDTargets,
 		"--job-id", req.JobID,
 	}
+	if req.SourceType == SourceTypeKVM {
+		args = kvmBackupArgs(req)
+	}
+
+	// Guest hooks carry guest credentials, so they go to the backup client via a
+	// 0600 file (removed by the client after loading) rather than the command line
+	if len(req.GuestHooks) > 0 {
+		hooksPath, err := writeGuestHooksFile(req.JobID, req.GuestHooks)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--guest-hooks-file", hooksPath)
+	}
+
+	// Per-VM consistency policy - the backup client falls back automatically
+	// when quiescing fails and reports the level it actually achieved
+	if req.ConsistencyLevel != "" {
+		args = append(args, "--consistency-level", req.ConsistencyLevel)
+	}
+	if req.VSSBackupType != "" && req.SourceType != SourceTypeKVM {
+		args = append(args, "--vss-backup-type", req.VSSBackupType)
+	}
+
+	// Disks excluded by policy have no NBD target - tell the client to skip them
+	if len(req.ExcludedDiskKeys) > 0 {
+		keys := make([]string, len(req.ExcludedDiskKeys))
+		for i, key := range req.ExcludedDiskKeys {
+			keys[i] = strconv.Itoa(key)
+		}
+		args = append(args, "--exclude-disk-keys", strings.Join(keys, ","))
+	}
 
 	// NOTE: For incremental backups, the backup client queries the SHA database
 	// directly for the previous change_id per disk (via GET /api/v1/backups/changeid)
 	// No need to pass it as a command-line flag
 
 	// Create command
+	// libvirt is reached with the SNA's own access (e.g. its SSH key), so KVM backups carry no credentials
 	cmd := exec.Command(sbcBinary, args...)
+	if req.SourceType != SourceTypeKVM {
+		if err := AttachVMwareCredentials(cmd, req.VMwareCredential, req.VCenterPass); err != nil {
+			removeGuestHooksFile(req.JobID)
+			return nil, err
+		}
+	}
 
 	// Set environment variables for change_id storage
 	cmd.Env = append(os.Environ(),
@@ -706,9 +780,16 @@ func (s *SNAControlServer) buildBackupCommand(req *BackupRequest) (*exec.Cmd, er
 		logDir = "/tmp"
 	}
 
+	// A resumed job continues the log of its interrupted run
 	logPath := filepath.Join(logDir, fmt.Sprintf("backup-%s.log", req.JobID))
-	logFile, err := os.Create(logPath)
+	logFlags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if req.Resume {
+		logFlags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	logFile, err := os.OpenFile(logPath, logFlags, 0644)
 	if err != nil {
+		ReleaseCommandFiles(cmd)
+		removeGuestHooksFile(req.JobID)
 		return nil, fmt.Errorf("failed to create log file: %w", err)
 	}
 
@@ -724,6 +805,46 @@ func (s *SNAControlServer) buildBackupCommand(req *BackupRequest) (*exec.Cmd, er
 	return cmd, nil
 }
 
+// guestHooksDir holds the job-scoped guest hooks files handed to the backup client
+const guestHooksDir = "/var/lib/sendense/hooks"
+
+// writeGuestHooksFile stores guest hooks for the backup client in a private job-scoped file
+func writeGuestHooksFile(jobID string, hooks []GuestHook) (string, error) {
+	if err := os.MkdirAll(guestHooksDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	data, err := json.Marshal(hooks)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode guest hooks: %w", err)
+	}
+
+	hooksPath := guestHooksFilePath(jobID)
+	if err := os.WriteFile(hooksPath, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write guest hooks file: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"job_id":     jobID,
+		"hook_count": len(hooks),
+	}).Info("🪝 Guest snapshot hooks prepared for backup client")
+
+	return hooksPath, nil
+}
+
+// guestHooksFilePath returns the guest hooks file of a job
+func guestHooksFilePath(jobID string) string {
+	return filepath.Join(guestHooksDir, fmt.Sprintf("%s.json", jobID))
+}
+
+// removeGuestHooksFile deletes the guest hooks file (and the guest credentials in it)
+// of a job whose backup client never started and so never loaded and removed it
+func removeGuestHooksFile(jobID string) {
+	if err := os.Remove(guestHooksFilePath(jobID)); err != nil && !os.IsNotExist(err) {
+		log.WithError(err).WithField("job_id", jobID).Warn("Failed to remove guest hooks file")
+	}
+}
+
 // Start starts the SNA control API server
 func (s *SNAControlServer) Start() error {
 	addr := fmt.Sprintf(":%d", s.port)
@@ -856,8 +977,21 @@ func (s *SNAControlServer) handleVMSpecChanges(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	password := req.Password
+	if req.VMwareCredential != nil {
+		resolved, err := s.resolveVMwareCredential(r.Context(), req.VMwareCredential)
+		if err != nil {
+			log.WithError(err).Error("Failed to resolve vCenter credentials for specification check")
+			response.Status = "error"
+			response.ErrorMessage = fmt.Sprintf("Credential resolution failed: %v", err)
+			s.sendVMSpecChangesResponse(w, response)
+			return
+		}
+		password = resolved
+	}
+
 	// Create discovery service with provided credentials
-	discovery, err := s.discoveryProvider.CreateDiscovery(req.VCenter, req.Username, req.Password, req.Datacenter)
+	discovery, err := s.discoveryProvider.CreateDiscovery(req.VCenter, req.Username, password, req.Datacenter)
 	if err != nil {
 		log.WithError(err).Error("Failed to create discovery service")
 		response.Status = "error"
@@ -880,7 +1014,7 @@ func (s *SNAControlServer) handleVMSpecChanges(w http.ResponseWriter, r *http.Re
 	storedVMInfo := s.convertAPIVMInfoToServices(&req.StoredVMInfo)
 
 	// Detect changes using the real service
-	diff, err := s.specChecker.DetectVMSpecificationChanges(context.Background(), req.VMPath, storedVMInfo)
+	diff, err := s.specChecker.DetectVMSpecificationChanges(context.Background(), discovery, req.VMPath, storedVMInfo)
 	if err != nil {
 		log.WithError(err).Error("Failed to detect VM specification changes")
 		response.Status = "error"
@@ -973,6 +1107,61 @@ func (s *SNAControlServer) sendVMSpecChangesResponse(w http.ResponseWriter, resp
 	}
 }
 
+// handleVMConfig collects the full VM configuration (VMX-level) for storage alongside a backup
+func (s *SNAControlServer) handleVMConfig(w http.ResponseWriter, r *http.Request) {
+	var req VMConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if req.VCenter == "" || req.VMPath == "" || req.VMwareCredential == nil {
+		http.Error(w, "vcenter, vm_path and vmware_credential are required", http.StatusBadRequest)
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"vm_path": req.VMPath,
+		"vcenter": req.VCenter,
+	}).Info("Received VM configuration capture request from SHA")
+
+	if s.discoveryProvider == nil {
+		http.Error(w, "VMware discovery service not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	password, err := s.resolveVMwareCredential(r.Context(), req.VMwareCredential)
+	if err != nil {
+		log.WithError(err).Error("Failed to resolve vCenter credentials for VM configuration capture")
+		http.Error(w, fmt.Sprintf("Credential resolution failed: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	discovery, err := s.discoveryProvider.CreateDiscovery(req.VCenter, req.Username, password, req.Datacenter)
+	if err != nil {
+		log.WithError(err).Error("Failed to create discovery service")
+		http.Error(w, fmt.Sprintf("Failed to create discovery service: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if err := discovery.Connect(r.Context()); err != nil {
+		log.WithError(err).Error("Failed to connect to vCenter for VM configuration capture")
+		http.Error(w, fmt.Sprintf("vCenter connection failed: %v", err), http.StatusBadGateway)
+		return
+	}
+	defer discovery.Disconnect()
+
+	vmConfig, err := discovery.GetVMConfiguration(r.Context(), req.VMPath)
+	if err != nil {
+		log.WithError(err).WithField("vm_path", req.VMPath).Error("Failed to collect VM configuration")
+		http.Error(w, fmt.Sprintf("VM configuration capture failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(vmConfig)
+}
+
 // SNA Enrollment Handler Methods
 
 // handleEnrollWithOMA handles SNA enrollment with SHA
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// vmwareSecretFD is the descriptor the backup client reads its vCenter secret from
+// (the first entry of cmd.ExtraFiles)
+const vmwareSecretFD = 3
+
+// VMwareCredentialRef lets the backup client resolve a SHA credential set itself with a
+// single-use token, so the SHA never sends the password to the SNA
+type VMwareCredentialRef struct {
+	CredentialID int    `json:"credential_id"`
+	Token        string `json:"token"`
+}
+
+// AttachVMwareCredentials hands the vCenter secret to the backup client through an
+// inherited pipe rather than its command line, where it would show up in ps. A
+// credential reference is preferred; a plaintext password is still accepted from
+// older SHAs. Call ReleaseCommandFiles once the command has started.
+func AttachVMwareCredentials(cmd *exec.Cmd, ref *VMwareCredentialRef, password string) error {
+	secret := password
+	args := []string{"--vmware-password-fd", strconv.Itoa(vmwareSecretFD)}
+	if ref != nil && ref.CredentialID > 0 {
+		secret = ref.Token
+		args = []string{
+			"--vmware-credential-ref", strconv.Itoa(ref.CredentialID),
+			"--vmware-credential-token-fd", strconv.Itoa(vmwareSecretFD),
+		}
+	}
+	if secret == "" {
+		return fmt.Errorf("no vCenter credentials in request")
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create credential pipe: %w", err)
+	}
+	// The secret is far smaller than the pipe buffer, so this never blocks
+	_, err = writer.WriteString(secret)
+	writer.Close()
+	if err != nil {
+		reader.Close()
+		return fmt.Errorf("failed to write credential pipe: %w", err)
+	}
+
+	cmd.Args = append(cmd.Args, args...)
+	cmd.ExtraFiles = append([]*os.File{reader}, cmd.ExtraFiles...)
+	return nil
+}
+
+// resolveVMwareCredential redeems a credential reference with its one-time token at the
+// SHA, for vCenter calls the SNA makes itself
+func (s *SNAControlServer) resolveVMwareCredential(ctx context.Context, ref *VMwareCredentialRef) (string, error) {
+	if ref == nil || ref.CredentialID == 0 || ref.Token == "" {
+		return "", fmt.Errorf("no vCenter credentials in request")
+	}
+	shaURL := s.shaURL
+	if shaURL == "" {
+		shaURL = "http://localhost:8082" // Default tunnel endpoint
+	}
+
+	body, err := json.Marshal(map[string]string{"token": ref.Token})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode credential request: %w", err)
+	}
+	url := fmt.Sprintf("%s/api/v1/vmware-credentials/%d/resolve", shaURL, ref.CredentialID)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create credential request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve credential %d from SHA: %w", ref.CredentialID, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return "", fmt.Errorf("SHA rejected the token for credential %d (expired or already used)", ref.CredentialID)
+	default:
+		return "", fmt.Errorf("SHA credential resolve failed with status %d", resp.StatusCode)
+	}
+
+	var resolved struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&resolved); err != nil {
+		return "", fmt.Errorf("invalid SHA credential response: %w", err)
+	}
+	if resolved.Password == "" {
+		return "", fmt.Errorf("SHA returned no password for credential %d", ref.CredentialID)
+	}
+	return resolved.Password, nil
+}
+
+// ReleaseCommandFiles closes the SNA's copies of descriptors passed to a child process
+func ReleaseCommandFiles(cmd *exec.Cmd) {
+	for _, file := range cmd.ExtraFiles {
+		file.Close()
+	}
+}
@@ -0,0 +1,74 @@
+package progress
+
+import "time"
+
+// ClientEventVersion is the newest backup client event schema this SNA understands.
+// Newer versions only add fields, so their events are still applied.
+const ClientEventVersion = 1
+
+// Backup client event types
+const (
+	ClientEventStatus   = "status"
+	ClientEventProgress = "progress"
+	ClientEventDisk     = "disk"
+	ClientEventSnapshot = "snapshot"
+	ClientEventChangeID = "change_id"
+	ClientEventError    = "error"
+)
+
+// ClientEvent is one line of the event stream a backup client writes to the descriptor
+// passed with --event-fd (sendense-backup-client internal/events)
+type ClientEvent struct {
+	Version int       `json:"v"`
+	Seq     uint64    `json:"seq"`
+	Time    time.Time `json:"time"`
+	JobID   string    `json:"job_id"`
+	Type    string    `json:"type"`
+
+	// status
+	Status   string `json:"status,omitempty"` // "running", "completed", "failed", "cancelled"
+	Phase    string `json:"phase,omitempty"`
+	SyncType string `json:"sync_type,omitempty"`
+
+	// progress and disk
+	DiskKey          int32  `json:"disk_key,omitempty"`
+	DiskStatus       string `json:"disk_status,omitempty"`
+	BytesTransferred int64  `json:"bytes_transferred,omitempty"`
+	TotalBytes       int64  `json:"total_bytes,omitempty"`
+	SpeedBps         int64  `json:"speed_bps,omitempty"`
+	ETASeconds       int    `json:"eta_seconds,omitempty"`
+
+	// snapshot
+	Action       string `json:"action,omitempty"` // "created", "removed"
+	VMRef        string `json:"vm_ref,omitempty"`
+	SnapshotRef  string `json:"snapshot_ref,omitempty"`
+	SnapshotName string `json:"snapshot_name,omitempty"`
+
+	// change_id
+	ChangeID string `json:"change_id,omitempty"`
+
+	// error and failed status
+	Error *ClientError `json:"error,omitempty"`
+}
+
+// ClientError is an error reported by the backup client with a stable code
+type ClientError struct {
+	Code        string    `json:"code"`
+	Message     string    `json:"message"`
+	Recoverable bool      `json:"recoverable"`
+	Time        time.Time `json:"time,omitempty"`
+}
+
+// ClientEventHello opens an event stream the backup client reconnects on after an SNA
+// restart; it is the first line the client writes to the event socket
+type ClientEventHello struct {
+	Version int    `json:"v"`
+	JobID   string `json:"job_id"`
+}
+
+// ClientEventResume answers a hello with the last event the SNA applied for the job;
+// the client replays the events after it
+type ClientEventResume struct {
+	LastSeq uint64 `json:"last_seq"`
+	Error   string `json:"error,omitempty"`
+}
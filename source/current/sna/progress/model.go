@@ -192,4 +192,8 @@ type ReplicationProgress struct {
 	CBT       CBTInfo           `json:"cbt"`
 	NBD       NBDInfo           `json:"nbd"`
 	Disks     []DiskProgress    `json:"disks"`
+
+	// Reported by backup clients with an event stream
+	SnapshotRef string        `json:"snapshot_ref,omitempty"`
+	Errors      []ClientError `json:"errors,omitempty"`
 }
@@ -5,8 +5,11 @@ set -euo pipefail
 # Simple wrapper for systemd service
 
 OMA_IP="${1:-10.245.246.125}"
+# SHA-side port of this SNA's API reverse tunnel (assigned at enrollment, see
+# tunnel_port in the enrollment config); 9081 for a single-SNA deployment
+TUNNEL_PORT="${2:-9081}"
 
-echo "Starting VMA SSH tunnel to $OMA_IP..."
+echo "Starting VMA SSH tunnel to $OMA_IP (API reverse tunnel on port $TUNNEL_PORT)..."
 
 exec /usr/bin/ssh -i /opt/vma/enrollment/vma_enrollment_key -p 443 -N \
     -o StrictHostKeyChecking=no \
@@ -15,5 +18,5 @@ exec /usr/bin/ssh -i /opt/vma/enrollment/vma_enrollment_key -p 443 -N \
     -o ServerAliveCountMax=3 \
     -o ExitOnForwardFailure=yes \
     -L 127.0.0.1:10808:127.0.0.1:10809 \
-    -R 127.0.0.1:9081:127.0.0.1:8081 \
+    -R 127.0.0.1:${TUNNEL_PORT}:127.0.0.1:8081 \
     vma_tunnel@$OMA_IP
@@ -74,6 +74,7 @@ type EnrollmentResult struct {
 	SSHUser     string `json:"ssh_user,omitempty"`
 	SSHOptions  string `json:"ssh_options,omitempty"`
 	HostKeyHash string `json:"host_key_hash,omitempty"`
+	TunnelPort  int    `json:"tunnel_port,omitempty"` // SHA-side port for this SNA's API reverse tunnel
 	Message     string `json:"message,omitempty"`
 }
 
@@ -89,6 +90,7 @@ type EnrollmentConfig struct {
 	SSHUser        string    `json:"ssh_user"`
 	SSHOptions     string    `json:"ssh_options"`
 	HostKeyHash    string    `json:"host_key_hash"`
+	TunnelPort     int       `json:"tunnel_port,omitempty"` // 0 = original 9081 tunnel
 	EnrolledAt     time.Time `json:"enrolled_at"`
 }
 
@@ -396,6 +398,7 @@ func (vec *SNAEnrollmentClient) saveEnrollmentConfig(
 		SSHUser:        result.SSHUser,
 		SSHOptions:     result.SSHOptions,
 		HostKeyHash:    result.HostKeyHash,
+		TunnelPort:     result.TunnelPort,
 		EnrolledAt:     time.Now(),
 	}
 
@@ -415,6 +418,7 @@ func (vec *SNAEnrollmentClient) saveEnrollmentConfig(
 		"config_path":      configPath,
 		"private_key_path": privateKeyPath,
 		"public_key_path":  publicKeyPath,
+		"tunnel_port":      result.TunnelPort,
 	}).Info("💾 Saved SNA enrollment configuration")
 
 	return config, nil
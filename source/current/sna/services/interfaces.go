@@ -5,12 +5,15 @@ package services
 import (
 	"context"
 	"time"
+
+	"github.com/vexxhost/migratekit-sha/models"
 )
 
 // VMSpecificationChecker defines the interface for VM specification change detection
 type VMSpecificationChecker interface {
 	// DetectVMSpecificationChanges compares current vCenter specs with stored VM data
-	DetectVMSpecificationChanges(ctx context.Context, vmPath string, storedVMInfo *StoredVMInfo) (*VMSpecificationDiff, error)
+	// using the vCenter connection of discovery
+	DetectVMSpecificationChanges(ctx context.Context, discovery VMwareDiscovery, vmPath string, storedVMInfo *StoredVMInfo) (*VMSpecificationDiff, error)
 
 	// GetChangesSummary returns a human-readable summary of changes
 	GetChangesSummary(diff *VMSpecificationDiff) string
@@ -35,6 +38,9 @@ type VMwareDiscovery interface {
 
 	// GetVMDetails gets detailed information for a specific VM by path
 	GetVMDetails(ctx context.Context, vmPath string) (*StoredVMInfo, error)
+
+	// GetVMConfiguration collects the full VM configuration for backup
+	GetVMConfiguration(ctx context.Context, vmPath string) (*models.VMConfiguration, error)
 }
 
 // StoredVMInfo represents VM information that can be stored and compared
@@ -97,6 +103,7 @@ type VMSpecificationDiff struct {
 	DisplayNameChange  *FieldChange           `json:"display_name_change,omitempty"`
 	AnnotationChange   *FieldChange           `json:"annotation_change,omitempty"`
 	FolderPathChange   *FieldChange           `json:"folder_path_change,omitempty"`
+	DiskChanges        []DiskChange           `json:"disk_changes,omitempty"`
 	LastChecked        time.Time              `json:"last_checked"`
 }
 
@@ -116,6 +123,12 @@ type NetworkAdapterChange struct {
 	NewValue     interface{} `json:"new_value,omitempty"`
 }
 
-
-
-
+// DiskChange represents a virtual disk added, removed or resized since the stored spec
+type DiskChange struct {
+	DiskID           string          `json:"disk_id"`
+	Label            string          `json:"label"`
+	ChangeType       string          `json:"change_type"` // "added", "removed", "resized"
+	OldCapacityBytes int64           `json:"old_capacity_bytes,omitempty"`
+	NewCapacityBytes int64           `json:"new_capacity_bytes,omitempty"`
+	Disk             *StoredDiskInfo `json:"disk"` // Current disk (stored disk when removed)
+}
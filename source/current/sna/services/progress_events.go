@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/vexxhost/migratekit/source/current/sna/progress"
+)
+
+// maxJobErrors bounds the errors kept per job
+const maxJobErrors = 20
+
+// ApplyClientEvent updates the progress of a job from one backup client event,
+// starting to track the job if needed
+func (s *ProgressService) ApplyClientEvent(ctx context.Context, jobID string, event *progress.ClientEvent) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	jobProg, exists := s.jobProgress[jobID]
+	if !exists {
+		jobProg = newJobProgress(jobID)
+		s.jobProgress[jobID] = jobProg
+	}
+
+	switch event.Type {
+	case progress.ClientEventStatus:
+		applyClientStatus(jobProg, event)
+
+	case progress.ClientEventDisk:
+		diskID := strconv.Itoa(int(event.DiskKey))
+		s.updateDiskProgress(jobProg, &ProgressUpdateRequest{
+			DiskID:           diskID,
+			BytesTransferred: event.BytesTransferred,
+			TotalBytes:       event.TotalBytes,
+			ThroughputBPS:    event.SpeedBps,
+		})
+		status := progress.DiskStatusStreaming
+		if event.DiskStatus == "completed" {
+			status = progress.DiskStatusCompleted
+		}
+		for i := range jobProg.Disks {
+			if jobProg.Disks[i].ID == diskID {
+				jobProg.Disks[i].Status = status
+			}
+		}
+		s.recalculateAggregateProgress(jobProg)
+
+	case progress.ClientEventProgress:
+		// Disk events already cover the disks being copied; this only matters for
+		// clients that report aggregate progress alone
+		if len(jobProg.Disks) == 0 {
+			s.updateAggregateProgress(jobProg, &ProgressUpdateRequest{
+				BytesTransferred: event.BytesTransferred,
+				TotalBytes:       event.TotalBytes,
+				ThroughputBPS:    event.SpeedBps,
+			})
+		}
+
+	case progress.ClientEventSnapshot:
+		switch event.Action {
+		case "created":
+			jobProg.SnapshotRef = event.SnapshotRef
+		case "removed":
+			jobProg.SnapshotRef = ""
+		}
+
+	case progress.ClientEventChangeID:
+		jobProg.CBT.ChangeID = event.ChangeID
+
+	case progress.ClientEventError:
+		addClientError(jobProg, event)
+	}
+
+	jobProg.UpdatedAt = time.Now()
+	return nil
+}
+
+// applyClientStatus maps a client status event onto the replication stage and status
+func applyClientStatus(jobProg *progress.ReplicationProgress, event *progress.ClientEvent) {
+	switch event.Status {
+	case "completed":
+		jobProg.Status = progress.StatusSucceeded
+		jobProg.Stage = progress.StagePersistChangeIDs
+		jobProg.Aggregate.Percent = 100.0
+		return
+	case "failed", "cancelled":
+		jobProg.Status = progress.StatusFailed
+		addClientError(jobProg, event)
+		return
+	}
+
+	switch event.Phase {
+	case "snapshot":
+		jobProg.Stage = progress.StageSnapshot
+		jobProg.Status = progress.StatusSnapshot
+	case "transferring":
+		jobProg.Stage = progress.StageTransfer
+		jobProg.Status = progress.StatusStreaming
+	default:
+		jobProg.Status = progress.StatusPreparing
+	}
+
+	switch event.SyncType {
+	case "full":
+		jobProg.CBT.Type = progress.CBTTypeFull
+	case "incremental":
+		jobProg.CBT.Type = progress.CBTTypeIncremental
+	}
+}
+
+// addClientError records the error carried by an event, keeping the latest maxJobErrors
+func addClientError(jobProg *progress.ReplicationProgress, event *progress.ClientEvent) {
+	if event.Error == nil {
+		return
+	}
+	clientErr := *event.Error
+	clientErr.Time = event.Time
+	jobProg.Errors = append(jobProg.Errors, clientErr)
+	if len(jobProg.Errors) > maxJobErrors {
+		jobProg.Errors = jobProg.Errors[len(jobProg.Errors)-maxJobErrors:]
+	}
+}
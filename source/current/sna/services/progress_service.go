@@ -89,7 +89,13 @@ func (s *ProgressService) StartJobTracking(ctx context.Context, jobID string) er
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	jobProg := &progress.ReplicationProgress{
+	s.jobProgress[jobID] = newJobProgress(jobID)
+	return nil
+}
+
+// newJobProgress returns the initial progress of a job
+func newJobProgress(jobID string) *progress.ReplicationProgress {
+	return &progress.ReplicationProgress{
 		JobID:     jobID,
 		Stage:     progress.StageDiscover,
 		Status:    progress.StatusQueued,
@@ -111,9 +117,6 @@ func (s *ProgressService) StartJobTracking(ctx context.Context, jobID string) er
 		},
 		Disks: []progress.DiskProgress{},
 	}
-
-	s.jobProgress[jobID] = jobProg
-	return nil
 }
 
 // UpdateJobStage updates the current stage of a job
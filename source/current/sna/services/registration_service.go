@@ -0,0 +1,150 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// registrationInterval is how often the SNA re-registers (the SHA marks it offline after 3 minutes)
+const registrationInterval = time.Minute
+
+// SNAScope is a vCenter placement this SNA serves (empty datacenter or cluster = all)
+type SNAScope struct {
+	VCenterHost string `json:"vcenter_host"`
+	Datacenter  string `json:"datacenter,omitempty"`
+	Cluster     string `json:"cluster,omitempty"`
+}
+
+// RegistrationConfig describes this SNA to the SHA
+type RegistrationConfig struct {
+	SHAURL            string     // SHA API base URL
+	EnrollmentID      string     // Enrollment this SNA was approved under (empty = pre-enrollment SNA)
+	TunnelPort        int        // SHA-side reverse tunnel port assigned at enrollment
+	Name              string     // Display name of this SNA
+	Version           string     // SNA version
+	MaxConcurrentJobs int        // Jobs this SNA runs at once (0 = SHA default)
+	Scopes            []SNAScope // Placements this SNA serves (empty = keep the SHA's scopes)
+}
+
+// registrationRequest is the body of POST /api/v1/snas/register
+type registrationRequest struct {
+	EnrollmentID      string     `json:"enrollment_id,omitempty"`
+	TunnelPort        int        `json:"tunnel_port,omitempty"`
+	Name              string     `json:"name"`
+	Version           string     `json:"version,omitempty"`
+	MaxConcurrentJobs int        `json:"max_concurrent_jobs,omitempty"`
+	Scopes            []SNAScope `json:"scopes,omitempty"`
+}
+
+// RegistrationService keeps this SNA registered with the SHA
+type RegistrationService struct {
+	config     RegistrationConfig
+	httpClient *http.Client
+}
+
+// NewRegistrationService creates a new registration service
+func NewRegistrationService(config RegistrationConfig) *RegistrationService {
+	return &RegistrationService{
+		config: config,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// ParseScopes parses "vcenter[/datacenter[/cluster]]" entries separated by commas
+func ParseScopes(value string) ([]SNAScope, error) {
+	var scopes []SNAScope
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, "/")
+		if len(parts) > 3 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid scope %q: expected vcenter[/datacenter[/cluster]]", entry)
+		}
+		scope := SNAScope{VCenterHost: parts[0]}
+		if len(parts) > 1 {
+			scope.Datacenter = parts[1]
+		}
+		if len(parts) > 2 {
+			scope.Cluster = parts[2]
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+// Run registers now and then on every interval until the context is cancelled
+func (s *RegistrationService) Run(ctx context.Context) {
+	log.WithFields(log.Fields{
+		"sha_url":     s.config.SHAURL,
+		"name":        s.config.Name,
+		"tunnel_port": s.config.TunnelPort,
+		"scopes":      len(s.config.Scopes),
+	}).Info("📡 Starting SNA registration with SHA")
+
+	registered := false
+	ticker := time.NewTicker(registrationInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.register(ctx); err != nil {
+			if registered {
+				log.WithError(err).Warn("Failed to re-register SNA with SHA")
+			} else {
+				log.WithError(err).Debug("SNA registration with SHA not yet accepted")
+			}
+			registered = false
+		} else if !registered {
+			log.WithField("name", s.config.Name).Info("✅ SNA registered with SHA")
+			registered = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// register sends one registration to the SHA
+func (s *RegistrationService) register(ctx context.Context) error {
+	body, err := json.Marshal(registrationRequest{
+		EnrollmentID:      s.config.EnrollmentID,
+		TunnelPort:        s.config.TunnelPort,
+		Name:              s.config.Name,
+		Version:           s.config.Version,
+		MaxConcurrentJobs: s.config.MaxConcurrentJobs,
+		Scopes:            s.config.Scopes,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal registration: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.SHAURL+"/api/v1/snas/register", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create registration request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach SHA: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("SHA rejected registration with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -113,6 +113,8 @@ func (c *RealVMwareClient) DiscoverVMsWithFilter(vcenter, username, password, da
 			Name:       vm.Name,
 			Path:       vm.Path,
 			Datacenter: vm.Datacenter,
+			ESXiHost:   vm.ESXiHost,
+			Cluster:    vm.Cluster,
 			PowerState: vm.PowerState,
 			GuestOS:    vm.OSType,
 			MemoryMB:   vm.MemoryMB,
@@ -120,6 +122,10 @@ func (c *RealVMwareClient) DiscoverVMsWithFilter(vcenter, username, password, da
 			VMXVersion: vm.VMXVersion,
 			Disks:      apiDisks,
 			Networks:   apiNetworks,
+
+			FolderPath:   vm.FolderPath,
+			ResourcePool: vm.ResourcePool,
+			Tags:         vm.Tags,
 		}
 	}
 
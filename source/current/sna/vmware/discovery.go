@@ -112,6 +112,8 @@ func (d *Discovery) DiscoverVMs(ctx context.Context) (*models.VMInventoryRequest
 		"name",
 		"config",
 		"runtime.powerState",
+		"runtime.host",
+		"resourcePool",
 		"guest.guestFullName",
 		"guest.toolsStatus",
 		"guest.toolsVersion",
@@ -132,7 +134,15 @@ func (d *Discovery) DiscoverVMs(ctx context.Context) (*models.VMInventoryRequest
 		vmByRef[vm.Reference()] = vm
 	}
 
+	// Tags feed rule-based machine groups on the SHA; discovery carries on without them
+	vmTags, err := d.resolveVMTags(ctx, refs)
+	if err != nil {
+		log.WithError(err).Warn("Failed to resolve vSphere tags - VMs are reported without tags")
+	}
+
 	// Match VM properties with VM objects using references (not array indices)
+	hostPlacements := make(map[types.ManagedObjectReference]hostPlacement)
+	resourcePools := make(map[types.ManagedObjectReference]string)
 	for _, vmMo := range vmMos {
 		vm, exists := vmByRef[vmMo.Reference()]
 		if !exists {
@@ -141,6 +151,30 @@ func (d *Discovery) DiscoverVMs(ctx context.Context) (*models.VMInventoryRequest
 		}
 
 		vmInfo := d.convertVMToModel(vm, &vmMo)
+		if hostRef := vmMo.Runtime.Host; hostRef != nil {
+			placement, resolved := hostPlacements[*hostRef]
+			if !resolved {
+				placement = d.resolveHostPlacement(ctx, *hostRef)
+				hostPlacements[*hostRef] = placement
+			}
+			vmInfo.ESXiHost = placement.name
+			vmInfo.Cluster = placement.cluster
+		}
+		if poolRef := vmMo.ResourcePool; poolRef != nil {
+			pool, resolved := resourcePools[*poolRef]
+			if !resolved {
+				pool = d.resolveResourcePoolName(ctx, *poolRef)
+				resourcePools[*poolRef] = pool
+			}
+			vmInfo.ResourcePool = pool
+		}
+		if vmTags != nil {
+			// An empty list tells the SHA the VM has no tags, not that they are unknown
+			vmInfo.Tags = vmTags[vmMo.Reference()]
+			if vmInfo.Tags == nil {
+				vmInfo.Tags = []string{}
+			}
+		}
 		vmInfos = append(vmInfos, vmInfo)
 	}
 
@@ -456,6 +490,54 @@ func (d *Discovery) resolveNetworkName(backing types.BaseVirtualDeviceBackingInf
 	}
 }
 
+// hostPlacement is the ESXi host a VM runs on and the cluster of that host
+type hostPlacement struct {
+	name    string
+	cluster string
+}
+
+// resolveHostPlacement returns the ESXi host a VM runs on and its cluster (empty if they can't
+// be read; the cluster is also empty for standalone hosts)
+func (d *Discovery) resolveHostPlacement(ctx context.Context, hostRef types.ManagedObjectReference) hostPlacement {
+	var hostMo mo.HostSystem
+	pc := property.DefaultCollector(d.client.Client)
+	if err := pc.RetrieveOne(ctx, hostRef, []string{"name", "parent"}, &hostMo); err != nil {
+		log.WithFields(log.Fields{
+			"host_ref": hostRef.Value,
+			"error":    err.Error(),
+		}).Debug("Failed to resolve ESXi host reference")
+		return hostPlacement{}
+	}
+
+	placement := hostPlacement{name: hostMo.Name}
+	if hostMo.Parent != nil && hostMo.Parent.Type == "ClusterComputeResource" {
+		var clusterMo mo.ClusterComputeResource
+		if err := pc.RetrieveOne(ctx, *hostMo.Parent, []string{"name"}, &clusterMo); err != nil {
+			log.WithFields(log.Fields{
+				"cluster_ref": hostMo.Parent.Value,
+				"error":       err.Error(),
+			}).Debug("Failed to resolve cluster of ESXi host")
+		} else {
+			placement.cluster = clusterMo.Name
+		}
+	}
+	return placement
+}
+
+// resolveResourcePoolName returns the name of a VM's resource pool (empty if it can't be read)
+func (d *Discovery) resolveResourcePoolName(ctx context.Context, poolRef types.ManagedObjectReference) string {
+	var poolMo mo.ResourcePool
+	pc := property.DefaultCollector(d.client.Client)
+	if err := pc.RetrieveOne(ctx, poolRef, []string{"name"}, &poolMo); err != nil {
+		log.WithFields(log.Fields{
+			"resource_pool_ref": poolRef.Value,
+			"error":             err.Error(),
+		}).Debug("Failed to resolve resource pool reference")
+		return ""
+	}
+	return poolMo.Name
+}
+
 // resolveNetworkReference resolves a standard network reference to its human-readable name
 func (d *Discovery) resolveNetworkReference(networkRef *types.ManagedObjectReference) string {
 	if networkRef == nil || d.client == nil {
@@ -228,12 +228,15 @@ func (s *Service) startMultiDiskReplication(jobID, vmPath string, nbdTargets []a
 		"migrate",
 		"--vmware-endpoint", vcenter,
 		"--vmware-username", username,
-		"--vmware-password", password,
 		"--vmware-path", vmPath,
 		"--job-id", jobID,
 		"--nbd-targets", ndbTargetsParam, // Pass all NBD targets for multi-disk support
 		"--debug",
 	)
+	// The password goes through an inherited pipe - arguments show up in ps and the log below
+	if err := api.AttachVMwareCredentials(cmd, nil, password); err != nil {
+		return err
+	}
 
 	// Standard environment variables
 	cmd.Env = append(os.Environ(),
@@ -246,6 +249,7 @@ func (s *Service) startMultiDiskReplication(jobID, vmPath string, nbdTargets []a
 	// Redirect output to log file
 	logFileHandle, err := os.Create(logFile)
 	if err != nil {
+		api.ReleaseCommandFiles(cmd)
 		return fmt.Errorf("failed to create log file %s: %w", logFile, err)
 	}
 	defer logFileHandle.Close()
@@ -261,8 +265,10 @@ func (s *Service) startMultiDiskReplication(jobID, vmPath string, nbdTargets []a
 		"log_file":          logFile,
 	}).Info("🚀 Executing multi-disk migratekit command with all targets")
 
-	// Start migratekit process
-	if err := cmd.Start(); err != nil {
+	// Start migratekit process (the child holds its own copy of the credential pipe)
+	err = cmd.Start()
+	api.ReleaseCommandFiles(cmd)
+	if err != nil {
 		return fmt.Errorf("failed to start multi-disk migratekit: %w", err)
 	}
 
@@ -328,12 +334,15 @@ func (s *Service) startDirectReplication(jobID, vmPath, targetDevice, vcenter, u
 	cmd := exec.Command("/opt/vma/bin/migratekit", "migrate",
 		"--vmware-endpoint", vcenter,
 		"--vmware-username", username,
-		"--vmware-password", password,
 		"--vmware-path", vmPath,
 		"--nbd-export-name", exportName, // Pass job-specific export name
 		"--job-id", jobID, // 🎯 CRITICAL FIX: Pass job ID for progress tracking
 		"--debug",
 	)
+	// The password goes through an inherited pipe - arguments show up in ps and the log below
+	if err := api.AttachVMwareCredentials(cmd, nil, password); err != nil {
+		return err
+	}
 
 	cmd.Dir = "/opt/vma"
 
@@ -364,8 +373,10 @@ func (s *Service) startDirectReplication(jobID, vmPath, targetDevice, vcenter, u
 		"env_vars":      []string{"CLOUDSTACK_API_URL=http://localhost:8082", "CLOUDSTACK_API_KEY=test-api-key", "CLOUDSTACK_SECRET_KEY=test-secret-key"},
 	}).Info("Executing migratekit command with output capture")
 
-	// Start migratekit in background
-	if err := cmd.Start(); err != nil {
+	// Start migratekit in background (the child holds its own copy of the credential pipe)
+	err = cmd.Start()
+	api.ReleaseCommandFiles(cmd)
+	if err != nil {
 		if logFileHandle != nil {
 			logFileHandle.Close()
 		}
@@ -3,6 +3,7 @@ package vmware
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/vexxhost/migratekit-sha/models"
 	"github.com/vexxhost/migratekit/source/current/sna/services"
@@ -10,25 +11,28 @@ import (
 
 // SpecificationServiceAdapter adapts VMSpecificationService to the services interface
 type SpecificationServiceAdapter struct {
-	discovery *Discovery
-	service   *VMSpecificationService
+	service *VMSpecificationService // Summaries and serialization need no vCenter connection
 }
 
 // NewSpecificationServiceAdapter creates a new specification service adapter
-func NewSpecificationServiceAdapter(discovery *Discovery) *SpecificationServiceAdapter {
+func NewSpecificationServiceAdapter() *SpecificationServiceAdapter {
 	return &SpecificationServiceAdapter{
-		discovery: discovery,
-		service:   NewVMSpecificationService(discovery),
+		service: &VMSpecificationService{},
 	}
 }
 
 // DetectVMSpecificationChanges implements services.VMSpecificationChecker
-func (a *SpecificationServiceAdapter) DetectVMSpecificationChanges(ctx context.Context, vmPath string, storedVMInfo *services.StoredVMInfo) (*services.VMSpecificationDiff, error) {
+func (a *SpecificationServiceAdapter) DetectVMSpecificationChanges(ctx context.Context, discovery services.VMwareDiscovery, vmPath string, storedVMInfo *services.StoredVMInfo) (*services.VMSpecificationDiff, error) {
+	discoveryAdapter, ok := discovery.(*DiscoveryAdapter)
+	if !ok {
+		return nil, fmt.Errorf("unsupported discovery type %T", discovery)
+	}
+
 	// Convert services.StoredVMInfo to models.VMInfo
 	modelVMInfo := a.convertStoredVMInfoToModel(storedVMInfo)
 
-	// Use the existing service to detect changes
-	diff, err := a.service.DetectVMSpecificationChanges(ctx, vmPath, modelVMInfo)
+	// Detect changes over the caller's vCenter connection
+	diff, err := NewVMSpecificationService(discoveryAdapter.discovery).DetectVMSpecificationChanges(ctx, vmPath, modelVMInfo)
 	if err != nil {
 		return nil, err
 	}
@@ -180,6 +184,22 @@ func (a *SpecificationServiceAdapter) convertDiffToServices(internal *VMSpecific
 		})
 	}
 
+	// Convert disk changes
+	for _, diskChange := range internal.DiskChanges {
+		change := services.DiskChange{
+			DiskID:           diskChange.DiskID,
+			Label:            diskChange.Label,
+			ChangeType:       diskChange.ChangeType,
+			OldCapacityBytes: diskChange.OldCapacityBytes,
+			NewCapacityBytes: diskChange.NewCapacityBytes,
+		}
+		if diskChange.Disk != nil {
+			disk := convertDiskToStored(*diskChange.Disk)
+			change.Disk = &disk
+		}
+		serviceDiff.DiskChanges = append(serviceDiff.DiskChanges, change)
+	}
+
 	return serviceDiff
 }
 
@@ -209,11 +229,82 @@ func (a *SpecificationServiceAdapter) convertServicesaDiffToInternal(serviceDiff
 		}
 	}
 
-	// Add other conversions as needed...
+	internal.PowerStateChange = convertFieldChangeToInternal(serviceDiff.PowerStateChange)
+	internal.VMwareToolsChanges = convertFieldChangeToInternal(serviceDiff.VMwareToolsChanges)
+	internal.DisplayNameChange = convertFieldChangeToInternal(serviceDiff.DisplayNameChange)
+	internal.AnnotationChange = convertFieldChangeToInternal(serviceDiff.AnnotationChange)
+	internal.FolderPathChange = convertFieldChangeToInternal(serviceDiff.FolderPathChange)
+
+	for _, netChange := range serviceDiff.NetworkChanges {
+		internal.NetworkChanges = append(internal.NetworkChanges, NetworkAdapterChange{
+			AdapterIndex: netChange.AdapterIndex,
+			ChangeType:   netChange.ChangeType,
+			Field:        netChange.Field,
+			OldValue:     netChange.OldValue,
+			NewValue:     netChange.NewValue,
+		})
+	}
+
+	for _, diskChange := range serviceDiff.DiskChanges {
+		change := DiskChange{
+			DiskID:           diskChange.DiskID,
+			Label:            diskChange.Label,
+			ChangeType:       diskChange.ChangeType,
+			OldCapacityBytes: diskChange.OldCapacityBytes,
+			NewCapacityBytes: diskChange.NewCapacityBytes,
+		}
+		if diskChange.Disk != nil {
+			disk := convertStoredDiskToModel(*diskChange.Disk)
+			change.Disk = &disk
+		}
+		internal.DiskChanges = append(internal.DiskChanges, change)
+	}
 
 	return internal
 }
 
+// convertFieldChangeToInternal converts a services.FieldChange back to internal format
+func convertFieldChangeToInternal(change *services.FieldChange) *FieldChange {
+	if change == nil {
+		return nil
+	}
+	return &FieldChange{
+		Field:    change.Field,
+		OldValue: change.OldValue,
+		NewValue: change.NewValue,
+	}
+}
+
+// convertDiskToStored converts models.DiskInfo to services.StoredDiskInfo
+func convertDiskToStored(disk models.DiskInfo) services.StoredDiskInfo {
+	return services.StoredDiskInfo{
+		ID:               disk.ID,
+		Path:             disk.Path,
+		SizeGB:           disk.SizeGB,
+		Datastore:        disk.Datastore,
+		VMDKPath:         disk.VMDKPath,
+		ProvisioningType: disk.ProvisioningType,
+		Label:            disk.Label,
+		CapacityBytes:    disk.CapacityBytes,
+		UnitNumber:       disk.UnitNumber,
+	}
+}
+
+// convertStoredDiskToModel converts services.StoredDiskInfo to models.DiskInfo
+func convertStoredDiskToModel(disk services.StoredDiskInfo) models.DiskInfo {
+	return models.DiskInfo{
+		ID:               disk.ID,
+		Path:             disk.Path,
+		SizeGB:           disk.SizeGB,
+		Datastore:        disk.Datastore,
+		VMDKPath:         disk.VMDKPath,
+		ProvisioningType: disk.ProvisioningType,
+		Label:            disk.Label,
+		CapacityBytes:    disk.CapacityBytes,
+		UnitNumber:       disk.UnitNumber,
+	}
+}
+
 // DiscoveryProviderAdapter adapts VMware discovery to the services interface
 type DiscoveryProviderAdapter struct{}
 
@@ -262,6 +353,11 @@ func (d *DiscoveryAdapter) GetVMDetails(ctx context.Context, vmPath string) (*se
 	return d.convertModelToStoredVMInfo(vmInfo), nil
 }
 
+// GetVMConfiguration implements services.VMwareDiscovery
+func (d *DiscoveryAdapter) GetVMConfiguration(ctx context.Context, vmPath string) (*models.VMConfiguration, error) {
+	return d.discovery.GetVMConfiguration(ctx, vmPath)
+}
+
 // convertModelToStoredVMInfo converts models.VMInfo to services.StoredVMInfo
 func (d *DiscoveryAdapter) convertModelToStoredVMInfo(model *models.VMInfo) *services.StoredVMInfo {
 	// Convert disks
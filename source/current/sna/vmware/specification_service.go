@@ -40,6 +40,7 @@ type VMSpecificationDiff struct {
 	DisplayNameChange  *FieldChange           `json:"display_name_change,omitempty"`
 	AnnotationChange   *FieldChange           `json:"annotation_change,omitempty"`
 	FolderPathChange   *FieldChange           `json:"folder_path_change,omitempty"`
+	DiskChanges        []DiskChange           `json:"disk_changes,omitempty"`
 	LastChecked        time.Time              `json:"last_checked"`
 }
 
@@ -59,6 +60,23 @@ type NetworkAdapterChange struct {
 	NewValue     interface{} `json:"new_value,omitempty"`
 }
 
+// Disk change types
+const (
+	DiskAdded   = "added"
+	DiskRemoved = "removed"
+	DiskResized = "resized"
+)
+
+// DiskChange represents a virtual disk added, removed or resized since the stored spec
+type DiskChange struct {
+	DiskID           string           `json:"disk_id"`
+	Label            string           `json:"label"`
+	ChangeType       string           `json:"change_type"` // "added", "removed", "resized"
+	OldCapacityBytes int64            `json:"old_capacity_bytes,omitempty"`
+	NewCapacityBytes int64            `json:"new_capacity_bytes,omitempty"`
+	Disk             *models.DiskInfo `json:"disk"` // Current disk (stored disk when removed)
+}
+
 // DetectVMSpecificationChanges compares current vCenter specs with stored VM data
 func (s *VMSpecificationService) DetectVMSpecificationChanges(ctx context.Context, vmPath string, storedVMInfo *models.VMInfo) (*VMSpecificationDiff, error) {
 	log.WithFields(log.Fields{
@@ -84,6 +102,7 @@ func (s *VMSpecificationService) DetectVMSpecificationChanges(ctx context.Contex
 			"has_cpu_change": diff.CPUChanges != nil,
 			"has_mem_change": diff.MemoryChanges != nil,
 			"has_net_change": len(diff.NetworkChanges) > 0,
+			"disk_changes":   len(diff.DiskChanges),
 		}).Warn("VM specification changes detected")
 	} else {
 		log.WithFields(log.Fields{
@@ -180,9 +199,82 @@ func (s *VMSpecificationService) compareVMSpecifications(stored, current *models
 		diff.HasChanges = true
 	}
 
+	// Compare virtual disks
+	diskChanges := s.compareDiskConfigurations(stored.Disks, current.Disks)
+	if len(diskChanges) > 0 {
+		diff.DiskChanges = diskChanges
+		diff.HasChanges = true
+	}
+
 	return diff
 }
 
+// compareDiskConfigurations compares virtual disks by their VMware disk ID ("disk-2000")
+func (s *VMSpecificationService) compareDiskConfigurations(stored, current []models.DiskInfo) []DiskChange {
+	var changes []DiskChange
+
+	currentDisks := make(map[string]models.DiskInfo, len(current))
+	for _, disk := range current {
+		currentDisks[disk.ID] = disk
+	}
+	storedDisks := make(map[string]bool, len(stored))
+
+	for _, storedDisk := range stored {
+		storedDisks[storedDisk.ID] = true
+		currentDisk, exists := currentDisks[storedDisk.ID]
+		if !exists {
+			removed := storedDisk
+			changes = append(changes, DiskChange{
+				DiskID:           storedDisk.ID,
+				Label:            storedDisk.Label,
+				ChangeType:       DiskRemoved,
+				OldCapacityBytes: diskCapacityBytes(storedDisk),
+				Disk:             &removed,
+			})
+			continue
+		}
+
+		oldCapacity := diskCapacityBytes(storedDisk)
+		newCapacity := diskCapacityBytes(currentDisk)
+		if oldCapacity != newCapacity {
+			resized := currentDisk
+			changes = append(changes, DiskChange{
+				DiskID:           currentDisk.ID,
+				Label:            currentDisk.Label,
+				ChangeType:       DiskResized,
+				OldCapacityBytes: oldCapacity,
+				NewCapacityBytes: newCapacity,
+				Disk:             &resized,
+			})
+		}
+	}
+
+	// Keep vCenter's disk order for added disks
+	for _, currentDisk := range current {
+		if !storedDisks[currentDisk.ID] {
+			added := currentDisk
+			changes = append(changes, DiskChange{
+				DiskID:           currentDisk.ID,
+				Label:            currentDisk.Label,
+				ChangeType:       DiskAdded,
+				NewCapacityBytes: diskCapacityBytes(currentDisk),
+				Disk:             &added,
+			})
+		}
+	}
+
+	return changes
+}
+
+// diskCapacityBytes returns a disk's capacity, falling back to its size in GB for
+// records stored before capacity was tracked
+func diskCapacityBytes(disk models.DiskInfo) int64 {
+	if disk.CapacityBytes > 0 {
+		return disk.CapacityBytes
+	}
+	return int64(disk.SizeGB) * 1024 * 1024 * 1024
+}
+
 // compareNetworkConfigurations compares network adapter configurations
 func (s *VMSpecificationService) compareNetworkConfigurations(stored, current []models.NetworkInfo) []NetworkAdapterChange {
 	var changes []NetworkAdapterChange
@@ -287,6 +379,7 @@ func (s *VMSpecificationService) countChanges(diff *VMSpecificationDiff) int {
 	}
 
 	count += len(diff.NetworkChanges)
+	count += len(diff.DiskChanges)
 
 	return count
 }
@@ -325,6 +418,15 @@ func (s *VMSpecificationService) GetChangesSummary(diff *VMSpecificationDiff) st
 		summary += fmt.Sprintf("- Network Changes: %d adapter(s) modified\n", len(diff.NetworkChanges))
 	}
 
+	for _, change := range diff.DiskChanges {
+		switch change.ChangeType {
+		case DiskResized:
+			summary += fmt.Sprintf("- Disk %s (%s): resized %d → %d bytes\n", change.DiskID, change.Label, change.OldCapacityBytes, change.NewCapacityBytes)
+		default:
+			summary += fmt.Sprintf("- Disk %s (%s): %s\n", change.DiskID, change.Label, change.ChangeType)
+		}
+	}
+
 	return summary
 }
 
@@ -0,0 +1,66 @@
+package vmware
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// resolveVMTags returns the vSphere tags attached to each VM as "category:tag", keyed by
+// VM reference. Tags come from the vAPI endpoint, which needs its own session.
+func (d *Discovery) resolveVMTags(ctx context.Context, refs []types.ManagedObjectReference) (map[types.ManagedObjectReference][]string, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	rc := rest.NewClient(d.client.Client)
+	if err := rc.Login(ctx, url.UserPassword(d.config.Username, d.config.Password)); err != nil {
+		return nil, fmt.Errorf("failed to log in to the vSphere tagging service: %w", err)
+	}
+	defer rc.Logout(context.Background())
+
+	manager := tags.NewManager(rc)
+
+	categories, err := manager.GetCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tag categories: %w", err)
+	}
+	categoryNames := make(map[string]string, len(categories))
+	for _, category := range categories {
+		categoryNames[category.ID] = category.Name
+	}
+
+	objects := make([]mo.Reference, len(refs))
+	for i := range refs {
+		objects[i] = refs[i]
+	}
+	attached, err := manager.GetAttachedTagsOnObjects(ctx, objects)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attached tags: %w", err)
+	}
+
+	vmTags := make(map[types.ManagedObjectReference][]string, len(attached))
+	for _, object := range attached {
+		names := make([]string, 0, len(object.Tags))
+		for _, tag := range object.Tags {
+			names = append(names, fmt.Sprintf("%s:%s", categoryNames[tag.CategoryID], tag.Name))
+		}
+		sort.Strings(names)
+		vmTags[object.ObjectID.Reference()] = names
+	}
+
+	log.WithFields(log.Fields{
+		"vm_count":   len(refs),
+		"tagged_vms": len(vmTags),
+		"categories": len(categories),
+	}).Debug("Resolved vSphere tags of discovered VMs")
+
+	return vmTags, nil
+}
@@ -0,0 +1,286 @@
+package vmware
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"github.com/vexxhost/migratekit-sha/models"
+)
+
+// GetVMConfiguration collects the full VM configuration (compute, firmware,
+// devices, custom attributes and tags) for storage alongside backup disk data
+func (d *Discovery) GetVMConfiguration(ctx context.Context, vmPath string) (*models.VMConfiguration, error) {
+	if d.client == nil {
+		return nil, fmt.Errorf("not connected to vCenter")
+	}
+
+	log.WithField("vm_path", vmPath).Info("📋 Collecting VM configuration from vCenter")
+
+	finder := find.NewFinder(d.client.Client, true)
+	dc, err := finder.DefaultDatacenter(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find datacenter: %w", err)
+	}
+	finder.SetDatacenter(dc)
+
+	vm, err := finder.VirtualMachine(ctx, vmPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find VM %s: %w", vmPath, err)
+	}
+
+	var mvm mo.VirtualMachine
+	pc := property.DefaultCollector(d.client.Client)
+	if err := pc.RetrieveOne(ctx, vm.Reference(), []string{"config", "customValue", "availableField"}, &mvm); err != nil {
+		return nil, fmt.Errorf("failed to retrieve VM configuration: %w", err)
+	}
+	if mvm.Config == nil {
+		return nil, fmt.Errorf("VM %s has no configuration (inaccessible or orphaned)", vmPath)
+	}
+
+	cfg := mvm.Config
+	vmConfig := &models.VMConfiguration{
+		UUID:             cfg.Uuid,
+		InstanceUUID:     cfg.InstanceUuid,
+		Name:             cfg.Name,
+		GuestID:          cfg.GuestId,
+		GuestFullName:    cfg.GuestFullName,
+		HardwareVersion:  cfg.Version,
+		Annotation:       cfg.Annotation,
+		FolderPath:       d.resolveFolderPath(vm),
+		NumCPUs:          int(cfg.Hardware.NumCPU),
+		CoresPerSocket:   int(cfg.Hardware.NumCoresPerSocket),
+		MemoryMB:         int(cfg.Hardware.MemoryMB),
+		CPUHotAddEnabled: cfg.CpuHotAddEnabled != nil && *cfg.CpuHotAddEnabled,
+		MemHotAddEnabled: cfg.MemoryHotAddEnabled != nil && *cfg.MemoryHotAddEnabled,
+		Firmware:         cfg.Firmware,
+		CollectedAt:      time.Now().UTC(),
+	}
+
+	if vmConfig.Firmware == "" {
+		vmConfig.Firmware = string(types.GuestOsDescriptorFirmwareTypeBios)
+	}
+	if cfg.BootOptions != nil && cfg.BootOptions.EfiSecureBootEnabled != nil {
+		vmConfig.SecureBoot = *cfg.BootOptions.EfiSecureBootEnabled
+	}
+	if cfg.CpuAllocation != nil && cfg.CpuAllocation.Reservation != nil {
+		vmConfig.CPUReservationMHz = *cfg.CpuAllocation.Reservation
+	}
+	if cfg.MemoryAllocation != nil && cfg.MemoryAllocation.Reservation != nil {
+		vmConfig.MemReservationMB = *cfg.MemoryAllocation.Reservation
+	}
+
+	for _, device := range cfg.Hardware.Device {
+		switch dev := device.(type) {
+		case types.BaseVirtualEthernetCard:
+			vmConfig.NICs = append(vmConfig.NICs, d.vmConfigNIC(dev))
+		case *types.VirtualDisk:
+			vmConfig.Disks = append(vmConfig.Disks, vmConfigDisk(dev))
+		case *types.VirtualTPM:
+			vmConfig.VTPM = true
+		case types.BaseVirtualController:
+			if controller, ok := vmConfigController(dev); ok {
+				vmConfig.Controllers = append(vmConfig.Controllers, controller)
+			}
+		}
+	}
+
+	vmConfig.CustomAttributes = customAttributes(&mvm)
+
+	// Tags live in the vAPI (REST) endpoint - missing tags shouldn't fail the backup
+	vmTags, err := d.getVMTags(ctx, vm)
+	if err != nil {
+		log.WithError(err).WithField("vm_path", vmPath).Warn("⚠️ Failed to collect vSphere tags for VM configuration")
+	}
+	vmConfig.Tags = vmTags
+
+	log.WithFields(log.Fields{
+		"vm_path":     vmPath,
+		"firmware":    vmConfig.Firmware,
+		"secure_boot": vmConfig.SecureBoot,
+		"nics":        len(vmConfig.NICs),
+		"controllers": len(vmConfig.Controllers),
+		"disks":       len(vmConfig.Disks),
+		"tags":        len(vmConfig.Tags),
+	}).Info("✅ VM configuration collected")
+
+	return vmConfig, nil
+}
+
+// vmConfigNIC converts a virtual ethernet card to its configuration record
+func (d *Discovery) vmConfigNIC(nic types.BaseVirtualEthernetCard) models.VMConfigNIC {
+	card := nic.GetVirtualEthernetCard()
+
+	vmNIC := models.VMConfigNIC{
+		Key:         card.Key,
+		MACAddress:  card.MacAddress,
+		AddressType: card.AddressType,
+		NetworkName: d.resolveNetworkName(card.Backing),
+	}
+
+	if desc := card.DeviceInfo.GetDescription(); desc != nil {
+		vmNIC.Label = desc.Label
+	}
+	if card.Connectable != nil {
+		vmNIC.Connected = card.Connectable.Connected
+		vmNIC.StartConnected = card.Connectable.StartConnected
+	}
+	if dvs, ok := card.Backing.(*types.VirtualEthernetCardDistributedVirtualPortBackingInfo); ok {
+		vmNIC.PortGroupKey = dvs.Port.PortgroupKey
+	}
+
+	switch nic.(type) {
+	case *types.VirtualVmxnet3:
+		vmNIC.AdapterType = "vmxnet3"
+	case *types.VirtualVmxnet2:
+		vmNIC.AdapterType = "vmxnet2"
+	case *types.VirtualE1000:
+		vmNIC.AdapterType = "e1000"
+	case *types.VirtualE1000e:
+		vmNIC.AdapterType = "e1000e"
+	case *types.VirtualPCNet32:
+		vmNIC.AdapterType = "pcnet32"
+	case *types.VirtualSriovEthernetCard:
+		vmNIC.AdapterType = "sriov"
+	default:
+		vmNIC.AdapterType = "unknown"
+	}
+
+	return vmNIC
+}
+
+// vmConfigController converts a storage controller to its configuration record
+// Returns false for non-storage controllers (PCI, USB, PS/2, ...)
+func vmConfigController(device types.BaseVirtualController) (models.VMConfigController, bool) {
+	var controllerType string
+	switch device.(type) {
+	case *types.ParaVirtualSCSIController:
+		controllerType = "pvscsi"
+	case *types.VirtualLsiLogicController:
+		controllerType = "lsilogic"
+	case *types.VirtualLsiLogicSASController:
+		controllerType = "lsilogic-sas"
+	case *types.VirtualBusLogicController:
+		controllerType = "buslogic"
+	case *types.VirtualAHCIController:
+		controllerType = "sata"
+	case *types.VirtualNVMEController:
+		controllerType = "nvme"
+	case *types.VirtualIDEController:
+		controllerType = "ide"
+	default:
+		return models.VMConfigController{}, false
+	}
+
+	c := device.GetVirtualController()
+	controller := models.VMConfigController{
+		Key:       c.Key,
+		Type:      controllerType,
+		BusNumber: c.BusNumber,
+	}
+	if desc := c.DeviceInfo.GetDescription(); desc != nil {
+		controller.Label = desc.Label
+	}
+	if scsi, ok := device.(types.BaseVirtualSCSIController); ok {
+		controller.SharedBus = string(scsi.GetVirtualSCSIController().SharedBus)
+	}
+
+	return controller, true
+}
+
+// vmConfigDisk converts a virtual disk to its placement record
+func vmConfigDisk(disk *types.VirtualDisk) models.VMConfigDisk {
+	vmDisk := models.VMConfigDisk{
+		Key:           disk.Key,
+		ControllerKey: disk.ControllerKey,
+		CapacityBytes: disk.CapacityInBytes,
+	}
+
+	if vmDisk.CapacityBytes == 0 {
+		vmDisk.CapacityBytes = disk.CapacityInKB * 1024
+	}
+	if disk.UnitNumber != nil {
+		vmDisk.UnitNumber = *disk.UnitNumber
+	}
+	if desc := disk.DeviceInfo.GetDescription(); desc != nil {
+		vmDisk.Label = desc.Label
+	}
+	if backing, ok := disk.Backing.(types.BaseVirtualDeviceFileBackingInfo); ok {
+		vmDisk.FileName = backing.GetVirtualDeviceFileBackingInfo().FileName
+	}
+	if flat, ok := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo); ok {
+		vmDisk.DiskMode = flat.DiskMode
+		vmDisk.ThinProvisioned = flat.ThinProvisioned != nil && *flat.ThinProvisioned
+	}
+
+	return vmDisk
+}
+
+// customAttributes maps the VM's custom attribute values to their field names
+func customAttributes(mvm *mo.VirtualMachine) map[string]string {
+	if len(mvm.CustomValue) == 0 {
+		return nil
+	}
+
+	names := make(map[int32]string, len(mvm.AvailableField))
+	for _, field := range mvm.AvailableField {
+		names[field.Key] = field.Name
+	}
+
+	attributes := make(map[string]string, len(mvm.CustomValue))
+	for _, value := range mvm.CustomValue {
+		stringValue, ok := value.(*types.CustomFieldStringValue)
+		if !ok {
+			continue
+		}
+		name := names[stringValue.Key]
+		if name == "" {
+			name = fmt.Sprintf("field-%d", stringValue.Key)
+		}
+		attributes[name] = stringValue.Value
+	}
+
+	return attributes
+}
+
+// getVMTags returns the vSphere tags attached to the VM with their category names
+func (d *Discovery) getVMTags(ctx context.Context, vm *object.VirtualMachine) ([]models.VMConfigTag, error) {
+	restClient := rest.NewClient(d.client.Client)
+	if err := restClient.Login(ctx, url.UserPassword(d.config.Username, d.config.Password)); err != nil {
+		return nil, fmt.Errorf("failed to log in to vAPI endpoint: %w", err)
+	}
+	defer restClient.Logout(ctx)
+
+	manager := tags.NewManager(restClient)
+	attached, err := manager.GetAttachedTags(ctx, vm.Reference())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attached tags: %w", err)
+	}
+
+	categories := make(map[string]string)
+	vmTags := make([]models.VMConfigTag, 0, len(attached))
+	for _, tag := range attached {
+		categoryName, ok := categories[tag.CategoryID]
+		if !ok {
+			category, err := manager.GetCategory(ctx, tag.CategoryID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get tag category %s: %w", tag.CategoryID, err)
+			}
+			categoryName = category.Name
+			categories[tag.CategoryID] = categoryName
+		}
+		vmTags = append(vmTags, models.VMConfigTag{Category: categoryName, Name: tag.Name})
+	}
+
+	return vmTags, nil
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/vexxhost/migratekit-volume-daemon/models"
+)
+
+const (
+	// operationEventKeepalive keeps idle streams alive through proxies
+	operationEventKeepalive = 15 * time.Second
+
+	// operationEventReplayPage is how many recorded events are replayed per query
+	operationEventReplayPage = 500
+
+	// operationEventTrailLimit caps a trail request without an explicit limit
+	operationEventTrailLimit = 1000
+)
+
+// StreamOperationEvents handles GET /api/v1/events
+//
+// Streams operation lifecycle events as server-sent events. Filter with operation_id,
+// volume_id or vm_id. Each event carries its recorded ID; reconnect with the
+// Last-Event-ID header (or ?after=) to replay what was missed before going live.
+// A stream of one operation ends after that operation's terminal event.
+func (h *Handler) StreamOperationEvents(c *gin.Context) {
+	filter := models.OperationEventFilter{
+		OperationID: c.Query("operation_id"),
+		VolumeID:    c.Query("volume_id"),
+		VMID:        c.Query("vm_id"),
+	}
+
+	cursor := c.GetHeader("Last-Event-ID")
+	if cursor == "" {
+		cursor = c.Query("after")
+	}
+	if cursor != "" {
+		afterID, err := strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event cursor: " + cursor})
+			return
+		}
+		filter.AfterID = afterID
+	}
+
+	// Subscribe before replaying so nothing published during the replay is lost
+	sub := h.volumeService.SubscribeOperationEvents(filter)
+	defer h.volumeService.UnsubscribeOperationEvents(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	lastSent := filter.AfterID
+
+	// Replay recorded history for a scoped stream or a resumed connection
+	if filter.OperationID != "" || filter.VolumeID != "" || filter.VMID != "" || filter.AfterID > 0 {
+		replay := filter
+		replay.Limit = operationEventReplayPage
+		for {
+			events, err := h.volumeService.ListOperationEvents(c.Request.Context(), replay)
+			if err != nil {
+				log.WithError(err).Warn("Failed to replay operation events")
+				return
+			}
+			for i := range events {
+				if err := writeOperationEvent(c, &events[i]); err != nil {
+					return
+				}
+				lastSent = events[i].ID
+				if filter.OperationID != "" && events[i].EventType.IsTerminal() {
+					return
+				}
+			}
+			if len(events) < replay.Limit {
+				break
+			}
+			replay.AfterID = lastSent
+		}
+	}
+
+	keepalive := time.NewTicker(operationEventKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				// Dropped as a slow subscriber; the client resumes from its last event ID
+				return
+			}
+			// Already sent during the replay (unrecorded events have no ID and always go out)
+			if event.ID != 0 && event.ID <= lastSent {
+				continue
+			}
+			if err := writeOperationEvent(c, &event); err != nil {
+				return
+			}
+			if event.ID != 0 {
+				lastSent = event.ID
+			}
+			if filter.OperationID != "" && event.EventType.IsTerminal() {
+				return
+			}
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(c.Writer, ": keepalive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeOperationEvent writes one event in SSE framing
+func writeOperationEvent(c *gin.Context, event *models.OperationEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if event.ID != 0 {
+		if _, err := fmt.Fprintf(c.Writer, "id: %d\n", event.ID); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event.EventType, data); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+// GetVolumeEvents handles GET /api/v1/volumes/:id/events
+func (h *Handler) GetVolumeEvents(c *gin.Context) {
+	filter, ok := parseEventTrailFilter(c)
+	if !ok {
+		return
+	}
+	filter.VolumeID = c.Param("id")
+
+	h.listOperationEvents(c, filter)
+}
+
+// GetOperationEvents handles GET /api/v1/operations/:id/events
+func (h *Handler) GetOperationEvents(c *gin.Context) {
+	filter, ok := parseEventTrailFilter(c)
+	if !ok {
+		return
+	}
+	filter.OperationID = c.Param("id")
+
+	h.listOperationEvents(c, filter)
+}
+
+func (h *Handler) listOperationEvents(c *gin.Context, filter models.OperationEventFilter) {
+	events, err := h.volumeService.ListOperationEvents(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// parseEventTrailFilter reads the after/limit query parameters of an event trail request
+func parseEventTrailFilter(c *gin.Context) (models.OperationEventFilter, bool) {
+	filter := models.OperationEventFilter{Limit: operationEventTrailLimit}
+
+	if afterParam := c.Query("after"); afterParam != "" {
+		afterID, err := strconv.ParseUint(afterParam, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event cursor: " + afterParam})
+			return filter, false
+		}
+		filter.AfterID = afterID
+	}
+
+	if limitParam := c.Query("limit"); limitParam != "" {
+		if limit, err := strconv.Atoi(limitParam); err == nil && limit > 0 {
+			filter.Limit = limit
+		}
+	}
+
+	return filter, true
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vexxhost/migratekit-volume-daemon/models"
+	"github.com/vexxhost/migratekit-volume-daemon/service"
+)
+
+// fakeEventService serves recorded operation events from memory and live events from a
+// real hub; the other volume service methods are not used by the event handlers
+type fakeEventService struct {
+	service.VolumeManagementService
+
+	recorded   []models.OperationEvent
+	hub        *service.OperationEventHub
+	subscribed chan struct{}
+}
+
+func newFakeEventService(recorded ...models.OperationEvent) *fakeEventService {
+	return &fakeEventService{
+		recorded:   recorded,
+		hub:        service.NewOperationEventHub(),
+		subscribed: make(chan struct{}, 1),
+	}
+}
+
+func (f *fakeEventService) ListOperationEvents(ctx context.Context, filter models.OperationEventFilter) ([]models.OperationEvent, error) {
+	var events []models.OperationEvent
+	for i := range f.recorded {
+		if f.recorded[i].ID > filter.AfterID && filter.Matches(&f.recorded[i]) {
+			events = append(events, f.recorded[i])
+		}
+	}
+	return events, nil
+}
+
+func (f *fakeEventService) SubscribeOperationEvents(filter models.OperationEventFilter) *service.OperationEventSubscription {
+	sub := f.hub.Subscribe(filter)
+	f.subscribed <- struct{}{}
+	return sub
+}
+
+func (f *fakeEventService) UnsubscribeOperationEvents(sub *service.OperationEventSubscription) {
+	f.hub.Unsubscribe(sub)
+}
+
+func operationEvent(id uint64, operationID string, eventType models.OperationEventType) models.OperationEvent {
+	return models.OperationEvent{ID: id, OperationID: operationID, EventType: eventType, VolumeID: "vol-1"}
+}
+
+// streamEvents runs a stream request until the handler returns and lists the event IDs
+// and types written, e.g. "2:provider-submitted"
+func streamEvents(t *testing.T, svc *fakeEventService, target, lastEventID string, live ...models.OperationEvent) []string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/events", NewHandler(svc, nil).StreamOperationEvents)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, target, nil).WithContext(ctx)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	rec := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		router.ServeHTTP(rec, req)
+	}()
+
+	select {
+	case <-svc.subscribed:
+	case <-done:
+		t.Fatalf("handler returned before subscribing: %d %s", rec.Code, rec.Body.String())
+	}
+	for _, event := range live {
+		svc.hub.Publish(event)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		cancel()
+		<-done
+		t.Fatal("stream did not end after the terminal event")
+	}
+
+	var got []string
+	var id string
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			got = append(got, id+":"+strings.TrimPrefix(line, "event: "))
+			id = ""
+		}
+	}
+	return got
+}
+
+func TestStreamOperationEvents(t *testing.T) {
+	recorded := []models.OperationEvent{
+		operationEvent(1, "op-1", models.EventOperationCreated),
+		operationEvent(2, "op-1", models.EventProviderSubmitted),
+		operationEvent(3, "op-2", models.EventOperationCreated),
+		operationEvent(4, "op-1", models.EventDeviceDetected),
+	}
+
+	tests := []struct {
+		name        string
+		recorded    []models.OperationEvent
+		lastEventID string
+		live        []models.OperationEvent
+		want        []string
+	}{
+		{
+			name:        "replays after Last-Event-ID then ends on live terminal event",
+			recorded:    recorded,
+			lastEventID: "1",
+			live: []models.OperationEvent{
+				operationEvent(4, "op-1", models.EventDeviceDetected), // Already replayed
+				operationEvent(5, "op-2", models.EventOperationCompleted),
+				operationEvent(6, "op-1", models.EventOperationCompleted),
+				operationEvent(7, "op-1", models.EventOperationCompleted),
+			},
+			want: []string{"2:provider-submitted", "4:device-detected", "6:completed"},
+		},
+		{
+			name:        "ends on recorded terminal event",
+			recorded:    append(recorded, operationEvent(5, "op-1", models.EventOperationFailed)),
+			lastEventID: "2",
+			want:        []string{"4:device-detected", "5:failed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newFakeEventService(tt.recorded...)
+
+			got := streamEvents(t, svc, "/api/v1/events?operation_id=op-1", tt.lastEventID, tt.live...)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("streamed events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamOperationEventsRejectsInvalidCursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/events", NewHandler(newFakeEventService(), nil).StreamOperationEvents)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/events?operation_id=op-1", nil)
+	req.Header.Set("Last-Event-ID", "not-a-number")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
@@ -36,6 +36,7 @@ func SetupRoutes(router *gin.Engine, volumeService service.VolumeManagementServi
 		v1.POST("/volumes/:id/attach", handler.AttachVolume)
 		v1.POST("/volumes/:id/attach-root", handler.AttachVolumeAsRoot)
 		v1.POST("/volumes/:id/detach", handler.DetachVolume)
+		v1.POST("/volumes/:id/resize", handler.ResizeVolume)
 		v1.DELETE("/volumes/:id", handler.DeleteVolume)
 
 		// Cleanup operations
@@ -50,13 +51,18 @@ func SetupRoutes(router *gin.Engine, volumeService service.VolumeManagementServi
 		// Volume status queries
 		v1.GET("/volumes/:id", handler.GetVolumeStatus)
 		v1.GET("/volumes/:id/device", handler.GetDeviceMapping)
+		v1.GET("/volumes/:id/events", handler.GetVolumeEvents)
 		v1.GET("/devices/:path/volume", handler.GetVolumeForDevice)
 		v1.GET("/vms/:id/volumes", handler.ListVolumesForVM)
 
 		// Operation tracking
 		v1.GET("/operations/:id", handler.GetOperation)
+		v1.GET("/operations/:id/events", handler.GetOperationEvents)
 		v1.GET("/operations", handler.ListOperations)
 
+		// Operation event stream (SSE, resumable by event ID)
+		v1.GET("/events", handler.StreamOperationEvents)
+
 		// NBD Export Management (NEW)
 		v1.POST("/exports", handler.CreateNBDExport)
 		v1.DELETE("/exports/:volume_id", handler.DeleteNBDExport)
@@ -144,6 +150,29 @@ func (h *Handler) DetachVolume(c *gin.Context) {
 	c.JSON(http.StatusCreated, operation)
 }
 
+// ResizeVolume handles POST /api/v1/volumes/:id/resize
+func (h *Handler) ResizeVolume(c *gin.Context) {
+	volumeID := c.Param("id")
+
+	var req models.ResizeVolumeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Size <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "size must be a positive number of bytes"})
+		return
+	}
+
+	operation, err := h.volumeService.ResizeVolume(c.Request.Context(), volumeID, req.Size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, operation)
+}
+
 // DeleteVolume handles DELETE /api/v1/volumes/:id
 func (h *Handler) DeleteVolume(c *gin.Context) {
 	volumeID := c.Param("id")
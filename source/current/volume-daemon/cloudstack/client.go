@@ -16,6 +16,7 @@ import (
 	"github.com/apache/cloudstack-go/cloudstack"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/vexxhost/migratekit-volume-daemon/device"
 	"github.com/vexxhost/migratekit-volume-daemon/models"
 	"github.com/vexxhost/migratekit-volume-daemon/service"
 )
@@ -27,16 +28,17 @@ type CloudStackConfig struct {
 	SecretKey string `json:"secret_key"`
 	Domain    string `json:"domain"`
 	Zone      string `json:"zone"`
+	OMAVMID   string `json:"oma_vm_id"`
 }
 
-// Client implements the CloudStackClient interface for volume operations
+// Client implements the VolumeProvider interface for CloudStack volume operations
 type Client struct {
 	cs     *cloudstack.CloudStackClient
 	config CloudStackConfig
 }
 
 // NewClient creates a new CloudStack client for volume operations
-func NewClient(config CloudStackConfig) service.CloudStackClient {
+func NewClient(config CloudStackConfig) service.VolumeProvider {
 	// Ensure API URL has the correct path
 	apiURL := config.APIURL
 	if !strings.HasSuffix(apiURL, "/client/api") {
@@ -58,6 +60,22 @@ func NewClient(config CloudStackConfig) service.CloudStackClient {
 	}
 }
 
+// Type returns the provider name
+func (c *Client) Type() string {
+	return service.ProviderCloudStack
+}
+
+// ApplianceVMID returns the SHA VM ID of the configuration the client was created from
+func (c *Client) ApplianceVMID(ctx context.Context) (string, error) {
+	return c.config.OMAVMID, nil
+}
+
+// ResolveDevice resolves an attached volume through its /dev/disk/by-id path. CloudStack
+// KVM sets the virtio serial to the volume UUID without hyphens.
+func (c *Client) ResolveDevice(volumeID string, timeout time.Duration) (string, string, error) {
+	return device.GetDeviceByVolumeID(volumeID, timeout)
+}
+
 // CreateVolume creates a new volume in CloudStack
 func (c *Client) CreateVolume(ctx context.Context, req models.CreateVolumeRequest) (string, error) {
 	log.WithFields(log.Fields{
@@ -314,6 +332,32 @@ func (c *Client) DeleteVolume(ctx context.Context, volumeID string) error {
 	return nil
 }
 
+// ResizeVolume grows a CloudStack volume to the given size (bytes, rounded up to whole GB)
+func (c *Client) ResizeVolume(ctx context.Context, volumeID string, sizeBytes int64) error {
+	sizeGB := (sizeBytes + (1024*1024*1024 - 1)) / (1024 * 1024 * 1024)
+
+	log.WithFields(log.Fields{
+		"volume_id": volumeID,
+		"size_gb":   sizeGB,
+	}).Info("Resizing CloudStack volume")
+
+	params := c.cs.Volume.NewResizeVolumeParams(volumeID)
+	params.SetSize(sizeGB)
+	params.SetShrinkok(false)
+
+	resp, err := c.cs.Volume.ResizeVolume(params)
+	if err != nil {
+		return fmt.Errorf("failed to resize volume %s: %w", volumeID, err)
+	}
+
+	log.WithFields(log.Fields{
+		"volume_id": volumeID,
+		"size":      resp.Size,
+	}).Info("CloudStack volume resized successfully")
+
+	return nil
+}
+
 // GetVolume retrieves volume information from CloudStack
 func (c *Client) GetVolume(ctx context.Context, volumeID string) (map[string]interface{}, error) {
 	log.WithFields(log.Fields{
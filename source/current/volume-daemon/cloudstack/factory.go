@@ -21,6 +21,7 @@ type OSSEAConfig struct {
 	Domain    string `db:"domain"`
 	Zone      string `db:"zone"`
 	IsActive  bool   `db:"is_active"`
+	OMAVMID   string `db:"oma_vm_id"`
 }
 
 // Factory creates CloudStack clients from database configuration
@@ -33,8 +34,13 @@ func NewFactory(db *sqlx.DB) *Factory {
 	return &Factory{db: db}
 }
 
+// CreateProvider implements service.ProviderFactory using the active configuration
+func (f *Factory) CreateProvider(ctx context.Context) (service.VolumeProvider, error) {
+	return f.CreateClient(ctx)
+}
+
 // CreateClient creates a CloudStack client using the active configuration from database
-func (f *Factory) CreateClient(ctx context.Context) (service.CloudStackClient, error) {
+func (f *Factory) CreateClient(ctx context.Context) (service.VolumeProvider, error) {
 	config, err := f.getActiveConfig(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get active CloudStack config: %w", err)
@@ -46,6 +52,7 @@ func (f *Factory) CreateClient(ctx context.Context) (service.CloudStackClient, e
 		SecretKey: config.SecretKey,
 		Domain:    config.Domain,
 		Zone:      config.Zone,
+		OMAVMID:   config.OMAVMID,
 	}
 
 	log.WithFields(log.Fields{
@@ -58,7 +65,7 @@ func (f *Factory) CreateClient(ctx context.Context) (service.CloudStackClient, e
 }
 
 // CreateClientByName creates a CloudStack client using a specific named configuration
-func (f *Factory) CreateClientByName(ctx context.Context, name string) (service.CloudStackClient, error) {
+func (f *Factory) CreateClientByName(ctx context.Context, name string) (service.VolumeProvider, error) {
 	config, err := f.getConfigByName(ctx, name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get CloudStack config '%s': %w", name, err)
@@ -70,6 +77,7 @@ func (f *Factory) CreateClientByName(ctx context.Context, name string) (service.
 		SecretKey: config.SecretKey,
 		Domain:    config.Domain,
 		Zone:      config.Zone,
+		OMAVMID:   config.OMAVMID,
 	}
 
 	log.WithFields(log.Fields{
@@ -89,7 +97,7 @@ func (f *Factory) GetActiveConfig(ctx context.Context) (*OSSEAConfig, error) {
 // ListConfigs lists all available CloudStack configurations
 func (f *Factory) ListConfigs(ctx context.Context) ([]OSSEAConfig, error) {
 	query := `
-		SELECT id, name, api_url, api_key, secret_key, domain, zone, is_active
+		SELECT id, name, api_url, api_key, secret_key, domain, zone, is_active, COALESCE(oma_vm_id, '') AS oma_vm_id
 		FROM ossea_configs 
 		ORDER BY name
 	`
@@ -110,7 +118,7 @@ func (f *Factory) ListConfigs(ctx context.Context) ([]OSSEAConfig, error) {
 // getActiveConfig retrieves the active CloudStack configuration from database
 func (f *Factory) getActiveConfig(ctx context.Context) (*OSSEAConfig, error) {
 	query := `
-		SELECT id, name, api_url, api_key, secret_key, domain, zone, is_active
+		SELECT id, name, api_url, api_key, secret_key, domain, zone, is_active, COALESCE(oma_vm_id, '') AS oma_vm_id
 		FROM ossea_configs 
 		WHERE is_active = true
 		LIMIT 1
@@ -137,7 +145,7 @@ func (f *Factory) getActiveConfig(ctx context.Context) (*OSSEAConfig, error) {
 // getConfigByName retrieves a specific CloudStack configuration by name
 func (f *Factory) getConfigByName(ctx context.Context, name string) (*OSSEAConfig, error) {
 	query := `
-		SELECT id, name, api_url, api_key, secret_key, domain, zone, is_active
+		SELECT id, name, api_url, api_key, secret_key, domain, zone, is_active, COALESCE(oma_vm_id, '') AS oma_vm_id
 		FROM ossea_configs 
 		WHERE name = ?
 		LIMIT 1
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -16,7 +17,9 @@ import (
 	"github.com/vexxhost/migratekit-volume-daemon/api"
 	"github.com/vexxhost/migratekit-volume-daemon/cloudstack"
 	"github.com/vexxhost/migratekit-volume-daemon/database"
+	"github.com/vexxhost/migratekit-volume-daemon/local"
 	"github.com/vexxhost/migratekit-volume-daemon/nbd"
+	"github.com/vexxhost/migratekit-volume-daemon/openstack"
 	"github.com/vexxhost/migratekit-volume-daemon/repository"
 	"github.com/vexxhost/migratekit-volume-daemon/service"
 )
@@ -34,26 +37,19 @@ func main() {
 	// Initialize repository
 	repo := database.NewRepository(db)
 
-	// Initialize CloudStack client factory
-	csFactory := cloudstack.NewFactory(db)
-
-	// Test CloudStack connectivity
-	if err := csFactory.TestConnection(context.Background()); err != nil {
-		log.Printf("⚠️  CloudStack connection test failed: %v", err)
-		log.Println("📝 Volume daemon will start but CloudStack operations will fail until configuration is fixed")
-	} else {
-		log.Println("✅ CloudStack connectivity verified")
+	// Initialize destination provider factory (VOLUME_PROVIDER selects the cloud)
+	providerFactory, providerName, err := newProviderFactory(db)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
 	}
+	log.Printf("🔄 Using %s provider factory for dynamic client creation (no caching)", providerName)
 
-	// 🆕 NEW: Use factory for dynamic client creation (no caching)
-	log.Println("🔄 Using CloudStack factory for dynamic client creation (no caching)")
-	
-	// Test that we can create a client (but don't cache it)
-	if _, err := csFactory.CreateClient(context.Background()); err != nil {
-		log.Printf("⚠️  CloudStack client test failed: %v", err)
-		log.Println("📝 Volume operations will fail until CloudStack is configured")
+	// Test destination connectivity
+	if err := providerFactory.TestConnection(context.Background()); err != nil {
+		log.Printf("⚠️  %s connection test failed: %v", providerName, err)
+		log.Println("📝 Volume daemon will start but volume operations will fail until configuration is fixed")
 	} else {
-		log.Println("✅ CloudStack factory validated - clients will be created dynamically")
+		log.Printf("✅ %s connectivity verified - providers will be created dynamically", providerName)
 	}
 
 	// 🆕 REFACTOR: Device monitor no longer needed with by-id resolution
@@ -74,8 +70,8 @@ func main() {
 	log.Printf("✅ OSSEA Volume Repository initialized")
 
 	// Initialize services
-	log.Println("🔧 Initializing Volume Service with CloudStack factory (dynamic client creation)")
-	volumeService := service.NewVolumeService(repo, csFactory, deviceMonitor, nbdExportManager, osseaVolumeRepo)
+	log.Printf("🔧 Initializing Volume Service with %s provider factory (dynamic client creation)", providerName)
+	volumeService := service.NewVolumeService(repo, providerFactory, deviceMonitor, nbdExportManager, osseaVolumeRepo)
 
 	// Initialize NBD cleanup service
 	cleanupService := service.NewNBDCleanupService(db, nbdExportManager)
@@ -137,6 +133,21 @@ func main() {
 	log.Println("✅ Volume Management Daemon stopped")
 }
 
+// newProviderFactory returns the factory of the destination cloud named by VOLUME_PROVIDER
+// (cloudstack, openstack or local; CloudStack when unset)
+func newProviderFactory(db *sqlx.DB) (service.ProviderFactory, string, error) {
+	switch name := os.Getenv("VOLUME_PROVIDER"); name {
+	case service.ProviderOpenStack:
+		return openstack.NewFactory(openstack.ConfigFromEnv()), name, nil
+	case service.ProviderLocal:
+		return local.NewFactory(local.ConfigFromEnv()), name, nil
+	case "", service.ProviderCloudStack:
+		return cloudstack.NewFactory(db), service.ProviderCloudStack, nil
+	default:
+		return nil, "", fmt.Errorf("unknown VOLUME_PROVIDER %q (expected cloudstack, openstack or local)", name)
+	}
+}
+
 func initDatabase() (*sqlx.DB, error) {
 	// For now, use environment variables or default values
 	// TODO: Load from configuration file
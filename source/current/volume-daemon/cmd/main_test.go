@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/vexxhost/migratekit-volume-daemon/cloudstack"
+	"github.com/vexxhost/migratekit-volume-daemon/local"
+	"github.com/vexxhost/migratekit-volume-daemon/openstack"
+	"github.com/vexxhost/migratekit-volume-daemon/service"
+)
+
+func TestNewProviderFactory(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		wantName string
+		wantErr  bool
+	}{
+		{name: "unset defaults to cloudstack", provider: "", wantName: service.ProviderCloudStack},
+		{name: "cloudstack", provider: "cloudstack", wantName: service.ProviderCloudStack},
+		{name: "openstack", provider: "openstack", wantName: service.ProviderOpenStack},
+		{name: "local", provider: "local", wantName: service.ProviderLocal},
+		{name: "unknown", provider: "vsphere", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("VOLUME_PROVIDER", tt.provider)
+
+			factory, name, err := newProviderFactory(nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("newProviderFactory() = %T, want an error", factory)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newProviderFactory() error = %v", err)
+			}
+			if name != tt.wantName {
+				t.Errorf("provider name = %q, want %q", name, tt.wantName)
+			}
+
+			var ok bool
+			switch tt.wantName {
+			case service.ProviderCloudStack:
+				_, ok = factory.(*cloudstack.Factory)
+			case service.ProviderOpenStack:
+				_, ok = factory.(*openstack.Factory)
+			case service.ProviderLocal:
+				_, ok = factory.(*local.Factory)
+			}
+			if !ok {
+				t.Errorf("factory = %T, want the %s factory", factory, tt.wantName)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+-- Remove resize operations from volume_operations
+
+DELETE FROM volume_operations WHERE type = 'resize';
+
+ALTER TABLE volume_operations
+MODIFY COLUMN type ENUM('create', 'attach', 'detach', 'delete', 'cleanup') NOT NULL;
@@ -0,0 +1,6 @@
+-- Migration: add_resize_operation_type
+-- Created: 20251013000000
+-- Allows volume resize operations (grown when a source disk is extended)
+
+ALTER TABLE volume_operations
+MODIFY COLUMN type ENUM('create', 'attach', 'detach', 'delete', 'cleanup', 'resize') NOT NULL;
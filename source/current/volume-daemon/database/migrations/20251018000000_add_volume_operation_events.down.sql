@@ -0,0 +1,3 @@
+-- Remove operation lifecycle events
+
+DROP TABLE IF EXISTS volume_operation_events;
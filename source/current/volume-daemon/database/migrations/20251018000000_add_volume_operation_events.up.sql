@@ -0,0 +1,22 @@
+-- Migration: add_volume_operation_events
+-- Created: 20251018000000
+-- Records operation lifecycle events (created, provider-submitted, device-detected,
+-- export-created, completed, failed) for the event stream and per-volume event trails
+
+CREATE TABLE IF NOT EXISTS volume_operation_events (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    operation_id VARCHAR(64) NOT NULL,
+    operation_type ENUM('create', 'attach', 'detach', 'delete', 'cleanup', 'resize') NOT NULL,
+    event_type VARCHAR(32) NOT NULL,
+    status ENUM('pending', 'executing', 'completed', 'failed', 'cancelled') NOT NULL,
+    volume_id VARCHAR(64) NOT NULL,
+    vm_id VARCHAR(64) NULL,
+    data JSON NULL,
+    error TEXT NULL,
+    created_at TIMESTAMP(3) DEFAULT CURRENT_TIMESTAMP(3),
+
+    INDEX idx_operation_events_operation_id (operation_id, id),
+    INDEX idx_operation_events_volume_id (volume_id, id),
+    INDEX idx_operation_events_vm_id (vm_id, id),
+    INDEX idx_operation_events_created_at (created_at)
+);
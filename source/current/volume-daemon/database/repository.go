@@ -222,6 +222,101 @@ func (r *Repository) ListOperations(ctx context.Context, filter models.Operation
 	return operations, nil
 }
 
+// CreateOperationEvent records an operation lifecycle event and sets its cursor ID
+func (r *Repository) CreateOperationEvent(ctx context.Context, event *models.OperationEvent) error {
+	var dataJSON []byte
+	if event.Data != nil {
+		var err error
+		dataJSON, err = json.Marshal(event.Data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event data: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO volume_operation_events (
+			operation_id, operation_type, event_type, status, volume_id, vm_id, data, error, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		event.OperationID, event.OperationType, event.EventType, event.Status,
+		event.VolumeID, event.VMID, dataJSON, event.Error, event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create operation event: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get operation event ID: %w", err)
+	}
+	event.ID = uint64(id)
+
+	return nil
+}
+
+// ListOperationEvents lists operation events after the filter cursor in cursor order
+func (r *Repository) ListOperationEvents(ctx context.Context, filter models.OperationEventFilter) ([]models.OperationEvent, error) {
+	query := `
+		SELECT id, operation_id, operation_type, event_type, status, volume_id, vm_id, data, error, created_at
+		FROM volume_operation_events
+		WHERE id > ?
+	`
+
+	args := []interface{}{filter.AfterID}
+
+	if filter.OperationID != "" {
+		query += " AND operation_id = ?"
+		args = append(args, filter.OperationID)
+	}
+
+	if filter.VolumeID != "" {
+		query += " AND volume_id = ?"
+		args = append(args, filter.VolumeID)
+	}
+
+	if filter.VMID != "" {
+		query += " AND vm_id = ?"
+		args = append(args, filter.VMID)
+	}
+
+	query += " ORDER BY id ASC"
+
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list operation events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.OperationEvent
+	for rows.Next() {
+		var event models.OperationEvent
+		var dataJSON sql.NullString
+
+		err := rows.Scan(
+			&event.ID, &event.OperationID, &event.OperationType, &event.EventType, &event.Status,
+			&event.VolumeID, &event.VMID, &dataJSON, &event.Error, &event.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan operation event: %w", err)
+		}
+
+		if dataJSON.Valid {
+			if err := json.Unmarshal([]byte(dataJSON.String), &event.Data); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal event data: %w", err)
+			}
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
 // CreateMapping creates a new device mapping record
 func (r *Repository) CreateMapping(ctx context.Context, mapping *models.DeviceMapping) error {
 	log.WithField("volume_uuid", mapping.VolumeUUID).Info("🔍 CreateMapping called - checking VM context ID")
@@ -4,7 +4,7 @@
 -- Volume operations tracking table
 CREATE TABLE IF NOT EXISTS volume_operations (
     id VARCHAR(64) PRIMARY KEY,
-    type ENUM('create', 'attach', 'detach', 'delete') NOT NULL,
+    type ENUM('create', 'attach', 'detach', 'delete', 'cleanup', 'resize') NOT NULL,
     status ENUM('pending', 'executing', 'completed', 'failed', 'cancelled') NOT NULL DEFAULT 'pending',
     volume_id VARCHAR(64) NOT NULL,
     vm_id VARCHAR(64) NULL,
@@ -42,6 +42,25 @@ CREATE TABLE IF NOT EXISTS device_mappings (
     INDEX idx_device_mappings_last_sync (last_sync)
 );
 
+-- Operation lifecycle events streamed to subscribers (id is the resume cursor)
+CREATE TABLE IF NOT EXISTS volume_operation_events (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    operation_id VARCHAR(64) NOT NULL,
+    operation_type ENUM('create', 'attach', 'detach', 'delete', 'cleanup', 'resize') NOT NULL,
+    event_type VARCHAR(32) NOT NULL,
+    status ENUM('pending', 'executing', 'completed', 'failed', 'cancelled') NOT NULL,
+    volume_id VARCHAR(64) NOT NULL,
+    vm_id VARCHAR(64) NULL,
+    data JSON NULL,
+    error TEXT NULL,
+    created_at TIMESTAMP(3) DEFAULT CURRENT_TIMESTAMP(3),
+    
+    INDEX idx_operation_events_operation_id (operation_id, id),
+    INDEX idx_operation_events_volume_id (volume_id, id),
+    INDEX idx_operation_events_vm_id (vm_id, id),
+    INDEX idx_operation_events_created_at (created_at)
+);
+
 -- Operation history for auditing (optional - for future use)
 CREATE TABLE IF NOT EXISTS volume_operation_history (
     id BIGINT AUTO_INCREMENT PRIMARY KEY,
@@ -1,4 +1,4 @@
-// Package device provides by-id device path resolution for CloudStack volumes
+// Package device provides by-id device path resolution for destination cloud volumes
 // This replaces complex size-based correlation with deterministic UUID-based resolution
 package device
 
@@ -66,6 +66,21 @@ func ConstructByIDPath(volumeID string) string {
 	return byIDPath
 }
 
+// ConstructSerialByIDPath builds /dev/disk/by-id path for a disk whose virtio serial is
+// the volume UUID as-is (OpenStack Nova, libvirt attach-disk --serial)
+//
+// Volume UUID: b3bb9310-1b59-4f62-97e8-cefffdfe3804
+// by-id path:  /dev/disk/by-id/virtio-b3bb9310-1b59-4f62-9
+//
+// Pattern: keep hyphens, take first 20 chars, prefix with virtio-
+func ConstructSerialByIDPath(volumeID string) string {
+	shortID := volumeID
+	if len(shortID) > 20 {
+		shortID = shortID[:20]
+	}
+	return fmt.Sprintf("/dev/disk/by-id/virtio-%s", shortID)
+}
+
 // WaitForByIDSymlink waits for /dev/disk/by-id symlink to appear and resolves it
 // Returns the actual device path that the symlink points to
 func WaitForByIDSymlink(byIDPath string, timeout time.Duration) (string, error) {
@@ -166,6 +181,25 @@ func GetDeviceByVolumeID(volumeID string, timeout time.Duration) (byIDPath strin
 	return byIDPath, devicePath, nil
 }
 
+// GetDeviceBySerial resolves a volume attached with its UUID as virtio serial to the
+// Linux device path using by-id
+func GetDeviceBySerial(volumeID string, timeout time.Duration) (byIDPath string, devicePath string, err error) {
+	byIDPath = ConstructSerialByIDPath(volumeID)
+
+	devicePath, err = WaitForByIDSymlink(byIDPath, timeout)
+	if err != nil {
+		return byIDPath, "", fmt.Errorf("failed to resolve device for volume %s: %w", volumeID, err)
+	}
+
+	log.WithFields(log.Fields{
+		"volume_id":   volumeID,
+		"by_id_path":  byIDPath,
+		"device_path": devicePath,
+	}).Info("✅ Volume resolved to device via by-id serial")
+
+	return byIDPath, devicePath, nil
+}
+
 // GetDeviceByVolumeIDWithDefault uses default timeout for convenience
 func (r *ByIDResolver) GetDeviceByVolumeID(volumeID string) (byIDPath string, devicePath string, err error) {
 	return GetDeviceByVolumeID(volumeID, r.defaultTimeout)
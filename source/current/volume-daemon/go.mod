@@ -9,10 +9,10 @@ replace github.com/vexxhost/migratekit => ../../..
 
 require (
 	github.com/apache/cloudstack-go v2.4.1+incompatible
-	github.com/fsnotify/fsnotify v1.9.0
 	github.com/gin-gonic/gin v1.10.1
 	github.com/go-sql-driver/mysql v1.8.1
 	github.com/google/uuid v1.6.0
+	github.com/gophercloud/gophercloud/v2 v2.8.0
 	github.com/jmoiron/sqlx v1.4.0
 	github.com/sirupsen/logrus v1.9.3
 )
@@ -0,0 +1,506 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-volume-daemon/device"
+	"github.com/vexxhost/migratekit-volume-daemon/models"
+	"github.com/vexxhost/migratekit-volume-daemon/service"
+)
+
+// LVM tags recording volume state; LVM is the only store of the local provider
+const (
+	tagVolume   = "sendense_volume"
+	tagName     = "sendense_name="
+	tagAttached = "sendense_vm="
+	tagRoot     = "sendense_root"
+)
+
+// invalidTagChars matches characters LVM does not accept in tags
+var invalidTagChars = regexp.MustCompile(`[^A-Za-z0-9_+.\-/=!:&#]`)
+
+// commandRunner runs a command and returns its standard output
+type commandRunner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// Client implements the VolumeProvider interface for LVM volumes and libvirt domains
+type Client struct {
+	config Config
+	run    commandRunner
+}
+
+// NewClient creates a new local client for volume operations
+func NewClient(config Config) *Client {
+	return &Client{config: config, run: runCommand}
+}
+
+// lvVolume is a logical volume as reported by lvs
+type lvVolume struct {
+	Name string `json:"lv_name"`
+	Size string `json:"lv_size"`
+	Tags string `json:"lv_tags"`
+	Time string `json:"lv_time"`
+}
+
+// Type returns the provider name
+func (c *Client) Type() string {
+	return service.ProviderLocal
+}
+
+// CreateVolume creates a logical volume named after a new volume UUID
+func (c *Client) CreateVolume(ctx context.Context, req models.CreateVolumeRequest) (string, error) {
+	volumeID := uuid.New().String()
+
+	log.WithFields(log.Fields{
+		"volume_id":    volumeID,
+		"name":         req.Name,
+		"size":         req.Size,
+		"volume_group": c.config.VolumeGroup,
+	}).Info("Creating local volume")
+
+	// Size in whole GB like the cloud providers
+	sizeGB := (req.Size + (1024*1024*1024 - 1)) / (1024 * 1024 * 1024)
+	if sizeGB == 0 {
+		sizeGB = 1 // Minimum 1GB
+	}
+
+	_, err := c.run(ctx, "lvcreate", "--yes",
+		"--name", lvName(volumeID),
+		"--size", fmt.Sprintf("%db", sizeGB*1024*1024*1024),
+		"--addtag", tagVolume,
+		"--addtag", tagName+invalidTagChars.ReplaceAllString(req.Name, "_"),
+		c.config.VolumeGroup)
+	if err != nil {
+		return "", fmt.Errorf("failed to create volume: %w", err)
+	}
+
+	log.WithField("volume_id", volumeID).Info("Local volume created successfully")
+	return volumeID, nil
+}
+
+// AttachVolume attaches a volume to a VM. The appliance runs on this host and already
+// sees the logical volume, so only libvirt domains get a disk attached.
+func (c *Client) AttachVolume(ctx context.Context, volumeID, vmID string) error {
+	return c.attach(ctx, volumeID, vmID, false)
+}
+
+// AttachVolumeAsRoot attaches a volume as the first disk (vda) of a libvirt domain
+func (c *Client) AttachVolumeAsRoot(ctx context.Context, volumeID, vmID string) error {
+	return c.attach(ctx, volumeID, vmID, true)
+}
+
+func (c *Client) attach(ctx context.Context, volumeID, vmID string, root bool) error {
+	log.WithFields(log.Fields{
+		"volume_id": volumeID,
+		"vm_id":     vmID,
+		"root":      root,
+	}).Info("Attaching local volume")
+
+	lv, err := c.getLV(ctx, volumeID)
+	if err != nil {
+		return err
+	}
+	if attached := lvTag(lv, tagAttached); attached != "" {
+		return fmt.Errorf("volume %s is already attached to %s", volumeID, attached)
+	}
+
+	if vmID != c.config.ApplianceID {
+		target := "vda"
+		if !root {
+			if target, err = c.nextTarget(ctx, vmID); err != nil {
+				return err
+			}
+		}
+
+		args := []string{"attach-disk", vmID, c.lvPath(volumeID), target,
+			"--targetbus", "virtio", "--serial", volumeID, "--cache", "none"}
+		args = append(args, c.persistenceFlags(ctx, vmID)...)
+		if _, err := c.virsh(ctx, args...); err != nil {
+			return fmt.Errorf("failed to attach volume %s to VM %s: %w", volumeID, vmID, err)
+		}
+	}
+
+	tagArgs := []string{"--addtag", tagAttached + vmID}
+	if root {
+		tagArgs = append(tagArgs, "--addtag", tagRoot)
+	}
+	if err := c.changeTags(ctx, volumeID, tagArgs...); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"volume_id": volumeID,
+		"vm_id":     vmID,
+	}).Info("Local volume attached successfully")
+	return nil
+}
+
+// DetachVolume detaches a volume from the VM recorded in its tags
+func (c *Client) DetachVolume(ctx context.Context, volumeID string) error {
+	log.WithField("volume_id", volumeID).Info("Detaching local volume")
+
+	lv, err := c.getLV(ctx, volumeID)
+	if err != nil {
+		return err
+	}
+
+	vmID := lvTag(lv, tagAttached)
+	if vmID == "" {
+		log.WithField("volume_id", volumeID).Info("Local volume is not attached")
+		return nil
+	}
+
+	if vmID != c.config.ApplianceID {
+		args := append([]string{"detach-disk", vmID, c.lvPath(volumeID)}, c.persistenceFlags(ctx, vmID)...)
+		if _, err := c.virsh(ctx, args...); err != nil {
+			return fmt.Errorf("failed to detach volume %s from VM %s: %w", volumeID, vmID, err)
+		}
+	}
+
+	tagArgs := []string{"--deltag", tagAttached + vmID}
+	if hasTag(lv, tagRoot) {
+		tagArgs = append(tagArgs, "--deltag", tagRoot)
+	}
+	if err := c.changeTags(ctx, volumeID, tagArgs...); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"volume_id": volumeID,
+		"vm_id":     vmID,
+	}).Info("Local volume detached successfully")
+	return nil
+}
+
+// DeleteVolume removes the logical volume
+func (c *Client) DeleteVolume(ctx context.Context, volumeID string) error {
+	log.WithField("volume_id", volumeID).Info("Deleting local volume")
+
+	if _, err := c.run(ctx, "lvremove", "--yes", c.lvRef(volumeID)); err != nil {
+		return fmt.Errorf("failed to delete volume %s: %w", volumeID, err)
+	}
+
+	log.WithField("volume_id", volumeID).Info("Local volume deleted successfully")
+	return nil
+}
+
+// ResizeVolume grows a logical volume to the given size (bytes, rounded up to whole GB)
+// and tells a running domain it is attached to about the new capacity
+func (c *Client) ResizeVolume(ctx context.Context, volumeID string, sizeBytes int64) error {
+	sizeGB := (sizeBytes + (1024*1024*1024 - 1)) / (1024 * 1024 * 1024)
+	newSize := sizeGB * 1024 * 1024 * 1024
+
+	log.WithFields(log.Fields{
+		"volume_id": volumeID,
+		"size_gb":   sizeGB,
+	}).Info("Resizing local volume")
+
+	lv, err := c.getLV(ctx, volumeID)
+	if err != nil {
+		return err
+	}
+	if size, _ := strconv.ParseInt(lv.Size, 10, 64); size >= newSize {
+		log.WithField("volume_id", volumeID).Info("Local volume already at requested size")
+		return nil
+	}
+
+	if _, err := c.run(ctx, "lvextend", "--size", fmt.Sprintf("%db", newSize), c.lvRef(volumeID)); err != nil {
+		return fmt.Errorf("failed to resize volume %s: %w", volumeID, err)
+	}
+
+	if vmID := lvTag(lv, tagAttached); vmID != "" && vmID != c.config.ApplianceID && c.isRunning(ctx, vmID) {
+		if _, err := c.virsh(ctx, "blockresize", vmID, c.lvPath(volumeID), fmt.Sprintf("%dB", newSize)); err != nil {
+			log.WithError(err).WithField("vm_id", vmID).Warn("Failed to notify VM of resized volume")
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"volume_id": volumeID,
+		"size_gb":   sizeGB,
+	}).Info("Local volume resized successfully")
+	return nil
+}
+
+// GetVolume retrieves volume information from LVM
+func (c *Client) GetVolume(ctx context.Context, volumeID string) (map[string]interface{}, error) {
+	lv, err := c.getLV(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+	return c.lvToMap(lv), nil
+}
+
+// ListVolumes lists the volumes attached to a VM, or all volumes if vmID is empty
+func (c *Client) ListVolumes(ctx context.Context, vmID string) ([]map[string]interface{}, error) {
+	lvs, err := c.listLVs(ctx, c.config.VolumeGroup)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes for VM %s: %w", vmID, err)
+	}
+
+	var result []map[string]interface{}
+	for i := range lvs {
+		if !hasTag(&lvs[i], tagVolume) {
+			continue
+		}
+		if vmID != "" && lvTag(&lvs[i], tagAttached) != vmID {
+			continue
+		}
+		result = append(result, c.lvToMap(&lvs[i]))
+	}
+
+	log.WithFields(log.Fields{
+		"vm_id":        vmID,
+		"volume_count": len(result),
+	}).Debug("Local volumes listed")
+
+	return result, nil
+}
+
+// GetVMPowerState returns the domain state using the CloudStack names ("Running",
+// "Stopped") the volume service checks for
+func (c *Client) GetVMPowerState(ctx context.Context, vmID string) (string, error) {
+	out, err := c.virsh(ctx, "domstate", vmID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get VM %s: %w", vmID, err)
+	}
+
+	switch state := strings.TrimSpace(string(out)); state {
+	case "shut off":
+		return "Stopped", nil
+	case "running":
+		return "Running", nil
+	default:
+		return state, nil
+	}
+}
+
+// ValidateVMPoweredOff ensures a domain is shut off before proceeding with cleanup
+func (c *Client) ValidateVMPoweredOff(ctx context.Context, vmID string) error {
+	state, err := c.GetVMPowerState(ctx, vmID)
+	if err != nil {
+		return fmt.Errorf("failed to get VM power state: %w", err)
+	}
+
+	if state != "Stopped" {
+		return fmt.Errorf("VM %s is not powered off (current state: %s)", vmID, state)
+	}
+
+	log.WithField("vm_id", vmID).Info("✅ VM validated as powered off")
+	return nil
+}
+
+// PowerOffVM forcefully stops a domain
+func (c *Client) PowerOffVM(ctx context.Context, vmID string) error {
+	log.WithField("vm_id", vmID).Info("Powering off VM")
+
+	if !c.isRunning(ctx, vmID) {
+		log.WithField("vm_id", vmID).Info("VM already powered off")
+		return nil
+	}
+
+	if _, err := c.virsh(ctx, "destroy", vmID); err != nil {
+		return fmt.Errorf("failed to power off VM %s: %w", vmID, err)
+	}
+
+	log.WithField("vm_id", vmID).Info("✅ VM powered off successfully")
+	return nil
+}
+
+// DeleteVM stops and undefines a domain; its volumes are left to the volume operations
+func (c *Client) DeleteVM(ctx context.Context, vmID string) error {
+	log.WithField("vm_id", vmID).Info("Deleting VM from libvirt")
+
+	if err := c.PowerOffVM(ctx, vmID); err != nil {
+		return fmt.Errorf("failed to power off VM before deletion: %w", err)
+	}
+
+	if _, err := c.virsh(ctx, "undefine", vmID, "--nvram"); err != nil {
+		return fmt.Errorf("failed to delete VM %s: %w", vmID, err)
+	}
+
+	log.WithField("vm_id", vmID).Info("✅ VM deleted successfully")
+	return nil
+}
+
+// ApplianceVMID returns the VM ID the daemon host answers to
+func (c *Client) ApplianceVMID(ctx context.Context) (string, error) {
+	return c.config.ApplianceID, nil
+}
+
+// ResolveDevice returns the logical volume path; it is stable and points at the
+// device-mapper node the appliance reads
+func (c *Client) ResolveDevice(volumeID string, timeout time.Duration) (string, string, error) {
+	lvPath := c.lvPath(volumeID)
+
+	devicePath, err := device.WaitForByIDSymlink(lvPath, timeout)
+	if err != nil {
+		return lvPath, "", fmt.Errorf("failed to resolve device for volume %s: %w", volumeID, err)
+	}
+	return lvPath, devicePath, nil
+}
+
+// Ping checks the volume group exists and libvirt answers
+func (c *Client) Ping(ctx context.Context) error {
+	if _, err := c.run(ctx, "vgs", c.config.VolumeGroup); err != nil {
+		return fmt.Errorf("volume group %s not available: %w", c.config.VolumeGroup, err)
+	}
+	if _, err := c.virsh(ctx, "uri"); err != nil {
+		return fmt.Errorf("libvirt not available: %w", err)
+	}
+	return nil
+}
+
+func lvName(volumeID string) string {
+	return "vol-" + volumeID
+}
+
+func (c *Client) lvRef(volumeID string) string {
+	return c.config.VolumeGroup + "/" + lvName(volumeID)
+}
+
+func (c *Client) lvPath(volumeID string) string {
+	return "/dev/" + c.lvRef(volumeID)
+}
+
+func (c *Client) getLV(ctx context.Context, volumeID string) (*lvVolume, error) {
+	lvs, err := c.listLVs(ctx, c.lvRef(volumeID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volume %s: %w", volumeID, err)
+	}
+	if len(lvs) == 0 {
+		return nil, fmt.Errorf("volume %s not found", volumeID)
+	}
+	return &lvs[0], nil
+}
+
+func (c *Client) listLVs(ctx context.Context, ref string) ([]lvVolume, error) {
+	out, err := c.run(ctx, "lvs", "--reportformat", "json", "--units", "b", "--nosuffix",
+		"-o", "lv_name,lv_size,lv_tags,lv_time", ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var report struct {
+		Report []struct {
+			LV []lvVolume `json:"lv"`
+		} `json:"report"`
+	}
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse lvs output: %w", err)
+	}
+	if len(report.Report) == 0 {
+		return nil, nil
+	}
+	return report.Report[0].LV, nil
+}
+
+func (c *Client) changeTags(ctx context.Context, volumeID string, args ...string) error {
+	args = append(args, c.lvRef(volumeID))
+	if _, err := c.run(ctx, "lvchange", args...); err != nil {
+		return fmt.Errorf("failed to update tags of volume %s: %w", volumeID, err)
+	}
+	return nil
+}
+
+func (c *Client) lvToMap(lv *lvVolume) map[string]interface{} {
+	size, _ := strconv.ParseInt(lv.Size, 10, 64)
+	volumeType := "DATADISK"
+	if hasTag(lv, tagRoot) {
+		volumeType = "ROOT"
+	}
+
+	return map[string]interface{}{
+		"id":               strings.TrimPrefix(lv.Name, "vol-"),
+		"name":             lvTag(lv, tagName),
+		"size":             size,
+		"state":            "Ready",
+		"type":             volumeType,
+		"zoneid":           c.config.VolumeGroup,
+		"zonename":         c.config.VolumeGroup,
+		"created":          lv.Time,
+		"virtualmachineid": lvTag(lv, tagAttached),
+		"storagetype":      "lvm",
+	}
+}
+
+func hasTag(lv *lvVolume, tag string) bool {
+	for _, t := range strings.Split(lv.Tags, ",") {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// lvTag returns the value of a "key=value" tag
+func lvTag(lv *lvVolume, prefix string) string {
+	for _, t := range strings.Split(lv.Tags, ",") {
+		if strings.HasPrefix(t, prefix) {
+			return strings.TrimPrefix(t, prefix)
+		}
+	}
+	return ""
+}
+
+// nextTarget returns the first free vdX device of a domain
+func (c *Client) nextTarget(ctx context.Context, vmID string) (string, error) {
+	out, err := c.virsh(ctx, "domblklist", vmID)
+	if err != nil {
+		return "", fmt.Errorf("failed to list disks of VM %s: %w", vmID, err)
+	}
+
+	used := make(map[string]bool)
+	for _, line := range strings.Split(string(out), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			used[fields[0]] = true
+		}
+	}
+
+	for letter := 'b'; letter <= 'z'; letter++ {
+		if target := "vd" + string(letter); !used[target] {
+			return target, nil
+		}
+	}
+	return "", fmt.Errorf("VM %s has no free virtio disk target", vmID)
+}
+
+// persistenceFlags applies a disk change to the live domain and its definition, or only
+// to the definition when it is shut off
+func (c *Client) persistenceFlags(ctx context.Context, vmID string) []string {
+	if c.isRunning(ctx, vmID) {
+		return []string{"--live", "--config"}
+	}
+	return []string{"--config"}
+}
+
+func (c *Client) isRunning(ctx context.Context, vmID string) bool {
+	state, err := c.GetVMPowerState(ctx, vmID)
+	return err == nil && state != "Stopped"
+}
+
+func (c *Client) virsh(ctx context.Context, args ...string) ([]byte, error) {
+	return c.run(ctx, "virsh", append([]string{"-c", c.config.LibvirtURI}, args...)...)
+}
+
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	log.WithField("args", args).Debugf("Running %s", name)
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
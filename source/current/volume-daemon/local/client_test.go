@@ -0,0 +1,246 @@
+package local
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vexxhost/migratekit-volume-daemon/models"
+)
+
+// fakeRunner records the commands a client runs and answers them from canned output
+// keyed by the command and its first argument (e.g. "virsh domstate")
+type fakeRunner struct {
+	outputs map[string]string
+	calls   [][]string
+}
+
+func (f *fakeRunner) run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	f.calls = append(f.calls, append([]string{name}, args...))
+
+	key := name
+	if name == "virsh" && len(args) > 2 {
+		key += " " + args[2] // Skip "-c <uri>"
+	}
+	return []byte(f.outputs[key]), nil
+}
+
+// commands returns the recorded calls of one command
+func (f *fakeRunner) commands(name string) [][]string {
+	var calls [][]string
+	for _, call := range f.calls {
+		if call[0] == name {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func newTestClient(outputs map[string]string) (*Client, *fakeRunner) {
+	runner := &fakeRunner{outputs: outputs}
+	client := NewClient(Config{VolumeGroup: "sendense", LibvirtURI: "qemu:///system", ApplianceID: "sha-host"})
+	client.run = runner.run
+	return client, runner
+}
+
+// lvsReport builds lvs JSON output for one logical volume
+func lvsReport(name, size, tags string) string {
+	return `{"report":[{"lv":[{"lv_name":"` + name + `","lv_size":"` + size +
+		`","lv_tags":"` + tags + `","lv_time":"2025-10-14 09:00:00 +0000"}]}]}`
+}
+
+func TestCreateVolumeArgs(t *testing.T) {
+	client, runner := newTestClient(nil)
+
+	volumeID, err := client.CreateVolume(context.Background(), models.CreateVolumeRequest{
+		Name: "web 01 (disk 2000)",
+		Size: 3*1024*1024*1024 + 1, // Rounds up to 4 GB
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume() error = %v", err)
+	}
+
+	calls := runner.commands("lvcreate")
+	if len(calls) != 1 {
+		t.Fatalf("lvcreate calls = %v, want one", runner.calls)
+	}
+	want := []string{"lvcreate", "--yes",
+		"--name", "vol-" + volumeID,
+		"--size", "4294967296b",
+		"--addtag", "sendense_volume",
+		"--addtag", "sendense_name=web_01__disk_2000_",
+		"sendense"}
+	if !reflect.DeepEqual(calls[0], want) {
+		t.Errorf("lvcreate args = %v, want %v", calls[0], want)
+	}
+}
+
+func TestGetVolumeParsesTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     string
+		wantName string
+		wantVM   string
+		wantType string
+	}{
+		{
+			name:     "detached data disk",
+			tags:     "sendense_volume,sendense_name=web-01-disk-2000",
+			wantName: "web-01-disk-2000",
+			wantType: "DATADISK",
+		},
+		{
+			name:     "root disk of a failover VM",
+			tags:     "sendense_volume,sendense_name=web-01-disk-2000,sendense_root,sendense_vm=failover-web-01",
+			wantName: "web-01-disk-2000",
+			wantVM:   "failover-web-01",
+			wantType: "ROOT",
+		},
+		{
+			name:     "name containing another tag prefix",
+			tags:     "sendense_volume,sendense_name=sendense_vm=x",
+			wantName: "sendense_vm=x",
+			wantType: "DATADISK",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, runner := newTestClient(map[string]string{
+				"lvs": lvsReport("vol-1234", "10737418240", tt.tags),
+			})
+
+			volume, err := client.GetVolume(context.Background(), "1234")
+			if err != nil {
+				t.Fatalf("GetVolume() error = %v", err)
+			}
+			if got := runner.calls[0][len(runner.calls[0])-1]; got != "sendense/vol-1234" {
+				t.Errorf("lvs queried %q, want sendense/vol-1234", got)
+			}
+
+			if volume["id"] != "1234" || volume["size"] != int64(10737418240) {
+				t.Errorf("id, size = %v, %v, want 1234, 10737418240", volume["id"], volume["size"])
+			}
+			if volume["name"] != tt.wantName {
+				t.Errorf("name = %q, want %q", volume["name"], tt.wantName)
+			}
+			if volume["virtualmachineid"] != tt.wantVM {
+				t.Errorf("virtualmachineid = %q, want %q", volume["virtualmachineid"], tt.wantVM)
+			}
+			if volume["type"] != tt.wantType {
+				t.Errorf("type = %q, want %q", volume["type"], tt.wantType)
+			}
+		})
+	}
+}
+
+func TestAttachVolumeArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		vmID        string
+		root        bool
+		state       string
+		wantAttach  []string // virsh arguments after "-c <uri>"; nil = no libvirt call
+		wantTagArgs []string
+	}{
+		{
+			name:  "data disk to running domain",
+			vmID:  "failover-web-01",
+			state: "running",
+			wantAttach: []string{"attach-disk", "failover-web-01", "/dev/sendense/vol-1234", "vdc",
+				"--targetbus", "virtio", "--serial", "1234", "--cache", "none", "--live", "--config"},
+			wantTagArgs: []string{"lvchange", "--addtag", "sendense_vm=failover-web-01", "sendense/vol-1234"},
+		},
+		{
+			name:  "root disk to shut off domain",
+			vmID:  "failover-web-01",
+			root:  true,
+			state: "shut off",
+			wantAttach: []string{"attach-disk", "failover-web-01", "/dev/sendense/vol-1234", "vda",
+				"--targetbus", "virtio", "--serial", "1234", "--cache", "none", "--config"},
+			wantTagArgs: []string{"lvchange", "--addtag", "sendense_vm=failover-web-01", "--addtag", "sendense_root", "sendense/vol-1234"},
+		},
+		{
+			name:        "appliance only records the attachment",
+			vmID:        "sha-host",
+			wantTagArgs: []string{"lvchange", "--addtag", "sendense_vm=sha-host", "sendense/vol-1234"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, runner := newTestClient(map[string]string{
+				"lvs":               lvsReport("vol-1234", "10737418240", "sendense_volume,sendense_name=web-01"),
+				"virsh domstate":    tt.state + "\n",
+				"virsh domblklist":  " Target   Source\n------------------------------\n vda      /dev/sendense/vol-root\n vdb      /dev/sendense/vol-data\n",
+				"virsh attach-disk": "Disk attached successfully\n",
+			})
+
+			var err error
+			if tt.root {
+				err = client.AttachVolumeAsRoot(context.Background(), "1234", tt.vmID)
+			} else {
+				err = client.AttachVolume(context.Background(), "1234", tt.vmID)
+			}
+			if err != nil {
+				t.Fatalf("attach error = %v", err)
+			}
+
+			var attach []string
+			for _, call := range runner.commands("virsh") {
+				if call[3] == "attach-disk" {
+					attach = call[3:]
+				}
+			}
+			if !reflect.DeepEqual(attach, tt.wantAttach) {
+				t.Errorf("virsh attach-disk args = %v, want %v", attach, tt.wantAttach)
+			}
+
+			tagCalls := runner.commands("lvchange")
+			if len(tagCalls) != 1 || !reflect.DeepEqual(tagCalls[0], tt.wantTagArgs) {
+				t.Errorf("lvchange calls = %v, want [%v]", tagCalls, tt.wantTagArgs)
+			}
+		})
+	}
+}
+
+func TestAttachVolumeRejectsAttachedVolume(t *testing.T) {
+	client, runner := newTestClient(map[string]string{
+		"lvs": lvsReport("vol-1234", "10737418240", "sendense_volume,sendense_vm=failover-web-01"),
+	})
+
+	err := client.AttachVolume(context.Background(), "1234", "failover-web-02")
+	if err == nil || !strings.Contains(err.Error(), "already attached to failover-web-01") {
+		t.Fatalf("AttachVolume() error = %v, want already attached", err)
+	}
+	if len(runner.commands("virsh")) != 0 || len(runner.commands("lvchange")) != 0 {
+		t.Errorf("attached volume was changed: %v", runner.calls)
+	}
+}
+
+func TestListVolumesFiltersByVM(t *testing.T) {
+	client, _ := newTestClient(map[string]string{
+		"lvs": `{"report":[{"lv":[
+			{"lv_name":"vol-a","lv_size":"1073741824","lv_tags":"sendense_volume,sendense_vm=vm-1"},
+			{"lv_name":"vol-b","lv_size":"1073741824","lv_tags":"sendense_volume,sendense_vm=vm-2"},
+			{"lv_name":"vol-c","lv_size":"1073741824","lv_tags":"sendense_volume"},
+			{"lv_name":"swap","lv_size":"1073741824","lv_tags":""}]}]}`,
+	})
+
+	all, err := client.ListVolumes(context.Background(), "")
+	if err != nil {
+		t.Fatalf("ListVolumes() error = %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("ListVolumes(\"\") = %d volumes, want 3 (untagged volumes skipped)", len(all))
+	}
+
+	attached, err := client.ListVolumes(context.Background(), "vm-1")
+	if err != nil {
+		t.Fatalf("ListVolumes() error = %v", err)
+	}
+	if len(attached) != 1 || attached[0]["id"] != "a" {
+		t.Errorf("ListVolumes(vm-1) = %v, want only volume a", attached)
+	}
+}
@@ -0,0 +1,70 @@
+// Package local implements the volume provider for a single host: LVM logical volumes
+// attached to libvirt domains. It needs no cloud, which makes it the provider for lab
+// setups and for testing the replication pipeline end to end.
+package local
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-volume-daemon/service"
+)
+
+// Config represents the local host configuration
+type Config struct {
+	VolumeGroup string `json:"volume_group"` // LVM volume group holding the volumes
+	LibvirtURI  string `json:"libvirt_uri"`  // libvirt connection for failover VMs
+	ApplianceID string `json:"appliance_id"` // VM ID the daemon host answers to as SHA
+}
+
+// ConfigFromEnv reads the configuration from LOCAL_* environment variables
+func ConfigFromEnv() Config {
+	config := Config{
+		VolumeGroup: os.Getenv("LOCAL_VOLUME_GROUP"),
+		LibvirtURI:  os.Getenv("LOCAL_LIBVIRT_URI"),
+		ApplianceID: os.Getenv("LOCAL_APPLIANCE_VM_ID"),
+	}
+	if config.VolumeGroup == "" {
+		config.VolumeGroup = "sendense"
+	}
+	if config.LibvirtURI == "" {
+		config.LibvirtURI = "qemu:///system"
+	}
+	if config.ApplianceID == "" {
+		config.ApplianceID = "local-appliance"
+	}
+	return config
+}
+
+// Factory creates local providers
+type Factory struct {
+	config Config
+}
+
+// NewFactory creates a new local provider factory
+func NewFactory(config Config) *Factory {
+	return &Factory{config: config}
+}
+
+// CreateProvider returns a provider for the configured volume group
+func (f *Factory) CreateProvider(ctx context.Context) (service.VolumeProvider, error) {
+	log.WithFields(log.Fields{
+		"volume_group": f.config.VolumeGroup,
+		"libvirt_uri":  f.config.LibvirtURI,
+	}).Debug("Creating local LVM/libvirt provider")
+
+	return NewClient(f.config), nil
+}
+
+// TestConnection checks the volume group and libvirt are reachable
+func (f *Factory) TestConnection(ctx context.Context) error {
+	client, err := f.CreateProvider(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create local provider: %w", err)
+	}
+
+	return client.Ping(ctx)
+}
@@ -28,6 +28,7 @@ const (
 	OperationDetach  VolumeOperationType = "detach"
 	OperationDelete  VolumeOperationType = "delete"
 	OperationCleanup VolumeOperationType = "cleanup"
+	OperationResize  VolumeOperationType = "resize"
 )
 
 // OperationStatus defines the status of a volume operation
@@ -41,6 +42,61 @@ const (
 	StatusCancelled OperationStatus = "cancelled"
 )
 
+// OperationEventType defines a step in the lifecycle of a volume operation
+type OperationEventType string
+
+const (
+	EventOperationCreated   OperationEventType = "created"            // Operation accepted and recorded
+	EventProviderSubmitted  OperationEventType = "provider-submitted" // Destination cloud completed the volume call
+	EventDeviceDetected     OperationEventType = "device-detected"    // Attached volume resolved to an SHA device
+	EventExportCreated      OperationEventType = "export-created"     // NBD export serves the device
+	EventOperationCompleted OperationEventType = "completed"
+	EventOperationFailed    OperationEventType = "failed"
+)
+
+// IsTerminal reports whether no further events follow for the operation
+func (t OperationEventType) IsTerminal() bool {
+	return t == EventOperationCompleted || t == EventOperationFailed
+}
+
+// OperationEvent is a recorded lifecycle event of a volume operation. IDs increase
+// monotonically and are the cursor event stream subscribers resume from.
+type OperationEvent struct {
+	ID            uint64                 `json:"id" db:"id"`
+	OperationID   string                 `json:"operation_id" db:"operation_id"`
+	OperationType VolumeOperationType    `json:"operation_type" db:"operation_type"`
+	EventType     OperationEventType     `json:"event_type" db:"event_type"`
+	Status        OperationStatus        `json:"status" db:"status"`
+	VolumeID      string                 `json:"volume_id" db:"volume_id"`
+	VMID          *string                `json:"vm_id,omitempty" db:"vm_id"`
+	Data          map[string]interface{} `json:"data,omitempty" db:"data"`
+	Error         *string                `json:"error,omitempty" db:"error"`
+	CreatedAt     time.Time              `json:"created_at" db:"created_at"`
+}
+
+// OperationEventFilter represents filter criteria for operation events
+type OperationEventFilter struct {
+	OperationID string `json:"operation_id,omitempty"`
+	VolumeID    string `json:"volume_id,omitempty"`
+	VMID        string `json:"vm_id,omitempty"`
+	AfterID     uint64 `json:"after_id,omitempty"` // Resume cursor: only events with a greater ID
+	Limit       int    `json:"limit,omitempty"`
+}
+
+// Matches reports whether an event passes the filter (the cursor is not checked)
+func (f OperationEventFilter) Matches(event *OperationEvent) bool {
+	if f.OperationID != "" && event.OperationID != f.OperationID {
+		return false
+	}
+	if f.VolumeID != "" && event.VolumeID != f.VolumeID {
+		return false
+	}
+	if f.VMID != "" && (event.VMID == nil || *event.VMID != f.VMID) {
+		return false
+	}
+	return true
+}
+
 // OperationMode defines the type of volume operation mode
 type OperationMode string
 
@@ -105,6 +161,11 @@ type AttachVolumeRequest struct {
 	VMID     string `json:"vm_id" validate:"required"`
 }
 
+// ResizeVolumeRequest represents a request to grow a volume
+type ResizeVolumeRequest struct {
+	Size int64 `json:"size" validate:"required,min=1"` // New size in bytes
+}
+
 // CleanupRequest represents a request to cleanup test failover resources
 type CleanupRequest struct {
 	TestVMID   string `json:"test_vm_id" validate:"required"`
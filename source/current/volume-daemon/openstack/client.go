@@ -0,0 +1,473 @@
+package openstack
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/blockstorage/v3/volumes"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/volumeattach"
+	"github.com/gophercloud/gophercloud/v2/pagination"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-volume-daemon/device"
+	"github.com/vexxhost/migratekit-volume-daemon/models"
+	"github.com/vexxhost/migratekit-volume-daemon/service"
+)
+
+// operationTimeout bounds waiting for Cinder/Nova to settle a volume or server state
+const operationTimeout = 5 * time.Minute
+
+// metadataURL is the Nova metadata service, used when no appliance ID is configured
+const metadataURL = "http://169.254.169.254/openstack/latest/meta_data.json"
+
+// Client implements the VolumeProvider interface for OpenStack volume operations
+type Client struct {
+	blockStorage *gophercloud.ServiceClient
+	compute      *gophercloud.ServiceClient
+	config       Config
+}
+
+// NewClient authenticates and creates a new OpenStack client for volume operations
+func NewClient(ctx context.Context, opts gophercloud.AuthOptions, config Config) (*Client, error) {
+	provider, err := openstack.NewClient(opts.IdentityEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OpenStack provider: %w", err)
+	}
+
+	ua := gophercloud.UserAgent{}
+	ua.Prepend("migratekit-volume-daemon")
+	provider.UserAgent = ua
+
+	provider.HTTPClient.Transport = &http.Transport{
+		TLSClientConfig: &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: config.Insecure,
+		},
+	}
+
+	if err := openstack.Authenticate(ctx, provider, opts); err != nil {
+		return nil, fmt.Errorf("failed to authenticate to OpenStack: %w", err)
+	}
+
+	endpoint := gophercloud.EndpointOpts{Region: config.Region}
+
+	blockStorage, err := openstack.NewBlockStorageV3(provider, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Cinder client: %w", err)
+	}
+	// 3.42 allows extending in-use volumes
+	blockStorage.Microversion = "3.42"
+
+	compute, err := openstack.NewComputeV2(provider, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Nova client: %w", err)
+	}
+
+	return &Client{
+		blockStorage: blockStorage,
+		compute:      compute,
+		config:       config,
+	}, nil
+}
+
+// Type returns the provider name
+func (c *Client) Type() string {
+	return service.ProviderOpenStack
+}
+
+// CreateVolume creates a new Cinder volume and waits for it to become available
+func (c *Client) CreateVolume(ctx context.Context, req models.CreateVolumeRequest) (string, error) {
+	log.WithFields(log.Fields{
+		"name":        req.Name,
+		"size":        req.Size,
+		"volume_type": req.DiskOfferingID,
+		"zone":        req.ZoneID,
+	}).Info("Creating OpenStack volume")
+
+	// Size in GB (Cinder expects GB, not bytes)
+	sizeGB := int((req.Size + (1024*1024*1024 - 1)) / (1024 * 1024 * 1024))
+	if sizeGB == 0 {
+		sizeGB = 1 // Minimum 1GB
+	}
+
+	opts := volumes.CreateOpts{
+		Name:             req.Name,
+		Size:             sizeGB,
+		VolumeType:       c.config.VolumeType,
+		AvailabilityZone: c.config.AvailabilityZone,
+		Metadata:         req.Metadata,
+	}
+	// The disk offering and zone of the request map to Cinder volume type and AZ
+	if req.DiskOfferingID != "" {
+		opts.VolumeType = req.DiskOfferingID
+	}
+	if req.ZoneID != "" {
+		opts.AvailabilityZone = req.ZoneID
+	}
+
+	volume, err := volumes.Create(ctx, c.blockStorage, opts, nil).Extract()
+	if err != nil {
+		return "", fmt.Errorf("failed to create volume: %w", err)
+	}
+
+	if err := c.waitForVolumeStatus(ctx, volume.ID, "available"); err != nil {
+		return "", err
+	}
+
+	log.WithFields(log.Fields{
+		"volume_id": volume.ID,
+		"name":      volume.Name,
+		"size_gb":   sizeGB,
+	}).Info("OpenStack volume created successfully")
+
+	return volume.ID, nil
+}
+
+// AttachVolume attaches a volume to a server through Nova and waits until it is in use
+func (c *Client) AttachVolume(ctx context.Context, volumeID, vmID string) error {
+	log.WithFields(log.Fields{
+		"volume_id": volumeID,
+		"vm_id":     vmID,
+	}).Info("Attaching OpenStack volume to server")
+
+	_, err := volumeattach.Create(ctx, c.compute, vmID, volumeattach.CreateOpts{
+		VolumeID: volumeID,
+	}).Extract()
+	if err != nil {
+		return fmt.Errorf("failed to attach volume %s to server %s: %w", volumeID, vmID, err)
+	}
+
+	if err := c.waitForVolumeStatus(ctx, volumeID, "in-use"); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"volume_id": volumeID,
+		"vm_id":     vmID,
+	}).Info("OpenStack volume attached successfully")
+
+	return nil
+}
+
+// AttachVolumeAsRoot is not supported: Nova cannot replace the root disk of an existing
+// server, failover servers must boot from the volume instead
+func (c *Client) AttachVolumeAsRoot(ctx context.Context, volumeID, vmID string) error {
+	return fmt.Errorf("attaching volume %s as root disk of server %s is not supported by OpenStack; boot the server from the volume instead", volumeID, vmID)
+}
+
+// DetachVolume detaches a volume from the server it is attached to
+func (c *Client) DetachVolume(ctx context.Context, volumeID string) error {
+	log.WithFields(log.Fields{
+		"volume_id": volumeID,
+	}).Info("Detaching OpenStack volume")
+
+	volume, err := volumes.Get(ctx, c.blockStorage, volumeID).Extract()
+	if err != nil {
+		return fmt.Errorf("failed to get volume %s: %w", volumeID, err)
+	}
+
+	if len(volume.Attachments) == 0 {
+		log.WithField("volume_id", volumeID).Info("OpenStack volume is not attached")
+		return nil
+	}
+
+	for _, attachment := range volume.Attachments {
+		err := volumeattach.Delete(ctx, c.compute, attachment.ServerID, volumeID).ExtractErr()
+		if err != nil {
+			return fmt.Errorf("failed to detach volume %s from server %s: %w", volumeID, attachment.ServerID, err)
+		}
+	}
+
+	if err := c.waitForVolumeStatus(ctx, volumeID, "available"); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"volume_id": volumeID,
+	}).Info("OpenStack volume detached successfully")
+
+	return nil
+}
+
+// DeleteVolume deletes a Cinder volume
+func (c *Client) DeleteVolume(ctx context.Context, volumeID string) error {
+	log.WithFields(log.Fields{
+		"volume_id": volumeID,
+	}).Info("Deleting OpenStack volume")
+
+	err := volumes.Delete(ctx, c.blockStorage, volumeID, volumes.DeleteOpts{}).ExtractErr()
+	if err != nil {
+		return fmt.Errorf("failed to delete volume %s: %w", volumeID, err)
+	}
+
+	log.WithFields(log.Fields{
+		"volume_id": volumeID,
+	}).Info("OpenStack volume deleted successfully")
+
+	return nil
+}
+
+// ResizeVolume grows a Cinder volume to the given size (bytes, rounded up to whole GB)
+func (c *Client) ResizeVolume(ctx context.Context, volumeID string, sizeBytes int64) error {
+	sizeGB := int((sizeBytes + (1024*1024*1024 - 1)) / (1024 * 1024 * 1024))
+
+	log.WithFields(log.Fields{
+		"volume_id": volumeID,
+		"size_gb":   sizeGB,
+	}).Info("Resizing OpenStack volume")
+
+	volume, err := volumes.Get(ctx, c.blockStorage, volumeID).Extract()
+	if err != nil {
+		return fmt.Errorf("failed to get volume %s: %w", volumeID, err)
+	}
+	if volume.Size >= sizeGB {
+		log.WithField("volume_id", volumeID).Info("OpenStack volume already at requested size")
+		return nil
+	}
+
+	err = volumes.ExtendSize(ctx, c.blockStorage, volumeID, volumes.ExtendSizeOpts{NewSize: sizeGB}).ExtractErr()
+	if err != nil {
+		return fmt.Errorf("failed to resize volume %s: %w", volumeID, err)
+	}
+
+	// Extending returns the volume to its previous status
+	if err := c.waitForVolumeStatus(ctx, volumeID, volume.Status); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"volume_id": volumeID,
+		"size_gb":   sizeGB,
+	}).Info("OpenStack volume resized successfully")
+
+	return nil
+}
+
+// GetVolume retrieves volume information from Cinder
+func (c *Client) GetVolume(ctx context.Context, volumeID string) (map[string]interface{}, error) {
+	volume, err := volumes.Get(ctx, c.blockStorage, volumeID).Extract()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volume %s: %w", volumeID, err)
+	}
+
+	return volumeToMap(volume), nil
+}
+
+// ListVolumes lists the volumes attached to a server, or all volumes if vmID is empty
+func (c *Client) ListVolumes(ctx context.Context, vmID string) ([]map[string]interface{}, error) {
+	var result []map[string]interface{}
+
+	err := volumes.List(c.blockStorage, volumes.ListOpts{}).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+		list, err := volumes.ExtractVolumes(page)
+		if err != nil {
+			return false, err
+		}
+
+		for i := range list {
+			if vmID != "" && attachedServer(&list[i]) != vmID {
+				continue
+			}
+			result = append(result, volumeToMap(&list[i]))
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes for server %s: %w", vmID, err)
+	}
+
+	log.WithFields(log.Fields{
+		"vm_id":        vmID,
+		"volume_count": len(result),
+	}).Debug("OpenStack volumes listed")
+
+	return result, nil
+}
+
+// GetVMPowerState returns the server power state using the CloudStack names
+// ("Running", "Stopped") the volume service checks for
+func (c *Client) GetVMPowerState(ctx context.Context, vmID string) (string, error) {
+	server, err := servers.Get(ctx, c.compute, vmID).Extract()
+	if err != nil {
+		return "", fmt.Errorf("failed to get server %s: %w", vmID, err)
+	}
+
+	switch server.Status {
+	case "SHUTOFF":
+		return "Stopped", nil
+	case "ACTIVE":
+		return "Running", nil
+	default:
+		return server.Status, nil
+	}
+}
+
+// ValidateVMPoweredOff ensures a server is shut off before proceeding with cleanup
+func (c *Client) ValidateVMPoweredOff(ctx context.Context, vmID string) error {
+	state, err := c.GetVMPowerState(ctx, vmID)
+	if err != nil {
+		return fmt.Errorf("failed to get VM power state: %w", err)
+	}
+
+	if state != "Stopped" {
+		return fmt.Errorf("VM %s is not powered off (current state: %s)", vmID, state)
+	}
+
+	log.WithField("vm_id", vmID).Info("✅ VM validated as powered off")
+	return nil
+}
+
+// PowerOffVM stops a server and waits for it to shut off
+func (c *Client) PowerOffVM(ctx context.Context, vmID string) error {
+	log.WithField("vm_id", vmID).Info("Powering off VM")
+
+	state, err := c.GetVMPowerState(ctx, vmID)
+	if err != nil {
+		return fmt.Errorf("failed to check VM state before power off: %w", err)
+	}
+
+	if state == "Stopped" {
+		log.WithField("vm_id", vmID).Info("VM already powered off")
+		return nil
+	}
+
+	if err := servers.Stop(ctx, c.compute, vmID).ExtractErr(); err != nil {
+		return fmt.Errorf("failed to power off VM %s: %w", vmID, err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+	if err := servers.WaitForStatus(waitCtx, c.compute, vmID, "SHUTOFF"); err != nil {
+		return fmt.Errorf("VM %s did not shut off: %w", vmID, err)
+	}
+
+	log.WithField("vm_id", vmID).Info("✅ VM powered off successfully")
+	return nil
+}
+
+// DeleteVM deletes a server from Nova
+func (c *Client) DeleteVM(ctx context.Context, vmID string) error {
+	log.WithField("vm_id", vmID).Info("Deleting VM from OpenStack")
+
+	if err := servers.Delete(ctx, c.compute, vmID).ExtractErr(); err != nil {
+		return fmt.Errorf("failed to delete VM %s: %w", vmID, err)
+	}
+
+	log.WithField("vm_id", vmID).Info("✅ VM deleted successfully")
+	return nil
+}
+
+// ApplianceVMID returns the configured appliance server ID, or the ID of the server the
+// daemon runs on from the Nova metadata service
+func (c *Client) ApplianceVMID(ctx context.Context) (string, error) {
+	if c.config.ApplianceID != "" {
+		return c.config.ApplianceID, nil
+	}
+
+	metaCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(metaCtx, http.MethodGet, metadataURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to query Nova metadata service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var metadata struct {
+		UUID string `json:"uuid"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return "", fmt.Errorf("failed to decode Nova metadata: %w", err)
+	}
+	if metadata.UUID == "" {
+		return "", errors.New("Nova metadata has no server UUID")
+	}
+
+	return metadata.UUID, nil
+}
+
+// ResolveDevice resolves an attached volume through its /dev/disk/by-id path. Nova sets
+// the virtio serial to the volume UUID with its hyphens.
+func (c *Client) ResolveDevice(volumeID string, timeout time.Duration) (string, string, error) {
+	return device.GetDeviceBySerial(volumeID, timeout)
+}
+
+// Ping tests connectivity to the Cinder API
+func (c *Client) Ping(ctx context.Context) error {
+	err := volumes.List(c.blockStorage, volumes.ListOpts{Limit: 1}).EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("OpenStack API ping failed: %w", err)
+	}
+
+	log.Debug("OpenStack API connectivity test successful")
+	return nil
+}
+
+// waitForVolumeStatus waits for a volume to reach a status, failing fast on error states
+func (c *Client) waitForVolumeStatus(ctx context.Context, volumeID, status string) error {
+	waitCtx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	err := gophercloud.WaitFor(waitCtx, func(ctx context.Context) (bool, error) {
+		volume, err := volumes.Get(ctx, c.blockStorage, volumeID).Extract()
+		if err != nil {
+			return false, err
+		}
+		if strings.HasPrefix(volume.Status, "error") {
+			return false, fmt.Errorf("volume entered status %s", volume.Status)
+		}
+		return volume.Status == status, nil
+	})
+	if err != nil {
+		return fmt.Errorf("volume %s did not reach status %s: %w", volumeID, status, err)
+	}
+	return nil
+}
+
+// attachedServer returns the server a volume is attached to, if any
+func attachedServer(volume *volumes.Volume) string {
+	if len(volume.Attachments) == 0 {
+		return ""
+	}
+	return volume.Attachments[0].ServerID
+}
+
+// volumeToMap converts a Cinder volume to the generic map shape of CloudStack volumes
+func volumeToMap(volume *volumes.Volume) map[string]interface{} {
+	result := map[string]interface{}{
+		"id":               volume.ID,
+		"name":             volume.Name,
+		"size":             int64(volume.Size) * 1024 * 1024 * 1024,
+		"state":            volume.Status,
+		"type":             "DATADISK",
+		"zoneid":           volume.AvailabilityZone,
+		"zonename":         volume.AvailabilityZone,
+		"diskofferingid":   volume.VolumeType,
+		"diskofferingname": volume.VolumeType,
+		"created":          volume.CreatedAt.Format(time.RFC3339),
+		"virtualmachineid": attachedServer(volume),
+	}
+	if volume.Bootable == "true" {
+		result["type"] = "ROOT"
+	}
+	if len(volume.Attachments) > 0 {
+		result["attached"] = volume.Attachments[0].AttachedAt.Format(time.RFC3339)
+		result["device"] = volume.Attachments[0].Device
+	}
+	return result
+}
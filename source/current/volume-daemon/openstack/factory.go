@@ -0,0 +1,70 @@
+// Package openstack implements the volume provider for OpenStack destinations:
+// Cinder volumes attached to Nova servers
+package openstack
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vexxhost/migratekit-volume-daemon/service"
+)
+
+// Config represents OpenStack connection and placement configuration
+type Config struct {
+	Region           string `json:"region"`
+	Insecure         bool   `json:"insecure"`
+	ApplianceID      string `json:"appliance_id"`      // Nova server ID of the SHA appliance
+	VolumeType       string `json:"volume_type"`       // Default Cinder volume type
+	AvailabilityZone string `json:"availability_zone"` // Default Cinder availability zone
+}
+
+// ConfigFromEnv reads the configuration from the standard OS_* environment variables
+// (credentials are read by gophercloud when the client authenticates)
+func ConfigFromEnv() Config {
+	return Config{
+		Region:           os.Getenv("OS_REGION_NAME"),
+		Insecure:         os.Getenv("OS_INSECURE") == "true",
+		ApplianceID:      os.Getenv("OS_APPLIANCE_SERVER_ID"),
+		VolumeType:       os.Getenv("OS_VOLUME_TYPE"),
+		AvailabilityZone: os.Getenv("OS_AVAILABILITY_ZONE"),
+	}
+}
+
+// Factory creates OpenStack providers from environment configuration
+type Factory struct {
+	config Config
+}
+
+// NewFactory creates a new OpenStack provider factory
+func NewFactory(config Config) *Factory {
+	return &Factory{config: config}
+}
+
+// CreateProvider authenticates against Keystone and returns a provider
+func (f *Factory) CreateProvider(ctx context.Context) (service.VolumeProvider, error) {
+	opts, err := openstack.AuthOptionsFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenStack credentials: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"auth_url": opts.IdentityEndpoint,
+		"region":   f.config.Region,
+	}).Info("Creating OpenStack client from environment configuration")
+
+	return NewClient(ctx, opts, f.config)
+}
+
+// TestConnection tests the connection to OpenStack
+func (f *Factory) TestConnection(ctx context.Context) error {
+	client, err := f.CreateProvider(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create OpenStack client: %w", err)
+	}
+
+	return client.Ping(ctx)
+}
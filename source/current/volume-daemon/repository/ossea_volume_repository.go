@@ -112,6 +112,44 @@ func (r *OSSEAVolumeRepository) UpdateVolumeDetachment(ctx context.Context, volu
 	return nil
 }
 
+// UpdateVolumeSize updates ossea_volumes table when a volume is resized
+func (r *OSSEAVolumeRepository) UpdateVolumeSize(ctx context.Context, volumeID string, sizeGB int) error {
+	query := `
+		UPDATE ossea_volumes
+		SET size_gb = ?, updated_at = NOW()
+		WHERE volume_id = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, sizeGB, volumeID)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"volume_id": volumeID,
+			"size_gb":   sizeGB,
+			"error":     err,
+		}).Error("Failed to update ossea_volumes on volume resize")
+		return fmt.Errorf("failed to update ossea_volumes size: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		log.WithFields(log.Fields{
+			"volume_id": volumeID,
+		}).Warn("No ossea_volumes record found to update - volume may not be tracked")
+		return nil // Non-fatal - volume might not be part of a migration
+	}
+
+	log.WithFields(log.Fields{
+		"volume_id": volumeID,
+		"size_gb":   sizeGB,
+	}).Info("✅ Updated ossea_volumes on volume resize")
+
+	return nil
+}
+
 // GetVolumeByID retrieves an ossea_volumes record by volume_id
 func (r *OSSEAVolumeRepository) GetVolumeByID(ctx context.Context, volumeID string) (*OSSEAVolume, error) {
 	query := `
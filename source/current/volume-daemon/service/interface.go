@@ -8,7 +8,7 @@ import (
 )
 
 // VolumeManagementService is the core interface for centralized volume management
-// ALL volume operations MUST go through this service - no direct CloudStack API calls allowed
+// ALL volume operations MUST go through this service - no direct destination cloud API calls allowed
 type VolumeManagementService interface {
 	// Volume Operations (ONLY way to interact with CloudStack volumes)
 	CreateVolume(ctx context.Context, req models.CreateVolumeRequest) (*models.VolumeOperation, error)
@@ -16,6 +16,7 @@ type VolumeManagementService interface {
 	AttachVolumeAsRoot(ctx context.Context, volumeID, vmID string) (*models.VolumeOperation, error)
 	DetachVolume(ctx context.Context, volumeID string) (*models.VolumeOperation, error)
 	DeleteVolume(ctx context.Context, volumeID string) (*models.VolumeOperation, error)
+	ResizeVolume(ctx context.Context, volumeID string, sizeBytes int64) (*models.VolumeOperation, error)
 
 	// Cleanup Operations
 	CleanupTestFailover(ctx context.Context, req models.CleanupRequest) (*models.VolumeOperation, error)
@@ -31,6 +32,11 @@ type VolumeManagementService interface {
 	ListOperations(ctx context.Context, filter models.OperationFilter) ([]models.VolumeOperation, error)
 	WaitForOperation(ctx context.Context, operationID string, timeout time.Duration) (*models.VolumeOperation, error)
 
+	// Operation Events (lifecycle events streamed instead of polling operations)
+	ListOperationEvents(ctx context.Context, filter models.OperationEventFilter) ([]models.OperationEvent, error)
+	SubscribeOperationEvents(filter models.OperationEventFilter) *OperationEventSubscription
+	UnsubscribeOperationEvents(sub *OperationEventSubscription)
+
 	// NBD Export Management (NEW - integrated with volume lifecycle)
 	CreateNBDExport(ctx context.Context, volumeID, vmName, vmID string, diskNumber int) (*models.NBDExportInfo, error)
 	DeleteNBDExport(ctx context.Context, volumeID string) error
@@ -54,20 +60,32 @@ type VolumeManagementService interface {
 	Stop(ctx context.Context) error
 }
 
-// CloudStackFactory defines the interface for creating CloudStack clients
-type CloudStackFactory interface {
-	CreateClient(ctx context.Context) (CloudStackClient, error)
+// Destination cloud providers a VolumeProvider can be backed by
+const (
+	ProviderCloudStack = "cloudstack"
+	ProviderOpenStack  = "openstack"
+	ProviderLocal      = "local"
+)
+
+// ProviderFactory creates volume providers from the current destination configuration
+type ProviderFactory interface {
+	CreateProvider(ctx context.Context) (VolumeProvider, error)
 	TestConnection(ctx context.Context) error
 }
 
-// CloudStackClient defines the interface for CloudStack operations
-type CloudStackClient interface {
+// VolumeProvider defines the destination cloud operations behind the volume service.
+// Implementations exist for CloudStack, OpenStack (Cinder/Nova) and local LVM/libvirt.
+type VolumeProvider interface {
+	// Type returns the provider name (ProviderCloudStack, ProviderOpenStack, ProviderLocal)
+	Type() string
+
 	// Volume operations
 	CreateVolume(ctx context.Context, req models.CreateVolumeRequest) (string, error)
 	AttachVolume(ctx context.Context, volumeID, vmID string) error
 	AttachVolumeAsRoot(ctx context.Context, volumeID, vmID string) error
 	DetachVolume(ctx context.Context, volumeID string) error
 	DeleteVolume(ctx context.Context, volumeID string) error
+	ResizeVolume(ctx context.Context, volumeID string, sizeBytes int64) error
 
 	// Volume queries
 	GetVolume(ctx context.Context, volumeID string) (map[string]interface{}, error)
@@ -79,6 +97,14 @@ type CloudStackClient interface {
 	PowerOffVM(ctx context.Context, vmID string) error
 	DeleteVM(ctx context.Context, vmID string) error
 
+	// Device correlation
+	// ApplianceVMID returns the ID of the appliance VM the daemon runs on; volumes
+	// attached to it get a local device and an NBD export
+	ApplianceVMID(ctx context.Context) (string, error)
+	// ResolveDevice waits for an attached volume's block device on the appliance and
+	// returns its stable path (used for mappings and NBD exports) and current device path
+	ResolveDevice(volumeID string, timeout time.Duration) (stablePath string, devicePath string, err error)
+
 	// Health check
 	Ping(ctx context.Context) error
 }
@@ -131,6 +157,10 @@ type VolumeRepository interface {
 	GetOperation(ctx context.Context, operationID string) (*models.VolumeOperation, error)
 	ListOperations(ctx context.Context, filter models.OperationFilter) ([]models.VolumeOperation, error)
 
+	// Operation lifecycle events
+	CreateOperationEvent(ctx context.Context, event *models.OperationEvent) error
+	ListOperationEvents(ctx context.Context, filter models.OperationEventFilter) ([]models.OperationEvent, error)
+
 	// Device mapping management
 	CreateMapping(ctx context.Context, mapping *models.DeviceMapping) error
 	UpdateMapping(ctx context.Context, mapping *models.DeviceMapping) error
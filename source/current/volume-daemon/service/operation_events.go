@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vexxhost/migratekit-volume-daemon/models"
+)
+
+// operationEventBuffer is how many events may queue for one subscriber before it is
+// dropped; it resumes from the database with its last event ID
+const operationEventBuffer = 256
+
+// OperationEventSubscription receives the operation events matching its filter until it
+// is unsubscribed. Events is closed when the subscriber falls too far behind.
+type OperationEventSubscription struct {
+	Events <-chan models.OperationEvent
+
+	events chan models.OperationEvent
+	filter models.OperationEventFilter
+}
+
+// OperationEventHub fans recorded operation events out to live subscribers. The
+// database holds the history; the hub only carries events published after subscribing.
+type OperationEventHub struct {
+	mu          sync.Mutex
+	subscribers map[*OperationEventSubscription]struct{}
+}
+
+// NewOperationEventHub creates an operation event hub
+func NewOperationEventHub() *OperationEventHub {
+	return &OperationEventHub{
+		subscribers: make(map[*OperationEventSubscription]struct{}),
+	}
+}
+
+// Publish delivers an event to matching subscribers; it never blocks
+func (h *OperationEventHub) Publish(event models.OperationEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers {
+		if !sub.filter.Matches(&event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// Drop slow subscribers rather than stall volume operations
+			log.WithField("event_id", event.ID).Warn("Operation event subscriber fell behind - closing its stream")
+			delete(h.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// Subscribe starts a subscription to events published from now on
+func (h *OperationEventHub) Subscribe(filter models.OperationEventFilter) *OperationEventSubscription {
+	events := make(chan models.OperationEvent, operationEventBuffer)
+	sub := &OperationEventSubscription{
+		Events: events,
+		events: events,
+		filter: filter,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe ends a subscription
+func (h *OperationEventHub) Unsubscribe(sub *OperationEventSubscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, exists := h.subscribers[sub]; exists {
+		delete(h.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// publishOperationEvent records an operation lifecycle event and pushes it to subscribers.
+// A failed insert is logged and the event still goes out live, without a cursor ID.
+func (vs *VolumeService) publishOperationEvent(ctx context.Context, operation *models.VolumeOperation, eventType models.OperationEventType, data map[string]interface{}) {
+	event := models.OperationEvent{
+		OperationID:   operation.ID,
+		OperationType: operation.Type,
+		EventType:     eventType,
+		Status:        operation.Status,
+		VolumeID:      operation.VolumeID,
+		VMID:          operation.VMID,
+		Data:          data,
+		Error:         operation.Error,
+		CreatedAt:     time.Now(),
+	}
+
+	if err := vs.repo.CreateOperationEvent(ctx, &event); err != nil {
+		log.WithFields(log.Fields{
+			"operation_id": operation.ID,
+			"event_type":   eventType,
+			"error":        err,
+		}).Warn("Failed to record operation event - publishing without cursor")
+	}
+
+	vs.eventHub.Publish(event)
+}
+
+// ListOperationEvents returns recorded operation events after the filter cursor
+func (vs *VolumeService) ListOperationEvents(ctx context.Context, filter models.OperationEventFilter) ([]models.OperationEvent, error) {
+	return vs.repo.ListOperationEvents(ctx, filter)
+}
+
+// SubscribeOperationEvents subscribes to operation events published from now on
+func (vs *VolumeService) SubscribeOperationEvents(filter models.OperationEventFilter) *OperationEventSubscription {
+	return vs.eventHub.Subscribe(filter)
+}
+
+// UnsubscribeOperationEvents ends an operation event subscription
+func (vs *VolumeService) UnsubscribeOperationEvents(sub *OperationEventSubscription) {
+	vs.eventHub.Unsubscribe(sub)
+}
+
+// WaitForOperation waits for an operation to complete or fail, woken by its terminal event
+func (vs *VolumeService) WaitForOperation(ctx context.Context, operationID string, timeout time.Duration) (*models.VolumeOperation, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Subscribe before reading the operation so its terminal event cannot be missed
+	sub := vs.eventHub.Subscribe(models.OperationEventFilter{OperationID: operationID})
+	defer func() { vs.eventHub.Unsubscribe(sub) }()
+
+	for {
+		operation, err := vs.GetOperation(ctx, operationID)
+		if err != nil {
+			return nil, err
+		}
+		if operation.Status == models.StatusCompleted || operation.Status == models.StatusFailed || operation.Status == models.StatusCancelled {
+			return operation, nil
+		}
+
+		dropped, err := waitForTerminalEvent(ctx, sub)
+		if err != nil {
+			return operation, fmt.Errorf("timeout waiting for operation %s: %w", operationID, err)
+		}
+		if dropped {
+			// Dropped as a slow subscriber - resubscribe and re-read the operation
+			sub = vs.eventHub.Subscribe(models.OperationEventFilter{OperationID: operationID})
+		}
+	}
+}
+
+// waitForTerminalEvent returns once a terminal event arrives or the hub drops the
+// subscription (dropped = true)
+func waitForTerminalEvent(ctx context.Context, sub *OperationEventSubscription) (bool, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case event, ok := <-sub.Events:
+			if !ok {
+				return true, nil
+			}
+			if event.EventType.IsTerminal() {
+				return false, nil
+			}
+		}
+	}
+}
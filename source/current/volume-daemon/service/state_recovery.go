@@ -13,7 +13,7 @@ import (
 // StateRecoveryService provides mechanisms to recover lost Volume Daemon device mappings
 type StateRecoveryService struct {
 	repo                VolumeRepository
-	cloudStackClient    CloudStackClient
+	provider            VolumeProvider
 	deviceMonitor       DeviceMonitor
 	maxRecoveryAttempts int
 	recoveryTimeout     time.Duration
@@ -22,12 +22,12 @@ type StateRecoveryService struct {
 // NewStateRecoveryService creates a new state recovery service
 func NewStateRecoveryService(
 	repo VolumeRepository,
-	cloudStackClient CloudStackClient,
+	provider VolumeProvider,
 	deviceMonitor DeviceMonitor,
 ) *StateRecoveryService {
 	return &StateRecoveryService{
 		repo:                repo,
-		cloudStackClient:    cloudStackClient,
+		provider:            provider,
 		deviceMonitor:       deviceMonitor,
 		maxRecoveryAttempts: 3,
 		recoveryTimeout:     2 * time.Minute,
@@ -19,27 +19,29 @@ import (
 // VolumeService implements the VolumeManagementService interface
 type VolumeService struct {
 	repo                    VolumeRepository
-	cloudStackFactory       CloudStackFactory        // 🆕 NEW: Factory for dynamic client creation
+	providerFactory         ProviderFactory          // Destination cloud provider, created per operation
 	deviceMonitor           DeviceMonitor
 	nbdExportManager        *nbd.ExportManager
 	osseaVolumeRepo         *repository.OSSEAVolumeRepository
 	persistentDeviceManager *PersistentDeviceManager // 🆕 NEW: Persistent device naming
+	eventHub                *OperationEventHub       // Live operation lifecycle events
 }
 
 // NewVolumeService creates a new volume management service
-func NewVolumeService(repo VolumeRepository, cloudStackFactory CloudStackFactory, deviceMonitor DeviceMonitor, nbdExportManager *nbd.ExportManager, osseaVolumeRepo *repository.OSSEAVolumeRepository) VolumeManagementService {
+func NewVolumeService(repo VolumeRepository, providerFactory ProviderFactory, deviceMonitor DeviceMonitor, nbdExportManager *nbd.ExportManager, osseaVolumeRepo *repository.OSSEAVolumeRepository) VolumeManagementService {
 	// 🆕 NEW: Initialize persistent device manager
 	persistentDeviceManager := NewPersistentDeviceManager(repo)
 
-	log.Printf("🔧 DEBUG: NewVolumeService - Using dynamic CloudStack client creation (no caching)")
+	log.Printf("🔧 DEBUG: NewVolumeService - Using dynamic volume provider creation (no caching)")
 
 	return &VolumeService{
 		repo:                    repo,
-		cloudStackFactory:       cloudStackFactory,
+		providerFactory:         providerFactory,
 		deviceMonitor:           deviceMonitor,
 		nbdExportManager:        nbdExportManager,
 		osseaVolumeRepo:         osseaVolumeRepo,
 		persistentDeviceManager: persistentDeviceManager,
+		eventHub:                NewOperationEventHub(),
 	}
 }
 
@@ -70,8 +72,9 @@ func (vs *VolumeService) CreateVolume(ctx context.Context, req models.CreateVolu
 	if err := vs.repo.CreateOperation(ctx, operation); err != nil {
 		return nil, fmt.Errorf("failed to create operation record: %w", err)
 	}
+	vs.publishOperationEvent(ctx, operation, models.EventOperationCreated, operation.Request)
 
-	// Execute CloudStack volume creation in background
+	// Execute provider volume creation in background
 	go vs.executeCreateVolume(context.Background(), operation, req)
 
 	return operation, nil
@@ -101,8 +104,9 @@ func (vs *VolumeService) AttachVolume(ctx context.Context, volumeID, vmID string
 	if err := vs.repo.CreateOperation(ctx, operation); err != nil {
 		return nil, fmt.Errorf("failed to create operation record: %w", err)
 	}
+	vs.publishOperationEvent(ctx, operation, models.EventOperationCreated, operation.Request)
 
-	// Execute CloudStack volume attachment in background
+	// Execute provider volume attachment in background
 	go vs.executeAttachVolume(context.Background(), operation, volumeID, vmID)
 
 	return operation, nil
@@ -134,8 +138,9 @@ func (vs *VolumeService) AttachVolumeAsRoot(ctx context.Context, volumeID, vmID
 	if err := vs.repo.CreateOperation(ctx, operation); err != nil {
 		return nil, fmt.Errorf("failed to create operation record: %w", err)
 	}
+	vs.publishOperationEvent(ctx, operation, models.EventOperationCreated, operation.Request)
 
-	// Execute CloudStack volume attachment as root in background
+	// Execute provider volume attachment as root in background
 	go vs.executeAttachVolumeAsRoot(context.Background(), operation, volumeID, vmID)
 
 	return operation, nil
@@ -163,8 +168,9 @@ func (vs *VolumeService) DetachVolume(ctx context.Context, volumeID string) (*mo
 	if err := vs.repo.CreateOperation(ctx, operation); err != nil {
 		return nil, fmt.Errorf("failed to create operation record: %w", err)
 	}
+	vs.publishOperationEvent(ctx, operation, models.EventOperationCreated, operation.Request)
 
-	// Execute CloudStack volume detachment in background
+	// Execute provider volume detachment in background
 	go vs.executeDetachVolume(context.Background(), operation, volumeID)
 
 	return operation, nil
@@ -192,13 +198,49 @@ func (vs *VolumeService) DeleteVolume(ctx context.Context, volumeID string) (*mo
 	if err := vs.repo.CreateOperation(ctx, operation); err != nil {
 		return nil, fmt.Errorf("failed to create operation record: %w", err)
 	}
+	vs.publishOperationEvent(ctx, operation, models.EventOperationCreated, operation.Request)
 
-	// Execute CloudStack volume deletion in background
+	// Execute provider volume deletion in background
 	go vs.executeDeleteVolume(context.Background(), operation, volumeID)
 
 	return operation, nil
 }
 
+// ResizeVolume grows a volume, e.g. after its source disk was extended
+func (vs *VolumeService) ResizeVolume(ctx context.Context, volumeID string, sizeBytes int64) (*models.VolumeOperation, error) {
+	if sizeBytes <= 0 {
+		return nil, fmt.Errorf("invalid volume size: %d", sizeBytes)
+	}
+
+	// Generate operation ID
+	operationID := uuid.New().String()
+
+	// Create operation record
+	operation := &models.VolumeOperation{
+		ID:       operationID,
+		Type:     models.OperationResize,
+		Status:   models.StatusPending,
+		VolumeID: volumeID,
+		Request: map[string]interface{}{
+			"volume_id": volumeID,
+			"size":      sizeBytes,
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	// Store operation
+	if err := vs.repo.CreateOperation(ctx, operation); err != nil {
+		return nil, fmt.Errorf("failed to create operation record: %w", err)
+	}
+	vs.publishOperationEvent(ctx, operation, models.EventOperationCreated, operation.Request)
+
+	// Execute provider volume resize in background
+	go vs.executeResizeVolume(context.Background(), operation, volumeID, sizeBytes)
+
+	return operation, nil
+}
+
 // CleanupTestFailover orchestrates complete test failover cleanup
 func (vs *VolumeService) CleanupTestFailover(ctx context.Context, req models.CleanupRequest) (*models.VolumeOperation, error) {
 	// Generate operation ID
@@ -226,6 +268,7 @@ func (vs *VolumeService) CleanupTestFailover(ctx context.Context, req models.Cle
 	if err := vs.repo.CreateOperation(ctx, operation); err != nil {
 		return nil, fmt.Errorf("failed to create cleanup operation record: %w", err)
 	}
+	vs.publishOperationEvent(ctx, operation, models.EventOperationCreated, operation.Request)
 
 	// Execute cleanup workflow in background
 	go vs.executeCleanupTestFailover(context.Background(), operation, req)
@@ -297,12 +340,6 @@ func (vs *VolumeService) ListOperations(ctx context.Context, filter models.Opera
 	return vs.repo.ListOperations(ctx, filter)
 }
 
-// WaitForOperation waits for an operation to complete (placeholder implementation)
-func (vs *VolumeService) WaitForOperation(ctx context.Context, operationID string, timeout time.Duration) (*models.VolumeOperation, error) {
-	// TODO: Implement proper waiting logic with polling
-	return vs.GetOperation(ctx, operationID)
-}
-
 // GetHealth returns the health status of the service
 func (vs *VolumeService) GetHealth(ctx context.Context) (*models.HealthStatus, error) {
 	// Test database connectivity
@@ -359,41 +396,43 @@ func (vs *VolumeService) Stop(ctx context.Context) error {
 	return nil
 }
 
-// createFreshCloudStackClient creates a fresh CloudStack client with current database config
-func (vs *VolumeService) createFreshCloudStackClient(ctx context.Context) (CloudStackClient, error) {
-	log.Debug("🔄 Creating fresh CloudStack client from current database config")
-	
-	client, err := vs.cloudStackFactory.CreateClient(ctx)
+// createFreshProvider creates a fresh volume provider with the current destination config
+func (vs *VolumeService) createFreshProvider(ctx context.Context) (VolumeProvider, error) {
+	log.Debug("🔄 Creating fresh volume provider from current config")
+
+	provider, err := vs.providerFactory.CreateProvider(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create fresh CloudStack client: %w", err)
+		return nil, fmt.Errorf("failed to create fresh volume provider: %w", err)
 	}
-	
-	log.Debug("✅ Fresh CloudStack client created with current config")
-	return client, nil
+
+	log.WithField("provider", provider.Type()).Debug("✅ Fresh volume provider created with current config")
+	return provider, nil
 }
 
-// executeCreateVolume executes the CloudStack volume creation operation
+// executeCreateVolume executes the volume creation operation
 func (vs *VolumeService) executeCreateVolume(ctx context.Context, operation *models.VolumeOperation, req models.CreateVolumeRequest) {
 	// Update operation status to executing
 	operation.Status = models.StatusExecuting
 	operation.UpdatedAt = time.Now()
 	vs.repo.UpdateOperation(ctx, operation)
 
-	// 🆕 NEW: Create fresh CloudStack client with current database config
-	cloudStackClient, err := vs.createFreshCloudStackClient(ctx)
+	// Create fresh volume provider with current destination config
+	provider, err := vs.createFreshProvider(ctx)
 	if err != nil {
-		log.Printf("❌ ERROR: Failed to create CloudStack client for operation %s: %v", operation.ID, err)
-		vs.completeOperationWithError(ctx, operation, fmt.Errorf("CloudStack client creation failed: %w", err))
+		log.Printf("❌ ERROR: Failed to create volume provider for operation %s: %v", operation.ID, err)
+		vs.completeOperationWithError(ctx, operation, fmt.Errorf("volume provider creation failed: %w", err))
 		return
 	}
-	log.Printf("✅ DEBUG: Fresh CloudStack client created for operation %s", operation.ID)
+	log.Printf("✅ DEBUG: Fresh %s provider created for operation %s", provider.Type(), operation.ID)
 
-	// Create volume in CloudStack using fresh client
-	volumeID, err := cloudStackClient.CreateVolume(ctx, req)
+	// Create volume using fresh provider
+	volumeID, err := provider.CreateVolume(ctx, req)
 	if err != nil {
-		vs.completeOperationWithError(ctx, operation, fmt.Errorf("CloudStack volume creation failed: %w", err))
+		vs.completeOperationWithError(ctx, operation, fmt.Errorf("%s volume creation failed: %w", provider.Type(), err))
 		return
 	}
+	operation.VolumeID = volumeID
+	vs.publishOperationEvent(ctx, operation, models.EventProviderSubmitted, map[string]interface{}{"provider": provider.Type()})
 
 	// Update operation with successful result
 	operation.Status = models.StatusCompleted
@@ -413,28 +452,30 @@ func (vs *VolumeService) executeCreateVolume(ctx context.Context, operation *mod
 			"error":        err,
 		}).Error("Failed to update operation after successful volume creation")
 	}
+	vs.publishOperationEvent(ctx, operation, models.EventOperationCompleted, operation.Response)
 }
 
-// executeAttachVolume executes the CloudStack volume attachment operation
+// executeAttachVolume executes the volume attachment operation
 func (vs *VolumeService) executeAttachVolume(ctx context.Context, operation *models.VolumeOperation, volumeID, vmID string) {
 	// Update operation status to executing
 	operation.Status = models.StatusExecuting
 	operation.UpdatedAt = time.Now()
 	vs.repo.UpdateOperation(ctx, operation)
 
-	// 🆕 NEW: Create fresh CloudStack client with current database config
-	cloudStackClient, err := vs.createFreshCloudStackClient(ctx)
+	// Create fresh volume provider with current destination config
+	provider, err := vs.createFreshProvider(ctx)
 	if err != nil {
-		vs.completeOperationWithError(ctx, operation, fmt.Errorf("CloudStack client creation failed: %w", err))
+		vs.completeOperationWithError(ctx, operation, fmt.Errorf("volume provider creation failed: %w", err))
 		return
 	}
 
-	// Attach volume in CloudStack using fresh client
-	err = cloudStackClient.AttachVolume(ctx, volumeID, vmID)
+	// Attach volume using fresh provider
+	err = provider.AttachVolume(ctx, volumeID, vmID)
 	if err != nil {
-		vs.completeOperationWithError(ctx, operation, fmt.Errorf("CloudStack volume attachment failed: %w", err))
+		vs.completeOperationWithError(ctx, operation, fmt.Errorf("%s volume attachment failed: %w", provider.Type(), err))
 		return
 	}
+	vs.publishOperationEvent(ctx, operation, models.EventProviderSubmitted, map[string]interface{}{"provider": provider.Type()})
 
 	// Determine operation mode and handle device correlation accordingly
 	var devicePath string
@@ -444,7 +485,7 @@ func (vs *VolumeService) executeAttachVolume(ctx context.Context, operation *mod
 	var requiresCorrelation bool
 
 	// Check if this is an SHA attachment (where device correlation is possible)
-	isOMAAttachment := vs.isOMAVM(ctx, vmID)
+	isOMAAttachment := vs.isOMAVM(ctx, provider, vmID)
 
 	if isOMAAttachment {
 		// SHA Mode: by-id device resolution
@@ -458,7 +499,7 @@ func (vs *VolumeService) executeAttachVolume(ctx context.Context, operation *mod
 		}).Info("🔗 SHA attachment - resolving device via by-id path")
 
 		// 🆕 NEW: Use by-id resolution instead of complex correlation
-		byIDPath, actualDevice, err := device.GetDeviceByVolumeID(volumeID, 10*time.Second)
+		byIDPath, actualDevice, err := provider.ResolveDevice(volumeID, 10*time.Second)
 		if err != nil {
 			vs.completeOperationWithError(ctx, operation, fmt.Errorf("by-id device resolution failed: %w", err))
 			return
@@ -466,6 +507,10 @@ func (vs *VolumeService) executeAttachVolume(ctx context.Context, operation *mod
 
 		// Use by-id path as device_path (stable across reboots)
 		devicePath = byIDPath
+		vs.publishOperationEvent(ctx, operation, models.EventDeviceDetected, map[string]interface{}{
+			"device_path":   byIDPath,
+			"actual_device": actualDevice,
+		})
 		
 		// Get device size from actual device
 		if size, err := device.GetDeviceSize(actualDevice); err == nil {
@@ -537,7 +582,13 @@ func (vs *VolumeService) executeAttachVolume(ctx context.Context, operation *mod
 				"by_id_path":  devicePath, // devicePath is now the by-id path
 			}).Info("🔗 Creating NBD export with stable by-id path")
 			
-			vs.createNBDExportForVolume(ctx, volumeID, vmID, devicePath)
+			if exportInfo := vs.createNBDExportForVolume(ctx, volumeID, vmID, devicePath); exportInfo != nil {
+				vs.publishOperationEvent(ctx, operation, models.EventExportCreated, map[string]interface{}{
+					"export_name": exportInfo.ExportName,
+					"device_path": exportInfo.DevicePath,
+					"port":        exportInfo.Port,
+				})
+			}
 		}
 	}
 
@@ -561,28 +612,30 @@ func (vs *VolumeService) executeAttachVolume(ctx context.Context, operation *mod
 			"error":        err,
 		}).Error("Failed to update operation after successful volume attachment")
 	}
+	vs.publishOperationEvent(ctx, operation, models.EventOperationCompleted, operation.Response)
 }
 
-// executeAttachVolumeAsRoot executes the CloudStack volume attachment as root disk operation
+// executeAttachVolumeAsRoot executes the volume attachment as root disk operation
 func (vs *VolumeService) executeAttachVolumeAsRoot(ctx context.Context, operation *models.VolumeOperation, volumeID, vmID string) {
 	// Update operation status to executing
 	operation.Status = models.StatusExecuting
 	operation.UpdatedAt = time.Now()
 	vs.repo.UpdateOperation(ctx, operation)
 
-	// 🆕 NEW: Create fresh CloudStack client with current database config
-	cloudStackClient, err := vs.createFreshCloudStackClient(ctx)
+	// Create fresh volume provider with current destination config
+	provider, err := vs.createFreshProvider(ctx)
 	if err != nil {
-		vs.completeOperationWithError(ctx, operation, fmt.Errorf("CloudStack client creation failed: %w", err))
+		vs.completeOperationWithError(ctx, operation, fmt.Errorf("volume provider creation failed: %w", err))
 		return
 	}
 
-	// Attach volume as root disk in CloudStack using fresh client
-	err = cloudStackClient.AttachVolumeAsRoot(ctx, volumeID, vmID)
+	// Attach volume as root disk using fresh provider
+	err = provider.AttachVolumeAsRoot(ctx, volumeID, vmID)
 	if err != nil {
-		vs.completeOperationWithError(ctx, operation, fmt.Errorf("CloudStack volume root attachment failed: %w", err))
+		vs.completeOperationWithError(ctx, operation, fmt.Errorf("%s volume root attachment failed: %w", provider.Type(), err))
 		return
 	}
+	vs.publishOperationEvent(ctx, operation, models.EventProviderSubmitted, map[string]interface{}{"provider": provider.Type()})
 
 	// Determine operation mode and handle device correlation accordingly
 	var devicePath string
@@ -592,7 +645,7 @@ func (vs *VolumeService) executeAttachVolumeAsRoot(ctx context.Context, operatio
 	var requiresCorrelation bool
 
 	// Check if this is an SHA attachment (where device correlation is possible)
-	isOMAAttachment := vs.isOMAVM(ctx, vmID)
+	isOMAAttachment := vs.isOMAVM(ctx, provider, vmID)
 
 	if isOMAAttachment {
 		// SHA Mode: by-id device resolution for root disk
@@ -610,7 +663,7 @@ func (vs *VolumeService) executeAttachVolumeAsRoot(ctx context.Context, operatio
 		}).Info("🔗 SHA root attachment - resolving device via by-id path")
 
 		// 🆕 NEW: Use by-id resolution for root disk
-		byIDPath, actualDevice, err := device.GetDeviceByVolumeID(volumeID, 10*time.Second)
+		byIDPath, actualDevice, err := provider.ResolveDevice(volumeID, 10*time.Second)
 		if err != nil {
 			vs.completeOperationWithError(ctx, operation, fmt.Errorf("by-id device resolution failed for root disk: %w", err))
 			return
@@ -618,6 +671,10 @@ func (vs *VolumeService) executeAttachVolumeAsRoot(ctx context.Context, operatio
 
 		// Use by-id path as device_path (stable across reboots)
 		devicePath = byIDPath
+		vs.publishOperationEvent(ctx, operation, models.EventDeviceDetected, map[string]interface{}{
+			"device_path":   byIDPath,
+			"actual_device": actualDevice,
+		})
 		
 		// Get device size from actual device
 		if size, err := device.GetDeviceSize(actualDevice); err == nil {
@@ -716,7 +773,13 @@ func (vs *VolumeService) executeAttachVolumeAsRoot(ctx context.Context, operatio
 				"device_id":   0,
 			}).Info("🔗 Creating NBD export for root disk with stable by-id path")
 			
-			vs.createNBDExportForVolume(ctx, volumeID, vmID, devicePath)
+			if exportInfo := vs.createNBDExportForVolume(ctx, volumeID, vmID, devicePath); exportInfo != nil {
+				vs.publishOperationEvent(ctx, operation, models.EventExportCreated, map[string]interface{}{
+					"export_name": exportInfo.ExportName,
+					"device_path": exportInfo.DevicePath,
+					"port":        exportInfo.Port,
+				})
+			}
 		}
 	}
 
@@ -741,19 +804,20 @@ func (vs *VolumeService) executeAttachVolumeAsRoot(ctx context.Context, operatio
 			"error":        err,
 		}).Error("Failed to update operation after successful volume root attachment")
 	}
+	vs.publishOperationEvent(ctx, operation, models.EventOperationCompleted, operation.Response)
 }
 
-// executeDetachVolume executes the CloudStack volume detachment operation
+// executeDetachVolume executes the volume detachment operation
 func (vs *VolumeService) executeDetachVolume(ctx context.Context, operation *models.VolumeOperation, volumeID string) {
 	// Update operation status to executing
 	operation.Status = models.StatusExecuting
 	operation.UpdatedAt = time.Now()
 	vs.repo.UpdateOperation(ctx, operation)
 
-	// 🆕 NEW: Create fresh CloudStack client with current database config
-	cloudStackClient, err := vs.createFreshCloudStackClient(ctx)
+	// Create fresh volume provider with current destination config
+	provider, err := vs.createFreshProvider(ctx)
 	if err != nil {
-		vs.completeOperationWithError(ctx, operation, fmt.Errorf("CloudStack client creation failed: %w", err))
+		vs.completeOperationWithError(ctx, operation, fmt.Errorf("volume provider creation failed: %w", err))
 		return
 	}
 
@@ -765,15 +829,16 @@ func (vs *VolumeService) executeDetachVolume(ctx context.Context, operation *mod
 		vmID = mapping.VMID
 	}
 
-	// Detach volume in CloudStack using fresh client
-	err = cloudStackClient.DetachVolume(ctx, volumeID)
+	// Detach volume using fresh provider
+	err = provider.DetachVolume(ctx, volumeID)
 	if err != nil {
-		vs.completeOperationWithError(ctx, operation, fmt.Errorf("CloudStack volume detachment failed: %w", err))
+		vs.completeOperationWithError(ctx, operation, fmt.Errorf("%s volume detachment failed: %w", provider.Type(), err))
 		return
 	}
+	vs.publishOperationEvent(ctx, operation, models.EventProviderSubmitted, map[string]interface{}{"provider": provider.Type()})
 
 	// Remove NBD export ONLY when detaching from SHA VM (prevents double SIGHUP during failover cleanup)
-	if devicePath != "" && !strings.HasPrefix(devicePath, "remote-vm-") && vs.isOMAVM(ctx, vmID) {
+	if devicePath != "" && !strings.HasPrefix(devicePath, "remote-vm-") && vs.isOMAVM(ctx, provider, vmID) {
 		vs.deleteNBDExportForVolume(ctx, volumeID)
 	}
 
@@ -816,19 +881,20 @@ func (vs *VolumeService) executeDetachVolume(ctx context.Context, operation *mod
 			"error":        err,
 		}).Error("Failed to update operation after successful volume detachment")
 	}
+	vs.publishOperationEvent(ctx, operation, models.EventOperationCompleted, operation.Response)
 }
 
-// executeDeleteVolume executes the CloudStack volume deletion operation
+// executeDeleteVolume executes the volume deletion operation
 func (vs *VolumeService) executeDeleteVolume(ctx context.Context, operation *models.VolumeOperation, volumeID string) {
 	// Update operation status to executing
 	operation.Status = models.StatusExecuting
 	operation.UpdatedAt = time.Now()
 	vs.repo.UpdateOperation(ctx, operation)
 
-	// 🆕 NEW: Create fresh CloudStack client with current database config
-	cloudStackClient, err := vs.createFreshCloudStackClient(ctx)
+	// Create fresh volume provider with current destination config
+	provider, err := vs.createFreshProvider(ctx)
 	if err != nil {
-		vs.completeOperationWithError(ctx, operation, fmt.Errorf("CloudStack client creation failed: %w", err))
+		vs.completeOperationWithError(ctx, operation, fmt.Errorf("volume provider creation failed: %w", err))
 		return
 	}
 
@@ -849,15 +915,15 @@ func (vs *VolumeService) executeDeleteVolume(ctx context.Context, operation *mod
 		vmID := mapping.VMID
 		devicePath := mapping.DevicePath
 
-		// Detach volume in CloudStack using fresh client
-		err := cloudStackClient.DetachVolume(ctx, volumeID)
+		// Detach volume using fresh provider
+		err := provider.DetachVolume(ctx, volumeID)
 		if err != nil {
-			vs.completeOperationWithError(ctx, operation, fmt.Errorf("CloudStack volume detachment failed: %w", err))
+			vs.completeOperationWithError(ctx, operation, fmt.Errorf("%s volume detachment failed: %w", provider.Type(), err))
 			return
 		}
 
 		// Remove NBD export (using same logic as executeDetachVolume)
-		if devicePath != "" && !strings.HasPrefix(devicePath, "remote-vm-") && vs.isOMAVM(ctx, vmID) {
+		if devicePath != "" && !strings.HasPrefix(devicePath, "remote-vm-") && vs.isOMAVM(ctx, provider, vmID) {
 			vs.deleteNBDExportForVolume(ctx, volumeID)
 		}
 
@@ -891,12 +957,13 @@ func (vs *VolumeService) executeDeleteVolume(ctx context.Context, operation *mod
 		}).Info("Volume not attached or mapping not found - proceeding directly with deletion")
 	}
 
-	// Step 2: Delete volume in CloudStack using fresh client
-	err = cloudStackClient.DeleteVolume(ctx, volumeID)
+	// Step 2: Delete volume using fresh provider
+	err = provider.DeleteVolume(ctx, volumeID)
 	if err != nil {
-		vs.completeOperationWithError(ctx, operation, fmt.Errorf("CloudStack volume deletion failed: %w", err))
+		vs.completeOperationWithError(ctx, operation, fmt.Errorf("%s volume deletion failed: %w", provider.Type(), err))
 		return
 	}
+	vs.publishOperationEvent(ctx, operation, models.EventProviderSubmitted, map[string]interface{}{"provider": provider.Type()})
 
 	// Step 3: Clean up any remaining mappings (safety cleanup)
 	if err := vs.repo.DeleteMapping(ctx, volumeID); err != nil {
@@ -923,6 +990,7 @@ func (vs *VolumeService) executeDeleteVolume(ctx context.Context, operation *mod
 			"error":        err,
 		}).Error("Failed to update operation after successful volume deletion")
 	}
+	vs.publishOperationEvent(ctx, operation, models.EventOperationCompleted, operation.Response)
 
 	log.WithFields(log.Fields{
 		"volume_id":    volumeID,
@@ -930,6 +998,81 @@ func (vs *VolumeService) executeDeleteVolume(ctx context.Context, operation *mod
 	}).Info("Volume deletion completed successfully with NBD export cleanup")
 }
 
+// executeResizeVolume executes the volume resize operation
+func (vs *VolumeService) executeResizeVolume(ctx context.Context, operation *models.VolumeOperation, volumeID string, sizeBytes int64) {
+	// Update operation status to executing
+	operation.Status = models.StatusExecuting
+	operation.UpdatedAt = time.Now()
+	vs.repo.UpdateOperation(ctx, operation)
+
+	provider, err := vs.createFreshProvider(ctx)
+	if err != nil {
+		vs.completeOperationWithError(ctx, operation, fmt.Errorf("volume provider creation failed: %w", err))
+		return
+	}
+
+	// Providers resize attached volumes in place; the guest sees the new capacity on the same device
+	if err := provider.ResizeVolume(ctx, volumeID, sizeBytes); err != nil {
+		vs.completeOperationWithError(ctx, operation, fmt.Errorf("%s volume resize failed: %w", provider.Type(), err))
+		return
+	}
+	vs.publishOperationEvent(ctx, operation, models.EventProviderSubmitted, map[string]interface{}{"provider": provider.Type()})
+
+	sizeGB := int((sizeBytes + (1024*1024*1024 - 1)) / (1024 * 1024 * 1024))
+	newSize := int64(sizeGB) * 1024 * 1024 * 1024
+
+	// Keep the device mapping size current
+	var devicePath string
+	if mapping, err := vs.repo.GetMapping(ctx, volumeID); err == nil {
+		devicePath = mapping.DevicePath
+		mapping.Size = newSize
+		mapping.LastSync = time.Now()
+		if err := vs.repo.UpdateMapping(ctx, mapping); err != nil {
+			log.WithFields(log.Fields{
+				"volume_id": volumeID,
+				"error":     err,
+			}).Warn("Failed to update device mapping size after volume resize")
+		}
+	}
+
+	// Update ossea_volumes table with the new size
+	if vs.osseaVolumeRepo != nil {
+		if err := vs.osseaVolumeRepo.UpdateVolumeSize(ctx, volumeID, sizeGB); err != nil {
+			log.WithFields(log.Fields{
+				"volume_id": volumeID,
+				"error":     err,
+			}).Warn("Failed to update ossea_volumes on resize - continuing")
+		}
+	}
+
+	// Update operation with successful result
+	operation.Status = models.StatusCompleted
+	now := time.Now()
+	operation.UpdatedAt = now
+	operation.CompletedAt = &now
+	operation.Response = map[string]interface{}{
+		"volume_id":   volumeID,
+		"size":        newSize,
+		"device_path": devicePath,
+		"message":     "Volume resized successfully",
+	}
+
+	if err := vs.repo.UpdateOperation(ctx, operation); err != nil {
+		log.WithFields(log.Fields{
+			"operation_id": operation.ID,
+			"volume_id":    volumeID,
+			"error":        err,
+		}).Error("Failed to update operation after successful volume resize")
+	}
+	vs.publishOperationEvent(ctx, operation, models.EventOperationCompleted, operation.Response)
+
+	log.WithFields(log.Fields{
+		"volume_id":   volumeID,
+		"size_gb":     sizeGB,
+		"device_path": devicePath,
+	}).Info("Volume resize completed successfully")
+}
+
 // completeOperationWithError marks an operation as failed with an error
 func (vs *VolumeService) completeOperationWithError(ctx context.Context, operation *models.VolumeOperation, err error) {
 	operation.Status = models.StatusFailed
@@ -946,6 +1089,7 @@ func (vs *VolumeService) completeOperationWithError(ctx context.Context, operati
 			"update_error":   updateErr,
 		}).Error("Failed to update operation with error status")
 	}
+	vs.publishOperationEvent(ctx, operation, models.EventOperationFailed, nil)
 }
 
 // createSimpleDeviceMapping creates device mapping with by-id path (replaces complex persistent naming)
@@ -997,17 +1141,20 @@ func (vs *VolumeService) createSimpleDeviceMapping(
 }
 
 // NOTE: Old correlation methods removed - replaced with by-id resolution
-// - correlateVolumeToDevice() → device.GetDeviceByVolumeID()
+// - correlateVolumeToDevice() → VolumeProvider.ResolveDevice()
 // - clearDeviceEventsAfterSuccess() → No longer needed
 // - createDeviceMappingWithPersistentNaming() → createSimpleDeviceMapping()
 
 // isOMAVM checks if the given VM ID is the SHA VM (where device correlation is possible)
-func (vs *VolumeService) isOMAVM(ctx context.Context, vmID string) bool {
-	// Get SHA VM ID from active ossea_configs record
-	shaVMID, err := vs.getOMAVMIDFromDatabase(ctx)
-	if err != nil {
-		log.WithError(err).Warn("Failed to get SHA VM ID from database - defaulting to false")
-		return false
+func (vs *VolumeService) isOMAVM(ctx context.Context, provider VolumeProvider, vmID string) bool {
+	shaVMID, err := provider.ApplianceVMID(ctx)
+	if err != nil || shaVMID == "" {
+		// Fall back to the active ossea_configs record
+		shaVMID, err = vs.getOMAVMIDFromDatabase(ctx)
+		if err != nil {
+			log.WithError(err).Warn("Failed to get SHA VM ID from database - defaulting to false")
+			return false
+		}
 	}
 	return vmID == shaVMID
 }
@@ -1049,10 +1196,10 @@ func (vs *VolumeService) executeCleanupTestFailover(ctx context.Context, operati
 		"delete_vm":    req.DeleteVM,
 	}).Info("🧹 Starting test failover cleanup workflow")
 
-	// 🆕 NEW: Create fresh CloudStack client with current database config
-	cloudStackClient, err := vs.createFreshCloudStackClient(ctx)
+	// Create fresh volume provider with current destination config
+	provider, err := vs.createFreshProvider(ctx)
 	if err != nil {
-		vs.completeOperationWithError(ctx, operation, fmt.Errorf("CloudStack client creation failed: %w", err))
+		vs.completeOperationWithError(ctx, operation, fmt.Errorf("volume provider creation failed: %w", err))
 		return
 	}
 
@@ -1061,7 +1208,7 @@ func (vs *VolumeService) executeCleanupTestFailover(ctx context.Context, operati
 		log.WithField("test_vm_id", req.TestVMID).Info("🔍 Validating test VM power state")
 
 		// Validate VM is powered off (required for safe volume detachment)
-		err := cloudStackClient.ValidateVMPoweredOff(ctx, req.TestVMID)
+		err := provider.ValidateVMPoweredOff(ctx, req.TestVMID)
 		if err != nil {
 			log.WithFields(log.Fields{
 				"test_vm_id": req.TestVMID,
@@ -1069,7 +1216,7 @@ func (vs *VolumeService) executeCleanupTestFailover(ctx context.Context, operati
 			}).Warn("Test VM not powered off, attempting automatic power off")
 
 			// Try to power off the VM
-			if powerErr := cloudStackClient.PowerOffVM(ctx, req.TestVMID); powerErr != nil {
+			if powerErr := provider.PowerOffVM(ctx, req.TestVMID); powerErr != nil {
 				vs.completeOperationWithError(ctx, operation, fmt.Errorf("failed to power off test VM: %w", powerErr))
 				return
 			}
@@ -1078,7 +1225,7 @@ func (vs *VolumeService) executeCleanupTestFailover(ctx context.Context, operati
 			time.Sleep(3 * time.Second)
 
 			// Re-validate powered off state
-			if validateErr := cloudStackClient.ValidateVMPoweredOff(ctx, req.TestVMID); validateErr != nil {
+			if validateErr := provider.ValidateVMPoweredOff(ctx, req.TestVMID); validateErr != nil {
 				vs.completeOperationWithError(ctx, operation, fmt.Errorf("test VM still not powered off after forced shutdown: %w", validateErr))
 				return
 			}
@@ -1093,7 +1240,7 @@ func (vs *VolumeService) executeCleanupTestFailover(ctx context.Context, operati
 		"test_vm_id": req.TestVMID,
 	}).Info("🔗 Detaching volume from test VM")
 
-	err = cloudStackClient.DetachVolume(ctx, req.VolumeID)
+	err = provider.DetachVolume(ctx, req.VolumeID)
 	if err != nil {
 		vs.completeOperationWithError(ctx, operation, fmt.Errorf("failed to detach volume from test VM: %w", err))
 		return
@@ -1110,24 +1257,29 @@ func (vs *VolumeService) executeCleanupTestFailover(ctx context.Context, operati
 		"oma_vm_id": req.SHAVMID,
 	}).Info("🔗 Reattaching volume to SHA")
 
-	err = cloudStackClient.AttachVolume(ctx, req.VolumeID, req.SHAVMID)
+	err = provider.AttachVolume(ctx, req.VolumeID, req.SHAVMID)
 	if err != nil {
 		vs.completeOperationWithError(ctx, operation, fmt.Errorf("failed to reattach volume to SHA: %w", err))
 		return
 	}
+	vs.publishOperationEvent(ctx, operation, models.EventProviderSubmitted, map[string]interface{}{"provider": provider.Type()})
 
 	// Wait for device to appear and resolve via by-id path
 	var devicePath string
 	var deviceSize int64
 	
 	// 🆕 NEW: Use by-id resolution for SHA reattachment
-	byIDPath, actualDevice, err := device.GetDeviceByVolumeID(req.VolumeID, 10*time.Second)
+	byIDPath, actualDevice, err := provider.ResolveDevice(req.VolumeID, 10*time.Second)
 	if err != nil {
 		log.WithError(err).Warn("Failed to resolve device via by-id - using placeholder")
 		devicePath = ""
 		deviceSize = 0
 	} else {
 		devicePath = byIDPath // Use stable by-id path
+		vs.publishOperationEvent(ctx, operation, models.EventDeviceDetected, map[string]interface{}{
+			"device_path":   byIDPath,
+			"actual_device": actualDevice,
+		})
 		if size, err := device.GetDeviceSize(actualDevice); err == nil {
 			deviceSize = size
 		}
@@ -1148,7 +1300,7 @@ func (vs *VolumeService) executeCleanupTestFailover(ctx context.Context, operati
 	if req.DeleteVM {
 		log.WithField("test_vm_id", req.TestVMID).Info("🗑️  Deleting test VM")
 
-		err = cloudStackClient.DeleteVM(ctx, req.TestVMID)
+		err = provider.DeleteVM(ctx, req.TestVMID)
 		if err != nil {
 			// Log warning but don't fail the cleanup operation
 			log.WithFields(log.Fields{
@@ -1181,6 +1333,7 @@ func (vs *VolumeService) executeCleanupTestFailover(ctx context.Context, operati
 			"error":        err,
 		}).Error("Failed to update operation after successful cleanup")
 	}
+	vs.publishOperationEvent(ctx, operation, models.EventOperationCompleted, operation.Response)
 
 	// Create/update device mapping record for SHA attachment
 	if devicePath != "" {
@@ -1363,11 +1516,12 @@ func (vs *VolumeService) ValidateNBDExports(ctx context.Context) error {
 
 // NBD Export Lifecycle Integration (Private Helper Methods)
 
-// createNBDExportForVolume creates an NBD export automatically during volume attachment
-func (vs *VolumeService) createNBDExportForVolume(ctx context.Context, volumeID, vmID, devicePath string) {
+// createNBDExportForVolume creates an NBD export automatically during volume attachment;
+// it returns nil when no export was created
+func (vs *VolumeService) createNBDExportForVolume(ctx context.Context, volumeID, vmID, devicePath string) *nbd.ExportInfo {
 	if vs.nbdExportManager == nil {
 		log.Warn("NBD export manager not available - skipping automatic export creation")
-		return
+		return nil
 	}
 
 	log.WithFields(log.Fields{
@@ -1421,7 +1575,7 @@ func (vs *VolumeService) createNBDExportForVolume(ctx context.Context, volumeID,
 			"vm_id":       vmID,
 			"device_path": devicePath,
 		}).Error("Failed to create NBD export during volume attachment")
-		return
+		return nil
 	}
 
 	log.WithFields(log.Fields{
@@ -1431,6 +1585,8 @@ func (vs *VolumeService) createNBDExportForVolume(ctx context.Context, volumeID,
 		"device_path": devicePath,
 		"port":        exportInfo.Port,
 	}).Info("✅ NBD export created automatically during volume attachment")
+
+	return exportInfo
 }
 
 // deleteNBDExportForVolume deletes an NBD export automatically during volume detachment